@@ -258,6 +258,30 @@ TEXT	foo(SB), DUPOK|NOSPLIT, $-8
 	VUMIN	V3.H8, V2.H8, V1.H8             // 416c636e
 	VUMIN	V3.S2, V2.S2, V1.S2             // 416ca32e
 	VUMIN	V3.S4, V2.S4, V1.S4             // 416ca36e
+	VSMAX	V3.B16, V2.B16, V1.B16          // 4164234e
+	VSMAX	V3.H8, V2.H8, V1.H8             // 4164634e
+	VSMAX	V3.S4, V2.S4, V1.S4             // 4164a34e
+	VSMIN	V3.B16, V2.B16, V1.B16          // 416c234e
+	VSMIN	V3.S2, V2.S2, V1.S2             // 416ca30e
+	VCMGT	V3.B8, V2.B8, V1.B8             // 4134230e
+	VCMGT	V3.S4, V2.S4, V1.S4             // 4134a34e
+	VCMGT	V3.D2, V2.D2, V1.D2             // 4134e34e
+	VMUL	V3.H8, V2.H8, V1.H8             // 419c634e
+	VMUL	V3.S4, V2.S4, V1.S4             // 419ca34e
+	VBIC	V3.B8, V2.B8, V1.B8             // 411c630e
+	VBIC	V3.B16, V2.B16, V1.B16          // 411c634e
+	VFADD	V3.S2, V2.S2, V1.S2             // 41d4230e
+	VFADD	V3.S4, V2.S4, V1.S4             // 41d4234e
+	VFADD	V3.D2, V2.D2, V1.D2             // 41d4634e
+	VFSUB	V3.S4, V2.S4, V1.S4             // 41d4a34e
+	VFSUB	V3.D2, V2.D2, V1.D2             // 41d4e34e
+	VFMUL	V3.S4, V2.S4, V1.S4             // 41dc236e
+	VFMUL	V3.D2, V2.D2, V1.D2             // 41dc636e
+	VFDIV	V3.S4, V2.S4, V1.S4             // 41fc236e
+	VFDIV	V3.D2, V2.D2, V1.D2             // 41fc636e
+	VFSQRT	V2.S2, V1.S2                    // 41f8a12e
+	VFSQRT	V2.S4, V1.S4                    // 41f8a16e
+	VFSQRT	V2.D2, V1.D2                    // 41f8e16e
 	FCCMPS	LT, F1, F2, $1	                // 41b4211e
 	FMADDS	F1, F3, F2, F4                  // 440c011f
 	FMADDD	F4, F5, F4, F4                  // 8414441f
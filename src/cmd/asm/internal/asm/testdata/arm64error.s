@@ -377,6 +377,14 @@ TEXT errors(SB),$0
 	VUMIN	V1.D2, V2.D2, V3.D2                              // ERROR "invalid arrangement"
 	VUMAX	V1.B8, V2.B8, V3.B16                             // ERROR "operand mismatch"
 	VUMIN	V1.H4, V2.S4, V3.H4                              // ERROR "operand mismatch"
+	VSMAX	V1.D2, V2.D2, V3.D2                              // ERROR "invalid arrangement"
+	VMUL	V1.D2, V2.D2, V3.D2                              // ERROR "invalid arrangement"
+	VBIC	V1.S4, V2.S4, V3.S4                              // ERROR "invalid arrangement"
+	VFADD	V1.B16, V2.B16, V3.B16                           // ERROR "invalid arrangement"
+	VFDIV	V1.H8, V2.H8, V3.H8                              // ERROR "invalid arrangement"
+	VFSQRT	V1.B16, V2.B16                                   // ERROR "invalid arrangement"
+	VREV32	V1.D2, V2.D2                                     // ERROR "invalid arrangement"
+	VCMGT	V1.S4, V2.S4, V3.D2                              // ERROR "operand mismatch"
 	VSLI	$64, V7.D2, V8.D2                                // ERROR "shift out of range"
 	VUSRA	$0, V7.D2, V8.D2                                 // ERROR "shift out of range"
 	CASPD	(R3, R4), (R2), (R8, R9)                         // ERROR "source register pair must start from even register"
@@ -0,0 +1,401 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"cmd/internal/objfile"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// The kinds of bytes in a binary.
+const (
+	kindText    = "text"
+	kindRodata  = "rodata"
+	kindData    = "data"
+	kindTypes   = "types"
+	kindPclntab = "pclntab"
+	kindDWARF   = "dwarf"
+	kindOther   = "other"
+)
+
+var kinds = []string{kindText, kindRodata, kindData, kindTypes, kindPclntab, kindDWARF, kindOther}
+
+func validKind(kind string) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// A Binary is the attribution of the bytes of a binary to symbols.
+type Binary struct {
+	File    string    `json:"file"`
+	Size    int64     `json:"size"` // size of the file
+	Symbols []*Symbol `json:"symbols"`
+}
+
+// A Symbol is a number of bytes of a binary attributed to a symbol.
+// A symbol such as a function may have bytes of several kinds, each
+// described by a separate Symbol.
+type Symbol struct {
+	Name    string `json:"name"`
+	Package string `json:"package,omitempty"`
+	Kind    string `json:"kind"`
+	Section string `json:"section,omitempty"`
+	Size    int64  `json:"size"`
+
+	addr uint64 // address, or 0 if the bytes are not loaded
+}
+
+const unattributed = "(unattributed)"
+
+// Analyze attributes the bytes of the binary file.
+func Analyze(file string) (*Binary, error) {
+	fi, err := os.Stat(file)
+	if err != nil {
+		return nil, err
+	}
+	f, err := objfile.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	sects, err := f.Sections()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	// The symbol table is missing from stripped binaries, in
+	// which case the text is attributed using the pclntab.
+	syms, _ := f.Symbols()
+
+	a := &analysis{sects: sects}
+	a.addPclntab(f, syms)
+	a.addDWARF(f, syms)
+	a.addSymbols(syms)
+	a.addUnattributed(fi.Size())
+	return &Binary{File: file, Size: fi.Size(), Symbols: a.syms}, nil
+}
+
+// An analysis holds the state of the attribution of a binary.
+type analysis struct {
+	sects []objfile.Section
+	syms  []*Symbol
+
+	// fine are the extents of the tables that are attributed at a
+	// finer granularity than the symbol table: the pclntab, the type
+	// descriptors and, for binaries with no symbol table, the
+	// functions. Symbols in the symbol table that overlap any of them
+	// are not attributed. fineEnd[i] is the largest end of fine[:i+1]
+	// once fine is sorted.
+	fine    []extent
+	fineEnd []uint64
+}
+
+type extent struct {
+	start, end uint64
+}
+
+// add adds the symbol s, assigning it to the loaded section that
+// contains its address if s.Section is not set.
+func (a *analysis) add(s *Symbol) {
+	if s.Size <= 0 {
+		return
+	}
+	if s.Section == "" && s.addr != 0 {
+		if sect := a.section(s.addr); sect != nil {
+			s.Section = sect.Name
+		}
+	}
+	a.syms = append(a.syms, s)
+}
+
+// section returns the loaded section that contains addr, or nil.
+func (a *analysis) section(addr uint64) *objfile.Section {
+	if i := a.sectionIndex(addr); i >= 0 {
+		return &a.sects[i]
+	}
+	return nil
+}
+
+// sectionIndex returns the index of the loaded section that contains
+// addr, or -1.
+func (a *analysis) sectionIndex(addr uint64) int {
+	for i, s := range a.sects {
+		if s.Addr != 0 && s.Addr <= addr && addr < s.Addr+s.Size {
+			return i
+		}
+	}
+	return -1
+}
+
+// overlapsFine reports whether the range [start, end) overlaps an
+// extent in a.fine.
+func (a *analysis) overlapsFine(start, end uint64) bool {
+	i := sort.Search(len(a.fine), func(i int) bool { return a.fine[i].start >= start })
+	return i < len(a.fine) && a.fine[i].start < end || i > 0 && a.fineEnd[i-1] > start
+}
+
+// addSymbols adds the symbols in the symbol table.
+func (a *analysis) addSymbols(syms []objfile.Sym) {
+	sort.Slice(a.fine, func(i, j int) bool { return a.fine[i].start < a.fine[j].start })
+	a.fineEnd = make([]uint64, len(a.fine))
+	for i, e := range a.fine {
+		a.fineEnd[i] = e.end
+		if i > 0 && a.fineEnd[i-1] > e.end {
+			a.fineEnd[i] = a.fineEnd[i-1]
+		}
+	}
+	// Visit the symbols in address order, so that symbols that
+	// overlap an earlier one, such as aliases, are not counted twice.
+	syms = append([]objfile.Sym(nil), syms...)
+	sort.SliceStable(syms, func(i, j int) bool { return syms[i].Addr < syms[j].Addr })
+	var prevEnd uint64
+	for _, s := range syms {
+		var kind string
+		switch s.Code {
+		case 'T', 't':
+			kind = kindText
+		case 'R', 'r':
+			kind = kindRodata
+			if strings.HasPrefix(s.Name, "go:itab.") || strings.HasPrefix(s.Name, "type:") {
+				kind = kindTypes
+			}
+		case 'D', 'd':
+			kind = kindData
+		default:
+			// Undefined and bss symbols take no space in the file.
+			continue
+		}
+		sect := a.section(s.Addr)
+		if sect == nil || s.Addr-sect.Addr >= sect.FileSize {
+			// The symbol is not in the file, such as a symbol in
+			// the zero-filled tail of a PE data section.
+			continue
+		}
+		// Where the symbol table does not record sizes, they
+		// extend to the next symbol, which may be in a later
+		// section.
+		start, end := s.Addr, s.Addr+uint64(s.Size)
+		if start < prevEnd {
+			start = prevEnd
+		}
+		if sectEnd := sect.Addr + sect.Size; end > sectEnd {
+			end = sectEnd
+		}
+		if sectEnd := sect.Addr + sect.FileSize; end > sectEnd {
+			end = sectEnd
+		}
+		if end <= start || a.overlapsFine(start, end) {
+			continue
+		}
+		prevEnd = end
+		a.add(&Symbol{Name: s.Name, Package: symPackage(s.Name), Kind: kind, Size: int64(end - start), addr: start})
+	}
+}
+
+// addUnattributed adds a symbol for the bytes of each section that are
+// not attributed to any symbol, and one for the bytes of the file that
+// are not in any section.
+func (a *analysis) addUnattributed(fileSize int64) {
+	// Mach-O binaries may have several sections of the same name,
+	// so symbols are assigned to sections by address where possible.
+	used := make([]int64, len(a.sects))
+	kindCount := make([]map[string]int, len(a.sects))
+	for _, s := range a.syms {
+		i := -1
+		if s.addr != 0 {
+			i = a.sectionIndex(s.addr)
+		} else {
+			for j := range a.sects {
+				if a.sects[j].Name == s.Section {
+					i = j
+					break
+				}
+			}
+		}
+		if i < 0 {
+			continue
+		}
+		used[i] += s.Size
+		if kindCount[i] == nil {
+			kindCount[i] = make(map[string]int)
+		}
+		kindCount[i][s.Kind]++
+	}
+	rest := fileSize
+	for i, sect := range a.sects {
+		if sect.FileSize == 0 {
+			continue
+		}
+		rest -= int64(sect.FileSize)
+		n := int64(sect.FileSize) - used[i]
+		if n <= 0 {
+			continue
+		}
+		// The unattributed bytes are of the kind of most of the
+		// symbols in the section.
+		kind := sectionKind(sect.Name)
+		if kind == "" {
+			kind = kindOther
+			max := 0
+			for _, k := range kinds {
+				if c := kindCount[i][k]; c > max {
+					kind, max = k, c
+				}
+			}
+		}
+		a.add(&Symbol{Name: unattributed, Kind: kind, Section: sect.Name, Size: n})
+	}
+	a.add(&Symbol{Name: "(headers)", Kind: kindOther, Size: rest})
+}
+
+// sectionKind returns the kind of the bytes of the section with the
+// given name if it is known from the name alone, or "".
+func sectionKind(name string) string {
+	switch {
+	case dwarfSection(name) != "":
+		return kindDWARF
+	case strings.HasSuffix(name, "gopclntab"):
+		return kindPclntab
+	case name == ".rodata" || name == "__rodata" || name == ".rdata":
+		// The type descriptors in these sections are attributed
+		// separately; the rest are constant data such as strings.
+		return kindRodata
+	case name == ".symtab" || name == ".strtab" || name == ".shstrtab" ||
+		name == ".gosymtab" || name == "__gosymtab" || name == ".note.go.buildid":
+		return kindOther
+	}
+	return ""
+}
+
+// dwarfSection returns the name of the DWARF section with the given
+// section name, without its prefix, such as "info" for ".debug_info",
+// or "" if the section is not a DWARF section.
+func dwarfSection(name string) string {
+	for _, prefix := range []string{".debug_", ".zdebug_", "__debug_", "__zdebug_"} {
+		if strings.HasPrefix(name, prefix) {
+			return name[len(prefix):]
+		}
+	}
+	return ""
+}
+
+// filterKind returns the symbols in syms of the given kind.
+func filterKind(syms []*Symbol, kind string) []*Symbol {
+	var out []*Symbol
+	for _, s := range syms {
+		if s.Kind == kind {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// symPackage returns the import path of the package of the symbol
+// name, or "" if the symbol does not belong to a Go package.
+func symPackage(name string) string {
+	switch {
+	case strings.HasPrefix(name, "type:"):
+		return typePackage(name[len("type:"):])
+	case strings.HasPrefix(name, "go:itab."):
+		// go:itab.T,I is attributed to the package of T.
+		typ, _, _ := strings.Cut(name[len("go:itab."):], ",")
+		return typePackage(typ)
+	case strings.HasPrefix(name, "go:"):
+		// Compiler-generated tables.
+		return ""
+	}
+	name = stripInstantiation(name)
+	pathend := strings.LastIndex(name, "/")
+	if pathend < 0 {
+		pathend = 0
+	}
+	if i := strings.Index(name[pathend:], "."); i > 0 {
+		return name[:pathend+i]
+	}
+	return ""
+}
+
+// typePackage returns the import path of the package that defines the
+// Go type with the given name, or of its element type if it is an
+// unnamed pointer, slice, array, channel or map type. It returns ""
+// for other unnamed types and for predeclared types.
+func typePackage(name string) string {
+	for {
+		switch {
+		case strings.HasPrefix(name, "*"):
+			name = name[1:]
+		case strings.HasPrefix(name, "[]"):
+			name = name[2:]
+		case strings.HasPrefix(name, "["):
+			_, elem, ok := strings.Cut(name, "]")
+			if !ok {
+				return ""
+			}
+			name = elem
+		case strings.HasPrefix(name, "chan "):
+			name = name[len("chan "):]
+		case strings.HasPrefix(name, "<-chan "):
+			name = name[len("<-chan "):]
+		case strings.HasPrefix(name, "chan<- "):
+			name = name[len("chan<- "):]
+		case strings.HasPrefix(name, "map["):
+			// Attribute maps to the package of their value type.
+			depth := 0
+			i := len("map")
+			for ; i < len(name); i++ {
+				if name[i] == '[' {
+					depth++
+				} else if name[i] == ']' {
+					if depth--; depth == 0 {
+						break
+					}
+				}
+			}
+			if i == len(name) {
+				return ""
+			}
+			name = name[i+1:]
+		case strings.HasPrefix(name, ".eq."):
+			// Equality functions.
+			name = name[len(".eq."):]
+		case strings.HasPrefix(name, ".hash."):
+			// Hash functions.
+			name = name[len(".hash."):]
+		case strings.HasPrefix(name, "func("), strings.HasPrefix(name, "struct {"),
+			strings.HasPrefix(name, "interface {"), strings.HasPrefix(name, "noalg."),
+			strings.HasPrefix(name, "."):
+			return ""
+		default:
+			return symPackage(name)
+		}
+	}
+}
+
+// stripInstantiation removes the type arguments of generic
+// instantiations, in square brackets, from the symbol name.
+func stripInstantiation(name string) string {
+	if !strings.Contains(name, "[") {
+		return name
+	}
+	var b strings.Builder
+	depth := 0
+	for _, c := range name {
+		switch {
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		case depth == 0:
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
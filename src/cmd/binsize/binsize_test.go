@@ -0,0 +1,236 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"internal/testenv"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/pprof/profile"
+)
+
+const testProg = `
+package main
+
+import "fmt"
+
+type Big struct {
+	A [64]int
+	M map[string]*Big
+}
+
+var Table = [...]string{"one", "two", "three"}
+
+//go:noinline
+func Work(b *Big) int {
+	n := 0
+	for i := range b.A {
+		n += b.A[i] * i
+	}
+	return n + len(b.M)
+}
+
+func main() {
+	b := &Big{}
+	fmt.Println(Work(b), Table, b)
+}
+`
+
+// buildProg builds testProg with the given linker flags, and returns
+// the path of the binary.
+func buildProg(t *testing.T, ldflags string) string {
+	t.Helper()
+	testenv.MustHaveGoBuild(t)
+	dir := t.TempDir()
+	src := filepath.Join(dir, "main.go")
+	if err := os.WriteFile(src, []byte(testProg), 0666); err != nil {
+		t.Fatal(err)
+	}
+	exe := filepath.Join(dir, "main.exe")
+	cmd := testenv.Command(t, testenv.GoToolPath(t), "build", "-ldflags="+ldflags, "-o", exe, src)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("build failed: %v\n%s", err, out)
+	}
+	return exe
+}
+
+// sizes returns the sizes of the symbols of b by package and kind, and
+// the total size.
+func sizes(b *Binary) (map[[2]string]int64, int64) {
+	m := make(map[[2]string]int64)
+	var total int64
+	for _, s := range b.Symbols {
+		m[[2]string{s.Package, s.Kind}] += s.Size
+		total += s.Size
+	}
+	return m, total
+}
+
+func TestAnalyze(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		ldflags string
+		kinds   []string // kinds of bytes attributed to package main
+	}{
+		{"dwarf", "", []string{kindText, kindTypes, kindPclntab, kindDWARF}},
+		{"stripped", "-s", []string{kindText, kindPclntab}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			exe := buildProg(t, test.ldflags)
+			b, err := Analyze(exe)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m, total := sizes(b)
+			if total != b.Size {
+				t.Errorf("attributed %d bytes, want the file size %d", total, b.Size)
+			}
+			for _, k := range test.kinds {
+				if m[[2]string{"main", k}] == 0 {
+					t.Errorf("no %s bytes attributed to package main", k)
+				}
+				if m[[2]string{"runtime", k}] <= m[[2]string{"main", k}] {
+					t.Errorf("%s bytes of runtime = %d, want more than %d of main", k, m[[2]string{"runtime", k}], m[[2]string{"main", k}])
+				}
+			}
+			if test.ldflags == "" {
+				found := false
+				for _, s := range b.Symbols {
+					if s.Name == "type:main.Big" && s.Kind == kindTypes && s.Package == "main" {
+						found = true
+					}
+				}
+				if !found {
+					t.Errorf("no type descriptor attributed to type:main.Big")
+				}
+
+				// Almost all of the DWARF information describes
+				// some function or package.
+				var dwarf, rest int64
+				for _, s := range b.Symbols {
+					if s.Kind == kindDWARF {
+						dwarf += s.Size
+						if s.Name == unattributed {
+							rest += s.Size
+						}
+					}
+				}
+				if rest > dwarf/10 {
+					t.Errorf("%d of %d DWARF bytes unattributed, want at most 10%%", rest, dwarf)
+				}
+			}
+		})
+	}
+}
+
+func TestOutput(t *testing.T) {
+	exe := buildProg(t, "")
+	b, err := Analyze(exe)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	writeSizes(&buf, b, "package", 5)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 7 {
+		t.Fatalf("got %d lines, want a header, 5 packages and a total:\n%s", len(lines), buf.String())
+	}
+	if f := strings.Fields(lines[6]); f[len(f)-1] != "total" || f[1] != "100.0%" {
+		t.Errorf("bad total line %q", lines[6])
+	}
+
+	buf.Reset()
+	if err := writeJSON(&buf, b); err != nil {
+		t.Fatal(err)
+	}
+	var b2 Binary
+	if err := json.Unmarshal(buf.Bytes(), &b2); err != nil {
+		t.Fatal(err)
+	}
+	if _, total := sizes(&b2); total != b.Size {
+		t.Errorf("JSON symbols total %d bytes, want %d", total, b.Size)
+	}
+
+	buf.Reset()
+	if err := writeProfile(&buf, b); err != nil {
+		t.Fatal(err)
+	}
+	p, err := profile.Parse(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var total int64
+	for _, s := range p.Sample {
+		total += s.Value[0]
+	}
+	if total != b.Size {
+		t.Errorf("profile samples total %d bytes, want %d", total, b.Size)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	old := &Binary{Size: 300, Symbols: []*Symbol{
+		{Name: "a.F", Package: "a", Kind: kindText, Size: 100},
+		{Name: "a.G", Package: "a", Kind: kindText, Size: 150},
+		{Name: "b.H", Package: "b", Kind: kindText, Size: 50},
+	}}
+	new := &Binary{Size: 320, Symbols: []*Symbol{
+		{Name: "a.F", Package: "a", Kind: kindText, Size: 100},
+		{Name: "a.G", Package: "a", Kind: kindText, Size: 120},
+		{Name: "c.I", Package: "c", Kind: kindText, Size: 100},
+	}}
+	d := diff(old, new)
+	var got []string
+	for _, sd := range d.Symbols {
+		got = append(got, sd.Name)
+	}
+	if want := "a.G b.H c.I"; strings.Join(got, " ") != want {
+		t.Errorf("changed symbols = %q, want %q", got, want)
+	}
+
+	var buf bytes.Buffer
+	writeDiff(&buf, old, new, "package", 0)
+	want := `
+  old  new  delta        %  package
+    0  100   +100           c
+   50    0    -50  -100.0%  b
+  250  220    -30   -12.0%  a
+  300  320    +20    +6.7%  total
+`[1:]
+	if buf.String() != want {
+		t.Errorf("writeDiff printed:\n%q\nwant:\n%q", buf.String(), want)
+	}
+}
+
+func TestSymPackage(t *testing.T) {
+	for _, test := range []struct {
+		sym, pkg string
+	}{
+		{"main.main", "main"},
+		{"runtime.(*mheap).alloc", "runtime"},
+		{"internal/abi.Kind.String", "internal/abi"},
+		{"example.com/a.b/c.F.func1", "example.com/a.b/c"},
+		{"slices.Sort[go.shape.[]example.com/x.T,go.shape.int]", "slices"},
+		{"type:*example.com/x.T", "example.com/x"},
+		{"type:map[string][]*encoding/json.field", "encoding/json"},
+		{"type:[4]chan<- os.Signal", "os"},
+		{"type:func(int) error", ""},
+		{"type:.eq.net/http.Header", "net/http"},
+		{"type:int", ""},
+		{"go:itab.*os.File,io.Reader", "os"},
+		{"go:string.*", ""},
+		{"_cgo_init", ""},
+	} {
+		if got := symPackage(test.sym); got != test.pkg {
+			t.Errorf("symPackage(%q) = %q, want %q", test.sym, got, test.pkg)
+		}
+	}
+}
@@ -0,0 +1,380 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"cmd/internal/objfile"
+	"cmd/internal/sys"
+	"debug/dwarf"
+	"sort"
+)
+
+// attrGoRuntimeType is the DW_AT_go_runtime_type attribute, which the
+// Go linker adds to the DWARF entries of types. Its value is the offset
+// of the runtime type descriptor of the type from the start of the
+// section that holds the type descriptors.
+const attrGoRuntimeType dwarf.Attr = 0x2904
+
+const langGo = 0x16 // DW_LANG_Go
+
+// An entry is a compilation unit or a top-level entry in one, in the
+// order in which they appear in .debug_info.
+type entry struct {
+	off  dwarf.Offset
+	pkg  string // package of the compilation unit
+	name string // function or variable name, or "" for the unit itself
+}
+
+// A ref is a reference from an entry, or from one of its children, to
+// an offset in a DWARF section other than .debug_info.
+type ref struct {
+	off int64
+	e   *entry
+}
+
+// addDWARF attributes the DWARF information to the packages and
+// functions it describes, and the type descriptors to their types,
+// whose addresses it records.
+func (a *analysis) addDWARF(f *objfile.File, syms []objfile.Sym) {
+	d, err := f.DWARF()
+	if err != nil {
+		return
+	}
+
+	var entries []*entry
+	lines := make(map[int64]string) // package of each line table, by offset
+	types := make(map[uint64]string)
+	funcs := make(map[uint64]*entry) // functions by address
+	var locs, ranges []ref           // references to location and range lists
+	addRefs := func(e *dwarf.Entry, ent *entry) {
+		for _, f := range e.Field {
+			off, ok := f.Val.(int64)
+			switch {
+			case !ok:
+			case f.Attr == dwarf.AttrLocation && f.Class == dwarf.ClassLocListPtr:
+				locs = append(locs, ref{off, ent})
+			case f.Attr == dwarf.AttrRanges && f.Class == dwarf.ClassRangeListPtr:
+				ranges = append(ranges, ref{off, ent})
+			}
+		}
+	}
+	var pkg string
+	r := d.Reader()
+walk:
+	for {
+		e, err := r.Next()
+		if err != nil || e == nil {
+			break
+		}
+		ent := &entry{off: e.Offset, pkg: pkg}
+		switch e.Tag {
+		case dwarf.TagCompileUnit:
+			pkg = ""
+			if lang, _ := e.Val(dwarf.AttrLanguage).(int64); lang == langGo {
+				pkg, _ = e.Val(dwarf.AttrName).(string)
+			}
+			if off, ok := e.Val(dwarf.AttrStmtList).(int64); ok {
+				lines[off] = pkg
+			}
+			ent.pkg = pkg
+			entries = append(entries, ent)
+			addRefs(e, ent)
+			continue
+		case dwarf.TagSubprogram, dwarf.TagVariable:
+			name, _ := e.Val(dwarf.AttrName).(string)
+			if pkg != "" && symPackage(name) != "" {
+				ent.name = name
+			}
+			if pc, ok := e.Val(dwarf.AttrLowpc).(uint64); ok && e.Tag == dwarf.TagSubprogram {
+				funcs[pc] = ent
+			}
+			entries = append(entries, ent)
+		case 0:
+			// The end of the children of the unit, which has no
+			// offset of its own.
+			continue
+		default:
+			if addr, ok := e.Val(attrGoRuntimeType).(uint64); ok && addr != 0 {
+				if name, ok := e.Val(dwarf.AttrName).(string); ok {
+					types[addr] = name
+				}
+			}
+			entries = append(entries, ent)
+		}
+		addRefs(e, ent)
+		if !e.Children {
+			continue
+		}
+		if e.Tag != dwarf.TagSubprogram {
+			r.SkipChildren()
+			continue
+		}
+		// The location lists of the parameters and variables of a
+		// function and the range lists of its blocks and inlined
+		// calls belong to the function.
+		for depth := 1; depth > 0; {
+			c, err := r.Next()
+			if err != nil || c == nil {
+				break walk
+			}
+			if c.Tag == 0 {
+				depth--
+				continue
+			}
+			addRefs(c, ent)
+			if c.Children {
+				depth++
+			}
+		}
+	}
+
+	if info := a.dwarfSection("info"); info != nil {
+		scale := float64(info.FileSize) / float64(info.Size)
+		rest := make(map[string]int64) // bytes of each package not attributed to a symbol
+		for i, e := range entries {
+			end := dwarf.Offset(info.Size)
+			if i+1 < len(entries) {
+				end = entries[i+1].off
+			}
+			if end <= e.off {
+				continue
+			}
+			a.addEntry(info, e, int64(float64(end-e.off)*scale), rest)
+		}
+		a.addRest(info, rest)
+	}
+	a.addRefs(a.dwarfSection("loclists", "loc"), locs)
+	a.addRefs(a.dwarfSection("rnglists", "ranges"), ranges)
+	a.addFrames(f, funcs)
+
+	if line := a.dwarfSection("line"); line != nil {
+		scale := float64(line.FileSize) / float64(line.Size)
+		offs := make([]int64, 0, len(lines))
+		for off := range lines {
+			offs = append(offs, off)
+		}
+		sort.Slice(offs, func(i, j int) bool { return offs[i] < offs[j] })
+		for i, off := range offs {
+			end := int64(line.Size)
+			if i+1 < len(offs) {
+				end = offs[i+1]
+			}
+			if pkg := lines[off]; pkg != "" {
+				a.add(&Symbol{Name: "(line table)", Package: pkg, Kind: kindDWARF, Section: line.Name, Size: int64(float64(end-off) * scale)})
+			}
+		}
+	}
+
+	if base := a.typesBase(syms); base != 0 {
+		a.addTypes(types, base, syms)
+	}
+}
+
+// addEntry attributes size bytes of the section sect to the function or
+// variable of e, or, if e has none, to its package in rest.
+func (a *analysis) addEntry(sect *objfile.Section, e *entry, size int64, rest map[string]int64) {
+	if e.name == "" {
+		rest[e.pkg] += size
+		return
+	}
+	a.add(&Symbol{Name: e.name, Package: symPackage(e.name), Kind: kindDWARF, Section: sect.Name, Size: size})
+}
+
+// addRest attributes the bytes of sect in rest to their packages.
+func (a *analysis) addRest(sect *objfile.Section, rest map[string]int64) {
+	for _, pkg := range sortedKeys(rest) {
+		if pkg != "" {
+			a.add(&Symbol{Name: "(debug info)", Package: pkg, Kind: kindDWARF, Section: sect.Name, Size: rest[pkg]})
+		}
+	}
+}
+
+// addRefs attributes the bytes of the section sect, which holds lists
+// such as location lists, to the entries that refer to them. Each list
+// extends to the next offset referred to, or to the end of the section.
+func (a *analysis) addRefs(sect *objfile.Section, refs []ref) {
+	if sect == nil || len(refs) == 0 {
+		return
+	}
+	sort.SliceStable(refs, func(i, j int) bool { return refs[i].off < refs[j].off })
+	scale := float64(sect.FileSize) / float64(sect.Size)
+	rest := make(map[string]int64)
+	for i, r := range refs {
+		if i > 0 && refs[i-1].off == r.off {
+			continue // a list referred to twice
+		}
+		end := int64(sect.Size)
+		for j := i + 1; j < len(refs); j++ {
+			if refs[j].off > r.off {
+				end = refs[j].off
+				break
+			}
+		}
+		if end > r.off {
+			a.addEntry(sect, r.e, int64(float64(end-r.off)*scale), rest)
+		}
+	}
+	a.addRest(sect, rest)
+}
+
+// addFrames attributes the frame description entries of .debug_frame
+// to the functions at the addresses they describe, given in funcs.
+// The common information entries are left unattributed.
+func (a *analysis) addFrames(f *objfile.File, funcs map[uint64]*entry) {
+	sect := a.dwarfSection("frame")
+	if sect == nil {
+		return
+	}
+	var arch *sys.Arch
+	for _, ar := range sys.Archs {
+		if ar.Name == f.GOARCH() {
+			arch = ar
+		}
+	}
+	data, err := f.SectionData(sect.Name)
+	if arch == nil || err != nil {
+		return
+	}
+	order, ptrSize := arch.ByteOrder, arch.PtrSize
+	addr := func(b []byte) uint64 {
+		if ptrSize == 4 {
+			return uint64(order.Uint32(b))
+		}
+		return order.Uint64(b)
+	}
+	scale := float64(sect.FileSize) / float64(sect.Size)
+	rest := make(map[string]int64)
+	for len(data) >= 8 {
+		// Each entry starts with its length and, in a frame
+		// description entry, the offset of its common information
+		// entry, which is all ones in a common information entry.
+		n := uint64(order.Uint32(data))
+		if n == 0 || n == 0xffffffff || n > uint64(len(data)-4) {
+			// The end of the section, padding, or 64-bit DWARF,
+			// which the Go linker only writes on AIX.
+			break
+		}
+		size := int(4 + n)
+		if cie := order.Uint32(data[4:]); cie != 0xffffffff && size >= 8+ptrSize {
+			if e := funcs[addr(data[8:])]; e != nil {
+				a.addEntry(sect, e, int64(float64(size)*scale), rest)
+			}
+		}
+		data = data[size:]
+	}
+	a.addRest(sect, rest)
+}
+
+// typesBase returns the address of the section that holds the type
+// descriptors, or 0 if it is not known.
+func (a *analysis) typesBase(syms []objfile.Sym) uint64 {
+	for _, s := range syms {
+		if s.Name == "runtime.types" {
+			if sect := a.section(s.Addr); sect != nil {
+				return sect.Addr
+			}
+			return 0
+		}
+	}
+	// Without a symbol table, guess from the section names.
+	for _, name := range []string{".data.rel.ro", ".rodata", "__rodata", ".rdata"} {
+		for _, s := range a.sects {
+			if s.Name == name && s.Addr != 0 {
+				return s.Addr
+			}
+		}
+	}
+	return 0
+}
+
+// addTypes attributes the type descriptors at the offsets from base in
+// types to the types named there. A type descriptor extends to the next type
+// descriptor or symbol, to include its uncommon type data and methods.
+func (a *analysis) addTypes(types map[uint64]string, base uint64, syms []objfile.Sym) {
+	if len(types) == 0 {
+		return
+	}
+	addrs := make([]uint64, 0, len(types))
+	for off := range types {
+		addrs = append(addrs, base+off)
+	}
+	sort.Slice(addrs, func(i, j int) bool { return addrs[i] < addrs[j] })
+
+	bounds := append([]uint64(nil), addrs...)
+	for _, s := range syms {
+		bounds = append(bounds, s.Addr)
+	}
+	for _, s := range a.sects {
+		if s.Addr != 0 {
+			bounds = append(bounds, s.Addr+s.Size)
+		}
+	}
+	sort.Slice(bounds, func(i, j int) bool { return bounds[i] < bounds[j] })
+
+	var used uint64
+	for _, addr := range addrs {
+		name := types[addr-base]
+		sect := a.section(addr)
+		if sect == nil || sect.FileSize == 0 {
+			continue
+		}
+		i := sort.Search(len(bounds), func(i int) bool { return bounds[i] > addr })
+		end := sect.Addr + sect.Size
+		if i < len(bounds) && bounds[i] < end {
+			end = bounds[i]
+		}
+		a.fine = append(a.fine, extent{addr, end})
+		a.add(&Symbol{Name: "type:" + name, Package: typePackage(name), Kind: kindTypes, Size: int64(end - addr), addr: addr})
+		used += end - addr
+	}
+
+	// The descriptors of types with no DWARF entry, such as those of
+	// the types of closures, and the data they share are unattributed.
+	// They extend from the type:* symbol to the next symbol, which
+	// marks the start of the next group of data.
+	var start uint64
+	for _, s := range syms {
+		if s.Name == "type:*" {
+			start = s.Addr
+			break
+		}
+	}
+	sect := a.section(start)
+	if sect == nil {
+		return
+	}
+	end := sect.Addr + sect.Size
+	for _, s := range syms {
+		if start < s.Addr && s.Addr < end {
+			end = s.Addr
+		}
+	}
+	if end > start+used {
+		a.fine = append(a.fine, extent{start, end})
+		a.add(&Symbol{Name: unattributed, Kind: kindTypes, Size: int64(end - start - used), addr: start})
+	}
+}
+
+// dwarfSection returns the first DWARF section with one of the given
+// names, such as "info" for .debug_info, or nil.
+func (a *analysis) dwarfSection(names ...string) *objfile.Section {
+	for _, name := range names {
+		for i := range a.sects {
+			if s := &a.sects[i]; dwarfSection(s.Name) == name && s.Size > 0 {
+				return s
+			}
+		}
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]int64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
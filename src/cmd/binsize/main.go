@@ -0,0 +1,153 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Binsize reports what the bytes of a Go executable are spent on.
+//
+// Usage:
+//
+//	go tool binsize [-by package|symbol|kind] [-kind kind] [-top n] [-json] [-pprof file] binary
+//	go tool binsize -diff [flags] old new
+//
+// Binsize attributes the bytes of the binary to the packages and
+// symbols they belong to, using the symbol table, the Go function
+// table (pclntab) and, if present, the DWARF debug information. Each
+// byte is counted as one of these kinds:
+//
+//	text     machine code
+//	rodata   read-only data, such as constants, strings and itabs
+//	data     initialized writable data
+//	types    runtime type descriptors
+//	pclntab  the Go function table used for tracebacks and stack maps
+//	dwarf    DWARF debug information
+//	other    everything else, such as file headers and symbol tables
+//
+// Type descriptors are only attributed to individual types if the
+// binary has DWARF information, which records the address of the
+// descriptor of each type. The Go function table and the DWARF
+// information, including the location and range lists and the frame
+// descriptions that the entries of functions refer to, are attributed
+// to the functions they describe, so that the total for a function
+// includes its code and its metadata, and the DWARF information that
+// does not describe a function is attributed to the package of its
+// compilation unit. Bytes that cannot
+// be attributed to any symbol are reported as "(unattributed)", for
+// each section. Sections that take no space in the file, such as
+// .bss, are not counted.
+//
+// By default, binsize prints the sizes of the largest packages, broken
+// down by kind. The -by flag selects grouping by symbol or by kind
+// instead, and the -kind flag restricts the report to one kind of
+// bytes. The -top flag sets the number of entries to print, with 0
+// meaning all of them.
+//
+// The -json flag prints every attributed symbol as JSON, ungrouped,
+// for processing by other tools.
+//
+// The -pprof flag writes a profile in the format read by pprof, in
+// which the samples are symbols, the sample values are their sizes and
+// the call stacks are the path of their package followed by their
+// kind, so that 'go tool pprof -http' shows the binary as a flame
+// graph.
+//
+// With -diff, binsize compares two binaries and reports the changes in
+// size of each entry, largest changes first. The -json and -pprof
+// flags then report the changes rather than the sizes.
+package main
+
+import (
+	"bufio"
+	"cmd/internal/objabi"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: go tool binsize [flags] binary\n")
+	fmt.Fprintf(os.Stderr, "       go tool binsize -diff [flags] old new\n\n")
+	flag.PrintDefaults()
+	os.Exit(2)
+}
+
+var (
+	byFlag    = flag.String("by", "package", "group sizes by `package`, symbol or kind")
+	kindFlag  = flag.String("kind", "", "only report bytes of `kind` text, rodata, data, types, pclntab, dwarf or other")
+	topFlag   = flag.Int("top", 20, "print the `n` largest entries, or all if 0")
+	diffFlag  = flag.Bool("diff", false, "compare two binaries")
+	jsonFlag  = flag.Bool("json", false, "print the symbols as JSON")
+	pprofFlag = flag.String("pprof", "", "write a pprof profile of the sizes to `file`")
+)
+
+func main() {
+	objabi.AddVersionFlag()
+
+	log.SetFlags(0)
+	log.SetPrefix("binsize: ")
+
+	flag.Usage = usage
+	flag.Parse()
+	if *diffFlag && flag.NArg() != 2 || !*diffFlag && flag.NArg() != 1 {
+		usage()
+	}
+	switch *byFlag {
+	case "package", "symbol", "kind":
+	default:
+		log.Fatalf("unknown -by value %q", *byFlag)
+	}
+	if *kindFlag != "" && !validKind(*kindFlag) {
+		log.Fatalf("unknown -kind value %q", *kindFlag)
+	}
+
+	var bins []*Binary
+	for _, file := range flag.Args() {
+		b, err := Analyze(file)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if *kindFlag != "" {
+			b.Symbols = filterKind(b.Symbols, *kindFlag)
+		}
+		bins = append(bins, b)
+	}
+
+	if *pprofFlag != "" {
+		f, err := os.Create(*pprofFlag)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if *diffFlag {
+			err = writeDiffProfile(f, bins[0], bins[1])
+		} else {
+			err = writeProfile(f, bins[0])
+		}
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	var err error
+	switch {
+	case *diffFlag && *jsonFlag:
+		err = writeDiffJSON(w, bins[0], bins[1])
+	case *jsonFlag:
+		err = writeJSON(w, bins[0])
+	case *pprofFlag != "":
+		// Only the profile was asked for.
+	case *diffFlag:
+		writeDiff(w, bins[0], bins[1], *byFlag, *topFlag)
+	default:
+		writeSizes(w, bins[0], *byFlag, *topFlag)
+	}
+	if ferr := w.Flush(); err == nil {
+		err = ferr
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
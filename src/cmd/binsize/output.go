@@ -0,0 +1,320 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/google/pprof/profile"
+)
+
+// A row is an entry of a report: the bytes of a package, a symbol or
+// a kind.
+type row struct {
+	name   string
+	size   int64
+	byKind map[string]int64
+}
+
+// groupKey returns the name of the row of s when grouping by by.
+func groupKey(s *Symbol, by string) string {
+	switch by {
+	case "kind":
+		return s.Kind
+	case "symbol":
+		return s.Name
+	}
+	if s.Package == "" {
+		return "(none)"
+	}
+	return s.Package
+}
+
+// group groups the symbols of b by by, and returns the rows by name.
+func group(b *Binary, by string) map[string]*row {
+	rows := make(map[string]*row)
+	for _, s := range b.Symbols {
+		key := groupKey(s, by)
+		r := rows[key]
+		if r == nil {
+			r = &row{name: key, byKind: make(map[string]int64)}
+			rows[key] = r
+		}
+		r.size += s.Size
+		r.byKind[s.Kind] += s.Size
+	}
+	return rows
+}
+
+// writeSizes prints the top entries of b, grouped by by.
+func writeSizes(w io.Writer, b *Binary, by string, top int) {
+	rows := group(b, by)
+	sorted := make([]*row, 0, len(rows))
+	var total row
+	total.name = "total"
+	total.byKind = make(map[string]int64)
+	for _, r := range rows {
+		sorted = append(sorted, r)
+		total.size += r.size
+		for k, n := range r.byKind {
+			total.byKind[k] += n
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].size != sorted[j].size {
+			return sorted[i].size > sorted[j].size
+		}
+		return sorted[i].name < sorted[j].name
+	})
+	if top > 0 && len(sorted) > top {
+		sorted = sorted[:top]
+	}
+
+	// Break down packages and symbols by the kinds of their bytes.
+	var cols []string
+	if by != "kind" {
+		for _, k := range kinds {
+			if total.byKind[k] != 0 {
+				cols = append(cols, k)
+			}
+		}
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "size\t%%\t")
+	for _, k := range cols {
+		fmt.Fprintf(tw, "%s\t", k)
+	}
+	fmt.Fprintf(tw, "  %s\n", by)
+	for _, r := range append(sorted, &total) {
+		fmt.Fprintf(tw, "%d\t%.1f%%\t", r.size, percent(r.size, b.Size))
+		for _, k := range cols {
+			fmt.Fprintf(tw, "%d\t", r.byKind[k])
+		}
+		fmt.Fprintf(tw, "  %s\n", r.name)
+	}
+	tw.Flush()
+}
+
+func percent(n, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return 100 * float64(n) / float64(total)
+}
+
+// writeDiff prints the entries of old and new, grouped by by, whose
+// sizes differ the most.
+func writeDiff(w io.Writer, old, new *Binary, by string, top int) {
+	type diffRow struct {
+		name     string
+		old, new int64
+	}
+	oldRows, newRows := group(old, by), group(new, by)
+	var rows []*diffRow
+	total := &diffRow{name: "total"}
+	for name, r := range oldRows {
+		d := &diffRow{name: name, old: r.size}
+		if n := newRows[name]; n != nil {
+			d.new = n.size
+		}
+		rows = append(rows, d)
+	}
+	for name, r := range newRows {
+		if oldRows[name] == nil {
+			rows = append(rows, &diffRow{name: name, new: r.size})
+		}
+	}
+	for _, d := range rows {
+		total.old += d.old
+		total.new += d.new
+	}
+	abs := func(n int64) int64 {
+		if n < 0 {
+			return -n
+		}
+		return n
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		di, dj := abs(rows[i].new-rows[i].old), abs(rows[j].new-rows[j].old)
+		if di != dj {
+			return di > dj
+		}
+		return rows[i].name < rows[j].name
+	})
+	for len(rows) > 0 && rows[len(rows)-1].new == rows[len(rows)-1].old {
+		rows = rows[:len(rows)-1]
+	}
+	if top > 0 && len(rows) > top {
+		rows = rows[:top]
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "old\tnew\tdelta\t%%\t  %s\n", by)
+	for _, d := range append(rows, total) {
+		var pct string
+		if d.old != 0 {
+			pct = fmt.Sprintf("%+.1f%%", percent(d.new-d.old, d.old))
+		}
+		fmt.Fprintf(tw, "%d\t%d\t%+d\t%s\t  %s\n", d.old, d.new, d.new-d.old, pct, d.name)
+	}
+	tw.Flush()
+}
+
+// writeJSON prints b as JSON.
+func writeJSON(w io.Writer, b *Binary) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(b)
+}
+
+// A symbolKey identifies a Symbol in two binaries.
+type symbolKey struct {
+	name, pkg, kind, section string
+}
+
+func keyOf(s *Symbol) symbolKey {
+	return symbolKey{s.Name, s.Package, s.Kind, s.Section}
+}
+
+// A SymbolDiff is the change in size of a Symbol between two binaries.
+type SymbolDiff struct {
+	Name    string `json:"name"`
+	Package string `json:"package,omitempty"`
+	Kind    string `json:"kind"`
+	Section string `json:"section,omitempty"`
+	Old     int64  `json:"old"`
+	New     int64  `json:"new"`
+}
+
+// A Diff is the change in size of the symbols of two binaries.
+type Diff struct {
+	Old     string        `json:"old"`
+	New     string        `json:"new"`
+	OldSize int64         `json:"oldSize"`
+	NewSize int64         `json:"newSize"`
+	Symbols []*SymbolDiff `json:"symbols"`
+}
+
+// diff returns the symbols of old and new whose sizes differ, in the
+// order of the symbols of old followed by the new symbols of new.
+func diff(old, new *Binary) *Diff {
+	d := &Diff{Old: old.File, New: new.File, OldSize: old.Size, NewSize: new.Size}
+	index := make(map[symbolKey]*SymbolDiff)
+	add := func(s *Symbol) *SymbolDiff {
+		k := keyOf(s)
+		sd := index[k]
+		if sd == nil {
+			sd = &SymbolDiff{Name: s.Name, Package: s.Package, Kind: s.Kind, Section: s.Section}
+			index[k] = sd
+			d.Symbols = append(d.Symbols, sd)
+		}
+		return sd
+	}
+	for _, s := range old.Symbols {
+		add(s).Old += s.Size
+	}
+	for _, s := range new.Symbols {
+		add(s).New += s.Size
+	}
+	changed := d.Symbols[:0]
+	for _, sd := range d.Symbols {
+		if sd.Old != sd.New {
+			changed = append(changed, sd)
+		}
+	}
+	d.Symbols = changed
+	return d
+}
+
+// writeDiffJSON prints the differences between old and new as JSON.
+func writeDiffJSON(w io.Writer, old, new *Binary) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(diff(old, new))
+}
+
+// writeProfile writes a pprof profile of the sizes of the symbols of b.
+func writeProfile(w io.Writer, b *Binary) error {
+	pb := newProfileBuilder()
+	for _, s := range b.Symbols {
+		pb.add(s, s.Size)
+	}
+	return pb.p.Write(w)
+}
+
+// writeDiffProfile writes a pprof profile of the changes in size of
+// the symbols of old and new.
+func writeDiffProfile(w io.Writer, old, new *Binary) error {
+	pb := newProfileBuilder()
+	for _, sd := range diff(old, new).Symbols {
+		s := &Symbol{Name: sd.Name, Package: sd.Package, Kind: sd.Kind, Section: sd.Section}
+		pb.add(s, sd.New-sd.Old)
+	}
+	return pb.p.Write(w)
+}
+
+// A profileBuilder builds a pprof profile in which each symbol is a
+// sample whose stack is the path of its package, then its kind, then
+// its name.
+type profileBuilder struct {
+	p    *profile.Profile
+	locs map[string]*profile.Location
+}
+
+func newProfileBuilder() *profileBuilder {
+	return &profileBuilder{
+		p: &profile.Profile{
+			SampleType: []*profile.ValueType{{Type: "size", Unit: "bytes"}},
+		},
+		locs: make(map[string]*profile.Location),
+	}
+}
+
+func (pb *profileBuilder) add(s *Symbol, size int64) {
+	if size == 0 {
+		return
+	}
+	// Stacks are listed from the leaf to the root.
+	name := s.Name
+	if s.Name == unattributed && s.Section != "" {
+		name += " " + s.Section
+	}
+	stack := []*profile.Location{pb.loc(name), pb.loc("[" + s.Kind + "]")}
+	pkg := s.Package
+	if pkg == "" {
+		pkg = "(none)"
+	}
+	for {
+		stack = append(stack, pb.loc(pkg))
+		i := strings.LastIndex(pkg, "/")
+		if i < 0 {
+			break
+		}
+		pkg = pkg[:i]
+	}
+	pb.p.Sample = append(pb.p.Sample, &profile.Sample{
+		Location: stack,
+		Value:    []int64{size},
+	})
+}
+
+// loc returns the location of the frame named name.
+func (pb *profileBuilder) loc(name string) *profile.Location {
+	l := pb.locs[name]
+	if l == nil {
+		fn := &profile.Function{ID: uint64(len(pb.p.Function) + 1), Name: name}
+		pb.p.Function = append(pb.p.Function, fn)
+		l = &profile.Location{ID: uint64(len(pb.p.Location) + 1), Line: []profile.Line{{Function: fn}}}
+		pb.p.Location = append(pb.p.Location, l)
+		pb.locs[name] = l
+	}
+	return l
+}
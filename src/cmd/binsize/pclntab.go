@@ -0,0 +1,180 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"cmd/internal/objfile"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// addPclntab attributes the pclntab to the functions it describes,
+// and, if the binary has no symbol table, the text to the functions.
+func (a *analysis) addPclntab(f *objfile.File, syms []objfile.Sym) {
+	data, err := f.PCLNTab()
+	if err != nil {
+		return
+	}
+	var addr uint64
+	for _, s := range syms {
+		if s.Name == "runtime.pclntab" {
+			addr = s.Addr
+			break
+		}
+	}
+	for _, s := range a.sects {
+		if addr == 0 && sectionKind(s.Name) == kindPclntab {
+			addr = s.Addr
+		}
+	}
+	funcs, err := parsePclntab(data)
+	if addr == 0 || err != nil {
+		// Leave the pclntab unattributed.
+		return
+	}
+	a.fine = append(a.fine, extent{addr, addr + uint64(len(data))})
+	for _, fn := range funcs {
+		a.add(&Symbol{Name: fn.name, Package: symPackage(fn.name), Kind: kindPclntab, Size: fn.size, addr: addr})
+	}
+
+	if len(syms) > 0 {
+		return
+	}
+	var text uint64
+	for _, s := range a.sects {
+		if s.Name == ".text" || s.Name == "__text" {
+			text = s.Addr
+			break
+		}
+	}
+	if text == 0 {
+		return
+	}
+	for _, fn := range funcs {
+		start, end := text+fn.entry, text+fn.end
+		a.fine = append(a.fine, extent{start, end})
+		a.add(&Symbol{Name: fn.name, Package: symPackage(fn.name), Kind: kindText, Size: int64(end - start), addr: start})
+	}
+}
+
+// A pclnFunc is a function described by the pclntab.
+type pclnFunc struct {
+	name       string
+	entry, end uint64 // offsets of the function's code from the start of the text
+	size       int64  // bytes of the pclntab that describe only this function
+}
+
+// funcSize is the size of the runtime's _func structure, which
+// describes a function in the pclntab.
+const funcSize = 11 * 4
+
+var errPclntab = errors.New("malformed pclntab")
+
+// parsePclntab parses the pclntab of Go 1.18 and later, and returns
+// the functions it describes with the number of bytes of the table
+// that describe each. These are the function's entry in the function
+// table, its _func structure, its name, and the PC-value tables of
+// the function that it does not share with a function that precedes
+// it in the table. The file tables, the names of inlined functions and
+// the other shared data are not attributed to any function.
+func parsePclntab(data []byte) (funcs []pclnFunc, err error) {
+	defer func() {
+		// Report bounds errors while reading the table as errors.
+		if e := recover(); e != nil {
+			funcs, err = nil, errPclntab
+		}
+	}()
+
+	if len(data) < 8 || data[4] != 0 || data[5] != 0 || (data[7] != 4 && data[7] != 8) {
+		return nil, errPclntab
+	}
+	const (
+		go118magic = 0xfffffff0
+		go120magic = 0xfffffff1
+	)
+	var order binary.ByteOrder
+	switch {
+	case binary.LittleEndian.Uint32(data) == go118magic, binary.LittleEndian.Uint32(data) == go120magic:
+		order = binary.LittleEndian
+	case binary.BigEndian.Uint32(data) == go118magic, binary.BigEndian.Uint32(data) == go120magic:
+		order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("unsupported pclntab version %#x", binary.LittleEndian.Uint32(data))
+	}
+	ptrSize := int(data[7])
+	word := func(i int) uint64 {
+		if ptrSize == 4 {
+			return uint64(order.Uint32(data[8+4*i:]))
+		}
+		return order.Uint64(data[8+8*i:])
+	}
+	u32 := func(b []byte, off int) uint32 {
+		return order.Uint32(b[off:])
+	}
+
+	nfunc := int(word(0))
+	funcnametab := data[word(3):]
+	pctab := data[word(6):]
+	functab := data[word(7):]
+
+	seen := make(map[uint32]bool) // offsets of PC-value tables already counted
+	for i := 0; i < nfunc; i++ {
+		fn := functab[u32(functab, 8*i+4):]
+		name := funcnametab[u32(fn, 4):]
+		if j := bytes.IndexByte(name, 0); j >= 0 {
+			name = name[:j]
+		}
+		npcdata := int(u32(fn, 28))
+		nfuncdata := int(fn[43])
+
+		size := 8 + roundUp(funcSize+4*npcdata+4*nfuncdata, ptrSize) + len(name) + 1
+		tables := []uint32{u32(fn, 16), u32(fn, 20), u32(fn, 24)} // pcsp, pcfile, pcln
+		for k := 0; k < npcdata; k++ {
+			tables = append(tables, u32(fn, funcSize+4*k))
+		}
+		for _, off := range tables {
+			if off != 0 && !seen[off] {
+				seen[off] = true
+				size += pcvalueLen(pctab[off:])
+			}
+		}
+
+		funcs = append(funcs, pclnFunc{
+			name:  string(name),
+			entry: uint64(u32(functab, 8*i)),
+			end:   uint64(u32(functab, 8*i+8)),
+			size:  int64(size),
+		})
+	}
+	return funcs, nil
+}
+
+// pcvalueLen returns the length of the PC-value table at the start of
+// p, which is a sequence of pairs of a value delta and a PC delta,
+// ended by a zero value delta.
+func pcvalueLen(p []byte) int {
+	n := 0
+	for first := true; ; first = false {
+		vdelta, k := binary.Uvarint(p[n:])
+		if k <= 0 {
+			return n
+		}
+		n += k
+		if vdelta == 0 && !first {
+			return n
+		}
+		_, k = binary.Uvarint(p[n:])
+		if k <= 0 {
+			return n
+		}
+		n += k
+	}
+}
+
+func roundUp(n, a int) int {
+	return (n + a - 1) &^ (a - 1)
+}
@@ -7,6 +7,7 @@ package amd64
 import (
 	"cmd/compile/internal/ssagen"
 	"cmd/internal/obj/x86"
+	"internal/buildcfg"
 )
 
 var leaptr = x86.ALEAQ
@@ -15,6 +16,10 @@ func Init(arch *ssagen.ArchInfo) {
 	arch.LinkArch = &x86.Linkamd64
 	arch.REGSP = x86.REGSP
 	arch.MAXWIDTH = 1 << 50
+	arch.SIMDRegSize = 16
+	if buildcfg.GOAMD64 >= 3 {
+		arch.SIMDRegSize = 32 // AVX2
+	}
 
 	arch.ZeroRange = zerorange
 	arch.Ginsnop = ginsnop
@@ -80,6 +80,8 @@ func storeByType(t *types.Type) obj.As {
 			return x86.AMOVQ
 		case 16:
 			return x86.AMOVUPS
+		case 32:
+			return x86.AVMOVDQU
 		}
 	}
 	panic(fmt.Sprintf("bad store type %v", t))
@@ -106,12 +108,37 @@ func moveByType(t *types.Type) obj.As {
 			return x86.AMOVQ
 		case 16:
 			return x86.AMOVUPS // int128s are in SSE registers
+		case 32:
+			return x86.AVMOVDQU // 256-bit vectors are in AVX registers
 		default:
 			panic(fmt.Sprintf("bad int register width %d:%v", t.Size(), t))
 		}
 	}
 }
 
+// vecReg returns the register that holds a value of type t allocated
+// to r: the Y register extending r for a 256-bit vector, r otherwise.
+func vecReg(t *types.Type, r int16) int16 {
+	if t.IsSIMD() && t.Size() == 32 {
+		return yreg(r)
+	}
+	return r
+}
+
+// yreg returns the Y register extending the X register r.
+func yreg(r int16) int16 {
+	return r - x86.REG_X0 + x86.REG_Y0
+}
+
+// zeroUpper clears the upper halves of the vector registers before a
+// call or return in a function that uses 256-bit vectors, to avoid the
+// penalty for mixing AVX and SSE instructions.
+func zeroUpper(s *ssagen.State) {
+	if s.UsesVec256 {
+		s.Prog(x86.AVZEROUPPER)
+	}
+}
+
 // opregreg emits instructions for
 //
 //	dest := dest(To) op src(From)
@@ -1010,7 +1037,7 @@ func ssaGenValue(s *ssagen.State, v *ssa.Value) {
 		x := v.Args[0].Reg()
 		y := v.Reg()
 		if x != y {
-			opregreg(s, moveByType(v.Type), y, x)
+			opregreg(s, moveByType(v.Type), vecReg(v.Type, y), vecReg(v.Type, x))
 		}
 	case ssa.OpLoadReg:
 		if v.Type.IsFlags() {
@@ -1020,7 +1047,7 @@ func ssaGenValue(s *ssagen.State, v *ssa.Value) {
 		p := s.Prog(loadByType(v.Type))
 		ssagen.AddrAuto(&p.From, v.Args[0])
 		p.To.Type = obj.TYPE_REG
-		p.To.Reg = v.Reg()
+		p.To.Reg = vecReg(v.Type, v.Reg())
 
 	case ssa.OpStoreReg:
 		if v.Type.IsFlags() {
@@ -1029,7 +1056,7 @@ func ssaGenValue(s *ssagen.State, v *ssa.Value) {
 		}
 		p := s.Prog(storeByType(v.Type))
 		p.From.Type = obj.TYPE_REG
-		p.From.Reg = v.Args[0].Reg()
+		p.From.Reg = vecReg(v.Type, v.Args[0].Reg())
 		ssagen.AddrAuto(&p.To, v)
 	case ssa.OpAMD64LoweredHasCPUFeature:
 		p := s.Prog(x86.AMOVBLZX)
@@ -1066,6 +1093,7 @@ func ssaGenValue(s *ssagen.State, v *ssa.Value) {
 			// set G register from TLS
 			getgFromTLS(s, x86.REG_R14)
 		}
+		zeroUpper(s)
 		if v.Op == ssa.OpAMD64CALLtail {
 			s.TailCall(v)
 			break
@@ -1080,6 +1108,7 @@ func ssaGenValue(s *ssagen.State, v *ssa.Value) {
 			getgFromTLS(s, x86.REG_R14)
 		}
 	case ssa.OpAMD64CALLclosure, ssa.OpAMD64CALLinter:
+		zeroUpper(s)
 		s.Call(v)
 
 	case ssa.OpAMD64LoweredGetCallerPC:
@@ -1294,6 +1323,59 @@ func ssaGenValue(s *ssagen.State, v *ssa.Value) {
 		p.To.Type = obj.TYPE_MEM
 		p.To.Reg = v.Args[0].Reg()
 		ssagen.AddAux(&p.To, v)
+	case ssa.OpAMD64PADDB, ssa.OpAMD64PADDL, ssa.OpAMD64PADDQ, ssa.OpAMD64PSUBB,
+		ssa.OpAMD64PSUBL, ssa.OpAMD64PSUBQ, ssa.OpAMD64PMULLD, ssa.OpAMD64PMINUB,
+		ssa.OpAMD64PMAXUB, ssa.OpAMD64PMINSD, ssa.OpAMD64PMAXSD, ssa.OpAMD64PCMPEQB,
+		ssa.OpAMD64PCMPEQL, ssa.OpAMD64PCMPEQQ, ssa.OpAMD64PCMPGTL, ssa.OpAMD64PCMPGTQ,
+		ssa.OpAMD64ADDPS, ssa.OpAMD64ADDPD, ssa.OpAMD64SUBPS, ssa.OpAMD64SUBPD,
+		ssa.OpAMD64MULPS, ssa.OpAMD64MULPD, ssa.OpAMD64DIVPS, ssa.OpAMD64DIVPD,
+		ssa.OpAMD64PAND, ssa.OpAMD64PANDN:
+		opregreg(s, v.Op.Asm(), v.Reg(), v.Args[1].Reg())
+	case ssa.OpAMD64SQRTPS, ssa.OpAMD64SQRTPD, ssa.OpAMD64PMOVMSKB:
+		opregreg(s, v.Op.Asm(), v.Reg(), v.Args[0].Reg())
+	case ssa.OpAMD64PXORzero:
+		opregreg(s, v.Op.Asm(), v.Reg(), v.Reg())
+	case ssa.OpAMD64VPMULLQ128, ssa.OpAMD64VPMINSQ128, ssa.OpAMD64VPMAXSQ128:
+		// OP arg1, arg0, out
+		p := s.Prog(v.Op.Asm())
+		p.From = obj.Addr{Type: obj.TYPE_REG, Reg: v.Args[1].Reg()}
+		p.AddRestSourceReg(v.Args[0].Reg())
+		p.To = obj.Addr{Type: obj.TYPE_REG, Reg: v.Reg()}
+	case ssa.OpAMD64VPADDB256, ssa.OpAMD64VPADDD256, ssa.OpAMD64VPADDQ256, ssa.OpAMD64VPSUBB256,
+		ssa.OpAMD64VPSUBD256, ssa.OpAMD64VPSUBQ256, ssa.OpAMD64VPMULLD256, ssa.OpAMD64VPMULLQ256,
+		ssa.OpAMD64VPMINUB256, ssa.OpAMD64VPMAXUB256, ssa.OpAMD64VPMINSD256, ssa.OpAMD64VPMAXSD256,
+		ssa.OpAMD64VPMINSQ256, ssa.OpAMD64VPMAXSQ256, ssa.OpAMD64VPCMPEQB256, ssa.OpAMD64VPCMPEQD256,
+		ssa.OpAMD64VPCMPEQQ256, ssa.OpAMD64VPCMPGTD256, ssa.OpAMD64VPCMPGTQ256, ssa.OpAMD64VADDPS256,
+		ssa.OpAMD64VADDPD256, ssa.OpAMD64VSUBPS256, ssa.OpAMD64VSUBPD256, ssa.OpAMD64VMULPS256,
+		ssa.OpAMD64VMULPD256, ssa.OpAMD64VDIVPS256, ssa.OpAMD64VDIVPD256, ssa.OpAMD64VPAND256,
+		ssa.OpAMD64VPOR256, ssa.OpAMD64VPXOR256, ssa.OpAMD64VPANDN256:
+		// OP arg1, arg0, out
+		p := s.Prog(v.Op.Asm())
+		p.From = obj.Addr{Type: obj.TYPE_REG, Reg: yreg(v.Args[1].Reg())}
+		p.AddRestSourceReg(yreg(v.Args[0].Reg()))
+		p.To = obj.Addr{Type: obj.TYPE_REG, Reg: yreg(v.Reg())}
+	case ssa.OpAMD64VSQRTPS256, ssa.OpAMD64VSQRTPD256:
+		opregreg(s, v.Op.Asm(), yreg(v.Reg()), yreg(v.Args[0].Reg()))
+	case ssa.OpAMD64VPMOVMSKB256:
+		opregreg(s, v.Op.Asm(), v.Reg(), yreg(v.Args[0].Reg()))
+	case ssa.OpAMD64VPXOR256zero:
+		r := yreg(v.Reg())
+		p := opregreg(s, v.Op.Asm(), r, r)
+		p.AddRestSourceReg(r)
+	case ssa.OpAMD64VMOVDQUload256:
+		p := s.Prog(v.Op.Asm())
+		p.From.Type = obj.TYPE_MEM
+		p.From.Reg = v.Args[0].Reg()
+		ssagen.AddAux(&p.From, v)
+		p.To.Type = obj.TYPE_REG
+		p.To.Reg = yreg(v.Reg())
+	case ssa.OpAMD64VMOVDQUstore256:
+		p := s.Prog(v.Op.Asm())
+		p.From.Type = obj.TYPE_REG
+		p.From.Reg = yreg(v.Args[1].Reg())
+		p.To.Type = obj.TYPE_MEM
+		p.To.Reg = v.Args[0].Reg()
+		ssagen.AddAux(&p.To, v)
 	case ssa.OpAMD64PrefetchT0, ssa.OpAMD64PrefetchNTA:
 		p := s.Prog(v.Op.Asm())
 		p.From.Type = obj.TYPE_MEM
@@ -1379,6 +1461,7 @@ func ssaGenBlock(s *ssagen.State, b, next *ssa.Block) {
 		}
 	case ssa.BlockExit, ssa.BlockRetJmp:
 	case ssa.BlockRet:
+		zeroUpper(s)
 		s.Prog(obj.ARET)
 
 	case ssa.BlockAMD64EQF:
@@ -14,6 +14,7 @@ func Init(arch *ssagen.ArchInfo) {
 	arch.LinkArch = &arm64.Linkarm64
 	arch.REGSP = arm64.REGSP
 	arch.MAXWIDTH = 1 << 50
+	arch.SIMDRegSize = 16
 
 	arch.PadFrame = padframe
 	arch.ZeroRange = zerorange
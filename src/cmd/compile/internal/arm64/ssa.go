@@ -49,6 +49,8 @@ func loadByType(t *types.Type) obj.As {
 			}
 		case 8:
 			return arm64.AMOVD
+		case 16:
+			return arm64.AFMOVQ
 		}
 	}
 	panic("bad load type")
@@ -73,11 +75,33 @@ func storeByType(t *types.Type) obj.As {
 			return arm64.AMOVW
 		case 8:
 			return arm64.AMOVD
+		case 16:
+			return arm64.AFMOVQ
 		}
 	}
 	panic("bad store type")
 }
 
+// vreg returns the V register with arrangement arng that overlaps
+// the F register r.
+func vreg(r int16, arng int16) int16 {
+	return (r-arm64.REG_F0)&31 + arm64.REG_ARNG + (arng&15)<<5
+}
+
+// vecArng returns the arrangement of the lanes of the vector op.
+func vecArng(op ssa.Op) int16 {
+	switch op {
+	case ssa.OpARM64VADDS4, ssa.OpARM64VSUBS4, ssa.OpARM64VMULS4, ssa.OpARM64VSMINS4, ssa.OpARM64VSMAXS4,
+		ssa.OpARM64VCMEQS4, ssa.OpARM64VCMGTS4, ssa.OpARM64VFADDS4, ssa.OpARM64VFSUBS4, ssa.OpARM64VFMULS4,
+		ssa.OpARM64VFDIVS4, ssa.OpARM64VFSQRTS4:
+		return arm64.ARNG_4S
+	case ssa.OpARM64VADDD2, ssa.OpARM64VSUBD2, ssa.OpARM64VCMEQD2, ssa.OpARM64VCMGTD2, ssa.OpARM64VFADDD2,
+		ssa.OpARM64VFSUBD2, ssa.OpARM64VFMULD2, ssa.OpARM64VFDIVD2, ssa.OpARM64VFSQRTD2:
+		return arm64.ARNG_2D
+	}
+	return arm64.ARNG_16B
+}
+
 // makeshift encodes a register shifted by a constant, used as an Offset in Prog.
 func makeshift(v *ssa.Value, reg int16, typ int64, s int64) int64 {
 	if s < 0 || s >= 64 {
@@ -130,6 +154,15 @@ func ssaGenValue(s *ssagen.State, v *ssa.Value) {
 		if x == y {
 			return
 		}
+		if v.Type == types.TypeVec128 {
+			// VMOV Vx.B16, Vy.B16
+			p := s.Prog(arm64.AVMOV)
+			p.From.Type = obj.TYPE_REG
+			p.From.Reg = vreg(x, arm64.ARNG_16B)
+			p.To.Type = obj.TYPE_REG
+			p.To.Reg = vreg(y, arm64.ARNG_16B)
+			return
+		}
 		as := arm64.AMOVD
 		if v.Type.IsFloat() {
 			switch v.Type.Size() {
@@ -446,7 +479,8 @@ func ssaGenValue(s *ssagen.State, v *ssa.Value) {
 		ssa.OpARM64MOVWUload,
 		ssa.OpARM64MOVDload,
 		ssa.OpARM64FMOVSload,
-		ssa.OpARM64FMOVDload:
+		ssa.OpARM64FMOVDload,
+		ssa.OpARM64FMOVQload:
 		p := s.Prog(v.Op.Asm())
 		p.From.Type = obj.TYPE_MEM
 		p.From.Reg = v.Args[0].Reg()
@@ -496,6 +530,7 @@ func ssaGenValue(s *ssagen.State, v *ssa.Value) {
 		ssa.OpARM64MOVDstore,
 		ssa.OpARM64FMOVSstore,
 		ssa.OpARM64FMOVDstore,
+		ssa.OpARM64FMOVQstore,
 		ssa.OpARM64STLRB,
 		ssa.OpARM64STLR,
 		ssa.OpARM64STLRW:
@@ -1144,6 +1179,35 @@ func ssaGenValue(s *ssagen.State, v *ssa.Value) {
 		p := s.Prog(v.Op.Asm())
 		p.From.Type = obj.TYPE_CONST
 		p.From.Offset = v.AuxInt
+	case ssa.OpARM64VADDB16, ssa.OpARM64VADDS4, ssa.OpARM64VADDD2, ssa.OpARM64VSUBB16, ssa.OpARM64VSUBS4,
+		ssa.OpARM64VSUBD2, ssa.OpARM64VMULS4, ssa.OpARM64VUMINB16, ssa.OpARM64VUMAXB16, ssa.OpARM64VSMINS4,
+		ssa.OpARM64VSMAXS4, ssa.OpARM64VCMEQB16, ssa.OpARM64VCMEQS4, ssa.OpARM64VCMEQD2, ssa.OpARM64VCMGTS4,
+		ssa.OpARM64VCMGTD2, ssa.OpARM64VFADDS4, ssa.OpARM64VFADDD2, ssa.OpARM64VFSUBS4, ssa.OpARM64VFSUBD2,
+		ssa.OpARM64VFMULS4, ssa.OpARM64VFMULD2, ssa.OpARM64VFDIVS4, ssa.OpARM64VFDIVD2, ssa.OpARM64VAND,
+		ssa.OpARM64VORR, ssa.OpARM64VEOR, ssa.OpARM64VBIC:
+		// OP arg1, arg0, out
+		arng := vecArng(v.Op)
+		p := s.Prog(v.Op.Asm())
+		p.From = obj.Addr{Type: obj.TYPE_REG, Reg: vreg(v.Args[1].Reg(), arng)}
+		p.Reg = vreg(v.Args[0].Reg(), arng)
+		p.To = obj.Addr{Type: obj.TYPE_REG, Reg: vreg(v.Reg(), arng)}
+	case ssa.OpARM64VFSQRTS4, ssa.OpARM64VFSQRTD2:
+		arng := vecArng(v.Op)
+		p := s.Prog(v.Op.Asm())
+		p.From = obj.Addr{Type: obj.TYPE_REG, Reg: vreg(v.Args[0].Reg(), arng)}
+		p.To = obj.Addr{Type: obj.TYPE_REG, Reg: vreg(v.Reg(), arng)}
+	case ssa.OpARM64VBSL:
+		// VBSL arg2, arg1, arg0
+		p := s.Prog(v.Op.Asm())
+		p.From = obj.Addr{Type: obj.TYPE_REG, Reg: vreg(v.Args[2].Reg(), arm64.ARNG_16B)}
+		p.Reg = vreg(v.Args[1].Reg(), arm64.ARNG_16B)
+		p.To = obj.Addr{Type: obj.TYPE_REG, Reg: vreg(v.Reg(), arm64.ARNG_16B)}
+	case ssa.OpARM64VEORzero:
+		r := vreg(v.Reg(), arm64.ARNG_16B)
+		p := s.Prog(v.Op.Asm())
+		p.From = obj.Addr{Type: obj.TYPE_REG, Reg: r}
+		p.Reg = r
+		p.To = obj.Addr{Type: obj.TYPE_REG, Reg: r}
 	case ssa.OpARM64FlagConstant:
 		v.Fatalf("FlagConstant op should never make it to codegen %v", v.LongString())
 	case ssa.OpARM64InvertFlags:
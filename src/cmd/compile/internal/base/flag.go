@@ -181,7 +181,10 @@ func ParseFlags() {
 	Debug.InlStaticInit = 1
 	Debug.PGOInline = 1
 	Debug.PGODevirtualize = 2
+	Debug.PGOLayout = 1
+	Debug.PGOUnroll = 1
 	Debug.SyncFrames = -1 // disable sync markers by default
+	Debug.VariableMakeThreshold = 32
 	Debug.ZeroCopy = 1
 	Debug.RangeFuncCheck = 1
 	Debug.MergeLocals = 1
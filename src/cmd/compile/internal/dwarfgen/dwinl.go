@@ -38,16 +38,16 @@ func assembleInlines(fnsym *obj.LSym, dwVars []*dwarf.Var) dwarf.InlCalls {
 
 	// Walk progs to build up the InlCalls data structure
 	var prevpos src.XPos
-	for p := fnsym.Func().Text; p != nil; p = p.Link {
+	forEachProg(fnsym, func(p *obj.Prog, _ int64) {
 		if p.Pos == prevpos {
-			continue
+			return
 		}
 		ii := posInlIndex(p.Pos)
 		if ii >= 0 {
 			insertInlCall(&inlcalls, ii, imap)
 		}
 		prevpos = p.Pos
-	}
+	})
 
 	// This is used to partition DWARF vars by inline index. Vars not
 	// produced by the inliner will wind up in the vmap[0] entry.
@@ -152,24 +152,26 @@ func assembleInlines(fnsym *obj.LSym, dwVars []*dwarf.Var) dwarf.InlCalls {
 	start := int64(-1)
 	curii := -1
 	var prevp *obj.Prog
-	for p := fnsym.Func().Text; p != nil; prevp, p = p, p.Link {
-		if prevp != nil && p.Pos == prevp.Pos {
-			continue
+	end := forEachProg(fnsym, func(p *obj.Prog, pc int64) {
+		samePos := prevp != nil && p.Pos == prevp.Pos
+		prevp = p
+		if samePos {
+			return
 		}
 		ii := posInlIndex(p.Pos)
 		if ii == curii {
-			continue
+			return
 		}
 		// Close out the current range
 		if start != -1 {
-			addRange(inlcalls.Calls, start, p.Pc, curii, imap)
+			addRange(inlcalls.Calls, start, pc, curii, imap)
 		}
 		// Begin new range
-		start = p.Pc
+		start = pc
 		curii = ii
-	}
+	})
 	if start != -1 {
-		addRange(inlcalls.Calls, start, fnsym.Size, curii, imap)
+		addRange(inlcalls.Calls, start, end, curii, imap)
 	}
 
 	// Issue 33188: if II foo is a child of II bar, then ensure that
@@ -194,7 +196,7 @@ func assembleInlines(fnsym *obj.LSym, dwVars []*dwarf.Var) dwarf.InlCalls {
 	// within the ranges for A, or C within B.
 	for k, c := range inlcalls.Calls {
 		if c.Root {
-			checkInlCall(fnsym.Name, inlcalls, fnsym.Size, k, -1)
+			checkInlCall(fnsym.Name, inlcalls, end, k, -1)
 		}
 	}
 
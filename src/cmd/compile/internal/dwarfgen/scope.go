@@ -72,18 +72,37 @@ func scopePCs(fnsym *obj.LSym, marks []ir.Mark, dwarfScopes []dwarf.Scope) {
 		return
 	}
 	p0 := fnsym.Func().Text
+	pc0 := p0.Pc
 	scope := findScope(marks, p0.Pos)
-	for p := p0; p != nil; p = p.Link {
+	end := forEachProg(fnsym, func(p *obj.Prog, pc int64) {
 		if p.Pos == p0.Pos {
-			continue
+			return
 		}
-		dwarfScopes[scope].AppendRange(dwarf.Range{Start: p0.Pc, End: p.Pc})
-		p0 = p
+		dwarfScopes[scope].AppendRange(dwarf.Range{Start: pc0, End: pc})
+		p0, pc0 = p, pc
 		scope = findScope(marks, p0.Pos)
+	})
+	if pc0 < end {
+		dwarfScopes[scope].AppendRange(dwarf.Range{Start: pc0, End: end})
+	}
+}
+
+// forEachProg calls do for each Prog of fnsym and then of its cold
+// fragment, if any, with the Prog's PC counting the fragment as
+// following fnsym, as the PCs in the DWARF info do. It returns the end
+// of the code.
+func forEachProg(fnsym *obj.LSym, do func(p *obj.Prog, pc int64)) int64 {
+	for p := fnsym.Func().Text; p != nil; p = p.Link {
+		do(p, p.Pc)
 	}
-	if p0.Pc < fnsym.Size {
-		dwarfScopes[scope].AppendRange(dwarf.Range{Start: p0.Pc, End: fnsym.Size})
+	end := fnsym.Size
+	if cold := fnsym.Func().Cold; cold != nil {
+		for p := cold.Func().Text; p != nil; p = p.Link {
+			do(p, end+p.Pc)
+		}
+		end += cold.Size
 	}
+	return end
 }
 
 func compactScopes(dwarfScopes []dwarf.Scope) []dwarf.Scope {
@@ -160,6 +160,9 @@ func Batch(fns []*ir.Func, recursive bool) {
 	}
 
 	b.walkAll()
+	if logGraph() {
+		b.logFlowGraph()
+	}
 	b.finish(fns)
 }
 
@@ -316,7 +319,11 @@ func (b *batch) finish(fns []*ir.Func) {
 			n.SetEsc(ir.EscHeap)
 		} else {
 			if base.Flag.LowerM != 0 && n.Op() != ir.ONAME && !goDeferWrapper {
-				base.WarnfAt(n.Pos(), "%v does not escape", n)
+				if k := variableMakeStackLen(n); k > 0 {
+					base.WarnfAt(n.Pos(), "%v does not escape, stack allocated if capacity <= %d", n, k)
+				} else {
+					base.WarnfAt(n.Pos(), "%v does not escape", n)
+				}
 			}
 			n.SetEsc(ir.EscNone)
 			if !loc.hasAttr(attrPersists) {
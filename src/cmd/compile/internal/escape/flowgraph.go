@@ -0,0 +1,130 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package escape
+
+import (
+	"cmd/compile/internal/base"
+	"cmd/compile/internal/ir"
+	"cmd/compile/internal/logopt"
+	"cmd/internal/src"
+	"fmt"
+	"strings"
+)
+
+// With -json and -d=escapegraph, escape analysis logs its data-flow
+// graph along with its decisions, so that tools such as "go tool
+// escape" can explain why a variable escapes.
+//
+// Each location is identified by an integer that is unique within the
+// package being compiled; the heap is always 0. The graph is logged
+// as diagnostics with these codes:
+//
+//	escedge: an edge of the graph. The message describes the flow as
+//	    in the -m=2 explanations. The first related location is the
+//	    destination of the flow, with message "dst: ID NAME". The
+//	    second is its source, with message "src: ID DEREFS NAME".
+//	    Any further related locations are the notes explaining the
+//	    flow, with messages "from: WHERE (WHY)".
+//
+//	escroot: the location at the diagnostic's position escapes, or
+//	    leaks if it is a parameter, because it flows to another
+//	    location, the root. The message is "escape ID ROOT" or
+//	    "leak ID ROOT DEREFS".
+//
+// Related locations for inlined positions ("inlineLoc") may follow
+// any of the above.
+
+// logGraph reports whether the data-flow graph should be logged.
+func logGraph() bool {
+	return base.Debug.EscapeGraph != 0 && logopt.Enabled()
+}
+
+// lastGraphID is the last location ID assigned by graphID.
+var lastGraphID int
+
+// graphID returns the ID of l in the logged data-flow graph.
+func (b *batch) graphID(l *location) int {
+	if l == &b.heapLoc {
+		return 0
+	}
+	if l.graphID == 0 {
+		lastGraphID++
+		l.graphID = lastGraphID
+	}
+	return l.graphID
+}
+
+// graphPos returns the source position to log for l.
+func graphPos(l *location) src.XPos {
+	if l.n != nil && l.n.Pos().IsKnown() {
+		return l.n.Pos()
+	}
+	if l.curfn != nil {
+		return l.curfn.Pos()
+	}
+	return src.NoXPos
+}
+
+// logFlowGraph logs the edges of the data-flow graph of the batch.
+func (b *batch) logFlowGraph() {
+	locs := append(b.allLocs[:len(b.allLocs):len(b.allLocs)], &b.heapLoc)
+	for _, dst := range locs {
+		for _, edge := range dst.edges {
+			b.logFlowEdge(dst, edge)
+		}
+	}
+}
+
+// logFlowEdge logs the edge from edge.src to dst.
+func (b *batch) logFlowEdge(dst *location, edge edge) {
+	pos := graphPos(edge.src)
+	if edge.notes != nil {
+		pos = edge.notes.where.Pos()
+	}
+	if !pos.IsKnown() {
+		pos = graphPos(dst)
+	}
+	if !pos.IsKnown() {
+		return
+	}
+	dstPos, srcPos := graphPos(dst), graphPos(edge.src)
+	if !dstPos.IsKnown() {
+		dstPos = pos
+	}
+	if !srcPos.IsKnown() {
+		srcPos = pos
+	}
+
+	fn := ir.FuncName(edge.src.curfn)
+	explanation := []*logopt.LoggedOpt{
+		logopt.NewLoggedOpt(dstPos, dstPos, "dst", "escape", fn,
+			fmt.Sprintf("%d %s", b.graphID(dst), b.explainLoc(dst))),
+		logopt.NewLoggedOpt(srcPos, srcPos, "src", "escape", fn,
+			fmt.Sprintf("%d %d %s", b.graphID(edge.src), edge.derefs, b.explainLoc(edge.src))),
+	}
+	for note := edge.notes; note != nil; note = note.next {
+		notePos := note.where.Pos()
+		explanation = append(explanation, logopt.NewLoggedOpt(notePos, notePos, "from", "escape", fn,
+			fmt.Sprintf("%v (%v)", note.where, note.why)))
+	}
+
+	ops := "&"
+	if edge.derefs >= 0 {
+		ops = strings.Repeat("*", edge.derefs)
+	}
+	flow := fmt.Sprintf("flow: %s = %s%v", b.explainLoc(dst), ops, b.explainLoc(edge.src))
+	logopt.LogOpt(pos, "escedge", "escape", fn, flow, explanation)
+}
+
+// logFlowRoot logs that parameter l leaks to root with the given
+// number of dereferences, or, if derefs is -1, that l escapes because
+// its address flows to root.
+func (b *batch) logFlowRoot(l, root *location, derefs int) {
+	msg := fmt.Sprintf("escape %d %d", b.graphID(l), b.graphID(root))
+	if derefs >= 0 {
+		msg = fmt.Sprintf("leak %d %d %d", b.graphID(l), b.graphID(root), derefs)
+	}
+	logopt.LogOpt(l.n.Pos(), "escroot", "escape", ir.FuncName(l.curfn), msg)
+}
@@ -75,6 +75,10 @@ type location struct {
 	captured   bool // has a closure captured this variable?
 	reassigned bool // has this variable been reassigned?
 	addrtaken  bool // has this variable's address been taken?
+
+	// graphID identifies the location in the data-flow graph
+	// logged for -d=escapegraph, once assigned.
+	graphID int
 }
 
 type locAttr uint8
@@ -107,6 +107,9 @@ func (b *batch) walkOne(root *location, walkgen uint32, enqueue func(*location))
 						var e_curfn *ir.Func // TODO(mdempsky): Fix.
 						logopt.LogOpt(l.n.Pos(), "escape", "escape", ir.FuncName(e_curfn), fmt.Sprintf("%v escapes to heap", l.n), explanation)
 					}
+					if logGraph() {
+						b.logFlowRoot(l, root, -1)
+					}
 				}
 				newAttrs |= attrEscapes | attrPersists | attrMutates | attrCalls
 			} else
@@ -138,6 +141,9 @@ func (b *batch) walkOne(root *location, walkgen uint32, enqueue func(*location))
 						logopt.LogOpt(l.n.Pos(), "leak", "escape", ir.FuncName(e_curfn),
 							fmt.Sprintf("parameter %v leaks to %s with derefs=%d", l.n, b.explainLoc(root), derefs), explanation)
 					}
+					if logGraph() {
+						b.logFlowRoot(l, root, derefs)
+					}
 				}
 				l.leakTo(root, derefs)
 			}
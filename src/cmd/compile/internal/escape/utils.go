@@ -5,6 +5,7 @@
 package escape
 
 import (
+	"cmd/compile/internal/base"
 	"cmd/compile/internal/ir"
 	"cmd/compile/internal/typecheck"
 	"cmd/compile/internal/types"
@@ -211,7 +212,10 @@ func HeapAllocReason(n ir.Node) string {
 			r = n.Len
 		}
 		if !ir.IsSmallIntConst(r) {
-			return "non-constant size"
+			if VariableMakeStackLen(n) == 0 {
+				return "non-constant size"
+			}
+			return ""
 		}
 		if t := n.Type(); t.Elem().Size() != 0 && ir.Int64Val(r) > ir.MaxImplicitStackVarSize/t.Elem().Size() {
 			return "too large for stack"
@@ -220,3 +224,40 @@ func HeapAllocReason(n ir.Node) string {
 
 	return ""
 }
+
+// VariableMakeStackLen returns the number of elements in the stack
+// buffer used to back n, a non-escaping make whose capacity is not
+// constant. If the capacity at run time is at most this length, the
+// slice is backed by the stack buffer; otherwise it is allocated on
+// the heap. VariableMakeStackLen returns 0 if n must always be heap
+// allocated.
+func VariableMakeStackLen(n *ir.MakeExpr) int64 {
+	size := n.Type().Elem().Size()
+	if size == 0 || int64(base.Debug.VariableMakeThreshold) < size {
+		return 0
+	}
+	k := int64(base.Debug.VariableMakeThreshold) / size
+	if n.Len.Op() == ir.OLITERAL && ir.Int64Val(n.Len) > k {
+		// The length never fits in the buffer.
+		return 0
+	}
+	return k
+}
+
+// variableMakeStackLen returns the length of the stack buffer backing
+// n if n is a non-escaping make with a non-constant capacity, and
+// 0 otherwise.
+func variableMakeStackLen(n ir.Node) int64 {
+	if n.Op() != ir.OMAKESLICE {
+		return 0
+	}
+	mk := n.(*ir.MakeExpr)
+	r := mk.Cap
+	if r == nil {
+		r = mk.Len
+	}
+	if ir.IsSmallIntConst(r) {
+		return 0
+	}
+	return VariableMakeStackLen(mk)
+}
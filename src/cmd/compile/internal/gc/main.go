@@ -38,6 +38,10 @@ import (
 	"runtime"
 )
 
+// pgoHotFuncCDFThreshold is the percentage of the profile's total call
+// edge weight made up by the edges to functions considered hot.
+const pgoHotFuncCDFThreshold = 90
+
 // handlePanic ensures that we print out an "internal compiler error" for any panic
 // or runtime exception during front-end compiler processing (unless there have
 // already been some compiler errors). It may also be invoked from the explicit panic in
@@ -183,6 +187,7 @@ func Main(archInit func(*ssagen.ArchInfo)) {
 
 	types.PtrSize = ssagen.Arch.LinkArch.PtrSize
 	types.RegSize = ssagen.Arch.LinkArch.RegSize
+	types.SIMDRegSize = ssagen.Arch.SIMDRegSize
 	types.MaxWidth = ssagen.Arch.MAXWIDTH
 
 	typecheck.Target = new(ir.Package)
@@ -228,6 +233,24 @@ func Main(archInit func(*ssagen.ArchInfo)) {
 	base.Timer.Start("fe", "devirtualize-and-inline")
 	interleaved.DevirtualizeAndInlinePackage(typecheck.Target, profile)
 
+	// Mark the functions in which the profile shows the program spends
+	// most of its time, so that the backend can unroll their loops more
+	// aggressively.
+	if profile != nil && base.Debug.PGOUnroll != 0 {
+		hot := profile.HotFuncs(pgoHotFuncCDFThreshold)
+		for _, fn := range typecheck.Target.Funcs {
+			if hot[ir.LinkFuncName(fn)] {
+				fn.SetPGOHot(true)
+			}
+		}
+	}
+
+	// Let the backend lay out the blocks of each function so that the
+	// paths to its hottest call sites are the fall-through paths.
+	if profile != nil && base.Debug.PGOLayout != 0 {
+		ssagen.PGOCallSiteWeights = profile.CallSiteWeights()
+	}
+
 	noder.MakeWrappers(typecheck.Target) // must happen after inlining
 
 	// Get variable capture right in for loops.
@@ -63,6 +63,7 @@ type symsStruct struct {
 	ARM64HasATOMICS *obj.LSym
 	ARMHasVFPv4     *obj.LSym
 	X86HasFMA       *obj.LSym
+	X86HasAVX512    *obj.LSym
 	X86HasPOPCNT    *obj.LSym
 	X86HasSSE41     *obj.LSym
 	// Wasm
@@ -318,7 +318,10 @@ func (lv *liveness) valueEffects(v *ssa.Value) (int32, liveEffect) {
 		effect |= uevar
 	}
 	if e&ssa.SymWrite != 0 {
-		if !isfat(n.Type()) || v.Op == ssa.OpVarDef {
+		// A spill of a whole variable writes all of it, even if its
+		// type is fat, as for the vector types of package simd.
+		if !isfat(n.Type()) || v.Op == ssa.OpVarDef ||
+			v.Op == ssa.OpStoreReg && v.Type.Size() == n.Type().Size() {
 			effect |= varkill
 		} else if lv.conservativeWrites {
 			effect |= uevar
@@ -204,6 +204,39 @@ func (pp *Progs) SetText(fn *ir.Func) {
 	ptxt.From.Sym = fn.LSym
 }
 
+// SetColdText starts the cold fragment of the function, which consists
+// of the Progs that follow, and returns its ATEXT Prog. The fragment
+// has the same FUNCDATA as the function, and its PCDATA tables start
+// over from their initial values.
+func (pp *Progs) SetColdText() *obj.Prog {
+	var funcdata []*obj.Prog
+	for p := pp.Text; p != pp.Next; p = p.Link {
+		if p.As == obj.AFUNCDATA {
+			funcdata = append(funcdata, p)
+		}
+	}
+
+	// Keep any pending PCDATA for the first Prog of the fragment.
+	nextLive, nextUnsafe := pp.NextLive, pp.NextUnsafe
+	pp.NextLive, pp.NextUnsafe = pp.PrevLive, pp.PrevUnsafe
+
+	cold := base.Ctxt.InitColdTextSym(pp.CurFunc.LSym)
+	ptxt := pp.Prog(obj.ATEXT)
+	cold.Func().Text = ptxt
+	ptxt.Pos = pp.Text.Pos
+	ptxt.From.Type = obj.TYPE_MEM
+	ptxt.From.Name = obj.NAME_EXTERN
+	ptxt.From.Sym = cold
+	for _, p := range funcdata {
+		q := pp.Prog(obj.AFUNCDATA)
+		q.From, q.To = p.From, p.To
+	}
+
+	pp.PrevLive, pp.PrevUnsafe = -1, false
+	pp.NextLive, pp.NextUnsafe = nextLive, nextUnsafe
+	return ptxt
+}
+
 // LosesStmtMark reports whether a prog with op as loses its statement mark on the way to DWARF.
 // The attributes from some opcodes are lost in translation.
 // TODO: this is an artifact of how funcpctab combines information for instructions at a single PC.
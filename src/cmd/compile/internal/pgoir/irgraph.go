@@ -388,6 +388,40 @@ func addIndirectEdges(g *IRGraph, namedEdgeMap pgo.NamedEdgeMap) {
 	}
 }
 
+// HotFuncs returns the linker symbol names of the functions called by
+// the hottest call edges in the profile, which together make up the top
+// cdfThreshold percent of the total edge weight. The profile attributes
+// the samples taken in a function, including those in its loops, to the
+// edges that call it.
+func (p *Profile) HotFuncs(cdfThreshold float64) map[string]bool {
+	hot := make(map[string]bool)
+	cum := int64(0)
+	for _, n := range p.NamedEdgeMap.ByWeight {
+		hot[n.CalleeName] = true
+		cum += p.NamedEdgeMap.Weight[n]
+		if pgo.WeightInPercentage(cum, p.TotalWeight) > cdfThreshold {
+			break
+		}
+	}
+	return hot
+}
+
+// CallSiteWeights returns the total weight of the call edges in the
+// profile from each call site, indexed by the linker symbol name of the
+// calling function and the line offset of the call site within it.
+func (p *Profile) CallSiteWeights() map[string]map[int]int64 {
+	weights := make(map[string]map[int]int64)
+	for e, w := range p.NamedEdgeMap.Weight {
+		m := weights[e.CallerName]
+		if m == nil {
+			m = make(map[int]int64)
+			weights[e.CallerName] = m
+		}
+		m[e.CallSiteOffset] += w
+	}
+	return weights
+}
+
 // PrintWeightedCallGraphDOT prints IRGraph in DOT format.
 func (p *Profile) PrintWeightedCallGraphDOT(edgeThreshold float64) {
 	fmt.Printf("\ndigraph G {\n")
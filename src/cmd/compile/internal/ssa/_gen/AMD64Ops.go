@@ -1070,6 +1070,83 @@ func init() {
 		{name: "LZCNTQ", argLength: 1, reg: gp11, asm: "LZCNTQ", typ: "UInt64", clobberFlags: true},
 		{name: "LZCNTL", argLength: 1, reg: gp11, asm: "LZCNTL", typ: "UInt32", clobberFlags: true},
 
+		// Vector operations, used by package simd. The ops without a size
+		// suffix work on 128-bit values in X registers and need SSE2 unless
+		// noted otherwise. The ops suffixed 256 work on 256-bit values, which
+		// live in the Y registers that extend the allocated X registers, and
+		// need AVX2 unless noted otherwise. The AVX-512 ops need F, VL and DQ.
+		{name: "PADDB", argLength: 2, reg: fp21, asm: "PADDB", commutative: true, resultInArg0: true},     // arg0 + arg1, bytes
+		{name: "PADDL", argLength: 2, reg: fp21, asm: "PADDL", commutative: true, resultInArg0: true},     // arg0 + arg1, 32-bit lanes
+		{name: "PADDQ", argLength: 2, reg: fp21, asm: "PADDQ", commutative: true, resultInArg0: true},     // arg0 + arg1, 64-bit lanes
+		{name: "PSUBB", argLength: 2, reg: fp21, asm: "PSUBB", resultInArg0: true},                        // arg0 - arg1, bytes
+		{name: "PSUBL", argLength: 2, reg: fp21, asm: "PSUBL", resultInArg0: true},                        // arg0 - arg1, 32-bit lanes
+		{name: "PSUBQ", argLength: 2, reg: fp21, asm: "PSUBQ", resultInArg0: true},                        // arg0 - arg1, 64-bit lanes
+		{name: "PMULLD", argLength: 2, reg: fp21, asm: "PMULLD", commutative: true, resultInArg0: true},   // arg0 * arg1, 32-bit lanes. SSE4.1
+		{name: "PMINUB", argLength: 2, reg: fp21, asm: "PMINUB", commutative: true, resultInArg0: true},   // min(arg0, arg1), unsigned bytes
+		{name: "PMAXUB", argLength: 2, reg: fp21, asm: "PMAXUB", commutative: true, resultInArg0: true},   // max(arg0, arg1), unsigned bytes
+		{name: "PMINSD", argLength: 2, reg: fp21, asm: "PMINSD", commutative: true, resultInArg0: true},   // min(arg0, arg1), signed 32-bit lanes. SSE4.1
+		{name: "PMAXSD", argLength: 2, reg: fp21, asm: "PMAXSD", commutative: true, resultInArg0: true},   // max(arg0, arg1), signed 32-bit lanes. SSE4.1
+		{name: "PCMPEQB", argLength: 2, reg: fp21, asm: "PCMPEQB", commutative: true, resultInArg0: true}, // arg0 == arg1 ? -1 : 0, bytes
+		{name: "PCMPEQL", argLength: 2, reg: fp21, asm: "PCMPEQL", commutative: true, resultInArg0: true}, // arg0 == arg1 ? -1 : 0, 32-bit lanes
+		{name: "PCMPEQQ", argLength: 2, reg: fp21, asm: "PCMPEQQ", commutative: true, resultInArg0: true}, // arg0 == arg1 ? -1 : 0, 64-bit lanes. SSE4.1
+		{name: "PCMPGTL", argLength: 2, reg: fp21, asm: "PCMPGTL", resultInArg0: true},                    // arg0 > arg1 ? -1 : 0, signed 32-bit lanes
+		{name: "PCMPGTQ", argLength: 2, reg: fp21, asm: "PCMPGTQ", resultInArg0: true},                    // arg0 > arg1 ? -1 : 0, signed 64-bit lanes. SSE4.2
+		{name: "ADDPS", argLength: 2, reg: fp21, asm: "ADDPS", commutative: true, resultInArg0: true},     // arg0 + arg1, float32 lanes
+		{name: "ADDPD", argLength: 2, reg: fp21, asm: "ADDPD", commutative: true, resultInArg0: true},     // arg0 + arg1, float64 lanes
+		{name: "SUBPS", argLength: 2, reg: fp21, asm: "SUBPS", resultInArg0: true},                        // arg0 - arg1, float32 lanes
+		{name: "SUBPD", argLength: 2, reg: fp21, asm: "SUBPD", resultInArg0: true},                        // arg0 - arg1, float64 lanes
+		{name: "MULPS", argLength: 2, reg: fp21, asm: "MULPS", commutative: true, resultInArg0: true},     // arg0 * arg1, float32 lanes
+		{name: "MULPD", argLength: 2, reg: fp21, asm: "MULPD", commutative: true, resultInArg0: true},     // arg0 * arg1, float64 lanes
+		{name: "DIVPS", argLength: 2, reg: fp21, asm: "DIVPS", resultInArg0: true},                        // arg0 / arg1, float32 lanes
+		{name: "DIVPD", argLength: 2, reg: fp21, asm: "DIVPD", resultInArg0: true},                        // arg0 / arg1, float64 lanes
+		{name: "PAND", argLength: 2, reg: fp21, asm: "PAND", commutative: true, resultInArg0: true},       // arg0 & arg1
+		{name: "PANDN", argLength: 2, reg: fp21, asm: "PANDN", resultInArg0: true},                        // ^arg0 & arg1
+		{name: "SQRTPS", argLength: 1, reg: fp11, asm: "SQRTPS"},                                          // sqrt(arg0), float32 lanes
+		{name: "SQRTPD", argLength: 1, reg: fp11, asm: "SQRTPD"},                                          // sqrt(arg0), float64 lanes
+		{name: "PMOVMSKB", argLength: 1, reg: fpgp, asm: "PMOVMSKB", typ: "UInt32"},                       // bit i is the sign bit of byte i of arg0
+		{name: "PXORzero", reg: fp01, asm: "PXOR", rematerializeable: true},                               // the zero vector
+		{name: "VPMULLQ128", argLength: 2, reg: fp21, asm: "VPMULLQ", commutative: true},                  // arg0 * arg1, 64-bit lanes. AVX-512
+		{name: "VPMINSQ128", argLength: 2, reg: fp21, asm: "VPMINSQ", commutative: true},                  // min(arg0, arg1), signed 64-bit lanes. AVX-512
+		{name: "VPMAXSQ128", argLength: 2, reg: fp21, asm: "VPMAXSQ", commutative: true},                  // max(arg0, arg1), signed 64-bit lanes. AVX-512
+
+		{name: "VPADDB256", argLength: 2, reg: fp21, asm: "VPADDB", commutative: true},                                                             // arg0 + arg1, bytes
+		{name: "VPADDD256", argLength: 2, reg: fp21, asm: "VPADDD", commutative: true},                                                             // arg0 + arg1, 32-bit lanes
+		{name: "VPADDQ256", argLength: 2, reg: fp21, asm: "VPADDQ", commutative: true},                                                             // arg0 + arg1, 64-bit lanes
+		{name: "VPSUBB256", argLength: 2, reg: fp21, asm: "VPSUBB"},                                                                                // arg0 - arg1, bytes
+		{name: "VPSUBD256", argLength: 2, reg: fp21, asm: "VPSUBD"},                                                                                // arg0 - arg1, 32-bit lanes
+		{name: "VPSUBQ256", argLength: 2, reg: fp21, asm: "VPSUBQ"},                                                                                // arg0 - arg1, 64-bit lanes
+		{name: "VPMULLD256", argLength: 2, reg: fp21, asm: "VPMULLD", commutative: true},                                                           // arg0 * arg1, 32-bit lanes
+		{name: "VPMULLQ256", argLength: 2, reg: fp21, asm: "VPMULLQ", commutative: true},                                                           // arg0 * arg1, 64-bit lanes. AVX-512
+		{name: "VPMINUB256", argLength: 2, reg: fp21, asm: "VPMINUB", commutative: true},                                                           // min(arg0, arg1), unsigned bytes
+		{name: "VPMAXUB256", argLength: 2, reg: fp21, asm: "VPMAXUB", commutative: true},                                                           // max(arg0, arg1), unsigned bytes
+		{name: "VPMINSD256", argLength: 2, reg: fp21, asm: "VPMINSD", commutative: true},                                                           // min(arg0, arg1), signed 32-bit lanes
+		{name: "VPMAXSD256", argLength: 2, reg: fp21, asm: "VPMAXSD", commutative: true},                                                           // max(arg0, arg1), signed 32-bit lanes
+		{name: "VPMINSQ256", argLength: 2, reg: fp21, asm: "VPMINSQ", commutative: true},                                                           // min(arg0, arg1), signed 64-bit lanes. AVX-512
+		{name: "VPMAXSQ256", argLength: 2, reg: fp21, asm: "VPMAXSQ", commutative: true},                                                           // max(arg0, arg1), signed 64-bit lanes. AVX-512
+		{name: "VPCMPEQB256", argLength: 2, reg: fp21, asm: "VPCMPEQB", commutative: true},                                                         // arg0 == arg1 ? -1 : 0, bytes
+		{name: "VPCMPEQD256", argLength: 2, reg: fp21, asm: "VPCMPEQD", commutative: true},                                                         // arg0 == arg1 ? -1 : 0, 32-bit lanes
+		{name: "VPCMPEQQ256", argLength: 2, reg: fp21, asm: "VPCMPEQQ", commutative: true},                                                         // arg0 == arg1 ? -1 : 0, 64-bit lanes
+		{name: "VPCMPGTD256", argLength: 2, reg: fp21, asm: "VPCMPGTD"},                                                                            // arg0 > arg1 ? -1 : 0, signed 32-bit lanes
+		{name: "VPCMPGTQ256", argLength: 2, reg: fp21, asm: "VPCMPGTQ"},                                                                            // arg0 > arg1 ? -1 : 0, signed 64-bit lanes
+		{name: "VADDPS256", argLength: 2, reg: fp21, asm: "VADDPS", commutative: true},                                                             // arg0 + arg1, float32 lanes
+		{name: "VADDPD256", argLength: 2, reg: fp21, asm: "VADDPD", commutative: true},                                                             // arg0 + arg1, float64 lanes
+		{name: "VSUBPS256", argLength: 2, reg: fp21, asm: "VSUBPS"},                                                                                // arg0 - arg1, float32 lanes
+		{name: "VSUBPD256", argLength: 2, reg: fp21, asm: "VSUBPD"},                                                                                // arg0 - arg1, float64 lanes
+		{name: "VMULPS256", argLength: 2, reg: fp21, asm: "VMULPS", commutative: true},                                                             // arg0 * arg1, float32 lanes
+		{name: "VMULPD256", argLength: 2, reg: fp21, asm: "VMULPD", commutative: true},                                                             // arg0 * arg1, float64 lanes
+		{name: "VDIVPS256", argLength: 2, reg: fp21, asm: "VDIVPS"},                                                                                // arg0 / arg1, float32 lanes
+		{name: "VDIVPD256", argLength: 2, reg: fp21, asm: "VDIVPD"},                                                                                // arg0 / arg1, float64 lanes
+		{name: "VPAND256", argLength: 2, reg: fp21, asm: "VPAND", commutative: true},                                                               // arg0 & arg1
+		{name: "VPOR256", argLength: 2, reg: fp21, asm: "VPOR", commutative: true},                                                                 // arg0 | arg1
+		{name: "VPXOR256", argLength: 2, reg: fp21, asm: "VPXOR", commutative: true},                                                               // arg0 ^ arg1
+		{name: "VPANDN256", argLength: 2, reg: fp21, asm: "VPANDN"},                                                                                // ^arg0 & arg1
+		{name: "VSQRTPS256", argLength: 1, reg: fp11, asm: "VSQRTPS"},                                                                              // sqrt(arg0), float32 lanes
+		{name: "VSQRTPD256", argLength: 1, reg: fp11, asm: "VSQRTPD"},                                                                              // sqrt(arg0), float64 lanes
+		{name: "VPMOVMSKB256", argLength: 1, reg: fpgp, asm: "VPMOVMSKB", typ: "UInt32"},                                                           // bit i is the sign bit of byte i of arg0
+		{name: "VPXOR256zero", reg: fp01, asm: "VPXOR", rematerializeable: true},                                                                   // the zero vector
+		{name: "VMOVDQUload256", argLength: 2, reg: fpload, asm: "VMOVDQU", aux: "SymOff", faultOnNilArg0: true, symEffect: "Read"},                // load 32 bytes from arg0+auxint+aux. arg1=mem
+		{name: "VMOVDQUstore256", argLength: 3, reg: fpstore, asm: "VMOVDQU", aux: "SymOff", typ: "Mem", faultOnNilArg0: true, symEffect: "Write"}, // store 32 bytes in arg1 to arg0+auxint+aux. arg2=mem
+
 		// CPUID feature: MOVBE
 		// MOVBEWload does not satisfy zero extended, so only use MOVBEWstore
 		{name: "MOVBEWstore", argLength: 3, reg: gpstore, asm: "MOVBEW", aux: "SymOff", typ: "Mem", faultOnNilArg0: true, symEffect: "Write"}, // swap and store 2 bytes in arg1 to arg0+auxint+aux. arg2=mem
@@ -135,6 +135,9 @@ func init() {
 		r1         = buildReg("R1")
 		r2         = buildReg("R2")
 		r3         = buildReg("R3")
+		// Vectors are kept in V0-V15 only, so that asyncPreempt saves
+		// the full 128 bits of just those registers.
+		vec = buildReg("F0 F1 F2 F3 F4 F5 F6 F7 F8 F9 F10 F11 F12 F13 F14 F15")
 	)
 	// Common regInfo
 	var (
@@ -174,6 +177,12 @@ func init() {
 		fpstore2       = regInfo{inputs: []regMask{gpspsbg, gpg, fp}}
 		readflags      = regInfo{inputs: nil, outputs: []regMask{gp}}
 		prefreg        = regInfo{inputs: []regMask{gpspsbg}}
+		vec01          = regInfo{inputs: nil, outputs: []regMask{vec}}
+		vec11          = regInfo{inputs: []regMask{vec}, outputs: []regMask{vec}}
+		vec21          = regInfo{inputs: []regMask{vec, vec}, outputs: []regMask{vec}}
+		vec31          = regInfo{inputs: []regMask{vec, vec, vec}, outputs: []regMask{vec}}
+		vecload        = regInfo{inputs: []regMask{gpspsbg}, outputs: []regMask{vec}}
+		vecstore       = regInfo{inputs: []regMask{gpspsbg, vec}}
 	)
 	ops := []opData{
 		// binary ops
@@ -379,6 +388,7 @@ func init() {
 		{name: "LDP", argLength: 2, reg: gpload2, aux: "SymOff", asm: "LDP", typ: "(UInt64,UInt64)", faultOnNilArg0: true, symEffect: "Read"}, // load from ptr = arg0 + auxInt + aux, returns the tuple <*(*uint64)ptr, *(*uint64)(ptr+8)>. arg1=mem.
 		{name: "FMOVSload", argLength: 2, reg: fpload, aux: "SymOff", asm: "FMOVS", typ: "Float32", faultOnNilArg0: true, symEffect: "Read"},  // load from arg0 + auxInt + aux.  arg1=mem.
 		{name: "FMOVDload", argLength: 2, reg: fpload, aux: "SymOff", asm: "FMOVD", typ: "Float64", faultOnNilArg0: true, symEffect: "Read"},  // load from arg0 + auxInt + aux.  arg1=mem.
+		{name: "FMOVQload", argLength: 2, reg: vecload, aux: "SymOff", asm: "FMOVQ", faultOnNilArg0: true, symEffect: "Read"},                 // load 16 bytes from arg0 + auxInt + aux.  arg1=mem.
 
 		// register indexed load
 		{name: "MOVDloadidx", argLength: 3, reg: gp2load, asm: "MOVD", typ: "UInt64"},    // load 64-bit dword from arg0 + arg1, arg2 = mem.
@@ -400,13 +410,14 @@ func init() {
 		{name: "FMOVSloadidx4", argLength: 3, reg: fp2load, asm: "FMOVS", typ: "Float32"}, // load 32-bit float from arg0 + arg1*4, arg2 = mem.
 		{name: "FMOVDloadidx8", argLength: 3, reg: fp2load, asm: "FMOVD", typ: "Float64"}, // load 64-bit float from arg0 + arg1*8, arg2 = mem.
 
-		{name: "MOVBstore", argLength: 3, reg: gpstore, aux: "SymOff", asm: "MOVB", typ: "Mem", faultOnNilArg0: true, symEffect: "Write"},   // store 1 byte of arg1 to arg0 + auxInt + aux.  arg2=mem.
-		{name: "MOVHstore", argLength: 3, reg: gpstore, aux: "SymOff", asm: "MOVH", typ: "Mem", faultOnNilArg0: true, symEffect: "Write"},   // store 2 bytes of arg1 to arg0 + auxInt + aux.  arg2=mem.
-		{name: "MOVWstore", argLength: 3, reg: gpstore, aux: "SymOff", asm: "MOVW", typ: "Mem", faultOnNilArg0: true, symEffect: "Write"},   // store 4 bytes of arg1 to arg0 + auxInt + aux.  arg2=mem.
-		{name: "MOVDstore", argLength: 3, reg: gpstore, aux: "SymOff", asm: "MOVD", typ: "Mem", faultOnNilArg0: true, symEffect: "Write"},   // store 8 bytes of arg1 to arg0 + auxInt + aux.  arg2=mem.
-		{name: "STP", argLength: 4, reg: gpstore2, aux: "SymOff", asm: "STP", typ: "Mem", faultOnNilArg0: true, symEffect: "Write"},         // store 16 bytes of arg1 and arg2 to arg0 + auxInt + aux.  arg3=mem.
-		{name: "FMOVSstore", argLength: 3, reg: fpstore, aux: "SymOff", asm: "FMOVS", typ: "Mem", faultOnNilArg0: true, symEffect: "Write"}, // store 4 bytes of arg1 to arg0 + auxInt + aux.  arg2=mem.
-		{name: "FMOVDstore", argLength: 3, reg: fpstore, aux: "SymOff", asm: "FMOVD", typ: "Mem", faultOnNilArg0: true, symEffect: "Write"}, // store 8 bytes of arg1 to arg0 + auxInt + aux.  arg2=mem.
+		{name: "MOVBstore", argLength: 3, reg: gpstore, aux: "SymOff", asm: "MOVB", typ: "Mem", faultOnNilArg0: true, symEffect: "Write"},    // store 1 byte of arg1 to arg0 + auxInt + aux.  arg2=mem.
+		{name: "MOVHstore", argLength: 3, reg: gpstore, aux: "SymOff", asm: "MOVH", typ: "Mem", faultOnNilArg0: true, symEffect: "Write"},    // store 2 bytes of arg1 to arg0 + auxInt + aux.  arg2=mem.
+		{name: "MOVWstore", argLength: 3, reg: gpstore, aux: "SymOff", asm: "MOVW", typ: "Mem", faultOnNilArg0: true, symEffect: "Write"},    // store 4 bytes of arg1 to arg0 + auxInt + aux.  arg2=mem.
+		{name: "MOVDstore", argLength: 3, reg: gpstore, aux: "SymOff", asm: "MOVD", typ: "Mem", faultOnNilArg0: true, symEffect: "Write"},    // store 8 bytes of arg1 to arg0 + auxInt + aux.  arg2=mem.
+		{name: "STP", argLength: 4, reg: gpstore2, aux: "SymOff", asm: "STP", typ: "Mem", faultOnNilArg0: true, symEffect: "Write"},          // store 16 bytes of arg1 and arg2 to arg0 + auxInt + aux.  arg3=mem.
+		{name: "FMOVSstore", argLength: 3, reg: fpstore, aux: "SymOff", asm: "FMOVS", typ: "Mem", faultOnNilArg0: true, symEffect: "Write"},  // store 4 bytes of arg1 to arg0 + auxInt + aux.  arg2=mem.
+		{name: "FMOVDstore", argLength: 3, reg: fpstore, aux: "SymOff", asm: "FMOVD", typ: "Mem", faultOnNilArg0: true, symEffect: "Write"},  // store 8 bytes of arg1 to arg0 + auxInt + aux.  arg2=mem.
+		{name: "FMOVQstore", argLength: 3, reg: vecstore, aux: "SymOff", asm: "FMOVQ", typ: "Mem", faultOnNilArg0: true, symEffect: "Write"}, // store 16 bytes of arg1 to arg0 + auxInt + aux.  arg2=mem.
 
 		// register indexed store
 		{name: "MOVBstoreidx", argLength: 4, reg: gpstore2, asm: "MOVB", typ: "Mem"},   // store 1 byte of arg2 to arg0 + arg1, arg3 = mem.
@@ -751,6 +762,41 @@ func init() {
 
 		// Publication barrier
 		{name: "DMB", argLength: 1, aux: "Int64", asm: "DMB", hasSideEffects: true}, // Do data barrier. arg0=memory, aux=option.
+
+		// Vector operations, used by package simd. They work on 128-bit
+		// values in V registers, the F registers seen as vectors of lanes.
+		{name: "VADDB16", argLength: 2, reg: vec21, asm: "VADD", commutative: true},        // arg0 + arg1, lanes B16
+		{name: "VADDS4", argLength: 2, reg: vec21, asm: "VADD", commutative: true},         // arg0 + arg1, lanes S4
+		{name: "VADDD2", argLength: 2, reg: vec21, asm: "VADD", commutative: true},         // arg0 + arg1, lanes D2
+		{name: "VSUBB16", argLength: 2, reg: vec21, asm: "VSUB"},                           // arg0 - arg1, lanes B16
+		{name: "VSUBS4", argLength: 2, reg: vec21, asm: "VSUB"},                            // arg0 - arg1, lanes S4
+		{name: "VSUBD2", argLength: 2, reg: vec21, asm: "VSUB"},                            // arg0 - arg1, lanes D2
+		{name: "VMULS4", argLength: 2, reg: vec21, asm: "VMUL", commutative: true},         // arg0 * arg1, lanes S4
+		{name: "VUMINB16", argLength: 2, reg: vec21, asm: "VUMIN", commutative: true},      // min(arg0, arg1), unsigned, lanes B16
+		{name: "VUMAXB16", argLength: 2, reg: vec21, asm: "VUMAX", commutative: true},      // max(arg0, arg1), unsigned, lanes B16
+		{name: "VSMINS4", argLength: 2, reg: vec21, asm: "VSMIN", commutative: true},       // min(arg0, arg1), signed, lanes S4
+		{name: "VSMAXS4", argLength: 2, reg: vec21, asm: "VSMAX", commutative: true},       // max(arg0, arg1), signed, lanes S4
+		{name: "VCMEQB16", argLength: 2, reg: vec21, asm: "VCMEQ", commutative: true},      // arg0 == arg1 ? -1 : 0, lanes B16
+		{name: "VCMEQS4", argLength: 2, reg: vec21, asm: "VCMEQ", commutative: true},       // arg0 == arg1 ? -1 : 0, lanes S4
+		{name: "VCMEQD2", argLength: 2, reg: vec21, asm: "VCMEQ", commutative: true},       // arg0 == arg1 ? -1 : 0, lanes D2
+		{name: "VCMGTS4", argLength: 2, reg: vec21, asm: "VCMGT"},                          // arg0 > arg1 ? -1 : 0, signed, lanes S4
+		{name: "VCMGTD2", argLength: 2, reg: vec21, asm: "VCMGT"},                          // arg0 > arg1 ? -1 : 0, signed, lanes D2
+		{name: "VFADDS4", argLength: 2, reg: vec21, asm: "VFADD", commutative: true},       // arg0 + arg1, lanes S4
+		{name: "VFADDD2", argLength: 2, reg: vec21, asm: "VFADD", commutative: true},       // arg0 + arg1, lanes D2
+		{name: "VFSUBS4", argLength: 2, reg: vec21, asm: "VFSUB"},                          // arg0 - arg1, lanes S4
+		{name: "VFSUBD2", argLength: 2, reg: vec21, asm: "VFSUB"},                          // arg0 - arg1, lanes D2
+		{name: "VFMULS4", argLength: 2, reg: vec21, asm: "VFMUL", commutative: true},       // arg0 * arg1, lanes S4
+		{name: "VFMULD2", argLength: 2, reg: vec21, asm: "VFMUL", commutative: true},       // arg0 * arg1, lanes D2
+		{name: "VFDIVS4", argLength: 2, reg: vec21, asm: "VFDIV"},                          // arg0 / arg1, lanes S4
+		{name: "VFDIVD2", argLength: 2, reg: vec21, asm: "VFDIV"},                          // arg0 / arg1, lanes D2
+		{name: "VFSQRTS4", argLength: 1, reg: vec11, asm: "VFSQRT"},                        // sqrt(arg0), lanes S4
+		{name: "VFSQRTD2", argLength: 1, reg: vec11, asm: "VFSQRT"},                        // sqrt(arg0), lanes D2
+		{name: "VAND", argLength: 2, reg: vec21, asm: "VAND", commutative: true},           // arg0 & arg1
+		{name: "VORR", argLength: 2, reg: vec21, asm: "VORR", commutative: true},           // arg0 | arg1
+		{name: "VEOR", argLength: 2, reg: vec21, asm: "VEOR", commutative: true},           // arg0 ^ arg1
+		{name: "VBIC", argLength: 2, reg: vec21, asm: "VBIC"},                              // arg0 &^ arg1
+		{name: "VBSL", argLength: 3, reg: vec31, asm: "VBSL", resultInArg0: true},          // arg0 ? arg1 : arg2, bitwise
+		{name: "VEORzero", argLength: 0, reg: vec01, asm: "VEOR", rematerializeable: true}, // the zero vector
 	}
 
 	blocks := []blockData{
@@ -797,6 +843,7 @@ func init() {
 		ParamFloatRegNames: "F0 F1 F2 F3 F4 F5 F6 F7 F8 F9 F10 F11 F12 F13 F14 F15",
 		gpregmask:          gp,
 		fpregmask:          fp,
+		vecregmask:         vec,
 		framepointerreg:    -1, // not used
 		linkreg:            int8(num["R30"]),
 	})
@@ -639,6 +639,52 @@ var genericOps = []opData{
 	// Prefetch instruction
 	{name: "PrefetchCache", argLength: 2, hasSideEffects: true},         // Do prefetch arg0 to cache. arg0=addr, arg1=memory.
 	{name: "PrefetchCacheStreamed", argLength: 2, hasSideEffects: true}, // Do non-temporal or streamed prefetch arg0 to cache. arg0=addr, arg1=memory.
+
+	// Vector operations, used by package simd. The values are the 256-bit
+	// vector types of package simd, or, where the vector registers are
+	// 128 bits wide, Vec128 halves of them, which the dec rules produce.
+	// Operations are lane-wise, on lanes of the given size: 8, 32 or 64
+	// bits for integers, and F32 or F64 for floating point. Comparisons
+	// set a lane to all ones if true and to zero if false.
+	{name: "VecAdd8", argLength: 2, commutative: true},   // arg0 + arg1
+	{name: "VecAdd32", argLength: 2, commutative: true},  // arg0 + arg1
+	{name: "VecAdd64", argLength: 2, commutative: true},  // arg0 + arg1
+	{name: "VecAddF32", argLength: 2, commutative: true}, // arg0 + arg1
+	{name: "VecAddF64", argLength: 2, commutative: true}, // arg0 + arg1
+	{name: "VecSub8", argLength: 2},                      // arg0 - arg1
+	{name: "VecSub32", argLength: 2},                     // arg0 - arg1
+	{name: "VecSub64", argLength: 2},                     // arg0 - arg1
+	{name: "VecSubF32", argLength: 2},                    // arg0 - arg1
+	{name: "VecSubF64", argLength: 2},                    // arg0 - arg1
+	{name: "VecMul32", argLength: 2, commutative: true},  // arg0 * arg1
+	{name: "VecMul64", argLength: 2, commutative: true},  // arg0 * arg1
+	{name: "VecMulF32", argLength: 2, commutative: true}, // arg0 * arg1
+	{name: "VecMulF64", argLength: 2, commutative: true}, // arg0 * arg1
+	{name: "VecDivF32", argLength: 2},                    // arg0 / arg1
+	{name: "VecDivF64", argLength: 2},                    // arg0 / arg1
+	{name: "VecMinU8", argLength: 2, commutative: true},  // min(arg0, arg1), unsigned
+	{name: "VecMaxU8", argLength: 2, commutative: true},  // max(arg0, arg1), unsigned
+	{name: "VecMin32", argLength: 2, commutative: true},  // min(arg0, arg1), signed
+	{name: "VecMax32", argLength: 2, commutative: true},  // max(arg0, arg1), signed
+	{name: "VecMin64", argLength: 2, commutative: true},  // min(arg0, arg1), signed
+	{name: "VecMax64", argLength: 2, commutative: true},  // max(arg0, arg1), signed
+	{name: "VecEq8", argLength: 2, commutative: true},    // arg0 == arg1
+	{name: "VecEq32", argLength: 2, commutative: true},   // arg0 == arg1
+	{name: "VecEq64", argLength: 2, commutative: true},   // arg0 == arg1
+	{name: "VecGt32", argLength: 2},                      // arg0 > arg1, signed
+	{name: "VecGt64", argLength: 2},                      // arg0 > arg1, signed
+	{name: "VecAnd", argLength: 2, commutative: true},    // arg0 & arg1
+	{name: "VecOr", argLength: 2, commutative: true},     // arg0 | arg1
+	{name: "VecXor", argLength: 2, commutative: true},    // arg0 ^ arg1
+	{name: "VecAndNot", argLength: 2},                    // arg0 &^ arg1
+	{name: "VecSqrtF32", argLength: 1},                   // sqrt(arg0)
+	{name: "VecSqrtF64", argLength: 1},                   // sqrt(arg0)
+	{name: "VecSignBits8", argLength: 1, typ: "UInt32"},  // bit i is the sign bit of byte i of arg0
+	{name: "VecZero"}, // the zero vector
+
+	{name: "Vec256Make", argLength: 2},              // arg0=low 128 bits, arg1=high 128 bits
+	{name: "Vec256Lo", argLength: 1, typ: "Vec128"}, // low 128 bits of arg0
+	{name: "Vec256Hi", argLength: 1, typ: "Vec128"}, // high 128 bits of arg0
 }
 
 //     kind          controls        successors   implicit exit
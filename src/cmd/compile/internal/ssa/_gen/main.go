@@ -40,6 +40,7 @@ type arch struct {
 	fpregmask          regMask
 	fp32regmask        regMask
 	fp64regmask        regMask
+	vecregmask         regMask // registers for vector values, if a subset of fpregmask
 	specialregmask     regMask
 	framepointerreg    int8
 	linkreg            int8
@@ -489,6 +490,9 @@ func genOp() {
 		if a.fp64regmask != 0 {
 			fmt.Fprintf(w, "var fp64RegMask%s = regMask(%d)\n", a.name, a.fp64regmask)
 		}
+		if a.vecregmask != 0 {
+			fmt.Fprintf(w, "var vecRegMask%s = regMask(%d)\n", a.name, a.vecregmask)
+		}
 		fmt.Fprintf(w, "var specialRegMask%s = regMask(%d)\n", a.name, a.specialregmask)
 		fmt.Fprintf(w, "var framepointerReg%s = int8(%d)\n", a.name, a.framepointerreg)
 		fmt.Fprintf(w, "var linkReg%s = int8(%d)\n", a.name, a.linkreg)
@@ -1483,7 +1483,7 @@ func typeName(typ string) string {
 		return "types.NewTuple(" + typeName(ts[0]) + ", " + typeName(ts[1]) + ")"
 	}
 	switch typ {
-	case "Flags", "Mem", "Void", "Int128":
+	case "Flags", "Mem", "Void", "Int128", "Vec128":
 		return "types.Type" + typ
 	default:
 		return "typ." + typ
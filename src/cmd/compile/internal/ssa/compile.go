@@ -481,6 +481,8 @@ var passes = [...]pass{
 	{name: "branchelim", fn: branchelim},
 	{name: "late fuse", fn: fuseLate},
 	{name: "dse", fn: dse},
+	{name: "unroll", fn: unroll}, // unroll small counted loops
+	{name: "licm", fn: licm},     // hoist loop-invariant values out of loops
 	{name: "memcombine", fn: memcombine},
 	{name: "writebarrier", fn: writebarrier, required: true}, // expand write barrier ops
 	{name: "insert resched checks", fn: insertLoopReschedChecks,
@@ -500,13 +502,15 @@ var passes = [...]pass{
 	{name: "critical", fn: critical, required: true}, // remove critical edges
 	{name: "phi tighten", fn: phiTighten},            // place rematerializable phi args near uses to reduce value lifetimes
 	{name: "likelyadjust", fn: likelyadjust},
+	{name: "pgolikely", fn: pgolikely},               // override branch predictions with the PGO profile
 	{name: "layout", fn: layout, required: true},     // schedule blocks
 	{name: "schedule", fn: schedule, required: true}, // schedule values
 	{name: "late nilcheck", fn: nilcheckelim2},
 	{name: "flagalloc", fn: flagalloc, required: true}, // allocate flags register
 	{name: "regalloc", fn: regalloc, required: true},   // allocate int & float registers + stack slots
 	{name: "loop rotate", fn: loopRotate},
-	{name: "trim", fn: trim}, // remove empty blocks
+	{name: "trim", fn: trim},         // remove empty blocks
+	{name: "pgosplit", fn: pgosplit}, // move cold blocks to the end for splitting
 }
 
 // Double-check phase ordering constraints.
@@ -578,6 +582,21 @@ var passOrder = [...]constraint{
 	{"regalloc", "loop rotate"},
 	// trim needs regalloc to be done first.
 	{"regalloc", "trim"},
+	// licm works best once redundant values and nil checks are gone.
+	{"generic cse", "licm"},
+	{"nilcheckelim", "licm"},
+	// licm hoists generic values; tighten must not sink them back.
+	{"licm", "tighten"},
+	// unroll needs bounds checks removed and the loop body fused into a
+	// single block, and licm then hoists invariant values out of the copies.
+	{"prove", "unroll"},
+	{"late fuse", "unroll"},
+	{"unroll", "licm"},
+	// pgolikely overrides the predictions of likelyadjust, which layout follows.
+	{"likelyadjust", "pgolikely"},
+	{"pgolikely", "layout"},
+	// pgosplit must see the final block order.
+	{"trim", "pgosplit"},
 	// memcombine works better if fuse happens first, to help merge stores.
 	{"late fuse", "memcombine"},
 	// memcombine is a arch-independent pass.
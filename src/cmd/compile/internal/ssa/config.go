@@ -32,6 +32,7 @@ type Config struct {
 	fpRegMask      regMask        // floating point register mask
 	fp32RegMask    regMask        // floating point register mask
 	fp64RegMask    regMask        // floating point register mask
+	vecRegMask     regMask        // vector register mask, if not all floating point registers
 	specialRegMask regMask        // special register mask
 	intParamRegs   []int8         // register numbers of integer param (in/out) registers
 	floatParamRegs []int8         // register numbers of floating param (in/out) registers
@@ -224,6 +225,7 @@ func NewConfig(arch string, types Types, ctxt *obj.Link, optimize, softfloat boo
 		c.registers = registersARM64[:]
 		c.gpRegMask = gpRegMaskARM64
 		c.fpRegMask = fpRegMaskARM64
+		c.vecRegMask = vecRegMaskARM64
 		c.intParamRegs = paramIntRegARM64
 		c.floatParamRegs = paramFloatRegARM64
 		c.FPReg = framepointerRegARM64
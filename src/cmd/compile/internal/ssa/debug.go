@@ -15,6 +15,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"internal/buildcfg"
+	"math"
 	"math/bits"
 	"sort"
 	"strings"
@@ -1502,6 +1503,25 @@ func (state *debugState) writePendingEntry(varID VarID, endBlock, endValue ID) {
 
 // PutLocationList adds list (a location list in its intermediate representation) to listSym.
 func (debugInfo *FuncDebug) PutLocationList(list []byte, ctxt *obj.Link, listSym, startPC *obj.LSym) {
+	// If the function has a cold fragment, GetPC returns the PCs in it
+	// as if it followed the function. Its entries come after those of
+	// the function, relative to the fragment.
+	if cold := startPC.Func().Cold; cold != nil {
+		debugInfo.putLocationEntries(list, ctxt, listSym, startPC, 0, startPC.Size)
+		debugInfo.putLocationEntries(list, ctxt, listSym, cold, startPC.Size, math.MaxInt64)
+	} else {
+		debugInfo.putLocationEntries(list, ctxt, listSym, startPC, 0, math.MaxInt64)
+	}
+
+	// Location list contents, now with real PCs.
+	// End entry.
+	listSym.WriteInt(ctxt, listSym.Size, ctxt.Arch.PtrSize, 0)
+	listSym.WriteInt(ctxt, listSym.Size, ctxt.Arch.PtrSize, 0)
+}
+
+// putLocationEntries writes the entries of list for the PCs in [lo, hi),
+// relative to startPC, which is at lo.
+func (debugInfo *FuncDebug) putLocationEntries(list []byte, ctxt *obj.Link, listSym, startPC *obj.LSym, lo, hi int64) {
 	getPC := debugInfo.GetPC
 
 	if ctxt.UseBASEntries {
@@ -1522,6 +1542,15 @@ func (debugInfo *FuncDebug) PutLocationList(list []byte, ctxt *obj.Link, listSym
 			end = 1
 		}
 
+		i += 2 * ctxt.Arch.PtrSize
+		datalen := 2 + int(ctxt.Arch.ByteOrder.Uint16(list[i:]))
+		data := list[i : i+datalen] // datalen and location encoding
+		i += datalen
+
+		if end <= lo || begin >= hi {
+			continue
+		}
+		begin, end = max(begin, lo)-lo, min(end, hi)-lo
 		if ctxt.UseBASEntries {
 			listSym.WriteInt(ctxt, listSym.Size, ctxt.Arch.PtrSize, int64(begin))
 			listSym.WriteInt(ctxt, listSym.Size, ctxt.Arch.PtrSize, int64(end))
@@ -1529,17 +1558,8 @@ func (debugInfo *FuncDebug) PutLocationList(list []byte, ctxt *obj.Link, listSym
 			listSym.WriteCURelativeAddr(ctxt, listSym.Size, startPC, int64(begin))
 			listSym.WriteCURelativeAddr(ctxt, listSym.Size, startPC, int64(end))
 		}
-
-		i += 2 * ctxt.Arch.PtrSize
-		datalen := 2 + int(ctxt.Arch.ByteOrder.Uint16(list[i:]))
-		listSym.WriteBytes(ctxt, listSym.Size, list[i:i+datalen]) // copy datalen and location encoding
-		i += datalen
+		listSym.WriteBytes(ctxt, listSym.Size, data)
 	}
-
-	// Location list contents, now with real PCs.
-	// End entry.
-	listSym.WriteInt(ctxt, listSym.Size, ctxt.Arch.PtrSize, 0)
-	listSym.WriteInt(ctxt, listSym.Size, ctxt.Arch.PtrSize, 0)
 }
 
 // Pack a value and block ID into an address-sized uint, returning
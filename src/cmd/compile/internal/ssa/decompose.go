@@ -97,8 +97,16 @@ func decomposeBuiltIn(f *Func) {
 				f.NamedValues[*dataName] = append(f.NamedValues[*dataName], v.Args[1])
 				toDelete = append(toDelete, namedVal{i, j})
 			}
-		case t.IsFloat():
-			// floats are never decomposed, even ones bigger than RegSize
+		case t.IsSIMD() && t.Size() > int64(types.SIMDRegSize):
+			// The halves have no Go type to describe them
+			// with, so the vector loses its name.
+			for j, v := range f.NamedValues[*name] {
+				if v.Op == OpVec256Make {
+					toDelete = append(toDelete, namedVal{i, j})
+				}
+			}
+		case t.IsFloat(), t.IsSIMD(), t == types.TypeVec128:
+			// floats and vectors that fit in a register are not decomposed
 		case t.Size() > f.Config.RegSize:
 			f.Fatalf("undecomposed named type %s %v", name, t)
 		}
@@ -132,8 +140,10 @@ func decomposeBuiltInPhi(v *Value) {
 		decomposeSlicePhi(v)
 	case v.Type.IsInterface():
 		decomposeInterfacePhi(v)
-	case v.Type.IsFloat():
-		// floats are never decomposed, even ones bigger than RegSize
+	case v.Type.IsSIMD() && v.Type.Size() > int64(types.SIMDRegSize):
+		decomposeVec256Phi(v)
+	case v.Type.IsFloat(), v.Type.IsSIMD(), v.Type == types.TypeVec128:
+		// floats and vectors that fit in a register are not decomposed
 	case v.Type.Size() > v.Block.Func.Config.RegSize:
 		v.Fatalf("%v undecomposed type %v", v, v.Type)
 	}
@@ -174,6 +184,18 @@ func decomposeSlicePhi(v *Value) {
 	v.AddArg(cap)
 }
 
+func decomposeVec256Phi(v *Value) {
+	lo := v.Block.NewValue0(v.Pos, OpPhi, types.TypeVec128)
+	hi := v.Block.NewValue0(v.Pos, OpPhi, types.TypeVec128)
+	for _, a := range v.Args {
+		lo.AddArg(a.Block.NewValue1(v.Pos, OpVec256Lo, types.TypeVec128, a))
+		hi.AddArg(a.Block.NewValue1(v.Pos, OpVec256Hi, types.TypeVec128, a))
+	}
+	v.reset(OpVec256Make)
+	v.AddArg(lo)
+	v.AddArg(hi)
+}
+
 func decomposeInt64Phi(v *Value) {
 	cfgtypes := &v.Block.Func.Config.Types
 	var partType *types.Type
@@ -249,7 +271,7 @@ func decomposeUser(f *Func) {
 		switch {
 		case t.IsStruct():
 			newNames = decomposeUserStructInto(f, name, newNames)
-		case t.IsArray():
+		case t.IsArray() && !t.IsSIMD():
 			newNames = decomposeUserArrayInto(f, name, newNames)
 		default:
 			f.Names[i] = name
@@ -353,7 +375,7 @@ func decomposeUserPhi(v *Value) {
 	switch {
 	case v.Type.IsStruct():
 		decomposeStructPhi(v)
-	case v.Type.IsArray():
+	case v.Type.IsArray() && !v.Type.IsSIMD():
 		decomposeArrayPhi(v)
 	}
 }
@@ -390,6 +390,12 @@ func (x *expandState) decomposeAsNecessary(pos src.XPos, b *Block, a, m0 *Value,
 	mem := m0
 	switch at.Kind() {
 	case types.TARRAY:
+		if at.IsSIMD() {
+			if at.Size() > int64(types.SIMDRegSize) {
+				return x.decomposePair(pos, b, a, mem, types.TypeVec128, types.TypeVec128, OpVec256Lo, OpVec256Hi, &rc)
+			}
+			break
+		}
 		et := at.Elem()
 		for i := int64(0); i < at.NumElem(); i++ {
 			e := b.NewValue1I(pos, OpArraySelect, et, i, a)
@@ -536,6 +542,17 @@ func (x *expandState) rewriteSelectOrArg(pos src.XPos, b *Block, container, a, m
 
 	switch at.Kind() {
 	case types.TARRAY:
+		if at.IsSIMD() {
+			if at.Size() > int64(types.SIMDRegSize) {
+				addArg(x.rewriteSelectOrArg(pos, b, container, nil, m0, types.TypeVec128, rc.next(types.TypeVec128)))
+				pos = pos.WithNotStmt()
+				addArg(x.rewriteSelectOrArg(pos, b, container, nil, m0, types.TypeVec128, rc.next(types.TypeVec128)))
+				a = makeOf(a, OpVec256Make, args)
+				x.commonSelectors[sk] = a
+				return a
+			}
+			break
+		}
 		et := at.Elem()
 		for i := int64(0); i < at.NumElem(); i++ {
 			e := x.rewriteSelectOrArg(pos, b, container, nil, m0, et, rc.next(et))
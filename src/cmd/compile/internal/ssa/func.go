@@ -44,8 +44,20 @@ type Func struct {
 	scheduled   bool  // Values in Blocks are in final order
 	laidout     bool  // Blocks are ordered
 	NoSplit     bool  // true if function is marked as nosplit.  Used by schedule check pass.
+	PGOHot      bool  // true if the PGO profile shows the function to be hot. Used by the loop unrolling pass.
 	dumpFileSeq uint8 // the sequence numbers of dump file. (%s_%02d__%s.dump", funcname, dumpFileSeq, phaseName)
 
+	// PGOCallWeights holds the weights of the function's call sites in the
+	// PGO profile, by the relative line (see src.Pos.RelLine) of the call.
+	// Values inlined at a call site have the outermost position of the call.
+	// Used by the pgolikely pass.
+	PGOCallWeights map[uint]int64
+
+	// ColdStart is the index in Blocks of the first block to emit in the
+	// function's cold fragment, or 0 if the function is not split.
+	// Set by the pgosplit pass.
+	ColdStart int
+
 	// when register allocation is done, maps value ids to locations
 	RegAlloc []Location
 
@@ -0,0 +1,192 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ssa
+
+import (
+	"cmd/internal/src"
+	"slices"
+)
+
+// licm hoists loop-invariant values out of loops.
+//
+// A value in a loop is invariant if each of its arguments is defined
+// outside the loop. An invariant value is moved to the loop's preheader,
+// the block through which the loop is entered, if doing so can't change
+// the program's behavior even when the loop runs zero times:
+//
+//   - The value must not have side effects, and it must not be able to
+//     fault. So calls, nil checks, and integer divisions stay in place.
+//   - A load must read the memory state in which the loop is entered,
+//     which is the case only if nothing in the loop writes memory,
+//     and its address must be known to be non-nil, because the nil
+//     check guarding it might otherwise be skipped or run too late.
+//   - An address computed from a pointer must be valid wherever it is
+//     live, since the garbage collector may see it. So the address of a
+//     field is hoisted only if its base is known to be non-nil, and the
+//     address of an indexed element, which is bounds checked in the
+//     loop, stays in place.
+//
+// Loops are processed from the innermost out, so that a value can be
+// hoisted out of a whole loop nest.
+func licm(f *Func) {
+	ln := f.loopnest()
+	if ln.hasIrreducible || len(ln.loops) == 0 {
+		return
+	}
+	ln.calculateDepths()
+
+	startMem := f.Cache.allocValueSlice(f.NumBlocks())
+	defer f.Cache.freeValueSlice(startMem)
+	endMem := f.Cache.allocValueSlice(f.NumBlocks())
+	defer f.Cache.freeValueSlice(endMem)
+	memState(f, startMem, endMem)
+
+	loops := slices.Clone(ln.loops)
+	slices.SortStableFunc(loops, func(a, b *loop) int {
+		return int(b.depth) - int(a.depth) // innermost first
+	})
+
+	po := f.postorder()
+	var blocks []*Block
+	for _, l := range loops {
+		pre := l.preheader(ln.b2l)
+		if pre == nil {
+			continue
+		}
+		// Collect the loop's blocks in reverse postorder,
+		// so that a value is visited after its arguments.
+		blocks = blocks[:0]
+		for i := len(po) - 1; i >= 0; i-- {
+			if b := po[i]; ln.b2l[b.ID].isWithinOrEq(l) {
+				blocks = append(blocks, b)
+			}
+		}
+		inLoop := func(v *Value) bool {
+			return ln.b2l[v.Block.ID].isWithinOrEq(l)
+		}
+
+		for changed := true; changed; {
+			changed = false
+			for _, b := range blocks {
+				for i := 0; i < len(b.Values); i++ {
+					v := b.Values[i]
+					if !canHoist(v, startMem[l.header.ID]) || slices.ContainsFunc(v.Args, inLoop) {
+						continue
+					}
+					if f.pass.debug > 0 {
+						f.Warnl(v.Pos, "hoisted %v out of loop", v.Op)
+					}
+					last := len(b.Values) - 1
+					b.Values[i] = b.Values[last]
+					b.Values[last] = nil
+					b.Values = b.Values[:last]
+					if v.Pos.IsStmt() == src.PosIsStmt && moveStmt(v, b) {
+						// The value no longer runs each time around the loop,
+						// so it is a poor place for the statement boundary.
+						v.Pos = v.Pos.WithNotStmt()
+					}
+					pre.Values = append(pre.Values, v)
+					v.Block = pre
+					i--
+					changed = true
+				}
+			}
+		}
+	}
+}
+
+// preheader returns the block from which loop l is entered, if there is
+// a unique such block and it leads only to the loop's header.
+// Otherwise it returns nil.
+func (l *loop) preheader(b2l []*loop) *Block {
+	var pre *Block
+	for _, e := range l.header.Preds {
+		p := e.b
+		if b2l[p.ID].isWithinOrEq(l) {
+			continue // a back edge
+		}
+		if pre != nil {
+			return nil
+		}
+		pre = p
+	}
+	if pre == nil || pre.Kind != BlockPlain {
+		return nil
+	}
+	return pre
+}
+
+// moveStmt moves the statement boundary of v, which is leaving block b,
+// to another value in b on the same line, or to the end of b, and reports
+// whether it found a place for it.
+func moveStmt(v *Value, b *Block) bool {
+	for _, u := range b.Values {
+		if u.Pos.IsStmt() != src.PosNotStmt && !isPoorStatementOp(u.Op) && u.Pos.SameFileAndLine(v.Pos) {
+			u.Pos = u.Pos.WithIsStmt()
+			return true
+		}
+	}
+	if b.Pos.IsStmt() != src.PosNotStmt && b.Pos.SameFileAndLine(v.Pos) {
+		b.Pos = b.Pos.WithIsStmt()
+		return true
+	}
+	return false
+}
+
+// canHoist reports whether v may be moved out of a loop entered with
+// memory state mem, provided its arguments are defined outside the loop.
+func canHoist(v *Value, mem *Value) bool {
+	switch v.Op {
+	case OpPhi, OpCopy, OpNilCheck, OpSelect0, OpSelect1, OpSelectN:
+		return false
+	case OpAddr, OpLocalAddr:
+		// Addresses of variables are cheaper to rematerialize
+		// than to keep live across the loop.
+		return false
+	case OpDiv8, OpDiv8u, OpDiv16, OpDiv16u, OpDiv32, OpDiv32u, OpDiv64, OpDiv64u, OpDiv128u,
+		OpMod8, OpMod8u, OpMod16, OpMod16u, OpMod32, OpMod32u, OpMod64, OpMod64u:
+		// Integer division may trap.
+		return false
+	case OpOffPtr:
+		// Unless its base is known to be non-nil, the address may be
+		// a bad pointer, such as 0x28, until the nil check in the loop.
+		// The garbage collector may find it live at a call before then.
+		return isNonNilAddr(v.Args[0])
+	case OpAddPtr, OpPtrIndex, OpConvert:
+		// Likewise, the address may be out of bounds of its object
+		// until the bounds check in the loop.
+		return false
+	}
+	if len(v.Args) == 0 {
+		// Constants and the like, which are also cheap to rematerialize.
+		return false
+	}
+	t := v.Type
+	if t.IsMemory() || t.IsVoid() || t.IsTuple() || t.IsResults() || t.IsFlags() || t.IsBoolean() {
+		// Comparisons are left next to the branches that use them.
+		return false
+	}
+	info := &opcodeTable[v.Op]
+	if info.call || info.hasSideEffects || info.nilCheck || info.faultOnNilArg0 || info.faultOnNilArg1 {
+		return false
+	}
+	if m := v.MemoryArg(); m != nil {
+		return v.Op == OpLoad && m == mem && isNonNilAddr(v.Args[0])
+	}
+	return true
+}
+
+// isNonNilAddr reports whether the address ptr is known to be non-nil,
+// and so can be loaded from before it is nil checked.
+func isNonNilAddr(ptr *Value) bool {
+	for ptr.Op == OpOffPtr {
+		ptr = ptr.Args[0]
+	}
+	switch ptr.Op {
+	case OpNilCheck, OpAddr, OpLocalAddr, OpSP, OpSB:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ssa
+
+import (
+	"cmd/compile/internal/types"
+	"testing"
+)
+
+// licmLoop builds a counting loop whose body contains values computed
+// from arguments defined before the loop. If store is set, the loop
+// body also writes memory.
+func licmLoop(c *Conf, store bool) fun {
+	intType := c.config.Types.Int64
+	ptrType := c.config.Types.Int64.PtrTo()
+	body := []interface{}{
+		Valu("mul", OpMul64, intType, 0, nil, "x", "y"),
+		Valu("div", OpDiv64, intType, 0, nil, "x", "y"),
+		Valu("checkedAddr", OpOffPtr, ptrType, 8, nil, "checked"),
+		Valu("checkedLoad", OpLoad, intType, 0, nil, "checkedAddr", "loopmem"),
+		Valu("uncheckedAddr", OpOffPtr, ptrType, 8, nil, "p"),
+		Valu("uncheckedLoad", OpLoad, intType, 0, nil, "uncheckedAddr", "loopmem"),
+		Valu("sum1", OpAdd64, intType, 0, nil, "mul", "checkedLoad"),
+		Valu("sum2", OpAdd64, intType, 0, nil, "div", "uncheckedLoad"),
+		Valu("sum", OpAdd64, intType, 0, nil, "sum1", "sum2"),
+		Valu("inc", OpAdd64, intType, 0, nil, "i", "one"),
+	}
+	loopmem := "mem"
+	if store {
+		body = append(body, Valu("store", OpStore, types.TypeMem, 0, intType, "p", "sum", "loopmem"))
+		loopmem = "store"
+	}
+	body = append(body, Goto("header"))
+
+	return c.Fun("entry",
+		Bloc("entry",
+			Valu("mem", OpInitMem, types.TypeMem, 0, nil),
+			Valu("sp", OpSP, c.config.Types.Uintptr, 0, nil),
+			Valu("x", OpArg, intType, 0, c.Temp(intType)),
+			Valu("y", OpArg, intType, 0, c.Temp(intType)),
+			Valu("p", OpArg, ptrType, 0, c.Temp(ptrType)),
+			Valu("n", OpArg, intType, 0, c.Temp(intType)),
+			Valu("checked", OpNilCheck, ptrType, 0, nil, "p", "mem"),
+			Valu("zero", OpConst64, intType, 0, nil),
+			Valu("one", OpConst64, intType, 1, nil),
+			Goto("header")),
+		Bloc("header",
+			Valu("i", OpPhi, intType, 0, nil, "zero", "inc"),
+			Valu("loopmem", OpPhi, types.TypeMem, 0, nil, "mem", loopmem),
+			Valu("cmp", OpLess64, c.config.Types.Bool, 0, nil, "i", "n"),
+			If("cmp", "body", "exit")),
+		Bloc("body", body...),
+		Bloc("exit",
+			Exit("loopmem")))
+}
+
+func TestLICM(t *testing.T) {
+	c := testConfig(t)
+	fun := licmLoop(c, false)
+	// Without stores in the loop, the memory phi is redundant.
+	copyelim(fun.f)
+	CheckFunc(fun.f)
+	licm(fun.f)
+	CheckFunc(fun.f)
+
+	entry := fun.blocks["entry"]
+	for _, name := range []string{"mul", "checkedAddr", "checkedLoad", "sum1"} {
+		if b := fun.values[name].Block; b != entry {
+			t.Errorf("%s is in %s, want it hoisted to %s", name, b, entry)
+		}
+	}
+	body := fun.blocks["body"]
+	for _, name := range []string{"div", "uncheckedAddr", "uncheckedLoad", "sum2", "sum", "inc"} {
+		if b := fun.values[name].Block; b != body {
+			t.Errorf("%s is in %s, want it left in %s", name, b, body)
+		}
+	}
+}
+
+func TestLICMStore(t *testing.T) {
+	c := testConfig(t)
+	fun := licmLoop(c, true)
+	CheckFunc(fun.f)
+	licm(fun.f)
+	CheckFunc(fun.f)
+
+	entry := fun.blocks["entry"]
+	if b := fun.values["mul"].Block; b != entry {
+		t.Errorf("mul is in %s, want it hoisted to %s", b, entry)
+	}
+	// The loop writes memory, so no load may be hoisted.
+	body := fun.blocks["body"]
+	for _, name := range []string{"checkedLoad", "uncheckedLoad"} {
+		if b := fun.values[name].Block; b != body {
+			t.Errorf("%s is in %s, want it left in %s", name, b, body)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ssa
+
+// pgolikely sets the likely direction of branches using the weights of
+// the function's call sites in the PGO profile.
+//
+// The weight of a block is the largest weight of the call sites in the
+// part of the function that it dominates. A branch is predicted to go
+// to the successor with the larger weight, overriding the static
+// predictions made by likelyadjust, so that layout makes the path
+// to the hottest call sites the fall-through path and moves the
+// rest towards the end of the function.
+//
+// The pgosplit pass then splits the blocks that lead only to panics out
+// of the function.
+func pgolikely(f *Func) {
+	if len(f.PGOCallWeights) == 0 {
+		return
+	}
+
+	weight := f.Cache.allocInt64Slice(f.NumBlocks())
+	defer f.Cache.freeInt64Slice(weight)
+	found := false
+	for _, b := range f.Blocks {
+		for _, v := range b.Values {
+			if v.Pos.IsKnown() {
+				line := f.Config.ctxt.OutermostPos(v.Pos).RelLine()
+				if w := f.PGOCallWeights[line]; w > weight[b.ID] {
+					weight[b.ID] = w
+					found = true
+				}
+			}
+		}
+	}
+	if !found {
+		return
+	}
+
+	// Blocks come after the blocks they dominate in postorder.
+	idom := f.Idom()
+	for _, b := range f.postorder() {
+		if d := idom[b.ID]; d != nil && weight[b.ID] > weight[d.ID] {
+			weight[d.ID] = weight[b.ID]
+		}
+	}
+
+	for _, b := range f.Blocks {
+		if len(b.Succs) != 2 || b.Kind == BlockFirst || b.Kind == BlockDefer {
+			continue
+		}
+		s0, s1 := b.Succs[0].b, b.Succs[1].b
+		// A successor with other predecessors may be reached without b,
+		// so its weight says nothing about the direction of b's branch.
+		w0, w1 := int64(0), int64(0)
+		if len(s0.Preds) == 1 {
+			w0 = weight[s0.ID]
+		}
+		if len(s1.Preds) == 1 {
+			w1 = weight[s1.ID]
+		}
+		prediction := b.Likely
+		switch {
+		case w0 > w1:
+			prediction = BranchLikely
+		case w1 > w0:
+			prediction = BranchUnlikely
+		}
+		if prediction != b.Likely {
+			if f.pass.debug > 0 {
+				dir := "likely"
+				if prediction == BranchUnlikely {
+					dir = "unlikely"
+				}
+				f.Warnl(b.Pos, "branch made %s by profile (weights %d, %d)", dir, w0, w1)
+			}
+			b.Likely = prediction
+		}
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ssa
+
+import (
+	"cmd/compile/internal/base"
+	"internal/buildcfg"
+)
+
+// pgosplit moves the cold blocks of a function that is hot in the PGO
+// profile to the end of its block list, and records in f.ColdStart
+// where they start. The code generator emits them as a separate text
+// symbol, the function's cold fragment, which the linker places after
+// all the hot code.
+//
+// A block is cold if every path from it leads to a BlockExit, that is,
+// if it can only end in a panic or another call that does not return.
+// Control never flows from a cold block back to a hot one, and the
+// fragment runs in the frame of the function until the goroutine
+// unwinds.
+func pgosplit(f *Func) {
+	if !canSplitCold(f) {
+		return
+	}
+
+	cold := f.Cache.allocBoolSlice(f.NumBlocks())
+	defer f.Cache.freeBoolSlice(cold)
+	pinned := f.Cache.allocBoolSlice(f.NumBlocks())
+	defer f.Cache.freeBoolSlice(pinned)
+
+	// Jump tables hold the addresses of their targets relative to the
+	// start of the function, so they must stay in it.
+	pinned[f.Entry.ID] = true
+	for _, b := range f.Blocks {
+		if b.Kind == BlockJumpTable {
+			for _, e := range b.Succs {
+				pinned[e.b.ID] = true
+			}
+		}
+	}
+
+	// Find the least fixed point, visiting blocks in postorder so that
+	// successors are usually decided before their predecessors.
+	po := f.postorder()
+	for changed := true; changed; {
+		changed = false
+		for _, b := range po {
+			if cold[b.ID] || pinned[b.ID] {
+				continue
+			}
+			c := b.Kind == BlockExit
+			if len(b.Succs) > 0 {
+				c = true
+				for _, e := range b.Succs {
+					if !cold[e.b.ID] {
+						c = false
+						break
+					}
+				}
+			}
+			if c {
+				cold[b.ID] = true
+				changed = true
+			}
+		}
+	}
+
+	// Move the cold blocks to the end, keeping the layout of each part.
+	n := 0
+	var coldBlocks []*Block
+	for _, b := range f.Blocks {
+		if cold[b.ID] {
+			coldBlocks = append(coldBlocks, b)
+			continue
+		}
+		f.Blocks[n] = b
+		n++
+	}
+	if len(coldBlocks) == 0 {
+		return
+	}
+	copy(f.Blocks[n:], coldBlocks)
+	f.ColdStart = n
+	if f.pass.debug > 0 {
+		f.Warnl(coldBlocks[0].Pos, "split %d cold blocks out of %s", len(coldBlocks), f.Name)
+	}
+}
+
+// canSplitCold reports whether the cold blocks of f may be emitted
+// as a separate text symbol.
+func canSplitCold(f *Func) bool {
+	if len(f.PGOCallWeights) == 0 || !f.Config.optimize {
+		return false
+	}
+	// Only amd64 can assemble a fragment that runs in another
+	// function's frame. Windows describes the frame with unwind info
+	// that assumes a prologue, and dynamic linking may need to reload
+	// the GOT register the fragment would not know about.
+	if f.Config.arch != "amd64" || buildcfg.GOOS == "windows" || f.Config.ctxt.Flag_dynlink {
+		return false
+	}
+	if base.Flag.CompilingRuntime || f.NoSplit {
+		return false
+	}
+	fn := f.fe.Func()
+	return !fn.HasDefer() && !fn.Wrapper() && !fn.ABIWrapper()
+}
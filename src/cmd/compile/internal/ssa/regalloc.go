@@ -839,11 +839,13 @@ func (s *regAllocState) compatRegs(t *types.Type) regMask {
 	if t.IsTuple() || t.IsFlags() {
 		return 0
 	}
-	if t.IsFloat() || t == types.TypeInt128 {
+	if t.IsFloat() || t == types.TypeInt128 || t == types.TypeVec128 || t.IsSIMD() {
 		if t.Kind() == types.TFLOAT32 && s.f.Config.fp32RegMask != 0 {
 			m = s.f.Config.fp32RegMask
 		} else if t.Kind() == types.TFLOAT64 && s.f.Config.fp64RegMask != 0 {
 			m = s.f.Config.fp64RegMask
+		} else if (t == types.TypeVec128 || t.IsSIMD()) && s.f.Config.vecRegMask != 0 {
+			m = s.f.Config.vecRegMask
 		} else {
 			m = s.f.Config.fpRegMask
 		}
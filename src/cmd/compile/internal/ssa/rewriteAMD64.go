@@ -501,6 +501,10 @@ func rewriteValueAMD64(v *Value) bool {
 		return rewriteValueAMD64_OpAMD64TESTW(v)
 	case OpAMD64TESTWconst:
 		return rewriteValueAMD64_OpAMD64TESTWconst(v)
+	case OpAMD64VMOVDQUload256:
+		return rewriteValueAMD64_OpAMD64VMOVDQUload256(v)
+	case OpAMD64VMOVDQUstore256:
+		return rewriteValueAMD64_OpAMD64VMOVDQUstore256(v)
 	case OpAMD64XADDLlock:
 		return rewriteValueAMD64_OpAMD64XADDLlock(v)
 	case OpAMD64XADDQlock:
@@ -1153,6 +1157,76 @@ func rewriteValueAMD64(v *Value) bool {
 	case OpTrunc64to8:
 		v.Op = OpCopy
 		return true
+	case OpVecAdd32:
+		return rewriteValueAMD64_OpVecAdd32(v)
+	case OpVecAdd64:
+		return rewriteValueAMD64_OpVecAdd64(v)
+	case OpVecAdd8:
+		return rewriteValueAMD64_OpVecAdd8(v)
+	case OpVecAddF32:
+		return rewriteValueAMD64_OpVecAddF32(v)
+	case OpVecAddF64:
+		return rewriteValueAMD64_OpVecAddF64(v)
+	case OpVecAnd:
+		return rewriteValueAMD64_OpVecAnd(v)
+	case OpVecAndNot:
+		return rewriteValueAMD64_OpVecAndNot(v)
+	case OpVecDivF32:
+		return rewriteValueAMD64_OpVecDivF32(v)
+	case OpVecDivF64:
+		return rewriteValueAMD64_OpVecDivF64(v)
+	case OpVecEq32:
+		return rewriteValueAMD64_OpVecEq32(v)
+	case OpVecEq64:
+		return rewriteValueAMD64_OpVecEq64(v)
+	case OpVecEq8:
+		return rewriteValueAMD64_OpVecEq8(v)
+	case OpVecGt32:
+		return rewriteValueAMD64_OpVecGt32(v)
+	case OpVecGt64:
+		return rewriteValueAMD64_OpVecGt64(v)
+	case OpVecMax32:
+		return rewriteValueAMD64_OpVecMax32(v)
+	case OpVecMax64:
+		return rewriteValueAMD64_OpVecMax64(v)
+	case OpVecMaxU8:
+		return rewriteValueAMD64_OpVecMaxU8(v)
+	case OpVecMin32:
+		return rewriteValueAMD64_OpVecMin32(v)
+	case OpVecMin64:
+		return rewriteValueAMD64_OpVecMin64(v)
+	case OpVecMinU8:
+		return rewriteValueAMD64_OpVecMinU8(v)
+	case OpVecMul32:
+		return rewriteValueAMD64_OpVecMul32(v)
+	case OpVecMul64:
+		return rewriteValueAMD64_OpVecMul64(v)
+	case OpVecMulF32:
+		return rewriteValueAMD64_OpVecMulF32(v)
+	case OpVecMulF64:
+		return rewriteValueAMD64_OpVecMulF64(v)
+	case OpVecOr:
+		return rewriteValueAMD64_OpVecOr(v)
+	case OpVecSignBits8:
+		return rewriteValueAMD64_OpVecSignBits8(v)
+	case OpVecSqrtF32:
+		return rewriteValueAMD64_OpVecSqrtF32(v)
+	case OpVecSqrtF64:
+		return rewriteValueAMD64_OpVecSqrtF64(v)
+	case OpVecSub32:
+		return rewriteValueAMD64_OpVecSub32(v)
+	case OpVecSub64:
+		return rewriteValueAMD64_OpVecSub64(v)
+	case OpVecSub8:
+		return rewriteValueAMD64_OpVecSub8(v)
+	case OpVecSubF32:
+		return rewriteValueAMD64_OpVecSubF32(v)
+	case OpVecSubF64:
+		return rewriteValueAMD64_OpVecSubF64(v)
+	case OpVecXor:
+		return rewriteValueAMD64_OpVecXor(v)
+	case OpVecZero:
+		return rewriteValueAMD64_OpVecZero(v)
 	case OpWB:
 		v.Op = OpAMD64LoweredWB
 		return true
@@ -22857,6 +22931,105 @@ func rewriteValueAMD64_OpAMD64TESTWconst(v *Value) bool {
 	}
 	return false
 }
+func rewriteValueAMD64_OpAMD64VMOVDQUload256(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	// match: (VMOVDQUload256 [off1] {sym} (ADDQconst [off2] ptr) mem)
+	// cond: is32Bit(int64(off1)+int64(off2))
+	// result: (VMOVDQUload256 [off1+off2] {sym} ptr mem)
+	for {
+		off1 := auxIntToInt32(v.AuxInt)
+		sym := auxToSym(v.Aux)
+		if v_0.Op != OpAMD64ADDQconst {
+			break
+		}
+		off2 := auxIntToInt32(v_0.AuxInt)
+		ptr := v_0.Args[0]
+		mem := v_1
+		if !(is32Bit(int64(off1) + int64(off2))) {
+			break
+		}
+		v.reset(OpAMD64VMOVDQUload256)
+		v.AuxInt = int32ToAuxInt(off1 + off2)
+		v.Aux = symToAux(sym)
+		v.AddArg2(ptr, mem)
+		return true
+	}
+	// match: (VMOVDQUload256 [off1] {sym1} (LEAQ [off2] {sym2} base) mem)
+	// cond: is32Bit(int64(off1)+int64(off2)) && canMergeSym(sym1, sym2)
+	// result: (VMOVDQUload256 [off1+off2] {mergeSym(sym1,sym2)} base mem)
+	for {
+		off1 := auxIntToInt32(v.AuxInt)
+		sym1 := auxToSym(v.Aux)
+		if v_0.Op != OpAMD64LEAQ {
+			break
+		}
+		off2 := auxIntToInt32(v_0.AuxInt)
+		sym2 := auxToSym(v_0.Aux)
+		base := v_0.Args[0]
+		mem := v_1
+		if !(is32Bit(int64(off1)+int64(off2)) && canMergeSym(sym1, sym2)) {
+			break
+		}
+		v.reset(OpAMD64VMOVDQUload256)
+		v.AuxInt = int32ToAuxInt(off1 + off2)
+		v.Aux = symToAux(mergeSym(sym1, sym2))
+		v.AddArg2(base, mem)
+		return true
+	}
+	return false
+}
+func rewriteValueAMD64_OpAMD64VMOVDQUstore256(v *Value) bool {
+	v_2 := v.Args[2]
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	// match: (VMOVDQUstore256 [off1] {sym} (ADDQconst [off2] ptr) val mem)
+	// cond: is32Bit(int64(off1)+int64(off2))
+	// result: (VMOVDQUstore256 [off1+off2] {sym} ptr val mem)
+	for {
+		off1 := auxIntToInt32(v.AuxInt)
+		sym := auxToSym(v.Aux)
+		if v_0.Op != OpAMD64ADDQconst {
+			break
+		}
+		off2 := auxIntToInt32(v_0.AuxInt)
+		ptr := v_0.Args[0]
+		val := v_1
+		mem := v_2
+		if !(is32Bit(int64(off1) + int64(off2))) {
+			break
+		}
+		v.reset(OpAMD64VMOVDQUstore256)
+		v.AuxInt = int32ToAuxInt(off1 + off2)
+		v.Aux = symToAux(sym)
+		v.AddArg3(ptr, val, mem)
+		return true
+	}
+	// match: (VMOVDQUstore256 [off1] {sym1} (LEAQ [off2] {sym2} base) val mem)
+	// cond: is32Bit(int64(off1)+int64(off2)) && canMergeSym(sym1, sym2)
+	// result: (VMOVDQUstore256 [off1+off2] {mergeSym(sym1,sym2)} base val mem)
+	for {
+		off1 := auxIntToInt32(v.AuxInt)
+		sym1 := auxToSym(v.Aux)
+		if v_0.Op != OpAMD64LEAQ {
+			break
+		}
+		off2 := auxIntToInt32(v_0.AuxInt)
+		sym2 := auxToSym(v_0.Aux)
+		base := v_0.Args[0]
+		val := v_1
+		mem := v_2
+		if !(is32Bit(int64(off1)+int64(off2)) && canMergeSym(sym1, sym2)) {
+			break
+		}
+		v.reset(OpAMD64VMOVDQUstore256)
+		v.AuxInt = int32ToAuxInt(off1 + off2)
+		v.Aux = symToAux(mergeSym(sym1, sym2))
+		v.AddArg3(base, val, mem)
+		return true
+	}
+	return false
+}
 func rewriteValueAMD64_OpAMD64XADDLlock(v *Value) bool {
 	v_2 := v.Args[2]
 	v_1 := v.Args[1]
@@ -26185,6 +26358,34 @@ func rewriteValueAMD64_OpLoad(v *Value) bool {
 		v.AddArg2(ptr, mem)
 		return true
 	}
+	// match: (Load <t> ptr mem)
+	// cond: t == types.TypeVec128
+	// result: (MOVOload ptr mem)
+	for {
+		t := v.Type
+		ptr := v_0
+		mem := v_1
+		if !(t == types.TypeVec128) {
+			break
+		}
+		v.reset(OpAMD64MOVOload)
+		v.AddArg2(ptr, mem)
+		return true
+	}
+	// match: (Load <t> ptr mem)
+	// cond: t.IsSIMD() && t.Size() == 32
+	// result: (VMOVDQUload256 ptr mem)
+	for {
+		t := v.Type
+		ptr := v_0
+		mem := v_1
+		if !(t.IsSIMD() && t.Size() == 32) {
+			break
+		}
+		v.reset(OpAMD64VMOVDQUload256)
+		v.AddArg2(ptr, mem)
+		return true
+	}
 	return false
 }
 func rewriteValueAMD64_OpLocalAddr(v *Value) bool {
@@ -29696,6 +29897,36 @@ func rewriteValueAMD64_OpStore(v *Value) bool {
 		v.AddArg3(ptr, val, mem)
 		return true
 	}
+	// match: (Store {t} ptr val mem)
+	// cond: t == types.TypeVec128
+	// result: (MOVOstore ptr val mem)
+	for {
+		t := auxToType(v.Aux)
+		ptr := v_0
+		val := v_1
+		mem := v_2
+		if !(t == types.TypeVec128) {
+			break
+		}
+		v.reset(OpAMD64MOVOstore)
+		v.AddArg3(ptr, val, mem)
+		return true
+	}
+	// match: (Store {t} ptr val mem)
+	// cond: t.IsSIMD() && t.Size() == 32
+	// result: (VMOVDQUstore256 ptr val mem)
+	for {
+		t := auxToType(v.Aux)
+		ptr := v_0
+		val := v_1
+		mem := v_2
+		if !(t.IsSIMD() && t.Size() == 32) {
+			break
+		}
+		v.reset(OpAMD64VMOVDQUstore256)
+		v.AddArg3(ptr, val, mem)
+		return true
+	}
 	return false
 }
 func rewriteValueAMD64_OpTrunc(v *Value) bool {
@@ -29710,6 +29941,1074 @@ func rewriteValueAMD64_OpTrunc(v *Value) bool {
 		return true
 	}
 }
+func rewriteValueAMD64_OpVecAdd32(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	// match: (VecAdd32 x y)
+	// cond: v.Type.Size() == 16
+	// result: (PADDL x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 16) {
+			break
+		}
+		v.reset(OpAMD64PADDL)
+		v.AddArg2(x, y)
+		return true
+	}
+	// match: (VecAdd32 x y)
+	// cond: v.Type.Size() == 32
+	// result: (VPADDD256 x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 32) {
+			break
+		}
+		v.reset(OpAMD64VPADDD256)
+		v.AddArg2(x, y)
+		return true
+	}
+	return false
+}
+func rewriteValueAMD64_OpVecAdd64(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	// match: (VecAdd64 x y)
+	// cond: v.Type.Size() == 16
+	// result: (PADDQ x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 16) {
+			break
+		}
+		v.reset(OpAMD64PADDQ)
+		v.AddArg2(x, y)
+		return true
+	}
+	// match: (VecAdd64 x y)
+	// cond: v.Type.Size() == 32
+	// result: (VPADDQ256 x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 32) {
+			break
+		}
+		v.reset(OpAMD64VPADDQ256)
+		v.AddArg2(x, y)
+		return true
+	}
+	return false
+}
+func rewriteValueAMD64_OpVecAdd8(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	// match: (VecAdd8 x y)
+	// cond: v.Type.Size() == 16
+	// result: (PADDB x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 16) {
+			break
+		}
+		v.reset(OpAMD64PADDB)
+		v.AddArg2(x, y)
+		return true
+	}
+	// match: (VecAdd8 x y)
+	// cond: v.Type.Size() == 32
+	// result: (VPADDB256 x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 32) {
+			break
+		}
+		v.reset(OpAMD64VPADDB256)
+		v.AddArg2(x, y)
+		return true
+	}
+	return false
+}
+func rewriteValueAMD64_OpVecAddF32(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	// match: (VecAddF32 x y)
+	// cond: v.Type.Size() == 16
+	// result: (ADDPS x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 16) {
+			break
+		}
+		v.reset(OpAMD64ADDPS)
+		v.AddArg2(x, y)
+		return true
+	}
+	// match: (VecAddF32 x y)
+	// cond: v.Type.Size() == 32
+	// result: (VADDPS256 x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 32) {
+			break
+		}
+		v.reset(OpAMD64VADDPS256)
+		v.AddArg2(x, y)
+		return true
+	}
+	return false
+}
+func rewriteValueAMD64_OpVecAddF64(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	// match: (VecAddF64 x y)
+	// cond: v.Type.Size() == 16
+	// result: (ADDPD x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 16) {
+			break
+		}
+		v.reset(OpAMD64ADDPD)
+		v.AddArg2(x, y)
+		return true
+	}
+	// match: (VecAddF64 x y)
+	// cond: v.Type.Size() == 32
+	// result: (VADDPD256 x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 32) {
+			break
+		}
+		v.reset(OpAMD64VADDPD256)
+		v.AddArg2(x, y)
+		return true
+	}
+	return false
+}
+func rewriteValueAMD64_OpVecAnd(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	// match: (VecAnd x y)
+	// cond: v.Type.Size() == 16
+	// result: (PAND x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 16) {
+			break
+		}
+		v.reset(OpAMD64PAND)
+		v.AddArg2(x, y)
+		return true
+	}
+	// match: (VecAnd x y)
+	// cond: v.Type.Size() == 32
+	// result: (VPAND256 x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 32) {
+			break
+		}
+		v.reset(OpAMD64VPAND256)
+		v.AddArg2(x, y)
+		return true
+	}
+	return false
+}
+func rewriteValueAMD64_OpVecAndNot(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	// match: (VecAndNot x y)
+	// cond: v.Type.Size() == 16
+	// result: (PANDN y x)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 16) {
+			break
+		}
+		v.reset(OpAMD64PANDN)
+		v.AddArg2(y, x)
+		return true
+	}
+	// match: (VecAndNot x y)
+	// cond: v.Type.Size() == 32
+	// result: (VPANDN256 y x)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 32) {
+			break
+		}
+		v.reset(OpAMD64VPANDN256)
+		v.AddArg2(y, x)
+		return true
+	}
+	return false
+}
+func rewriteValueAMD64_OpVecDivF32(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	// match: (VecDivF32 x y)
+	// cond: v.Type.Size() == 16
+	// result: (DIVPS x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 16) {
+			break
+		}
+		v.reset(OpAMD64DIVPS)
+		v.AddArg2(x, y)
+		return true
+	}
+	// match: (VecDivF32 x y)
+	// cond: v.Type.Size() == 32
+	// result: (VDIVPS256 x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 32) {
+			break
+		}
+		v.reset(OpAMD64VDIVPS256)
+		v.AddArg2(x, y)
+		return true
+	}
+	return false
+}
+func rewriteValueAMD64_OpVecDivF64(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	// match: (VecDivF64 x y)
+	// cond: v.Type.Size() == 16
+	// result: (DIVPD x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 16) {
+			break
+		}
+		v.reset(OpAMD64DIVPD)
+		v.AddArg2(x, y)
+		return true
+	}
+	// match: (VecDivF64 x y)
+	// cond: v.Type.Size() == 32
+	// result: (VDIVPD256 x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 32) {
+			break
+		}
+		v.reset(OpAMD64VDIVPD256)
+		v.AddArg2(x, y)
+		return true
+	}
+	return false
+}
+func rewriteValueAMD64_OpVecEq32(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	// match: (VecEq32 x y)
+	// cond: v.Type.Size() == 16
+	// result: (PCMPEQL x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 16) {
+			break
+		}
+		v.reset(OpAMD64PCMPEQL)
+		v.AddArg2(x, y)
+		return true
+	}
+	// match: (VecEq32 x y)
+	// cond: v.Type.Size() == 32
+	// result: (VPCMPEQD256 x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 32) {
+			break
+		}
+		v.reset(OpAMD64VPCMPEQD256)
+		v.AddArg2(x, y)
+		return true
+	}
+	return false
+}
+func rewriteValueAMD64_OpVecEq64(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	// match: (VecEq64 x y)
+	// cond: v.Type.Size() == 16
+	// result: (PCMPEQQ x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 16) {
+			break
+		}
+		v.reset(OpAMD64PCMPEQQ)
+		v.AddArg2(x, y)
+		return true
+	}
+	// match: (VecEq64 x y)
+	// cond: v.Type.Size() == 32
+	// result: (VPCMPEQQ256 x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 32) {
+			break
+		}
+		v.reset(OpAMD64VPCMPEQQ256)
+		v.AddArg2(x, y)
+		return true
+	}
+	return false
+}
+func rewriteValueAMD64_OpVecEq8(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	// match: (VecEq8 x y)
+	// cond: v.Type.Size() == 16
+	// result: (PCMPEQB x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 16) {
+			break
+		}
+		v.reset(OpAMD64PCMPEQB)
+		v.AddArg2(x, y)
+		return true
+	}
+	// match: (VecEq8 x y)
+	// cond: v.Type.Size() == 32
+	// result: (VPCMPEQB256 x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 32) {
+			break
+		}
+		v.reset(OpAMD64VPCMPEQB256)
+		v.AddArg2(x, y)
+		return true
+	}
+	return false
+}
+func rewriteValueAMD64_OpVecGt32(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	// match: (VecGt32 x y)
+	// cond: v.Type.Size() == 16
+	// result: (PCMPGTL x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 16) {
+			break
+		}
+		v.reset(OpAMD64PCMPGTL)
+		v.AddArg2(x, y)
+		return true
+	}
+	// match: (VecGt32 x y)
+	// cond: v.Type.Size() == 32
+	// result: (VPCMPGTD256 x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 32) {
+			break
+		}
+		v.reset(OpAMD64VPCMPGTD256)
+		v.AddArg2(x, y)
+		return true
+	}
+	return false
+}
+func rewriteValueAMD64_OpVecGt64(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	// match: (VecGt64 x y)
+	// cond: v.Type.Size() == 16
+	// result: (PCMPGTQ x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 16) {
+			break
+		}
+		v.reset(OpAMD64PCMPGTQ)
+		v.AddArg2(x, y)
+		return true
+	}
+	// match: (VecGt64 x y)
+	// cond: v.Type.Size() == 32
+	// result: (VPCMPGTQ256 x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 32) {
+			break
+		}
+		v.reset(OpAMD64VPCMPGTQ256)
+		v.AddArg2(x, y)
+		return true
+	}
+	return false
+}
+func rewriteValueAMD64_OpVecMax32(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	// match: (VecMax32 x y)
+	// cond: v.Type.Size() == 16
+	// result: (PMAXSD x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 16) {
+			break
+		}
+		v.reset(OpAMD64PMAXSD)
+		v.AddArg2(x, y)
+		return true
+	}
+	// match: (VecMax32 x y)
+	// cond: v.Type.Size() == 32
+	// result: (VPMAXSD256 x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 32) {
+			break
+		}
+		v.reset(OpAMD64VPMAXSD256)
+		v.AddArg2(x, y)
+		return true
+	}
+	return false
+}
+func rewriteValueAMD64_OpVecMax64(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	// match: (VecMax64 x y)
+	// cond: v.Type.Size() == 16
+	// result: (VPMAXSQ128 x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 16) {
+			break
+		}
+		v.reset(OpAMD64VPMAXSQ128)
+		v.AddArg2(x, y)
+		return true
+	}
+	// match: (VecMax64 x y)
+	// cond: v.Type.Size() == 32
+	// result: (VPMAXSQ256 x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 32) {
+			break
+		}
+		v.reset(OpAMD64VPMAXSQ256)
+		v.AddArg2(x, y)
+		return true
+	}
+	return false
+}
+func rewriteValueAMD64_OpVecMaxU8(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	// match: (VecMaxU8 x y)
+	// cond: v.Type.Size() == 16
+	// result: (PMAXUB x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 16) {
+			break
+		}
+		v.reset(OpAMD64PMAXUB)
+		v.AddArg2(x, y)
+		return true
+	}
+	// match: (VecMaxU8 x y)
+	// cond: v.Type.Size() == 32
+	// result: (VPMAXUB256 x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 32) {
+			break
+		}
+		v.reset(OpAMD64VPMAXUB256)
+		v.AddArg2(x, y)
+		return true
+	}
+	return false
+}
+func rewriteValueAMD64_OpVecMin32(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	// match: (VecMin32 x y)
+	// cond: v.Type.Size() == 16
+	// result: (PMINSD x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 16) {
+			break
+		}
+		v.reset(OpAMD64PMINSD)
+		v.AddArg2(x, y)
+		return true
+	}
+	// match: (VecMin32 x y)
+	// cond: v.Type.Size() == 32
+	// result: (VPMINSD256 x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 32) {
+			break
+		}
+		v.reset(OpAMD64VPMINSD256)
+		v.AddArg2(x, y)
+		return true
+	}
+	return false
+}
+func rewriteValueAMD64_OpVecMin64(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	// match: (VecMin64 x y)
+	// cond: v.Type.Size() == 16
+	// result: (VPMINSQ128 x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 16) {
+			break
+		}
+		v.reset(OpAMD64VPMINSQ128)
+		v.AddArg2(x, y)
+		return true
+	}
+	// match: (VecMin64 x y)
+	// cond: v.Type.Size() == 32
+	// result: (VPMINSQ256 x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 32) {
+			break
+		}
+		v.reset(OpAMD64VPMINSQ256)
+		v.AddArg2(x, y)
+		return true
+	}
+	return false
+}
+func rewriteValueAMD64_OpVecMinU8(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	// match: (VecMinU8 x y)
+	// cond: v.Type.Size() == 16
+	// result: (PMINUB x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 16) {
+			break
+		}
+		v.reset(OpAMD64PMINUB)
+		v.AddArg2(x, y)
+		return true
+	}
+	// match: (VecMinU8 x y)
+	// cond: v.Type.Size() == 32
+	// result: (VPMINUB256 x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 32) {
+			break
+		}
+		v.reset(OpAMD64VPMINUB256)
+		v.AddArg2(x, y)
+		return true
+	}
+	return false
+}
+func rewriteValueAMD64_OpVecMul32(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	// match: (VecMul32 x y)
+	// cond: v.Type.Size() == 16
+	// result: (PMULLD x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 16) {
+			break
+		}
+		v.reset(OpAMD64PMULLD)
+		v.AddArg2(x, y)
+		return true
+	}
+	// match: (VecMul32 x y)
+	// cond: v.Type.Size() == 32
+	// result: (VPMULLD256 x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 32) {
+			break
+		}
+		v.reset(OpAMD64VPMULLD256)
+		v.AddArg2(x, y)
+		return true
+	}
+	return false
+}
+func rewriteValueAMD64_OpVecMul64(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	// match: (VecMul64 x y)
+	// cond: v.Type.Size() == 16
+	// result: (VPMULLQ128 x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 16) {
+			break
+		}
+		v.reset(OpAMD64VPMULLQ128)
+		v.AddArg2(x, y)
+		return true
+	}
+	// match: (VecMul64 x y)
+	// cond: v.Type.Size() == 32
+	// result: (VPMULLQ256 x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 32) {
+			break
+		}
+		v.reset(OpAMD64VPMULLQ256)
+		v.AddArg2(x, y)
+		return true
+	}
+	return false
+}
+func rewriteValueAMD64_OpVecMulF32(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	// match: (VecMulF32 x y)
+	// cond: v.Type.Size() == 16
+	// result: (MULPS x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 16) {
+			break
+		}
+		v.reset(OpAMD64MULPS)
+		v.AddArg2(x, y)
+		return true
+	}
+	// match: (VecMulF32 x y)
+	// cond: v.Type.Size() == 32
+	// result: (VMULPS256 x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 32) {
+			break
+		}
+		v.reset(OpAMD64VMULPS256)
+		v.AddArg2(x, y)
+		return true
+	}
+	return false
+}
+func rewriteValueAMD64_OpVecMulF64(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	// match: (VecMulF64 x y)
+	// cond: v.Type.Size() == 16
+	// result: (MULPD x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 16) {
+			break
+		}
+		v.reset(OpAMD64MULPD)
+		v.AddArg2(x, y)
+		return true
+	}
+	// match: (VecMulF64 x y)
+	// cond: v.Type.Size() == 32
+	// result: (VMULPD256 x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 32) {
+			break
+		}
+		v.reset(OpAMD64VMULPD256)
+		v.AddArg2(x, y)
+		return true
+	}
+	return false
+}
+func rewriteValueAMD64_OpVecOr(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	// match: (VecOr x y)
+	// cond: v.Type.Size() == 16
+	// result: (POR x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 16) {
+			break
+		}
+		v.reset(OpAMD64POR)
+		v.AddArg2(x, y)
+		return true
+	}
+	// match: (VecOr x y)
+	// cond: v.Type.Size() == 32
+	// result: (VPOR256 x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 32) {
+			break
+		}
+		v.reset(OpAMD64VPOR256)
+		v.AddArg2(x, y)
+		return true
+	}
+	return false
+}
+func rewriteValueAMD64_OpVecSignBits8(v *Value) bool {
+	v_0 := v.Args[0]
+	// match: (VecSignBits8 x)
+	// cond: x.Type.Size() == 16
+	// result: (PMOVMSKB x)
+	for {
+		x := v_0
+		if !(x.Type.Size() == 16) {
+			break
+		}
+		v.reset(OpAMD64PMOVMSKB)
+		v.AddArg(x)
+		return true
+	}
+	// match: (VecSignBits8 x)
+	// cond: x.Type.Size() == 32
+	// result: (VPMOVMSKB256 x)
+	for {
+		x := v_0
+		if !(x.Type.Size() == 32) {
+			break
+		}
+		v.reset(OpAMD64VPMOVMSKB256)
+		v.AddArg(x)
+		return true
+	}
+	return false
+}
+func rewriteValueAMD64_OpVecSqrtF32(v *Value) bool {
+	v_0 := v.Args[0]
+	// match: (VecSqrtF32 x)
+	// cond: v.Type.Size() == 16
+	// result: (SQRTPS x)
+	for {
+		x := v_0
+		if !(v.Type.Size() == 16) {
+			break
+		}
+		v.reset(OpAMD64SQRTPS)
+		v.AddArg(x)
+		return true
+	}
+	// match: (VecSqrtF32 x)
+	// cond: v.Type.Size() == 32
+	// result: (VSQRTPS256 x)
+	for {
+		x := v_0
+		if !(v.Type.Size() == 32) {
+			break
+		}
+		v.reset(OpAMD64VSQRTPS256)
+		v.AddArg(x)
+		return true
+	}
+	return false
+}
+func rewriteValueAMD64_OpVecSqrtF64(v *Value) bool {
+	v_0 := v.Args[0]
+	// match: (VecSqrtF64 x)
+	// cond: v.Type.Size() == 16
+	// result: (SQRTPD x)
+	for {
+		x := v_0
+		if !(v.Type.Size() == 16) {
+			break
+		}
+		v.reset(OpAMD64SQRTPD)
+		v.AddArg(x)
+		return true
+	}
+	// match: (VecSqrtF64 x)
+	// cond: v.Type.Size() == 32
+	// result: (VSQRTPD256 x)
+	for {
+		x := v_0
+		if !(v.Type.Size() == 32) {
+			break
+		}
+		v.reset(OpAMD64VSQRTPD256)
+		v.AddArg(x)
+		return true
+	}
+	return false
+}
+func rewriteValueAMD64_OpVecSub32(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	// match: (VecSub32 x y)
+	// cond: v.Type.Size() == 16
+	// result: (PSUBL x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 16) {
+			break
+		}
+		v.reset(OpAMD64PSUBL)
+		v.AddArg2(x, y)
+		return true
+	}
+	// match: (VecSub32 x y)
+	// cond: v.Type.Size() == 32
+	// result: (VPSUBD256 x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 32) {
+			break
+		}
+		v.reset(OpAMD64VPSUBD256)
+		v.AddArg2(x, y)
+		return true
+	}
+	return false
+}
+func rewriteValueAMD64_OpVecSub64(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	// match: (VecSub64 x y)
+	// cond: v.Type.Size() == 16
+	// result: (PSUBQ x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 16) {
+			break
+		}
+		v.reset(OpAMD64PSUBQ)
+		v.AddArg2(x, y)
+		return true
+	}
+	// match: (VecSub64 x y)
+	// cond: v.Type.Size() == 32
+	// result: (VPSUBQ256 x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 32) {
+			break
+		}
+		v.reset(OpAMD64VPSUBQ256)
+		v.AddArg2(x, y)
+		return true
+	}
+	return false
+}
+func rewriteValueAMD64_OpVecSub8(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	// match: (VecSub8 x y)
+	// cond: v.Type.Size() == 16
+	// result: (PSUBB x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 16) {
+			break
+		}
+		v.reset(OpAMD64PSUBB)
+		v.AddArg2(x, y)
+		return true
+	}
+	// match: (VecSub8 x y)
+	// cond: v.Type.Size() == 32
+	// result: (VPSUBB256 x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 32) {
+			break
+		}
+		v.reset(OpAMD64VPSUBB256)
+		v.AddArg2(x, y)
+		return true
+	}
+	return false
+}
+func rewriteValueAMD64_OpVecSubF32(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	// match: (VecSubF32 x y)
+	// cond: v.Type.Size() == 16
+	// result: (SUBPS x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 16) {
+			break
+		}
+		v.reset(OpAMD64SUBPS)
+		v.AddArg2(x, y)
+		return true
+	}
+	// match: (VecSubF32 x y)
+	// cond: v.Type.Size() == 32
+	// result: (VSUBPS256 x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 32) {
+			break
+		}
+		v.reset(OpAMD64VSUBPS256)
+		v.AddArg2(x, y)
+		return true
+	}
+	return false
+}
+func rewriteValueAMD64_OpVecSubF64(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	// match: (VecSubF64 x y)
+	// cond: v.Type.Size() == 16
+	// result: (SUBPD x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 16) {
+			break
+		}
+		v.reset(OpAMD64SUBPD)
+		v.AddArg2(x, y)
+		return true
+	}
+	// match: (VecSubF64 x y)
+	// cond: v.Type.Size() == 32
+	// result: (VSUBPD256 x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 32) {
+			break
+		}
+		v.reset(OpAMD64VSUBPD256)
+		v.AddArg2(x, y)
+		return true
+	}
+	return false
+}
+func rewriteValueAMD64_OpVecXor(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	// match: (VecXor x y)
+	// cond: v.Type.Size() == 16
+	// result: (PXOR x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 16) {
+			break
+		}
+		v.reset(OpAMD64PXOR)
+		v.AddArg2(x, y)
+		return true
+	}
+	// match: (VecXor x y)
+	// cond: v.Type.Size() == 32
+	// result: (VPXOR256 x y)
+	for {
+		x := v_0
+		y := v_1
+		if !(v.Type.Size() == 32) {
+			break
+		}
+		v.reset(OpAMD64VPXOR256)
+		v.AddArg2(x, y)
+		return true
+	}
+	return false
+}
+func rewriteValueAMD64_OpVecZero(v *Value) bool {
+	// match: (VecZero)
+	// cond: v.Type.Size() == 16
+	// result: (PXORzero)
+	for {
+		if !(v.Type.Size() == 16) {
+			break
+		}
+		v.reset(OpAMD64PXORzero)
+		return true
+	}
+	// match: (VecZero)
+	// cond: v.Type.Size() == 32
+	// result: (VPXOR256zero)
+	for {
+		if !(v.Type.Size() == 32) {
+			break
+		}
+		v.reset(OpAMD64VPXOR256zero)
+		return true
+	}
+	return false
+}
 func rewriteValueAMD64_OpZero(v *Value) bool {
 	v_1 := v.Args[1]
 	v_0 := v.Args[0]
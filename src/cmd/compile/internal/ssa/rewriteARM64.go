@@ -122,6 +122,10 @@ func rewriteValueARM64(v *Value) bool {
 		return rewriteValueARM64_OpARM64FMOVDstoreidx(v)
 	case OpARM64FMOVDstoreidx8:
 		return rewriteValueARM64_OpARM64FMOVDstoreidx8(v)
+	case OpARM64FMOVQload:
+		return rewriteValueARM64_OpARM64FMOVQload(v)
+	case OpARM64FMOVQstore:
+		return rewriteValueARM64_OpARM64FMOVQstore(v)
 	case OpARM64FMOVSload:
 		return rewriteValueARM64_OpARM64FMOVSload(v)
 	case OpARM64FMOVSloadidx:
@@ -1107,6 +1111,103 @@ func rewriteValueARM64(v *Value) bool {
 	case OpTrunc64to8:
 		v.Op = OpCopy
 		return true
+	case OpVecAdd32:
+		v.Op = OpARM64VADDS4
+		return true
+	case OpVecAdd64:
+		v.Op = OpARM64VADDD2
+		return true
+	case OpVecAdd8:
+		v.Op = OpARM64VADDB16
+		return true
+	case OpVecAddF32:
+		v.Op = OpARM64VFADDS4
+		return true
+	case OpVecAddF64:
+		v.Op = OpARM64VFADDD2
+		return true
+	case OpVecAnd:
+		v.Op = OpARM64VAND
+		return true
+	case OpVecAndNot:
+		v.Op = OpARM64VBIC
+		return true
+	case OpVecDivF32:
+		v.Op = OpARM64VFDIVS4
+		return true
+	case OpVecDivF64:
+		v.Op = OpARM64VFDIVD2
+		return true
+	case OpVecEq32:
+		v.Op = OpARM64VCMEQS4
+		return true
+	case OpVecEq64:
+		v.Op = OpARM64VCMEQD2
+		return true
+	case OpVecEq8:
+		v.Op = OpARM64VCMEQB16
+		return true
+	case OpVecGt32:
+		v.Op = OpARM64VCMGTS4
+		return true
+	case OpVecGt64:
+		v.Op = OpARM64VCMGTD2
+		return true
+	case OpVecMax32:
+		v.Op = OpARM64VSMAXS4
+		return true
+	case OpVecMax64:
+		return rewriteValueARM64_OpVecMax64(v)
+	case OpVecMaxU8:
+		v.Op = OpARM64VUMAXB16
+		return true
+	case OpVecMin32:
+		v.Op = OpARM64VSMINS4
+		return true
+	case OpVecMin64:
+		return rewriteValueARM64_OpVecMin64(v)
+	case OpVecMinU8:
+		v.Op = OpARM64VUMINB16
+		return true
+	case OpVecMul32:
+		v.Op = OpARM64VMULS4
+		return true
+	case OpVecMulF32:
+		v.Op = OpARM64VFMULS4
+		return true
+	case OpVecMulF64:
+		v.Op = OpARM64VFMULD2
+		return true
+	case OpVecOr:
+		v.Op = OpARM64VORR
+		return true
+	case OpVecSqrtF32:
+		v.Op = OpARM64VFSQRTS4
+		return true
+	case OpVecSqrtF64:
+		v.Op = OpARM64VFSQRTD2
+		return true
+	case OpVecSub32:
+		v.Op = OpARM64VSUBS4
+		return true
+	case OpVecSub64:
+		v.Op = OpARM64VSUBD2
+		return true
+	case OpVecSub8:
+		v.Op = OpARM64VSUBB16
+		return true
+	case OpVecSubF32:
+		v.Op = OpARM64VFSUBS4
+		return true
+	case OpVecSubF64:
+		v.Op = OpARM64VFSUBD2
+		return true
+	case OpVecXor:
+		v.Op = OpARM64VEOR
+		return true
+	case OpVecZero:
+		v.Op = OpARM64VEORzero
+		return true
 	case OpWB:
 		v.Op = OpARM64LoweredWB
 		return true
@@ -4959,6 +5060,109 @@ func rewriteValueARM64_OpARM64FMOVDstoreidx8(v *Value) bool {
 	}
 	return false
 }
+func rewriteValueARM64_OpARM64FMOVQload(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	b := v.Block
+	config := b.Func.Config
+	// match: (FMOVQload [off1] {sym} (ADDconst [off2] ptr) mem)
+	// cond: is32Bit(int64(off1)+off2) && (ptr.Op != OpSB || !config.ctxt.Flag_dynlink)
+	// result: (FMOVQload [off1+int32(off2)] {sym} ptr mem)
+	for {
+		off1 := auxIntToInt32(v.AuxInt)
+		sym := auxToSym(v.Aux)
+		if v_0.Op != OpARM64ADDconst {
+			break
+		}
+		off2 := auxIntToInt64(v_0.AuxInt)
+		ptr := v_0.Args[0]
+		mem := v_1
+		if !(is32Bit(int64(off1)+off2) && (ptr.Op != OpSB || !config.ctxt.Flag_dynlink)) {
+			break
+		}
+		v.reset(OpARM64FMOVQload)
+		v.AuxInt = int32ToAuxInt(off1 + int32(off2))
+		v.Aux = symToAux(sym)
+		v.AddArg2(ptr, mem)
+		return true
+	}
+	// match: (FMOVQload [off1] {sym1} (MOVDaddr [off2] {sym2} ptr) mem)
+	// cond: canMergeSym(sym1,sym2) && is32Bit(int64(off1)+int64(off2)) && ptr.Op != OpSB
+	// result: (FMOVQload [off1+off2] {mergeSym(sym1,sym2)} ptr mem)
+	for {
+		off1 := auxIntToInt32(v.AuxInt)
+		sym1 := auxToSym(v.Aux)
+		if v_0.Op != OpARM64MOVDaddr {
+			break
+		}
+		off2 := auxIntToInt32(v_0.AuxInt)
+		sym2 := auxToSym(v_0.Aux)
+		ptr := v_0.Args[0]
+		mem := v_1
+		if !(canMergeSym(sym1, sym2) && is32Bit(int64(off1)+int64(off2)) && ptr.Op != OpSB) {
+			break
+		}
+		v.reset(OpARM64FMOVQload)
+		v.AuxInt = int32ToAuxInt(off1 + off2)
+		v.Aux = symToAux(mergeSym(sym1, sym2))
+		v.AddArg2(ptr, mem)
+		return true
+	}
+	return false
+}
+func rewriteValueARM64_OpARM64FMOVQstore(v *Value) bool {
+	v_2 := v.Args[2]
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	b := v.Block
+	config := b.Func.Config
+	// match: (FMOVQstore [off1] {sym} (ADDconst [off2] ptr) val mem)
+	// cond: is32Bit(int64(off1)+off2) && (ptr.Op != OpSB || !config.ctxt.Flag_dynlink)
+	// result: (FMOVQstore [off1+int32(off2)] {sym} ptr val mem)
+	for {
+		off1 := auxIntToInt32(v.AuxInt)
+		sym := auxToSym(v.Aux)
+		if v_0.Op != OpARM64ADDconst {
+			break
+		}
+		off2 := auxIntToInt64(v_0.AuxInt)
+		ptr := v_0.Args[0]
+		val := v_1
+		mem := v_2
+		if !(is32Bit(int64(off1)+off2) && (ptr.Op != OpSB || !config.ctxt.Flag_dynlink)) {
+			break
+		}
+		v.reset(OpARM64FMOVQstore)
+		v.AuxInt = int32ToAuxInt(off1 + int32(off2))
+		v.Aux = symToAux(sym)
+		v.AddArg3(ptr, val, mem)
+		return true
+	}
+	// match: (FMOVQstore [off1] {sym1} (MOVDaddr [off2] {sym2} ptr) val mem)
+	// cond: canMergeSym(sym1,sym2) && is32Bit(int64(off1)+int64(off2)) && ptr.Op != OpSB
+	// result: (FMOVQstore [off1+off2] {mergeSym(sym1,sym2)} ptr val mem)
+	for {
+		off1 := auxIntToInt32(v.AuxInt)
+		sym1 := auxToSym(v.Aux)
+		if v_0.Op != OpARM64MOVDaddr {
+			break
+		}
+		off2 := auxIntToInt32(v_0.AuxInt)
+		sym2 := auxToSym(v_0.Aux)
+		ptr := v_0.Args[0]
+		val := v_1
+		mem := v_2
+		if !(canMergeSym(sym1, sym2) && is32Bit(int64(off1)+int64(off2)) && ptr.Op != OpSB) {
+			break
+		}
+		v.reset(OpARM64FMOVQstore)
+		v.AuxInt = int32ToAuxInt(off1 + off2)
+		v.Aux = symToAux(mergeSym(sym1, sym2))
+		v.AddArg3(ptr, val, mem)
+		return true
+	}
+	return false
+}
 func rewriteValueARM64_OpARM64FMOVSload(v *Value) bool {
 	v_1 := v.Args[1]
 	v_0 := v.Args[0]
@@ -19270,6 +19474,20 @@ func rewriteValueARM64_OpLoad(v *Value) bool {
 		v.AddArg2(ptr, mem)
 		return true
 	}
+	// match: (Load <t> ptr mem)
+	// cond: t == types.TypeVec128
+	// result: (FMOVQload ptr mem)
+	for {
+		t := v.Type
+		ptr := v_0
+		mem := v_1
+		if !(t == types.TypeVec128) {
+			break
+		}
+		v.reset(OpARM64FMOVQload)
+		v.AddArg2(ptr, mem)
+		return true
+	}
 	return false
 }
 func rewriteValueARM64_OpLocalAddr(v *Value) bool {
@@ -22995,8 +23213,57 @@ func rewriteValueARM64_OpStore(v *Value) bool {
 		v.AddArg3(ptr, val, mem)
 		return true
 	}
+	// match: (Store {t} ptr val mem)
+	// cond: t == types.TypeVec128
+	// result: (FMOVQstore ptr val mem)
+	for {
+		t := auxToType(v.Aux)
+		ptr := v_0
+		val := v_1
+		mem := v_2
+		if !(t == types.TypeVec128) {
+			break
+		}
+		v.reset(OpARM64FMOVQstore)
+		v.AddArg3(ptr, val, mem)
+		return true
+	}
 	return false
 }
+func rewriteValueARM64_OpVecMax64(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	b := v.Block
+	// match: (VecMax64 <t> x y)
+	// result: (VBSL (VCMGTD2 <t> x y) x y)
+	for {
+		t := v.Type
+		x := v_0
+		y := v_1
+		v.reset(OpARM64VBSL)
+		v0 := b.NewValue0(v.Pos, OpARM64VCMGTD2, t)
+		v0.AddArg2(x, y)
+		v.AddArg3(v0, x, y)
+		return true
+	}
+}
+func rewriteValueARM64_OpVecMin64(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	b := v.Block
+	// match: (VecMin64 <t> x y)
+	// result: (VBSL (VCMGTD2 <t> x y) y x)
+	for {
+		t := v.Type
+		x := v_0
+		y := v_1
+		v.reset(OpARM64VBSL)
+		v0 := b.NewValue0(v.Pos, OpARM64VCMGTD2, t)
+		v0.AddArg2(x, y)
+		v.AddArg3(v0, y, x)
+		return true
+	}
+}
 func rewriteValueARM64_OpZero(v *Value) bool {
 	v_1 := v.Args[1]
 	v_0 := v.Args[0]
@@ -40,6 +40,80 @@ func rewriteValuedec(v *Value) bool {
 		return rewriteValuedec_OpStructMake1(v)
 	case OpStructSelect:
 		return rewriteValuedec_OpStructSelect(v)
+	case OpVec256Hi:
+		return rewriteValuedec_OpVec256Hi(v)
+	case OpVec256Lo:
+		return rewriteValuedec_OpVec256Lo(v)
+	case OpVecAdd32:
+		return rewriteValuedec_OpVecAdd32(v)
+	case OpVecAdd64:
+		return rewriteValuedec_OpVecAdd64(v)
+	case OpVecAdd8:
+		return rewriteValuedec_OpVecAdd8(v)
+	case OpVecAddF32:
+		return rewriteValuedec_OpVecAddF32(v)
+	case OpVecAddF64:
+		return rewriteValuedec_OpVecAddF64(v)
+	case OpVecAnd:
+		return rewriteValuedec_OpVecAnd(v)
+	case OpVecAndNot:
+		return rewriteValuedec_OpVecAndNot(v)
+	case OpVecDivF32:
+		return rewriteValuedec_OpVecDivF32(v)
+	case OpVecDivF64:
+		return rewriteValuedec_OpVecDivF64(v)
+	case OpVecEq32:
+		return rewriteValuedec_OpVecEq32(v)
+	case OpVecEq64:
+		return rewriteValuedec_OpVecEq64(v)
+	case OpVecEq8:
+		return rewriteValuedec_OpVecEq8(v)
+	case OpVecGt32:
+		return rewriteValuedec_OpVecGt32(v)
+	case OpVecGt64:
+		return rewriteValuedec_OpVecGt64(v)
+	case OpVecMax32:
+		return rewriteValuedec_OpVecMax32(v)
+	case OpVecMax64:
+		return rewriteValuedec_OpVecMax64(v)
+	case OpVecMaxU8:
+		return rewriteValuedec_OpVecMaxU8(v)
+	case OpVecMin32:
+		return rewriteValuedec_OpVecMin32(v)
+	case OpVecMin64:
+		return rewriteValuedec_OpVecMin64(v)
+	case OpVecMinU8:
+		return rewriteValuedec_OpVecMinU8(v)
+	case OpVecMul32:
+		return rewriteValuedec_OpVecMul32(v)
+	case OpVecMul64:
+		return rewriteValuedec_OpVecMul64(v)
+	case OpVecMulF32:
+		return rewriteValuedec_OpVecMulF32(v)
+	case OpVecMulF64:
+		return rewriteValuedec_OpVecMulF64(v)
+	case OpVecOr:
+		return rewriteValuedec_OpVecOr(v)
+	case OpVecSignBits8:
+		return rewriteValuedec_OpVecSignBits8(v)
+	case OpVecSqrtF32:
+		return rewriteValuedec_OpVecSqrtF32(v)
+	case OpVecSqrtF64:
+		return rewriteValuedec_OpVecSqrtF64(v)
+	case OpVecSub32:
+		return rewriteValuedec_OpVecSub32(v)
+	case OpVecSub64:
+		return rewriteValuedec_OpVecSub64(v)
+	case OpVecSub8:
+		return rewriteValuedec_OpVecSub8(v)
+	case OpVecSubF32:
+		return rewriteValuedec_OpVecSubF32(v)
+	case OpVecSubF64:
+		return rewriteValuedec_OpVecSubF64(v)
+	case OpVecXor:
+		return rewriteValuedec_OpVecXor(v)
+	case OpVecZero:
+		return rewriteValuedec_OpVecZero(v)
 	}
 	return false
 }
@@ -445,6 +519,27 @@ func rewriteValuedec_OpLoad(v *Value) bool {
 		v.AddArg2(v0, v1)
 		return true
 	}
+	// match: (Load <t> ptr mem)
+	// cond: t.IsSIMD() && t.Size() > int64(types.SIMDRegSize)
+	// result: (Vec256Make (Load <types.TypeVec128> ptr mem) (Load <types.TypeVec128> (OffPtr <typ.BytePtr> [16] ptr) mem))
+	for {
+		t := v.Type
+		ptr := v_0
+		mem := v_1
+		if !(t.IsSIMD() && t.Size() > int64(types.SIMDRegSize)) {
+			break
+		}
+		v.reset(OpVec256Make)
+		v0 := b.NewValue0(v.Pos, OpLoad, types.TypeVec128)
+		v0.AddArg2(ptr, mem)
+		v1 := b.NewValue0(v.Pos, OpLoad, types.TypeVec128)
+		v2 := b.NewValue0(v.Pos, OpOffPtr, typ.BytePtr)
+		v2.AuxInt = int64ToAuxInt(16)
+		v2.AddArg(ptr)
+		v1.AddArg2(v2, mem)
+		v.AddArg2(v0, v1)
+		return true
+	}
 	return false
 }
 func rewriteValuedec_OpSliceCap(v *Value) bool {
@@ -718,6 +813,32 @@ func rewriteValuedec_OpStore(v *Value) bool {
 		v.AddArg3(v0, data, v1)
 		return true
 	}
+	// match: (Store {t} dst x mem)
+	// cond: t.IsSIMD() && t.Size() > int64(types.SIMDRegSize)
+	// result: (Store {types.TypeVec128} (OffPtr <typ.BytePtr> [16] dst) (Vec256Hi x) (Store {types.TypeVec128} dst (Vec256Lo x) mem))
+	for {
+		t := auxToType(v.Aux)
+		dst := v_0
+		x := v_1
+		mem := v_2
+		if !(t.IsSIMD() && t.Size() > int64(types.SIMDRegSize)) {
+			break
+		}
+		v.reset(OpStore)
+		v.Aux = typeToAux(types.TypeVec128)
+		v0 := b.NewValue0(v.Pos, OpOffPtr, typ.BytePtr)
+		v0.AuxInt = int64ToAuxInt(16)
+		v0.AddArg(dst)
+		v1 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v1.AddArg(x)
+		v2 := b.NewValue0(v.Pos, OpStore, types.TypeMem)
+		v2.Aux = typeToAux(types.TypeVec128)
+		v3 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v3.AddArg(x)
+		v2.AddArg3(dst, v3, mem)
+		v.AddArg3(v0, v1, v2)
+		return true
+	}
 	// match: (Store dst (StructMake1 <t> f0) mem)
 	// result: (Store {t.FieldType(0)} (OffPtr <t.FieldType(0).PtrTo()> [0] dst) f0 mem)
 	for {
@@ -1089,6 +1210,1125 @@ func rewriteValuedec_OpStructSelect(v *Value) bool {
 	}
 	return false
 }
+func rewriteValuedec_OpVec256Hi(v *Value) bool {
+	v_0 := v.Args[0]
+	// match: (Vec256Hi (Vec256Make _ hi))
+	// result: hi
+	for {
+		if v_0.Op != OpVec256Make {
+			break
+		}
+		hi := v_0.Args[1]
+		v.copyOf(hi)
+		return true
+	}
+	return false
+}
+func rewriteValuedec_OpVec256Lo(v *Value) bool {
+	v_0 := v.Args[0]
+	// match: (Vec256Lo (Vec256Make lo _))
+	// result: lo
+	for {
+		if v_0.Op != OpVec256Make {
+			break
+		}
+		lo := v_0.Args[0]
+		v.copyOf(lo)
+		return true
+	}
+	return false
+}
+func rewriteValuedec_OpVecAdd32(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	b := v.Block
+	// match: (VecAdd32 <t> x y)
+	// cond: t.Size() > int64(types.SIMDRegSize)
+	// result: (Vec256Make (VecAdd32 <types.TypeVec128> (Vec256Lo x) (Vec256Lo y)) (VecAdd32 <types.TypeVec128> (Vec256Hi x) (Vec256Hi y)))
+	for {
+		t := v.Type
+		x := v_0
+		y := v_1
+		if !(t.Size() > int64(types.SIMDRegSize)) {
+			break
+		}
+		v.reset(OpVec256Make)
+		v0 := b.NewValue0(v.Pos, OpVecAdd32, types.TypeVec128)
+		v1 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v1.AddArg(x)
+		v2 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v2.AddArg(y)
+		v0.AddArg2(v1, v2)
+		v3 := b.NewValue0(v.Pos, OpVecAdd32, types.TypeVec128)
+		v4 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v4.AddArg(x)
+		v5 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v5.AddArg(y)
+		v3.AddArg2(v4, v5)
+		v.AddArg2(v0, v3)
+		return true
+	}
+	return false
+}
+func rewriteValuedec_OpVecAdd64(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	b := v.Block
+	// match: (VecAdd64 <t> x y)
+	// cond: t.Size() > int64(types.SIMDRegSize)
+	// result: (Vec256Make (VecAdd64 <types.TypeVec128> (Vec256Lo x) (Vec256Lo y)) (VecAdd64 <types.TypeVec128> (Vec256Hi x) (Vec256Hi y)))
+	for {
+		t := v.Type
+		x := v_0
+		y := v_1
+		if !(t.Size() > int64(types.SIMDRegSize)) {
+			break
+		}
+		v.reset(OpVec256Make)
+		v0 := b.NewValue0(v.Pos, OpVecAdd64, types.TypeVec128)
+		v1 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v1.AddArg(x)
+		v2 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v2.AddArg(y)
+		v0.AddArg2(v1, v2)
+		v3 := b.NewValue0(v.Pos, OpVecAdd64, types.TypeVec128)
+		v4 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v4.AddArg(x)
+		v5 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v5.AddArg(y)
+		v3.AddArg2(v4, v5)
+		v.AddArg2(v0, v3)
+		return true
+	}
+	return false
+}
+func rewriteValuedec_OpVecAdd8(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	b := v.Block
+	// match: (VecAdd8 <t> x y)
+	// cond: t.Size() > int64(types.SIMDRegSize)
+	// result: (Vec256Make (VecAdd8 <types.TypeVec128> (Vec256Lo x) (Vec256Lo y)) (VecAdd8 <types.TypeVec128> (Vec256Hi x) (Vec256Hi y)))
+	for {
+		t := v.Type
+		x := v_0
+		y := v_1
+		if !(t.Size() > int64(types.SIMDRegSize)) {
+			break
+		}
+		v.reset(OpVec256Make)
+		v0 := b.NewValue0(v.Pos, OpVecAdd8, types.TypeVec128)
+		v1 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v1.AddArg(x)
+		v2 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v2.AddArg(y)
+		v0.AddArg2(v1, v2)
+		v3 := b.NewValue0(v.Pos, OpVecAdd8, types.TypeVec128)
+		v4 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v4.AddArg(x)
+		v5 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v5.AddArg(y)
+		v3.AddArg2(v4, v5)
+		v.AddArg2(v0, v3)
+		return true
+	}
+	return false
+}
+func rewriteValuedec_OpVecAddF32(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	b := v.Block
+	// match: (VecAddF32 <t> x y)
+	// cond: t.Size() > int64(types.SIMDRegSize)
+	// result: (Vec256Make (VecAddF32 <types.TypeVec128> (Vec256Lo x) (Vec256Lo y)) (VecAddF32 <types.TypeVec128> (Vec256Hi x) (Vec256Hi y)))
+	for {
+		t := v.Type
+		x := v_0
+		y := v_1
+		if !(t.Size() > int64(types.SIMDRegSize)) {
+			break
+		}
+		v.reset(OpVec256Make)
+		v0 := b.NewValue0(v.Pos, OpVecAddF32, types.TypeVec128)
+		v1 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v1.AddArg(x)
+		v2 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v2.AddArg(y)
+		v0.AddArg2(v1, v2)
+		v3 := b.NewValue0(v.Pos, OpVecAddF32, types.TypeVec128)
+		v4 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v4.AddArg(x)
+		v5 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v5.AddArg(y)
+		v3.AddArg2(v4, v5)
+		v.AddArg2(v0, v3)
+		return true
+	}
+	return false
+}
+func rewriteValuedec_OpVecAddF64(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	b := v.Block
+	// match: (VecAddF64 <t> x y)
+	// cond: t.Size() > int64(types.SIMDRegSize)
+	// result: (Vec256Make (VecAddF64 <types.TypeVec128> (Vec256Lo x) (Vec256Lo y)) (VecAddF64 <types.TypeVec128> (Vec256Hi x) (Vec256Hi y)))
+	for {
+		t := v.Type
+		x := v_0
+		y := v_1
+		if !(t.Size() > int64(types.SIMDRegSize)) {
+			break
+		}
+		v.reset(OpVec256Make)
+		v0 := b.NewValue0(v.Pos, OpVecAddF64, types.TypeVec128)
+		v1 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v1.AddArg(x)
+		v2 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v2.AddArg(y)
+		v0.AddArg2(v1, v2)
+		v3 := b.NewValue0(v.Pos, OpVecAddF64, types.TypeVec128)
+		v4 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v4.AddArg(x)
+		v5 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v5.AddArg(y)
+		v3.AddArg2(v4, v5)
+		v.AddArg2(v0, v3)
+		return true
+	}
+	return false
+}
+func rewriteValuedec_OpVecAnd(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	b := v.Block
+	// match: (VecAnd <t> x y)
+	// cond: t.Size() > int64(types.SIMDRegSize)
+	// result: (Vec256Make (VecAnd <types.TypeVec128> (Vec256Lo x) (Vec256Lo y)) (VecAnd <types.TypeVec128> (Vec256Hi x) (Vec256Hi y)))
+	for {
+		t := v.Type
+		x := v_0
+		y := v_1
+		if !(t.Size() > int64(types.SIMDRegSize)) {
+			break
+		}
+		v.reset(OpVec256Make)
+		v0 := b.NewValue0(v.Pos, OpVecAnd, types.TypeVec128)
+		v1 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v1.AddArg(x)
+		v2 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v2.AddArg(y)
+		v0.AddArg2(v1, v2)
+		v3 := b.NewValue0(v.Pos, OpVecAnd, types.TypeVec128)
+		v4 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v4.AddArg(x)
+		v5 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v5.AddArg(y)
+		v3.AddArg2(v4, v5)
+		v.AddArg2(v0, v3)
+		return true
+	}
+	return false
+}
+func rewriteValuedec_OpVecAndNot(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	b := v.Block
+	// match: (VecAndNot <t> x y)
+	// cond: t.Size() > int64(types.SIMDRegSize)
+	// result: (Vec256Make (VecAndNot <types.TypeVec128> (Vec256Lo x) (Vec256Lo y)) (VecAndNot <types.TypeVec128> (Vec256Hi x) (Vec256Hi y)))
+	for {
+		t := v.Type
+		x := v_0
+		y := v_1
+		if !(t.Size() > int64(types.SIMDRegSize)) {
+			break
+		}
+		v.reset(OpVec256Make)
+		v0 := b.NewValue0(v.Pos, OpVecAndNot, types.TypeVec128)
+		v1 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v1.AddArg(x)
+		v2 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v2.AddArg(y)
+		v0.AddArg2(v1, v2)
+		v3 := b.NewValue0(v.Pos, OpVecAndNot, types.TypeVec128)
+		v4 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v4.AddArg(x)
+		v5 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v5.AddArg(y)
+		v3.AddArg2(v4, v5)
+		v.AddArg2(v0, v3)
+		return true
+	}
+	return false
+}
+func rewriteValuedec_OpVecDivF32(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	b := v.Block
+	// match: (VecDivF32 <t> x y)
+	// cond: t.Size() > int64(types.SIMDRegSize)
+	// result: (Vec256Make (VecDivF32 <types.TypeVec128> (Vec256Lo x) (Vec256Lo y)) (VecDivF32 <types.TypeVec128> (Vec256Hi x) (Vec256Hi y)))
+	for {
+		t := v.Type
+		x := v_0
+		y := v_1
+		if !(t.Size() > int64(types.SIMDRegSize)) {
+			break
+		}
+		v.reset(OpVec256Make)
+		v0 := b.NewValue0(v.Pos, OpVecDivF32, types.TypeVec128)
+		v1 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v1.AddArg(x)
+		v2 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v2.AddArg(y)
+		v0.AddArg2(v1, v2)
+		v3 := b.NewValue0(v.Pos, OpVecDivF32, types.TypeVec128)
+		v4 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v4.AddArg(x)
+		v5 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v5.AddArg(y)
+		v3.AddArg2(v4, v5)
+		v.AddArg2(v0, v3)
+		return true
+	}
+	return false
+}
+func rewriteValuedec_OpVecDivF64(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	b := v.Block
+	// match: (VecDivF64 <t> x y)
+	// cond: t.Size() > int64(types.SIMDRegSize)
+	// result: (Vec256Make (VecDivF64 <types.TypeVec128> (Vec256Lo x) (Vec256Lo y)) (VecDivF64 <types.TypeVec128> (Vec256Hi x) (Vec256Hi y)))
+	for {
+		t := v.Type
+		x := v_0
+		y := v_1
+		if !(t.Size() > int64(types.SIMDRegSize)) {
+			break
+		}
+		v.reset(OpVec256Make)
+		v0 := b.NewValue0(v.Pos, OpVecDivF64, types.TypeVec128)
+		v1 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v1.AddArg(x)
+		v2 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v2.AddArg(y)
+		v0.AddArg2(v1, v2)
+		v3 := b.NewValue0(v.Pos, OpVecDivF64, types.TypeVec128)
+		v4 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v4.AddArg(x)
+		v5 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v5.AddArg(y)
+		v3.AddArg2(v4, v5)
+		v.AddArg2(v0, v3)
+		return true
+	}
+	return false
+}
+func rewriteValuedec_OpVecEq32(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	b := v.Block
+	// match: (VecEq32 <t> x y)
+	// cond: t.Size() > int64(types.SIMDRegSize)
+	// result: (Vec256Make (VecEq32 <types.TypeVec128> (Vec256Lo x) (Vec256Lo y)) (VecEq32 <types.TypeVec128> (Vec256Hi x) (Vec256Hi y)))
+	for {
+		t := v.Type
+		x := v_0
+		y := v_1
+		if !(t.Size() > int64(types.SIMDRegSize)) {
+			break
+		}
+		v.reset(OpVec256Make)
+		v0 := b.NewValue0(v.Pos, OpVecEq32, types.TypeVec128)
+		v1 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v1.AddArg(x)
+		v2 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v2.AddArg(y)
+		v0.AddArg2(v1, v2)
+		v3 := b.NewValue0(v.Pos, OpVecEq32, types.TypeVec128)
+		v4 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v4.AddArg(x)
+		v5 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v5.AddArg(y)
+		v3.AddArg2(v4, v5)
+		v.AddArg2(v0, v3)
+		return true
+	}
+	return false
+}
+func rewriteValuedec_OpVecEq64(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	b := v.Block
+	// match: (VecEq64 <t> x y)
+	// cond: t.Size() > int64(types.SIMDRegSize)
+	// result: (Vec256Make (VecEq64 <types.TypeVec128> (Vec256Lo x) (Vec256Lo y)) (VecEq64 <types.TypeVec128> (Vec256Hi x) (Vec256Hi y)))
+	for {
+		t := v.Type
+		x := v_0
+		y := v_1
+		if !(t.Size() > int64(types.SIMDRegSize)) {
+			break
+		}
+		v.reset(OpVec256Make)
+		v0 := b.NewValue0(v.Pos, OpVecEq64, types.TypeVec128)
+		v1 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v1.AddArg(x)
+		v2 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v2.AddArg(y)
+		v0.AddArg2(v1, v2)
+		v3 := b.NewValue0(v.Pos, OpVecEq64, types.TypeVec128)
+		v4 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v4.AddArg(x)
+		v5 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v5.AddArg(y)
+		v3.AddArg2(v4, v5)
+		v.AddArg2(v0, v3)
+		return true
+	}
+	return false
+}
+func rewriteValuedec_OpVecEq8(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	b := v.Block
+	// match: (VecEq8 <t> x y)
+	// cond: t.Size() > int64(types.SIMDRegSize)
+	// result: (Vec256Make (VecEq8 <types.TypeVec128> (Vec256Lo x) (Vec256Lo y)) (VecEq8 <types.TypeVec128> (Vec256Hi x) (Vec256Hi y)))
+	for {
+		t := v.Type
+		x := v_0
+		y := v_1
+		if !(t.Size() > int64(types.SIMDRegSize)) {
+			break
+		}
+		v.reset(OpVec256Make)
+		v0 := b.NewValue0(v.Pos, OpVecEq8, types.TypeVec128)
+		v1 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v1.AddArg(x)
+		v2 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v2.AddArg(y)
+		v0.AddArg2(v1, v2)
+		v3 := b.NewValue0(v.Pos, OpVecEq8, types.TypeVec128)
+		v4 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v4.AddArg(x)
+		v5 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v5.AddArg(y)
+		v3.AddArg2(v4, v5)
+		v.AddArg2(v0, v3)
+		return true
+	}
+	return false
+}
+func rewriteValuedec_OpVecGt32(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	b := v.Block
+	// match: (VecGt32 <t> x y)
+	// cond: t.Size() > int64(types.SIMDRegSize)
+	// result: (Vec256Make (VecGt32 <types.TypeVec128> (Vec256Lo x) (Vec256Lo y)) (VecGt32 <types.TypeVec128> (Vec256Hi x) (Vec256Hi y)))
+	for {
+		t := v.Type
+		x := v_0
+		y := v_1
+		if !(t.Size() > int64(types.SIMDRegSize)) {
+			break
+		}
+		v.reset(OpVec256Make)
+		v0 := b.NewValue0(v.Pos, OpVecGt32, types.TypeVec128)
+		v1 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v1.AddArg(x)
+		v2 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v2.AddArg(y)
+		v0.AddArg2(v1, v2)
+		v3 := b.NewValue0(v.Pos, OpVecGt32, types.TypeVec128)
+		v4 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v4.AddArg(x)
+		v5 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v5.AddArg(y)
+		v3.AddArg2(v4, v5)
+		v.AddArg2(v0, v3)
+		return true
+	}
+	return false
+}
+func rewriteValuedec_OpVecGt64(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	b := v.Block
+	// match: (VecGt64 <t> x y)
+	// cond: t.Size() > int64(types.SIMDRegSize)
+	// result: (Vec256Make (VecGt64 <types.TypeVec128> (Vec256Lo x) (Vec256Lo y)) (VecGt64 <types.TypeVec128> (Vec256Hi x) (Vec256Hi y)))
+	for {
+		t := v.Type
+		x := v_0
+		y := v_1
+		if !(t.Size() > int64(types.SIMDRegSize)) {
+			break
+		}
+		v.reset(OpVec256Make)
+		v0 := b.NewValue0(v.Pos, OpVecGt64, types.TypeVec128)
+		v1 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v1.AddArg(x)
+		v2 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v2.AddArg(y)
+		v0.AddArg2(v1, v2)
+		v3 := b.NewValue0(v.Pos, OpVecGt64, types.TypeVec128)
+		v4 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v4.AddArg(x)
+		v5 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v5.AddArg(y)
+		v3.AddArg2(v4, v5)
+		v.AddArg2(v0, v3)
+		return true
+	}
+	return false
+}
+func rewriteValuedec_OpVecMax32(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	b := v.Block
+	// match: (VecMax32 <t> x y)
+	// cond: t.Size() > int64(types.SIMDRegSize)
+	// result: (Vec256Make (VecMax32 <types.TypeVec128> (Vec256Lo x) (Vec256Lo y)) (VecMax32 <types.TypeVec128> (Vec256Hi x) (Vec256Hi y)))
+	for {
+		t := v.Type
+		x := v_0
+		y := v_1
+		if !(t.Size() > int64(types.SIMDRegSize)) {
+			break
+		}
+		v.reset(OpVec256Make)
+		v0 := b.NewValue0(v.Pos, OpVecMax32, types.TypeVec128)
+		v1 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v1.AddArg(x)
+		v2 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v2.AddArg(y)
+		v0.AddArg2(v1, v2)
+		v3 := b.NewValue0(v.Pos, OpVecMax32, types.TypeVec128)
+		v4 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v4.AddArg(x)
+		v5 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v5.AddArg(y)
+		v3.AddArg2(v4, v5)
+		v.AddArg2(v0, v3)
+		return true
+	}
+	return false
+}
+func rewriteValuedec_OpVecMax64(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	b := v.Block
+	// match: (VecMax64 <t> x y)
+	// cond: t.Size() > int64(types.SIMDRegSize)
+	// result: (Vec256Make (VecMax64 <types.TypeVec128> (Vec256Lo x) (Vec256Lo y)) (VecMax64 <types.TypeVec128> (Vec256Hi x) (Vec256Hi y)))
+	for {
+		t := v.Type
+		x := v_0
+		y := v_1
+		if !(t.Size() > int64(types.SIMDRegSize)) {
+			break
+		}
+		v.reset(OpVec256Make)
+		v0 := b.NewValue0(v.Pos, OpVecMax64, types.TypeVec128)
+		v1 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v1.AddArg(x)
+		v2 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v2.AddArg(y)
+		v0.AddArg2(v1, v2)
+		v3 := b.NewValue0(v.Pos, OpVecMax64, types.TypeVec128)
+		v4 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v4.AddArg(x)
+		v5 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v5.AddArg(y)
+		v3.AddArg2(v4, v5)
+		v.AddArg2(v0, v3)
+		return true
+	}
+	return false
+}
+func rewriteValuedec_OpVecMaxU8(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	b := v.Block
+	// match: (VecMaxU8 <t> x y)
+	// cond: t.Size() > int64(types.SIMDRegSize)
+	// result: (Vec256Make (VecMaxU8 <types.TypeVec128> (Vec256Lo x) (Vec256Lo y)) (VecMaxU8 <types.TypeVec128> (Vec256Hi x) (Vec256Hi y)))
+	for {
+		t := v.Type
+		x := v_0
+		y := v_1
+		if !(t.Size() > int64(types.SIMDRegSize)) {
+			break
+		}
+		v.reset(OpVec256Make)
+		v0 := b.NewValue0(v.Pos, OpVecMaxU8, types.TypeVec128)
+		v1 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v1.AddArg(x)
+		v2 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v2.AddArg(y)
+		v0.AddArg2(v1, v2)
+		v3 := b.NewValue0(v.Pos, OpVecMaxU8, types.TypeVec128)
+		v4 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v4.AddArg(x)
+		v5 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v5.AddArg(y)
+		v3.AddArg2(v4, v5)
+		v.AddArg2(v0, v3)
+		return true
+	}
+	return false
+}
+func rewriteValuedec_OpVecMin32(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	b := v.Block
+	// match: (VecMin32 <t> x y)
+	// cond: t.Size() > int64(types.SIMDRegSize)
+	// result: (Vec256Make (VecMin32 <types.TypeVec128> (Vec256Lo x) (Vec256Lo y)) (VecMin32 <types.TypeVec128> (Vec256Hi x) (Vec256Hi y)))
+	for {
+		t := v.Type
+		x := v_0
+		y := v_1
+		if !(t.Size() > int64(types.SIMDRegSize)) {
+			break
+		}
+		v.reset(OpVec256Make)
+		v0 := b.NewValue0(v.Pos, OpVecMin32, types.TypeVec128)
+		v1 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v1.AddArg(x)
+		v2 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v2.AddArg(y)
+		v0.AddArg2(v1, v2)
+		v3 := b.NewValue0(v.Pos, OpVecMin32, types.TypeVec128)
+		v4 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v4.AddArg(x)
+		v5 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v5.AddArg(y)
+		v3.AddArg2(v4, v5)
+		v.AddArg2(v0, v3)
+		return true
+	}
+	return false
+}
+func rewriteValuedec_OpVecMin64(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	b := v.Block
+	// match: (VecMin64 <t> x y)
+	// cond: t.Size() > int64(types.SIMDRegSize)
+	// result: (Vec256Make (VecMin64 <types.TypeVec128> (Vec256Lo x) (Vec256Lo y)) (VecMin64 <types.TypeVec128> (Vec256Hi x) (Vec256Hi y)))
+	for {
+		t := v.Type
+		x := v_0
+		y := v_1
+		if !(t.Size() > int64(types.SIMDRegSize)) {
+			break
+		}
+		v.reset(OpVec256Make)
+		v0 := b.NewValue0(v.Pos, OpVecMin64, types.TypeVec128)
+		v1 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v1.AddArg(x)
+		v2 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v2.AddArg(y)
+		v0.AddArg2(v1, v2)
+		v3 := b.NewValue0(v.Pos, OpVecMin64, types.TypeVec128)
+		v4 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v4.AddArg(x)
+		v5 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v5.AddArg(y)
+		v3.AddArg2(v4, v5)
+		v.AddArg2(v0, v3)
+		return true
+	}
+	return false
+}
+func rewriteValuedec_OpVecMinU8(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	b := v.Block
+	// match: (VecMinU8 <t> x y)
+	// cond: t.Size() > int64(types.SIMDRegSize)
+	// result: (Vec256Make (VecMinU8 <types.TypeVec128> (Vec256Lo x) (Vec256Lo y)) (VecMinU8 <types.TypeVec128> (Vec256Hi x) (Vec256Hi y)))
+	for {
+		t := v.Type
+		x := v_0
+		y := v_1
+		if !(t.Size() > int64(types.SIMDRegSize)) {
+			break
+		}
+		v.reset(OpVec256Make)
+		v0 := b.NewValue0(v.Pos, OpVecMinU8, types.TypeVec128)
+		v1 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v1.AddArg(x)
+		v2 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v2.AddArg(y)
+		v0.AddArg2(v1, v2)
+		v3 := b.NewValue0(v.Pos, OpVecMinU8, types.TypeVec128)
+		v4 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v4.AddArg(x)
+		v5 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v5.AddArg(y)
+		v3.AddArg2(v4, v5)
+		v.AddArg2(v0, v3)
+		return true
+	}
+	return false
+}
+func rewriteValuedec_OpVecMul32(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	b := v.Block
+	// match: (VecMul32 <t> x y)
+	// cond: t.Size() > int64(types.SIMDRegSize)
+	// result: (Vec256Make (VecMul32 <types.TypeVec128> (Vec256Lo x) (Vec256Lo y)) (VecMul32 <types.TypeVec128> (Vec256Hi x) (Vec256Hi y)))
+	for {
+		t := v.Type
+		x := v_0
+		y := v_1
+		if !(t.Size() > int64(types.SIMDRegSize)) {
+			break
+		}
+		v.reset(OpVec256Make)
+		v0 := b.NewValue0(v.Pos, OpVecMul32, types.TypeVec128)
+		v1 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v1.AddArg(x)
+		v2 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v2.AddArg(y)
+		v0.AddArg2(v1, v2)
+		v3 := b.NewValue0(v.Pos, OpVecMul32, types.TypeVec128)
+		v4 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v4.AddArg(x)
+		v5 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v5.AddArg(y)
+		v3.AddArg2(v4, v5)
+		v.AddArg2(v0, v3)
+		return true
+	}
+	return false
+}
+func rewriteValuedec_OpVecMul64(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	b := v.Block
+	// match: (VecMul64 <t> x y)
+	// cond: t.Size() > int64(types.SIMDRegSize)
+	// result: (Vec256Make (VecMul64 <types.TypeVec128> (Vec256Lo x) (Vec256Lo y)) (VecMul64 <types.TypeVec128> (Vec256Hi x) (Vec256Hi y)))
+	for {
+		t := v.Type
+		x := v_0
+		y := v_1
+		if !(t.Size() > int64(types.SIMDRegSize)) {
+			break
+		}
+		v.reset(OpVec256Make)
+		v0 := b.NewValue0(v.Pos, OpVecMul64, types.TypeVec128)
+		v1 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v1.AddArg(x)
+		v2 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v2.AddArg(y)
+		v0.AddArg2(v1, v2)
+		v3 := b.NewValue0(v.Pos, OpVecMul64, types.TypeVec128)
+		v4 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v4.AddArg(x)
+		v5 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v5.AddArg(y)
+		v3.AddArg2(v4, v5)
+		v.AddArg2(v0, v3)
+		return true
+	}
+	return false
+}
+func rewriteValuedec_OpVecMulF32(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	b := v.Block
+	// match: (VecMulF32 <t> x y)
+	// cond: t.Size() > int64(types.SIMDRegSize)
+	// result: (Vec256Make (VecMulF32 <types.TypeVec128> (Vec256Lo x) (Vec256Lo y)) (VecMulF32 <types.TypeVec128> (Vec256Hi x) (Vec256Hi y)))
+	for {
+		t := v.Type
+		x := v_0
+		y := v_1
+		if !(t.Size() > int64(types.SIMDRegSize)) {
+			break
+		}
+		v.reset(OpVec256Make)
+		v0 := b.NewValue0(v.Pos, OpVecMulF32, types.TypeVec128)
+		v1 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v1.AddArg(x)
+		v2 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v2.AddArg(y)
+		v0.AddArg2(v1, v2)
+		v3 := b.NewValue0(v.Pos, OpVecMulF32, types.TypeVec128)
+		v4 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v4.AddArg(x)
+		v5 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v5.AddArg(y)
+		v3.AddArg2(v4, v5)
+		v.AddArg2(v0, v3)
+		return true
+	}
+	return false
+}
+func rewriteValuedec_OpVecMulF64(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	b := v.Block
+	// match: (VecMulF64 <t> x y)
+	// cond: t.Size() > int64(types.SIMDRegSize)
+	// result: (Vec256Make (VecMulF64 <types.TypeVec128> (Vec256Lo x) (Vec256Lo y)) (VecMulF64 <types.TypeVec128> (Vec256Hi x) (Vec256Hi y)))
+	for {
+		t := v.Type
+		x := v_0
+		y := v_1
+		if !(t.Size() > int64(types.SIMDRegSize)) {
+			break
+		}
+		v.reset(OpVec256Make)
+		v0 := b.NewValue0(v.Pos, OpVecMulF64, types.TypeVec128)
+		v1 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v1.AddArg(x)
+		v2 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v2.AddArg(y)
+		v0.AddArg2(v1, v2)
+		v3 := b.NewValue0(v.Pos, OpVecMulF64, types.TypeVec128)
+		v4 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v4.AddArg(x)
+		v5 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v5.AddArg(y)
+		v3.AddArg2(v4, v5)
+		v.AddArg2(v0, v3)
+		return true
+	}
+	return false
+}
+func rewriteValuedec_OpVecOr(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	b := v.Block
+	// match: (VecOr <t> x y)
+	// cond: t.Size() > int64(types.SIMDRegSize)
+	// result: (Vec256Make (VecOr <types.TypeVec128> (Vec256Lo x) (Vec256Lo y)) (VecOr <types.TypeVec128> (Vec256Hi x) (Vec256Hi y)))
+	for {
+		t := v.Type
+		x := v_0
+		y := v_1
+		if !(t.Size() > int64(types.SIMDRegSize)) {
+			break
+		}
+		v.reset(OpVec256Make)
+		v0 := b.NewValue0(v.Pos, OpVecOr, types.TypeVec128)
+		v1 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v1.AddArg(x)
+		v2 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v2.AddArg(y)
+		v0.AddArg2(v1, v2)
+		v3 := b.NewValue0(v.Pos, OpVecOr, types.TypeVec128)
+		v4 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v4.AddArg(x)
+		v5 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v5.AddArg(y)
+		v3.AddArg2(v4, v5)
+		v.AddArg2(v0, v3)
+		return true
+	}
+	return false
+}
+func rewriteValuedec_OpVecSignBits8(v *Value) bool {
+	v_0 := v.Args[0]
+	b := v.Block
+	typ := &b.Func.Config.Types
+	// match: (VecSignBits8 x)
+	// cond: x.Type.Size() > int64(types.SIMDRegSize)
+	// result: (Or32 (VecSignBits8 (Vec256Lo x)) (Lsh32x64 <typ.UInt32> (VecSignBits8 (Vec256Hi x)) (Const64 <typ.UInt64> [16])))
+	for {
+		x := v_0
+		if !(x.Type.Size() > int64(types.SIMDRegSize)) {
+			break
+		}
+		v.reset(OpOr32)
+		v0 := b.NewValue0(v.Pos, OpVecSignBits8, typ.UInt32)
+		v1 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v1.AddArg(x)
+		v0.AddArg(v1)
+		v2 := b.NewValue0(v.Pos, OpLsh32x64, typ.UInt32)
+		v3 := b.NewValue0(v.Pos, OpVecSignBits8, typ.UInt32)
+		v4 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v4.AddArg(x)
+		v3.AddArg(v4)
+		v5 := b.NewValue0(v.Pos, OpConst64, typ.UInt64)
+		v5.AuxInt = int64ToAuxInt(16)
+		v2.AddArg2(v3, v5)
+		v.AddArg2(v0, v2)
+		return true
+	}
+	return false
+}
+func rewriteValuedec_OpVecSqrtF32(v *Value) bool {
+	v_0 := v.Args[0]
+	b := v.Block
+	// match: (VecSqrtF32 <t> x)
+	// cond: t.Size() > int64(types.SIMDRegSize)
+	// result: (Vec256Make (VecSqrtF32 <types.TypeVec128> (Vec256Lo x)) (VecSqrtF32 <types.TypeVec128> (Vec256Hi x)))
+	for {
+		t := v.Type
+		x := v_0
+		if !(t.Size() > int64(types.SIMDRegSize)) {
+			break
+		}
+		v.reset(OpVec256Make)
+		v0 := b.NewValue0(v.Pos, OpVecSqrtF32, types.TypeVec128)
+		v1 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v1.AddArg(x)
+		v0.AddArg(v1)
+		v2 := b.NewValue0(v.Pos, OpVecSqrtF32, types.TypeVec128)
+		v3 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v3.AddArg(x)
+		v2.AddArg(v3)
+		v.AddArg2(v0, v2)
+		return true
+	}
+	return false
+}
+func rewriteValuedec_OpVecSqrtF64(v *Value) bool {
+	v_0 := v.Args[0]
+	b := v.Block
+	// match: (VecSqrtF64 <t> x)
+	// cond: t.Size() > int64(types.SIMDRegSize)
+	// result: (Vec256Make (VecSqrtF64 <types.TypeVec128> (Vec256Lo x)) (VecSqrtF64 <types.TypeVec128> (Vec256Hi x)))
+	for {
+		t := v.Type
+		x := v_0
+		if !(t.Size() > int64(types.SIMDRegSize)) {
+			break
+		}
+		v.reset(OpVec256Make)
+		v0 := b.NewValue0(v.Pos, OpVecSqrtF64, types.TypeVec128)
+		v1 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v1.AddArg(x)
+		v0.AddArg(v1)
+		v2 := b.NewValue0(v.Pos, OpVecSqrtF64, types.TypeVec128)
+		v3 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v3.AddArg(x)
+		v2.AddArg(v3)
+		v.AddArg2(v0, v2)
+		return true
+	}
+	return false
+}
+func rewriteValuedec_OpVecSub32(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	b := v.Block
+	// match: (VecSub32 <t> x y)
+	// cond: t.Size() > int64(types.SIMDRegSize)
+	// result: (Vec256Make (VecSub32 <types.TypeVec128> (Vec256Lo x) (Vec256Lo y)) (VecSub32 <types.TypeVec128> (Vec256Hi x) (Vec256Hi y)))
+	for {
+		t := v.Type
+		x := v_0
+		y := v_1
+		if !(t.Size() > int64(types.SIMDRegSize)) {
+			break
+		}
+		v.reset(OpVec256Make)
+		v0 := b.NewValue0(v.Pos, OpVecSub32, types.TypeVec128)
+		v1 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v1.AddArg(x)
+		v2 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v2.AddArg(y)
+		v0.AddArg2(v1, v2)
+		v3 := b.NewValue0(v.Pos, OpVecSub32, types.TypeVec128)
+		v4 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v4.AddArg(x)
+		v5 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v5.AddArg(y)
+		v3.AddArg2(v4, v5)
+		v.AddArg2(v0, v3)
+		return true
+	}
+	return false
+}
+func rewriteValuedec_OpVecSub64(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	b := v.Block
+	// match: (VecSub64 <t> x y)
+	// cond: t.Size() > int64(types.SIMDRegSize)
+	// result: (Vec256Make (VecSub64 <types.TypeVec128> (Vec256Lo x) (Vec256Lo y)) (VecSub64 <types.TypeVec128> (Vec256Hi x) (Vec256Hi y)))
+	for {
+		t := v.Type
+		x := v_0
+		y := v_1
+		if !(t.Size() > int64(types.SIMDRegSize)) {
+			break
+		}
+		v.reset(OpVec256Make)
+		v0 := b.NewValue0(v.Pos, OpVecSub64, types.TypeVec128)
+		v1 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v1.AddArg(x)
+		v2 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v2.AddArg(y)
+		v0.AddArg2(v1, v2)
+		v3 := b.NewValue0(v.Pos, OpVecSub64, types.TypeVec128)
+		v4 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v4.AddArg(x)
+		v5 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v5.AddArg(y)
+		v3.AddArg2(v4, v5)
+		v.AddArg2(v0, v3)
+		return true
+	}
+	return false
+}
+func rewriteValuedec_OpVecSub8(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	b := v.Block
+	// match: (VecSub8 <t> x y)
+	// cond: t.Size() > int64(types.SIMDRegSize)
+	// result: (Vec256Make (VecSub8 <types.TypeVec128> (Vec256Lo x) (Vec256Lo y)) (VecSub8 <types.TypeVec128> (Vec256Hi x) (Vec256Hi y)))
+	for {
+		t := v.Type
+		x := v_0
+		y := v_1
+		if !(t.Size() > int64(types.SIMDRegSize)) {
+			break
+		}
+		v.reset(OpVec256Make)
+		v0 := b.NewValue0(v.Pos, OpVecSub8, types.TypeVec128)
+		v1 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v1.AddArg(x)
+		v2 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v2.AddArg(y)
+		v0.AddArg2(v1, v2)
+		v3 := b.NewValue0(v.Pos, OpVecSub8, types.TypeVec128)
+		v4 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v4.AddArg(x)
+		v5 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v5.AddArg(y)
+		v3.AddArg2(v4, v5)
+		v.AddArg2(v0, v3)
+		return true
+	}
+	return false
+}
+func rewriteValuedec_OpVecSubF32(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	b := v.Block
+	// match: (VecSubF32 <t> x y)
+	// cond: t.Size() > int64(types.SIMDRegSize)
+	// result: (Vec256Make (VecSubF32 <types.TypeVec128> (Vec256Lo x) (Vec256Lo y)) (VecSubF32 <types.TypeVec128> (Vec256Hi x) (Vec256Hi y)))
+	for {
+		t := v.Type
+		x := v_0
+		y := v_1
+		if !(t.Size() > int64(types.SIMDRegSize)) {
+			break
+		}
+		v.reset(OpVec256Make)
+		v0 := b.NewValue0(v.Pos, OpVecSubF32, types.TypeVec128)
+		v1 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v1.AddArg(x)
+		v2 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v2.AddArg(y)
+		v0.AddArg2(v1, v2)
+		v3 := b.NewValue0(v.Pos, OpVecSubF32, types.TypeVec128)
+		v4 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v4.AddArg(x)
+		v5 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v5.AddArg(y)
+		v3.AddArg2(v4, v5)
+		v.AddArg2(v0, v3)
+		return true
+	}
+	return false
+}
+func rewriteValuedec_OpVecSubF64(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	b := v.Block
+	// match: (VecSubF64 <t> x y)
+	// cond: t.Size() > int64(types.SIMDRegSize)
+	// result: (Vec256Make (VecSubF64 <types.TypeVec128> (Vec256Lo x) (Vec256Lo y)) (VecSubF64 <types.TypeVec128> (Vec256Hi x) (Vec256Hi y)))
+	for {
+		t := v.Type
+		x := v_0
+		y := v_1
+		if !(t.Size() > int64(types.SIMDRegSize)) {
+			break
+		}
+		v.reset(OpVec256Make)
+		v0 := b.NewValue0(v.Pos, OpVecSubF64, types.TypeVec128)
+		v1 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v1.AddArg(x)
+		v2 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v2.AddArg(y)
+		v0.AddArg2(v1, v2)
+		v3 := b.NewValue0(v.Pos, OpVecSubF64, types.TypeVec128)
+		v4 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v4.AddArg(x)
+		v5 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v5.AddArg(y)
+		v3.AddArg2(v4, v5)
+		v.AddArg2(v0, v3)
+		return true
+	}
+	return false
+}
+func rewriteValuedec_OpVecXor(v *Value) bool {
+	v_1 := v.Args[1]
+	v_0 := v.Args[0]
+	b := v.Block
+	// match: (VecXor <t> x y)
+	// cond: t.Size() > int64(types.SIMDRegSize)
+	// result: (Vec256Make (VecXor <types.TypeVec128> (Vec256Lo x) (Vec256Lo y)) (VecXor <types.TypeVec128> (Vec256Hi x) (Vec256Hi y)))
+	for {
+		t := v.Type
+		x := v_0
+		y := v_1
+		if !(t.Size() > int64(types.SIMDRegSize)) {
+			break
+		}
+		v.reset(OpVec256Make)
+		v0 := b.NewValue0(v.Pos, OpVecXor, types.TypeVec128)
+		v1 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v1.AddArg(x)
+		v2 := b.NewValue0(v.Pos, OpVec256Lo, types.TypeVec128)
+		v2.AddArg(y)
+		v0.AddArg2(v1, v2)
+		v3 := b.NewValue0(v.Pos, OpVecXor, types.TypeVec128)
+		v4 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v4.AddArg(x)
+		v5 := b.NewValue0(v.Pos, OpVec256Hi, types.TypeVec128)
+		v5.AddArg(y)
+		v3.AddArg2(v4, v5)
+		v.AddArg2(v0, v3)
+		return true
+	}
+	return false
+}
+func rewriteValuedec_OpVecZero(v *Value) bool {
+	b := v.Block
+	// match: (VecZero <t>)
+	// cond: t.Size() > int64(types.SIMDRegSize)
+	// result: (Vec256Make (VecZero <types.TypeVec128>) (VecZero <types.TypeVec128>))
+	for {
+		t := v.Type
+		if !(t.Size() > int64(types.SIMDRegSize)) {
+			break
+		}
+		v.reset(OpVec256Make)
+		v0 := b.NewValue0(v.Pos, OpVecZero, types.TypeVec128)
+		v.AddArg2(v0, v0)
+		return true
+	}
+	return false
+}
 func rewriteBlockdec(b *Block) bool {
 	return false
 }
@@ -0,0 +1,328 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ssa
+
+import "cmd/compile/internal/types"
+
+const (
+	// unrollBudget is the maximum number of values in the body of an
+	// unrolled loop, counting all of its copies.
+	unrollBudget = 32
+	// unrollMaxFactor is the maximum number of copies of a loop's body.
+	unrollMaxFactor = 4
+
+	// unrollHotBudget and unrollHotMaxFactor are used instead of
+	// unrollBudget and unrollMaxFactor in functions that the PGO
+	// profile shows to be hot.
+	unrollHotBudget    = 128
+	unrollHotMaxFactor = 8
+)
+
+// unroll unrolls small counted loops.
+//
+// It handles innermost loops made up of a header, which contains the
+// loop's phis and tests an induction variable found by findIndVar, and
+// a single body block with no calls, such as
+//
+//	for i := 0; i < len(s); i++ {
+//		sum += s[i]
+//	}
+//
+// Such a loop is rewritten into a loop that runs k copies of the body
+// per iteration for as long as at least k iterations remain, followed
+// by the original loop, which runs the remaining iterations:
+//
+//	for i := 0; i < len(s)-(k-1); i += k {
+//		sum += s[i]
+//		sum += s[i+1]
+//		...
+//		sum += s[i+k-1]
+//	}
+//	for ; i < len(s); i++ {
+//		sum += s[i]
+//	}
+//
+// The number of copies k is chosen so that the unrolled body stays within
+// a size budget, which is larger in functions that are hot according to
+// the PGO profile. Only loops counting up are unrolled, and only if the
+// limit of the unrolled loop can be computed without overflow.
+func unroll(f *Func) {
+	ivs := findIndVar(f)
+	if len(ivs) == 0 {
+		return
+	}
+	ln := f.loopnest()
+	if ln.hasIrreducible {
+		return
+	}
+	sdom := f.Sdom()
+
+	budget, maxFactor := unrollBudget, unrollMaxFactor
+	if f.PGOHot {
+		budget, maxFactor = unrollHotBudget, unrollHotMaxFactor
+	}
+
+	changed := false
+	for _, iv := range ivs {
+		if iv.flags&indVarCountDown != 0 {
+			continue
+		}
+		h := iv.ind.Block
+		l := ln.b2l[h.ID]
+		if l == nil || l.header != h || !l.isInner || l.nBlocks != 2 {
+			continue
+		}
+		body := iv.entry
+		if body.Kind != BlockPlain || body.Succs[0].b != h {
+			continue
+		}
+		pre := l.preheader(ln.b2l)
+		if pre == nil || !canUnroll(h, body) {
+			continue
+		}
+		// The limit of the unrolled loop is computed in the
+		// preheader, so the bounds must be loop-invariant.
+		if !sdom.isAncestor(iv.min.Block, h) || !sdom.isAncestor(iv.max.Block, h) {
+			continue
+		}
+
+		k := maxFactor
+		for k > 1 && k*len(body.Values) > budget {
+			k /= 2
+		}
+		if k < 2 {
+			continue
+		}
+		limit, ok := unrollLimit(pre, iv, k)
+		if limit == nil {
+			continue
+		}
+
+		unrollLoop(h, body, pre, iv, limit, ok, k)
+		changed = true
+		if f.pass.debug > 0 {
+			if f.PGOHot {
+				f.Warnl(h.Pos, "unrolled hot loop by %d", k)
+			} else {
+				f.Warnl(h.Pos, "unrolled loop by %d", k)
+			}
+		}
+	}
+	if changed {
+		f.invalidateCFG()
+	}
+}
+
+// canUnroll reports whether the loop made up of header h and body block
+// body has a shape that unroll can handle.
+func canUnroll(h, body *Block) bool {
+	if len(h.Preds) != 2 || len(body.Preds) != 1 {
+		return false
+	}
+	ctrl := h.Controls[0]
+	if ctrl.Block != h || ctrl.Uses != 1 {
+		return false
+	}
+	for _, v := range h.Values {
+		if v.Op != OpPhi && v != ctrl {
+			return false
+		}
+	}
+	for _, v := range body.Values {
+		if v.Op == OpPhi || opcodeTable[v.Op].call {
+			return false
+		}
+	}
+	return true
+}
+
+// unrollLimit returns the limit of the induction variable iv for the
+// loop unrolled by k, which runs its body only if at least k iterations
+// remain. It returns nil if the loop is known to run fewer than k times.
+//
+// If the limit may have overflowed, unrollLimit also returns a condition
+// that is true only if it did not, in which case the unrolled loop must
+// be skipped if the condition is false.
+func unrollLimit(pre *Block, iv indVar, k int) (limit, ok *Value) {
+	f := pre.Func
+	t := iv.ind.Type
+	step := iv.nxt.Args[0].AuxInt
+	if iv.nxt.Args[0] == iv.ind {
+		step = iv.nxt.Args[1].AuxInt
+	}
+	if step > maxSignedValue(t)/int64(k-1) {
+		return nil, nil
+	}
+	d := step * int64(k-1)
+
+	if iv.max.isGenericIntConst() {
+		max := iv.max.AuxInt
+		if max < minSignedValue(t)+d {
+			return nil, nil
+		}
+		if iv.min.isGenericIntConst() {
+			n := max
+			if iv.flags&indVarMaxInc != 0 {
+				n++ // can't overflow: findIndVar checked that max+step doesn't
+			}
+			if iv.min.AuxInt >= n || diff(n, iv.min.AuxInt)/uint64(step) < uint64(k) {
+				return nil, nil
+			}
+		}
+		return f.constVal(iv.max.Op, t, max-d, true), nil
+	}
+
+	pos := iv.max.Pos.WithNotStmt()
+	limit = pre.NewValue2(pos, unrollOp(OpSub64, t), t, iv.max, f.constVal(unrollOp(OpConst64, t), t, d, true))
+	// If max is x-c for some nonnegative x, then max-d = x-(c+d)
+	// can't underflow as long as c+d doesn't overflow.
+	if x, c := findKNN(iv.max); x != nil && c >= 0 && c <= maxSignedValue(t)-d {
+		return limit, nil
+	}
+	// Otherwise max-d underflowed if it isn't less than max.
+	return limit, pre.NewValue2(pos, unrollOp(OpLess64, t), f.Config.Types.Bool, limit, iv.max)
+}
+
+// unrollLoop unrolls the loop made up of header h and body block body,
+// entered from pre, by k. The induction variable of the unrolled loop
+// is tested against limit. If ok is not nil, the unrolled loop runs
+// only if ok is true.
+func unrollLoop(h, body, pre *Block, iv indVar, limit, ok *Value, k int) {
+	f := h.Func
+	preIdx := 0
+	if h.Preds[0].b != pre {
+		preIdx = 1
+	}
+	backIdx := 1 - preIdx
+
+	uh := f.NewBlock(BlockIf)
+	uh.Pos = h.Pos
+	ub := f.NewBlock(BlockPlain)
+	ub.Pos = body.Pos
+
+	// cur maps the header's phis to their values at the start of the
+	// copy of the body being built.
+	cur := make(map[*Value]*Value)
+	var phis []*Value
+	for _, v := range h.Values {
+		if v.Op == OpPhi {
+			cur[v] = uh.NewValue0(v.Pos, OpPhi, v.Type)
+			phis = append(phis, v)
+		}
+	}
+	uphis := make(map[*Value]*Value, len(phis))
+	for p, up := range cur {
+		uphis[p] = up
+	}
+
+	copies := make(map[*Value]*Value, len(body.Values))
+	for i := 0; i < k; i++ {
+		for _, v := range body.Values {
+			c := ub.NewValue0(v.Pos, v.Op, v.Type)
+			c.AuxInt = v.AuxInt
+			c.Aux = v.Aux
+			copies[v] = c
+		}
+		for _, v := range body.Values {
+			c := copies[v]
+			for _, a := range v.Args {
+				if w := copies[a]; w != nil {
+					a = w
+				} else if w := cur[a]; w != nil {
+					a = w
+				}
+				c.AddArg(a)
+			}
+		}
+		next := make(map[*Value]*Value, len(phis))
+		for _, p := range phis {
+			a := p.Args[backIdx]
+			if w := copies[a]; w != nil {
+				a = w
+			} else if w := cur[a]; w != nil {
+				a = w
+			}
+			next[p] = a
+		}
+		cur = next
+	}
+	cmp := OpLess64
+	if iv.flags&indVarMaxInc != 0 {
+		cmp = OpLeq64
+	}
+	t := iv.ind.Type
+	uh.SetControl(uh.NewValue2(h.Controls[0].Pos, unrollOp(cmp, t), f.Config.Types.Bool, uphis[iv.ind], limit))
+	uh.Likely = BranchLikely
+
+	// Splice the unrolled loop in between pre and h, giving both loops
+	// a preheader to which licm can hoist their invariant values:
+	//
+	//	pre -> [g -> e ->] uh <-> ub
+	//	        |          |
+	//	        +--------> m -> h <-> body
+	//
+	// Block g, which skips the unrolled loop if ok is false,
+	// and its successor e are present only if ok is not nil.
+	var g *Block
+	if ok != nil {
+		g = f.NewBlock(BlockIf)
+		g.Pos = h.Pos
+		g.SetControl(ok)
+		g.Likely = BranchLikely
+		pre.Succs[0] = Edge{g, 0}
+		g.Preds = append(g.Preds, Edge{pre, 0})
+		e := f.NewBlock(BlockPlain)
+		e.Pos = h.Pos
+		g.AddEdgeTo(e)
+		e.AddEdgeTo(uh)
+	} else {
+		pre.Succs[0] = Edge{uh, 0}
+		uh.Preds = append(uh.Preds, Edge{pre, 0})
+	}
+	m := f.NewBlock(BlockPlain)
+	m.Pos = h.Pos
+	uh.AddEdgeTo(ub)
+	ub.AddEdgeTo(uh)
+	uh.AddEdgeTo(m)
+	if g != nil {
+		g.AddEdgeTo(m)
+	}
+	m.Succs = append(m.Succs, Edge{h, preIdx})
+	h.Preds[preIdx] = Edge{m, 0}
+
+	for _, p := range phis {
+		up := uphis[p]
+		init := p.Args[preIdx]
+		up.AddArg2(init, cur[p])
+		if g != nil {
+			up = m.NewValue2(p.Pos, OpPhi, p.Type, up, init)
+		}
+		p.SetArg(preIdx, up)
+	}
+}
+
+// unrollOps maps 64-bit integer operations to their 8, 16, 32,
+// and 64-bit variants.
+var unrollOps = map[Op][4]Op{
+	OpConst64: {OpConst8, OpConst16, OpConst32, OpConst64},
+	OpSub64:   {OpSub8, OpSub16, OpSub32, OpSub64},
+	OpLess64:  {OpLess8, OpLess16, OpLess32, OpLess64},
+	OpLeq64:   {OpLeq8, OpLeq16, OpLeq32, OpLeq64},
+}
+
+// unrollOp returns the variant of the 64-bit integer operation op
+// for integers of type t.
+func unrollOp(op Op, t *types.Type) Op {
+	ops := unrollOps[op]
+	switch t.Size() {
+	case 1:
+		return ops[0]
+	case 2:
+		return ops[1]
+	case 4:
+		return ops[2]
+	}
+	return ops[3]
+}
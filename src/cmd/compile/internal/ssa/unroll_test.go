@@ -0,0 +1,118 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ssa
+
+import (
+	"cmd/compile/internal/types"
+	"testing"
+)
+
+// unrollTestLoop builds a loop that stores to p each of the values
+// of i from 0 up to n.
+func unrollTestLoop(c *Conf, n string) fun {
+	intType := c.config.Types.Int64
+	ptrType := c.config.Types.Int64.PtrTo()
+	return c.Fun("entry",
+		Bloc("entry",
+			Valu("mem", OpInitMem, types.TypeMem, 0, nil),
+			Valu("sp", OpSP, c.config.Types.Uintptr, 0, nil),
+			Valu("p", OpArg, ptrType, 0, c.Temp(ptrType)),
+			Valu("s", OpArg, ptrType, 0, c.Temp(ptrType)),
+			Valu("len", OpArg, intType, 0, c.Temp(intType)),
+			Valu("zero", OpConst64, intType, 0, nil),
+			Valu("one", OpConst64, intType, 1, nil),
+			Valu("ten", OpConst64, intType, 10, nil),
+			Valu("sliceLen", OpSliceLen, intType, 0, nil, "slice"),
+			Valu("slice", OpSliceMake, types.NewSlice(intType), 0, nil, "s", "len", "len"),
+			Goto("header")),
+		Bloc("header",
+			Valu("i", OpPhi, intType, 0, nil, "zero", "inc"),
+			Valu("loopmem", OpPhi, types.TypeMem, 0, nil, "mem", "store"),
+			Valu("cmp", OpLess64, c.config.Types.Bool, 0, nil, "i", n),
+			If("cmp", "body", "exit")),
+		Bloc("body",
+			Valu("store", OpStore, types.TypeMem, 0, intType, "p", "i", "loopmem"),
+			Valu("inc", OpAdd64, intType, 0, nil, "one", "i"),
+			Goto("header")),
+		Bloc("exit",
+			Exit("loopmem")))
+}
+
+func TestUnroll(t *testing.T) {
+	for _, test := range []struct {
+		n      string // loop limit
+		hot    bool
+		factor int
+		guard  bool // whether the unrolled loop needs an overflow check
+	}{
+		{"ten", false, 4, false},
+		{"ten", true, 8, false},
+		{"sliceLen", false, 4, false},
+		{"len", false, 4, true},
+		{"len", true, 8, true},
+	} {
+		c := testConfig(t)
+		fun := unrollTestLoop(c, test.n)
+		fun.f.PGOHot = test.hot
+		// The unrolled loop's header and body, and a block leading from it
+		// to the original loop, are added, as well as a block testing for
+		// overflow and the unrolled loop's preheader if needed.
+		nblocks := fun.f.NumBlocks()
+		CheckFunc(fun.f)
+		unroll(fun.f)
+		CheckFunc(fun.f)
+		wantBlocks := nblocks + 3
+		if test.guard {
+			wantBlocks += 2
+		}
+		if n := fun.f.NumBlocks(); n != wantBlocks {
+			t.Errorf("n=%s hot=%v: unrolling left %d blocks, want %d", test.n, test.hot, n, wantBlocks)
+		}
+
+		var stores int
+		for _, b := range fun.f.Blocks {
+			if b == fun.blocks["body"] {
+				continue
+			}
+			for _, v := range b.Values {
+				if v.Op == OpStore {
+					stores++
+				}
+			}
+		}
+		if stores != test.factor {
+			t.Errorf("n=%s hot=%v: unrolled loop has %d stores, want %d", test.n, test.hot, stores, test.factor)
+		}
+	}
+}
+
+func TestUnrollCountDown(t *testing.T) {
+	c := testConfig(t)
+	intType := c.config.Types.Int64
+	fun := c.Fun("entry",
+		Bloc("entry",
+			Valu("mem", OpInitMem, types.TypeMem, 0, nil),
+			Valu("sp", OpSP, c.config.Types.Uintptr, 0, nil),
+			Valu("n", OpArg, intType, 0, c.Temp(intType)),
+			Valu("zero", OpConst64, intType, 0, nil),
+			Valu("minusOne", OpConst64, intType, -1, nil),
+			Goto("header")),
+		Bloc("header",
+			Valu("i", OpPhi, intType, 0, nil, "n", "dec"),
+			Valu("cmp", OpLess64, c.config.Types.Bool, 0, nil, "zero", "i"),
+			If("cmp", "body", "exit")),
+		Bloc("body",
+			Valu("dec", OpAdd64, intType, 0, nil, "minusOne", "i"),
+			Goto("header")),
+		Bloc("exit",
+			Exit("mem")))
+	CheckFunc(fun.f)
+	nblocks := fun.f.NumBlocks()
+	unroll(fun.f)
+	CheckFunc(fun.f)
+	if fun.f.NumBlocks() != nblocks {
+		t.Errorf("loop counting down was unrolled")
+	}
+}
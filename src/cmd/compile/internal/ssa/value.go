@@ -597,11 +597,16 @@ func CanSSA(t *types.Type) bool {
 	}
 	switch t.Kind() {
 	case types.TARRAY:
+		if t.IsSIMD() {
+			// Vectors are kept whole in vector registers, if the
+			// architecture has them. Indexing goes through memory.
+			return types.SIMDRegSize != 0
+		}
 		// We can't do larger arrays because dynamic indexing is
 		// not supported on SSA variables.
 		// TODO: allow if all indexes are constant.
 		if t.NumElem() <= 1 {
-			return CanSSA(t.Elem())
+			return CanSSA(t.Elem()) && !t.Elem().IsSIMD()
 		}
 		return false
 	case types.TSTRUCT:
@@ -609,7 +614,7 @@ func CanSSA(t *types.Type) bool {
 			return false
 		}
 		for _, t1 := range t.Fields() {
-			if !CanSSA(t1.Type) {
+			if !CanSSA(t1.Type) || t1.Type.IsSIMD() {
 				return false
 			}
 		}
@@ -23,6 +23,10 @@ type ArchInfo struct {
 	MAXWIDTH  int64
 	SoftFloat bool
 
+	// SIMDRegSize is the size of the vector registers used for the
+	// types of package simd, or 0 if they are kept in memory.
+	SIMDRegSize int
+
 	PadFrame func(int64) int64
 
 	// ZeroRange zeroes a range of memory on stack. It is only inserted
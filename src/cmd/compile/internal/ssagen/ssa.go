@@ -47,6 +47,11 @@ const ssaDumpFile = "ssa.html"
 // ssaDumpInlined holds all inlined functions when ssaDump contains a function name.
 var ssaDumpInlined []*ir.Func
 
+// PGOCallSiteWeights holds the weights of the call sites in the PGO
+// profile, by the linker symbol name of the calling function and the
+// line offset of the call site within it.
+var PGOCallSiteWeights map[string]map[int]int64
+
 func DumpInline(fn *ir.Func) {
 	if ssaDump != "" && ssaDump == ir.FuncName(fn) {
 		ssaDumpInlined = append(ssaDumpInlined, fn)
@@ -146,6 +151,7 @@ func InitConfig() {
 	ir.Syms.X86HasPOPCNT = typecheck.LookupRuntimeVar("x86HasPOPCNT")       // bool
 	ir.Syms.X86HasSSE41 = typecheck.LookupRuntimeVar("x86HasSSE41")         // bool
 	ir.Syms.X86HasFMA = typecheck.LookupRuntimeVar("x86HasFMA")             // bool
+	ir.Syms.X86HasAVX512 = typecheck.LookupRuntimeVar("x86HasAVX512")       // bool
 	ir.Syms.ARMHasVFPv4 = typecheck.LookupRuntimeVar("armHasVFPv4")         // bool
 	ir.Syms.ARM64HasATOMICS = typecheck.LookupRuntimeVar("arm64HasATOMICS") // bool
 	ir.Syms.Staticuint64s = typecheck.LookupRuntimeVar("staticuint64s")
@@ -362,6 +368,15 @@ func buildssa(fn *ir.Func, worker int) *ssa.Func {
 	if fn.Pragma&ir.Nosplit != 0 {
 		s.f.NoSplit = true
 	}
+	s.f.PGOHot = fn.PGOHot()
+	if w := PGOCallSiteWeights[ir.LinkFuncName(fn)]; w != nil {
+		// See the note on line numbers in package pgoir.
+		start := int(base.Ctxt.InnermostPos(fn.Pos()).RelLine())
+		s.f.PGOCallWeights = make(map[uint]int64, len(w))
+		for off, weight := range w {
+			s.f.PGOCallWeights[uint(start+off)] = weight
+		}
+	}
 	s.f.ABI0 = ssaConfig.ABI0
 	s.f.ABI1 = ssaConfig.ABI1
 	s.f.ABIDefault = abiForFunc(nil, ssaConfig.ABI0, ssaConfig.ABI1)
@@ -2882,6 +2897,11 @@ func (s *state) exprCheckPtr(n ir.Node, checkPtrOK bool) *ssa.Value {
 		to := n.Type()
 		from := n.X.Type()
 
+		if to.IsSIMD() && !ssa.CanSSA(from) {
+			// Load the vector from the array it converts.
+			return s.load(to, s.addr(n.X))
+		}
+
 		// Assume everything will work out, so set up our return value.
 		// Anything interesting that happens from here is a fatal.
 		x := s.expr(n.X)
@@ -3276,6 +3296,13 @@ func (s *state) exprCheckPtr(n ir.Node, checkPtrOK bool) *ssa.Value {
 		case n.X.Type().IsSlice():
 			p := s.addr(n)
 			return s.load(n.X.Type().Elem(), p)
+		case n.X.Type().IsSIMD():
+			a := s.vecAddr(n.X)
+			i := s.expr(n.Index)
+			len := s.constInt(types.Types[types.TINT], n.X.Type().NumElem())
+			i = s.boundsCheck(i, len, ssa.BoundsIndex, n.Bounded())
+			p := s.newValue2(ssa.OpPtrIndex, types.NewPtr(n.Type()), a, i)
+			return s.load(n.Type(), p)
 		case n.X.Type().IsArray():
 			if ssa.CanSSA(n.X.Type()) {
 				// SSA can handle arrays of length at most 1.
@@ -3923,6 +3950,16 @@ func (s *state) assignWhichMayOverlap(left ir.Node, right *ssa.Value, deref bool
 			n := t.NumElem()
 
 			i := s.expr(left.Index) // index
+			if t.IsSIMD() {
+				// Set the element in a copy of the vector in memory:
+				// tmp = a; tmp[i] = v; a = tmp
+				addr := s.vecTemp(left.Pos(), t, s.expr(left.X))
+				len := s.constInt(types.Types[types.TINT], n)
+				i = s.boundsCheck(i, len, ssa.BoundsIndex, left.Bounded())
+				s.store(t.Elem(), s.newValue2(ssa.OpPtrIndex, types.NewPtr(t.Elem()), addr, i), right)
+				s.assign(left.X, s.load(t, addr), false, 0)
+				return
+			}
 			if n == 0 {
 				// The bounds check must fail.  Might as well
 				// ignore the actual index and just use zeros.
@@ -4030,6 +4067,8 @@ func (s *state) zeroVal(t *types.Type) *ssa.Value {
 			v.AddArg(s.zeroVal(t.FieldType(i)))
 		}
 		return v
+	case t.IsSIMD():
+		return s.entryNewValue0(ssa.OpVecZero, t)
 	case t.IsArray():
 		switch t.NumElem() {
 		case 0:
@@ -5067,6 +5106,126 @@ func InitTables() {
 
 	/******** math/big ********/
 	alias("math/big", "mulWW", "math/bits", "Mul64", p8...)
+
+	/******** simd ********/
+	// The vector operations of package simd are implemented by functions
+	// on vector values, which are replaced by vector ops. On amd64, the
+	// vectors are kept in the 256-bit AVX2 registers if GOAMD64>=v3, and
+	// as pairs of 128-bit SSE registers otherwise. The ops that need more
+	// than GOAMD64 guarantees are guarded by a check of the CPU feature,
+	// falling back to calling the Go function. NEON, on arm64, has no
+	// 64-bit lane multiplication or move mask, so those stay Go calls.
+	makeSIMD := func(op ssa.Op, feature *obj.LSym) intrinsicBuilder {
+		return func(s *state, n *ir.CallExpr, args []*ssa.Value) *ssa.Value {
+			emit := func() *ssa.Value {
+				if len(args) == 1 {
+					return s.newValue1(op, n.Type(), args[0])
+				}
+				return s.newValue2(op, n.Type(), args[0], args[1])
+			}
+			if feature == nil {
+				return emit()
+			}
+
+			v := s.entryNewValue0A(ssa.OpHasCPUFeature, types.Types[types.TBOOL], feature)
+			b := s.endBlock()
+			b.Kind = ssa.BlockIf
+			b.SetControl(v)
+			bTrue := s.f.NewBlock(ssa.BlockPlain)
+			bFalse := s.f.NewBlock(ssa.BlockPlain)
+			bEnd := s.f.NewBlock(ssa.BlockPlain)
+			b.AddEdgeTo(bTrue)
+			b.AddEdgeTo(bFalse)
+			b.Likely = ssa.BranchLikely
+
+			// We have the instructions - use them directly.
+			s.startBlock(bTrue)
+			s.vars[n] = emit()
+			s.endBlock().AddEdgeTo(bEnd)
+
+			// Call the pure Go version.
+			s.startBlock(bFalse)
+			s.vars[n] = s.callResult(n, callNormal)
+			s.endBlock().AddEdgeTo(bEnd)
+
+			// Merge results.
+			s.startBlock(bEnd)
+			return s.variable(n, n.Type())
+		}
+	}
+	const (
+		sse2   = iota // SSE2, or AVX2 for 256-bit vectors
+		sse41         // SSE4.1, or AVX2 for 256-bit vectors
+		sse42         // SSE4.2, or AVX2 for 256-bit vectors
+		avx512        // AVX-512 F, VL and DQ
+	)
+	for _, op := range []struct {
+		fn    string
+		op    ssa.Op
+		amd64 int  // CPU feature needed on amd64
+		arm64 bool // implemented on arm64
+	}{
+		{"addUint8x32", ssa.OpVecAdd8, sse2, true},
+		{"subUint8x32", ssa.OpVecSub8, sse2, true},
+		{"minUint8x32", ssa.OpVecMinU8, sse2, true},
+		{"maxUint8x32", ssa.OpVecMaxU8, sse2, true},
+		{"equalUint8x32", ssa.OpVecEq8, sse2, true},
+		{"andUint8x32", ssa.OpVecAnd, sse2, true},
+		{"orUint8x32", ssa.OpVecOr, sse2, true},
+		{"xorUint8x32", ssa.OpVecXor, sse2, true},
+		{"andNotUint8x32", ssa.OpVecAndNot, sse2, true},
+		{"signBitsUint8x32", ssa.OpVecSignBits8, sse2, false},
+		{"addInt32x8", ssa.OpVecAdd32, sse2, true},
+		{"subInt32x8", ssa.OpVecSub32, sse2, true},
+		{"mulInt32x8", ssa.OpVecMul32, sse41, true},
+		{"minInt32x8", ssa.OpVecMin32, sse41, true},
+		{"maxInt32x8", ssa.OpVecMax32, sse41, true},
+		{"equalInt32x8", ssa.OpVecEq32, sse2, true},
+		{"greaterInt32x8", ssa.OpVecGt32, sse2, true},
+		{"andInt32x8", ssa.OpVecAnd, sse2, true},
+		{"orInt32x8", ssa.OpVecOr, sse2, true},
+		{"xorInt32x8", ssa.OpVecXor, sse2, true},
+		{"andNotInt32x8", ssa.OpVecAndNot, sse2, true},
+		{"addInt64x4", ssa.OpVecAdd64, sse2, true},
+		{"subInt64x4", ssa.OpVecSub64, sse2, true},
+		{"mulInt64x4", ssa.OpVecMul64, avx512, false},
+		{"minInt64x4", ssa.OpVecMin64, avx512, true},
+		{"maxInt64x4", ssa.OpVecMax64, avx512, true},
+		{"equalInt64x4", ssa.OpVecEq64, sse41, true},
+		{"greaterInt64x4", ssa.OpVecGt64, sse42, true},
+		{"andInt64x4", ssa.OpVecAnd, sse2, true},
+		{"orInt64x4", ssa.OpVecOr, sse2, true},
+		{"xorInt64x4", ssa.OpVecXor, sse2, true},
+		{"andNotInt64x4", ssa.OpVecAndNot, sse2, true},
+		{"addFloat32x8", ssa.OpVecAddF32, sse2, true},
+		{"subFloat32x8", ssa.OpVecSubF32, sse2, true},
+		{"mulFloat32x8", ssa.OpVecMulF32, sse2, true},
+		{"divFloat32x8", ssa.OpVecDivF32, sse2, true},
+		{"sqrtFloat32x8", ssa.OpVecSqrtF32, sse2, true},
+		{"addFloat64x4", ssa.OpVecAddF64, sse2, true},
+		{"subFloat64x4", ssa.OpVecSubF64, sse2, true},
+		{"mulFloat64x4", ssa.OpVecMulF64, sse2, true},
+		{"divFloat64x4", ssa.OpVecDivF64, sse2, true},
+		{"sqrtFloat64x4", ssa.OpVecSqrtF64, sse2, true},
+	} {
+		if op.arm64 {
+			addF("simd", op.fn, makeSIMD(op.op, nil), sys.ARM64)
+		}
+		var feature *obj.LSym
+		switch {
+		case op.amd64 == avx512:
+			if buildcfg.GOAMD64 < 4 {
+				feature = ir.Syms.X86HasAVX512
+			}
+		case buildcfg.GOAMD64 >= 2:
+			// GOAMD64=v2 implies SSE4.2, and v3 implies AVX2.
+		case op.amd64 == sse41:
+			feature = ir.Syms.X86HasSSE41
+		case op.amd64 == sse42:
+			continue // there is no check for SSE4.2; use the Go function
+		}
+		addF("simd", op.fn, makeSIMD(op.op, feature), sys.AMD64)
+	}
 }
 
 // findIntrinsic returns a function which builds the SSA equivalent of the
@@ -5655,7 +5814,12 @@ func (s *state) addr(n ir.Node) *ssa.Value {
 		if n.Type() == n.X.Type() {
 			return s.addr(n.X)
 		}
-		addr := s.addr(n.X)
+		var addr *ssa.Value
+		if n.X.Type().IsSIMD() {
+			addr = s.vecAddr(n.X)
+		} else {
+			addr = s.addr(n.X)
+		}
 		return s.newValue1(ssa.OpCopy, t, addr) // ensure that addr has the right type
 	case ir.OCALLFUNC, ir.OCALLINTER:
 		n := n.(*ir.CallExpr)
@@ -5680,6 +5844,29 @@ func (s *state) addr(n ir.Node) *ssa.Value {
 	}
 }
 
+// vecAddr returns the address of the vector n of package simd. A vector
+// that is kept in registers is stored in a temporary first.
+func (s *state) vecAddr(n ir.Node) *ssa.Value {
+	switch n.Op() {
+	case ir.ONAME, ir.ODOT, ir.OINDEX:
+		if !s.canSSA(n) {
+			return s.addr(n)
+		}
+	case ir.ODEREF, ir.ODOTPTR:
+		return s.addr(n)
+	}
+	return s.vecTemp(n.Pos(), n.Type(), s.expr(n))
+}
+
+// vecTemp stores the vector v of type t in a new temporary and returns
+// its address. The temporary has the underlying array type, so that it
+// is not kept in registers itself.
+func (s *state) vecTemp(pos src.XPos, t *types.Type, v *ssa.Value) *ssa.Value {
+	_, addr := s.temp(pos, t.Underlying())
+	s.store(t, addr, v)
+	return addr
+}
+
 // canSSA reports whether n is SSA-able.
 // n must be an ONAME (or an ODOT sequence with an ONAME base).
 func (s *state) canSSA(n ir.Node) bool {
@@ -6992,6 +7179,10 @@ type State struct {
 
 	// wasm: The number of values on the WebAssembly stack. This is only used as a safeguard.
 	OnWasmStackSkipped int
+
+	// UsesVec256 reports whether the function keeps 256-bit vectors
+	// of package simd in registers.
+	UsesVec256 bool
 }
 
 func (s *State) FuncInfo() *obj.FuncInfo {
@@ -7235,6 +7426,17 @@ func emitWrappedFuncInfo(e *ssafn, pp *objw.Progs) {
 func genssa(f *ssa.Func, pp *objw.Progs) {
 	var s State
 	s.ABI = f.OwnAux.Fn.ABI()
+	if types.SIMDRegSize == 32 {
+	vec256:
+		for _, b := range f.Blocks {
+			for _, v := range b.Values {
+				if v.Type.IsSIMD() && v.Type.Size() == 32 {
+					s.UsesVec256 = true
+					break vec256
+				}
+			}
+		}
+	}
 
 	e := f.Frontend().(*ssafn)
 
@@ -7302,8 +7504,21 @@ func genssa(f *ssa.Func, pp *objw.Progs) {
 
 	var argLiveIdx int = -1 // argument liveness info index
 
+	// coldText is the ATEXT Prog of the function's cold fragment, which
+	// holds the blocks from f.ColdStart on, if any.
+	var coldText *obj.Prog
+
 	// Emit basic blocks
 	for i, b := range f.Blocks {
+		if i == f.ColdStart && i > 0 {
+			if f.Blocks[i-1].Kind == ssa.BlockExit {
+				// Keep the return address of the panic call inside
+				// the function, as at the end of the function below.
+				Arch.Ginsnop(s.pp)
+			}
+			coldText = s.pp.SetColdText()
+			argLiveIdx = -1
+		}
 		s.bstart[b.ID] = s.pp.Next
 		s.lineRunStart = nil
 		s.SetPos(s.pp.Pos.WithNotStmt()) // It needs a non-empty Pos, but cannot be a statement boundary (yet).
@@ -7411,11 +7626,13 @@ func genssa(f *ssa.Func, pp *objw.Progs) {
 
 		// Emit control flow instructions for block
 		var next *ssa.Block
-		if i < len(f.Blocks)-1 && base.Flag.N == 0 {
+		if i < len(f.Blocks)-1 && base.Flag.N == 0 && i+1 != f.ColdStart {
 			// If -N, leave next==nil so every block with successors
 			// ends in a JMP (except call blocks - plive doesn't like
 			// select{send,recv} followed by a JMP call).  Helps keep
 			// line numbers for otherwise empty blocks.
+			// The last block before the cold fragment can't fall
+			// through into it either.
 			next = f.Blocks[i+1]
 		}
 		x := s.pp.Next
@@ -7459,9 +7676,38 @@ func genssa(f *ssa.Func, pp *objw.Progs) {
 		s.pp.Prog(obj.ARET)
 	}
 
+	var inCold map[*obj.Prog]bool
+	if coldText != nil {
+		inCold = make(map[*obj.Prog]bool)
+		for p := coldText; p != nil; p = p.Link {
+			inCold[p] = true
+		}
+	}
+
 	if inlMarks != nil {
 		hasCall := false
 
+		// The function and its cold fragment each have their own
+		// inlining tree, and each mark goes with the part it is in.
+		var hotMarks map[int32]*obj.Prog
+		var coldMarks map[int32]bool
+		if coldText != nil {
+			hotMarks = make(map[int32]*obj.Prog)
+			coldMarks = make(map[int32]bool)
+		}
+		addInlMark := func(p *obj.Prog, id int32) {
+			switch {
+			case coldText == nil:
+				s.pp.CurFunc.LSym.Func().AddInlMark(p, id)
+			case inCold[p]:
+				coldText.From.Sym.Func().AddInlMark(p, id)
+				coldMarks[id] = true
+			default:
+				s.pp.CurFunc.LSym.Func().AddInlMark(p, id)
+				hotMarks[id] = p
+			}
+		}
+
 		// We have some inline marks. Try to find other instructions we're
 		// going to emit anyway, and use those instructions instead of the
 		// inline marks.
@@ -7487,24 +7733,51 @@ func genssa(f *ssa.Func, pp *objw.Progs) {
 			if len(marks) == 0 {
 				continue
 			}
+			var other []*obj.Prog
 			for _, m := range marks {
+				if inCold[m] != inCold[p] {
+					// The mark is in the other part of the function.
+					other = append(other, m)
+					continue
+				}
 				// We found an instruction with the same source position as
 				// some of the inline marks.
 				// Use this instruction instead.
 				p.Pos = p.Pos.WithIsStmt() // promote position to a statement
-				s.pp.CurFunc.LSym.Func().AddInlMark(p, inlMarks[m])
+				addInlMark(p, inlMarks[m])
 				// Make the inline mark a real nop, so it doesn't generate any code.
 				m.As = obj.ANOP
 				m.Pos = src.NoXPos
 				m.From = obj.Addr{}
 				m.To = obj.Addr{}
 			}
-			delete(inlMarksByPos, pos)
+			if len(other) > 0 {
+				inlMarksByPos[pos] = other
+			} else {
+				delete(inlMarksByPos, pos)
+			}
 		}
 		// Any unmatched inline marks now need to be added to the inlining tree (and will generate a nop instruction).
 		for _, p := range inlMarkList {
 			if p.As != obj.ANOP {
-				s.pp.CurFunc.LSym.Func().AddInlMark(p, inlMarks[p])
+				addInlMark(p, inlMarks[p])
+			}
+		}
+
+		if coldText != nil {
+			// Code inlined into the cold fragment unwinds to its call
+			// sites through marks in the fragment. Add the missing ones
+			// at its end, with the positions of the marks in the function.
+			for p := coldText; p != nil; p = p.Link {
+				for id := base.Ctxt.PosTable.Pos(p.Pos).Base().InliningIndex(); id >= 0; id = base.Ctxt.InlTree.Parent(id) {
+					if coldMarks[int32(id)] || hotMarks[int32(id)] == nil {
+						continue
+					}
+					nop := Arch.Ginsnop(s.pp)
+					nop.Pos = hotMarks[int32(id)].Pos.WithNotStmt()
+					inCold[nop] = true
+					addInlMark(nop, int32(id))
+				}
 			}
 		}
 
@@ -7564,6 +7837,15 @@ func genssa(f *ssa.Func, pp *objw.Progs) {
 		// Register a callback that will be used later to fill in PCs into location
 		// lists. At the moment, Prog.Pc is a sequence number; it's not a real PC
 		// until after assembly, so the translation needs to be deferred.
+		// The PCs in the cold fragment, if any, are given as if it
+		// followed the function.
+		fnsym := e.curfn.LSym
+		pc := func(p *obj.Prog) int64 {
+			if inCold[p] {
+				return fnsym.Size + p.Pc
+			}
+			return p.Pc
+		}
 		debugInfo.GetPC = func(b, v ssa.ID) int64 {
 			switch v {
 			case ssa.BlockStart.ID:
@@ -7571,15 +7853,18 @@ func genssa(f *ssa.Func, pp *objw.Progs) {
 					return 0 // Start at the very beginning, at the assembler-generated prologue.
 					// this should only happen for function args (ssa.OpArg)
 				}
-				return bstart[b].Pc
+				return pc(bstart[b])
 			case ssa.BlockEnd.ID:
 				blk := f.Blocks[idToIdx[b]]
 				nv := len(blk.Values)
-				return valueToProgAfter[blk.Values[nv-1].ID].Pc
+				return pc(valueToProgAfter[blk.Values[nv-1].ID])
 			case ssa.FuncEnd.ID:
-				return e.curfn.LSym.Size
+				if coldText != nil {
+					return fnsym.Size + coldText.From.Sym.Size
+				}
+				return fnsym.Size
 			default:
-				return valueToProgAfter[v].Pc
+				return pc(valueToProgAfter[v])
 			}
 		}
 	}
@@ -7726,6 +8011,10 @@ func defframe(s *State, e *ssafn, f *ssa.Func) {
 	pp.Text.To.Type = obj.TYPE_TEXTSIZE
 	pp.Text.To.Val = int32(types.RoundUp(f.OwnAux.ArgWidth(), int64(types.RegSize)))
 	pp.Text.To.Offset = frame
+	if cold := pp.Text.From.Sym.Func().Cold; cold != nil {
+		// The cold fragment runs in the same frame.
+		cold.Func().Text.To = pp.Text.To
+	}
 
 	p := pp.Text
 
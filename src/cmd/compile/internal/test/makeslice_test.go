@@ -0,0 +1,90 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !race
+
+package test
+
+import (
+	"internal/testenv"
+	"testing"
+)
+
+//go:noinline
+func sumBytes(s string) int {
+	b := make([]byte, len(s))
+	copy(b, s)
+	n := 0
+	for _, c := range b {
+		n += int(c)
+	}
+	return n
+}
+
+//go:noinline
+func sumZeroed(n, c int) int64 {
+	x := make([]int64, n, c)
+	x = x[:c]
+	for i := range x {
+		x[i] += int64(i)
+	}
+	var sum int64
+	for _, v := range x {
+		sum += v
+	}
+	return sum
+}
+
+func TestVariableMakeStack(t *testing.T) {
+	testenv.SkipIfOptimizationOff(t)
+
+	small, large := "0123456789", "0123456789012345678901234567890123456789"
+	if got, want := sumBytes(small), 525; got != want {
+		t.Errorf("sumBytes(%q) = %d, want %d", small, got, want)
+	}
+	if got, want := sumBytes(large), 2100; got != want {
+		t.Errorf("sumBytes(%q) = %d, want %d", large, got, want)
+	}
+	if n := testing.AllocsPerRun(10, func() { sumBytes(small) }); n != 0 {
+		t.Errorf("sumBytes(small): got %v allocs, want 0", n)
+	}
+	if n := testing.AllocsPerRun(10, func() { sumBytes(large) }); n != 1 {
+		t.Errorf("sumBytes(large): got %v allocs, want 1", n)
+	}
+
+	// The stack buffer must be zeroed on each use.
+	for i := 0; i < 3; i++ {
+		if got, want := sumZeroed(1, 4), int64(6); got != want {
+			t.Errorf("sumZeroed(1, 4) = %d, want %d", got, want)
+		}
+	}
+	if n := testing.AllocsPerRun(10, func() { sumZeroed(2, 4) }); n != 0 {
+		t.Errorf("sumZeroed(2, 4): got %v allocs, want 0", n)
+	}
+	if n := testing.AllocsPerRun(10, func() { sumZeroed(2, 5) }); n != 1 {
+		t.Errorf("sumZeroed(2, 5): got %v allocs, want 1", n)
+	}
+}
+
+func TestVariableMakeStackPanics(t *testing.T) {
+	for _, tt := range []struct {
+		n, c int
+		want string
+	}{
+		{5, 3, "runtime error: makeslice: cap out of range"},
+		{-1, 3, "runtime error: makeslice: len out of range"},
+		{1, -3, "runtime error: makeslice: cap out of range"},
+		{50, 10, "runtime error: makeslice: cap out of range"},
+	} {
+		func() {
+			defer func() {
+				err, _ := recover().(error)
+				if err == nil || err.Error() != tt.want {
+					t.Errorf("sumZeroed(%d, %d) panicked with %v, want %q", tt.n, tt.c, err, tt.want)
+				}
+			}()
+			sumZeroed(tt.n, tt.c)
+		}()
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package test
+
+import (
+	"regexp"
+	"runtime"
+	"testing"
+)
+
+const layoutSrc = `package p
+
+func F(x int) int {
+	if x > 100 {
+		return x * 7
+	}
+	return g(x)
+}
+
+//go:noinline
+func g(x int) int {
+	return x + 1
+}
+`
+
+// TestPGOLayout tests that branches are predicted to go towards the call
+// sites that are hot according to the profile, overriding the static
+// prediction that paths with calls are unlikely.
+func TestPGOLayout(t *testing.T) {
+	t.Parallel()
+
+	f := pgoFrame{"example.com/pgo.F", 3, 7}
+	g := pgoFrame{"example.com/pgo.g", 11, 12}
+	out := buildPGOPackage(t, layoutSrc, []pgoSample{
+		{[]pgoFrame{g, f}, 1000},
+	}, "-d=ssa/pgolikely/debug=1")
+
+	want := `p.go:4:\d+: branch made unlikely by profile \(weights 0, 1000\)`
+	if !regexp.MustCompile(want).Match(out) {
+		t.Errorf("output does not match %q:\n%s", want, out)
+	}
+}
+
+const splitSrc = `package p
+
+func F(s []int, i int) int {
+	return g(i) + s[i]
+}
+
+//go:noinline
+func g(x int) int {
+	return x + 1
+}
+`
+
+// TestPGOSplit tests that the blocks of a hot function that lead to a
+// panic are split out of it.
+func TestPGOSplit(t *testing.T) {
+	if runtime.GOARCH != "amd64" || runtime.GOOS == "windows" {
+		t.Skipf("cold blocks are not split on %s/%s", runtime.GOOS, runtime.GOARCH)
+	}
+	t.Parallel()
+
+	f := pgoFrame{"example.com/pgo.F", 3, 4}
+	g := pgoFrame{"example.com/pgo.g", 8, 9}
+	out := buildPGOPackage(t, splitSrc, []pgoSample{
+		{[]pgoFrame{g, f}, 1000},
+	}, "-d=ssa/pgosplit/debug=1")
+
+	want := `p.go:4:\d+: split 1 cold blocks out of F`
+	if !regexp.MustCompile(want).Match(out) {
+		t.Errorf("output does not match %q:\n%s", want, out)
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package test
+
+import (
+	"internal/profile"
+	"internal/testenv"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+const unrollSrc = `package p
+
+func hot(s []int) int {
+	x := 0
+	for i := 0; i < len(s); i++ {
+		x += s[i]
+	}
+	return x
+}
+
+func cold(s []int) int {
+	x := 0
+	for i := 0; i < len(s); i++ {
+		x += s[i]
+	}
+	return x
+}
+
+func Main(s []int) int {
+	return hot(s) + cold(s)
+}
+`
+
+// A pgoFrame is a frame of a sample in a synthetic CPU profile.
+type pgoFrame struct {
+	fn        string // linker symbol name of the function
+	startLine int64  // line of the function's declaration
+	line      int64
+}
+
+// A pgoSample is a sample in a synthetic CPU profile,
+// with its stack listed from the innermost frame out.
+type pgoSample struct {
+	stack []pgoFrame
+	count int64
+}
+
+// writePGOProfile writes a CPU profile with the given samples to file.
+func writePGOProfile(t *testing.T, file string, samples []pgoSample) {
+	p := &profile.Profile{
+		SampleType: []*profile.ValueType{{Type: "samples", Unit: "count"}, {Type: "cpu", Unit: "nanoseconds"}},
+		PeriodType: &profile.ValueType{Type: "cpu", Unit: "nanoseconds"},
+		Period:     10000000,
+	}
+	funcs := make(map[string]*profile.Function)
+	locs := make(map[pgoFrame]*profile.Location)
+	for _, s := range samples {
+		var stack []*profile.Location
+		for _, fr := range s.stack {
+			fn := funcs[fr.fn]
+			if fn == nil {
+				fn = &profile.Function{ID: uint64(len(p.Function) + 1), Name: fr.fn, SystemName: fr.fn, StartLine: fr.startLine}
+				funcs[fr.fn] = fn
+				p.Function = append(p.Function, fn)
+			}
+			loc := locs[fr]
+			if loc == nil {
+				id := uint64(len(p.Location) + 1)
+				loc = &profile.Location{ID: id, Address: id, Line: []profile.Line{{Function: fn, Line: fr.line}}}
+				locs[fr] = loc
+				p.Location = append(p.Location, loc)
+			}
+			stack = append(stack, loc)
+		}
+		p.Sample = append(p.Sample, &profile.Sample{Location: stack, Value: []int64{s.count, s.count * p.Period}})
+	}
+
+	f, err := os.Create(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := p.Write(f); err != nil {
+		t.Fatalf("error writing profile: %v", err)
+	}
+}
+
+// buildPGOPackage builds a package with source src in module
+// example.com/pgo using a profile with the given samples, and
+// returns the output of the compiler.
+func buildPGOPackage(t *testing.T, src string, samples []pgoSample, gcflags string) []byte {
+	testenv.MustHaveGoBuild(t)
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/pgo\ngo 1.23\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "p.go"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	writePGOProfile(t, filepath.Join(dir, "default.pgo"), samples)
+
+	cmd := testenv.Command(t, testenv.GoToolPath(t), "build", "-o", os.DevNull,
+		"-gcflags=-pgoprofile=default.pgo "+gcflags, ".")
+	cmd.Dir = dir
+	cmd = testenv.CleanCmdEnv(cmd)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("build failed: %v, output:\n%s", err, out)
+	}
+	return out
+}
+
+// TestPGOUnroll tests that loops in functions that are hot according
+// to the profile are unrolled more than loops in other functions.
+func TestPGOUnroll(t *testing.T) {
+	t.Parallel()
+
+	// Nearly all samples are in the loop of hot.
+	hot := pgoFrame{"example.com/pgo.hot", 3, 6}
+	cold := pgoFrame{"example.com/pgo.cold", 11, 14}
+	main := pgoFrame{"example.com/pgo.Main", 19, 20}
+	out := buildPGOPackage(t, unrollSrc, []pgoSample{
+		{[]pgoFrame{hot, main}, 1000},
+		{[]pgoFrame{cold, main}, 1},
+	}, "-d=ssa/unroll/debug=1")
+
+	for _, want := range []string{
+		`p.go:5:\d+: unrolled hot loop by 8`,
+		`p.go:13:\d+: unrolled loop by 4`,
+	} {
+		if !regexp.MustCompile(want).Match(out) {
+			t.Errorf("output does not match %q:\n%s", want, out)
+		}
+	}
+}
@@ -231,6 +231,7 @@ var runtimeDecls = [...]struct {
 	{"x86HasPOPCNT", varTag, 6},
 	{"x86HasSSE41", varTag, 6},
 	{"x86HasFMA", varTag, 6},
+	{"x86HasAVX512", varTag, 6},
 	{"armHasVFPv4", varTag, 6},
 	{"arm64HasATOMICS", varTag, 6},
 	{"asanregisterglobals", funcTag, 123},
@@ -17,6 +17,10 @@ var PtrSize int
 
 var RegSize int
 
+// SIMDRegSize is the size of the vector registers that hold the values
+// of the types of package simd, or 0 if the values are kept in memory.
+var SIMDRegSize int
+
 // Slices in the runtime are represented by three components:
 //
 //	type slice struct {
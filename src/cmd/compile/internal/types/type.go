@@ -1012,7 +1012,7 @@ func (t *Type) ArgWidth() int64 {
 
 func (t *Type) Size() int64 {
 	if t.kind == TSSA {
-		if t == TypeInt128 {
+		if t == TypeInt128 || t == TypeVec128 {
 			return 16
 		}
 		return 0
@@ -1428,6 +1428,15 @@ func (t *Type) IsArray() bool {
 	return t.kind == TARRAY
 }
 
+// IsSIMD reports whether t is one of the vector types of package simd.
+func (t *Type) IsSIMD() bool {
+	if t.kind != TARRAY {
+		return false
+	}
+	s := t.Sym()
+	return s != nil && s.Pkg != nil && s.Pkg.Path == "simd"
+}
+
 func (t *Type) IsStruct() bool {
 	return t.kind == TSTRUCT
 }
@@ -1629,12 +1638,15 @@ var (
 	TypeFlags     = newSSA("flags")
 	TypeVoid      = newSSA("void")
 	TypeInt128    = newSSA("int128")
+	TypeVec128    = newSSA("vec128")
 	TypeResultMem = newResults([]*Type{TypeMem})
 )
 
 func init() {
 	TypeInt128.width = 16
 	TypeInt128.align = 8
+	TypeVec128.width = 16
+	TypeVec128.align = 8
 }
 
 // NewNamed returns a new named type for the given type name. obj should be an
@@ -421,6 +421,9 @@ func walkMakeSlice(n *ir.MakeExpr, init *ir.Nodes) ir.Node {
 		if why := escape.HeapAllocReason(n); why != "" {
 			base.Fatalf("%v has EscNone, but %v", n, why)
 		}
+		if !ir.IsSmallIntConst(r) {
+			return walkMakeSliceStack(n, l, r, init)
+		}
 		// var arr [r]T
 		// n = arr[:l]
 		i := typecheck.IndexConst(r)
@@ -475,6 +478,60 @@ func walkMakeSlice(n *ir.MakeExpr, init *ir.Nodes) ir.Node {
 	return walkExpr(typecheck.Expr(sh), init)
 }
 
+// walkMakeSliceStack walks a non-escaping OMAKESLICE node n whose
+// capacity r is not constant. The slice is backed by a fixed-size
+// stack buffer when the capacity fits in it, and by a heap allocation
+// otherwise:
+//
+//	var arr [K]T
+//	var s []T
+//	if uint64(cap) <= K {
+//	    if uint64(len) > uint64(cap) {
+//	        if len < 0 { panicmakeslicelen() }
+//	        panicmakeslicecap()
+//	    }
+//	    arr = [K]T{}
+//	    s = arr[:len:cap]
+//	} else {
+//	    s = make([]T, len, cap) // heap allocated
+//	}
+func walkMakeSliceStack(n *ir.MakeExpr, l, r ir.Node, init *ir.Nodes) ir.Node {
+	t := n.Type()
+	k := escape.VariableMakeStackLen(n)
+	l = cheapExpr(l, init)
+	r = cheapExpr(r, init)
+	u64 := types.Types[types.TUINT64]
+
+	arr := typecheck.TempAt(base.Pos, ir.CurFunc, types.NewArray(t.Elem(), k))
+	s := typecheck.TempAt(base.Pos, ir.CurFunc, t)
+
+	nif := ir.NewIfStmt(base.Pos, ir.NewBinaryExpr(base.Pos, ir.OLE, typecheck.Conv(r, u64), ir.NewInt(base.Pos, k)), nil, nil)
+	nifcap := ir.NewIfStmt(base.Pos, ir.NewBinaryExpr(base.Pos, ir.OGT, typecheck.Conv(l, u64), typecheck.Conv(r, u64)), nil, nil)
+	niflen := ir.NewIfStmt(base.Pos, ir.NewBinaryExpr(base.Pos, ir.OLT, l, ir.NewInt(base.Pos, 0)), nil, nil)
+	niflen.Body = []ir.Node{mkcall("panicmakeslicelen", nil, init)}
+	nifcap.Body.Append(niflen, mkcall("panicmakeslicecap", nil, init))
+
+	// The checks above ensure that 0 <= len <= cap <= K.
+	slice := ir.NewSliceExpr(base.Pos, ir.OSLICE3, arr, nil, typecheck.Conv(l, types.Types[types.TINT]), typecheck.Conv(r, types.Types[types.TINT]))
+	slice.SetBounded(true)
+	nif.Body.Append(
+		nifcap,
+		ir.NewAssignStmt(base.Pos, arr, nil), // zero temp
+		// The conv is necessary in case n.Type is named.
+		ir.NewAssignStmt(base.Pos, s, typecheck.Conv(typecheck.Expr(slice), t)),
+	)
+
+	mk := ir.NewMakeExpr(base.Pos, ir.OMAKESLICE, l, r)
+	mk.SetType(t)
+	mk.SetTypecheck(1)
+	mk.RType = n.RType
+	mk.SetEsc(ir.EscHeap)
+	nif.Else = []ir.Node{ir.NewAssignStmt(base.Pos, s, mk)}
+
+	appendWalkStmt(init, nif)
+	return s
+}
+
 // walkMakeSliceCopy walks an OMAKESLICECOPY node.
 func walkMakeSliceCopy(n *ir.MakeExpr, init *ir.Nodes) ir.Node {
 	if n.Esc() == ir.EscNone {
@@ -0,0 +1,146 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"internal/testenv"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// logRecords returns an optimization log for package p in file x.go
+// holding the given escroot records and an escedge record for each
+// destination and source pair in edges. The message of the i'th
+// edge record is "e<i>".
+func logRecords(edges [][2]string, roots []string) string {
+	var b strings.Builder
+	b.WriteString(`{"version":0,"package":"p","goos":"linux","goarch":"amd64","gc_version":"devel","file":"/x.go"}` + "\n")
+	rng := func(line int) string {
+		return fmt.Sprintf(`{"start":{"line":%d,"character":1},"end":{"line":%d,"character":1}}`, line, line)
+	}
+	loc := func(line int) string {
+		return fmt.Sprintf(`{"uri":"file:///x.go","range":%s}`, rng(line))
+	}
+	for i, e := range edges {
+		fmt.Fprintf(&b, `{"range":%s,"code":"escedge","message":"e%d","relatedInformation":[`+
+			`{"location":%s,"message":"dst: %s"},{"location":%s,"message":"src: %s"},{"location":%s,"message":"inlineLoc"}]}`+"\n",
+			rng(10+i), i, loc(10+i), e[0], loc(20+i), e[1], loc(1))
+	}
+	for _, r := range roots {
+		fmt.Fprintf(&b, `{"range":%s,"code":"escroot","message":%q}`+"\n", rng(1), r)
+	}
+	return b.String()
+}
+
+func TestLeakPath(t *testing.T) {
+	// x's value flows directly to the heap, but its address only
+	// flows there through p, or through p, b and a.
+	log := logRecords([][2]string{
+		{"0 {heap}", "1 0 x"},
+		{"0 {heap}", "2 0 p"},
+		{"2 p", "1 -1 x"},
+		{"0 {heap}", "3 0 a"},
+		{"3 a", "4 0 b"},
+		{"4 b", "2 0 p"},
+		{"5 r", "6 0 q"},
+	}, []string{"escape 1 0", "leak 6 5 0"})
+
+	g := &graph{nodes: make(map[nodeKey]*node)}
+	if err := g.read(strings.NewReader(log)); err != nil {
+		t.Fatal(err)
+	}
+	if len(g.edges) != 7 {
+		t.Fatalf("read %d edges, want 7", len(g.edges))
+	}
+
+	xs := g.explainAll()
+	if len(xs) != 2 {
+		t.Fatalf("got %d explanations, want 2", len(xs))
+	}
+	for i, want := range []string{"e2 e1", "e6"} {
+		var flows []string
+		for _, e := range xs[i].path {
+			flows = append(flows, e.flow)
+		}
+		if got := strings.Join(flows, " "); got != want {
+			t.Errorf("path for %s = %q, want %q", xs[i].node.name, got, want)
+		}
+	}
+	if got, want := xs[0].message(), "x escapes to heap"; got != want {
+		t.Errorf("message for x = %q, want %q", got, want)
+	}
+	if got, want := xs[1].message(), "parameter q leaks to r with derefs=0"; got != want {
+		t.Errorf("message for q = %q, want %q", got, want)
+	}
+}
+
+func TestEscape(t *testing.T) {
+	testenv.MustHaveGoBuild(t)
+	t.Parallel()
+
+	const src = `package p
+
+var sink *int
+
+func f(n int) {
+	x := n
+	p := &x
+	q := p
+	r := q
+	s := r
+	sink = s
+	sink = p
+}
+
+func g(b []byte) int {
+	buf := make([]byte, len(b))
+	copy(buf, b)
+	return len(buf)
+}
+`
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "x.go"), []byte(src), 0666); err != nil {
+		t.Fatal(err)
+	}
+	cmd := testenv.Command(t, testenv.GoToolPath(t), "tool", "compile", "-p=p", "-json=0,file://log", "-d=escapegraph", "-o", "x.o", "x.go")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("%v: %v\n%s", cmd, err, out)
+	}
+
+	g, err := readGraph(filepath.Join(dir, "log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nodes := g.match("x")
+	if len(nodes) != 1 || len(nodes[0].roots) != 1 {
+		t.Fatalf("match(x) = %v, want one escaping node", nodes)
+	}
+	x := g.explain(nodes[0], nodes[0].roots[0])
+	var out strings.Builder
+	writeExplanations(&out, []*explanation{x})
+	t.Logf("%s", out.String())
+	var flows []string
+	for _, e := range x.path {
+		flows = append(flows, e.flow)
+	}
+	if got, want := strings.Join(flows, "; "), "flow: p = &x; flow: {heap} = p"; got != want {
+		t.Errorf("path for x = %q, want %q", got, want)
+	}
+
+	for _, name := range []string{"x.go:6", "q", "make([]byte, len(b))", "buf"} {
+		nodes := g.match(name)
+		if len(nodes) != 1 {
+			t.Errorf("match(%q) returned %d nodes, want 1", name, len(nodes))
+		}
+	}
+	if nodes := g.match("make([]byte, len(b))"); len(nodes) == 1 && len(nodes[0].roots) != 0 {
+		t.Errorf("make([]byte, len(b)) escapes, want no escape")
+	}
+}
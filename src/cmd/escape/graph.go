@@ -0,0 +1,337 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The types below mirror the subset of the LSP-shaped records written
+// by the compiler's -json flag that escape uses.
+
+type header struct {
+	Version int    `json:"version"`
+	Package string `json:"package"`
+	File    string `json:"file,omitempty"`
+}
+
+type position struct {
+	Line      uint `json:"line"`
+	Character uint `json:"character"`
+}
+
+type lspRange struct {
+	Start position `json:"start"`
+	End   position `json:"end"`
+}
+
+type location struct {
+	URI   string   `json:"uri"`
+	Range lspRange `json:"range"`
+}
+
+type relatedInformation struct {
+	Location location `json:"location"`
+	Message  string   `json:"message"`
+}
+
+type diagnostic struct {
+	Range              lspRange             `json:"range"`
+	Severity           int                  `json:"severity,omitempty"`
+	Code               string               `json:"code,omitempty"`
+	Source             string               `json:"source,omitempty"`
+	Message            string               `json:"message"`
+	RelatedInformation []relatedInformation `json:"relatedInformation,omitempty"`
+}
+
+// String returns loc in the file:line:col form used by compiler
+// diagnostics.
+func (loc location) String() string {
+	return fmt.Sprintf("%s:%d:%d", uriPath(loc.URI), loc.Range.Start.Line, loc.Range.Start.Character)
+}
+
+// uriPath returns the file name for a file URI, relative to the
+// current directory if it is below it.
+func uriPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	p := u.Path
+	if len(p) >= 3 && p[0] == '/' && p[2] == ':' {
+		p = p[1:] // Windows drive letter
+	}
+	p = filepath.FromSlash(p)
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, p); err == nil && filepath.IsLocal(rel) {
+			return rel
+		}
+	}
+	return p
+}
+
+// A nodeKey identifies a location in the data-flow graph.
+// IDs are only unique within a package.
+type nodeKey struct {
+	pkg string
+	id  int
+}
+
+// A node is a location in the data-flow graph: a variable, the
+// storage for an allocation, a temporary, or the heap.
+type node struct {
+	key   nodeKey
+	name  string
+	loc   location
+	in    []*edge // edges into this node
+	roots []root  // why this node escapes or leaks, if it does
+}
+
+// A root records that a node escapes or leaks because it flows to
+// another node with at most derefs dereferences. derefs is -1 if the
+// node's address flows to it.
+type root struct {
+	node   *node
+	derefs int
+}
+
+// An edge is a flow of a value from src to dst.
+type edge struct {
+	dst, src *node
+	derefs   int // >= -1
+	loc      location
+	flow     string // as in the -m=2 explanations, e.g. "flow: p = &x"
+	notes    []note
+}
+
+// A note explains an edge by the expression that gives rise to it.
+type note struct {
+	loc  location
+	text string // e.g. "&x (address-of)"
+}
+
+// A graph is the data-flow graph of escape analysis, for one or more
+// packages.
+type graph struct {
+	nodes map[nodeKey]*node
+	edges []*edge
+}
+
+// readGraph reads the data-flow graph from the optimization logs
+// in dir, as written by the compiler's -json and -d=escapegraph flags.
+func readGraph(dir string) (*graph, error) {
+	g := &graph{nodes: make(map[nodeKey]*node)}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(path) != ".json" {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if err := g.read(f); err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(g.edges, func(i, j int) bool {
+		return lessLoc(g.edges[i].loc, g.edges[j].loc)
+	})
+	return g, nil
+}
+
+// read adds the graph recorded in the log file r.
+func (g *graph) read(r io.Reader) error {
+	dec := json.NewDecoder(r)
+	var h header
+	if err := dec.Decode(&h); err != nil {
+		return err
+	}
+	if h.Version != 0 {
+		return fmt.Errorf("unsupported log version %d", h.Version)
+	}
+	for {
+		var d diagnostic
+		if err := dec.Decode(&d); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		switch d.Code {
+		case "escedge":
+			if err := g.addEdge(h.Package, &d); err != nil {
+				return err
+			}
+		case "escroot":
+			if err := g.addRoot(h.Package, &d); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// node returns the node with the given key, creating it if needed.
+func (g *graph) node(key nodeKey) *node {
+	n := g.nodes[key]
+	if n == nil {
+		n = &node{key: key}
+		g.nodes[key] = n
+	}
+	return n
+}
+
+// addEdge adds the edge described by the escedge record d.
+func (g *graph) addEdge(pkg string, d *diagnostic) error {
+	e := &edge{flow: d.Message}
+	for _, ri := range d.RelatedInformation {
+		what, text, _ := strings.Cut(ri.Message, ": ")
+		switch what {
+		case "dst":
+			f := strings.SplitN(text, " ", 2)
+			if len(f) != 2 {
+				return fmt.Errorf("malformed escedge destination %q", text)
+			}
+			id, err := strconv.Atoi(f[0])
+			if err != nil {
+				return fmt.Errorf("malformed escedge destination %q", text)
+			}
+			e.dst = g.node(nodeKey{pkg, id})
+			e.dst.name = f[1]
+			if id != 0 { // the heap has no location
+				e.dst.loc = ri.Location
+			}
+			e.loc = ri.Location // until a note refines it
+		case "src":
+			f := strings.SplitN(text, " ", 3)
+			if len(f) != 3 {
+				return fmt.Errorf("malformed escedge source %q", text)
+			}
+			id, err1 := strconv.Atoi(f[0])
+			derefs, err2 := strconv.Atoi(f[1])
+			if err1 != nil || err2 != nil {
+				return fmt.Errorf("malformed escedge source %q", text)
+			}
+			e.src = g.node(nodeKey{pkg, id})
+			e.src.name = f[2]
+			if id != 0 {
+				e.src.loc = ri.Location
+			}
+			e.derefs = derefs
+		case "from":
+			if len(e.notes) == 0 {
+				e.loc = ri.Location
+			}
+			e.notes = append(e.notes, note{ri.Location, text})
+		}
+	}
+	if e.dst == nil || e.src == nil {
+		return fmt.Errorf("escedge record missing source or destination")
+	}
+	e.dst.in = append(e.dst.in, e)
+	g.edges = append(g.edges, e)
+	return nil
+}
+
+// addRoot records the escape or leak described by the escroot record d.
+func (g *graph) addRoot(pkg string, d *diagnostic) error {
+	f := strings.Fields(d.Message)
+	if len(f) == 0 {
+		return fmt.Errorf("malformed escroot record %q", d.Message)
+	}
+	var ids []int
+	for _, s := range f[1:] {
+		id, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("malformed escroot record %q", d.Message)
+		}
+		ids = append(ids, id)
+	}
+	var n *node
+	switch {
+	case f[0] == "escape" && len(ids) == 2:
+		n = g.node(nodeKey{pkg, ids[0]})
+		n.roots = append(n.roots, root{g.node(nodeKey{pkg, ids[1]}), -1})
+	case f[0] == "leak" && len(ids) == 3:
+		n = g.node(nodeKey{pkg, ids[0]})
+		n.roots = append(n.roots, root{g.node(nodeKey{pkg, ids[1]}), ids[2]})
+	default:
+		return fmt.Errorf("malformed escroot record %q", d.Message)
+	}
+	return nil
+}
+
+// leakPath returns the shortest path by which n flows to r.node with
+// at most r.derefs dereferences, in order from n to r.node.
+// It returns nil if there is no such path.
+//
+// The number of dereferences along a path is computed as in the
+// compiler: walking back from the root, each edge adds its derefs,
+// and a path through a node whose address flows to the root continues
+// from that node with 0 dereferences, since the node's value, not its
+// address, flows on.
+func (g *graph) leakPath(n *node, r root) []*edge {
+	type state struct {
+		n      *node
+		derefs int
+	}
+	type step struct {
+		prev state
+		e    *edge
+	}
+	// Every path that reaches n with few enough dereferences can be
+	// shortened to one that stays under this bound.
+	maxDerefs := len(g.edges) + 1
+
+	start := state{r.node, 0}
+	seen := map[state]step{start: {}}
+	queue := []state{start}
+	for len(queue) > 0 {
+		s := queue[0]
+		queue = queue[1:]
+		for _, e := range s.n.in {
+			next := state{e.src, max(s.derefs, 0) + e.derefs}
+			if next.derefs > maxDerefs {
+				continue
+			}
+			if _, ok := seen[next]; ok {
+				continue
+			}
+			seen[next] = step{s, e}
+			if next.n == n && next.derefs <= r.derefs {
+				var path []*edge
+				for st := next; st != start; st = seen[st].prev {
+					path = append(path, seen[st].e)
+				}
+				return path
+			}
+			queue = append(queue, next)
+		}
+	}
+	return nil
+}
+
+// lessLoc reports whether a comes before b in source order.
+func lessLoc(a, b location) bool {
+	if a.URI != b.URI {
+		return a.URI < b.URI
+	}
+	if a.Range.Start.Line != b.Range.Start.Line {
+		return a.Range.Start.Line < b.Range.Start.Line
+	}
+	return a.Range.Start.Character < b.Range.Start.Character
+}
@@ -0,0 +1,353 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Escape explains the decisions of the compiler's escape analysis.
+//
+// Usage:
+//
+//	go tool escape [-json] dir [name ...]
+//	go tool escape -graph [-json] dir
+//
+// Dir is a directory of optimization logs written by the compiler
+// when given the -json flag together with -d=escapegraph, which makes
+// it also log the data-flow graph used by escape analysis. For example:
+//
+//	go build -gcflags='-json=0,/tmp/esc -d=escapegraph' ./mypkg
+//	go tool escape /tmp/esc buf
+//
+// For each name, escape prints the shortest data-flow path by which
+// each matching variable or allocation escapes to the heap, or, for a
+// parameter, leaks to the heap or to a result. A name is either the
+// name of a variable, the expression that allocates, such as
+// "make([]byte, n)", or a source position in the form file:line, which
+// matches everything at that line of a file with that name. With no
+// names, escape explains everything that escapes.
+//
+// The explanations follow the form of those printed by the compiler's
+// -m=2 flag, but list only the shortest path of flows rather than
+// the first one found.
+//
+// The -graph flag prints the whole data-flow graph instead, one flow
+// per line, each followed by the expressions that give rise to it.
+//
+// The -json flag prints the output as JSON. Explanations are printed
+// as LSP PublishDiagnosticsParams objects, one per source file, in
+// the form of the diagnostics written by the compiler's -json flag.
+// The graph is printed as a single object with "nodes" and "edges"
+// lists.
+package main
+
+import (
+	"bufio"
+	"cmd/internal/objabi"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: go tool escape [-json] dir [name ...]\n")
+	fmt.Fprintf(os.Stderr, "       go tool escape -graph [-json] dir\n\n")
+	flag.PrintDefaults()
+	os.Exit(2)
+}
+
+var (
+	jsonFlag  = flag.Bool("json", false, "print output as JSON")
+	graphFlag = flag.Bool("graph", false, "print the data-flow graph")
+)
+
+func main() {
+	objabi.AddVersionFlag()
+
+	log.SetFlags(0)
+	log.SetPrefix("escape: ")
+
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() < 1 || *graphFlag && flag.NArg() > 1 {
+		usage()
+	}
+
+	g, err := readGraph(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(g.edges) == 0 {
+		log.Fatalf("no data-flow graph in %s; compile with -json and -d=escapegraph", flag.Arg(0))
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+
+	if *graphFlag {
+		if *jsonFlag {
+			err = writeGraphJSON(w, g)
+		} else {
+			writeGraph(w, g)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	var xs []*explanation
+	if flag.NArg() == 1 {
+		xs = g.explainAll()
+	} else {
+		for _, name := range flag.Args()[1:] {
+			nodes := g.match(name)
+			if len(nodes) == 0 {
+				log.Fatalf("nothing named %s in the data-flow graph", name)
+			}
+			found := false
+			for _, n := range nodes {
+				for _, r := range n.roots {
+					xs = append(xs, g.explain(n, r))
+					found = true
+				}
+			}
+			if !found {
+				log.Printf("%s does not escape", name)
+			}
+		}
+	}
+	if *jsonFlag {
+		err = writeExplanationsJSON(w, xs)
+	} else {
+		writeExplanations(w, xs)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// An explanation is the shortest path by which a node escapes or
+// leaks.
+type explanation struct {
+	node *node
+	root root
+	path []*edge
+}
+
+func (g *graph) explain(n *node, r root) *explanation {
+	return &explanation{node: n, root: r, path: g.leakPath(n, r)}
+}
+
+// explainAll explains every escape and leak in g, in source order.
+func (g *graph) explainAll() []*explanation {
+	var nodes []*node
+	for _, n := range g.nodes {
+		if len(n.roots) > 0 {
+			nodes = append(nodes, n)
+		}
+	}
+	sortNodes(nodes)
+	var xs []*explanation
+	for _, n := range nodes {
+		for _, r := range n.roots {
+			xs = append(xs, g.explain(n, r))
+		}
+	}
+	return xs
+}
+
+// match returns the nodes named by name, in source order.
+func (g *graph) match(name string) []*node {
+	var matches func(n *node) bool
+	if file, line, ok := strings.Cut(name, ":"); ok && !strings.ContainsAny(name, "()[]{} ") {
+		l, err := strconv.Atoi(line)
+		if err != nil {
+			return nil
+		}
+		matches = func(n *node) bool {
+			path := uriPath(n.loc.URI)
+			return n.loc.Range.Start.Line == uint(l) &&
+				(path == file || strings.HasSuffix(path, "/"+file) || strings.HasSuffix(path, string(os.PathSeparator)+file))
+		}
+	} else {
+		matches = func(n *node) bool {
+			return n.name == name || displayName(n.name) == name
+		}
+	}
+
+	var nodes []*node
+	for _, n := range g.nodes {
+		if n.key.id != 0 && n.name != "{temp}" && matches(n) {
+			nodes = append(nodes, n)
+		}
+	}
+	sortNodes(nodes)
+	return nodes
+}
+
+// sortNodes sorts nodes in source order.
+func sortNodes(nodes []*node) {
+	sort.Slice(nodes, func(i, j int) bool {
+		a, b := nodes[i], nodes[j]
+		if a.loc != b.loc {
+			return lessLoc(a.loc, b.loc)
+		}
+		if a.key.pkg != b.key.pkg {
+			return a.key.pkg < b.key.pkg
+		}
+		return a.key.id < b.key.id
+	})
+}
+
+// displayName returns the name of an allocation's storage as the
+// allocating expression, as in the compiler's -m diagnostics.
+func displayName(name string) string {
+	if s, ok := strings.CutPrefix(name, "{storage for "); ok {
+		if s, ok := strings.CutSuffix(s, "}"); ok {
+			return s
+		}
+	}
+	return name
+}
+
+// message returns the message describing the escape or leak.
+func (x *explanation) message() string {
+	if x.root.derefs < 0 {
+		return fmt.Sprintf("%s escapes to heap", displayName(x.node.name))
+	}
+	return fmt.Sprintf("parameter %s leaks to %s with derefs=%d", x.node.name, x.root.node.name, x.root.derefs)
+}
+
+// code returns the diagnostic code for the escape or leak, as used by
+// the compiler.
+func (x *explanation) code() string {
+	if x.root.derefs < 0 {
+		return "escape"
+	}
+	return "leak"
+}
+
+func writeExplanations(w io.Writer, xs []*explanation) {
+	for _, x := range xs {
+		pos := x.node.loc.String()
+		fmt.Fprintf(w, "%s: %s:\n", pos, x.message())
+		if x.path == nil {
+			fmt.Fprintf(w, "%s:   no path found\n", pos)
+		}
+		for _, e := range x.path {
+			fmt.Fprintf(w, "%s:   %s:\n", pos, e.flow)
+			for _, n := range e.notes {
+				fmt.Fprintf(w, "%s:     from %s at %s\n", pos, n.text, n.loc)
+			}
+		}
+	}
+}
+
+// publishDiagnosticsParams is the LSP notification of the diagnostics
+// for a file.
+type publishDiagnosticsParams struct {
+	URI         string       `json:"uri"`
+	Diagnostics []diagnostic `json:"diagnostics"`
+}
+
+func writeExplanationsJSON(w io.Writer, xs []*explanation) error {
+	var files []*publishDiagnosticsParams
+	byURI := make(map[string]*publishDiagnosticsParams)
+	for _, x := range xs {
+		d := diagnostic{
+			Range:    x.node.loc.Range,
+			Severity: 3, // information
+			Code:     x.code(),
+			Source:   "go compiler",
+			Message:  x.message(),
+		}
+		for _, e := range x.path {
+			d.RelatedInformation = append(d.RelatedInformation, relatedInformation{e.loc, e.flow})
+			for _, n := range e.notes {
+				d.RelatedInformation = append(d.RelatedInformation, relatedInformation{n.loc, "from " + n.text})
+			}
+		}
+		uri := x.node.loc.URI
+		f := byURI[uri]
+		if f == nil {
+			f = &publishDiagnosticsParams{URI: uri}
+			byURI[uri] = f
+			files = append(files, f)
+		}
+		f.Diagnostics = append(f.Diagnostics, d)
+	}
+	enc := json.NewEncoder(w)
+	for _, f := range files {
+		if err := enc.Encode(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeGraph(w io.Writer, g *graph) {
+	for _, e := range g.edges {
+		fmt.Fprintf(w, "%s: %s\n", e.loc, e.flow)
+		for _, n := range e.notes {
+			fmt.Fprintf(w, "%s:     from %s at %s\n", e.loc, n.text, n.loc)
+		}
+	}
+}
+
+type jsonNode struct {
+	Package  string   `json:"package"`
+	ID       int      `json:"id"`
+	Name     string   `json:"name"`
+	Location location `json:"location"`
+	Escapes  bool     `json:"escapes,omitempty"`
+}
+
+type jsonEdge struct {
+	Package  string               `json:"package"`
+	Dst      int                  `json:"dst"`
+	Src      int                  `json:"src"`
+	Derefs   int                  `json:"derefs"`
+	Location location             `json:"location"`
+	Notes    []relatedInformation `json:"notes,omitempty"`
+}
+
+func writeGraphJSON(w io.Writer, g *graph) error {
+	var out struct {
+		Nodes []jsonNode `json:"nodes"`
+		Edges []jsonEdge `json:"edges"`
+	}
+	var nodes []*node
+	for _, n := range g.nodes {
+		nodes = append(nodes, n)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		a, b := nodes[i].key, nodes[j].key
+		if a.pkg != b.pkg {
+			return a.pkg < b.pkg
+		}
+		return a.id < b.id
+	})
+	for _, n := range nodes {
+		out.Nodes = append(out.Nodes, jsonNode{n.key.pkg, n.key.id, n.name, n.loc, len(n.roots) > 0})
+	}
+	for _, e := range g.edges {
+		je := jsonEdge{
+			Package:  e.dst.key.pkg,
+			Dst:      e.dst.key.id,
+			Src:      e.src.key.id,
+			Derefs:   e.derefs,
+			Location: e.loc,
+		}
+		for _, n := range e.notes {
+			je.Notes = append(je.Notes, relatedInformation{n.loc, n.text})
+		}
+		out.Edges = append(out.Edges, je)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(out)
+}
@@ -1506,6 +1506,7 @@
 //	edit        edit go.work from tools or scripts
 //	init        initialize workspace file
 //	sync        sync workspace build list to modules
+//	tidy        tidy all modules in the workspace together
 //	use         add modules to workspace file
 //	vendor      make vendored copy of dependencies
 //
@@ -1628,6 +1629,39 @@
 // See the workspaces reference at https://go.dev/ref/mod#workspaces
 // for more information.
 //
+// # Tidy all modules in the workspace together
+//
+// Usage:
+//
+//	go work tidy [-e] [-v]
+//
+// Tidy runs the equivalent of 'go mod tidy' in each module of the
+// workspace, keeping the modules consistent with one another.
+//
+// Before tidying a module, tidy upgrades each of the module's dependencies
+// to the version selected by the workspace's build list, as 'go work sync'
+// does, so that the tidied modules agree on the versions of the
+// dependencies they share. It then adds any missing requirements to the
+// module's go.mod file and removes unused ones, and updates its go.sum file.
+// Since tidying one module can change the versions that the workspace
+// selects for the others, tidy repeats these steps until no go.mod file
+// changes.
+// Finally, it removes any checksums that are no longer needed from the
+// workspace's go.work.sum file, and updates the go version in go.work if
+// a module now requires a newer one.
+//
+// Tidy reports each change it makes to a go.mod file, and the reason for
+// it, to standard error.
+//
+// The -v flag causes tidy to print information about removed modules
+// to standard error.
+//
+// The -e flag causes tidy to attempt to proceed despite errors
+// encountered while loading packages.
+//
+// See the workspaces reference at https://go.dev/ref/mod#workspaces
+// for more information.
+//
 // # Add modules to workspace file
 //
 // Usage:
@@ -1804,7 +1838,8 @@
 // The rule for a match in the cache is that the run involves the same
 // test binary and the flags on the command line come entirely from a
 // restricted set of 'cacheable' test flags, defined as -benchtime, -cpu,
-// -list, -parallel, -run, -short, -timeout, -failfast, -fullpath and -v.
+// -list, -parallel, -run, -shard, -short, -timeout, -failfast, -fullpath
+// and -v.
 // If a run of go test has any test or non-test flags outside this set,
 // the result is not cached. To disable test caching, use any test flag
 // or argument other than the cacheable flags. The idiomatic way to disable
@@ -1842,6 +1877,23 @@
 //	    If file ends in a slash or names an existing directory,
 //	    the test is written to pkg.test in that directory.
 //
+//	-rerun-failed
+//	    Run only the tests, examples, and fuzz tests that did not pass
+//	    in the previous run of each package with -rerun-failed, or all
+//	    of them if there was no such run, reporting those that passed
+//	    as passed (cached) in verbose output and counting them in the
+//	    summary line. A package whose test binary, test flags, or test
+//	    inputs (the files and environment variables consulted by the
+//	    tests) have changed since its previous run is tested in full.
+//
+//	-shardtiming file
+//	    With -shard, balance the shards by the running time of each
+//	    test rather than by the number of tests. The running times are
+//	    read from file, which holds the output of an earlier run of
+//	    'go test -json', and are not updated by this run. Every shard
+//	    must be given the same file, or the shards may overlap and miss
+//	    tests.
+//
 // The test binary also accepts flags that control execution of the test; these
 // flags are also accessible by 'go test'. See 'go help testflag' for details.
 //
@@ -3074,6 +3126,16 @@
 //	    because it must run them to look for those sub-tests.
 //	    See also -skip.
 //
+//	-shard i/n
+//	    Run only shard i of n (1 <= i <= n) of each package's tests,
+//	    examples, benchmarks, and fuzz tests. The top-level functions
+//	    in each test binary are divided deterministically among the n
+//	    shards, so that running the same test binaries with -shard=1/n
+//	    through -shard=n/n runs each function exactly once. With -list,
+//	    only the functions in shard i are listed. The test binary reports
+//	    the shard before running any tests, and 'go test -json' records it
+//	    in the Shard field of the events that follow. See also -shardtiming.
+//
 //	-short
 //	    Tell long-running tests to shorten their run time.
 //	    It is off by default but set during all.bash so that installing
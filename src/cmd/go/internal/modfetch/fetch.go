@@ -521,7 +521,7 @@ func readGoSum(dst map[module.Version][]string, file string, data []byte) {
 			continue
 		}
 		if len(f) != 3 {
-			if cfg.CmdName == "mod tidy" {
+			if cfg.CmdName == "mod tidy" || cfg.CmdName == "work tidy" {
 				// ignore malformed line so that go mod tidy can fix go.sum
 				continue
 			} else {
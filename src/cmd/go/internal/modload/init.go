@@ -26,6 +26,7 @@ import (
 	"cmd/go/internal/gover"
 	"cmd/go/internal/lockedfile"
 	"cmd/go/internal/modfetch"
+	"cmd/go/internal/par"
 	"cmd/go/internal/search"
 
 	"golang.org/x/mod/modfile"
@@ -365,6 +366,10 @@ func Reset() {
 	requirements = nil
 	workFilePath = ""
 	modfetch.Reset()
+
+	// The go.mod files of the workspace's modules may have changed
+	// (as in 'go work tidy'), so forget their summaries.
+	rawGoModSummaryCache = par.ErrCache[module.Version, *modFileSummary]{}
 }
 
 // Init determines whether module mode is enabled, locates the root of the
@@ -1368,7 +1373,7 @@ func setDefaultBuildMod() {
 	// to modload functions instead of relying on an implicit setting
 	// based on command name.
 	switch cfg.CmdName {
-	case "get", "mod download", "mod init", "mod tidy", "work sync":
+	case "get", "mod download", "mod init", "mod tidy", "work sync", "work tidy":
 		// These commands are intended to update go.mod and go.sum.
 		cfg.BuildMod = "mod"
 		return
@@ -1696,6 +1701,18 @@ func WriteGoMod(ctx context.Context, opts WriteOpts) error {
 	return commitRequirements(ctx, opts)
 }
 
+// TidyWorkSum rewrites the go.work.sum file to contain only the checksums
+// needed to load the packages most recently loaded in workspace mode that
+// are not already recorded in the go.sum file of a workspace module.
+func TidyWorkSum(ctx context.Context) error {
+	if !inWorkspaceMode() {
+		panic("TidyWorkSum called outside workspace mode")
+	}
+	keep := keepSums(ctx, loaded, requirements, addBuildListZipSums)
+	modfetch.TrimGoSum(keep)
+	return modfetch.WriteGoSum(ctx, keep, mustHaveCompleteRequirements())
+}
+
 // commitRequirements ensures go.mod and go.sum are up to date with the current
 // requirements.
 //
@@ -1753,7 +1770,7 @@ func commitRequirements(ctx context.Context, opts WriteOpts) (err error) {
 	}
 	wroteGo := opts.TidyWroteGo
 	if !wroteGo && modFile.Go == nil || modFile.Go.Version != goVersion {
-		alwaysUpdate := cfg.BuildMod == "mod" || cfg.CmdName == "mod tidy" || cfg.CmdName == "get"
+		alwaysUpdate := cfg.BuildMod == "mod" || cfg.CmdName == "mod tidy" || cfg.CmdName == "work tidy" || cfg.CmdName == "get"
 		if modFile.Go == nil && goVersion == gover.DefaultGoModVersion && !alwaysUpdate {
 			// The go.mod has no go line, the implied default Go version matches
 			// what we've computed for the graph, and we're not in one of the
@@ -1807,7 +1824,7 @@ func commitRequirements(ctx context.Context, opts WriteOpts) (err error) {
 		return errGoModDirty
 	}
 
-	if !dirty && cfg.CmdName != "mod tidy" {
+	if !dirty && cfg.CmdName != "mod tidy" && cfg.CmdName != "work tidy" {
 		// The go.mod file has the same semantic content that it had before
 		// (but not necessarily the same exact bytes).
 		// Don't write go.mod, but write go.sum in case we added or trimmed sums.
@@ -31,6 +31,7 @@ var passFlagToTest = map[string]bool{
 	"outputdir":            true,
 	"parallel":             true,
 	"run":                  true,
+	"shard":                true,
 	"short":                true,
 	"shuffle":              true,
 	"skip":                 true,
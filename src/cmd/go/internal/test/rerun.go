@@ -0,0 +1,189 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"strings"
+
+	"cmd/go/internal/cache"
+	"cmd/go/internal/cfg"
+	"cmd/go/internal/load"
+	"cmd/go/internal/work"
+)
+
+// The -rerun-failed flag reruns only the tests that did not pass in the
+// previous run of a package with -rerun-failed.
+//
+// After each run of a test binary with -rerun-failed, the go command
+// records the results of its top-level tests, read from the binary's
+// result log (-test.resultlogfile), in a per-package entry in the build
+// cache. Other runs record nothing, so that they do not pay for it. The
+// entry also identifies the test binary, its arguments, and the test
+// inputs (see computeTestInputsID) of the run. On the next run with
+// -rerun-failed, if all three still match, the go command passes the
+// tests that passed to the test binary (-test.passedfile), which reports
+// them as passed without running them and runs the rest. Otherwise the
+// package is tested in full.
+//
+// The entry has the form:
+//
+//	# test results
+//	binary <test binary content ID>
+//	args <test arguments, quoted (see rerunArgs)>
+//	testlog <hash of test log>
+//	inputs <test inputs ID>
+//	pass TestA 0.010000
+//	fail TestB 1.250000
+//	...
+//
+// The testlog and inputs lines are present only if the run wrote a test
+// log. The test log itself is stored in a separate entry keyed by its hash,
+// so that the test inputs ID can be recomputed at the time of the next run.
+
+var testResultsMagic = []byte("# test results\n")
+
+// testResultsKey returns the cache key under which the results of the
+// most recent run of the tests for package p are recorded.
+func testResultsKey(p *load.Package) cache.ActionID {
+	h := cache.NewHash("testResults")
+	fmt.Fprintf(h, "test results %s %s/%s\n", p.ImportPath, cfg.Goos, cfg.Goarch)
+	return h.Sum()
+}
+
+// testResultsLogKey returns the cache key under which the test log with
+// the given hash, recorded with the results for package p, is stored.
+func testResultsLogKey(p *load.Package, sum string) cache.ActionID {
+	return cache.Subkey(testResultsKey(p), "testlog:"+sum)
+}
+
+// rerunArgs returns the test arguments that must match between runs
+// for -rerun-failed to trust the earlier results. It omits flags that
+// only affect how results are reported or how long the tests may run.
+func rerunArgs() []string {
+	var args []string
+	for _, arg := range testArgs {
+		name, _, _ := strings.Cut(arg, "=")
+		switch name {
+		case "-test.v", "-test.fullpath", "-test.timeout", "-test.parallel", "-test.failfast", "-test.shuffle":
+			continue
+		}
+		args = append(args, arg)
+	}
+	return args
+}
+
+// saveTestResults records the results in resultlog of the test run
+// action a for a later run with -rerun-failed.
+func saveTestResults(a *work.Action, resultlog []byte) {
+	var buf bytes.Buffer
+	buf.Write(testResultsMagic)
+	fmt.Fprintf(&buf, "binary %s\n", a.Deps[0].BuildContentID())
+	fmt.Fprintf(&buf, "args %q\n", rerunArgs())
+	testlog, err := os.ReadFile(a.Objdir + "testlog.txt")
+	if err == nil && bytes.HasPrefix(testlog, testlogMagic) && testlog[len(testlog)-1] == '\n' {
+		if inputs, err := computeTestInputsID(a, testlog); err == nil {
+			sum := fmt.Sprintf("%x", sha256.Sum256(testlog))
+			if err := cache.PutBytes(cache.Default(), testResultsLogKey(a.Package, sum), testlog); err == nil {
+				fmt.Fprintf(&buf, "testlog %s\n", sum)
+				fmt.Fprintf(&buf, "inputs %x\n", inputs)
+			}
+		}
+	}
+	buf.Write(resultlog)
+	if cache.DebugTest {
+		fmt.Fprintf(os.Stderr, "testcache: %s: save test results\n", a.Package.ImportPath)
+	}
+	cache.PutBytes(cache.Default(), testResultsKey(a.Package), buf.Bytes())
+}
+
+// loadPassedTests returns the results of the tests that passed in the
+// previous run of the tests for the test run action a, in the format
+// read by -test.passedfile, along with the number of such tests and the
+// test log of the previous run. It returns nil if there is no previous
+// run or if the test binary, its arguments, or its inputs have changed since.
+func loadPassedTests(a *work.Action) (passed []byte, n int, testlog []byte) {
+	p := a.Package
+	data, _, err := cache.GetBytes(cache.Default(), testResultsKey(p))
+	if err != nil || !bytes.HasPrefix(data, testResultsMagic) {
+		if cache.DebugTest {
+			fmt.Fprintf(os.Stderr, "testcache: %s: no previous test results\n", p.ImportPath)
+		}
+		return nil, 0, nil
+	}
+	mismatch := func(what string) ([]byte, int, []byte) {
+		if cache.DebugTest {
+			fmt.Fprintf(os.Stderr, "testcache: %s: %s changed since previous test results\n", p.ImportPath, what)
+		}
+		return nil, 0, nil
+	}
+
+	header := make(map[string]string)
+	rest := string(data[len(testResultsMagic):])
+	for {
+		line, after, _ := strings.Cut(rest, "\n")
+		key, val, _ := strings.Cut(line, " ")
+		if key != "binary" && key != "args" && key != "testlog" && key != "inputs" {
+			break
+		}
+		header[key] = val
+		rest = after
+	}
+	if header["binary"] != a.Deps[0].BuildContentID() {
+		return mismatch("test binary")
+	}
+	if header["args"] != fmt.Sprintf("%q", rerunArgs()) {
+		return mismatch("test arguments")
+	}
+	testlog, _, err = cache.GetBytes(cache.Default(), testResultsLogKey(p, header["testlog"]))
+	if header["testlog"] == "" || err != nil {
+		return mismatch("test inputs")
+	}
+	if inputs, err := computeTestInputsID(a, testlog); err != nil || fmt.Sprintf("%x", inputs) != header["inputs"] {
+		return mismatch("test inputs")
+	}
+
+	results, err := parseResultLog([]byte(rest))
+	if err != nil {
+		if cache.DebugTest {
+			fmt.Fprintf(os.Stderr, "testcache: %s: previous test results malformed: %v\n", p.ImportPath, err)
+		}
+		return nil, 0, nil
+	}
+
+	// With -count, a test may appear more than once.
+	// It passed only if it passed every time.
+	ok := make(map[string]bool)
+	for _, r := range results {
+		prev, seen := ok[r.name]
+		ok[r.name] = r.action == "pass" && (prev || !seen)
+	}
+	var buf bytes.Buffer
+	for _, r := range results {
+		if ok[r.name] {
+			fmt.Fprintf(&buf, "pass %s %f\n", r.name, r.seconds)
+			ok[r.name] = false // write each test once
+			n++
+		}
+	}
+	return buf.Bytes(), n, testlog
+}
+
+// appendTestlog appends the inputs recorded in testlog, the test log of
+// an earlier run, to the test log of the test run action a.
+func appendTestlog(a *work.Action, testlog []byte) {
+	f, err := os.OpenFile(a.Objdir+"testlog.txt", os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		// No test log was written, so the result is not cacheable anyway.
+		return
+	}
+	defer f.Close()
+	// The earlier run started in the package directory too.
+	fmt.Fprintf(f, "chdir %s\n", a.Package.Dir)
+	f.Write(bytes.TrimPrefix(testlog, testlogMagic))
+}
@@ -0,0 +1,113 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// The -shardtiming flag balances test shards using the running time of
+// each top-level test, read from the 'go test -json' output of an
+// earlier run. The go command reads the file once, before running any
+// tests, and passes the durations recorded for each package to its test
+// binary (-test.shardtimes), which uses them to assign tests to shards.
+//
+// The durations are an input to the run and are never updated by it,
+// so that every shard of a sharded run, whether the shards run at the
+// same time or one after another, computes the same assignment as long
+// as each is given the same file.
+
+// loadShardTimes reads the test durations recorded in file, the output
+// of 'go test -json', and returns them for each package in the format
+// read by -test.shardtimes.
+func loadShardTimes(file string) (map[string][]byte, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	times := make(map[string]map[string]float64)
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 || line[0] != '{' {
+			// Tolerate build output and other lines that are not events.
+			continue
+		}
+		var e struct {
+			Action  string
+			Package string
+			Test    string
+			Elapsed float64
+		}
+		if err := json.Unmarshal(line, &e); err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", file, i+1, err)
+		}
+		if e.Test == "" || strings.Contains(e.Test, "/") {
+			// Only top-level tests are assigned to shards.
+			continue
+		}
+		switch e.Action {
+		case "pass", "fail", "skip":
+			if times[e.Package] == nil {
+				times[e.Package] = make(map[string]float64)
+			}
+			// With -count, a test may appear more than once.
+			// Use its longest running time.
+			times[e.Package][e.Test] = max(times[e.Package][e.Test], e.Elapsed)
+		}
+	}
+	shardTimes := make(map[string][]byte)
+	for pkg, t := range times {
+		shardTimes[pkg] = formatShardTimes(t)
+	}
+	return shardTimes, nil
+}
+
+// A testResult is one line of a test binary's result log.
+type testResult struct {
+	action  string // "pass", "fail", or "skip"
+	name    string // name of the top-level test, example, or fuzz test
+	seconds float64
+}
+
+// parseResultLog parses the result log written by the test binary,
+// one "action name seconds" line per completed top-level test.
+func parseResultLog(data []byte) ([]testResult, error) {
+	var results []testResult
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" {
+			continue
+		}
+		f := strings.Fields(line)
+		if len(f) != 3 {
+			return nil, fmt.Errorf("malformed result line %q", line)
+		}
+		secs, err := strconv.ParseFloat(f[2], 64)
+		if err != nil {
+			return nil, fmt.Errorf("malformed result line %q", line)
+		}
+		results = append(results, testResult{f[0], f[1], secs})
+	}
+	return results, nil
+}
+
+// formatShardTimes formats times for the test binary's
+// -test.shardtimes flag, one "name seconds" line per test.
+func formatShardTimes(times map[string]float64) []byte {
+	names := make([]string, 0, len(times))
+	for name := range times {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	var buf bytes.Buffer
+	for _, name := range names {
+		fmt.Fprintf(&buf, "%s %.3f\n", name, times[name])
+	}
+	return buf.Bytes()
+}
@@ -7,6 +7,7 @@ package test
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"internal/coverage"
@@ -127,7 +128,8 @@ elapsed time in the summary line.
 The rule for a match in the cache is that the run involves the same
 test binary and the flags on the command line come entirely from a
 restricted set of 'cacheable' test flags, defined as -benchtime, -cpu,
--list, -parallel, -run, -short, -timeout, -failfast, -fullpath and -v.
+-list, -parallel, -run, -shard, -short, -timeout, -failfast, -fullpath
+and -v.
 If a run of go test has any test or non-test flags outside this set,
 the result is not cached. To disable test caching, use any test flag
 or argument other than the cacheable flags. The idiomatic way to disable
@@ -165,6 +167,23 @@ In addition to the build flags, the flags handled by 'go test' itself are:
 	    If file ends in a slash or names an existing directory,
 	    the test is written to pkg.test in that directory.
 
+	-rerun-failed
+	    Run only the tests, examples, and fuzz tests that did not pass
+	    in the previous run of each package with -rerun-failed, or all
+	    of them if there was no such run, reporting those that passed
+	    as passed (cached) in verbose output and counting them in the
+	    summary line. A package whose test binary, test flags, or test
+	    inputs (the files and environment variables consulted by the
+	    tests) have changed since its previous run is tested in full.
+
+	-shardtiming file
+	    With -shard, balance the shards by the running time of each
+	    test rather than by the number of tests. The running times are
+	    read from file, which holds the output of an earlier run of
+	    'go test -json', and are not updated by this run. Every shard
+	    must be given the same file, or the shards may overlap and miss
+	    tests.
+
 The test binary also accepts flags that control execution of the test; these
 flags are also accessible by 'go test'. See 'go help testflag' for details.
 
@@ -311,6 +330,16 @@ control the execution of any test:
 	    because it must run them to look for those sub-tests.
 	    See also -skip.
 
+	-shard i/n
+	    Run only shard i of n (1 <= i <= n) of each package's tests,
+	    examples, benchmarks, and fuzz tests. The top-level functions
+	    in each test binary are divided deterministically among the n
+	    shards, so that running the same test binaries with -shard=1/n
+	    through -shard=n/n runs each function exactly once. With -list,
+	    only the functions in shard i are listed. The test binary reports
+	    the shard before running any tests, and 'go test -json' records it
+	    in the Shard field of the events that follow. See also -shardtiming.
+
 	-short
 	    Tell long-running tests to shorten their run time.
 	    It is off by default but set during all.bash so that installing
@@ -548,6 +577,9 @@ var (
 	testList         string                            // -list flag
 	testO            string                            // -o flag
 	testOutputDir    outputdirFlag                     // -outputdir flag
+	testRerunFailed  bool                              // -rerun-failed flag
+	testShard        shardFlag                         // -shard flag
+	testShardTiming  string                            // -shardtiming flag
 	testShuffle      shuffleFlag                       // -shuffle flag
 	testTimeout      time.Duration                     // -timeout flag
 	testV            testVFlag                         // -v flag
@@ -596,6 +628,7 @@ var (
 	testWaitDelay      time.Duration                // how long to wait for output to close after a test binary exits; zero means unlimited
 	testCacheExpire    time.Time                    // ignore cached test results before this time
 	testShouldFailFast atomic.Bool                  // signals pending tests to fail fast
+	testShardTimes     map[string][]byte            // test durations for -shardtiming, by import path
 
 	testBlockProfile, testCPUProfile, testMemProfile, testMutexProfile, testTrace string // profiling flag that limits test to one package
 
@@ -754,6 +787,16 @@ func runTest(ctx context.Context, cmd *base.Command, args []string) {
 			}
 		}
 	}
+	if testShardTiming != "" {
+		if testShard.count == 0 {
+			base.Fatalf("cannot use -shardtiming flag without -shard flag")
+		}
+		var err error
+		testShardTimes, err = loadShardTimes(testShardTiming)
+		if err != nil {
+			base.Fatalf("reading -shardtiming file: %v", err)
+		}
+	}
 	if testProfile() != "" && len(pkgs) != 1 {
 		base.Fatalf("cannot use %s flag with multiple packages", testProfile())
 	}
@@ -1231,6 +1274,9 @@ func builderTest(b *work.Builder, ctx context.Context, pkgOpts load.PackageOpts,
 		rta := &runTestActor{
 			writeCoverMetaAct: writeCoverMetaAct,
 		}
+		if testShardTiming != "" {
+			rta.c.shardTimes = testShardTimes[p.ImportPath]
+		}
 		runAction = &work.Action{
 			Mode:       "test run",
 			Actor:      rta,
@@ -1333,6 +1379,8 @@ type runTestActor struct {
 type runCache struct {
 	disableCache bool // cache should be disabled for this run
 
+	shardTimes []byte // recorded test durations for -shardtiming
+
 	buf *bytes.Buffer
 	id1 cache.ActionID
 	id2 cache.ActionID
@@ -1489,10 +1537,35 @@ func (r *runTestActor) Act(b *work.Builder, ctx context.Context, a *work.Action)
 
 	execCmd := work.FindExecCmd()
 	testlogArg := []string{}
-	if !r.c.disableCache && len(execCmd) == 0 {
+	if (!r.c.disableCache || testRerunFailed) && len(execCmd) == 0 {
+		// -rerun-failed needs the test log even when the result
+		// is not cached, to tell whether the test inputs change.
 		testlogArg = []string{"-test.testlogfile=" + a.Objdir + "testlog.txt"}
 	}
+	resultlogArg := []string{}
+	if testRerunFailed && len(execCmd) == 0 {
+		resultlogArg = []string{"-test.resultlogfile=" + a.Objdir + "resultlog.txt"}
+	}
 	panicArg := "-test.paniconexit0"
+	shardArg := []string{}
+	if len(r.c.shardTimes) > 0 {
+		if err := os.WriteFile(a.Objdir+"shardtimes.txt", r.c.shardTimes, 0666); err != nil {
+			return err
+		}
+		shardArg = []string{"-test.shardtimes=" + a.Objdir + "shardtimes.txt"}
+	}
+	rerunArg := []string{}
+	numPassed := 0
+	var passedTestlog []byte
+	if testRerunFailed && len(execCmd) == 0 {
+		var passed []byte
+		if passed, numPassed, passedTestlog = loadPassedTests(a); numPassed > 0 {
+			if err := os.WriteFile(a.Objdir+"passed.txt", passed, 0666); err != nil {
+				return err
+			}
+			rerunArg = []string{"-test.passedfile=" + a.Objdir + "passed.txt"}
+		}
+	}
 	fuzzArg := []string{}
 	if testFuzz != "" {
 		fuzzCacheDir := filepath.Join(cache.Default().FuzzDir(), a.Package.ImportPath)
@@ -1527,7 +1600,7 @@ func (r *runTestActor) Act(b *work.Builder, ctx context.Context, a *work.Action)
 		// fresh copies of tools to test as part of the testing.
 		addToEnv = "GOCOVERDIR=" + gcd
 	}
-	args := str.StringList(execCmd, a.Deps[0].BuiltTarget(), testlogArg, panicArg, fuzzArg, coverdirArg, testArgs)
+	args := str.StringList(execCmd, a.Deps[0].BuiltTarget(), testlogArg, resultlogArg, panicArg, fuzzArg, coverdirArg, shardArg, rerunArg, testArgs)
 
 	if testCoverProfile != "" {
 		// Write coverage to temporary profile, for merging later.
@@ -1616,6 +1689,21 @@ func (r *runTestActor) Act(b *work.Builder, ctx context.Context, a *work.Action)
 	a.TestOutput = &buf
 	t := fmt.Sprintf("%.3fs", time.Since(t0).Seconds())
 
+	if numPassed > 0 {
+		// The tests that were not run again may have read inputs that
+		// the tests that were did not. Carry those inputs over so that
+		// changing them invalidates both the cached result and the
+		// recorded test results.
+		appendTestlog(a, passedTestlog)
+	}
+	if len(resultlogArg) > 0 {
+		if resultlog, err := os.ReadFile(a.Objdir + "resultlog.txt"); err == nil {
+			saveTestResults(a, resultlog)
+		} else if cache.DebugTest {
+			fmt.Fprintf(os.Stderr, "testcache: %s: reading result log: %v\n", a.Package.ImportPath, err)
+		}
+	}
+
 	mergeCoverProfile(cmd.Stdout, a.Objdir+"_cover_.out")
 
 	if err == nil {
@@ -1637,7 +1725,11 @@ func (r *runTestActor) Act(b *work.Builder, ctx context.Context, a *work.Action)
 			// line we're about to print (https://golang.org/issue/49317).
 			cmd.Stdout.Write([]byte("\n"))
 		}
-		fmt.Fprintf(cmd.Stdout, "ok  \t%s\t%s%s%s\n", a.Package.ImportPath, t, coveragePercentage(out), norun)
+		rerun := ""
+		if numPassed > 0 {
+			rerun = fmt.Sprintf(" [%d passed previously]", numPassed)
+		}
+		fmt.Fprintf(cmd.Stdout, "ok  \t%s\t%s%s%s%s\n", a.Package.ImportPath, t, coveragePercentage(out), norun, rerun)
 		r.c.saveOutput(a)
 	} else {
 		if testFailFast {
@@ -1727,6 +1819,7 @@ func (c *runCache) tryCacheWithID(b *work.Builder, a *work.Action, id string) bo
 			"-test.list",
 			"-test.parallel",
 			"-test.run",
+			"-test.shard",
 			"-test.short",
 			"-test.timeout",
 			"-test.failfast",
@@ -1774,6 +1867,10 @@ func (c *runCache) tryCacheWithID(b *work.Builder, a *work.Action, id string) bo
 
 	h := cache.NewHash("testResult")
 	fmt.Fprintf(h, "test binary %s args %q execcmd %q", id, cacheArgs, work.ExecCmd)
+	if testShardTiming != "" {
+		// The shard assignment depends on the recorded test durations.
+		fmt.Fprintf(h, " shardtimes %x", sha256.Sum256(c.shardTimes))
+	}
 	testID := h.Sum()
 	if c.id1 == (cache.ActionID{}) {
 		c.id1 = testID
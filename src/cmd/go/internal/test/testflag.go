@@ -34,6 +34,8 @@ func init() {
 	work.AddCoverFlags(CmdTest, &testCoverProfile)
 	cf.Var((*base.StringsFlag)(&work.ExecCmd), "exec", "")
 	cf.BoolVar(&testJSON, "json", false, "")
+	cf.BoolVar(&testRerunFailed, "rerun-failed", false, "")
+	cf.StringVar(&testShardTiming, "shardtiming", "", "")
 	cf.Var(&testVet, "vet", "")
 
 	// Register flags to be forwarded to the test binary. We retain variables for
@@ -67,6 +69,7 @@ func init() {
 	cf.StringVar(&testTrace, "trace", "", "")
 	cf.Var(&testV, "v", "")
 	cf.Var(&testShuffle, "shuffle", "")
+	cf.Var(&testShard, "shard", "")
 
 	for name, ok := range passFlagToTest {
 		if ok {
@@ -211,6 +214,34 @@ func (f *shuffleFlag) Set(value string) error {
 	return nil
 }
 
+// shardFlag implements the -shard flag, of the form "i/n" with 1 <= i <= n.
+// The zero value means the tests are not sharded.
+type shardFlag struct {
+	index, count int
+}
+
+func (f *shardFlag) String() string {
+	if f.count == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d/%d", f.index, f.count)
+}
+
+func (f *shardFlag) Set(value string) error {
+	if value == "" {
+		*f = shardFlag{}
+		return nil
+	}
+	is, ns, ok := strings.Cut(value, "/")
+	i, err1 := strconv.Atoi(is)
+	n, err2 := strconv.Atoi(ns)
+	if !ok || err1 != nil || err2 != nil || n < 1 || i < 1 || i > n {
+		return fmt.Errorf("-shard argument must be of the form i/n with 1 <= i <= n")
+	}
+	*f = shardFlag{index: i, count: n}
+	return nil
+}
+
 // testFlags processes the command line, grabbing -x and -c, rewriting known flags
 // to have "test" before them, and reading the command line for the test binary.
 // Unfortunately for us, we need to do our own flag processing because go test
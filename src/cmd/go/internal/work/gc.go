@@ -636,6 +636,21 @@ func (gcToolchain) ld(b *Builder, root *Action, targetPath, importcfg, mainpkg s
 		ldflags = append(ldflags, "-X=runtime.godebugDefault="+root.Package.DefaultGODEBUG)
 	}
 
+	// Pass the PGO profile used to compile package main, so that
+	// the linker can lay out the hot functions together.
+	// The profile is already covered by the link action ID,
+	// which includes package main's action ID.
+	for _, a1 := range root.Deps {
+		if a1.Package != root.Package {
+			continue
+		}
+		for _, a2 := range a1.Deps {
+			if a2.Mode == "preprocess PGO profile" {
+				ldflags = append(ldflags, "-pgoprofile="+a2.built)
+			}
+		}
+	}
+
 	// If the user has not specified the -extld option, then specify the
 	// appropriate linker. In case of C++ code, use the compiler named
 	// by the CXX environment variable or defaultCXX if CXX is not set.
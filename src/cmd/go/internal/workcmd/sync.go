@@ -58,39 +58,9 @@ func runSync(ctx context.Context, cmd *base.Command, args []string) {
 	if err != nil {
 		toolchain.SwitchOrFatal(ctx, err)
 	}
-	mustSelectFor := map[module.Version][]module.Version{}
-
+	mustSelectFor := workspaceSelections(ctx)
 	mms := modload.MainModules
 
-	opts := modload.PackageOpts{
-		Tags:                     imports.AnyTags(),
-		VendorModulesInGOROOTSrc: true,
-		ResolveMissingImports:    false,
-		LoadTests:                true,
-		AllowErrors:              true,
-		SilencePackageErrors:     true,
-		SilenceUnmatchedWarnings: true,
-	}
-	for _, m := range mms.Versions() {
-		opts.MainModule = m
-		_, pkgs := modload.LoadPackages(ctx, opts, "all")
-		opts.MainModule = module.Version{} // reset
-
-		var (
-			mustSelect   []module.Version
-			inMustSelect = map[module.Version]bool{}
-		)
-		for _, pkg := range pkgs {
-			if r := modload.PackageModule(pkg); r.Version != "" && !inMustSelect[r] {
-				// r has a known version, so force that version.
-				mustSelect = append(mustSelect, r)
-				inMustSelect[r] = true
-			}
-		}
-		gover.ModSort(mustSelect) // ensure determinism
-		mustSelectFor[m] = mustSelect
-	}
-
 	workFilePath := modload.WorkFilePath() // save go.work path because EnterModule clobbers it.
 
 	var goV string
@@ -144,3 +114,41 @@ func runSync(ctx context.Context, cmd *base.Command, args []string) {
 		base.Fatal(err)
 	}
 }
+
+// workspaceSelections returns, for each module in the workspace, the
+// versions selected by the workspace's build list for the modules
+// providing the packages in that module's "all" pattern.
+// It must be called in workspace mode, after the module graph is loaded.
+func workspaceSelections(ctx context.Context) map[module.Version][]module.Version {
+	mustSelectFor := map[module.Version][]module.Version{}
+
+	opts := modload.PackageOpts{
+		Tags:                     imports.AnyTags(),
+		VendorModulesInGOROOTSrc: true,
+		ResolveMissingImports:    false,
+		LoadTests:                true,
+		AllowErrors:              true,
+		SilencePackageErrors:     true,
+		SilenceUnmatchedWarnings: true,
+	}
+	for _, m := range modload.MainModules.Versions() {
+		opts.MainModule = m
+		_, pkgs := modload.LoadPackages(ctx, opts, "all")
+		opts.MainModule = module.Version{} // reset
+
+		var (
+			mustSelect   []module.Version
+			inMustSelect = map[module.Version]bool{}
+		)
+		for _, pkg := range pkgs {
+			if r := modload.PackageModule(pkg); r.Version != "" && !inMustSelect[r] {
+				// r has a known version, so force that version.
+				mustSelect = append(mustSelect, r)
+				inMustSelect[r] = true
+			}
+		}
+		gover.ModSort(mustSelect) // ensure determinism
+		mustSelectFor[m] = mustSelect
+	}
+	return mustSelectFor
+}
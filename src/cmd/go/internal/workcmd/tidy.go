@@ -0,0 +1,262 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// go work tidy
+
+package workcmd
+
+import (
+	"cmd/go/internal/base"
+	"cmd/go/internal/cfg"
+	"cmd/go/internal/gover"
+	"cmd/go/internal/imports"
+	"cmd/go/internal/modload"
+	"cmd/go/internal/toolchain"
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+)
+
+var cmdTidy = &base.Command{
+	UsageLine: "go work tidy [-e] [-v]",
+	Short:     "tidy all modules in the workspace together",
+	Long: `Tidy runs the equivalent of 'go mod tidy' in each module of the
+workspace, keeping the modules consistent with one another.
+
+Before tidying a module, tidy upgrades each of the module's dependencies
+to the version selected by the workspace's build list, as 'go work sync'
+does, so that the tidied modules agree on the versions of the
+dependencies they share. It then adds any missing requirements to the
+module's go.mod file and removes unused ones, and updates its go.sum file.
+Since tidying one module can change the versions that the workspace
+selects for the others, tidy repeats these steps until no go.mod file
+changes.
+Finally, it removes any checksums that are no longer needed from the
+workspace's go.work.sum file, and updates the go version in go.work if
+a module now requires a newer one.
+
+Tidy reports each change it makes to a go.mod file, and the reason for
+it, to standard error.
+
+The -v flag causes tidy to print information about removed modules
+to standard error.
+
+The -e flag causes tidy to attempt to proceed despite errors
+encountered while loading packages.
+
+See the workspaces reference at https://go.dev/ref/mod#workspaces
+for more information.
+`,
+	Run: runTidy,
+}
+
+var tidyE bool // if true, report errors but proceed anyway.
+
+func init() {
+	cmdTidy.Flag.BoolVar(&cfg.BuildV, "v", false, "")
+	cmdTidy.Flag.BoolVar(&tidyE, "e", false, "")
+	base.AddChdirFlag(&cmdTidy.Flag)
+	base.AddModCommonFlags(&cmdTidy.Flag)
+}
+
+func runTidy(ctx context.Context, cmd *base.Command, args []string) {
+	if len(args) > 0 {
+		base.Fatalf("go: 'go work tidy' accepts no arguments")
+	}
+
+	modload.ForceUseModules = true
+	modload.InitWorkfile()
+	if modload.WorkFilePath() == "" {
+		base.Fatalf("go: no go.work file found\n\t(run 'go work init' first or specify path using GOWORK environment variable)")
+	}
+
+	workFilePath := modload.WorkFilePath() // save go.work path because EnterModule clobbers it.
+
+	// Tidying a module can add requirements on, or upgrade, dependencies
+	// that other modules in the workspace share, which changes the
+	// workspace's build list. Repeat until the build list, and so every
+	// module's go.mod file, stops changing. Versions only ever increase,
+	// so this terminates.
+	var goV string
+	for first := true; ; first = false {
+		if !first {
+			modload.Reset()
+			modload.ForceUseModules = true
+			modload.InitWorkfile()
+		}
+		_, err := modload.LoadModGraph(ctx, "")
+		if err != nil {
+			toolchain.SwitchOrFatal(ctx, err)
+		}
+		mustSelectFor := workspaceSelections(ctx)
+		mms := modload.MainModules
+
+		changed := false
+		for _, m := range mms.Versions() {
+			if mms.ModRoot(m) == "" && m.Path == "command-line-arguments" {
+				// This is not a real module.
+				// TODO(#49228): Remove this special case once the special
+				// command-line-arguments module is gone.
+				continue
+			}
+			if tidyModule(ctx, mms.ModRoot(m), mustSelectFor[m], &goV) {
+				changed = true
+			}
+		}
+		base.ExitIfErrors()
+		if !changed {
+			break
+		}
+	}
+
+	wf, err := modload.ReadWorkFile(workFilePath)
+	if err != nil {
+		base.Fatal(err)
+	}
+	modload.UpdateWorkGoVersion(wf, goV)
+	modload.UpdateWorkFile(wf)
+	if err := modload.WriteWorkFile(workFilePath, wf); err != nil {
+		base.Fatal(err)
+	}
+
+	// Return to workspace mode and load the packages of all the workspace's
+	// modules to determine which checksums go.work.sum still needs.
+	// Checksums recorded in the tidied go.sum files are not needed.
+	modload.Reset()
+	modload.ForceUseModules = true
+	modload.InitWorkfile()
+	modload.LoadPackages(ctx, modload.PackageOpts{
+		Tags:                     imports.AnyTags(),
+		VendorModulesInGOROOTSrc: true,
+		ResolveMissingImports:    false,
+		LoadTests:                true,
+		AllowErrors:              true,
+		SilenceMissingStdImports: true,
+		SilencePackageErrors:     true,
+		SilenceUnmatchedWarnings: true,
+	}, "all")
+	if err := modload.TidyWorkSum(ctx); err != nil {
+		base.Fatal(err)
+	}
+}
+
+// tidyModule aligns the dependencies of the workspace module rooted at
+// modRoot with the versions in mustSelect, tidies it, and reports the
+// changes made to its go.mod file. It raises *goV to the module's go
+// version and reports whether the go.mod file changed.
+func tidyModule(ctx context.Context, modRoot string, mustSelect []module.Version, goV *string) bool {
+	gomod := filepath.Join(modRoot, "go.mod")
+	before, err := readRequirements(gomod)
+	if err != nil {
+		base.Fatal(err)
+	}
+
+	// Use EnterModule to reset the global state in modload to be in
+	// single-module mode using the modroot of the module.
+	modload.EnterModule(ctx, modRoot)
+
+	// Align the module's dependencies with the workspace before tidying,
+	// in the same way that 'go work sync' does.
+	if _, err := modload.EditBuildList(ctx, nil, mustSelect); err != nil {
+		base.Errorf("go: %s: %v", base.ShortPath(gomod), err)
+		return false
+	}
+	modload.LoadPackages(ctx, modload.PackageOpts{
+		Tags:                     imports.AnyTags(),
+		Tidy:                     true,
+		VendorModulesInGOROOTSrc: true,
+		ResolveMissingImports:    true,
+		LoadTests:                true,
+		AllowErrors:              tidyE,
+		SilenceMissingStdImports: true,
+		Switcher:                 new(toolchain.Switcher),
+	}, "all")
+	*goV = gover.Max(*goV, modload.MainModules.GoVersion())
+
+	after, err := readRequirements(gomod)
+	if err != nil {
+		base.Fatal(err)
+	}
+	reportTidyChanges(base.ShortPath(gomod), before, after, mustSelect)
+	return !maps.Equal(before, after)
+}
+
+// readRequirements returns the versions of the modules, and of go and
+// toolchain, required by the go.mod file gomod.
+func readRequirements(gomod string) (map[string]string, error) {
+	data, err := os.ReadFile(gomod)
+	if err != nil {
+		return nil, err
+	}
+	f, err := modfile.Parse(gomod, data, nil)
+	if err != nil {
+		return nil, err
+	}
+	reqs := make(map[string]string)
+	if f.Go != nil {
+		reqs["go"] = f.Go.Version
+	}
+	if f.Toolchain != nil {
+		reqs["toolchain"] = f.Toolchain.Name
+	}
+	for _, r := range f.Require {
+		reqs[r.Mod.Path] = r.Mod.Version
+	}
+	return reqs, nil
+}
+
+// reportTidyChanges reports to standard error the changes between the
+// requirements before and after tidying of the go.mod file gomod,
+// along with the reason for each. mustSelect lists the versions
+// selected by the workspace's build list.
+func reportTidyChanges(gomod string, before, after map[string]string, mustSelect []module.Version) {
+	selected := make(map[string]string)
+	for _, m := range mustSelect {
+		selected[m.Path] = m.Version
+	}
+
+	var paths []string
+	for path := range before {
+		if after[path] != before[path] {
+			paths = append(paths, path)
+		}
+	}
+	for path := range after {
+		if _, ok := before[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		old, new := before[path], after[path]
+		var change, reason string
+		switch {
+		case old == "":
+			change = fmt.Sprintf("added %s %s", path, new)
+			reason = "needed by the module's packages or tests"
+		case new == "":
+			change = fmt.Sprintf("removed %s %s", path, old)
+			reason = "not needed by the module's packages or tests"
+		default:
+			verb := "upgraded"
+			if gover.ModCompare(path, new, old) < 0 {
+				verb = "downgraded"
+			}
+			change = fmt.Sprintf("%s %s %s => %s", verb, path, old, new)
+			if selected[path] == new {
+				reason = "to match the workspace build list"
+			} else {
+				reason = "required by the module's dependencies"
+			}
+		}
+		fmt.Fprintf(os.Stderr, "go: %s: %s (%s)\n", gomod, change, reason)
+	}
+}
@@ -73,6 +73,7 @@ used.
 		cmdEdit,
 		cmdInit,
 		cmdSync,
+		cmdTidy,
 		cmdUse,
 		cmdVendor,
 	},
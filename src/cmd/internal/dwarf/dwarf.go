@@ -98,6 +98,13 @@ type FnState struct {
 	InlCalls      InlCalls
 	UseBASEntries bool
 
+	// ColdPC is the start of the function's cold fragment, if the
+	// compiler split one out of it. The PCs in Scopes, InlCalls and
+	// the location lists then run through the Size bytes at StartPC
+	// and on into the ColdSize bytes at ColdPC.
+	ColdPC   Sym
+	ColdSize int64
+
 	dictIndexToOffset []int64
 }
 
@@ -330,6 +337,8 @@ const (
 	DW_ABRV_FUNCTION_ABSTRACT
 	DW_ABRV_FUNCTION_CONCRETE
 	DW_ABRV_WRAPPER_CONCRETE
+	DW_ABRV_FUNCTION_RANGES
+	DW_ABRV_FUNCTION_CONCRETE_RANGES
 	DW_ABRV_INLINED_SUBROUTINE
 	DW_ABRV_INLINED_SUBROUTINE_RANGES
 	DW_ABRV_VARIABLE
@@ -501,6 +510,31 @@ var abbrevs = []dwAbbrev{
 		},
 	},
 
+	/* FUNCTION_RANGES */
+	{
+		DW_TAG_subprogram,
+		DW_CHILDREN_yes,
+		[]dwAttrForm{
+			{DW_AT_name, DW_FORM_string},
+			{DW_AT_ranges, DW_FORM_sec_offset},
+			{DW_AT_frame_base, DW_FORM_block1},
+			{DW_AT_decl_file, DW_FORM_data4},
+			{DW_AT_decl_line, DW_FORM_udata},
+			{DW_AT_external, DW_FORM_flag},
+		},
+	},
+
+	/* FUNCTION_CONCRETE_RANGES */
+	{
+		DW_TAG_subprogram,
+		DW_CHILDREN_yes,
+		[]dwAttrForm{
+			{DW_AT_abstract_origin, DW_FORM_ref_addr},
+			{DW_AT_ranges, DW_FORM_sec_offset},
+			{DW_AT_frame_base, DW_FORM_block1},
+		},
+	},
+
 	/* INLINED_SUBROUTINE */
 	{
 		DW_TAG_inlined_subroutine,
@@ -781,6 +815,21 @@ var abbrevs = []dwAbbrev{
 
 // GetAbbrev returns the contents of the .debug_abbrev section.
 func GetAbbrev() []byte {
+	return getAbbrev(false, 0)
+}
+
+// GetAbbrev5 is like GetAbbrev, but returns the contents of the
+// .debug_abbrev section of a DWARF 5 binary with addresses of ptrSize
+// bytes. There, the linker replaces each DW_AT_low_pc address in a DIE
+// with its index in .debug_addr, encoded as a ULEB128 that fills the
+// original field, and each DW_AT_high_pc address with its offset from
+// the DW_AT_low_pc. Compilation units start with a DW_AT_addr_base
+// attribute, which the linker writes before the others.
+func GetAbbrev5(ptrSize int) []byte {
+	return getAbbrev(true, ptrSize)
+}
+
+func getAbbrev(dwarf5 bool, ptrSize int) []byte {
 	abbrevs := Abbrevs()
 	var buf []byte
 	for i := 1; i < len(abbrevs); i++ {
@@ -788,9 +837,25 @@ func GetAbbrev() []byte {
 		buf = AppendUleb128(buf, uint64(i))
 		buf = AppendUleb128(buf, uint64(abbrevs[i].tag))
 		buf = append(buf, abbrevs[i].children)
+		if dwarf5 && abbrevs[i].tag == DW_TAG_compile_unit {
+			buf = AppendUleb128(buf, DW_AT_addr_base)
+			buf = AppendUleb128(buf, DW_FORM_sec_offset)
+		}
 		for _, f := range abbrevs[i].attr {
+			form := f.form
+			if dwarf5 && form == DW_FORM_addr {
+				switch f.attr {
+				case DW_AT_low_pc:
+					form = DW_FORM_addrx
+				case DW_AT_high_pc:
+					form = DW_FORM_data8
+					if ptrSize == 4 {
+						form = DW_FORM_data4
+					}
+				}
+			}
 			buf = AppendUleb128(buf, uint64(f.attr))
-			buf = AppendUleb128(buf, uint64(f.form))
+			buf = AppendUleb128(buf, uint64(form))
 		}
 		buf = append(buf, 0, 0)
 	}
@@ -991,30 +1056,93 @@ func PutBasedRanges(ctxt Context, sym Sym, ranges []Range) {
 }
 
 // PutRanges writes a range table to s.Ranges.
-// All addresses in ranges are relative to s.base.
+// All addresses in ranges are relative to s.StartPC, and run on into
+// the cold fragment at s.ColdPC, if any.
 func (s *FnState) PutRanges(ctxt Context, ranges []Range) {
 	ps := ctxt.PtrSize()
-	sym, base := s.Ranges, s.StartPC
-
-	if s.UseBASEntries {
-		// Using a Base Address Selection Entry reduces the number of relocations, but
-		// this is not done on macOS because it is not supported by dsymutil/dwarfdump/lldb
-		ctxt.AddInt(sym, ps, -1)
-		ctxt.AddAddress(sym, base, 0)
-		PutBasedRanges(ctxt, sym, ranges)
-		return
-	}
+	sym := s.Ranges
+	hot, cold := s.splitRanges(ranges)
+	put := func(base Sym, ranges []Range) {
+		if s.UseBASEntries {
+			// Using a Base Address Selection Entry reduces the number of relocations, but
+			// this is not done on macOS because it is not supported by dsymutil/dwarfdump/lldb
+			ctxt.AddInt(sym, ps, -1)
+			ctxt.AddAddress(sym, base, 0)
+			for _, r := range ranges {
+				ctxt.AddInt(sym, ps, r.Start)
+				ctxt.AddInt(sym, ps, r.End)
+			}
+			return
+		}
 
-	// Write ranges full of relocations
-	for _, r := range ranges {
-		ctxt.AddCURelativeAddress(sym, base, r.Start)
-		ctxt.AddCURelativeAddress(sym, base, r.End)
+		// Write ranges full of relocations
+		for _, r := range ranges {
+			ctxt.AddCURelativeAddress(sym, base, r.Start)
+			ctxt.AddCURelativeAddress(sym, base, r.End)
+		}
+	}
+	put(s.StartPC, hot)
+	if len(cold) > 0 {
+		put(s.ColdPC, cold)
 	}
 	// Write trailer.
 	ctxt.AddInt(sym, ps, 0)
 	ctxt.AddInt(sym, ps, 0)
 }
 
+// splitRanges splits ranges, which are relative to s.StartPC, at the
+// end of the function's code at s.StartPC. It returns the ranges before
+// that point, and the ranges after it relative to s.ColdPC.
+func (s *FnState) splitRanges(ranges []Range) (hot, cold []Range) {
+	if s.ColdPC == nil {
+		return ranges, nil
+	}
+	for _, r := range ranges {
+		if r.Start < s.Size {
+			end := r.End
+			if end > s.Size {
+				end = s.Size
+			}
+			hot = append(hot, Range{r.Start, end})
+		}
+		if r.End > s.Size {
+			start := r.Start
+			if start < s.Size {
+				start = s.Size
+			}
+			cold = append(cold, Range{start - s.Size, r.End - s.Size})
+		}
+	}
+	return hot, cold
+}
+
+// singleRange reports whether ranges, which are relative to s.StartPC,
+// cover a single range of addresses. If so, it returns that range and
+// the symbol it is relative to.
+func (s *FnState) singleRange(ranges []Range) (Sym, Range, bool) {
+	hot, cold := s.splitRanges(ranges)
+	switch {
+	case len(hot) == 1 && len(cold) == 0:
+		return s.StartPC, hot[0], true
+	case len(hot) == 0 && len(cold) == 1:
+		return s.ColdPC, cold[0], true
+	}
+	return nil, Range{}, false
+}
+
+// putFuncPCs writes the attributes for the code of the subprogram DIE
+// with the given abbrev: its low and high PCs, or a range list if the
+// function has a cold fragment.
+func (s *FnState) putFuncPCs(ctxt Context, abbrev int) {
+	if s.ColdPC == nil {
+		putattr(ctxt, s.Info, abbrev, DW_FORM_addr, DW_CLS_ADDRESS, 0, s.StartPC)
+		putattr(ctxt, s.Info, abbrev, DW_FORM_addr, DW_CLS_ADDRESS, s.Size, s.StartPC)
+		return
+	}
+	putattr(ctxt, s.Info, abbrev, DW_FORM_sec_offset, DW_CLS_PTR, ctxt.Size(s.Ranges), s.Ranges)
+	s.PutRanges(ctxt, []Range{{0, s.Size + s.ColdSize}})
+}
+
 // Return TRUE if the inlined call in the specified slot is empty,
 // meaning it has a zero-length range (no instructions), and all
 // of its children are empty.
@@ -1192,7 +1320,8 @@ func putInlinedFunc(ctxt Context, s *FnState, callIdx int) error {
 	callee := ic.AbsFunSym
 
 	abbrev := DW_ABRV_INLINED_SUBROUTINE_RANGES
-	if len(ic.Ranges) == 1 {
+	base, r, single := s.singleRange(ic.Ranges)
+	if single {
 		abbrev = DW_ABRV_INLINED_SUBROUTINE
 	}
 	Uleb128put(ctxt, s.Info, int64(abbrev))
@@ -1208,10 +1337,8 @@ func putInlinedFunc(ctxt Context, s *FnState, callIdx int) error {
 		putattr(ctxt, s.Info, abbrev, DW_FORM_sec_offset, DW_CLS_PTR, ctxt.Size(s.Ranges), s.Ranges)
 		s.PutRanges(ctxt, ic.Ranges)
 	} else {
-		st := ic.Ranges[0].Start
-		en := ic.Ranges[0].End
-		putattr(ctxt, s.Info, abbrev, DW_FORM_addr, DW_CLS_ADDRESS, st, s.StartPC)
-		putattr(ctxt, s.Info, abbrev, DW_FORM_addr, DW_CLS_ADDRESS, en, s.StartPC)
+		putattr(ctxt, s.Info, abbrev, DW_FORM_addr, DW_CLS_ADDRESS, r.Start, base)
+		putattr(ctxt, s.Info, abbrev, DW_FORM_addr, DW_CLS_ADDRESS, r.End, base)
 	}
 
 	// Emit call file, line attrs.
@@ -1258,14 +1385,17 @@ func PutConcreteFunc(ctxt Context, s *FnState, isWrapper bool) error {
 	if isWrapper {
 		abbrev = DW_ABRV_WRAPPER_CONCRETE
 	}
-	Uleb128put(ctxt, s.Info, int64(abbrev))
+	dieAbbrev := abbrev
+	if s.ColdPC != nil {
+		dieAbbrev = DW_ABRV_FUNCTION_CONCRETE_RANGES
+	}
+	Uleb128put(ctxt, s.Info, int64(dieAbbrev))
 
 	// Abstract origin.
 	putattr(ctxt, s.Info, abbrev, DW_FORM_ref_addr, DW_CLS_REFERENCE, 0, s.Absfn)
 
 	// Start/end PC.
-	putattr(ctxt, s.Info, abbrev, DW_FORM_addr, DW_CLS_ADDRESS, 0, s.StartPC)
-	putattr(ctxt, s.Info, abbrev, DW_FORM_addr, DW_CLS_ADDRESS, s.Size, s.StartPC)
+	s.putFuncPCs(ctxt, dieAbbrev)
 
 	// cfa / frame base
 	putattr(ctxt, s.Info, abbrev, DW_FORM_block1, DW_CLS_BLOCK, 1, []byte{DW_OP_call_frame_cfa})
@@ -1304,7 +1434,11 @@ func PutDefaultFunc(ctxt Context, s *FnState, isWrapper bool) error {
 	if isWrapper {
 		abbrev = DW_ABRV_WRAPPER
 	}
-	Uleb128put(ctxt, s.Info, int64(abbrev))
+	dieAbbrev := abbrev
+	if s.ColdPC != nil {
+		dieAbbrev = DW_ABRV_FUNCTION_RANGES
+	}
+	Uleb128put(ctxt, s.Info, int64(dieAbbrev))
 
 	name := s.Name
 	if strings.HasPrefix(name, `"".`) {
@@ -1312,8 +1446,7 @@ func PutDefaultFunc(ctxt Context, s *FnState, isWrapper bool) error {
 	}
 
 	putattr(ctxt, s.Info, DW_ABRV_FUNCTION, DW_FORM_string, DW_CLS_STRING, int64(len(name)), name)
-	putattr(ctxt, s.Info, abbrev, DW_FORM_addr, DW_CLS_ADDRESS, 0, s.StartPC)
-	putattr(ctxt, s.Info, abbrev, DW_FORM_addr, DW_CLS_ADDRESS, s.Size, s.StartPC)
+	s.putFuncPCs(ctxt, dieAbbrev)
 	putattr(ctxt, s.Info, abbrev, DW_FORM_block1, DW_CLS_BLOCK, 1, []byte{DW_OP_call_frame_cfa})
 	if isWrapper {
 		putattr(ctxt, s.Info, abbrev, DW_FORM_flag, DW_CLS_FLAG, int64(1), 0)
@@ -1412,10 +1545,10 @@ func putscope(ctxt Context, s *FnState, scopes []Scope, curscope int32, fnabbrev
 			continue
 		}
 
-		if len(scope.Ranges) == 1 {
+		if base, r, ok := s.singleRange(scope.Ranges); ok {
 			Uleb128put(ctxt, s.Info, DW_ABRV_LEXICAL_BLOCK_SIMPLE)
-			putattr(ctxt, s.Info, DW_ABRV_LEXICAL_BLOCK_SIMPLE, DW_FORM_addr, DW_CLS_ADDRESS, scope.Ranges[0].Start, s.StartPC)
-			putattr(ctxt, s.Info, DW_ABRV_LEXICAL_BLOCK_SIMPLE, DW_FORM_addr, DW_CLS_ADDRESS, scope.Ranges[0].End, s.StartPC)
+			putattr(ctxt, s.Info, DW_ABRV_LEXICAL_BLOCK_SIMPLE, DW_FORM_addr, DW_CLS_ADDRESS, r.Start, base)
+			putattr(ctxt, s.Info, DW_ABRV_LEXICAL_BLOCK_SIMPLE, DW_FORM_addr, DW_CLS_ADDRESS, r.End, base)
 		} else {
 			Uleb128put(ctxt, s.Info, DW_ABRV_LEXICAL_BLOCK_RANGES)
 			putattr(ctxt, s.Info, DW_ABRV_LEXICAL_BLOCK_RANGES, DW_FORM_sec_offset, DW_CLS_PTR, ctxt.Size(s.Ranges), s.Ranges)
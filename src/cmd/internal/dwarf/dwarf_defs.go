@@ -491,3 +491,45 @@ const (
 	DW_CFA_offset      = 0x2 << 6 // +register (ULEB128 offset)
 	DW_CFA_restore     = 0x3 << 6 // +register
 )
+
+// The tables below are from http://dwarfstd.org/doc/DWARF5.pdf.
+
+// Table 7.2
+const (
+	DW_UT_compile = 0x01
+)
+
+// Table 7.5
+const (
+	DW_AT_addr_base = 0x73 // sec_offset
+)
+
+// Table 7.6
+const (
+	DW_FORM_addrx = 0x1b // index into .debug_addr
+)
+
+// Table 7.9
+const (
+	DW_OP_addrx = 0xa1 // 1 ULEB128 index into .debug_addr
+)
+
+// Table 7.10
+const (
+	DW_LLE_end_of_list   = 0x00
+	DW_LLE_base_addressx = 0x01
+	DW_LLE_offset_pair   = 0x04
+)
+
+// Table 7.25
+const (
+	DW_LNCT_path            = 0x1
+	DW_LNCT_directory_index = 0x2
+)
+
+// Table 7.30
+const (
+	DW_RLE_end_of_list   = 0x00
+	DW_RLE_base_addressx = 0x01
+	DW_RLE_offset_pair   = 0x04
+)
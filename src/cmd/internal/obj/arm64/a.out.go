@@ -953,18 +953,25 @@ const (
 	AVADDV
 	AVAND
 	AVBCAX
+	AVBIC
 	AVBIF
 	AVBIT
 	AVBSL
 	AVCMEQ
+	AVCMGT
 	AVCMTST
 	AVCNT
 	AVDUP
 	AVEOR
 	AVEOR3
 	AVEXT
+	AVFADD
+	AVFDIV
 	AVFMLA
 	AVFMLS
+	AVFMUL
+	AVFSQRT
+	AVFSUB
 	AVLD1
 	AVLD1R
 	AVLD2
@@ -978,6 +985,7 @@ const (
 	AVMOVI
 	AVMOVQ
 	AVMOVS
+	AVMUL
 	AVORR
 	AVPMULL
 	AVPMULL2
@@ -988,6 +996,8 @@ const (
 	AVREV64
 	AVSHL
 	AVSLI
+	AVSMAX
+	AVSMIN
 	AVSRI
 	AVST1
 	AVST2
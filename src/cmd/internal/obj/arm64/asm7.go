@@ -3223,6 +3223,15 @@ func buildop(ctxt *obj.Link) {
 			oprangeset(AVUZP1, t)
 			oprangeset(AVUZP2, t)
 			oprangeset(AVBIF, t)
+			oprangeset(AVBIC, t)
+			oprangeset(AVCMGT, t)
+			oprangeset(AVSMAX, t)
+			oprangeset(AVSMIN, t)
+			oprangeset(AVMUL, t)
+			oprangeset(AVFADD, t)
+			oprangeset(AVFSUB, t)
+			oprangeset(AVFMUL, t)
+			oprangeset(AVFDIV, t)
 
 		case AVADD:
 			oprangeset(AVSUB, t)
@@ -3269,6 +3278,7 @@ func buildop(ctxt *obj.Link) {
 			oprangeset(AVRBIT, t)
 			oprangeset(AVREV64, t)
 			oprangeset(AVREV16, t)
+			oprangeset(AVFSQRT, t)
 
 		case AVZIP1:
 			oprangeset(AVZIP2, t)
@@ -4806,15 +4816,15 @@ func (c *ctxt7) asmout(p *obj.Prog, o *Optab, out []uint32) {
 		}
 
 		switch p.As {
-		case AVORR, AVAND, AVEOR, AVBIT, AVBSL, AVBIF:
+		case AVORR, AVAND, AVEOR, AVBIT, AVBSL, AVBIF, AVBIC:
 			if af != ARNG_16B && af != ARNG_8B {
 				c.ctxt.Diag("invalid arrangement: %v", p)
 			}
-		case AVFMLA, AVFMLS:
+		case AVFMLA, AVFMLS, AVFADD, AVFSUB, AVFMUL, AVFDIV:
 			if af != ARNG_2D && af != ARNG_2S && af != ARNG_4S {
 				c.ctxt.Diag("invalid arrangement: %v", p)
 			}
-		case AVUMAX, AVUMIN:
+		case AVUMAX, AVUMIN, AVSMAX, AVSMIN, AVMUL:
 			if af == ARNG_2D {
 				c.ctxt.Diag("invalid arrangement: %v", p)
 			}
@@ -4822,16 +4832,22 @@ func (c *ctxt7) asmout(p *obj.Prog, o *Optab, out []uint32) {
 		switch p.As {
 		case AVAND, AVEOR:
 			size = 0
-		case AVBSL:
+		case AVBSL, AVBIC:
 			size = 1
 		case AVORR, AVBIT, AVBIF:
 			size = 2
-		case AVFMLA, AVFMLS:
+		case AVFMLA, AVFMLS, AVFADD, AVFMUL, AVFDIV:
 			if af == ARNG_2D {
 				size = 1
 			} else {
 				size = 0
 			}
+		case AVFSUB:
+			if af == ARNG_2D {
+				size = 3
+			} else {
+				size = 2
+			}
 		case AVRAX1:
 			if af != ARNG_2D {
 				c.ctxt.Diag("invalid arrangement: %v", p)
@@ -5193,6 +5209,9 @@ func (c *ctxt7) asmout(p *obj.Prog, o *Optab, out []uint32) {
 		case ARNG_4S:
 			Q = 1
 			size = 2
+		case ARNG_2D:
+			Q = 1
+			size = 3
 		default:
 			c.ctxt.Diag("invalid arrangement: %v\n", p)
 		}
@@ -5201,6 +5220,14 @@ func (c *ctxt7) asmout(p *obj.Prog, o *Optab, out []uint32) {
 			c.ctxt.Diag("invalid arrangement: %v", p)
 		}
 
+		if p.As == AVFSQRT && af != ARNG_2S && af != ARNG_4S && af != ARNG_2D {
+			c.ctxt.Diag("invalid arrangement: %v", p)
+		}
+
+		if p.As != AVFSQRT && af == ARNG_2D {
+			c.ctxt.Diag("invalid arrangement: %v", p)
+		}
+
 		if p.As == AVREV32 && (af == ARNG_2S || af == ARNG_4S) {
 			c.ctxt.Diag("invalid arrangement: %v", p)
 		}
@@ -6564,6 +6591,9 @@ func (c *ctxt7) oprrr(p *obj.Prog, a obj.As) uint32 {
 	case AVBIF:
 		return 1<<29 | 7<<25 | 7<<21 | 7<<10
 
+	case AVBIC:
+		return 7<<25 | 1<<21 | 7<<10
+
 	case AVBIT:
 		return 1<<29 | 0x75<<21 | 7<<10
 
@@ -6573,6 +6603,33 @@ func (c *ctxt7) oprrr(p *obj.Prog, a obj.As) uint32 {
 	case AVCMTST:
 		return 0xE<<24 | 1<<21 | 0x23<<10
 
+	case AVCMGT:
+		return 7<<25 | 1<<21 | 0xd<<10
+
+	case AVSMAX:
+		return 7<<25 | 1<<21 | 0x19<<10
+
+	case AVSMIN:
+		return 7<<25 | 1<<21 | 0x1b<<10
+
+	case AVMUL:
+		return 7<<25 | 1<<21 | 0x27<<10
+
+	case AVFADD:
+		return 7<<25 | 1<<21 | 0x35<<10
+
+	case AVFSUB:
+		return 7<<25 | 1<<21 | 0x35<<10
+
+	case AVFMUL:
+		return 1<<29 | 7<<25 | 1<<21 | 0x37<<10
+
+	case AVFDIV:
+		return 1<<29 | 7<<25 | 1<<21 | 0x3f<<10
+
+	case AVFSQRT:
+		return 1<<29 | 0xE<<24 | 1<<21 | 0x1f<<12 | 2<<10
+
 	case AVUMAX:
 		return 1<<29 | 7<<25 | 1<<21 | 0x19<<10
 
@@ -365,6 +365,10 @@ func (ctxt *Link) populateDWARF(curfn Func, s *LSym) {
 		InlCalls:      inlcalls,
 		UseBASEntries: ctxt.UseBASEntries,
 	}
+	if cold := s.Func().Cold; cold != nil {
+		fnstate.ColdPC = cold
+		fnstate.ColdSize = cold.Size
+	}
 	if absfunc != nil {
 		err = dwarf.PutAbstractFunc(dwctxt, fnstate)
 		if err != nil {
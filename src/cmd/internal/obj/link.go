@@ -500,6 +500,13 @@ type FuncInfo struct {
 	WasmImport    *WasmImport
 
 	sehUnwindInfoSym *LSym
+
+	// Cold is the cold fragment split out of this function, if any.
+	// Hot is, for a cold fragment, the function it was split from.
+	// The fragment shares the function's frame, and the only branches
+	// between them go from the function to the fragment.
+	Cold *LSym
+	Hot  *LSym
 }
 
 // JumpTable represents a table used for implementing multi-way
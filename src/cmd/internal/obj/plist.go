@@ -159,18 +159,50 @@ func Flushplist(ctxt *Link, plist *Plist, newprog ProgAlloc) {
 
 	// Turn functions into machine code images.
 	for _, s := range text {
+		if s.Func().Hot != nil {
+			// Cold fragments are assembled along with the
+			// functions they were split from.
+			continue
+		}
 		mkfwd(s)
 		if ctxt.Arch.ErrorCheck != nil {
 			ctxt.Arch.ErrorCheck(ctxt, s)
 		}
 		linkpatch(ctxt, s, newprog)
+		cold := s.Func().Cold
+		var coldJumps []coldJump
+		if cold != nil {
+			mkfwd(cold)
+			if ctxt.Arch.ErrorCheck != nil {
+				ctxt.Arch.ErrorCheck(ctxt, cold)
+			}
+			linkpatch(ctxt, cold, newprog)
+			coldJumps = ctxt.jumpsToCold(s, newprog)
+		}
 		ctxt.Arch.Preprocess(ctxt, s, newprog)
+		if cold != nil {
+			// The fragment shares the frame that s sets up, so
+			// it is preprocessed after s. It is assembled first,
+			// so that the jumps into it know their targets.
+			ctxt.Arch.Preprocess(ctxt, cold, newprog)
+			ctxt.Arch.Assemble(ctxt, cold, newprog)
+			if ctxt.Errors > 0 {
+				continue
+			}
+			linkpcln(ctxt, cold)
+			for _, j := range coldJumps {
+				j.p.To.Offset = j.target.Pc
+			}
+		}
 		ctxt.Arch.Assemble(ctxt, s, newprog)
 		if ctxt.Errors > 0 {
 			continue
 		}
 		linkpcln(ctxt, s)
 		ctxt.populateDWARF(plist.Curfn, s)
+		if cold != nil {
+			ctxt.generateDebugLinesSymbol(cold, cold.Func().dwarfDebugLinesSym)
+		}
 		if ctxt.Headtype == objabi.Hwindows && ctxt.Arch.SEH != nil {
 			s.Func().sehUnwindInfoSym = ctxt.Arch.SEH(ctxt, s)
 		}
@@ -219,6 +251,106 @@ func (ctxt *Link) InitTextSym(s *LSym, flag int, start src.XPos) {
 	ctxt.dwarfSym(s)
 }
 
+// InitColdTextSym creates and returns the cold fragment of the function
+// s, to which the compiler moves the code that s rarely runs, such as
+// the paths to panics. The fragment's TEXT instruction and code follow
+// those of s in the same Plist. Only s may branch into the fragment, and
+// the fragment may not branch back.
+//
+// Unlike InitTextSym, InitColdTextSym may be called concurrently for
+// different functions.
+func (ctxt *Link) InitColdTextSym(s *LSym) *LSym {
+	fn := s.Func()
+	if fn.Cold != nil {
+		ctxt.Diag("%s: function split twice", s.Name)
+	}
+	cold := &LSym{
+		Name: s.Name + ".cold",
+		Type: objabi.STEXT,
+	}
+	cold.Set(AttrStatic, true)
+	cold.Set(AttrOnList, true)
+	cold.SetABI(s.ABI())
+	cfn := cold.NewFuncInfo()
+	cfn.FuncFlag = fn.FuncFlag | abi.FuncFlagCold
+	cfn.StartLine = fn.StartLine
+	cfn.Hot = s
+	fn.Cold = cold
+	cfn.dwarfDebugLinesSym = &LSym{
+		Type: objabi.SDWARFLINES,
+	}
+	return cold
+}
+
+// A coldJump is a jump from a function to its cold fragment, whose
+// offset into the fragment is known only once the fragment is
+// assembled.
+type coldJump struct {
+	p      *Prog
+	target *Prog
+}
+
+// jumpsToCold rewrites the branches of s into its cold fragment, which
+// are resolved by the assembler only within one function, into jumps
+// to the fragment's symbol. Unconditional jumps are rewritten in place.
+// Conditional branches are redirected to jumps appended to s, one per
+// target. It returns the jumps.
+func (ctxt *Link) jumpsToCold(s *LSym, newprog ProgAlloc) []coldJump {
+	cold := s.Func().Cold
+	inCold := make(map[*Prog]bool)
+	for p := cold.Func().Text; p != nil; p = p.Link {
+		inCold[p] = true
+	}
+	for p := cold.Func().Text; p != nil; p = p.Link {
+		if p.To.Type == TYPE_BRANCH && p.To.Target() != nil && !inCold[p.To.Target()] {
+			ctxt.Diag("%s: branch out of the cold fragment of %s", p, s.Name)
+		}
+	}
+
+	var jumps []coldJump
+	stubs := make(map[*Prog]*Prog)
+	last := s.Func().Text
+	for p := last; p != nil; p = p.Link {
+		last = p
+		if p.To.Type != TYPE_BRANCH || !inCold[p.To.Target()] {
+			continue
+		}
+		target := p.To.Target()
+		if p.As == AJMP {
+			p.To.Val = nil
+			p.To.Sym = cold
+			jumps = append(jumps, coldJump{p, target})
+			continue
+		}
+		stub := stubs[target]
+		if stub == nil {
+			stub = newprog()
+			stub.As = AJMP
+			stub.Pos = p.Pos.WithNotStmt()
+			stub.To.Type = TYPE_BRANCH
+			stub.To.Sym = cold
+			stubs[target] = stub
+			jumps = append(jumps, coldJump{stub, target})
+		}
+		p.To.SetTarget(stub)
+	}
+	if len(stubs) == 0 {
+		return jumps
+	}
+
+	// Append the jumps for the conditional branches, in the order of
+	// their targets. They are not worth preempting at.
+	last = ctxt.StartUnsafePoint(last, newprog)
+	for _, j := range jumps {
+		if stubs[j.target] == j.p {
+			j.p.Link = nil
+			last.Link = j.p
+			last = j.p
+		}
+	}
+	return jumps
+}
+
 func (ctxt *Link) toFuncFlag(flag int) abi.FuncFlag {
 	var out abi.FuncFlag
 	if flag&TOPFRAME != 0 {
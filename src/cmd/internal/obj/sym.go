@@ -245,6 +245,17 @@ func (ctxt *Link) NumberSyms() {
 	ctxt.Data = append(ctxt.Data, ctxt.constSyms...)
 	ctxt.constSyms = nil
 
+	// Cold fragments are also created in the concurrent phase. Place
+	// each after the function it was split from.
+	text := make([]*LSym, 0, len(ctxt.Text))
+	for _, s := range ctxt.Text {
+		text = append(text, s)
+		if fn := s.Func(); fn != nil && fn.Cold != nil {
+			text = append(text, fn.Cold)
+		}
+	}
+	ctxt.Text = text
+
 	ctxt.pkgIdx = make(map[string]int32)
 	ctxt.defs = []*LSym{}
 	ctxt.hashed64defs = []*LSym{}
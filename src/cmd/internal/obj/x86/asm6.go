@@ -4929,6 +4929,7 @@ func (ab *AsmBuf) doasm(ctxt *obj.Link, cursym *obj.LSym, p *obj.Prog) {
 					r = obj.Addrel(cursym)
 					r.Off = int32(p.Pc + int64(ab.Len()))
 					r.Sym = p.To.Sym
+					r.Add = p.To.Offset
 					// Note: R_CALL instead of R_PCREL. R_CALL is more permissive in that
 					// it can point to a trampoline instead of the destination itself.
 					r.Type = objabi.R_CALL
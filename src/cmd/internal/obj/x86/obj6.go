@@ -601,14 +601,21 @@ func preprocess(ctxt *obj.Link, cursym *obj.LSym, newprog obj.ProgAlloc) {
 		return
 	}
 
+	if cursym.Func().Hot != nil {
+		preprocessCold(ctxt, cursym, newprog)
+		return
+	}
+
 	p := cursym.Func().Text
 	autoffset := int32(p.To.Offset)
 	if autoffset < 0 {
 		autoffset = 0
 	}
 
-	hasCall := false
-	for q := p; q != nil; q = q.Link {
+	// The cold fragment of a function, if any, runs in its frame and
+	// at least calls the panic that it leads to.
+	hasCall := cursym.Func().Cold != nil
+	for q := p; q != nil && !hasCall; q = q.Link {
 		if q.As == obj.ACALL || q.As == obj.ADUFFCOPY || q.As == obj.ADUFFZERO {
 			hasCall = true
 			break
@@ -642,7 +649,7 @@ func preprocess(ctxt *obj.Link, cursym *obj.LSym, newprog obj.ProgAlloc) {
 	}
 
 	// TODO(rsc): Remove 'ctxt.Arch.Family == sys.AMD64 &&'.
-	if ctxt.Arch.Family == sys.AMD64 && autoffset < abi.StackSmall && !p.From.Sym.NoSplit() {
+	if ctxt.Arch.Family == sys.AMD64 && autoffset < abi.StackSmall && !p.From.Sym.NoSplit() && !hasCall {
 		leaf := true
 	LeafSearch:
 		for q := p; q != nil; q = q.Link {
@@ -840,8 +847,37 @@ func preprocess(ctxt *obj.Link, cursym *obj.LSym, newprog obj.ProgAlloc) {
 		p = end
 	}
 
-	var deltasp int32
-	for p = cursym.Func().Text; p != nil; p = p.Link {
+	adjustFrame(ctxt, cursym, newprog, autoffset, localoffset, bpsize, 0)
+}
+
+// preprocessCold prepares the cold fragment cursym of a function. The
+// function branches to the fragment with its frame set up, so the
+// fragment has no prologue of its own and its stack offsets and
+// epilogues are those of the function.
+func preprocessCold(ctxt *obj.Link, cursym *obj.LSym, newprog obj.ProgAlloc) {
+	hot := cursym.Func().Hot
+	autoffset := hot.Func().Locals
+	var bpsize int
+	if !hot.NoFrame() {
+		bpsize = ctxt.Arch.PtrSize
+	}
+	cursym.Func().Args = hot.Func().Args
+	cursym.Func().Locals = autoffset
+	cursym.Func().Text.To.Offset = int64(autoffset)
+
+	// The TEXT instruction has no width, so this sets the SP
+	// adjustment in effect from the first instruction on.
+	cursym.Func().Text.Spadj = autoffset
+
+	adjustFrame(ctxt, cursym, newprog, autoffset, autoffset-int32(bpsize), bpsize, autoffset)
+}
+
+// adjustFrame rewrites the stack references of cursym to be relative
+// to SP, tracking the SP adjustment from deltasp at its start, records
+// the SP adjustments, and expands each RET into an epilogue that pops
+// the frame of autoffset bytes, localoffset of which are locals.
+func adjustFrame(ctxt *obj.Link, cursym *obj.LSym, newprog obj.ProgAlloc, autoffset, localoffset int32, bpsize int, deltasp int32) {
+	for p := cursym.Func().Text; p != nil; p = p.Link {
 		pcsize := ctxt.Arch.RegSize
 		switch p.From.Name {
 		case obj.NAME_AUTO:
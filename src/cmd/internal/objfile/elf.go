@@ -27,7 +27,18 @@ func openElf(r io.ReaderAt) (rawFile, error) {
 }
 
 func (f *elfFile) symbols() ([]Sym, error) {
-	elfSyms, err := f.elf.Symbols()
+	file := f.elf
+	elfSyms, err := file.Symbols()
+	if err == elf.ErrNoSymbols {
+		// The symbol table may have been moved to a separate debug
+		// information file, whose section headers describe the
+		// sections the symbols refer to.
+		if df, dfErr := f.elf.DebugFile(); dfErr == nil {
+			defer df.Close()
+			file = df
+			elfSyms, err = df.Symbols()
+		}
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -42,10 +53,10 @@ func (f *elfFile) symbols() ([]Sym, error) {
 			sym.Code = 'B'
 		default:
 			i := int(s.Section)
-			if i < 0 || i >= len(f.elf.Sections) {
+			if i < 0 || i >= len(file.Sections) {
 				break
 			}
-			sect := f.elf.Sections[i]
+			sect := file.Sections[i]
 			switch sect.Flags & (elf.SHF_WRITE | elf.SHF_ALLOC | elf.SHF_EXECINSTR) {
 			case elf.SHF_ALLOC | elf.SHF_EXECINSTR:
 				sym.Code = 'T'
@@ -146,6 +157,14 @@ func (f *elfFile) loadAddress() (uint64, error) {
 }
 
 func (f *elfFile) dwarf() (*dwarf.Data, error) {
+	if f.elf.Section(".debug_info") == nil && f.elf.Section(".zdebug_info") == nil {
+		// The debug information may have been moved to a separate
+		// file, as by the -debugfile linker flag.
+		if df, err := f.elf.DebugFile(); err == nil {
+			defer df.Close()
+			return df.DWARF()
+		}
+	}
 	return f.elf.DWARF()
 }
 
@@ -180,3 +199,29 @@ func (f *elfFile) symbolData(start, end string) []byte {
 	}
 	return nil
 }
+
+func (f *elfFile) sectionData(name string) ([]byte, error) {
+	s := f.elf.Section(name)
+	if s == nil || s.Type == elf.SHT_NOBITS {
+		return nil, fmt.Errorf("no %s section", name)
+	}
+	return s.Data()
+}
+
+func (f *elfFile) sections() ([]Section, error) {
+	var sects []Section
+	for _, s := range f.elf.Sections {
+		if s.Type == elf.SHT_NULL {
+			continue
+		}
+		sect := Section{Name: s.Name, Size: s.Size, FileSize: s.FileSize}
+		if s.Flags&elf.SHF_ALLOC != 0 {
+			sect.Addr = s.Addr
+		}
+		if s.Type == elf.SHT_NOBITS {
+			sect.FileSize = 0
+		}
+		sects = append(sects, sect)
+	}
+	return sects, nil
+}
@@ -12,6 +12,7 @@ import (
 	"fmt"
 	"io"
 	"sort"
+	"strings"
 )
 
 const stabTypeMask = 0xe0
@@ -137,3 +138,43 @@ func (f *machoFile) loadAddress() (uint64, error) {
 func (f *machoFile) dwarf() (*dwarf.Data, error) {
 	return f.macho.DWARF()
 }
+
+func (f *machoFile) sectionData(name string) ([]byte, error) {
+	s := f.macho.Section(name)
+	if s == nil {
+		return nil, fmt.Errorf("no %s section", name)
+	}
+	data, err := s.Data()
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(name, "__zdebug") {
+		return decompressDebug(data)
+	}
+	return data, nil
+}
+
+func (f *machoFile) sections() ([]Section, error) {
+	const (
+		sectionType         = 0xff
+		zerofill            = 0x1
+		gbZerofill          = 0xc
+		threadLocalZerofill = 0x12
+	)
+	var sects []Section
+	for _, s := range f.macho.Sections {
+		sect := Section{Name: s.Name, Addr: s.Addr, Size: s.Size, FileSize: s.Size}
+		switch s.Flags & sectionType {
+		case zerofill, gbZerofill, threadLocalZerofill:
+			sect.FileSize = 0
+		}
+		if strings.HasPrefix(s.Name, "__zdebug") {
+			sect.Addr = 0
+			sect.Size = compressedDebugSize(s, s.Size)
+		} else if strings.HasPrefix(s.Name, "__debug") {
+			sect.Addr = 0
+		}
+		sects = append(sects, sect)
+	}
+	return sects, nil
+}
@@ -6,9 +6,12 @@
 package objfile
 
 import (
+	"bytes"
 	"cmd/internal/archive"
+	"compress/zlib"
 	"debug/dwarf"
 	"debug/gosym"
+	"encoding/binary"
 	"fmt"
 	"io"
 	"os"
@@ -22,6 +25,8 @@ type rawFile interface {
 	goarch() string
 	loadAddress() (uint64, error)
 	dwarf() (*dwarf.Data, error)
+	sections() ([]Section, error)
+	sectionData(name string) ([]byte, error)
 }
 
 // A File is an opened executable file.
@@ -45,6 +50,14 @@ type Sym struct {
 	Relocs []Reloc // in increasing Addr order
 }
 
+// A Section is a section of an executable file.
+type Section struct {
+	Name     string // section name
+	Addr     uint64 // virtual address, or 0 if the section is not loaded
+	Size     uint64 // size in memory, or uncompressed size for compressed debug sections
+	FileSize uint64 // size in the file, 0 for sections with no data in the file
+}
+
 type Reloc struct {
 	Addr     uint64 // Address of first byte that reloc applies to.
 	Size     uint64 // Number of bytes
@@ -118,6 +131,18 @@ func (f *File) DWARF() (*dwarf.Data, error) {
 	return f.entries[0].DWARF()
 }
 
+func (f *File) PCLNTab() ([]byte, error) {
+	return f.entries[0].PCLNTab()
+}
+
+func (f *File) Sections() ([]Section, error) {
+	return f.entries[0].Sections()
+}
+
+func (f *File) SectionData(name string) ([]byte, error) {
+	return f.entries[0].SectionData(name)
+}
+
 func (f *File) Disasm() (*Disasm, error) {
 	return f.entries[0].Disasm()
 }
@@ -184,3 +209,51 @@ func (e *Entry) LoadAddress() (uint64, error) {
 func (e *Entry) DWARF() (*dwarf.Data, error) {
 	return e.raw.dwarf()
 }
+
+// PCLNTab returns the raw contents of the Go function table, pclntab,
+// which the runtime uses to map PCs to functions and source lines.
+func (e *Entry) PCLNTab() ([]byte, error) {
+	_, _, pclntab, err := e.raw.pcln()
+	if err == nil && len(pclntab) == 0 {
+		err = fmt.Errorf("pclntab not found")
+	}
+	return pclntab, err
+}
+
+// Sections returns the sections of the file, in the order in which
+// they appear in the file's section table.
+func (e *Entry) Sections() ([]Section, error) {
+	return e.raw.sections()
+}
+
+// SectionData returns the contents of the named section, uncompressed
+// if it is a compressed debug section.
+func (e *Entry) SectionData(name string) ([]byte, error) {
+	return e.raw.sectionData(name)
+}
+
+// compressedDebugSize returns the uncompressed size of a debug section
+// compressed by the Go linker in the "ZLIB" format used on non-ELF
+// systems, or size if the section is not compressed.
+func compressedDebugSize(r io.ReaderAt, size uint64) uint64 {
+	var hdr [12]byte
+	if _, err := r.ReadAt(hdr[:], 0); err != nil || string(hdr[:4]) != "ZLIB" {
+		return size
+	}
+	return binary.BigEndian.Uint64(hdr[4:])
+}
+
+// decompressDebug returns the contents of a debug section compressed
+// by the Go linker in the "ZLIB" format, or data if it is not compressed.
+func decompressDebug(data []byte) ([]byte, error) {
+	if len(data) < 12 || string(data[:4]) != "ZLIB" {
+		return data, nil
+	}
+	size := binary.BigEndian.Uint64(data[4:12])
+	r, err := zlib.NewReader(bytes.NewReader(data[12:]))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(io.LimitReader(r, int64(size)))
+}
@@ -12,6 +12,7 @@ import (
 	"fmt"
 	"io"
 	"sort"
+	"strings"
 )
 
 type peFile struct {
@@ -200,3 +201,43 @@ func (f *peFile) imageBase() (uint64, error) {
 func (f *peFile) dwarf() (*dwarf.Data, error) {
 	return f.pe.DWARF()
 }
+
+func (f *peFile) sectionData(name string) ([]byte, error) {
+	s := f.pe.Section(name)
+	if s == nil {
+		return nil, fmt.Errorf("no %s section", name)
+	}
+	data, err := s.Data()
+	if err != nil {
+		return nil, err
+	}
+	// The data is padded to the file alignment.
+	if s.VirtualSize != 0 && uint64(s.VirtualSize) < uint64(len(data)) {
+		data = data[:s.VirtualSize]
+	}
+	if strings.HasPrefix(name, ".zdebug") {
+		return decompressDebug(data)
+	}
+	return data, nil
+}
+
+func (f *peFile) sections() ([]Section, error) {
+	imageBase, _ := f.imageBase()
+	var sects []Section
+	for _, s := range f.pe.Sections {
+		sect := Section{
+			Name:     s.Name,
+			Addr:     imageBase + uint64(s.VirtualAddress),
+			Size:     uint64(s.VirtualSize),
+			FileSize: uint64(s.Size),
+		}
+		if strings.HasPrefix(s.Name, ".zdebug") {
+			sect.Addr = 0
+			sect.Size = compressedDebugSize(s, sect.FileSize)
+		} else if strings.HasPrefix(s.Name, ".debug") {
+			sect.Addr = 0
+		}
+		sects = append(sects, sect)
+	}
+	return sects, nil
+}
@@ -154,3 +154,23 @@ func (f *plan9File) loadAddress() (uint64, error) {
 func (f *plan9File) dwarf() (*dwarf.Data, error) {
 	return nil, errors.New("no DWARF data in Plan 9 file")
 }
+
+func (f *plan9File) sectionData(name string) ([]byte, error) {
+	s := f.plan9.Section(name)
+	if s == nil {
+		return nil, fmt.Errorf("no %s section", name)
+	}
+	return s.Data()
+}
+
+func (f *plan9File) sections() ([]Section, error) {
+	var sects []Section
+	for _, s := range f.plan9.Sections {
+		sect := Section{Name: s.Name, Size: uint64(s.Size), FileSize: uint64(s.Size)}
+		if s.Name == "text" {
+			sect.Addr = f.plan9.LoadAddress + f.plan9.HdrSize
+		}
+		sects = append(sects, sect)
+	}
+	return sects, nil
+}
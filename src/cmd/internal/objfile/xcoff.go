@@ -161,3 +161,27 @@ func (f *xcoffFile) loadAddress() (uint64, error) {
 func (f *xcoffFile) dwarf() (*dwarf.Data, error) {
 	return f.xcoff.DWARF()
 }
+
+func (f *xcoffFile) sectionData(name string) ([]byte, error) {
+	s := f.xcoff.Section(name)
+	if s == nil {
+		return nil, fmt.Errorf("no %s section", name)
+	}
+	return s.Data()
+}
+
+func (f *xcoffFile) sections() ([]Section, error) {
+	var sects []Section
+	for _, s := range f.xcoff.Sections {
+		sect := Section{Name: s.Name, Size: s.Size, FileSize: s.Size}
+		switch s.Type {
+		case xcoff.STYP_TEXT, xcoff.STYP_DATA:
+			sect.Addr = s.VirtualAddress
+		case xcoff.STYP_BSS:
+			sect.Addr = s.VirtualAddress
+			sect.FileSize = 0
+		}
+		sects = append(sects, sect)
+	}
+	return sects, nil
+}
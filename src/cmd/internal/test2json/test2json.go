@@ -32,6 +32,7 @@ type event struct {
 	Time    *time.Time `json:",omitempty"`
 	Action  string
 	Package string     `json:",omitempty"`
+	Shard   string     `json:",omitempty"`
 	Test    string     `json:",omitempty"`
 	Elapsed *float64   `json:",omitempty"`
 	Output  *textBytes `json:",omitempty"`
@@ -51,6 +52,7 @@ func (b textBytes) MarshalText() ([]byte, error) { return b, nil }
 type Converter struct {
 	w          io.Writer  // JSON output stream
 	pkg        string     // package to name in events
+	shard      string     // test shard ("i/n") reported by the test binary, if any
 	mode       Mode       // mode bits
 	start      time.Time  // time converter started
 	testName   string     // name of current test, for output attribution
@@ -178,6 +180,9 @@ var (
 
 	skipLinePrefix = []byte("?   \t")
 	skipLineSuffix = []byte("\t[no test files]")
+
+	// printed by test before running tests when -test.shard is set.
+	shardPrefix = []byte("-test.shard ")
 )
 
 // handleInputLine handles a single whole test output line.
@@ -233,6 +238,12 @@ func (c *Converter) handleInputLine(line []byte) {
 		c.result = "skip"
 	}
 
+	// "-test.shard i/n" precedes the tests run by a shard.
+	// Report it as plain output but attribute later events to the shard.
+	if shard, ok := bytes.CutPrefix(trim, shardPrefix); ok && len(c.report) == 0 {
+		c.shard = string(shard)
+	}
+
 	// "=== RUN   "
 	// "=== PAUSE "
 	// "=== CONT  "
@@ -383,9 +394,11 @@ func (c *Converter) writeOutputEvent(out []byte) {
 }
 
 // writeEvent writes a single event.
-// It adds the package, time (if requested), and test name (if needed).
+// It adds the package, shard (if known), time (if requested),
+// and test name (if needed).
 func (c *Converter) writeEvent(e *event) {
 	e.Package = c.pkg
+	e.Shard = c.shard
 	if c.mode&Timestamp != 0 {
 		t := time.Now()
 		e.Time = &t
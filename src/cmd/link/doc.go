@@ -59,6 +59,12 @@ Flags:
 		Compress DWARF if possible (default true).
 	-cpuprofile file
 		Write CPU profile to file.
+	-debugfile file
+		Write the DWARF debug information to the separate file instead
+		of the binary, and record the name and checksum of file in a
+		.gnu_debuglink section of the binary. The binary is given a GNU
+		build ID, as with -B gobuildid, if it has none. With -s, the
+		symbol table is also moved to file. Only supported on ELF.
 	-d
 		Disable generation of dynamic executables.
 		The emitted code is the same in either case; the option
@@ -68,6 +74,9 @@ Flags:
 		system tools now assume the presence of the header.
 	-dumpdep
 		Dump symbol dependency graph.
+	-dwarfversion version
+		Generate DWARF debug information of the given version, 4 or 5
+		(default 5, or 4 on AIX and Darwin, which support only version 4).
 	-extar ar
 		Set the external archive program (default "ar").
 		Used only for -buildmode=c-archive.
@@ -79,6 +88,12 @@ Flags:
 		Ignore version mismatch in the linked archives.
 	-g
 		Disable Go package data checks.
+	-icf
+		Fold functions with identical code and metadata, such as
+		instantiations of a generic function with different shapes,
+		into a single copy. Only functions whose names are printed the
+		same in tracebacks are folded. Only applies to internally linked
+		executables.
 	-importcfg file
 		Read import configuration from file.
 		In the file, set packagefile, packageshlib to specify import resolution.
@@ -106,6 +121,9 @@ Flags:
 		Link with C/C++ memory sanitizer support.
 	-o file
 		Write output to file (default a.out, or a.out.exe on Windows).
+	-pgoprofile file
+		Use the PGO profile in file to order functions, placing
+		functions that call each other frequently next to each other.
 	-pluginpath path
 		The path name used to prefix exported plugin symbols.
 	-r dir1:dir2:...
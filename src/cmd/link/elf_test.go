@@ -11,6 +11,7 @@ import (
 	"cmd/internal/buildid"
 	"cmd/internal/notsha256"
 	"cmd/link/internal/ld"
+	"debug/dwarf"
 	"debug/elf"
 	"fmt"
 	"internal/platform"
@@ -560,3 +561,115 @@ func TestFlagR(t *testing.T) {
 		t.Errorf("executable failed to run: %v\n%s", err, out)
 	}
 }
+
+func TestDebugFile(t *testing.T) {
+	testenv.MustHaveGoBuild(t)
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		ldflags string
+		cgo     bool
+	}{
+		{name: "internal"},
+		{name: "stripped", ldflags: "-s"},
+		{name: "external", ldflags: "-linkmode=external", cgo: true},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			if test.cgo {
+				testenv.MustHaveCGO(t)
+			}
+			t.Parallel()
+
+			tmpdir := t.TempDir()
+			src := filepath.Join(tmpdir, "x.go")
+			if err := os.WriteFile(src, []byte(goSource), 0444); err != nil {
+				t.Fatal(err)
+			}
+			exe := filepath.Join(tmpdir, "x.exe")
+			debugFile := filepath.Join(tmpdir, "x.debug")
+			ldflags := strings.TrimSpace(test.ldflags + " -debugfile=" + debugFile)
+			cmd := testenv.Command(t, testenv.GoToolPath(t), "build", "-ldflags="+ldflags, "-o", exe, src)
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("build failed: %v, output:\n%s", err, out)
+			}
+			if out, err := testenv.Command(t, exe).CombinedOutput(); err != nil {
+				t.Errorf("executable failed to run: %v\n%s", err, out)
+			}
+
+			ef, err := elf.Open(exe)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer ef.Close()
+			for _, s := range ef.Sections {
+				if strings.HasPrefix(s.Name, ".debug_") || strings.HasPrefix(s.Name, ".zdebug_") {
+					t.Errorf("executable has DWARF section %s", s.Name)
+				}
+			}
+			name, crc, err := ef.DebugLink()
+			if err != nil || name != "x.debug" {
+				t.Fatalf("DebugLink() = %q, %#x, %v; want x.debug", name, crc, err)
+			}
+
+			df, err := ef.DebugFile()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer df.Close()
+			id, err := ef.BuildID()
+			if err != nil || id == nil {
+				t.Errorf("no GNU build ID: %v", err)
+			}
+			if debugID, _ := df.BuildID(); !bytes.Equal(id, debugID) {
+				t.Errorf("debug file build ID %x, want %x", debugID, id)
+			}
+
+			d, err := df.DWARF()
+			if err != nil {
+				t.Fatal(err)
+			}
+			found := false
+			for r := d.Reader(); ; {
+				e, err := r.Next()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if e == nil {
+					break
+				}
+				if name, _ := e.Val(dwarf.AttrName).(string); name == "main.main" {
+					found = true
+					break
+				}
+			}
+			if !found {
+				t.Errorf("main.main not found in DWARF")
+			}
+
+			_, err = ef.Symbols()
+			if test.ldflags == "-s" {
+				if err != elf.ErrNoSymbols {
+					t.Errorf("stripped executable has symbols, err = %v", err)
+				}
+				syms, err := df.Symbols()
+				if err != nil {
+					t.Fatal(err)
+				}
+				found := false
+				for _, s := range syms {
+					if s.Name == "main.main" {
+						found = true
+					}
+				}
+				if !found {
+					t.Errorf("main.main not found in debug file symbols")
+				}
+			} else if err != nil {
+				t.Errorf("Symbols: %v", err)
+			}
+		})
+	}
+}
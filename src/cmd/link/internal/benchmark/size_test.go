@@ -0,0 +1,60 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchmark
+
+import (
+	"debug/elf"
+	"internal/testenv"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// BenchmarkBinarySize reports the size of some programs, and of their
+// text, as linked with and without identical code folding (-icf).
+// The time is that of a complete build and not of interest.
+func BenchmarkBinarySize(b *testing.B) {
+	testenv.MustHaveGoBuild(b)
+	testenv.MustInternalLink(b, false)
+
+	progs := []struct {
+		name, pkg string
+	}{
+		{"Generics", "./testdata/generics"},
+		{"Go", "cmd/go"},
+	}
+	for _, prog := range progs {
+		for _, icf := range []bool{false, true} {
+			name := prog.name
+			ldflags := "-ldflags=-icf=false"
+			if icf {
+				name += "ICF"
+				ldflags = "-ldflags=-icf"
+			}
+			b.Run(name, func(b *testing.B) {
+				exe := filepath.Join(b.TempDir(), "a.exe")
+				for i := 0; i < b.N; i++ {
+					cmd := testenv.Command(b, testenv.GoToolPath(b), "build", ldflags, "-o", exe, prog.pkg)
+					if out, err := cmd.CombinedOutput(); err != nil {
+						b.Fatalf("%v: %v:\n%s", cmd.Args, err, out)
+					}
+				}
+				b.StopTimer()
+
+				fi, err := os.Stat(exe)
+				if err != nil {
+					b.Fatal(err)
+				}
+				b.ReportMetric(float64(fi.Size()), "bytes")
+				if f, err := elf.Open(exe); err == nil {
+					if text := f.Section(".text"); text != nil {
+						b.ReportMetric(float64(text.Size), "text-bytes")
+					}
+					f.Close()
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Generics instantiates generic functions and types from the standard
+// library with many types of the same layout, which produces many
+// functions with identical code.
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"sync/atomic"
+)
+
+type (
+	I8  int8
+	U8  uint8
+	I16 int16
+	U16 uint16
+	I32 int32
+	U32 uint32
+	I64 int64
+	U64 uint64
+)
+
+type Set[K comparable] struct {
+	m map[K]struct{}
+	n atomic.Int64
+}
+
+func (s *Set[K]) Add(k K) {
+	if s.m == nil {
+		s.m = make(map[K]struct{})
+	}
+	s.m[k] = struct{}{}
+	s.n.Add(1)
+}
+
+func (s *Set[K]) Sorted(less func(a, b K) int) []K {
+	keys := make([]K, 0, len(s.m))
+	for k := range s.m {
+		keys = append(keys, k)
+	}
+	slices.SortFunc(keys, less)
+	return keys
+}
+
+func use[T cmp.Ordered](vals ...T) {
+	var s Set[T]
+	for _, v := range vals {
+		s.Add(v)
+	}
+	sorted := s.Sorted(cmp.Compare[T])
+	slices.Reverse(sorted)
+	sorted2 := slices.Clone(sorted)
+	slices.Sort(sorted2)
+	i, found := slices.BinarySearch(sorted2, vals[0])
+	fmt.Println(sorted, slices.Max(sorted), slices.Min(sorted), slices.Index(sorted, vals[0]), i, found)
+}
+
+func main() {
+	use[I8](3, 1, 2)
+	use[U8](3, 1, 2)
+	use[I16](3, 1, 2)
+	use[U16](3, 1, 2)
+	use[I32](3, 1, 2)
+	use[U32](3, 1, 2)
+	use[I64](3, 1, 2)
+	use[U64](3, 1, 2)
+	use("c", "a", "b")
+}
@@ -121,13 +121,16 @@ func trampoline(ctxt *Link, s loader.Sym) {
 		if !ldr.AttrReachable(rs) || ldr.SymType(rs) == sym.Sxxx {
 			continue // something is wrong. skip it here and we'll emit a better error later
 		}
+		if into, ok := ctxt.foldedText[rs]; ok {
+			rs = into // rs is folded into another function; see icf.go
+		}
 
 		if ldr.SymValue(rs) == 0 && ldr.SymType(rs) != sym.SDYNIMPORT && ldr.SymType(rs) != sym.SUNDEFEXT {
 			// Symbols in the same package are laid out together (if we
-			// don't randomize the function order).
+			// don't reorder the functions).
 			// Except that if SymPkg(s) == "", it is a host object symbol
 			// which may call an external symbol via PLT.
-			if ldr.SymPkg(s) != "" && ldr.SymPkg(rs) == ldr.SymPkg(s) && *flagRandLayout == 0 {
+			if ldr.SymPkg(s) != "" && ldr.SymPkg(rs) == ldr.SymPkg(s) && !textReordered() {
 				// RISC-V is only able to reach +/-1MiB via a JAL instruction.
 				// We need to generate a trampoline when an address is
 				// currently unknown.
@@ -136,7 +139,7 @@ func trampoline(ctxt *Link, s loader.Sym) {
 				}
 			}
 			// Runtime packages are laid out together.
-			if isRuntimeDepPkg(ldr.SymPkg(s)) && isRuntimeDepPkg(ldr.SymPkg(rs)) && *flagRandLayout == 0 {
+			if isRuntimeDepPkg(ldr.SymPkg(s)) && isRuntimeDepPkg(ldr.SymPkg(rs)) && !textReordered() {
 				continue
 			}
 		}
@@ -354,6 +357,10 @@ func (st *relocSymState) relocsym(s loader.Sym, P []byte) {
 				log.Fatalf("cannot handle R_TLS_IE (sym %s) when linking internally", ldr.SymName(s))
 			}
 		case objabi.R_ADDR, objabi.R_PEIMAGEOFF:
+			if rt == objabi.R_ADDR && dwarfAddrs != nil && isDwarfDIE(ldr.SymType(s)) {
+				st.relocDWARFAddr(s, P, &relocs, ri)
+				continue
+			}
 			if weak && !ldr.AttrReachable(rs) {
 				// Redirect it to runtime.unreachableMethod, which will throw if called.
 				rs = syms.unreachableMethod
@@ -429,6 +436,10 @@ func (st *relocSymState) relocsym(s loader.Sym, P []byte) {
 			if ldr.SymSect(rs) == nil {
 				st.err.Errorf(s, "missing DWARF section for relocation target %s", ldr.SymName(rs))
 			}
+			add, ok := dwarfListOffset(rs, r.Add())
+			if !ok {
+				st.err.Errorf(s, "no DWARF list at %s+%d", ldr.SymName(rs), r.Add())
+			}
 
 			if target.IsExternal() {
 				// On most platforms, the external linker needs to adjust DWARF references
@@ -440,7 +451,7 @@ func (st *relocSymState) relocsym(s loader.Sym, P []byte) {
 					nExtReloc++
 				}
 
-				xadd := r.Add() + ldr.SymValue(rs) - int64(ldr.SymSect(rs).Vaddr)
+				xadd := add + ldr.SymValue(rs) - int64(ldr.SymSect(rs).Vaddr)
 
 				o = xadd
 				if target.IsElf() && target.IsAMD64() {
@@ -448,7 +459,7 @@ func (st *relocSymState) relocsym(s loader.Sym, P []byte) {
 				}
 				break
 			}
-			o = ldr.SymValue(rs) + r.Add() - int64(ldr.SymSect(rs).Vaddr)
+			o = ldr.SymValue(rs) + add - int64(ldr.SymSect(rs).Vaddr)
 		case objabi.R_METHODOFF:
 			if !ldr.AttrReachable(rs) {
 				// Set it to a sentinel value. The runtime knows this is not pointing to
@@ -655,6 +666,10 @@ func extreloc(ctxt *Link, ldr *loader.Loader, s loader.Sym, r loader.Reloc) (loa
 		return rr, false
 
 	case objabi.R_ADDR, objabi.R_PEIMAGEOFF:
+		if rt == objabi.R_ADDR && dwarfAddrs != nil && isDwarfDIE(ldr.SymType(s)) {
+			// See relocDWARFAddr.
+			return rr, false
+		}
 		// set up addend for eventual relocation via outer symbol.
 		rs := r.Sym()
 		if r.Weak() && !ldr.AttrReachable(rs) {
@@ -674,8 +689,9 @@ func extreloc(ctxt *Link, ldr *loader.Loader, s loader.Sym, r loader.Reloc) (loa
 			return rr, false
 		}
 		rs := r.Sym()
+		add, _ := dwarfListOffset(rs, r.Add())
 		rr.Xsym = loader.Sym(ldr.SymSect(rs).Sym)
-		rr.Xadd = r.Add() + ldr.SymValue(rs) - int64(ldr.SymSect(rs).Vaddr)
+		rr.Xadd = add + ldr.SymValue(rs) - int64(ldr.SymSect(rs).Vaddr)
 
 	// r.Sym() can be 0 when CALL $(constant) is transformed from absolute PC to relative PC call.
 	case objabi.R_GOTPCREL, objabi.R_CALL, objabi.R_PCREL:
@@ -2400,8 +2416,7 @@ func (ctxt *Link) textaddress() {
 
 	ldr := ctxt.loader
 
-	if *flagRandLayout != 0 {
-		r := rand.New(rand.NewSource(*flagRandLayout))
+	if textReordered() {
 		textp := ctxt.Textp
 		i := 0
 		// don't move the buildid symbol
@@ -2415,9 +2430,14 @@ func (ctxt *Link) textaddress() {
 			i++
 		}
 		textp = textp[i:]
-		r.Shuffle(len(textp), func(i, j int) {
-			textp[i], textp[j] = textp[j], textp[i]
-		})
+		if *flagRandLayout != 0 {
+			r := rand.New(rand.NewSource(*flagRandLayout))
+			r.Shuffle(len(textp), func(i, j int) {
+				textp[i], textp[j] = textp[j], textp[i]
+			})
+		} else {
+			ctxt.pgoLayout(textp)
+		}
 	}
 
 	text := ctxt.xdefine("runtime.text", sym.STEXT, 0)
@@ -2547,6 +2567,8 @@ func (ctxt *Link) textaddress() {
 		}
 	}
 
+	ctxt.setFoldedTextAddrs()
+
 	// Add MinLC size after etext, so it won't collide with the next symbol
 	// (which may confuse some symbolizer).
 	sect.Length = va - sect.Vaddr + uint64(ctxt.Arch.MinLC)
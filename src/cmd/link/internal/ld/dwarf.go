@@ -169,6 +169,26 @@ func isDwarf64(ctxt *Link) bool {
 	return ctxt.HeadType == objabi.Haix
 }
 
+// isDwarf5 reports whether the linker generates DWARF 5, rather than
+// DWARF 4, debug info.
+func isDwarf5() bool {
+	return *flagDwarfVersion == 5
+}
+
+// dwarf5Supported reports whether the linker can generate DWARF 5 for
+// the target. It can't on AIX, whose debug info format is based on
+// DWARF 4 with 64-bit section offsets, or on Darwin, where the compiler
+// avoids the base address selection entries that the linker turns
+// into DWARF 5 range and location lists, for the benefit of dsymutil
+// and older versions of LLDB.
+func dwarf5Supported(ctxt *Link) bool {
+	switch ctxt.HeadType {
+	case objabi.Haix, objabi.Hdarwin:
+		return false
+	}
+	return true
+}
+
 // https://sourceware.org/gdb/onlinedocs/gdb/dotdebug_005fgdb_005fscripts-section.html
 // Each entry inside .debug_gdb_scripts section begins with a non-null prefix
 // byte that specifies the kind of entry. The following entries are supported:
@@ -218,7 +238,11 @@ var dwarfp []dwarfSecInfo
 func (d *dwctxt) writeabbrev() dwarfSecInfo {
 	abrvs := d.ldr.CreateSymForUpdate(".debug_abbrev", 0)
 	abrvs.SetType(sym.SDWARFSECT)
-	abrvs.AddBytes(dwarf.GetAbbrev())
+	if isDwarf5() {
+		abrvs.AddBytes(dwarf.GetAbbrev5(d.arch.PtrSize))
+	} else {
+		abrvs.AddBytes(dwarf.GetAbbrev())
+	}
 	return dwarfSecInfo{syms: []loader.Sym{abrvs.Sym()}}
 }
 
@@ -1014,6 +1038,17 @@ func (d *dwctxt) createUnitLength(su *loader.SymbolBuilder, v uint64) {
 	d.addDwarfAddrField(su, v)
 }
 
+// setUnitLength sets the initial length field created by
+// createUnitLength at the start of su, given the size of the unit
+// including that field.
+func (d *dwctxt) setUnitLength(su *loader.SymbolBuilder, size int64) {
+	if isDwarf64(d.linkctxt) {
+		su.SetUint(d.arch, 4, uint64(size-12)) // 4 because of 0xFFFFFFFF
+	} else {
+		su.SetUint32(d.arch, 0, uint32(size-4))
+	}
+}
+
 // addDwarfAddrField adds a DWARF field in DWARF 64bits or 32bits.
 func (d *dwctxt) addDwarfAddrField(sb *loader.SymbolBuilder, v uint64) {
 	if isDwarf64(d.linkctxt) {
@@ -1138,6 +1173,12 @@ func expandFile(fname string) string {
 	return expandGoroot(fname)
 }
 
+// A fileDir is an entry in the DWARF line table's file name table.
+type fileDir struct {
+	base string // file name
+	dir  int    // index in the include directory table
+}
+
 // writeDirFileTables emits the portion of the DWARF line table
 // prologue containing the include directories and file names,
 // described in section 6.2.4 of the DWARF 4 standard. It walks the
@@ -1145,10 +1186,6 @@ func expandFile(fname string) string {
 // are emitted to the directory table first, then the file table is
 // emitted after that.
 func (d *dwctxt) writeDirFileTables(unit *sym.CompilationUnit, lsu *loader.SymbolBuilder) {
-	type fileDir struct {
-		base string
-		dir  int
-	}
 	dirNums := make(map[string]int)
 	dirs := []string{""}
 	files := []fileDir{}
@@ -1188,9 +1225,14 @@ func (d *dwctxt) writeDirFileTables(unit *sym.CompilationUnit, lsu *loader.Symbo
 		}
 	}
 
+	lsDwsym := dwSym(lsu.Sym())
+	if isDwarf5() {
+		d.writeDirFileTables5(lsu, dirs, files)
+		return
+	}
+
 	// Emit directory section. This is a series of nul terminated
 	// strings, followed by a single zero byte.
-	lsDwsym := dwSym(lsu.Sym())
 	for k := 1; k < len(dirs); k++ {
 		d.AddString(lsDwsym, dirs[k])
 	}
@@ -1206,6 +1248,41 @@ func (d *dwctxt) writeDirFileTables(unit *sym.CompilationUnit, lsu *loader.Symbo
 	lsu.AddUint8(0) // terminator
 }
 
+// writeDirFileTables5 emits the directory and file name tables in the
+// DWARF 5 format, described in section 6.2.4 of the DWARF 5 standard,
+// in which each table is preceded by a description of its entries.
+func (d *dwctxt) writeDirFileTables5(lsu *loader.SymbolBuilder, dirs []string, files []fileDir) {
+	lsDwsym := dwSym(lsu.Sym())
+
+	// Emit directory section. Directory 0 is the compilation
+	// directory, which is left implicit in earlier versions.
+	lsu.AddUint8(1) // directory_entry_format_count
+	dwarf.Uleb128put(d, lsDwsym, dwarf.DW_LNCT_path)
+	dwarf.Uleb128put(d, lsDwsym, dwarf.DW_FORM_string)
+	dwarf.Uleb128put(d, lsDwsym, int64(len(dirs))) // directories_count
+	d.AddString(lsDwsym, getCompilationDir())
+	for k := 1; k < len(dirs); k++ {
+		d.AddString(lsDwsym, dirs[k])
+	}
+
+	// Emit file section. File 0 is the primary source file, but
+	// the line programs emitted by the compiler number files from
+	// 1, as in earlier versions, so repeat the first file as file 0.
+	if len(files) > 0 {
+		files = append(files[:1:1], files...)
+	}
+	lsu.AddUint8(2) // file_name_entry_format_count
+	dwarf.Uleb128put(d, lsDwsym, dwarf.DW_LNCT_path)
+	dwarf.Uleb128put(d, lsDwsym, dwarf.DW_FORM_string)
+	dwarf.Uleb128put(d, lsDwsym, dwarf.DW_LNCT_directory_index)
+	dwarf.Uleb128put(d, lsDwsym, dwarf.DW_FORM_udata)
+	dwarf.Uleb128put(d, lsDwsym, int64(len(files))) // file_names_count
+	for _, f := range files {
+		d.AddString(lsDwsym, f.base)
+		dwarf.Uleb128put(d, lsDwsym, int64(f.dir))
+	}
+}
+
 // writelines collects up and chains together the symbols needed to
 // form the DWARF line table for the specified compilation unit,
 // returning a list of symbols. The returned list will include an
@@ -1232,13 +1309,21 @@ func (d *dwctxt) writelines(unit *sym.CompilationUnit, lineProlog loader.Sym) []
 	unitLengthOffset := lsu.Size()
 	d.createUnitLength(lsu, 0) // unit_length (*), filled in at end
 	unitstart = lsu.Size()
-	lsu.AddUint16(d.arch, 2) // dwarf version (appendix F) -- version 3 is incompatible w/ XCode 9.0's dsymutil, latest supported on OSX 10.12 as of 2018-05
+	if isDwarf5() {
+		lsu.AddUint16(d.arch, 5)            // dwarf version (appendix F)
+		lsu.AddUint8(uint8(d.arch.PtrSize)) // address_size
+		lsu.AddUint8(0)                     // segment_selector_size
+	} else {
+		lsu.AddUint16(d.arch, 2) // dwarf version (appendix F) -- version 3 is incompatible w/ XCode 9.0's dsymutil, latest supported on OSX 10.12 as of 2018-05
+	}
 	headerLengthOffset := lsu.Size()
 	d.addDwarfAddrField(lsu, 0) // header_length (*), filled in at end
 	headerstart = lsu.Size()
 
-	// cpos == unitstart + 4 + 2 + 4
-	lsu.AddUint8(1)                // minimum_instruction_length
+	lsu.AddUint8(1) // minimum_instruction_length
+	if isDwarf5() {
+		lsu.AddUint8(1) // maximum_operations_per_instruction
+	}
 	lsu.AddUint8(is_stmt)          // default_is_stmt
 	lsu.AddUint8(LINE_BASE & 0xFF) // line_base
 	lsu.AddUint8(LINE_RANGE)       // line_range
@@ -1318,6 +1403,277 @@ func (d *dwctxt) writepcranges(unit *sym.CompilationUnit, base loader.Sym, pcs [
 	return syms
 }
 
+// dwarfAddrTable holds the parts of a DWARF 5 binary that the linker
+// needs when it applies relocations: the addresses in .debug_addr, to
+// which DIEs and range and location lists refer by index, and the new
+// offsets of the range and location lists that it rewrote in the
+// DWARF 5 format.
+type dwarfAddrTable struct {
+	index map[dwarfAddr]uint64           // index of each address in its unit's table
+	units map[loader.Sym]loader.Sym      // unit table of each DIE symbol with addresses
+	lists map[loader.Sym]map[int64]int64 // new offsets of lists, by old offset
+}
+
+// dwarfAddr is the address of a symbol plus an addend in the
+// contribution of a compilation unit to .debug_addr.
+type dwarfAddr struct {
+	unit loader.Sym
+	sym  loader.Sym
+	add  int64
+}
+
+// dwarfAddrs holds the .debug_addr indices, or is nil if the linker
+// does not generate DWARF 5.
+var dwarfAddrs *dwarfAddrTable
+
+// writeAddrHeader writes the header of a compilation unit's
+// contribution to .debug_addr (sec 7.27 of the DWARF 5 standard). The
+// unit length is filled in later by setUnitLength.
+func (d *dwctxt) writeAddrHeader(su *loader.SymbolBuilder) {
+	// Fields marked with (*) must be changed for 64-bit dwarf
+	d.createUnitLength(su, 0)          // unit_length (*), filled in at end
+	su.AddUint16(d.arch, 5)            // dwarf version
+	su.AddUint8(uint8(d.arch.PtrSize)) // address_size
+	su.AddUint8(0)                     // segment_selector_size
+}
+
+// addrIndex returns the index of the address of s plus add in unit,
+// a compilation unit's contribution to .debug_addr, adding it to the
+// contribution if needed.
+func (d *dwctxt) addrIndex(unit, s loader.Sym, add int64) uint64 {
+	a := dwarfAddr{unit, s, add}
+	i, ok := dwarfAddrs.index[a]
+	if !ok {
+		su := d.ldr.MakeSymbolUpdater(unit)
+		i = uint64(su.Size()-d.addrHeaderSize()) / uint64(d.arch.PtrSize)
+		dwarfAddrs.index[a] = i
+		su.AddAddrPlus(d.arch, s, add)
+	}
+	return i
+}
+
+// addrHeaderSize returns the size of the header written by
+// writeAddrHeader, which is the value of DW_AT_addr_base for the
+// compilation unit, relative to the unit's contribution.
+func (d *dwctxt) addrHeaderSize() int64 {
+	if isDwarf64(d.linkctxt) {
+		return 16
+	}
+	return 8
+}
+
+// isDwarfDIE reports whether symbols of kind k hold DIEs.
+func isDwarfDIE(k sym.SymKind) bool {
+	return k >= sym.SDWARFCUINFO && k <= sym.SDWARFVAR
+}
+
+// isDwarfHighPC reports whether the ri'th relocation in relocs, an
+// R_ADDR relocation in a DIE, is for a DW_AT_high_pc attribute. In the
+// DIEs that the compiler and linker generate, DW_AT_high_pc directly
+// follows the DW_AT_low_pc for the same function, and no other address
+// directly follows another.
+func isDwarfHighPC(relocs *loader.Relocs, ri int) bool {
+	if ri == 0 {
+		return false
+	}
+	r, prev := relocs.At(ri), relocs.At(ri-1)
+	return prev.Type() == objabi.R_ADDR && prev.Sym() == r.Sym() && prev.Off()+int32(prev.Siz()) == r.Off()
+}
+
+// collectDIEAddrs adds the addresses that the DIEs in syms refer to by
+// index to unit, a compilation unit's contribution to .debug_addr.
+// See relocDWARFAddr.
+func (d *dwctxt) collectDIEAddrs(unit loader.Sym, syms []loader.Sym) {
+	for _, s := range syms {
+		relocs := d.ldr.Relocs(s)
+		for ri := 0; ri < relocs.Count(); ri++ {
+			r := relocs.At(ri)
+			if r.Type() == objabi.R_ADDR && !isDwarfHighPC(&relocs, ri) {
+				d.addrIndex(unit, r.Sym(), r.Add())
+				dwarfAddrs.units[s] = unit
+			}
+		}
+	}
+}
+
+// relocDWARFAddr applies the ri'th relocation in relocs, an R_ADDR
+// relocation in DIE symbol s, in a DWARF 5 binary. A DW_AT_high_pc
+// becomes an offset from the DW_AT_low_pc before it, and any other
+// address becomes its index in .debug_addr, encoded as a ULEB128 that
+// fills the relocated field. See also GetAbbrev5 in cmd/internal/dwarf.
+func (st *relocSymState) relocDWARFAddr(s loader.Sym, P []byte, relocs *loader.Relocs, ri int) {
+	r := relocs.At(ri)
+	b := P[r.Off() : r.Off()+int32(r.Siz())]
+	if isDwarfHighPC(relocs, ri) {
+		o := r.Add() - relocs.At(ri-1).Add()
+		switch len(b) {
+		case 4:
+			st.target.Arch.ByteOrder.PutUint32(b, uint32(o))
+		case 8:
+			st.target.Arch.ByteOrder.PutUint64(b, uint64(o))
+		default:
+			st.err.Errorf(s, "bad DW_AT_high_pc size %d", len(b))
+		}
+		return
+	}
+	i, ok := dwarfAddrs.index[dwarfAddr{dwarfAddrs.units[s], r.Sym(), r.Add()}]
+	if !ok {
+		st.err.Errorf(s, "missing .debug_addr entry for %s", st.ldr.SymName(r.Sym()))
+		return
+	}
+	if st.ldr.SymType(s) == sym.SDWARFVAR {
+		// The address of a variable is the operand of a DW_OP_addr.
+		if r.Off() == 0 || P[r.Off()-1] != dwarf.DW_OP_addr {
+			st.err.Errorf(s, "unexpected address of %s in variable DIE", st.ldr.SymName(r.Sym()))
+			return
+		}
+		P[r.Off()-1] = dwarf.DW_OP_addrx
+	}
+	for j := range b {
+		b[j] = byte(i & 0x7f)
+		i >>= 7
+		if j < len(b)-1 {
+			b[j] |= 0x80
+		}
+	}
+	if i != 0 {
+		st.err.Errorf(s, "too many .debug_addr entries")
+	}
+}
+
+// dwarfListOffset returns the offset in s of the range or location
+// list that was at offset off before the linker rewrote s in the
+// DWARF 5 format, and whether there is such a list. Offsets in other
+// symbols are unchanged.
+func dwarfListOffset(s loader.Sym, off int64) (int64, bool) {
+	if dwarfAddrs == nil {
+		return off, true
+	}
+	lists, ok := dwarfAddrs.lists[s]
+	if !ok {
+		return off, true
+	}
+	off, ok = lists[off]
+	return off, ok
+}
+
+// writeListsUnit rewrites the range or location lists in syms, which
+// make up the contribution of compilation unit u to .debug_ranges or
+// .debug_loc, in the DWARF 5 .debug_rnglists or .debug_loclists
+// format, and prepends the header of the unit's contribution (sec 7.28
+// and 7.29 of the DWARF 5 standard) to the first symbol. The lists
+// refer to addresses in addrs, the unit's contribution to .debug_addr.
+//
+// The compiler writes the lists of each function in the DWARF 4 format,
+// beginning with a base address selection entry, which becomes a
+// DW_RLE_base_addressx or DW_LLE_base_addressx entry here. Since this
+// adds to .debug_addr, it must run serially.
+func (d *dwctxt) writeListsUnit(u *sym.CompilationUnit, addrs loader.Sym, syms []loader.Sym, loc bool) {
+	var size int64
+	for i, s := range syms {
+		d.writeLists(u, addrs, s, i == 0, loc)
+		size += d.ldr.SymSize(s)
+	}
+	d.setUnitLength(d.ldr.MakeSymbolUpdater(syms[0]), size)
+}
+
+// writeLists rewrites the lists in s for writeListsUnit, starting with
+// the header of the unit's contribution if header is set.
+func (d *dwctxt) writeLists(u *sym.CompilationUnit, addrs, s loader.Sym, header, loc bool) {
+	var endOfList, baseAddressx, offsetPair byte = dwarf.DW_RLE_end_of_list, dwarf.DW_RLE_base_addressx, dwarf.DW_RLE_offset_pair
+	if loc {
+		endOfList, baseAddressx, offsetPair = dwarf.DW_LLE_end_of_list, dwarf.DW_LLE_base_addressx, dwarf.DW_LLE_offset_pair
+	}
+
+	ldr, arch := d.ldr, d.arch
+	ps := arch.PtrSize
+	data := ldr.Data(s)
+	relocs := ldr.Relocs(s)
+	ri := 0
+	relocAt := func(off int) (loader.Reloc, bool) {
+		if ri < relocs.Count() {
+			if r := relocs.At(ri); int(r.Off()) == off {
+				ri++
+				return r, true
+			}
+		}
+		return loader.Reloc{}, false
+	}
+	readAddr := func(off int) uint64 {
+		if ps == 4 {
+			return uint64(arch.ByteOrder.Uint32(data[off:]))
+		}
+		return arch.ByteOrder.Uint64(data[off:])
+	}
+	cuOffset := func(r loader.Reloc) uint64 {
+		// See the R_ADDRCUOFF case in relocsym.
+		return uint64(ldr.SymValue(r.Sym()) + r.Add() - ldr.SymValue(loader.Sym(u.Textp[0])))
+	}
+	baseAddr := uint64(1)<<(8*ps) - 1
+
+	var out []byte
+	offsets := make(map[int64]int64)
+	for pos := 0; pos < len(data); {
+		offsets[int64(pos)] = int64(len(out))
+		for done := false; !done; {
+			if pos+2*ps > len(data) {
+				d.linkctxt.Errorf(s, "truncated DWARF list at offset %d", pos)
+				return
+			}
+			begin, end := readAddr(pos), readAddr(pos+ps)
+			rb, hasRB := relocAt(pos)
+			re, hasRE := relocAt(pos + ps)
+			pos += 2 * ps
+			switch {
+			case !hasRB && begin == baseAddr && hasRE && re.Type() == objabi.R_ADDR:
+				out = append(out, baseAddressx)
+				out = dwarf.AppendUleb128(out, d.addrIndex(addrs, re.Sym(), re.Add()))
+				continue
+			case hasRB && hasRE && rb.Type() == objabi.R_ADDRCUOFF && re.Type() == objabi.R_ADDRCUOFF:
+				begin, end = cuOffset(rb), cuOffset(re)
+			case hasRB || hasRE:
+				d.linkctxt.Errorf(s, "unexpected relocation in DWARF list at offset %d", pos-2*ps)
+				return
+			case begin == 0 && end == 0:
+				out = append(out, endOfList)
+				done = true
+				continue
+			}
+			out = append(out, offsetPair)
+			out = dwarf.AppendUleb128(out, begin)
+			out = dwarf.AppendUleb128(out, end)
+			if loc {
+				n := int(arch.ByteOrder.Uint16(data[pos:]))
+				pos += 2
+				out = dwarf.AppendUleb128(out, uint64(n))
+				out = append(out, data[pos:pos+n]...)
+				pos += n
+			}
+		}
+	}
+	if ri != relocs.Count() {
+		d.linkctxt.Errorf(s, "unexpected relocation in DWARF list")
+	}
+
+	su := ldr.MakeSymbolUpdater(s)
+	su.ResetRelocs()
+	su.SetData(nil)
+	su.SetSize(0)
+	if header {
+		// Fields marked with (*) must be changed for 64-bit dwarf
+		d.createUnitLength(su, 0)          // unit_length (*), filled in by writeListsUnit
+		su.AddUint16(d.arch, 5)            // dwarf version
+		su.AddUint8(uint8(d.arch.PtrSize)) // address_size
+		su.AddUint8(0)                     // segment_selector_size
+		su.AddUint32(d.arch, 0)            // offset_entry_count
+	}
+	for old, off := range offsets {
+		offsets[old] = off + su.Size()
+	}
+	su.AddBytes(out)
+	dwarfAddrs.lists[s] = offsets
+}
+
 /*
  *  Emit .debug_frame
  */
@@ -1504,7 +1860,7 @@ const (
 	COMPUNITHEADERSIZE = 4 + 2 + 4 + 1
 )
 
-func (d *dwctxt) writeUnitInfo(u *sym.CompilationUnit, abbrevsym loader.Sym, infoEpilog loader.Sym) []loader.Sym {
+func (d *dwctxt) writeUnitInfo(u *sym.CompilationUnit, abbrevsym loader.Sym, addrProlog loader.Sym, infoEpilog loader.Sym) []loader.Sym {
 	syms := []loader.Sym{}
 	if len(u.Textp) == 0 && u.DWInfo.Child == nil && len(u.VarDIEs) == 0 {
 		return syms
@@ -1518,15 +1874,29 @@ func (d *dwctxt) writeUnitInfo(u *sym.CompilationUnit, abbrevsym loader.Sym, inf
 	// Fields marked with (*) must be changed for 64-bit dwarf
 	// This must match COMPUNITHEADERSIZE above.
 	d.createUnitLength(su, 0) // unit_length (*), will be filled in later.
-	su.AddUint16(d.arch, 4)   // dwarf version (appendix F)
+	if isDwarf5() {
+		su.AddUint16(d.arch, 5)            // dwarf version (appendix F)
+		su.AddUint8(dwarf.DW_UT_compile)   // unit_type
+		su.AddUint8(uint8(d.arch.PtrSize)) // address_size
 
-	// debug_abbrev_offset (*)
-	d.addDwarfAddrRef(su, abbrevsym)
+		// debug_abbrev_offset (*)
+		d.addDwarfAddrRef(su, abbrevsym)
+	} else {
+		su.AddUint16(d.arch, 4) // dwarf version (appendix F)
 
-	su.AddUint8(uint8(d.arch.PtrSize)) // address_size
+		// debug_abbrev_offset (*)
+		d.addDwarfAddrRef(su, abbrevsym)
+
+		su.AddUint8(uint8(d.arch.PtrSize)) // address_size
+	}
 
 	ds := dwSym(s)
 	dwarf.Uleb128put(d, ds, int64(compunit.Abbrev))
+	if isDwarf5() {
+		// DW_AT_addr_base, which GetAbbrev5 adds to the start of
+		// compilation unit abbrevs.
+		d.AddDWARFAddrSectionOffset(ds, dwSym(addrProlog), d.addrHeaderSize())
+	}
 	dwarf.PutAttrs(d, ds, compunit.Abbrev, compunit.Attr)
 
 	// This is an under-estimate; more will be needed for type DIEs.
@@ -1953,6 +2323,8 @@ type dwUnitSyms struct {
 	// Inputs for a given unit.
 	lineProlog  loader.Sym
 	rangeProlog loader.Sym
+	locProlog   loader.Sym
+	addrProlog  loader.Sym
 	infoEpilog  loader.Sym
 
 	// Outputs for a given unit.
@@ -1974,7 +2346,7 @@ func (d *dwctxt) dwUnitPortion(u *sym.CompilationUnit, abbrevsym loader.Sym, us
 		us.rangessyms = d.writepcranges(u, base, u.PCs, us.rangeProlog)
 		us.locsyms = d.collectUnitLocs(u)
 	}
-	us.infosyms = d.writeUnitInfo(u, abbrevsym, us.infoEpilog)
+	us.infosyms = d.writeUnitInfo(u, abbrevsym, us.addrProlog, us.infoEpilog)
 }
 
 func (d *dwctxt) dwarfGenerateDebugSyms() {
@@ -2006,10 +2378,14 @@ func (d *dwctxt) dwarfGenerateDebugSyms() {
 	}
 
 	// Create the section symbols.
+	locName, rangesName := ".debug_loc", ".debug_ranges"
+	if isDwarf5() {
+		locName, rangesName = ".debug_loclists", ".debug_rnglists"
+	}
 	frameSym := mkSecSym(".debug_frame")
-	locSym := mkSecSym(".debug_loc")
+	locSym := mkSecSym(locName)
 	lineSym := mkSecSym(".debug_line")
-	rangesSym := mkSecSym(".debug_ranges")
+	rangesSym := mkSecSym(rangesName)
 	infoSym := mkSecSym(".debug_info")
 
 	// Create the section objects
@@ -2018,6 +2394,15 @@ func (d *dwctxt) dwarfGenerateDebugSyms() {
 	rangesSec := dwarfSecInfo{syms: []loader.Sym{rangesSym}}
 	frameSec := dwarfSecInfo{syms: []loader.Sym{frameSym}}
 	infoSec := dwarfSecInfo{syms: []loader.Sym{infoSym}}
+	var addrSec dwarfSecInfo
+	if isDwarf5() {
+		addrSec.syms = []loader.Sym{mkSecSym(".debug_addr")}
+		dwarfAddrs = &dwarfAddrTable{
+			index: make(map[dwarfAddr]uint64),
+			units: make(map[loader.Sym]loader.Sym),
+			lists: make(map[loader.Sym]map[int64]int64),
+		}
+	}
 
 	// Create any new symbols that will be needed during the
 	// parallel portion below.
@@ -2027,6 +2412,11 @@ func (d *dwctxt) dwarfGenerateDebugSyms() {
 		us := &unitSyms[i]
 		us.lineProlog = mkAnonSym(sym.SDWARFLINES)
 		us.rangeProlog = mkAnonSym(sym.SDWARFRANGE)
+		if isDwarf5() {
+			us.locProlog = mkAnonSym(sym.SDWARFLOC)
+			us.addrProlog = mkAnonSym(sym.SDWARFSECT)
+			d.writeAddrHeader(d.ldr.MakeSymbolUpdater(us.addrProlog))
+		}
 		us.infoEpilog = mkAnonSym(sym.SDWARFFCN)
 	}
 
@@ -2061,6 +2451,24 @@ func (d *dwctxt) dwarfGenerateDebugSyms() {
 	}
 	wg.Wait()
 
+	// DWARF 5 refers to addresses by their index in .debug_addr,
+	// which is shared by all units, so finish it serially.
+	if isDwarf5() {
+		for i, u := range d.linkctxt.compUnits {
+			us := &unitSyms[i]
+			d.collectDIEAddrs(us.addrProlog, us.infosyms)
+			if len(us.rangessyms) > 0 {
+				d.writeListsUnit(u, us.addrProlog, us.rangessyms, false)
+			}
+			if len(us.locsyms) > 0 {
+				us.locsyms = append([]loader.Sym{us.locProlog}, us.locsyms...)
+				d.writeListsUnit(u, us.addrProlog, us.locsyms, true)
+			}
+			su := d.ldr.MakeSymbolUpdater(us.addrProlog)
+			d.setUnitLength(su, su.Size())
+		}
+	}
+
 	markReachable := func(syms []loader.Sym) []loader.Sym {
 		for _, s := range syms {
 			d.ldr.SetAttrNotInSymbolTable(s, true)
@@ -2076,6 +2484,9 @@ func (d *dwctxt) dwarfGenerateDebugSyms() {
 		infoSec.syms = append(infoSec.syms, markReachable(r.infosyms)...)
 		locSec.syms = append(locSec.syms, markReachable(r.locsyms)...)
 		rangesSec.syms = append(rangesSec.syms, markReachable(r.rangessyms)...)
+		if r.addrProlog != 0 && len(r.infosyms) > 0 {
+			addrSec.syms = append(addrSec.syms, markReachable([]loader.Sym{r.addrProlog})...)
+		}
 	}
 	dwarfp = append(dwarfp, lineSec)
 	dwarfp = append(dwarfp, frameSec)
@@ -2088,6 +2499,9 @@ func (d *dwctxt) dwarfGenerateDebugSyms() {
 		dwarfp = append(dwarfp, locSec)
 	}
 	dwarfp = append(dwarfp, rangesSec)
+	if addrSec.secSym() != 0 {
+		dwarfp = append(dwarfp, addrSec)
+	}
 
 	// Check to make sure we haven't listed any symbols more than once
 	// in the info section. This used to be done by setting and
@@ -2130,6 +2544,9 @@ func dwarfaddshstrings(ctxt *Link, add func(string)) {
 	}
 
 	secs := []string{"abbrev", "frame", "info", "loc", "line", "gdb_scripts", "ranges"}
+	if isDwarf5() {
+		secs = []string{"abbrev", "frame", "info", "loclists", "line", "gdb_scripts", "rnglists", "addr"}
+	}
 	for _, sec := range secs {
 		add(".debug_" + sec)
 		if ctxt.IsExternal() {
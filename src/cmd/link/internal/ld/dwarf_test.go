@@ -6,6 +6,7 @@ package ld
 
 import (
 	"debug/dwarf"
+	"debug/elf"
 	"debug/pe"
 	"fmt"
 	"internal/platform"
@@ -1466,7 +1467,14 @@ func TestIssue39757(t *testing.T) {
 
 	// Collect the start/end PC for main.main
 	lowpc := maindie.Val(dwarf.AttrLowpc).(uint64)
-	highpc := maindie.Val(dwarf.AttrHighpc).(uint64)
+	var highpc uint64
+	switch v := maindie.Val(dwarf.AttrHighpc).(type) {
+	case uint64:
+		highpc = v
+	case int64:
+		// DWARF 5 encodes the high PC as an offset from the low PC.
+		highpc = lowpc + uint64(v)
+	}
 
 	// Now read the line table for the 'main' compilation unit.
 	mainIdx := ex.IdxFromOffset(maindie.Offset)
@@ -2075,3 +2083,125 @@ func TestConsistentGoKindAndRuntimeType(t *testing.T) {
 		t.Logf("%d types checked\n", typesChecked)
 	}
 }
+
+func TestDWARF5(t *testing.T) {
+	// Check that -dwarfversion=5 produces DWARF 5 units and
+	// -dwarfversion=4 produces DWARF 4 ones, and that the
+	// line tables and ranges read from both are the same.
+	testenv.MustHaveGoBuild(t)
+	mustHaveDWARF(t)
+	switch runtime.GOOS {
+	case "aix", "darwin", "ios", "plan9", "windows":
+		t.Skipf("skipping on %s: DWARF 5 is not supported, or executables are not ELF", runtime.GOOS)
+	}
+	t.Parallel()
+
+	const prog = `
+package main
+
+import "fmt"
+
+func sum(x, y int) int {
+	s := 0
+	for i := x; i < y; i++ {
+		s += i * x
+	}
+	return s
+}
+
+func main() {
+	fmt.Println(sum(1, 10))
+}
+`
+	dir := t.TempDir()
+	src := filepath.Join(dir, "test.go")
+	if err := os.WriteFile(src, []byte(prog), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	type result struct {
+		ranges map[string][][2]uint64 // CU and function ranges, by name
+		lines  []string
+	}
+	build := func(exp string, wantVers uint16, wantSects []string) result {
+		dst := filepath.Join(dir, exp+".exe")
+		cmd := testenv.Command(t, testenv.GoToolPath(t), "build", "-ldflags=-"+exp, "-o", dst, src)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("%v: %v\n%s", cmd, err, out)
+		}
+		f, err := elf.Open(dst)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		for _, name := range wantSects {
+			if f.Section(name) == nil {
+				t.Errorf("%s: missing section %s", exp, name)
+			}
+		}
+		info, err := f.Section(".debug_info").Data()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if vers := f.ByteOrder.Uint16(info[4:]); vers != wantVers {
+			t.Errorf("%s: first unit has DWARF version %d, want %d", exp, vers, wantVers)
+		}
+
+		d, err := f.DWARF()
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := result{ranges: make(map[string][][2]uint64)}
+		rdr := d.Reader()
+		for {
+			e, err := rdr.Next()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if e == nil {
+				break
+			}
+			name, _ := e.Val(dwarf.AttrName).(string)
+			switch e.Tag {
+			case dwarf.TagCompileUnit:
+				if _, ok := e.Val(dwarf.AttrAddrBase).(int64); ok != (wantVers == 5) {
+					t.Errorf("%s: unit %s has DW_AT_addr_base = %v, want %v", exp, name, ok, wantVers == 5)
+				}
+				if name != "main" {
+					rdr.SkipChildren()
+					break
+				}
+				lr, err := d.LineReader(e)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var le dwarf.LineEntry
+				for lr.Next(&le) == nil {
+					r.lines = append(r.lines, fmt.Sprintf("%#x %s:%d", le.Address, filepath.Base(le.File.Name), le.Line))
+				}
+			case dwarf.TagSubprogram, dwarf.TagInlinedSubroutine, dwarf.TagLexDwarfBlock:
+			default:
+				continue
+			}
+			rngs, err := d.Ranges(e)
+			if err != nil {
+				t.Fatalf("%s: reading ranges of %s: %v", exp, name, err)
+			}
+			key := fmt.Sprintf("%v %s %d", e.Tag, name, len(r.ranges))
+			r.ranges[key] = rngs
+		}
+		if len(r.lines) == 0 {
+			t.Fatalf("%s: no line table entries for package main", exp)
+		}
+		return r
+	}
+
+	v5 := build("dwarfversion=5", 5, []string{".debug_addr", ".debug_rnglists", ".debug_loclists"})
+	v4 := build("dwarfversion=4", 4, []string{".debug_ranges", ".debug_loc"})
+	if !reflect.DeepEqual(v5.lines, v4.lines) {
+		t.Errorf("line tables differ:\nDWARF 5: %v\nDWARF 4: %v", v5.lines, v4.lines)
+	}
+	if !reflect.DeepEqual(v5.ranges, v4.ranges) {
+		t.Errorf("ranges differ:\nDWARF 5: %v\nDWARF 4: %v", v5.ranges, v4.ranges)
+	}
+}
@@ -0,0 +1,415 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ld
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// An elfImage is an ELF file being split into an executable and a
+// separate debug information file. Headers of either class are held
+// in their 64-bit form.
+type elfImage struct {
+	data   []byte
+	order  binary.ByteOrder
+	is64   bool
+	hdr    elf.Header64
+	shdrs  []elf.Section64
+	names  []string
+	maxEnd uint64 // end of the file contents loaded by the program headers
+}
+
+func readElfImage(data []byte) (*elfImage, error) {
+	if len(data) < elf.EI_NIDENT || string(data[:4]) != elf.ELFMAG {
+		return nil, fmt.Errorf("not an ELF file")
+	}
+	img := &elfImage{data: data}
+	switch elf.Data(data[elf.EI_DATA]) {
+	case elf.ELFDATA2LSB:
+		img.order = binary.LittleEndian
+	case elf.ELFDATA2MSB:
+		img.order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("unknown ELF data encoding %d", data[elf.EI_DATA])
+	}
+	img.is64 = elf.Class(data[elf.EI_CLASS]) == elf.ELFCLASS64
+
+	r := bytes.NewReader(data)
+	if img.is64 {
+		if err := binary.Read(r, img.order, &img.hdr); err != nil {
+			return nil, err
+		}
+	} else {
+		var h elf.Header32
+		if err := binary.Read(r, img.order, &h); err != nil {
+			return nil, err
+		}
+		img.hdr = elf.Header64{
+			Ident: h.Ident, Type: h.Type, Machine: h.Machine, Version: h.Version,
+			Entry: uint64(h.Entry), Phoff: uint64(h.Phoff), Shoff: uint64(h.Shoff),
+			Flags: h.Flags, Ehsize: h.Ehsize, Phentsize: h.Phentsize, Phnum: h.Phnum,
+			Shentsize: h.Shentsize, Shnum: h.Shnum, Shstrndx: h.Shstrndx,
+		}
+	}
+	if img.hdr.Shnum == 0 || img.hdr.Shnum >= uint16(elf.SHN_LORESERVE) || img.hdr.Shstrndx >= img.hdr.Shnum {
+		return nil, fmt.Errorf("unsupported section header table")
+	}
+
+	for i := 0; i < int(img.hdr.Phnum); i++ {
+		off := int64(img.hdr.Phoff) + int64(i)*int64(img.hdr.Phentsize)
+		if off < 0 || off >= int64(len(data)) {
+			return nil, fmt.Errorf("program header %d out of range", i)
+		}
+		r := bytes.NewReader(data[off:])
+		var p elf.Prog64
+		if img.is64 {
+			if err := binary.Read(r, img.order, &p); err != nil {
+				return nil, err
+			}
+		} else {
+			var p32 elf.Prog32
+			if err := binary.Read(r, img.order, &p32); err != nil {
+				return nil, err
+			}
+			p = elf.Prog64{Type: p32.Type, Off: uint64(p32.Off), Filesz: uint64(p32.Filesz)}
+		}
+		if elf.ProgType(p.Type) == elf.PT_LOAD && p.Off+p.Filesz > img.maxEnd {
+			img.maxEnd = p.Off + p.Filesz
+		}
+	}
+
+	for i := 0; i < int(img.hdr.Shnum); i++ {
+		off := int64(img.hdr.Shoff) + int64(i)*int64(img.hdr.Shentsize)
+		if off < 0 || off >= int64(len(data)) {
+			return nil, fmt.Errorf("section header %d out of range", i)
+		}
+		r := bytes.NewReader(data[off:])
+		var s elf.Section64
+		if img.is64 {
+			if err := binary.Read(r, img.order, &s); err != nil {
+				return nil, err
+			}
+		} else {
+			var s32 elf.Section32
+			if err := binary.Read(r, img.order, &s32); err != nil {
+				return nil, err
+			}
+			s = elf.Section64{
+				Name: s32.Name, Type: s32.Type, Flags: uint64(s32.Flags), Addr: uint64(s32.Addr),
+				Off: uint64(s32.Off), Size: uint64(s32.Size), Link: s32.Link, Info: s32.Info,
+				Addralign: uint64(s32.Addralign), Entsize: uint64(s32.Entsize),
+			}
+		}
+		if elf.SectionType(s.Type) == elf.SHT_SYMTAB_SHNDX {
+			return nil, fmt.Errorf("unsupported extended section indexes")
+		}
+		if elf.SectionType(s.Type) != elf.SHT_NOBITS && s.Off+s.Size > uint64(len(data)) {
+			return nil, fmt.Errorf("section %d out of range", i)
+		}
+		img.shdrs = append(img.shdrs, s)
+	}
+
+	strtab := img.sectionData(int(img.hdr.Shstrndx))
+	for _, s := range img.shdrs {
+		var name string
+		if int(s.Name) < len(strtab) {
+			name = string(strtab[s.Name:])
+			name = name[:strings.IndexByte(name, 0)+1]
+			name = strings.TrimSuffix(name, "\x00")
+		}
+		img.names = append(img.names, name)
+	}
+	return img, nil
+}
+
+func (img *elfImage) sectionData(i int) []byte {
+	s := img.shdrs[i]
+	if elf.SectionType(s.Type) == elf.SHT_NOBITS {
+		return nil
+	}
+	return img.data[s.Off : s.Off+s.Size]
+}
+
+// elfWriter builds an ELF file of the same class and byte order as img.
+type elfWriter struct {
+	img *elfImage
+	buf bytes.Buffer
+}
+
+// align pads the output to a multiple of a.
+func (w *elfWriter) align(a uint64) {
+	for a > 1 && uint64(w.buf.Len())%a != 0 {
+		w.buf.WriteByte(0)
+	}
+}
+
+// section appends data with the alignment of s, and sets the offset
+// and size of s to those of the data.
+func (w *elfWriter) section(s *elf.Section64, data []byte) {
+	w.align(s.Addralign)
+	s.Off = uint64(w.buf.Len())
+	s.Size = uint64(len(data))
+	w.buf.Write(data)
+}
+
+// putHeader encodes the file header hdr at the start of b.
+func (w *elfWriter) putHeader(b []byte, hdr *elf.Header64) {
+	var out bytes.Buffer
+	if w.img.is64 {
+		binary.Write(&out, w.img.order, hdr)
+	} else {
+		binary.Write(&out, w.img.order, &elf.Header32{
+			Ident: hdr.Ident, Type: hdr.Type, Machine: hdr.Machine, Version: hdr.Version,
+			Entry: uint32(hdr.Entry), Phoff: uint32(hdr.Phoff), Shoff: uint32(hdr.Shoff),
+			Flags: hdr.Flags, Ehsize: hdr.Ehsize, Phentsize: hdr.Phentsize, Phnum: hdr.Phnum,
+			Shentsize: hdr.Shentsize, Shnum: hdr.Shnum, Shstrndx: hdr.Shstrndx,
+		})
+	}
+	copy(b, out.Bytes())
+}
+
+// sectionHeaders encodes the section headers shdrs.
+func (w *elfWriter) sectionHeaders(shdrs []elf.Section64) []byte {
+	var out bytes.Buffer
+	for i := range shdrs {
+		s := &shdrs[i]
+		if w.img.is64 {
+			binary.Write(&out, w.img.order, s)
+		} else {
+			binary.Write(&out, w.img.order, &elf.Section32{
+				Name: s.Name, Type: s.Type, Flags: uint32(s.Flags), Addr: uint32(s.Addr),
+				Off: uint32(s.Off), Size: uint32(s.Size), Link: s.Link, Info: s.Info,
+				Addralign: uint32(s.Addralign), Entsize: uint32(s.Entsize),
+			})
+		}
+	}
+	return out.Bytes()
+}
+
+func (w *elfWriter) shentsize() uint16 {
+	if w.img.is64 {
+		return uint16(binary.Size(elf.Section64{}))
+	}
+	return uint16(binary.Size(elf.Section32{}))
+}
+
+// splitDebugFile implements the -debugfile flag. It moves the DWARF
+// sections of the ELF output file, and with -s its symbol table, to a
+// separate debug information file, and adds a .gnu_debuglink section
+// naming that file to the output, much like objcopy --only-keep-debug,
+// strip and objcopy --add-gnu-debuglink would. The debug file keeps
+// the section headers of the output, with the contents of sections
+// that are not moved removed, and its notes, which hold the build IDs
+// by which debuggers find it.
+func (ctxt *Link) splitDebugFile() {
+	data, err := os.ReadFile(*flagOutfile)
+	if err != nil {
+		Exitf("%v", err)
+	}
+	img, err := readElfImage(data)
+	if err != nil {
+		Exitf("-debugfile: %s: %v", *flagOutfile, err)
+	}
+
+	// Decide which sections move to the debug file.
+	move := make([]bool, len(img.shdrs))
+	haveDWARF := false
+	for i, name := range img.names {
+		if strings.HasPrefix(name, ".debug_") || strings.HasPrefix(name, ".zdebug_") {
+			move[i] = true
+			haveDWARF = true
+		}
+		if debugStripSymtab && elf.SectionType(img.shdrs[i].Type) == elf.SHT_SYMTAB {
+			move[i] = true
+			if link := img.shdrs[i].Link; link != 0 && int(link) < len(move) {
+				move[link] = true
+			}
+		}
+	}
+	if !haveDWARF {
+		Exitf("-debugfile: %s has no DWARF debug information", *flagOutfile)
+	}
+	for i, s := range img.shdrs {
+		if move[i] && (s.Flags&uint64(elf.SHF_ALLOC) != 0 || s.Off < img.maxEnd) {
+			Exitf("-debugfile: cannot move loaded section %s", img.names[i])
+		}
+	}
+
+	debugData := img.writeDebugFile(move)
+	if err := os.WriteFile(*flagDebugfile, debugData, 0666); err != nil {
+		Exitf("%v", err)
+	}
+	out := img.writeStripped(move, debugLink(filepath.Base(*flagDebugfile), crc32.ChecksumIEEE(debugData), img.order))
+	if err := os.WriteFile(*flagOutfile, out, 0777); err != nil {
+		Exitf("%v", err)
+	}
+}
+
+// debugLink returns the contents of a .gnu_debuglink section naming
+// the debug file name with the given CRC-32 checksum.
+func debugLink(name string, crc uint32, order binary.ByteOrder) []byte {
+	b := append([]byte(name), 0)
+	for len(b)%4 != 0 {
+		b = append(b, 0)
+	}
+	var c [4]byte
+	order.PutUint32(c[:], crc)
+	return append(b, c[:]...)
+}
+
+// writeDebugFile returns the debug file holding the sections of img
+// marked in move, its notes and its section names. The other sections
+// become SHT_NOBITS, so that the debug file has the same section
+// indexes and addresses as the executable.
+func (img *elfImage) writeDebugFile(move []bool) []byte {
+	w := &elfWriter{img: img}
+	hdr := img.hdr
+	w.buf.Write(make([]byte, hdr.Ehsize))
+
+	shdrs := append([]elf.Section64(nil), img.shdrs...)
+	for i := 1; i < len(shdrs); i++ {
+		s := &shdrs[i]
+		switch {
+		case move[i], elf.SectionType(s.Type) == elf.SHT_NOTE, i == int(hdr.Shstrndx):
+			w.section(s, img.sectionData(i))
+		default:
+			s.Type = uint32(elf.SHT_NOBITS)
+			s.Off = uint64(w.buf.Len())
+		}
+	}
+
+	w.align(8)
+	hdr.Phoff, hdr.Phnum = 0, 0
+	hdr.Shoff = uint64(w.buf.Len())
+	hdr.Shentsize = w.shentsize()
+	w.buf.Write(w.sectionHeaders(shdrs))
+	b := w.buf.Bytes()
+	w.putHeader(b, &hdr)
+	return b
+}
+
+// writeStripped returns img without the sections marked in move and
+// with a .gnu_debuglink section holding link. The file contents up to
+// the end of the loaded segments are unchanged; the remaining sections
+// and the section headers are laid out again after them.
+func (img *elfImage) writeStripped(move []bool, link []byte) []byte {
+	hdr := img.hdr
+	shstrndx := int(hdr.Shstrndx)
+
+	// New indexes of the kept sections; moved sections map to 0.
+	newIndex := make([]uint32, len(img.shdrs))
+	var shdrs []elf.Section64
+	var tail []int // old indexes of the sections to lay out again
+	cut := uint64(len(img.data))
+	for i, s := range img.shdrs {
+		if move[i] {
+			continue
+		}
+		newIndex[i] = uint32(len(shdrs))
+		shdrs = append(shdrs, s)
+		if i > 0 && elf.SectionType(s.Type) != elf.SHT_NOBITS && s.Off >= img.maxEnd || i == shstrndx {
+			tail = append(tail, i)
+			if s.Off < cut && s.Off >= img.maxEnd {
+				cut = s.Off
+			}
+		}
+	}
+	for i, s := range img.shdrs {
+		if move[i] && s.Off < cut {
+			cut = s.Off
+		}
+	}
+	if cut < img.maxEnd {
+		cut = img.maxEnd
+	}
+
+	// Fix up references to section indexes.
+	for i := range shdrs {
+		s := &shdrs[i]
+		if s.Link != 0 && int(s.Link) < len(newIndex) {
+			s.Link = newIndex[s.Link]
+		}
+		typ := elf.SectionType(s.Type)
+		if (typ == elf.SHT_REL || typ == elf.SHT_RELA || s.Flags&uint64(elf.SHF_INFO_LINK) != 0) && int(s.Info) < len(newIndex) {
+			s.Info = newIndex[s.Info]
+		}
+	}
+
+	w := &elfWriter{img: img}
+	w.buf.Write(img.data[:cut])
+
+	// Rewrite the section indexes of symbols in the kept symbol
+	// tables. Symbols in moved sections become undefined.
+	contents := make(map[int][]byte) // new contents of sections after cut
+	for i, s := range img.shdrs {
+		typ := elf.SectionType(s.Type)
+		if move[i] || typ != elf.SHT_SYMTAB && typ != elf.SHT_DYNSYM {
+			continue
+		}
+		var syms []byte
+		if s.Off < cut {
+			syms = w.buf.Bytes()[s.Off : s.Off+s.Size]
+		} else {
+			syms = append([]byte(nil), img.sectionData(i)...)
+			contents[i] = syms
+		}
+		symSize, shndxOff := elf.Sym32Size, 14
+		if img.is64 {
+			symSize, shndxOff = elf.Sym64Size, 6
+		}
+		for off := 0; off+symSize <= len(syms); off += symSize {
+			shndx := img.order.Uint16(syms[off+shndxOff:])
+			if shndx != 0 && shndx < uint16(elf.SHN_LORESERVE) && int(shndx) < len(newIndex) {
+				img.order.PutUint16(syms[off+shndxOff:], uint16(newIndex[shndx]))
+			}
+		}
+	}
+
+	// Lay out the remaining sections, with the name of the new section
+	// added to the section names.
+	strtab := append([]byte(nil), img.sectionData(shstrndx)...)
+	linkName := uint32(len(strtab))
+	strtab = append(strtab, ".gnu_debuglink\x00"...)
+	for _, i := range tail {
+		data, ok := contents[i]
+		if !ok {
+			data = img.sectionData(i)
+		}
+		if i == shstrndx {
+			data = strtab
+		}
+		w.section(&shdrs[newIndex[i]], data)
+	}
+	linkShdr := elf.Section64{Name: linkName, Type: uint32(elf.SHT_PROGBITS), Addralign: 4}
+	w.section(&linkShdr, link)
+	shdrs = append(shdrs, linkShdr)
+
+	// Write the section headers in place if they fit there, and
+	// otherwise at the end of the file.
+	hdr.Shentsize = w.shentsize()
+	headers := w.sectionHeaders(shdrs)
+	oldLen := uint64(len(img.shdrs)) * uint64(img.hdr.Shentsize)
+	b := w.buf.Bytes()
+	if img.hdr.Shoff+oldLen <= cut && uint64(len(headers)) <= oldLen {
+		copy(b[img.hdr.Shoff:img.hdr.Shoff+oldLen], make([]byte, oldLen))
+		copy(b[img.hdr.Shoff:], headers)
+	} else {
+		w.align(8)
+		hdr.Shoff = uint64(w.buf.Len())
+		w.buf.Write(headers)
+		b = w.buf.Bytes()
+	}
+	hdr.Shnum = uint16(len(shdrs))
+	hdr.Shstrndx = uint16(newIndex[shstrndx])
+	w.putHeader(b, &hdr)
+	return b
+}
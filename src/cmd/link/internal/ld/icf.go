@@ -0,0 +1,397 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ld
+
+import (
+	"bytes"
+	"cmd/internal/goobj"
+	"cmd/link/internal/loader"
+	"cmd/link/internal/sym"
+	"encoding/binary"
+	"hash/maphash"
+	"internal/abi"
+	"sort"
+	"strings"
+)
+
+// Identical code folding.
+//
+// Generic instantiations with different shapes and method wrappers for
+// different types often compile to exactly the same machine code. With
+// -icf, the linker keeps only one copy of each such function, and
+// resolves references to the others to the copy it keeps.
+//
+// Two functions are folded only if their code, their relocations, and
+// all the metadata that describes them at run time are identical: the
+// PC-value tables, the function data and the inlining tree, including
+// the source files they refer to, and their names as the runtime
+// prints them, which elide the type arguments of generic functions.
+// As a result, the pclntab entry of the kept function describes the
+// folded ones exactly, and tracebacks, profiles and runtime.FuncForPC
+// show the same names with and without folding. Only DWARF, which
+// has no entries for the folded functions, and the ELF symbol table,
+// which keeps a symbol for each of them at the address of the function
+// it was folded into, tell them apart. Functions whose identity matters
+// at run time, such as those of the runtime and those with a special
+// FuncID, are never folded.
+//
+// Relocations may refer to other functions that are themselves folded,
+// so functions are compared by partition refinement, as in other
+// linkers: candidate functions start out grouped by their contents,
+// and groups are split until every pair of functions in a group refers
+// to the same symbols or to functions in the same group.
+
+// icfEnabled reports whether identical code folding applies to the
+// current link. Folding is not done when linking externally or
+// dynamically, where the folded symbols would need to be visible to
+// other linkers, or on Wasm, where functions are referred to by index.
+func (ctxt *Link) icfEnabled() bool {
+	if !*flagICF || ctxt.IsExternal() || ctxt.DynlinkingGo() || ctxt.IsWasm() {
+		return false
+	}
+	return ctxt.BuildMode == BuildModeExe || ctxt.BuildMode == BuildModePIE
+}
+
+// foldIdenticalText folds functions with identical code, removing the
+// folded functions from ctxt.Textp and from their compilation units.
+// Their addresses are set to those of the functions they are folded
+// into by setFoldedTextAddrs, once text addresses are assigned.
+func (ctxt *Link) foldIdenticalText() {
+	if !ctxt.icfEnabled() {
+		return
+	}
+	ldr := ctxt.loader
+	f := &icf{ldr: ldr, class: make(map[loader.Sym]int)}
+
+	// Find the candidates and group them by their own contents.
+	entry := ldr.Lookup(*flagEntrySymbol, sym.SymVerABIInternal)
+	var cands []loader.Sym
+	byHash := make(map[uint64][]int) // hash to groups with that hash
+	seed := maphash.MakeSeed()
+	for _, s := range ctxt.Textp {
+		if s == entry || !f.candidate(s) {
+			continue
+		}
+		cands = append(cands, s)
+		h := f.hash(seed, s)
+		g := -1
+		for _, i := range byHash[h] {
+			if f.sameContents(f.groups[i][0], s) {
+				g = i
+				break
+			}
+		}
+		if g < 0 {
+			g = len(f.groups)
+			f.groups = append(f.groups, nil)
+			byHash[h] = append(byHash[h], g)
+		}
+		f.groups[g] = append(f.groups[g], s)
+		f.class[s] = g
+	}
+	if len(cands) == 0 {
+		return
+	}
+
+	// Split groups until the symbols each function refers to are in
+	// the same classes for all the functions in a group.
+	for f.refine() {
+	}
+
+	ctxt.foldedText = make(map[loader.Sym]loader.Sym)
+	for _, g := range f.groups {
+		for _, s := range g[1:] {
+			ctxt.foldedText[s] = g[0]
+		}
+	}
+	if len(ctxt.foldedText) == 0 {
+		return
+	}
+	textp := ctxt.Textp[:0]
+	for _, s := range ctxt.Textp {
+		if _, ok := ctxt.foldedText[s]; !ok {
+			textp = append(textp, s)
+		}
+	}
+	ctxt.Textp = textp
+	for _, lib := range ctxt.Library {
+		for _, u := range lib.Units {
+			utextp := u.Textp[:0]
+			for _, s := range u.Textp {
+				if _, ok := ctxt.foldedText[loader.Sym(s)]; !ok {
+					utextp = append(utextp, s)
+				}
+			}
+			u.Textp = utextp
+		}
+	}
+	if ctxt.Debugvlog != 0 {
+		var size int64
+		for s := range ctxt.foldedText {
+			size += ldr.SymSize(s)
+		}
+		ctxt.Logf("icf: folded %d functions, %d bytes of text\n", len(ctxt.foldedText), size)
+	}
+}
+
+// setFoldedTextAddrs sets the address and section of each folded
+// function to those of the function it is folded into.
+func (ctxt *Link) setFoldedTextAddrs() {
+	ldr := ctxt.loader
+	for s, into := range ctxt.foldedText {
+		ldr.SetSymValue(s, ldr.SymValue(into))
+		ldr.SetSymSect(s, ldr.SymSect(into))
+	}
+}
+
+// foldedTextSyms returns the folded functions, in symbol order.
+func (ctxt *Link) foldedTextSyms() []loader.Sym {
+	syms := make([]loader.Sym, 0, len(ctxt.foldedText))
+	for s := range ctxt.foldedText {
+		syms = append(syms, s)
+	}
+	sort.Slice(syms, func(i, j int) bool { return syms[i] < syms[j] })
+	return syms
+}
+
+// icf holds the state of identical code folding.
+type icf struct {
+	ldr    *loader.Loader
+	groups [][]loader.Sym     // candidate functions, grouped by class
+	class  map[loader.Sym]int // index in groups of each candidate
+	tmp    [2][]loader.Sym    // scratch space for sameContents
+	refs   []loader.Sym       // scratch space for refine
+	buf    []byte             // scratch space for refine
+}
+
+// candidate reports whether the text symbol s may be folded.
+// Only Go functions that need no special treatment at run time are
+// candidates.
+func (f *icf) candidate(s loader.Sym) bool {
+	ldr := f.ldr
+	if ldr.SymSize(s) == 0 || ldr.AttrSpecial(s) || ldr.AttrCgoExport(s) || ldr.IsDeferReturnTramp(s) {
+		return false
+	}
+	if ldr.SymPkg(s) == "runtime" {
+		// The runtime identifies some of its functions by PC.
+		return false
+	}
+	fi := ldr.FuncInfo(s)
+	if !fi.Valid() {
+		return false
+	}
+	if id := fi.FuncID(); id != abi.FuncIDNormal && id != abi.FuncIDWrapper {
+		return false
+	}
+	return fi.FuncFlag()&(abi.FuncFlagAsm|abi.FuncFlagTopFrame) == 0
+}
+
+// hash returns a hash of the code and relocations of s, which is the
+// same for functions that have the same contents.
+func (f *icf) hash(seed maphash.Seed, s loader.Sym) uint64 {
+	var h maphash.Hash
+	h.SetSeed(seed)
+	h.WriteString(printName(f.ldr.SymName(s)))
+	h.Write(f.ldr.Data(s))
+	relocs := f.ldr.Relocs(s)
+	var b [8]byte
+	for i := 0; i < relocs.Count(); i++ {
+		r := relocs.At(i)
+		if r.IsMarker() {
+			continue
+		}
+		binary.LittleEndian.PutUint32(b[:], uint32(r.Off()))
+		binary.LittleEndian.PutUint16(b[4:], uint16(r.Type()))
+		b[6] = r.Siz()
+		h.Write(b[:7])
+	}
+	return h.Sum64()
+}
+
+// sameContents reports whether a and b are identical apart from the
+// symbols they refer to, which are compared by refine.
+func (f *icf) sameContents(a, b loader.Sym) bool {
+	ldr := f.ldr
+	if printName(ldr.SymName(a)) != printName(ldr.SymName(b)) {
+		return false
+	}
+	if ldr.SymSize(a) != ldr.SymSize(b) || ldr.SymAlign(a) != ldr.SymAlign(b) ||
+		!bytes.Equal(ldr.Data(a), ldr.Data(b)) || !f.sameRelocs(a, b) {
+		return false
+	}
+
+	fa, fb := ldr.FuncInfo(a), ldr.FuncInfo(b)
+	if fa.Args() != fb.Args() || fa.Locals() != fb.Locals() ||
+		fa.FuncID() != fb.FuncID() || fa.FuncFlag() != fb.FuncFlag() ||
+		fa.StartLine() != fb.StartLine() {
+		return false
+	}
+	fa.Preload()
+	fb.Preload()
+	ua, ub := ldr.SymUnit(a), ldr.SymUnit(b)
+	sameFile := func(i, j goobj.CUFileIndex) bool {
+		return i == j && ua.FileTable[i] == ub.FileTable[j]
+	}
+	if fa.NumFile() != fb.NumFile() || fa.NumInlTree() != fb.NumInlTree() {
+		return false
+	}
+	for k := 0; k < int(fa.NumFile()); k++ {
+		if !sameFile(fa.File(k), fb.File(k)) {
+			return false
+		}
+	}
+	for k := 0; k < int(fa.NumInlTree()); k++ {
+		na, nb := fa.InlTree(k), fb.InlTree(k)
+		if na.Parent != nb.Parent || na.Line != nb.Line || na.ParentPC != nb.ParentPC || !sameFile(na.File, nb.File) {
+			return false
+		}
+	}
+
+	pcspA, pcfileA, pclineA, pcinlineA, pcdataA := ldr.PcdataAuxs(a, f.tmp[0])
+	pcspB, pcfileB, pclineB, pcinlineB, pcdataB := ldr.PcdataAuxs(b, f.tmp[1])
+	f.tmp[0], f.tmp[1] = pcdataA, pcdataB
+	if !f.sameData(pcspA, pcspB) || !f.sameData(pcfileA, pcfileB) ||
+		!f.sameData(pclineA, pclineB) || !f.sameData(pcinlineA, pcinlineB) ||
+		len(pcdataA) != len(pcdataB) {
+		return false
+	}
+	for k := range pcdataA {
+		if !f.sameData(pcdataA[k], pcdataB[k]) {
+			return false
+		}
+	}
+
+	fdA := ldr.Funcdata(a, f.tmp[0])
+	fdB := ldr.Funcdata(b, f.tmp[1])
+	f.tmp[0], f.tmp[1] = fdA, fdB
+	if len(fdA) != len(fdB) {
+		return false
+	}
+	for k := range fdA {
+		if !f.sameData(fdA[k], fdB[k]) || fdA[k] != 0 && !f.sameRelocs(fdA[k], fdB[k]) {
+			return false
+		}
+	}
+	return true
+}
+
+// printName returns the name of the function with symbol name name as
+// the runtime prints it, with the type arguments of a generic function
+// replaced by "...". It matches runtime.funcNameForPrint.
+func printName(name string) string {
+	i := strings.IndexByte(name, '[')
+	j := strings.LastIndexByte(name, ']')
+	if i < 0 || j <= i {
+		return name
+	}
+	return name[:i] + "[...]" + name[j+1:]
+}
+
+// sameData reports whether the symbols a and b, either of which may
+// be 0, have the same data.
+func (f *icf) sameData(a, b loader.Sym) bool {
+	if a == b {
+		return true
+	}
+	if a == 0 || b == 0 {
+		return false
+	}
+	return bytes.Equal(f.ldr.Data(a), f.ldr.Data(b))
+}
+
+// sameRelocs reports whether the relocations of a and b are the same,
+// apart from the symbols they refer to. Marker relocations, which only
+// affect reachability, are ignored.
+func (f *icf) sameRelocs(a, b loader.Sym) bool {
+	ra, rb := f.ldr.Relocs(a), f.ldr.Relocs(b)
+	i, j := 0, 0
+	for {
+		for i < ra.Count() && ra.At(i).IsMarker() {
+			i++
+		}
+		for j < rb.Count() && rb.At(j).IsMarker() {
+			j++
+		}
+		if i == ra.Count() || j == rb.Count() {
+			return i == ra.Count() && j == rb.Count()
+		}
+		x, y := ra.At(i), rb.At(j)
+		if x.Off() != y.Off() || x.Siz() != y.Siz() || x.Type() != y.Type() ||
+			x.Weak() != y.Weak() || x.Add() != y.Add() {
+			return false
+		}
+		i++
+		j++
+	}
+}
+
+// appendRefs appends the symbols that s refers to through relocations,
+// its function data and its inlining tree to refs.
+func (f *icf) appendRefs(refs []loader.Sym, s loader.Sym) []loader.Sym {
+	ldr := f.ldr
+	appendRelocs := func(s loader.Sym) {
+		relocs := ldr.Relocs(s)
+		for i := 0; i < relocs.Count(); i++ {
+			if r := relocs.At(i); !r.IsMarker() {
+				refs = append(refs, r.Sym())
+			}
+		}
+	}
+	appendRelocs(s)
+	for _, fd := range ldr.Funcdata(s, nil) {
+		if fd != 0 {
+			appendRelocs(fd)
+		}
+	}
+	fi := ldr.FuncInfo(s)
+	fi.Preload()
+	for k := 0; k < int(fi.NumInlTree()); k++ {
+		refs = append(refs, fi.InlTree(k).Func)
+	}
+	return refs
+}
+
+// refine splits each group of functions by the classes of the symbols
+// they refer to, where a function that is not a candidate is in a
+// class of its own. It reports whether any group was split.
+func (f *icf) refine() bool {
+	split := false
+	newClass := make(map[loader.Sym]int, len(f.class))
+	var groups [][]loader.Sym
+	for _, g := range f.groups {
+		if len(g) == 1 {
+			newClass[g[0]] = len(groups)
+			groups = append(groups, g)
+			continue
+		}
+		keys := make(map[string]int)
+		first := len(groups)
+		for _, s := range g {
+			f.refs = f.appendRefs(f.refs[:0], s)
+			f.buf = f.buf[:0]
+			for _, r := range f.refs {
+				c, ok := f.class[r]
+				if !ok {
+					// Not a candidate: distinct from every other symbol.
+					c = -1 - int(r)
+				}
+				f.buf = binary.AppendVarint(f.buf, int64(c))
+			}
+			i, ok := keys[string(f.buf)]
+			if !ok {
+				i = len(groups)
+				keys[string(f.buf)] = i
+				groups = append(groups, nil)
+			}
+			groups[i] = append(groups[i], s)
+			newClass[s] = i
+		}
+		if len(groups)-first > 1 {
+			split = true
+		}
+	}
+	f.groups, f.class = groups, newClass
+	return split
+}
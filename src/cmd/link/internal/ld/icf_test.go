@@ -0,0 +1,127 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ld
+
+import (
+	"debug/elf"
+	"internal/testenv"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+const icfProg = `
+package main
+
+import "fmt"
+
+type Pair struct{ x, y int }
+
+//go:noinline
+func (p Pair) Sum() int { return p.x + p.y }
+
+// The wrappers for the promoted methods of A and B are identical, but
+// have different names.
+type A struct{ Pair }
+type B struct{ Pair }
+
+type Summer interface{ Sum() int }
+
+//go:noinline
+func Total[T int64 | uint64](s []T) T {
+	var t T
+	for _, v := range s {
+		t += v
+	}
+	return t
+}
+
+func main() {
+	for _, s := range []Summer{&A{Pair{1, 2}}, &B{Pair{3, 4}}, A{Pair{5, 6}}, B{Pair{7, 8}}} {
+		fmt.Println(s.Sum())
+	}
+	fmt.Println(Total([]int64{1, 2, 3}), Total([]uint64{4, 5, 6}))
+}
+`
+
+const icfProgOutput = "3\n7\n11\n15\n6 15\n"
+
+func TestICF(t *testing.T) {
+	testenv.MustHaveGoBuild(t)
+	testenv.MustInternalLink(t, false)
+	if runtime.GOOS == "darwin" || runtime.GOOS == "ios" || runtime.GOOS == "windows" || runtime.GOOS == "plan9" {
+		t.Skipf("skipping on %s: test reads ELF symbols", runtime.GOOS)
+	}
+	t.Parallel()
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "icf.go")
+	if err := os.WriteFile(src, []byte(icfProg), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	build := func(name string, ldflags string) (textSize uint64, syms map[string]uint64) {
+		exe := filepath.Join(dir, name)
+		cmd := testenv.Command(t, testenv.GoToolPath(t), "build", "-ldflags="+ldflags, "-o", exe, src)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("%v: %v:\n%s", cmd.Args, err, out)
+		}
+		out, err := testenv.Command(t, exe).CombinedOutput()
+		if err != nil {
+			t.Fatalf("%s: %v:\n%s", exe, err, out)
+		}
+		if string(out) != icfProgOutput {
+			t.Errorf("%s: got output\n%s\nwant\n%s", exe, out, icfProgOutput)
+		}
+
+		f, err := elf.Open(exe)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		text := f.Section(".text")
+		if text == nil {
+			t.Fatalf("%s: no .text section", exe)
+		}
+		elfSyms, err := f.Symbols()
+		if err != nil {
+			t.Fatal(err)
+		}
+		syms = make(map[string]uint64)
+		for _, s := range elfSyms {
+			syms[s.Name] = s.Value
+		}
+		return text.Size, syms
+	}
+
+	size, syms := build("nofold", "-icf=false")
+	foldedSize, foldedSyms := build("fold", "-icf")
+
+	if foldedSize >= size {
+		t.Errorf("text size with -icf is %d, want less than %d", foldedSize, size)
+	}
+	for _, test := range []struct {
+		a, b string
+		fold bool
+	}{
+		{"main.A.Sum", "main.B.Sum", false},
+		{"main.(*A).Sum", "main.(*B).Sum", false},
+		{"main.Total[go.shape.int64]", "main.Total[go.shape.uint64]", true},
+	} {
+		a, aok := foldedSyms[test.a]
+		b, bok := foldedSyms[test.b]
+		if !aok || !bok {
+			t.Errorf("with -icf: missing symbol %s or %s", test.a, test.b)
+			continue
+		}
+		if (a == b) != test.fold {
+			t.Errorf("with -icf: %s at %#x and %s at %#x, want folded %v", test.a, a, test.b, b, test.fold)
+		}
+		if syms[test.a] == syms[test.b] {
+			t.Errorf("without -icf: %s and %s both at %#x, want different addresses", test.a, test.b, syms[test.a])
+		}
+	}
+}
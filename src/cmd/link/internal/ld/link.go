@@ -77,6 +77,8 @@ type Link struct {
 
 	tramps []loader.Sym // trampolines
 
+	foldedText map[loader.Sym]loader.Sym // functions folded by -icf, to the functions they are folded into
+
 	compUnits []*sym.CompilationUnit // DWARF compilation units
 	runtimeCU *sym.CompilationUnit   // One of the runtime CUs, the last one seen.
 
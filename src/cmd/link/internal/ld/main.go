@@ -104,6 +104,10 @@ var (
 	flagEntrySymbol   = flag.String("E", "", "set `entry` symbol name")
 	flagPruneWeakMap  = flag.Bool("pruneweakmap", true, "prune weak mapinit refs")
 	flagRandLayout    = flag.Int64("randlayout", 0, "randomize function layout")
+	flagPGOProfile    = flag.String("pgoprofile", "", "use the profile in `file` to lay out functions")
+	flagICF           = flag.Bool("icf", false, "fold functions with identical code")
+	flagDebugfile     = flag.String("debugfile", "", "write DWARF debug information to the separate `file`, linked from the ELF binary")
+	flagDwarfVersion  = flag.Int("dwarfversion", 0, "generate DWARF `version` 4 or 5 (default 5, or 4 on AIX and Darwin)")
 	cpuprofile        = flag.String("cpuprofile", "", "write cpu profile to `file`")
 	memprofile        = flag.String("memprofile", "", "write memory profile to `file`")
 	memprofilerate    = flag.Int64("memprofilerate", 0, "set runtime.MemProfileRate to `rate`")
@@ -112,6 +116,10 @@ var (
 
 	flagW ternaryFlag
 	FlagW = new(bool) // the -w flag, computed in main from flagW
+
+	// debugStripSymtab reports whether -s was given with -debugfile,
+	// in which case the symbol table moves to the debug file.
+	debugStripSymtab bool
 )
 
 // ternaryFlag is like a boolean flag, but has a default value that is
@@ -247,6 +255,13 @@ func Main(arch *sys.Arch, theArch Arch) {
 
 	checkStrictDups = *FlagStrictDups
 
+	if *flagDebugfile != "" && *FlagS {
+		// Generate the symbol table, to move it to the debug file
+		// with the DWARF information.
+		debugStripSymtab = true
+		*FlagS = false
+	}
+
 	switch flagW {
 	case ternaryFlagFalse:
 		*FlagW = false
@@ -259,6 +274,21 @@ func Main(arch *sys.Arch, theArch Arch) {
 		}
 	}
 
+	switch *flagDwarfVersion {
+	case 0:
+		*flagDwarfVersion = 5
+		if !dwarf5Supported(ctxt) {
+			*flagDwarfVersion = 4
+		}
+	case 4:
+	case 5:
+		if !dwarf5Supported(ctxt) {
+			Exitf("-dwarfversion=5 is not supported on %s", ctxt.HeadType)
+		}
+	default:
+		Exitf("invalid -dwarfversion value %d", *flagDwarfVersion)
+	}
+
 	if !buildcfg.Experiment.RegabiWrappers {
 		abiInternalVer = 0
 	}
@@ -312,6 +342,20 @@ func Main(arch *sys.Arch, theArch Arch) {
 	if ctxt.linkShared && !ctxt.IsELF {
 		Exitf("-linkshared can only be used on elf systems")
 	}
+	if *flagDebugfile != "" {
+		switch {
+		case !ctxt.IsELF:
+			Exitf("-debugfile can only be used on elf systems")
+		case ctxt.BuildMode == BuildModeCArchive:
+			Exitf("-debugfile cannot be used with -buildmode=c-archive")
+		case !dwarfEnabled(ctxt):
+			Exitf("-debugfile requires DWARF generation, which is disabled")
+		}
+		if len(buildinfo) == 0 && *flagBuildid != "" {
+			// Let debuggers find the debug file by build ID.
+			addbuildinfo("gobuildid")
+		}
+	}
 
 	if ctxt.Debugvlog != 0 {
 		onOff := func(b bool) string {
@@ -364,6 +408,9 @@ func Main(arch *sys.Arch, theArch Arch) {
 		fieldtrack(ctxt.Arch, ctxt.loader)
 	}
 
+	bench.Start("foldIdenticalText")
+	ctxt.foldIdenticalText()
+
 	bench.Start("dwarfGenerateDebugInfo")
 	dwarfGenerateDebugInfo(ctxt)
 
@@ -462,6 +509,10 @@ func Main(arch *sys.Arch, theArch Arch) {
 
 	bench.Start("hostlink")
 	ctxt.hostlink()
+	if *flagDebugfile != "" {
+		bench.Start("splitDebugFile")
+		ctxt.splitDebugFile()
+	}
 	if ctxt.Debugvlog != 0 {
 		ctxt.Logf("%s", ctxt.loader.Stat())
 		ctxt.Logf("%d liveness data\n", liveness)
@@ -305,7 +305,7 @@ func (state *pclntab) generateFuncnametab(ctxt *Link, funcs []loader.Sym) map[lo
 	writeFuncNameTab := func(ctxt *Link, s loader.Sym) {
 		symtab := ctxt.loader.MakeSymbolUpdater(s)
 		for s, off := range nameOffsets {
-			symtab.AddCStringAt(int64(off), ctxt.loader.SymName(s))
+			symtab.AddCStringAt(int64(off), funcName(ctxt.loader, s))
 		}
 	}
 
@@ -313,13 +313,23 @@ func (state *pclntab) generateFuncnametab(ctxt *Link, funcs []loader.Sym) map[lo
 	var size int64
 	walkFuncs(ctxt, funcs, func(s loader.Sym) {
 		nameOffsets[s] = uint32(size)
-		size += int64(len(ctxt.loader.SymName(s)) + 1) // NULL terminate
+		size += int64(len(funcName(ctxt.loader, s)) + 1) // NULL terminate
 	})
 
 	state.funcnametab = state.addGeneratedSym(ctxt, "runtime.funcnametab", size, writeFuncNameTab)
 	return nameOffsets
 }
 
+// funcName returns the name of the function s in runtime.funcnametab.
+// The cold fragment of a function has the function's name.
+func funcName(ldr *loader.Loader, s loader.Sym) string {
+	name := ldr.SymName(s)
+	if fi := ldr.FuncInfo(s); fi.Valid() && fi.FuncFlag()&abi.FuncFlagCold != 0 {
+		name = strings.TrimSuffix(name, ".cold")
+	}
+	return name
+}
+
 // walkFilenames walks funcs, calling a function for each filename used in each
 // function's line table.
 func walkFilenames(ctxt *Link, funcs []loader.Sym, f func(*sym.CompilationUnit, goobj.CUFileIndex)) {
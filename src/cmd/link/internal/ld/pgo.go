@@ -0,0 +1,135 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ld
+
+import (
+	"bufio"
+	"cmd/internal/pgo"
+	"cmd/link/internal/loader"
+	"fmt"
+	"internal/abi"
+	"os"
+	"sort"
+)
+
+// readPGOProfile reads the PGO profile in file, which may be a pprof
+// profile or a profile preprocessed by 'go tool preprofile'.
+// It returns nil if the profile has no samples.
+func readPGOProfile(file string) (*pgo.Profile, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("error opening profile: %w", err)
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+
+	isSerialized, err := pgo.IsSerialized(r)
+	if err != nil {
+		return nil, fmt.Errorf("error processing profile header: %w", err)
+	}
+	var p *pgo.Profile
+	if isSerialized {
+		p, err = pgo.FromSerialized(r)
+	} else {
+		p, err = pgo.FromPProf(r)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if p.TotalWeight == 0 {
+		return nil, nil
+	}
+	return p, nil
+}
+
+// textReordered reports whether the text symbols are reordered by
+// -randlayout or -pgoprofile, so that functions from the same package
+// are not necessarily laid out together.
+func textReordered() bool {
+	return *flagRandLayout != 0 || *flagPGOProfile != ""
+}
+
+// pgoFuncOrder returns the linker symbol names of the functions in the
+// call graph of profile p, in the order in which to lay them out.
+//
+// Functions are merged into chains along the call edges in order of
+// decreasing weight: the chain starting with a callee is appended to
+// the chain of its caller, so that a function is placed soon after its
+// hottest caller. The chains are then ordered by decreasing weight,
+// which is the total weight of the call edges from their functions.
+// This is a simplified form of call-chain clustering.
+func pgoFuncOrder(p *pgo.Profile) []string {
+	type chain struct {
+		funcs  []string
+		weight int64
+	}
+	var chains []*chain
+	chainOf := make(map[string]*chain)
+	lookup := func(name string) *chain {
+		c := chainOf[name]
+		if c == nil {
+			c = &chain{funcs: []string{name}}
+			chainOf[name] = c
+			chains = append(chains, c)
+		}
+		return c
+	}
+
+	for _, e := range p.NamedEdgeMap.ByWeight {
+		caller, callee := lookup(e.CallerName), lookup(e.CalleeName)
+		caller.weight += p.NamedEdgeMap.Weight[e]
+		if caller == callee || callee.funcs[0] != e.CalleeName {
+			continue
+		}
+		caller.funcs = append(caller.funcs, callee.funcs...)
+		caller.weight += callee.weight
+		for _, name := range callee.funcs {
+			chainOf[name] = caller
+		}
+		callee.funcs = nil
+	}
+
+	sort.SliceStable(chains, func(i, j int) bool {
+		return chains[i].weight > chains[j].weight
+	})
+	var order []string
+	for _, c := range chains {
+		order = append(order, c.funcs...)
+	}
+	return order
+}
+
+// pgoLayout reorders the text symbols in textp so that the functions
+// in the call graph of the profile named by -pgoprofile come first,
+// in the order given by pgoFuncOrder. The other functions, which the
+// profile shows to be cold, keep their order after them, followed by
+// the cold fragments that the compiler split out of hot functions.
+func (ctxt *Link) pgoLayout(textp []loader.Sym) {
+	p, err := readPGOProfile(*flagPGOProfile)
+	if err != nil {
+		Exitf("%s: PGO error: %v", *flagPGOProfile, err)
+	}
+	if p == nil {
+		return
+	}
+
+	rank := make(map[string]int)
+	for i, name := range pgoFuncOrder(p) {
+		rank[name] = i
+	}
+	ldr := ctxt.loader
+	key := func(s loader.Sym) int {
+		if fi := ldr.FuncInfo(s); fi.Valid() && fi.FuncFlag()&abi.FuncFlagCold != 0 {
+			return len(rank) + 1
+		}
+		if r, ok := rank[ldr.SymName(s)]; ok {
+			return r
+		}
+		return len(rank)
+	}
+	sort.SliceStable(textp, func(i, j int) bool {
+		return key(textp[i]) < key(textp[j])
+	})
+}
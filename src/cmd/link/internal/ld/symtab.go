@@ -213,6 +213,12 @@ func genelfsym(ctxt *Link, elfbind elf.SymBind) {
 		putelfsym(ctxt, s, elf.STT_FUNC, elfbind)
 	}
 
+	// Functions folded by -icf, as aliases of the functions they are
+	// folded into.
+	for _, s := range ctxt.foldedTextSyms() {
+		putelfsym(ctxt, s, elf.STT_FUNC, elfbind)
+	}
+
 	// runtime.etext marker symbol.
 	s = ldr.Lookup("runtime.etext", 0)
 	if ldr.SymType(s) == sym.STEXT {
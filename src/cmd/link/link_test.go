@@ -1415,3 +1415,167 @@ func TestRandLayout(t *testing.T) {
 		t.Errorf("randlayout with different seeds produced same layout:\n%s\n===\n\n%s", syms[0], syms[1])
 	}
 }
+
+const pgoLayoutSrc = `
+package main
+
+func main() {
+	println(hotA(1))
+}
+
+//go:noinline
+func hotA(x int) int {
+	return hotB(x) + 1
+}
+
+//go:noinline
+func hotB(x int) int {
+	return x * 2
+}
+`
+
+// pgoLayoutProfile is a preprocessed profile (as written by
+// "go tool preprofile") for pgoLayoutSrc.
+const pgoLayoutProfile = `GO PREPROFILE V1
+main.hotA
+main.hotB
+1 1000
+main.main
+main.hotA
+1 500
+`
+
+func TestPGOLayout(t *testing.T) {
+	// Test that the functions in the call graph of the PGO profile
+	// are laid out first, each callee after its hottest caller.
+	testenv.MustHaveGoBuild(t)
+
+	t.Parallel()
+
+	tmpdir := t.TempDir()
+
+	src := filepath.Join(tmpdir, "x.go")
+	if err := os.WriteFile(src, []byte(pgoLayoutSrc), 0666); err != nil {
+		t.Fatal(err)
+	}
+	prof := filepath.Join(tmpdir, "x.pgo")
+	if err := os.WriteFile(prof, []byte(pgoLayoutProfile), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	exe := filepath.Join(tmpdir, "x.exe")
+	cmd := testenv.Command(t, testenv.GoToolPath(t), "build", "-ldflags=-pgoprofile="+prof, "-o", exe, src)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("build failed: %v\n%s", err, out)
+	}
+	cmd = testenv.Command(t, exe)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("executable failed to run: %v\n%s", err, out)
+	}
+	cmd = testenv.Command(t, testenv.GoToolPath(t), "tool", "nm", "-n", exe)
+	out, err = cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("fail to run \"go tool nm\": %v\n%s", err, out)
+	}
+
+	// Collect the Go functions in address order.
+	var funcs []string
+	for _, line := range strings.Split(string(out), "\n") {
+		f := strings.Fields(line)
+		if len(f) == 3 && f[1] == "T" && f[2] != "runtime.text" && f[2] != "go:buildid" {
+			funcs = append(funcs, f[2])
+		}
+	}
+	want := []string{"main.main", "main.hotA", "main.hotB"}
+	if len(funcs) < len(want) || strings.Join(funcs[:len(want)], " ") != strings.Join(want, " ") {
+		t.Errorf("first functions are %v, want %v", funcs[:min(len(funcs), len(want))], want)
+	}
+}
+
+const pgoSplitSrc = `
+package main
+
+import "os"
+
+func main() {
+	s := []int{1, 2, 3}
+	println(hotA(s, len(os.Args)))
+}
+
+//go:noinline
+func hotA(s []int, i int) int {
+	return hotB(i) + s[i]
+}
+
+//go:noinline
+func hotB(x int) int {
+	return x * 2
+}
+`
+
+// pgoSplitProfile is a preprocessed profile for pgoSplitSrc.
+const pgoSplitProfile = `GO PREPROFILE V1
+main.hotA
+main.hotB
+1 1000
+main.main
+main.hotA
+2 500
+`
+
+func TestPGOSplit(t *testing.T) {
+	// Test that the cold fragment split out of a hot function is
+	// laid out after the other functions, and that a panic in it is
+	// reported in the function.
+	testenv.MustHaveGoBuild(t)
+	if runtime.GOARCH != "amd64" || runtime.GOOS == "windows" {
+		t.Skipf("cold blocks are not split on %s/%s", runtime.GOOS, runtime.GOARCH)
+	}
+
+	t.Parallel()
+
+	tmpdir := t.TempDir()
+
+	src := filepath.Join(tmpdir, "x.go")
+	if err := os.WriteFile(src, []byte(pgoSplitSrc), 0666); err != nil {
+		t.Fatal(err)
+	}
+	prof := filepath.Join(tmpdir, "x.pgo")
+	if err := os.WriteFile(prof, []byte(pgoSplitProfile), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	exe := filepath.Join(tmpdir, "x.exe")
+	cmd := testenv.Command(t, testenv.GoToolPath(t), "build", "-gcflags=-pgoprofile="+prof, "-ldflags=-pgoprofile="+prof, "-o", exe, src)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("build failed: %v\n%s", err, out)
+	}
+	cmd = testenv.Command(t, testenv.GoToolPath(t), "tool", "nm", "-n", exe)
+	out, err = cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("fail to run \"go tool nm\": %v\n%s", err, out)
+	}
+	var funcs []string
+	for _, line := range strings.Split(string(out), "\n") {
+		f := strings.Fields(line)
+		if len(f) == 3 && (f[1] == "T" || f[1] == "t") && strings.HasPrefix(f[2], "main.") {
+			funcs = append(funcs, f[2])
+		}
+	}
+	if len(funcs) == 0 || funcs[len(funcs)-1] != "main.hotA.cold" {
+		t.Errorf("functions of package main are %v, want main.hotA.cold last", funcs)
+	}
+
+	// Index s out of range in the cold fragment of hotA.
+	cmd = testenv.Command(t, exe, "a", "b", "c")
+	out, err = cmd.CombinedOutput()
+	if err == nil {
+		t.Fatalf("executable did not panic:\n%s", out)
+	}
+	want := `(?m)^main\.hotA\(.*\)\n\t.*x\.go:13 `
+	if !regexp.MustCompile(want).Match(out) {
+		t.Errorf("traceback does not match %q:\n%s", want, out)
+	}
+}
@@ -38,6 +38,7 @@
 //		Time    time.Time // encodes as an RFC3339-format string
 //		Action  string
 //		Package string
+//		Shard   string
 //		Test    string
 //		Elapsed float64 // seconds
 //		Output  string
@@ -65,6 +66,10 @@
 // different tests are interlaced; the Package field allows readers to
 // separate them.
 //
+// The Shard field, if present, records the shard ("i/n") of the package's
+// tests being run, as selected by the -test.shard flag. It is set on every
+// event following the test binary's report of its shard.
+//
 // The Test field, if present, specifies the test, example, or benchmark
 // function that caused the event. Events for the overall package test
 // do not set Test.
@@ -0,0 +1,145 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build ignore
+
+package main
+
+// This program generates the ML-KEM-1024 implementation from the ML-KEM-768
+// one, by renaming the identifiers that depend on the parameter set.
+
+import (
+	"bytes"
+	"flag"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var replacements = map[string]string{
+	"k": "k1024",
+
+	"encryptionKey": "encryptionKey1024",
+	"decryptionKey": "decryptionKey1024",
+
+	"generateKey":    "generateKey1024",
+	"newKeyFromSeed": "newKeyFromSeed1024",
+	"kemKeyGen":      "kemKeyGen1024",
+	"kemEncaps":      "kemEncaps1024",
+	"parseEK":        "parseEK1024",
+	"pkeEncrypt":     "pkeEncrypt1024",
+	"kemDecaps":      "kemDecaps1024",
+	"pkeDecrypt":     "pkeDecrypt1024",
+
+	"encodingSize4":             "encodingSize5",
+	"encodingSize10":            "encodingSize11",
+	"ringCompressAndEncode4":    "ringCompressAndEncode5",
+	"ringCompressAndEncode10":   "ringCompressAndEncode11",
+	"ringDecodeAndDecompress4":  "ringDecodeAndDecompress5",
+	"ringDecodeAndDecompress10": "ringDecodeAndDecompress11",
+}
+
+// suffix768 matches the identifiers which are renamed by replacing their
+// 768 suffix, both in code and in comments.
+var suffix768 = regexp.MustCompile(`\b(\w+)768\b`)
+
+// commentWords matches the identifiers in replacements that might appear in
+// comments. Single-letter names are left alone.
+var commentWords = regexp.MustCompile(`\b(encryptionKey|decryptionKey|generateKey|newKeyFromSeed|kemKeyGen|kemEncaps|parseEK|pkeEncrypt|kemDecaps|pkeDecrypt)\b`)
+
+func rename(name string) string {
+	if r, ok := replacements[name]; ok {
+		return r
+	}
+	if strings.HasSuffix(name, "768") {
+		return strings.TrimSuffix(name, "768") + "1024"
+	}
+	return name
+}
+
+func main() {
+	inputFile := flag.String("input", "", "")
+	outputFile := flag.String("output", "", "")
+	flag.Parse()
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, *inputFile, nil, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Drop the package documentation and the header comments, which precede
+	// the imports, and the constants, which are shared by the two parameter
+	// sets, along with their comments.
+	var importsEnd token.Pos
+	var consts []*ast.GenDecl
+	decls := f.Decls[:0]
+	for _, decl := range f.Decls {
+		if d, ok := decl.(*ast.GenDecl); ok {
+			switch d.Tok {
+			case token.IMPORT:
+				importsEnd = d.End()
+			case token.CONST:
+				consts = append(consts, d)
+				continue
+			}
+		}
+		decls = append(decls, decl)
+	}
+	f.Decls = decls
+	f.Doc = nil
+	comments := f.Comments[:0]
+	for _, c := range f.Comments {
+		if c.Pos() < importsEnd || slices.ContainsFunc(consts, func(d *ast.GenDecl) bool {
+			start := d.Pos()
+			if d.Doc != nil {
+				start = d.Doc.Pos()
+			}
+			return start <= c.Pos() && c.End() <= d.End()
+		}) {
+			continue
+		}
+		for _, cc := range c.List {
+			cc.Text = suffix768.ReplaceAllString(cc.Text, "${1}1024")
+			cc.Text = commentWords.ReplaceAllString(cc.Text, "${1}1024")
+			cc.Text = strings.ReplaceAll(cc.Text, "ML-KEM-768", "ML-KEM-1024")
+		}
+		comments = append(comments, c)
+	}
+	f.Comments = comments
+
+	ast.Inspect(f, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok {
+			id.Name = rename(id.Name)
+		}
+		return true
+	})
+
+	var buf bytes.Buffer
+	buf.WriteString(header)
+	if err := format.Node(&buf, fset, f); err != nil {
+		log.Fatal(err)
+	}
+	out, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*outputFile, out, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+const header = `// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Code generated by generate1024.go. DO NOT EDIT.
+
+`
@@ -0,0 +1,108 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mlkem
+
+import (
+	"bytes"
+	"crypto/internal/mlkem"
+	"testing"
+)
+
+func TestSizes(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		got, want int
+	}{
+		{"SharedKeySize", SharedKeySize, mlkem.SharedKeySize},
+		{"SeedSize", SeedSize, mlkem.SeedSize},
+		{"CiphertextSize768", CiphertextSize768, mlkem.CiphertextSize768},
+		{"EncapsulationKeySize768", EncapsulationKeySize768, mlkem.EncapsulationKeySize768},
+		{"CiphertextSize1024", CiphertextSize1024, mlkem.CiphertextSize1024},
+		{"EncapsulationKeySize1024", EncapsulationKeySize1024, mlkem.EncapsulationKeySize1024},
+	} {
+		if test.got != test.want {
+			t.Errorf("%s = %d, want %d", test.name, test.got, test.want)
+		}
+	}
+}
+
+type encapsulationKey interface {
+	Bytes() []byte
+	Encapsulate() ([]byte, []byte)
+}
+
+type decapsulationKey[E encapsulationKey] interface {
+	Bytes() []byte
+	Decapsulate([]byte) ([]byte, error)
+	EncapsulationKey() E
+}
+
+func TestRoundTrip(t *testing.T) {
+	t.Run("768", func(t *testing.T) {
+		testRoundTrip(t, GenerateKey768, NewEncapsulationKey768, NewDecapsulationKey768,
+			EncapsulationKeySize768, CiphertextSize768)
+	})
+	t.Run("1024", func(t *testing.T) {
+		testRoundTrip(t, GenerateKey1024, NewEncapsulationKey1024, NewDecapsulationKey1024,
+			EncapsulationKeySize1024, CiphertextSize1024)
+	})
+}
+
+func testRoundTrip[E encapsulationKey, D decapsulationKey[E]](
+	t *testing.T, generateKey func() (D, error),
+	newEncapsulationKey func([]byte) (E, error),
+	newDecapsulationKey func([]byte) (D, error),
+	encapsulationKeySize, ciphertextSize int) {
+	dk, err := generateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dk.Bytes()) != SeedSize {
+		t.Errorf("decapsulation key length = %d, want %d", len(dk.Bytes()), SeedSize)
+	}
+	ekBytes := dk.EncapsulationKey().Bytes()
+	if len(ekBytes) != encapsulationKeySize {
+		t.Errorf("encapsulation key length = %d, want %d", len(ekBytes), encapsulationKeySize)
+	}
+
+	// The receiver publishes the encapsulation key, and the sender parses it.
+	ek, err := newEncapsulationKey(ekBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Ke, c := ek.Encapsulate()
+	if len(Ke) != SharedKeySize {
+		t.Errorf("shared key length = %d, want %d", len(Ke), SharedKeySize)
+	}
+	if len(c) != ciphertextSize {
+		t.Errorf("ciphertext length = %d, want %d", len(c), ciphertextSize)
+	}
+
+	// The receiver can restore the decapsulation key from its seed.
+	dk1, err := newDecapsulationKey(dk.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(dk1.EncapsulationKey().Bytes(), ekBytes) {
+		t.Errorf("decapsulation key from seed has a different encapsulation key")
+	}
+	Kd, err := dk1.Decapsulate(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(Ke, Kd) {
+		t.Errorf("shared keys do not match: %x, %x", Ke, Kd)
+	}
+
+	if _, err := newEncapsulationKey(ekBytes[1:]); err == nil {
+		t.Errorf("expected error for short encapsulation key")
+	}
+	if _, err := newDecapsulationKey(dk.Bytes()[1:]); err == nil {
+		t.Errorf("expected error for short seed")
+	}
+	if _, err := dk.Decapsulate(c[1:]); err == nil {
+		t.Errorf("expected error for short ciphertext")
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"internal/godebug"
 	"io"
 	"net"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -130,18 +131,21 @@ const (
 	scsvRenegotiation uint16 = 0x00ff
 )
 
-// CurveID is the type of a TLS identifier for an elliptic curve. See
+// CurveID is the type of a TLS identifier for a key exchange mechanism. See
 // https://www.iana.org/assignments/tls-parameters/tls-parameters.xml#tls-parameters-8.
 //
-// In TLS 1.3, this type is called NamedGroup, but at this time this library
-// only supports Elliptic Curve based groups. See RFC 8446, Section 4.2.7.
+// In TLS 1.2, this registry used to support only elliptic curves. In TLS 1.3,
+// it was extended to other groups and renamed NamedGroup. See RFC 8446, Section
+// 4.2.7. It was then also extended to other mechanisms, such as hybrid
+// post-quantum KEMs.
 type CurveID uint16
 
 const (
-	CurveP256 CurveID = 23
-	CurveP384 CurveID = 24
-	CurveP521 CurveID = 25
-	X25519    CurveID = 29
+	CurveP256      CurveID = 23
+	CurveP384      CurveID = 24
+	CurveP521      CurveID = 25
+	X25519         CurveID = 29
+	X25519MLKEM768 CurveID = 4588
 )
 
 // TLS 1.3 Key Share. See RFC 8446, Section 4.2.8.
@@ -299,6 +303,13 @@ type ConnectionState struct {
 
 	// ekm is a closure exposed via ExportKeyingMaterial.
 	ekm func(label string, context []byte, length int) ([]byte, error)
+
+	// testingOnlyDidHRR is true if a HelloRetryRequest was sent/received.
+	testingOnlyDidHRR bool
+
+	// testingOnlyCurveID is the key exchange group negotiated in a TLS 1.3
+	// handshake, or zero otherwise.
+	testingOnlyCurveID CurveID
 }
 
 // ExportKeyingMaterial returns length bytes of exported key material in a new
@@ -750,10 +761,17 @@ type Config struct {
 	// which is currently TLS 1.3.
 	MaxVersion uint16
 
-	// CurvePreferences contains the elliptic curves that will be used in
-	// an ECDHE handshake, in preference order. If empty, the default will
-	// be used. The client will use the first preference as the type for
-	// its key share in TLS 1.3. This may change in the future.
+	// CurvePreferences contains the set of key exchange mechanisms that will
+	// be used in an ECDHE handshake, in preference order. If empty, the
+	// default will be used. The client will use the first preference as the
+	// type for its key share in TLS 1.3. This may change in the future.
+	//
+	// From Go 1.23, the default includes the X25519MLKEM768 hybrid
+	// post-quantum key exchange. To disable it, set CurvePreferences
+	// explicitly or use the GODEBUG=tlsmlkem=0 environment variable.
+	//
+	// X25519MLKEM768 is only supported in TLS 1.3, and is ignored when
+	// negotiating earlier versions.
 	CurvePreferences []CurveID
 
 	// DynamicRecordSizingDisabled disables adaptive sizing of TLS records.
@@ -1081,20 +1099,38 @@ func supportedVersionsFromMax(maxVersion uint16) []uint16 {
 	return versions
 }
 
-var defaultCurvePreferences = []CurveID{X25519, CurveP256, CurveP384, CurveP521}
+var tlsmlkem = godebug.New("tlsmlkem")
 
-func (c *Config) curvePreferences() []CurveID {
+// defaultCurvePreferences is the default set of supported key exchanges, as
+// well as the preference order.
+func defaultCurvePreferences() []CurveID {
+	if tlsmlkem.Value() == "0" {
+		return []CurveID{X25519, CurveP256, CurveP384, CurveP521}
+	}
+	return []CurveID{X25519MLKEM768, X25519, CurveP256, CurveP384, CurveP521}
+}
+
+// curvePreferences returns the key exchanges that can be negotiated
+// at the given protocol version, in preference order.
+func (c *Config) curvePreferences(version uint16) []CurveID {
+	var curvePreferences []CurveID
 	if needFIPS() {
-		return fipsCurvePreferences(c)
+		curvePreferences = fipsCurvePreferences(c)
+	} else if c == nil || len(c.CurvePreferences) == 0 {
+		curvePreferences = defaultCurvePreferences()
+	} else {
+		curvePreferences = c.CurvePreferences
 	}
-	if c == nil || len(c.CurvePreferences) == 0 {
-		return defaultCurvePreferences
+	if version < VersionTLS13 {
+		curvePreferences = slices.DeleteFunc(slices.Clone(curvePreferences), func(c CurveID) bool {
+			return c == X25519MLKEM768
+		})
 	}
-	return c.CurvePreferences
+	return curvePreferences
 }
 
-func (c *Config) supportsCurve(curve CurveID) bool {
-	for _, cc := range c.curvePreferences() {
+func (c *Config) supportsCurve(version uint16, curve CurveID) bool {
+	for _, cc := range c.curvePreferences(version) {
 		if cc == curve {
 			return true
 		}
@@ -1253,7 +1289,7 @@ func (chi *ClientHelloInfo) SupportsCertificate(c *Certificate) error {
 	}
 
 	// The only signed key exchange we support is ECDHE.
-	if !supportsECDHE(config, chi.SupportedCurves, chi.SupportedPoints) {
+	if !supportsECDHE(config, vers, chi.SupportedCurves, chi.SupportedPoints) {
 		return supportsRSAFallback(errors.New("client doesn't support ECDHE, can only use legacy RSA key exchange"))
 	}
 
@@ -1274,7 +1310,7 @@ func (chi *ClientHelloInfo) SupportsCertificate(c *Certificate) error {
 			}
 			var curveOk bool
 			for _, c := range chi.SupportedCurves {
-				if c == curve && config.supportsCurve(c) {
+				if c == curve && config.supportsCurve(vers, c) {
 					curveOk = true
 					break
 				}
@@ -47,7 +47,9 @@ type Conn struct {
 	// zero or one.
 	handshakes       int
 	extMasterSecret  bool
-	didResume        bool // whether this connection was a session resumption
+	didResume        bool    // whether this connection was a session resumption
+	didHRR           bool    // whether a HelloRetryRequest was sent/received
+	curveID          CurveID // key exchange group of a TLS 1.3 handshake
 	cipherSuite      uint16
 	ocspResponse     []byte   // stapled OCSP response
 	scts             [][]byte // signed certificate timestamps from server
@@ -1608,6 +1610,8 @@ func (c *Conn) connectionStateLocked() ConnectionState {
 	state.Version = c.vers
 	state.NegotiatedProtocol = c.clientProtocol
 	state.DidResume = c.didResume
+	state.testingOnlyDidHRR = c.didHRR
+	state.testingOnlyCurveID = c.curveID
 	state.NegotiatedProtocolIsMutual = true
 	state.ServerName = c.serverName
 	state.CipherSuite = c.cipherSuite
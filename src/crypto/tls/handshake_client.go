@@ -8,9 +8,9 @@ import (
 	"bytes"
 	"context"
 	"crypto"
-	"crypto/ecdh"
 	"crypto/ecdsa"
 	"crypto/ed25519"
+	"crypto/internal/mlkem"
 	"crypto/rsa"
 	"crypto/subtle"
 	"crypto/x509"
@@ -39,7 +39,7 @@ type clientHandshakeState struct {
 
 var testingOnlyForceClientHelloSignatureAlgorithms []SignatureScheme
 
-func (c *Conn) makeClientHello() (*clientHelloMsg, *ecdh.PrivateKey, error) {
+func (c *Conn) makeClientHello() (*clientHelloMsg, *keySharePrivateKeys, error) {
 	config := c.config
 	if len(config.ServerName) == 0 && !config.InsecureSkipVerify {
 		return nil, nil, errors.New("tls: either ServerName or InsecureSkipVerify must be specified in the tls.Config")
@@ -62,7 +62,8 @@ func (c *Conn) makeClientHello() (*clientHelloMsg, *ecdh.PrivateKey, error) {
 		return nil, nil, errors.New("tls: no supported versions satisfy MinVersion and MaxVersion")
 	}
 
-	clientHelloVersion := config.maxSupportedVersion(roleClient)
+	maxVersion := config.maxSupportedVersion(roleClient)
+	clientHelloVersion := maxVersion
 	// The version at the beginning of the ClientHello was capped at TLS 1.2
 	// for compatibility reasons. The supported_versions extension is used
 	// to negotiate versions now. See RFC 8446, Section 4.2.1.
@@ -78,7 +79,7 @@ func (c *Conn) makeClientHello() (*clientHelloMsg, *ecdh.PrivateKey, error) {
 		ocspStapling:                 true,
 		scts:                         true,
 		serverName:                   hostnameInSNI(config.ServerName),
-		supportedCurves:              config.curvePreferences(),
+		supportedCurves:              config.curvePreferences(maxVersion),
 		supportedPoints:              []uint8{pointFormatUncompressed},
 		secureRenegotiationSupported: true,
 		alpnProtocols:                config.NextProtos,
@@ -133,7 +134,7 @@ func (c *Conn) makeClientHello() (*clientHelloMsg, *ecdh.PrivateKey, error) {
 		hello.supportedSignatureAlgorithms = testingOnlyForceClientHelloSignatureAlgorithms
 	}
 
-	var key *ecdh.PrivateKey
+	var keyShareKeys *keySharePrivateKeys
 	if hello.supportedVersions[0] == VersionTLS13 {
 		// Reset the list of ciphers when the client only supports TLS 1.3.
 		if len(hello.supportedVersions) == 1 {
@@ -145,15 +146,21 @@ func (c *Conn) makeClientHello() (*clientHelloMsg, *ecdh.PrivateKey, error) {
 			hello.cipherSuites = append(hello.cipherSuites, defaultCipherSuitesTLS13NoAES...)
 		}
 
-		curveID := config.curvePreferences()[0]
-		if _, ok := curveForCurveID(curveID); !ok {
-			return nil, nil, errors.New("tls: CurvePreferences includes unsupported curve")
-		}
-		key, err = generateECDHEKey(config.rand(), curveID)
+		curveID := config.curvePreferences(maxVersion)[0]
+		var data []byte
+		keyShareKeys, data, err = generateKeyShare(config.rand(), curveID)
 		if err != nil {
 			return nil, nil, err
 		}
-		hello.keyShares = []keyShare{{group: curveID, data: key.PublicKey().Bytes()}}
+		hello.keyShares = []keyShare{{group: curveID, data: data}}
+		if curveID == X25519MLKEM768 {
+			// Also send a standalone X25519 key share, reusing the same X25519
+			// key, in case the server doesn't support the hybrid mechanism.
+			// This avoids a HelloRetryRequest round trip.
+			hello.keyShares = append(hello.keyShares, keyShare{
+				group: X25519, data: keyShareKeys.ecdhe.PublicKey().Bytes(),
+			})
+		}
 	}
 
 	if c.quic != nil {
@@ -167,7 +174,38 @@ func (c *Conn) makeClientHello() (*clientHelloMsg, *ecdh.PrivateKey, error) {
 		hello.quicTransportParameters = p
 	}
 
-	return hello, key, nil
+	return hello, keyShareKeys, nil
+}
+
+// generateKeyShare generates the private keys for a TLS 1.3 key share of
+// the given group, and returns them along with the key share data.
+func generateKeyShare(rand io.Reader, curveID CurveID) (*keySharePrivateKeys, []byte, error) {
+	if curveID == X25519MLKEM768 {
+		ecdheKey, err := generateECDHEKey(rand, X25519)
+		if err != nil {
+			return nil, nil, err
+		}
+		seed := make([]byte, mlkem.SeedSize)
+		if _, err := io.ReadFull(rand, seed); err != nil {
+			return nil, nil, err
+		}
+		mlkemKey, err := mlkem.NewDecapsulationKey768(seed)
+		if err != nil {
+			return nil, nil, err
+		}
+		// Per draft-kwiatkowski-tls-ecdhe-mlkem-02, the hybrid key share
+		// is the ML-KEM encapsulation key followed by the X25519 public key.
+		data := append(mlkemKey.EncapsulationKey().Bytes(), ecdheKey.PublicKey().Bytes()...)
+		return &keySharePrivateKeys{curveID: curveID, ecdhe: ecdheKey, mlkem: mlkemKey}, data, nil
+	}
+	if _, ok := curveForCurveID(curveID); !ok {
+		return nil, nil, errors.New("tls: CurvePreferences includes unsupported curve")
+	}
+	ecdheKey, err := generateECDHEKey(rand, curveID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &keySharePrivateKeys{curveID: curveID, ecdhe: ecdheKey}, ecdheKey.PublicKey().Bytes(), nil
 }
 
 func (c *Conn) clientHandshake(ctx context.Context) (err error) {
@@ -179,7 +217,7 @@ func (c *Conn) clientHandshake(ctx context.Context) (err error) {
 	// need to be reset.
 	c.didResume = false
 
-	hello, ecdheKey, err := c.makeClientHello()
+	hello, keyShareKeys, err := c.makeClientHello()
 	if err != nil {
 		return err
 	}
@@ -249,14 +287,14 @@ func (c *Conn) clientHandshake(ctx context.Context) (err error) {
 
 	if c.vers == VersionTLS13 {
 		hs := &clientHandshakeStateTLS13{
-			c:           c,
-			ctx:         ctx,
-			serverHello: serverHello,
-			hello:       hello,
-			ecdheKey:    ecdheKey,
-			session:     session,
-			earlySecret: earlySecret,
-			binderKey:   binderKey,
+			c:            c,
+			ctx:          ctx,
+			serverHello:  serverHello,
+			hello:        hello,
+			keyShareKeys: keyShareKeys,
+			session:      session,
+			earlySecret:  earlySecret,
+			binderKey:    binderKey,
 		}
 
 		// In TLS 1.3, session tickets are delivered after the handshake.
@@ -17,6 +17,7 @@ import (
 	"os"
 	"os/exec"
 	"runtime"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -437,6 +438,139 @@ func testHandshake(t *testing.T, clientConfig, serverConfig *Config) (serverStat
 	return
 }
 
+func TestHandshakeMLKEM(t *testing.T) {
+	defaultWithoutMLKEM := []CurveID{X25519, CurveP256, CurveP384, CurveP521}
+	for _, test := range []struct {
+		name           string
+		clientConfig   func(*Config)
+		serverConfig   func(*Config)
+		preparation    func(*testing.T)
+		expectClient   []CurveID
+		expectSelected CurveID
+		expectHRR      bool
+	}{
+		{
+			name:           "Default",
+			expectClient:   append([]CurveID{X25519MLKEM768}, defaultWithoutMLKEM...),
+			expectSelected: X25519MLKEM768,
+		},
+		{
+			name: "ClientCurvePreferences",
+			clientConfig: func(config *Config) {
+				config.CurvePreferences = []CurveID{X25519}
+			},
+			expectClient:   []CurveID{X25519},
+			expectSelected: X25519,
+		},
+		{
+			name: "ServerCurvePreferencesX25519",
+			serverConfig: func(config *Config) {
+				config.CurvePreferences = []CurveID{X25519}
+			},
+			expectClient:   append([]CurveID{X25519MLKEM768}, defaultWithoutMLKEM...),
+			expectSelected: X25519,
+		},
+		{
+			name: "ServerCurvePreferencesHRR",
+			serverConfig: func(config *Config) {
+				config.CurvePreferences = []CurveID{CurveP256}
+			},
+			expectClient:   append([]CurveID{X25519MLKEM768}, defaultWithoutMLKEM...),
+			expectSelected: CurveP256,
+			expectHRR:      true,
+		},
+		{
+			name: "ClientMLKEMOnly",
+			clientConfig: func(config *Config) {
+				config.CurvePreferences = []CurveID{X25519MLKEM768}
+			},
+			expectClient:   []CurveID{X25519MLKEM768},
+			expectSelected: X25519MLKEM768,
+		},
+		{
+			name: "HRRMLKEM",
+			clientConfig: func(config *Config) {
+				config.CurvePreferences = []CurveID{CurveP256, X25519MLKEM768}
+			},
+			serverConfig: func(config *Config) {
+				config.CurvePreferences = []CurveID{X25519MLKEM768}
+			},
+			expectClient:   []CurveID{CurveP256, X25519MLKEM768},
+			expectSelected: X25519MLKEM768,
+			expectHRR:      true,
+		},
+		{
+			name: "ClientTLSv12",
+			clientConfig: func(config *Config) {
+				config.MaxVersion = VersionTLS12
+			},
+			expectClient: defaultWithoutMLKEM,
+		},
+		{
+			name: "ServerTLSv12",
+			serverConfig: func(config *Config) {
+				config.MaxVersion = VersionTLS12
+			},
+			expectClient: append([]CurveID{X25519MLKEM768}, defaultWithoutMLKEM...),
+		},
+		{
+			name: "GODEBUG",
+			preparation: func(t *testing.T) {
+				t.Setenv("GODEBUG", "tlsmlkem=0")
+			},
+			expectClient:   defaultWithoutMLKEM,
+			expectSelected: X25519,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if test.preparation != nil {
+				test.preparation(t)
+			} else {
+				t.Parallel()
+			}
+			serverConfig := testConfig.Clone()
+			if test.serverConfig != nil {
+				test.serverConfig(serverConfig)
+			}
+			serverConfig.GetConfigForClient = func(hello *ClientHelloInfo) (*Config, error) {
+				if !slices.Equal(hello.SupportedCurves, test.expectClient) {
+					t.Errorf("got client curves %v, expected %v", hello.SupportedCurves, test.expectClient)
+				}
+				return nil, nil
+			}
+			clientConfig := testConfig.Clone()
+			if test.clientConfig != nil {
+				test.clientConfig(clientConfig)
+			}
+			ss, cs, err := testHandshake(t, clientConfig, serverConfig)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ss.testingOnlyCurveID != test.expectSelected {
+				t.Errorf("server selected curve %v, expected %v", ss.testingOnlyCurveID, test.expectSelected)
+			}
+			if cs.testingOnlyCurveID != test.expectSelected {
+				t.Errorf("client selected curve %v, expected %v", cs.testingOnlyCurveID, test.expectSelected)
+			}
+			if test.expectHRR {
+				if !ss.testingOnlyDidHRR {
+					t.Error("server did not use HRR")
+				}
+				if !cs.testingOnlyDidHRR {
+					t.Error("client did not use HRR")
+				}
+			} else {
+				if ss.testingOnlyDidHRR {
+					t.Error("server used HRR")
+				}
+				if cs.testingOnlyDidHRR {
+					t.Error("client used HRR")
+				}
+			}
+		})
+	}
+}
+
 func fromHex(s string) []byte {
 	b, _ := hex.DecodeString(s)
 	return b
@@ -0,0 +1,182 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package elf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// debugFileRoot is the directory under which the separate debug
+// information files of installed binaries are found.
+const debugFileRoot = "/usr/lib/debug"
+
+var errNoDebugFile = errors.New("elf: no separate debug information file found")
+
+// DebugLink returns the name of the separate debug information file of
+// f and the CRC-32 checksum of that file, as recorded in the
+// .gnu_debuglink section of f. It returns an empty name if f has no
+// such section. The name never contains a path separator.
+func (f *File) DebugLink() (name string, crc uint32, err error) {
+	s := f.Section(".gnu_debuglink")
+	if s == nil || s.Type == SHT_NOBITS {
+		return "", 0, nil
+	}
+	b, err := s.Data()
+	if err != nil {
+		return "", 0, err
+	}
+	// The name is followed by a NUL byte, padding to a multiple of
+	// four bytes and the checksum.
+	i := bytes.IndexByte(b, 0)
+	off := (i + 4) &^ 3
+	if i <= 0 || off+4 > len(b) {
+		return "", 0, &FormatError{int64(s.Offset), "malformed .gnu_debuglink section", nil}
+	}
+	// The file is looked up in fixed directories, so the name must
+	// not refer to another one.
+	name = string(b[:i])
+	if name != filepath.Base(name) || strings.Contains(name, "..") {
+		return "", 0, &FormatError{int64(s.Offset), "invalid file name in .gnu_debuglink section", name}
+	}
+	return name, f.ByteOrder.Uint32(b[off:]), nil
+}
+
+// BuildID returns the build ID recorded in the NT_GNU_BUILD_ID note of f,
+// or nil if f has no such note.
+func (f *File) BuildID() ([]byte, error) {
+	const ntGNUBuildID = 3
+	for _, s := range f.Sections {
+		if s.Type != SHT_NOTE {
+			continue
+		}
+		b, err := s.Data()
+		if err != nil {
+			return nil, err
+		}
+		// Notes are a sequence of a header of name size,
+		// description size and type, followed by the name and the
+		// description, each starting at the alignment of the
+		// section.
+		align := uint64(4)
+		if s.Addralign == 8 {
+			align = 8
+		}
+		pad := func(n uint64) uint64 { return (n + align - 1) &^ (align - 1) }
+		for len(b) >= 12 {
+			namesz := uint64(f.ByteOrder.Uint32(b))
+			descsz := uint64(f.ByteOrder.Uint32(b[4:]))
+			typ := f.ByteOrder.Uint32(b[8:])
+			descOff := pad(12 + namesz)
+			if descOff+descsz > uint64(len(b)) {
+				return nil, &FormatError{int64(s.Offset), "malformed note", nil}
+			}
+			if typ == ntGNUBuildID && string(b[12:12+namesz]) == "GNU\x00" {
+				return b[descOff : descOff+descsz], nil
+			}
+			next := pad(descOff + descsz)
+			if next >= uint64(len(b)) {
+				break
+			}
+			b = b[next:]
+		}
+	}
+	return nil, nil
+}
+
+// DebugFile opens the separate file holding the debug information of f,
+// such as one written by the -debugfile flag of the Go linker or by
+// objcopy --only-keep-debug.
+//
+// DebugFile looks for the file named after the build ID of f under
+// /usr/lib/debug/.build-id, and then, if f has a .gnu_debuglink
+// section, for the file it names in the directory of f, in the .debug
+// subdirectory of that directory, and in the same directory under
+// /usr/lib/debug. The file found must be a regular file, and its build
+// ID or checksum must match those recorded in f. The directory of f is
+// only known if f was created by [Open] or by [NewFile] with an
+// [*os.File].
+//
+// DebugFile returns an error if no such file is found.
+func (f *File) DebugFile() (*File, error) {
+	id, err := f.BuildID()
+	if err != nil {
+		return nil, err
+	}
+	if len(id) >= 2 {
+		hexID := fmt.Sprintf("%x", id)
+		name := filepath.Join(debugFileRoot, ".build-id", hexID[:2], hexID[2:]+".debug")
+		if df, err := openDebugFile(name); err == nil {
+			if dfID, _ := df.BuildID(); bytes.Equal(dfID, id) {
+				return df, nil
+			}
+			df.Close()
+		}
+	}
+
+	link, crc, err := f.DebugLink()
+	if err != nil {
+		return nil, err
+	}
+	if link == "" || f.path == "" {
+		return nil, errNoDebugFile
+	}
+	dir, err := filepath.Abs(filepath.Dir(f.path))
+	if err != nil {
+		return nil, err
+	}
+	self, _ := os.Stat(f.path)
+	for _, name := range []string{
+		filepath.Join(dir, link),
+		filepath.Join(dir, ".debug", link),
+		filepath.Join(debugFileRoot, dir, link),
+	} {
+		fi, err := os.Stat(name)
+		if err != nil || !fi.Mode().IsRegular() || self != nil && os.SameFile(fi, self) {
+			continue
+		}
+		if c, err := fileCRC(name, fi.Size()); err != nil || c != crc {
+			continue
+		}
+		if df, err := openDebugFile(name); err == nil {
+			return df, nil
+		}
+	}
+	return nil, errNoDebugFile
+}
+
+// openDebugFile opens the named debug information file, which must be
+// a regular file.
+func openDebugFile(name string) (*File, error) {
+	fi, err := os.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.Mode().IsRegular() {
+		return nil, errNoDebugFile
+	}
+	return Open(name)
+}
+
+// fileCRC returns the CRC-32 checksum of the first size bytes of the
+// named file, as used by .gnu_debuglink sections.
+func fileCRC(name string, size int64) (uint32, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	h := crc32.NewIEEE()
+	if _, err := io.CopyN(h, file, size); err != nil {
+		return 0, err
+	}
+	return h.Sum32(), nil
+}
@@ -55,6 +55,7 @@ type File struct {
 	Sections  []*Section
 	Progs     []*Prog
 	closer    io.Closer
+	path      string // name of the underlying file, if known
 	gnuNeed   []verneed
 	gnuVersym []byte
 }
@@ -287,6 +288,9 @@ func NewFile(r io.ReaderAt) (*File, error) {
 	}
 
 	f := new(File)
+	if osf, ok := r.(*os.File); ok {
+		f.path = osf.Name()
+	}
 	f.Class = Class(ident[EI_CLASS])
 	switch f.Class {
 	case ELFCLASS32:
@@ -3,11 +3,11 @@ module std
 go 1.23
 
 require (
-	golang.org/x/crypto v0.22.1-0.20240415215343-5defcc193aab
-	golang.org/x/net v0.24.1-0.20240405221309-ec05fdcd7114
+	golang.org/x/crypto v0.30.0
+	golang.org/x/net v0.32.1-0.20250121202134-9a960c88dd98
 )
 
 require (
-	golang.org/x/sys v0.19.0 // indirect
-	golang.org/x/text v0.14.0 // indirect
+	golang.org/x/sys v0.28.0 // indirect
+	golang.org/x/text v0.21.0 // indirect
 )
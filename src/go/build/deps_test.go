@@ -147,6 +147,9 @@ var depsRules = `
 	MATH
 	< runtime/metrics;
 
+	MATH
+	< simd;
+
 	RUNTIME, math/rand/v2
 	< internal/concurrent;
 
@@ -479,8 +482,8 @@ var depsRules = `
 	CRYPTO, FMT, math/big
 	< crypto/internal/boring/bbig
 	< crypto/rand
-	< crypto/internal/mlkem768
-	< crypto/ed25519
+	< crypto/internal/mlkem
+	< crypto/mlkem, crypto/ed25519
 	< encoding/asn1
 	< golang.org/x/crypto/cryptobyte/asn1
 	< golang.org/x/crypto/cryptobyte
@@ -526,7 +529,11 @@ var depsRules = `
 
 	FMT
 	< golang.org/x/net/http2/hpack
-	< net/http/internal, net/http/internal/ascii, net/http/internal/testcert;
+	< net/http/internal, net/http/internal/ascii, net/http/internal/testcert,
+	  net/http/internal/qpack;
+
+	NET, crypto/tls
+	< net/http/internal/quic;
 
 	FMT, NET, container/list, encoding/binary, log
 	< golang.org/x/text/transform
@@ -540,11 +547,14 @@ var depsRules = `
 	< net/http/httptrace;
 
 	compress/gzip,
+	internal/zstd,
 	golang.org/x/net/http/httpguts,
 	golang.org/x/net/http/httpproxy,
 	golang.org/x/net/http2/hpack,
 	net/http/internal,
 	net/http/internal/ascii,
+	net/http/internal/qpack,
+	net/http/internal/quic,
 	net/http/internal/testcert,
 	net/http/httptrace,
 	mime/multipart,
@@ -556,8 +566,8 @@ var depsRules = `
 	encoding/json, net/http
 	< expvar;
 
-	net/http, net/http/internal/ascii
-	< net/http/cookiejar, net/http/httputil;
+	net/http, net/http/internal/ascii, hash/fnv
+	< net/http/cookiejar, net/http/httputil, net/http/websocket;
 
 	net/http, flag
 	< net/http/httptest;
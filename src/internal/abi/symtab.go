@@ -27,6 +27,12 @@ const (
 
 	// FuncFlagAsm indicates that a function was implemented in assembly.
 	FuncFlagAsm
+
+	// FuncFlagCold indicates the cold fragment of a function, which
+	// the compiler split out of it so that the linker can place it away
+	// from the hot code. The fragment runs in the frame of the function
+	// it was split from, and has the same name.
+	FuncFlagCold
 )
 
 // A FuncID identifies particular functions that need to be treated
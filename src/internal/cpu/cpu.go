@@ -31,6 +31,7 @@ var X86 struct {
 	HasAVX2      bool
 	HasAVX512F   bool
 	HasAVX512BW  bool
+	HasAVX512DQ  bool
 	HasAVX512VL  bool
 	HasBMI1      bool
 	HasBMI2      bool
@@ -36,6 +36,12 @@ func TestX86ifAVX512VLhasAVX512F(t *testing.T) {
 	}
 }
 
+func TestX86ifAVX512DQhasAVX512F(t *testing.T) {
+	if X86.HasAVX512DQ && !X86.HasAVX512F {
+		t.Fatalf("HasAVX512F expected true when HasAVX512DQ is true, got false")
+	}
+}
+
 func TestDisableSSE3(t *testing.T) {
 	if GetGOAMD64level() > 1 {
 		t.Skip("skipping test: can't run on GOAMD64>v1 machines")
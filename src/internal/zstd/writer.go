@@ -0,0 +1,868 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zstd
+
+import (
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/bits"
+	"slices"
+	"sync"
+)
+
+// The Writer is a simple, fast compressor. It finds matches within
+// each block using a hash table of four-byte sequences, Huffman codes
+// the literals when that helps, and encodes the sequences using the
+// predefined FSE tables, so it never needs to write an FSE table.
+
+const (
+	// maxBlockSize is the maximum size of a block. RFC 3.1.1.2.3.
+	maxBlockSize = 128 << 10
+
+	// windowDescriptor describes a window of maxBlockSize bytes,
+	// which is enough since matches never cross a block boundary.
+	// RFC 3.1.1.1.2.
+	windowDescriptor = (17 - 10) << 3
+
+	minMatch = 4
+	hashBits = 15
+)
+
+// ErrWriterClosed is returned by a write to a closed Writer.
+var ErrWriterClosed = errors.New("zstd: write to closed Writer")
+
+// Writer compresses data written to it in the zstd format,
+// and writes the compressed data to an underlying writer.
+type Writer struct {
+	w           io.Writer
+	err         error
+	wroteHeader bool
+	closed      bool
+	checksum    xxhash64
+
+	buf   []byte               // uncompressed data not yet written
+	out   []byte               // the block being written
+	lits  []byte               // literals of the block being compressed
+	seqs  []sequence           // sequences of the block being compressed
+	table [1 << hashBits]int32 // hash of 4 bytes to position+1 in block
+}
+
+// A sequence is a run of literals followed by a match. RFC 3.1.1.3.2.
+type sequence struct {
+	litLen   uint32
+	matchLen uint32
+	offset   uint32
+}
+
+// NewWriter creates a new Writer that compresses data written to it
+// and writes the compressed data to w.
+// The caller must call Close to finish the compressed stream.
+func NewWriter(w io.Writer) *Writer {
+	zw := new(Writer)
+	zw.Reset(w)
+	return zw
+}
+
+// Reset discards the Writer's state and makes it equivalent to
+// the result of NewWriter, but writing to w instead.
+func (zw *Writer) Reset(w io.Writer) {
+	zw.w = w
+	zw.err = nil
+	zw.wroteHeader = false
+	zw.closed = false
+	zw.checksum.reset()
+	zw.buf = zw.buf[:0]
+}
+
+// Write compresses p, writing the compressed data to the
+// underlying writer as each block is complete.
+func (zw *Writer) Write(p []byte) (int, error) {
+	if zw.err != nil {
+		return 0, zw.err
+	}
+	if zw.closed {
+		return 0, ErrWriterClosed
+	}
+	n := 0
+	for len(p) > 0 {
+		if len(zw.buf) == 0 && len(p) >= maxBlockSize {
+			// Compress directly from p.
+			if err := zw.writeBlock(p[:maxBlockSize], false); err != nil {
+				return n, err
+			}
+			n += maxBlockSize
+			p = p[maxBlockSize:]
+			continue
+		}
+		m := maxBlockSize - len(zw.buf)
+		if m > len(p) {
+			m = len(p)
+		}
+		zw.buf = append(zw.buf, p[:m]...)
+		n += m
+		p = p[m:]
+		if len(zw.buf) == maxBlockSize {
+			if err := zw.writeBlock(zw.buf, false); err != nil {
+				return n, err
+			}
+			zw.buf = zw.buf[:0]
+		}
+	}
+	return n, nil
+}
+
+// Flush writes any buffered data to the underlying writer,
+// so that a reader can decompress all the data written so far.
+// Flushing often reduces compression.
+func (zw *Writer) Flush() error {
+	if zw.err != nil {
+		return zw.err
+	}
+	if zw.closed {
+		return ErrWriterClosed
+	}
+	if len(zw.buf) == 0 {
+		return nil
+	}
+	err := zw.writeBlock(zw.buf, false)
+	zw.buf = zw.buf[:0]
+	return err
+}
+
+// Close finishes the compressed stream, writing any buffered data
+// and the checksum to the underlying writer.
+// It does not close the underlying writer.
+func (zw *Writer) Close() error {
+	if zw.err != nil || zw.closed {
+		return zw.err
+	}
+	zw.closed = true
+	if err := zw.writeBlock(zw.buf, true); err != nil {
+		return err
+	}
+	zw.buf = zw.buf[:0]
+	var sum [4]byte
+	binary.LittleEndian.PutUint32(sum[:], uint32(zw.checksum.digest()))
+	return zw.write(sum[:])
+}
+
+func (zw *Writer) write(b []byte) error {
+	if _, err := zw.w.Write(b); err != nil {
+		zw.err = err
+	}
+	return zw.err
+}
+
+// writeBlock writes data, which is at most maxBlockSize bytes,
+// as a single block, preceded by the frame header if needed.
+func (zw *Writer) writeBlock(data []byte, last bool) error {
+	out := zw.out[:0]
+	if !zw.wroteHeader {
+		zw.wroteHeader = true
+		// Magic number and Frame_Header_Descriptor, which has
+		// only Content_Checksum_flag set. RFC 3.1.1.1.
+		out = append(out, 0x28, 0xb5, 0x2f, 0xfd, 0x04, windowDescriptor)
+	}
+	zw.checksum.update(data)
+
+	hdr := len(out)
+	out = append(out, 0, 0, 0) // Block_Header, set below.
+	blockType := 0             // Raw_Block
+	out = zw.compressBlock(out, data)
+	if n := len(out) - hdr - 3; n > 0 && n < len(data) {
+		blockType = 2 // Compressed_Block
+	} else if len(data) > 1 && isRLE(data) {
+		blockType = 1 // RLE_Block
+		out = append(out[:hdr+3], data[0])
+	} else {
+		out = append(out[:hdr+3], data...)
+	}
+	bh := uint32(blockType)<<1 | uint32(len(data))<<3
+	if blockType == 2 {
+		bh = uint32(blockType)<<1 | uint32(len(out)-hdr-3)<<3
+	}
+	if last {
+		bh |= 1
+	}
+	out[hdr] = byte(bh)
+	out[hdr+1] = byte(bh >> 8)
+	out[hdr+2] = byte(bh >> 16)
+	zw.out = out
+	return zw.write(out)
+}
+
+// isRLE reports whether all the bytes of data are the same.
+func isRLE(data []byte) bool {
+	for _, b := range data[1:] {
+		if b != data[0] {
+			return false
+		}
+	}
+	return true
+}
+
+func hash4(b []byte) uint32 {
+	return (binary.LittleEndian.Uint32(b) * 2654435761) >> (32 - hashBits)
+}
+
+// compressBlock appends to out the compressed form of data,
+// without a block header. The result may be longer than data,
+// in which case the caller writes a raw block instead.
+// RFC 3.1.1.3.
+func (zw *Writer) compressBlock(out, data []byte) []byte {
+	if len(data) < 2*minMatch {
+		return out
+	}
+	for i := range zw.table {
+		zw.table[i] = 0
+	}
+	lits, seqs := zw.lits[:0], zw.seqs[:0]
+	anchor := 0
+	for i := 0; i+minMatch <= len(data); {
+		h := hash4(data[i:])
+		cand := int(zw.table[h]) - 1
+		zw.table[h] = int32(i + 1)
+		if cand < 0 || binary.LittleEndian.Uint32(data[cand:]) != binary.LittleEndian.Uint32(data[i:]) {
+			// Skip ahead faster in data that does not compress.
+			i += 1 + (i-anchor)>>8
+			continue
+		}
+		n := minMatch
+		for i+n < len(data) && data[cand+n] == data[i+n] {
+			n++
+		}
+		if n < 6 && i-cand > 1<<10 {
+			// A short match far back costs more than its literals.
+			i++
+			continue
+		}
+		for i > anchor && cand > 0 && data[i-1] == data[cand-1] {
+			i--
+			cand--
+			n++
+		}
+		lits = append(lits, data[anchor:i]...)
+		seqs = append(seqs, sequence{
+			litLen:   uint32(i - anchor),
+			matchLen: uint32(n),
+			offset:   uint32(i - cand),
+		})
+		i += n
+		anchor = i
+		// Record a position near the end of the match,
+		// which helps find repeated matches.
+		if i-2 > cand && i+minMatch <= len(data) {
+			zw.table[hash4(data[i-2:])] = int32(i - 2 + 1)
+		}
+	}
+	lits = append(lits, data[anchor:]...)
+	zw.lits, zw.seqs = lits, seqs
+
+	out = appendLiterals(out, lits)
+
+	// Sequences_Section_Header. RFC 3.1.1.3.2.1.
+	switch n := len(seqs); {
+	case n < 128:
+		out = append(out, byte(n))
+	case n < 0x7f00:
+		out = append(out, byte(n>>8)+128, byte(n))
+	default:
+		out = append(out, 0xff, byte(n-0x7f00), byte((n-0x7f00)>>8))
+	}
+	if len(seqs) == 0 {
+		return out
+	}
+	// Symbol_Compression_Modes: all Predefined_Mode.
+	out = append(out, 0)
+	return encodeSequences(out, seqs)
+}
+
+// encodeSequences appends the bitstream encoding seqs
+// using the predefined FSE tables. RFC 3.1.1.3.2.2.
+//
+// The bitstream is read backward, so the sequences are
+// written from last to first, and each field is written
+// in the reverse of the order in which it is read.
+func encodeSequences(out []byte, seqs []sequence) []byte {
+	encodersOnce.Do(initEncoders)
+	bw := bitWriter{out: out}
+	llEnc, ofEnc, mlEnc := literalEncoder, offsetEncoder, matchEncoder
+
+	var ll, of, ml seqCodeValue
+	for i := len(seqs) - 1; i >= 0; i-- {
+		s := &seqs[i]
+		ll = literalLengthCode(s.litLen)
+		ml = matchLengthCode(s.matchLen)
+		of = offsetCode(s.offset)
+		if i == len(seqs)-1 {
+			// The decoder does not update the states
+			// after the last sequence, so start in any
+			// state for its symbols.
+			llEnc.init(ll.code)
+			ofEnc.init(of.code)
+			mlEnc.init(ml.code)
+		} else {
+			ofEnc.encode(&bw, of.code)
+			mlEnc.encode(&bw, ml.code)
+			llEnc.encode(&bw, ll.code)
+		}
+		bw.add(ll.extra, ll.bits)
+		bw.add(ml.extra, ml.bits)
+		bw.add(of.extra, of.bits)
+	}
+	mlEnc.flush(&bw)
+	ofEnc.flush(&bw)
+	llEnc.flush(&bw)
+	return bw.close()
+}
+
+// seqCodeValue is a literal length, match length, or offset,
+// as a code for FSE encoding and extra bits added to the
+// code's baseline value.
+type seqCodeValue struct {
+	code  uint8
+	bits  uint8
+	extra uint32
+}
+
+// literalLengthCode returns the code for a literal length.
+// RFC 3.1.1.3.2.1.1.
+func literalLengthCode(n uint32) seqCodeValue {
+	if n < literalLengthOffset {
+		return seqCodeValue{code: uint8(n)}
+	}
+	return baselineCode(n, literalLengthOffset, literalLengthBase)
+}
+
+// matchLengthCode returns the code for a match length,
+// which is at least 3. RFC 3.1.1.3.2.1.1.
+func matchLengthCode(n uint32) seqCodeValue {
+	if n-3 < matchLengthOffset {
+		return seqCodeValue{code: uint8(n - 3)}
+	}
+	return baselineCode(n, matchLengthOffset, matchLengthBase)
+}
+
+// baselineCode returns the code for n in a table of baselines
+// and bit counts such as literalLengthBase.
+func baselineCode(n uint32, offset int, base []uint32) seqCodeValue {
+	i := len(base) - 1
+	for base[i]&0xffffff > n {
+		i--
+	}
+	return seqCodeValue{
+		code:  uint8(offset + i),
+		bits:  uint8(base[i] >> 24),
+		extra: n - base[i]&0xffffff,
+	}
+}
+
+// offsetCode returns the code for a match offset. The offset is
+// written as offset+3, so that it is never taken as a repeated offset.
+// RFC 3.1.1.3.2.1.1, 3.1.1.5.
+func offsetCode(offset uint32) seqCodeValue {
+	v := offset + 3
+	code := uint8(bits.Len32(v) - 1)
+	return seqCodeValue{code: code, bits: code, extra: v - 1<<code}
+}
+
+// fseEncoder encodes symbols using an FSE table.
+type fseEncoder struct {
+	tableBits int
+	table     []fseEntry // the decoding table
+	// next maps a symbol and the state that follows it to the
+	// state to encode the symbol, indexed by sym<<tableBits|state.
+	next  []uint8
+	start []uint8 // a state for each symbol, for the last symbol encoded
+	state uint16
+}
+
+var (
+	encodersOnce   sync.Once
+	literalEncoder fseEncoder
+	offsetEncoder  fseEncoder
+	matchEncoder   fseEncoder
+)
+
+func initEncoders() {
+	literalEncoder.build(literalPredefinedDistribution, 6)
+	offsetEncoder.build(offsetPredefinedDistribution, 5)
+	matchEncoder.build(matchPredefinedDistribution, 6)
+}
+
+// build builds an encoder for the FSE table with the given distribution.
+// The decoding table maps each state to a symbol and a range of
+// states that can follow it, and the ranges of the states for each
+// symbol partition the states. The encoder inverts the table.
+func (e *fseEncoder) build(norm []int16, tableBits int) {
+	var r Reader
+	e.tableBits = tableBits
+	e.table = make([]fseEntry, 1<<tableBits)
+	if err := r.buildFSE(0, norm, e.table, tableBits); err != nil {
+		panic("zstd: bad predefined distribution: " + err.Error())
+	}
+	e.next = make([]uint8, len(norm)<<tableBits)
+	e.start = make([]uint8, len(norm))
+	for i := range e.start {
+		e.start[i] = 0xff
+	}
+	for s := len(e.table) - 1; s >= 0; s-- {
+		ent := e.table[s]
+		// Start in a state that reads bits to find the
+		// next state, which appendHuffTree needs.
+		if cur := e.start[ent.sym]; cur == 0xff || ent.bits >= e.table[cur].bits {
+			e.start[ent.sym] = uint8(s)
+		}
+		for n := int(ent.base); n < int(ent.base)+1<<ent.bits; n++ {
+			e.next[int(ent.sym)<<tableBits|n] = uint8(s)
+		}
+	}
+}
+
+func (e *fseEncoder) init(sym uint8) {
+	e.state = uint16(e.start[sym])
+}
+
+// encode writes the bits that take the decoder from a state for sym
+// to the current state, and moves to that state.
+func (e *fseEncoder) encode(bw *bitWriter, sym uint8) {
+	s := e.next[int(sym)<<e.tableBits|int(e.state)]
+	ent := &e.table[s]
+	bw.add(uint32(e.state-ent.base), ent.bits)
+	e.state = uint16(s)
+}
+
+// flush writes the state, which is the decoder's initial state.
+func (e *fseEncoder) flush(bw *bitWriter) {
+	bw.add(uint32(e.state), uint8(e.tableBits))
+}
+
+// bitWriter writes a bitstream that is read by a reverseBitReader.
+type bitWriter struct {
+	out   []byte
+	bits  uint64
+	nbits uint8
+}
+
+// add writes the low n bits of v, where n is at most 32.
+func (bw *bitWriter) add(v uint32, n uint8) {
+	bw.bits |= uint64(v&(1<<n-1)) << bw.nbits
+	bw.nbits += n
+	for bw.nbits >= 8 {
+		bw.out = append(bw.out, byte(bw.bits))
+		bw.bits >>= 8
+		bw.nbits -= 8
+	}
+}
+
+// appendNormalizedCounts writes the FSE table description of the
+// distribution norm, and returns the result, padded to a byte.
+// RFC 4.1.1.
+func (bw *bitWriter) appendNormalizedCounts(norm []int16, tableBits int) []byte {
+	bw.add(uint32(tableBits-5), 4)
+	remaining := 1<<tableBits + 1
+	threshold := 1 << tableBits
+	nbits := uint8(tableBits + 1)
+	prev0 := false
+	for sym := 0; remaining > 1; {
+		if prev0 {
+			// Write the number of zeros that follow as
+			// 2-bit repeat flags. See readFSE.
+			start := sym
+			for norm[sym] == 0 {
+				sym++
+			}
+			for ; sym-start >= 3; start += 3 {
+				bw.add(3, 2)
+			}
+			bw.add(uint32(sym-start), 2)
+			prev0 = false
+			continue
+		}
+		n := int(norm[sym])
+		sym++
+		max := 2*threshold - 1 - remaining
+		if n < 0 {
+			remaining--
+		} else {
+			remaining -= n
+		}
+		if v := n + 1; v < max {
+			bw.add(uint32(v), nbits-1)
+		} else {
+			if v >= threshold {
+				v += max
+			}
+			bw.add(uint32(v), nbits)
+		}
+		prev0 = n == 0
+		for remaining < threshold {
+			nbits--
+			threshold >>= 1
+		}
+	}
+	if bw.nbits > 0 {
+		bw.out = append(bw.out, byte(bw.bits))
+	}
+	return bw.out
+}
+
+// close writes the final 1 bit that marks the end of the
+// bitstream and returns the result.
+func (bw *bitWriter) close() []byte {
+	bw.add(1, 1)
+	if bw.nbits > 0 {
+		bw.out = append(bw.out, byte(bw.bits))
+	}
+	return bw.out
+}
+
+// appendLiterals appends the Literals_Section for lits,
+// Huffman coding them if that makes them smaller. RFC 3.1.1.3.1.
+func appendLiterals(out, lits []byte) []byte {
+	if len(lits) >= 64 {
+		if hout, ok := appendHuffLiterals(out, lits); ok {
+			return hout
+		}
+	}
+	// Raw_Literals_Block.
+	switch n := len(lits); {
+	case n < 1<<5:
+		out = append(out, byte(n<<3))
+	case n < 1<<12:
+		out = append(out, byte(n<<4)|1<<2, byte(n>>4))
+	default:
+		out = append(out, byte(n<<4)|3<<2, byte(n>>4), byte(n>>12))
+	}
+	return append(out, lits...)
+}
+
+// appendHuffLiterals appends lits as a Compressed_Literals_Block.
+// It reports false if Huffman coding would not save space.
+// RFC 3.1.1.3.1.4, 4.2.1.
+func appendHuffLiterals(out, lits []byte) ([]byte, bool) {
+	var freq [256]uint32
+	for _, b := range lits {
+		freq[b]++
+	}
+	maxSym := 255
+	for freq[maxSym] == 0 {
+		maxSym--
+	}
+	var lens [256]uint8
+	maxLen := huffmanLengths(freq[:maxSym+1], lens[:maxSym+1])
+	if maxLen == 0 {
+		return out, false // only one symbol
+	}
+	var treeBuf [1 + 128]byte
+	tree, ok := appendHuffTree(treeBuf[:0], lens[:maxSym+1], maxLen)
+	if !ok {
+		return out, false
+	}
+
+	// Estimate the size, and give up unless it
+	// saves at least 1/32 of the literals.
+	streams := 1
+	if len(lits) >= 1<<10 {
+		streams = 4
+	}
+	nbits := 0
+	for sym, n := range freq[:maxSym+1] {
+		nbits += int(n) * int(lens[sym])
+	}
+	size := len(tree) + (nbits+7)/8 + streams
+	if streams == 4 {
+		size += 6
+	}
+	if size >= len(lits)-len(lits)>>5 {
+		return out, false
+	}
+
+	// Assign codes in the order of the decoder's table:
+	// by increasing weight, then by symbol. See readHuff.
+	var codes [256]uint16
+	var count [maxHuffmanBits + 2]uint32
+	for _, l := range lens[:maxSym+1] {
+		if l > 0 {
+			count[maxLen+1-int(l)]++
+		}
+	}
+	var next [maxHuffmanBits + 2]uint32
+	for w, start := 1, uint32(0); w <= maxLen; w++ {
+		next[w] = start
+		start += count[w] << (w - 1)
+	}
+	for sym, l := range lens[:maxSym+1] {
+		if l > 0 {
+			w := maxLen + 1 - int(l)
+			codes[sym] = uint16(next[w] >> (w - 1))
+			next[w] += 1 << (w - 1)
+		}
+	}
+
+	// Literals_Section_Header, with the sizes filled in below.
+	hdr := len(out)
+	headerSize := 3
+	switch {
+	case streams == 1:
+	case len(lits) < 1<<14 && size < 1<<14:
+		headerSize = 4
+	default:
+		headerSize = 5
+	}
+	out = append(out, make([]byte, headerSize)...)
+
+	out = append(out, tree...)
+
+	// The streams. RFC 3.1.1.3.1.6.
+	if streams == 1 {
+		out = appendHuffStream(out, lits, &codes, &lens)
+	} else {
+		jump := len(out)
+		out = append(out, make([]byte, 6)...)
+		segSize := (len(lits) + 3) / 4
+		for i := 0; i < 4; i++ {
+			start := len(out)
+			lo, hi := i*segSize, (i+1)*segSize
+			if lo > len(lits) {
+				lo = len(lits)
+			}
+			if hi > len(lits) {
+				hi = len(lits)
+			}
+			seg := lits[lo:hi]
+			out = appendHuffStream(out, seg, &codes, &lens)
+			if i < 3 {
+				binary.LittleEndian.PutUint16(out[jump+2*i:], uint16(len(out)-start))
+			}
+		}
+	}
+
+	regen, comp := len(lits), len(out)-hdr-headerSize
+	if comp >= len(lits) {
+		return out[:hdr], false
+	}
+	h := out[hdr : hdr+headerSize]
+	h[0] = 2 | byte(regen&0xf)<<4 // Compressed_Literals_Block
+	switch headerSize {
+	case 3:
+		if streams == 4 {
+			h[0] |= 1 << 2
+		}
+		h[1] = byte(regen>>4)&0x3f | byte(comp)<<6
+		h[2] = byte(comp >> 2)
+	case 4:
+		h[0] |= 2 << 2
+		h[1] = byte(regen >> 4)
+		h[2] = byte(regen>>12)&3 | byte(comp)<<2
+		h[3] = byte(comp >> 6)
+	case 5:
+		h[0] |= 3 << 2
+		h[1] = byte(regen >> 4)
+		h[2] = byte(regen>>12)&0x3f | byte(comp)<<6
+		h[3] = byte(comp >> 2)
+		h[4] = byte(comp >> 10)
+	}
+	return out, true
+}
+
+// appendHuffTree appends the Huffman_Tree_Description for the code
+// lengths in lens, whose longest length is maxLen. It reports false
+// if the description is too large. RFC 4.2.1.
+func appendHuffTree(out []byte, lens []uint8, maxLen int) ([]byte, bool) {
+	// The weight of the last symbol is implied.
+	maxSym := len(lens) - 1
+	if maxSym <= 128 {
+		// Direct representation, with 4-bit weights.
+		out = append(out, byte(127+maxSym))
+		for i := 0; i < maxSym; i += 2 {
+			w1 := huffWeight(lens[i], maxLen)
+			w2 := uint8(0)
+			if i+1 < maxSym {
+				w2 = huffWeight(lens[i+1], maxLen)
+			}
+			out = append(out, w1<<4|w2)
+		}
+		return out, true
+	}
+
+	// Weights compressed with FSE. RFC 4.2.1.2.
+	weights := make([]uint8, maxSym)
+	var count [maxHuffmanBits + 1]int
+	for i := range weights {
+		weights[i] = huffWeight(lens[i], maxLen)
+		count[weights[i]]++
+	}
+	const tableBits = 6
+	norm, ok := normalizeCounts(count[:maxLen+1], tableBits)
+	if !ok {
+		return out, false
+	}
+	hdr := len(out)
+	out = append(out, 0) // size, set below
+	bw := bitWriter{out: out}
+	out = bw.appendNormalizedCounts(norm, tableBits)
+
+	var enc fseEncoder
+	enc.build(norm, tableBits)
+	// The decoder reads the weights alternately with two states,
+	// and it stops when there are not enough bits to update the
+	// state of the second last weight. See readHuff.
+	c := len(weights)
+	states := [2]fseEncoder{enc, enc}
+	states[(c-1)&1].init(weights[c-1])
+	states[(c-2)&1].init(weights[c-2])
+	if states[(c-2)&1].table[states[(c-2)&1].state].bits == 0 {
+		return out[:hdr], false
+	}
+	bw = bitWriter{out: out}
+	for i := c - 3; i >= 0; i-- {
+		states[i&1].encode(&bw, weights[i])
+	}
+	states[1].flush(&bw)
+	states[0].flush(&bw)
+	out = bw.close()
+	n := len(out) - hdr - 1
+	if n >= 128 {
+		return out[:hdr], false
+	}
+	out[hdr] = byte(n)
+	return out, true
+}
+
+// normalizeCounts returns the FSE distribution of the symbols with
+// the given counts, with probabilities that sum to 1<<tableBits.
+// It reports false if only one symbol has a nonzero count.
+func normalizeCounts(count []int, tableBits int) ([]int16, bool) {
+	total, used, largest := 0, 0, 0
+	for sym, n := range count {
+		total += n
+		if n > 0 {
+			used++
+			if n > count[largest] {
+				largest = sym
+			}
+		}
+	}
+	if used < 2 {
+		return nil, false
+	}
+	norm := make([]int16, len(count))
+	sum := 0
+	for sym, n := range count {
+		if n > 0 {
+			norm[sym] = int16(n << tableBits / total)
+			if norm[sym] == 0 {
+				norm[sym] = 1
+			}
+			sum += int(norm[sym])
+		}
+	}
+	// Correct rounding errors using the largest probabilities.
+	for ; sum < 1<<tableBits; sum++ {
+		norm[largest]++
+	}
+	for ; sum > 1<<tableBits; sum-- {
+		m := 0
+		for sym := range norm {
+			if norm[sym] > norm[m] {
+				m = sym
+			}
+		}
+		norm[m]--
+	}
+	return norm, true
+}
+
+// huffWeight returns the weight of a code of length l,
+// in a code whose longest length is maxLen. RFC 4.2.1.
+func huffWeight(l uint8, maxLen int) uint8 {
+	if l == 0 {
+		return 0
+	}
+	return uint8(maxLen + 1 - int(l))
+}
+
+// appendHuffStream appends a Huffman coded stream of lits.
+// The stream is read backward, so the last literal is written first.
+func appendHuffStream(out, lits []byte, codes *[256]uint16, lens *[256]uint8) []byte {
+	bw := bitWriter{out: out}
+	for i := len(lits) - 1; i >= 0; i-- {
+		b := lits[i]
+		bw.add(uint32(codes[b]), lens[b])
+	}
+	return bw.close()
+}
+
+// huffmanLengths sets lens to the lengths of a Huffman code for
+// symbols with the frequencies in freq, with no length longer than
+// maxHuffmanBits. It returns the longest length, or 0 if fewer than
+// two symbols are used.
+func huffmanLengths(freq []uint32, lens []uint8) int {
+	type node struct {
+		freq   uint32
+		parent int32
+	}
+	syms := make([]int, 0, len(freq))
+	for sym, f := range freq {
+		if f > 0 {
+			syms = append(syms, sym)
+		}
+	}
+	if len(syms) < 2 {
+		return 0
+	}
+	f := make([]uint32, len(freq))
+	copy(f, freq)
+	nodes := make([]node, 2*len(syms)-1)
+	for {
+		slices.SortStableFunc(syms, func(a, b int) int { return cmp.Compare(f[a], f[b]) })
+		n := len(syms)
+		for i, sym := range syms {
+			nodes[i] = node{freq: f[sym]}
+		}
+		// Merge the two lightest nodes, taking them from the
+		// sorted leaves and from the internal nodes, which are
+		// created in order of increasing weight.
+		leaf, inner := 0, n
+		lightest := func(end int) int {
+			if leaf < n && (inner >= end || nodes[leaf].freq <= nodes[inner].freq) {
+				leaf++
+				return leaf - 1
+			}
+			inner++
+			return inner - 1
+		}
+		for end := n; end < len(nodes); end++ {
+			a := lightest(end)
+			b := lightest(end)
+			nodes[end] = node{freq: nodes[a].freq + nodes[b].freq}
+			nodes[a].parent = int32(end)
+			nodes[b].parent = int32(end)
+		}
+		// Parents follow their children, so compute depths
+		// from the root down.
+		depth := make([]uint8, len(nodes))
+		for i := len(nodes) - 2; i >= 0; i-- {
+			depth[i] = depth[nodes[i].parent] + 1
+		}
+		maxLen := 0
+		for i, sym := range syms {
+			lens[sym] = depth[i]
+			if int(depth[i]) > maxLen {
+				maxLen = int(depth[i])
+			}
+		}
+		if maxLen <= maxHuffmanBits {
+			return maxLen
+		}
+		// Flatten the distribution and try again.
+		for _, sym := range syms {
+			f[sym] = f[sym]>>1 | 1
+		}
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zstd
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// writerTests are inputs for round-trip tests of the Writer.
+func writerTests(t testing.TB) map[string][]byte {
+	rnd := make([]byte, 3*maxBlockSize+17)
+	rand.New(rand.NewSource(1)).Read(rnd)
+	text := bigData(t)
+	return map[string][]byte{
+		"empty":     nil,
+		"short":     []byte("hello, world\n"),
+		"zeros":     make([]byte, 2*maxBlockSize+1),
+		"repeated":  []byte(strings.Repeat("abcdefghij", 1000)),
+		"random":    rnd,
+		"text":      text[:5*maxBlockSize/2],
+		"textBlock": text[:maxBlockSize],
+	}
+}
+
+func compress(t testing.TB, data []byte, chunk int) []byte {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	for p := data; len(p) > 0; {
+		n := min(chunk, len(p))
+		if _, err := w.Write(p[:n]); err != nil {
+			t.Fatal(err)
+		}
+		p = p[n:]
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestWriterRoundTrip(t *testing.T) {
+	for name, data := range writerTests(t) {
+		for _, chunk := range []int{1000, maxBlockSize, 1 << 30} {
+			compressed := compress(t, data, chunk)
+			got, err := io.ReadAll(NewReader(bytes.NewReader(compressed)))
+			if err != nil {
+				t.Errorf("%s, writes of %d: decompressing: %v", name, chunk, err)
+				continue
+			}
+			if !bytes.Equal(got, data) {
+				t.Errorf("%s, writes of %d: round trip mismatch", name, chunk)
+				showDiffs(t, got, data)
+			}
+		}
+	}
+}
+
+// TestWriterLiterals tests Huffman coding literals with alphabets
+// of various sizes, which use both representations of the weights.
+func TestWriterLiterals(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for maxSym := 2; maxSym < 256; maxSym += 7 {
+		// Random bytes with a skewed distribution, so that
+		// they compress, and few matches.
+		data := make([]byte, 10000)
+		for i := range data {
+			data[i] = byte(min(maxSym, int(rnd.ExpFloat64()*float64(maxSym)/8)))
+		}
+		data[0] = byte(maxSym)
+		if _, ok := appendHuffLiterals(nil, data); !ok {
+			t.Errorf("maxSym %d: not Huffman coded", maxSym)
+		}
+		compressed := compress(t, data, len(data))
+		got, err := io.ReadAll(NewReader(bytes.NewReader(compressed)))
+		if err != nil {
+			t.Errorf("maxSym %d: decompressing: %v", maxSym, err)
+			continue
+		}
+		if !bytes.Equal(got, data) {
+			t.Errorf("maxSym %d: round trip mismatch", maxSym)
+		}
+	}
+}
+
+func TestWriterCompresses(t *testing.T) {
+	data := bigData(t)[:1<<20]
+	compressed := compress(t, data, len(data))
+	t.Logf("compressed %d bytes to %d", len(data), len(compressed))
+	if len(compressed) > len(data)/2 {
+		t.Errorf("compressed %d bytes of text to %d, want at most half", len(data), len(compressed))
+	}
+}
+
+func TestWriterFlush(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	r := NewReader(&buf)
+	for _, s := range []string{"hello, ", "world", strings.Repeat("!", 100)} {
+		if _, err := io.WriteString(w, s); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		got := make([]byte, len(s))
+		if _, err := io.ReadFull(r, got); err != nil {
+			t.Fatalf("reading flushed data: %v", err)
+		}
+		if string(got) != s {
+			t.Fatalf("read flushed data %q, want %q", got, s)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := r.Read(make([]byte, 1)); n != 0 || err != io.EOF {
+		t.Errorf("Read at end = %d, %v, want 0, EOF", n, err)
+	}
+	if _, err := w.Write([]byte("x")); err != ErrWriterClosed {
+		t.Errorf("Write after Close: %v, want %v", err, ErrWriterClosed)
+	}
+}
+
+func TestWriterReset(t *testing.T) {
+	var buf1, buf2 bytes.Buffer
+	w := NewWriter(&buf1)
+	io.WriteString(w, "first")
+	w.Close()
+	w.Reset(&buf2)
+	io.WriteString(w, "second")
+	w.Close()
+	for _, test := range []struct {
+		buf  *bytes.Buffer
+		want string
+	}{{&buf1, "first"}, {&buf2, "second"}} {
+		got, err := io.ReadAll(NewReader(test.buf))
+		if err != nil || string(got) != test.want {
+			t.Errorf("got %q, %v; want %q", got, err, test.want)
+		}
+	}
+}
+
+// TestWriterZstd checks that the zstd program can decompress
+// the Writer's output.
+func TestWriterZstd(t *testing.T) {
+	zstd := findZstd(t)
+	for name, data := range writerTests(t) {
+		cmd := exec.Command(zstd, "-d")
+		cmd.Stdin = bytes.NewReader(compress(t, data, len(data)))
+		cmd.Stderr = os.Stderr
+		got, err := cmd.Output()
+		if err != nil {
+			t.Errorf("%s: zstd -d: %v", name, err)
+			continue
+		}
+		if !bytes.Equal(got, data) {
+			t.Errorf("%s: zstd -d output mismatch", name)
+		}
+	}
+}
+
+func FuzzWriter(f *testing.F) {
+	for _, test := range tests {
+		f.Add([]byte(test.uncompressed))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		got, err := io.ReadAll(NewReader(bytes.NewReader(compress(t, data, len(data)))))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Error("round trip mismatch")
+		}
+	})
+}
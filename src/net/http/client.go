@@ -103,6 +103,13 @@ type Client struct {
 	// RoundTripper implementations should use the Request's Context
 	// for cancellation instead of implementing CancelRequest.
 	Timeout time.Duration
+
+	// RetryPolicy specifies how the Client retries requests which
+	// fail with a transient error, and hedges slow requests.
+	// Retries count towards the Timeout.
+	//
+	// If RetryPolicy is nil, requests are not retried.
+	RetryPolicy *RetryPolicy
 }
 
 // DefaultClient is the default [Client] and is used by [Get], [Head], and [Post].
@@ -722,7 +729,7 @@ func (c *Client) do(req *Request) (retres *Response, reterr error) {
 		reqs = append(reqs, req)
 		var err error
 		var didTimeout func() bool
-		if resp, didTimeout, err = c.send(req, deadline); err != nil {
+		if resp, didTimeout, err = c.sendWithRetries(req, deadline); err != nil {
 			// c.send() always closes req.Body
 			reqBodyClosed = true
 			if !deadline.IsZero() && didTimeout() {
@@ -7,6 +7,7 @@
 package http_test
 
 import (
+	"bufio"
 	"bytes"
 	"compress/gzip"
 	"context"
@@ -22,6 +23,7 @@ import (
 	"net/http/httptest"
 	"net/http/httptrace"
 	"net/http/httputil"
+	"net/http/internal/testcert"
 	"net/textproto"
 	"net/url"
 	"os"
@@ -41,6 +43,9 @@ const (
 	http1Mode  = testMode("h1")     // HTTP/1.1
 	https1Mode = testMode("https1") // HTTPS/1.1
 	http2Mode  = testMode("h2")     // HTTP/2
+	http3Mode  = testMode("h3")     // HTTP/3
+
+	http2UnencryptedMode = testMode("h2unencrypted") // HTTP/2 without TLS
 )
 
 type testNotParallelOpt struct{}
@@ -95,6 +100,7 @@ func run[T TBRun[T]](t T, f func(t T, mode testMode), opts ...any) {
 type clientServerTest struct {
 	t  testing.TB
 	h2 bool
+	h3 bool
 	h  Handler
 	ts *httptest.Server
 	tr *Transport
@@ -120,7 +126,7 @@ func (t *clientServerTest) getURL(u string) string {
 }
 
 func (t *clientServerTest) scheme() string {
-	if t.h2 {
+	if t.h2 || t.h3 {
 		return "https"
 	}
 	return "http"
@@ -148,12 +154,13 @@ func optWithServerLog(lg *log.Logger) func(*httptest.Server) {
 //	func(*httptest.Server) // run before starting the server
 //	func(*http.Transport)
 func newClientServerTest(t testing.TB, mode testMode, h Handler, opts ...any) *clientServerTest {
-	if mode == http2Mode {
+	if mode == http2Mode || mode == http2UnencryptedMode {
 		CondSkipHTTP2(t)
 	}
 	cst := &clientServerTest{
 		t:  t,
 		h2: mode == http2Mode,
+		h3: mode == http3Mode,
 		h:  h,
 	}
 	cst.ts = httptest.NewUnstartedServer(h)
@@ -183,6 +190,14 @@ func newClientServerTest(t testing.TB, mode testMode, h Handler, opts ...any) *c
 		ExportHttp2ConfigureServer(cst.ts.Config, nil)
 		cst.ts.TLS = cst.ts.Config.TLSConfig
 		cst.ts.StartTLS()
+	case http2UnencryptedMode:
+		p := &Protocols{}
+		p.SetHTTP1(true)
+		p.SetUnencryptedHTTP2(true)
+		cst.ts.Config.Protocols = p
+		cst.ts.Start()
+	case http3Mode:
+		startHTTP3Server(t, cst.ts)
 	default:
 		t.Fatalf("unknown test mode %v", mode)
 	}
@@ -193,6 +208,17 @@ func newClientServerTest(t testing.TB, mode testMode, h Handler, opts ...any) *c
 			t.Fatal(err)
 		}
 	}
+	if mode == http2UnencryptedMode {
+		p := &Protocols{}
+		p.SetUnencryptedHTTP2(true)
+		cst.tr.Protocols = p
+	}
+	if mode == http3Mode {
+		cst.tr.EnableHTTP3()
+		if err := ExportHTTP3Connect(cst.tr, cst.ts.URL, cst.ts.Listener.Addr().String()); err != nil {
+			t.Fatal(err)
+		}
+	}
 	for _, f := range transportFuncs {
 		f(cst.tr)
 	}
@@ -202,6 +228,41 @@ func newClientServerTest(t testing.TB, mode testMode, h Handler, opts ...any) *c
 	return cst
 }
 
+// startHTTP3Server starts ts over TLS, and serves HTTP/3 on the UDP port
+// with the same number as its TCP port.
+func startHTTP3Server(t testing.TB, ts *httptest.Server) {
+	cert, err := tls.X509KeyPair(testcert.LocalhostCert, testcert.LocalhostKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts.Config.TLSConfig = &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+	ts.StartTLS()
+	pc, err := net.ListenPacket("udp", ts.Listener.Addr().String())
+	if err != nil {
+		ts.Close()
+		t.Skipf("cannot listen on UDP loopback: %v", err)
+	}
+	served := make(chan struct{})
+	go func() {
+		defer close(served)
+		ts.Config.ServeQUIC(pc, "", "")
+	}()
+	t.Cleanup(func() {
+		ts.Config.Close()
+		<-served
+	})
+	for ExportHTTP3AltSvc(ts.Config) == "" {
+		select {
+		case <-served:
+			t.Fatal("ServeQUIC returned early")
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
 type testLogWriter struct {
 	t testing.TB
 }
@@ -213,7 +274,7 @@ func (w testLogWriter) Write(b []byte) (int, error) {
 
 // Testing the newClientServerTest helper itself.
 func TestNewClientServerTest(t *testing.T) {
-	run(t, testNewClientServerTest, []testMode{http1Mode, https1Mode, http2Mode})
+	run(t, testNewClientServerTest, []testMode{http1Mode, https1Mode, http2Mode, http2UnencryptedMode, http3Mode})
 }
 func testNewClientServerTest(t *testing.T, mode testMode) {
 	var got struct {
@@ -243,6 +304,12 @@ func testNewClientServerTest(t *testing.T, mode testMode) {
 	case http2Mode:
 		wantProto = "HTTP/2.0"
 		wantTLS = true
+	case http2UnencryptedMode:
+		wantProto = "HTTP/2.0"
+		wantTLS = false
+	case http3Mode:
+		wantProto = "HTTP/3.0"
+		wantTLS = true
 	}
 	if got.proto != wantProto {
 		t.Errorf("req.Proto = %q, want %q", got.proto, wantProto)
@@ -1770,3 +1837,146 @@ func testEarlyHintsRequest(t *testing.T, mode testMode) {
 		t.Errorf("Read body %q; want Hello", body)
 	}
 }
+
+func TestUnencryptedHTTP2(t *testing.T) { run(t, testUnencryptedHTTP2, []testMode{http1Mode}) }
+func testUnencryptedHTTP2(t *testing.T, mode testMode) {
+	CondSkipHTTP2(t)
+	cst := newClientServerTest(t, mode, HandlerFunc(func(w ResponseWriter, r *Request) {
+		io.WriteString(w, r.Proto)
+	}), func(ts *httptest.Server) {
+		ts.Config.Protocols = new(Protocols)
+		ts.Config.Protocols.SetHTTP1(true)
+		ts.Config.Protocols.SetUnencryptedHTTP2(true)
+	})
+	for _, test := range []struct {
+		name             string
+		http1            bool
+		unencryptedHTTP2 bool
+		want             string
+	}{
+		{"HTTP1", true, false, "HTTP/1.1"},
+		{"UnencryptedHTTP2", false, true, "HTTP/2.0"},
+		{"HTTP1+UnencryptedHTTP2", true, true, "HTTP/1.1"},
+	} {
+		tr := &Transport{Protocols: new(Protocols)}
+		tr.Protocols.SetHTTP1(test.http1)
+		tr.Protocols.SetUnencryptedHTTP2(test.unencryptedHTTP2)
+		defer tr.CloseIdleConnections()
+		c := &Client{Transport: tr}
+		for i := 0; i < 2; i++ {
+			res, err := c.Get(cst.ts.URL)
+			if err != nil {
+				t.Fatalf("%v: Get: %v", test.name, err)
+			}
+			body, err := io.ReadAll(res.Body)
+			res.Body.Close()
+			if err != nil {
+				t.Fatalf("%v: reading body: %v", test.name, err)
+			}
+			if res.Proto != test.want || string(body) != test.want {
+				t.Errorf("%v: response Proto = %q, request Proto = %q; want %q", test.name, res.Proto, body, test.want)
+			}
+		}
+	}
+
+	// A short HTTP/1 request is not mistaken for the start of an HTTP/2 preface.
+	conn, err := net.Dial("tcp", cst.ts.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	io.WriteString(conn, "GET / HTTP/1.0\r\n\r\n")
+	res, err := ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatalf("HTTP/1.0 request: %v", err)
+	}
+	res.Body.Close()
+	if res.StatusCode != 200 {
+		t.Errorf("HTTP/1.0 request: status %v, want 200", res.Status)
+	}
+}
+
+func TestUnencryptedHTTP2Only(t *testing.T) { run(t, testUnencryptedHTTP2Only, []testMode{http1Mode}) }
+func testUnencryptedHTTP2Only(t *testing.T, mode testMode) {
+	CondSkipHTTP2(t)
+	cst := newClientServerTest(t, mode, HandlerFunc(func(w ResponseWriter, r *Request) {
+		io.WriteString(w, r.Proto)
+	}), optQuietLog, func(ts *httptest.Server) {
+		ts.Config.Protocols = new(Protocols)
+		ts.Config.Protocols.SetUnencryptedHTTP2(true)
+	})
+	if res, err := cst.c.Get(cst.ts.URL); err == nil {
+		res.Body.Close()
+		t.Fatalf("HTTP/1 request to server without HTTP1: got %v, want error", res.Status)
+	}
+
+	cst.tr.Protocols = new(Protocols)
+	cst.tr.Protocols.SetUnencryptedHTTP2(true)
+	if got := cst.getURL(cst.ts.URL); got != "HTTP/2.0" {
+		t.Errorf("request Proto = %q, want HTTP/2.0", got)
+	}
+}
+
+func TestServeTLSProtocols(t *testing.T) {
+	CondSkipHTTP2(t)
+	setParallel(t)
+	defer afterTest(t)
+	protos := func(http1, http2 bool) *Protocols {
+		p := new(Protocols)
+		p.SetHTTP1(http1)
+		p.SetHTTP2(http2)
+		return p
+	}
+	cert, err := tls.X509KeyPair(testcert.LocalhostCert, testcert.LocalhostKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		name          string
+		serverProtos  *Protocols
+		clientProtos  *Protocols
+		want          string
+		wantClientErr bool
+	}{
+		{"default", nil, nil, "HTTP/2.0", false},
+		{"server HTTP1", protos(true, false), nil, "HTTP/1.1", false},
+		{"server HTTP2", protos(false, true), nil, "HTTP/2.0", false},
+		{"client HTTP1", nil, protos(true, false), "HTTP/1.1", false},
+		{"client HTTP2", protos(true, true), protos(false, true), "HTTP/2.0", false},
+		{"no common protocol", protos(false, true), protos(true, false), "", true},
+	} {
+		ln := newLocalListener(t)
+		srv := &Server{
+			Handler: HandlerFunc(func(w ResponseWriter, r *Request) {
+				io.WriteString(w, r.Proto)
+			}),
+			TLSConfig: &tls.Config{Certificates: []tls.Certificate{cert}},
+			ErrorLog:  quietLog,
+			Protocols: test.serverProtos,
+		}
+		go srv.ServeTLS(ln, "", "")
+
+		tr := &Transport{
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+			ForceAttemptHTTP2: true,
+			Protocols:         test.clientProtos,
+		}
+		res, err := (&Client{Transport: tr}).Get("https://" + ln.Addr().String())
+		if test.wantClientErr {
+			if err == nil {
+				res.Body.Close()
+				t.Errorf("%v: got %v, want error", test.name, res.Status)
+			}
+		} else if err != nil {
+			t.Errorf("%v: Get: %v", test.name, err)
+		} else {
+			body, _ := io.ReadAll(res.Body)
+			res.Body.Close()
+			if string(body) != test.want {
+				t.Errorf("%v: request Proto = %q, want %q", test.name, body, test.want)
+			}
+		}
+		tr.CloseIdleConnections()
+		srv.Close()
+	}
+}
@@ -0,0 +1,437 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// HTTP response compression.
+
+package http
+
+import (
+	"compress/gzip"
+	"internal/zstd"
+	"io"
+	"net/http/internal/ascii"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// compressMinSize is the size of the smallest response body
+// that CompressHandler compresses.
+const compressMinSize = 1 << 10
+
+// CompressHandler returns a [Handler] that runs h, compressing the
+// response bodies it writes with gzip or zstd when the request's
+// Accept-Encoding header permits. When the client accepts both
+// encodings equally, gzip is used.
+//
+// CompressHandler compresses a response only if it has a status with
+// a body other than 206 (Partial Content), no Content-Encoding or
+// Content-Range header, no Cache-Control no-transform directive, and
+// a Content-Type that is likely to compress well, such as text,
+// JSON, JavaScript, or XML. If the handler does not set the
+// Content-Type, CompressHandler sets it to the result of
+// [DetectContentType], as the server would. Responses with bodies
+// smaller than 1KB are not compressed, unless the handler flushes
+// them first.
+//
+// When it compresses a response, CompressHandler sets the
+// Content-Encoding header, removes the Content-Length and
+// Accept-Ranges headers, and changes a strong ETag "tag" to
+// "tag-gzip" or "tag-zstd", so that the compressed representation
+// has its own validator. It removes these suffixes from the entity
+// tags in the request's If-None-Match and If-Match headers before
+// calling h. CompressHandler adds Accept-Encoding to the Vary header
+// of all responses.
+//
+// The [ResponseWriter] passed to h supports [Flusher], which flushes
+// any compressed data, and [ResponseController].
+// CompressHandler does not compress responses to Upgrade or
+// CONNECT requests.
+func CompressHandler(h Handler) Handler {
+	return HandlerFunc(func(w ResponseWriter, r *Request) {
+		if r.Method == "CONNECT" || r.Header.has("Upgrade") {
+			h.ServeHTTP(w, r)
+			return
+		}
+		encoding := negotiateEncoding(r.Header["Accept-Encoding"])
+		if encoding == "" {
+			addVary(w.Header(), "Accept-Encoding")
+			h.ServeHTTP(w, r)
+			return
+		}
+		cw := &compressWriter{rw: w, encoding: encoding}
+		r, cw.etagSuffixed = stripETagSuffixes(r, encoding)
+		h.ServeHTTP(cw, r)
+		cw.finish()
+	})
+}
+
+// negotiateEncoding returns the content coding to use for a response
+// to a request with the given Accept-Encoding header values:
+// "gzip", "zstd", or "" for no compression. RFC 9110, Section 12.5.3.
+func negotiateEncoding(accept []string) string {
+	var gzipQ, zstdQ, anyQ float64 = -1, -1, -1
+	for _, v := range accept {
+		for _, elem := range strings.Split(v, ",") {
+			coding, params, _ := strings.Cut(elem, ";")
+			coding = textproto.TrimString(coding)
+			q := 1.0
+			for _, p := range strings.Split(params, ";") {
+				name, value, _ := strings.Cut(p, "=")
+				if ascii.EqualFold(textproto.TrimString(name), "q") {
+					if f, err := strconv.ParseFloat(textproto.TrimString(value), 64); err == nil && f >= 0 && f <= 1 {
+						q = f
+					}
+				}
+			}
+			switch {
+			case ascii.EqualFold(coding, "gzip"), ascii.EqualFold(coding, "x-gzip"):
+				gzipQ = max(gzipQ, q)
+			case ascii.EqualFold(coding, "zstd"):
+				zstdQ = max(zstdQ, q)
+			case coding == "*":
+				anyQ = max(anyQ, q)
+			}
+		}
+	}
+	if gzipQ < 0 {
+		gzipQ = anyQ
+	}
+	if zstdQ < 0 {
+		zstdQ = anyQ
+	}
+	switch {
+	case zstdQ > 0 && zstdQ > gzipQ:
+		return "zstd"
+	case gzipQ > 0:
+		return "gzip"
+	}
+	return ""
+}
+
+// addVary adds name to the Vary header in h, unless it is already there.
+func addVary(h Header, name string) {
+	for _, v := range h["Vary"] {
+		for _, elem := range strings.Split(v, ",") {
+			if elem = textproto.TrimString(elem); elem == "*" || ascii.EqualFold(elem, name) {
+				return
+			}
+		}
+	}
+	h.Add("Vary", name)
+}
+
+// stripETagSuffixes returns r with the suffix for encoding removed
+// from the entity tags of its If-None-Match and If-Match headers,
+// and reports whether any entity tag of If-None-Match had the suffix.
+// It does not modify r.
+func stripETagSuffixes(r *Request, encoding string) (_ *Request, suffixed bool) {
+	suffix := "-" + encoding + `"`
+	var r2 *Request
+	for _, name := range []string{"If-None-Match", "If-Match"} {
+		v := r.Header.get(name)
+		if !strings.Contains(v, suffix) {
+			continue
+		}
+		var b strings.Builder
+		for {
+			v = textproto.TrimString(v)
+			if v == "" {
+				break
+			}
+			if v[0] == ',' || v[0] == '*' {
+				b.WriteByte(v[0])
+				v = v[1:]
+				continue
+			}
+			etag, remain := scanETag(v)
+			if etag == "" {
+				// Leave the rest of an invalid header alone.
+				b.WriteString(v)
+				break
+			}
+			if trimmed, ok := strings.CutSuffix(etag, suffix); ok {
+				etag = trimmed + `"`
+				if name == "If-None-Match" {
+					suffixed = true
+				}
+			}
+			b.WriteString(etag)
+			v = remain
+		}
+		if r2 == nil {
+			r2 = new(Request)
+			*r2 = *r
+			r2.Header = r.Header.Clone()
+		}
+		r2.Header.Set(name, b.String())
+	}
+	if r2 == nil {
+		return r, false
+	}
+	return r2, suffixed
+}
+
+// compressibleContentType reports whether a response with the
+// Content-Type ct is likely to compress well. Types which are already
+// compressed, such as most image, audio, and video formats, are not.
+func compressibleContentType(ct string) bool {
+	mt, _, _ := strings.Cut(ct, ";")
+	mt, _ = ascii.ToLower(textproto.TrimString(mt))
+	if strings.HasPrefix(mt, "text/") ||
+		strings.HasSuffix(mt, "+json") ||
+		strings.HasSuffix(mt, "+xml") {
+		return true
+	}
+	switch mt {
+	case "application/json",
+		"application/javascript",
+		"application/x-javascript",
+		"application/ecmascript",
+		"application/xml",
+		"application/wasm",
+		"application/x-ndjson",
+		"application/vnd.ms-fontobject",
+		"font/otf",
+		"font/ttf",
+		"image/bmp",
+		"image/x-icon",
+		"image/vnd.microsoft.icon":
+		return true
+	}
+	return false
+}
+
+// A compressor compresses a response body.
+type compressor interface {
+	io.WriteCloser
+	Flush() error
+}
+
+var (
+	gzipWriterPool sync.Pool // *gzip.Writer
+	zstdWriterPool sync.Pool // *zstd.Writer
+)
+
+func getCompressor(encoding string, w io.Writer) compressor {
+	if encoding == "zstd" {
+		if zw, ok := zstdWriterPool.Get().(*zstd.Writer); ok {
+			zw.Reset(w)
+			return zw
+		}
+		return zstd.NewWriter(w)
+	}
+	if gw, ok := gzipWriterPool.Get().(*gzip.Writer); ok {
+		gw.Reset(w)
+		return gw
+	}
+	return gzip.NewWriter(w)
+}
+
+func putCompressor(c compressor) {
+	switch c := c.(type) {
+	case *gzip.Writer:
+		c.Reset(nil)
+		gzipWriterPool.Put(c)
+	case *zstd.Writer:
+		c.Reset(nil)
+		zstdWriterPool.Put(c)
+	}
+}
+
+// compressWriter is the ResponseWriter passed to the handler
+// of CompressHandler.
+//
+// It buffers the start of the response body until it has enough to
+// decide whether to compress the response, or the handler flushes
+// or returns.
+type compressWriter struct {
+	rw           ResponseWriter
+	encoding     string // "gzip" or "zstd"
+	etagSuffixed bool   // the request's If-None-Match had the encoding's suffix
+
+	status      int  // status set by the handler
+	wroteHeader bool // the handler wrote the header
+	decided     bool // the header was written to rw
+	buf         []byte
+	c           compressor // nil if not compressing
+	err         error      // error writing to rw
+}
+
+func (cw *compressWriter) Header() Header {
+	return cw.rw.Header()
+}
+
+func (cw *compressWriter) WriteHeader(code int) {
+	if code >= 100 && code <= 199 && code != StatusSwitchingProtocols {
+		// Informational responses pass through.
+		cw.rw.WriteHeader(code)
+		return
+	}
+	if cw.wroteHeader {
+		if cw.decided {
+			cw.rw.WriteHeader(code) // let rw report the superfluous call
+		}
+		return
+	}
+	cw.wroteHeader = true
+	cw.status = code
+	if !bodyAllowedForStatus(code) || code == StatusPartialContent {
+		cw.decide(true, false)
+	}
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if !cw.wroteHeader {
+		cw.WriteHeader(StatusOK)
+	}
+	if !cw.decided {
+		cw.buf = append(cw.buf, p...)
+		if len(cw.buf) >= compressMinSize {
+			cw.decide(false, false)
+		}
+		if cw.err != nil {
+			return 0, cw.err
+		}
+		return len(p), nil
+	}
+	if cw.err != nil {
+		return 0, cw.err
+	}
+	if cw.c == nil {
+		return cw.rw.Write(p)
+	}
+	n, err := cw.c.Write(p)
+	if err != nil {
+		cw.err = err
+	}
+	return n, err
+}
+
+// Flush writes the response header, and any buffered and compressed
+// data, and flushes the underlying ResponseWriter.
+func (cw *compressWriter) Flush() {
+	cw.FlushError()
+}
+
+// FlushError is like Flush, but returns an error.
+// It is used by ResponseController.
+func (cw *compressWriter) FlushError() error {
+	if !cw.wroteHeader {
+		cw.WriteHeader(StatusOK)
+	}
+	if !cw.decided {
+		cw.decide(false, true)
+	}
+	if cw.err != nil {
+		return cw.err
+	}
+	if cw.c != nil {
+		if err := cw.c.Flush(); err != nil {
+			cw.err = err
+			return err
+		}
+	}
+	return NewResponseController(cw.rw).Flush()
+}
+
+// Unwrap returns the underlying ResponseWriter, for ResponseController.
+func (cw *compressWriter) Unwrap() ResponseWriter {
+	return cw.rw
+}
+
+// finish completes the response after the handler returns.
+func (cw *compressWriter) finish() {
+	if !cw.wroteHeader {
+		// The handler wrote nothing.
+		addVary(cw.rw.Header(), "Accept-Encoding")
+		return
+	}
+	if !cw.decided {
+		cw.decide(true, false)
+	}
+	if cw.c != nil {
+		if err := cw.c.Close(); err == nil {
+			putCompressor(cw.c)
+		}
+		cw.c = nil
+	}
+}
+
+// decide decides whether to compress the response,
+// writes the header, and writes any buffered data.
+// If final is true, the handler has written the whole body.
+// If flushing is true, the handler is flushing the response.
+func (cw *compressWriter) decide(final, flushing bool) {
+	cw.decided = true
+	h := cw.rw.Header()
+	addVary(h, "Accept-Encoding")
+	if cw.shouldCompress(final, flushing) {
+		h.Del("Content-Length")
+		h.Del("Accept-Ranges")
+		h.Set("Content-Encoding", cw.encoding)
+		cw.suffixETag(h)
+		cw.c = getCompressor(cw.encoding, cw.rw)
+	} else if cw.status == StatusNotModified && cw.etagSuffixed {
+		// The request matched the ETag of the compressed response.
+		cw.suffixETag(h)
+	}
+	cw.rw.WriteHeader(cw.status)
+	if len(cw.buf) == 0 {
+		return
+	}
+	var err error
+	if cw.c != nil {
+		_, err = cw.c.Write(cw.buf)
+	} else {
+		_, err = cw.rw.Write(cw.buf)
+	}
+	if err != nil {
+		cw.err = err
+	}
+	cw.buf = nil
+}
+
+func (cw *compressWriter) shouldCompress(final, flushing bool) bool {
+	if !bodyAllowedForStatus(cw.status) || cw.status == StatusPartialContent {
+		return false
+	}
+	h := cw.rw.Header()
+	if h.has("Content-Encoding") || h.has("Content-Range") {
+		return false
+	}
+	for _, v := range h["Cache-Control"] {
+		for _, directive := range strings.Split(v, ",") {
+			if ascii.EqualFold(textproto.TrimString(directive), "no-transform") {
+				return false
+			}
+		}
+	}
+	if _, haveType := h["Content-Type"]; !haveType && len(cw.buf) > 0 {
+		// Set the Content-Type as the server would.
+		h.Set("Content-Type", DetectContentType(cw.buf))
+	}
+	if !compressibleContentType(h.Get("Content-Type")) {
+		return false
+	}
+	if cl := h.get("Content-Length"); cl != "" {
+		if n, err := strconv.ParseInt(cl, 10, 64); err == nil && n < compressMinSize {
+			return false
+		}
+	}
+	if final && len(cw.buf) < compressMinSize {
+		return false
+	}
+	return len(cw.buf) > 0 || flushing
+}
+
+// suffixETag adds the encoding's suffix to a strong ETag in h.
+func (cw *compressWriter) suffixETag(h Header) {
+	etag := h.get("Etag")
+	if len(etag) < 2 || etag[0] != '"' || etag[len(etag)-1] != '"' {
+		return
+	}
+	h.Set("Etag", etag[:len(etag)-1]+"-"+cw.encoding+`"`)
+}
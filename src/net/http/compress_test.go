@@ -0,0 +1,361 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http_test
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"internal/zstd"
+	"io"
+	. "net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	for _, test := range []struct {
+		accept []string
+		want   string
+	}{
+		{nil, ""},
+		{[]string{""}, ""},
+		{[]string{"gzip"}, "gzip"},
+		{[]string{"GZIP"}, "gzip"},
+		{[]string{"x-gzip"}, "gzip"},
+		{[]string{"zstd"}, "zstd"},
+		{[]string{"br"}, ""},
+		{[]string{"identity"}, ""},
+		{[]string{"gzip, zstd"}, "gzip"},
+		{[]string{"zstd, gzip"}, "gzip"},
+		{[]string{"gzip;q=0.5, zstd"}, "zstd"},
+		{[]string{"gzip", "zstd;q=0.9"}, "gzip"},
+		{[]string{"gzip;q=0"}, ""},
+		{[]string{"gzip;q=0, zstd;q=0.1"}, "zstd"},
+		{[]string{"*"}, "gzip"},
+		{[]string{"*;q=0.5, zstd"}, "zstd"},
+		{[]string{"*, gzip;q=0"}, "zstd"},
+		{[]string{"gzip ; Q=0.2 , zstd;q=0.1"}, "gzip"},
+		{[]string{"gzip;q=2"}, "gzip"},
+	} {
+		if got := ExportNegotiateEncoding(test.accept); got != test.want {
+			t.Errorf("negotiateEncoding(%q) = %q, want %q", test.accept, got, test.want)
+		}
+	}
+}
+
+// decompress returns the body of rec, decoded according
+// to its Content-Encoding.
+func decompress(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+	var r io.Reader = rec.Body
+	switch enc := rec.Header().Get("Content-Encoding"); enc {
+	case "", "identity":
+	case "gzip":
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			t.Fatalf("gzip.NewReader: %v", err)
+		}
+		r = zr
+	case "zstd":
+		r = zstd.NewReader(r)
+	default:
+		t.Fatalf("unexpected Content-Encoding %q", enc)
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("decompressing %v body: %v", rec.Header().Get("Content-Encoding"), err)
+	}
+	return string(b)
+}
+
+func TestCompressHandler(t *testing.T) {
+	text := strings.Repeat("The quick brown fox jumps over the lazy dog.\n", 100)
+	png := "\x89PNG\x0D\x0A\x1A\x0A" + text
+	for _, test := range []struct {
+		name     string
+		method   string
+		header   Header // request header
+		handler  func(w ResponseWriter, r *Request)
+		wantEnc  string
+		wantCode int
+	}{{
+		name:    "gzip",
+		header:  Header{"Accept-Encoding": {"gzip"}},
+		handler: func(w ResponseWriter, r *Request) { io.WriteString(w, text) },
+		wantEnc: "gzip",
+	}, {
+		name:    "zstd",
+		header:  Header{"Accept-Encoding": {"zstd"}},
+		handler: func(w ResponseWriter, r *Request) { io.WriteString(w, text) },
+		wantEnc: "zstd",
+	}, {
+		name:    "no Accept-Encoding",
+		handler: func(w ResponseWriter, r *Request) { io.WriteString(w, text) },
+	}, {
+		name:   "small writes",
+		header: Header{"Accept-Encoding": {"gzip"}},
+		handler: func(w ResponseWriter, r *Request) {
+			for _, line := range strings.SplitAfter(text, "\n") {
+				io.WriteString(w, line)
+			}
+		},
+		wantEnc: "gzip",
+	}, {
+		name:    "small body",
+		header:  Header{"Accept-Encoding": {"gzip"}},
+		handler: func(w ResponseWriter, r *Request) { io.WriteString(w, "hello") },
+	}, {
+		name:   "small Content-Length",
+		header: Header{"Accept-Encoding": {"gzip"}},
+		handler: func(w ResponseWriter, r *Request) {
+			w.Header().Set("Content-Length", "10")
+			io.WriteString(w, text[:10])
+		},
+	}, {
+		name:    "sniffed image",
+		header:  Header{"Accept-Encoding": {"gzip"}},
+		handler: func(w ResponseWriter, r *Request) { io.WriteString(w, png) },
+	}, {
+		name:   "JSON",
+		header: Header{"Accept-Encoding": {"gzip"}},
+		handler: func(w ResponseWriter, r *Request) {
+			w.Header().Set("Content-Type", "application/problem+json; charset=utf-8")
+			io.WriteString(w, text)
+		},
+		wantEnc: "gzip",
+	}, {
+		name:   "video",
+		header: Header{"Accept-Encoding": {"gzip"}},
+		handler: func(w ResponseWriter, r *Request) {
+			w.Header().Set("Content-Type", "video/mp4")
+			io.WriteString(w, text)
+		},
+	}, {
+		name:   "already encoded",
+		header: Header{"Accept-Encoding": {"gzip"}},
+		handler: func(w ResponseWriter, r *Request) {
+			w.Header().Set("Content-Encoding", "identity")
+			io.WriteString(w, text)
+		},
+		wantEnc: "identity",
+	}, {
+		name:   "no-transform",
+		header: Header{"Accept-Encoding": {"gzip"}},
+		handler: func(w ResponseWriter, r *Request) {
+			w.Header().Set("Cache-Control", "public, no-transform")
+			io.WriteString(w, text)
+		},
+	}, {
+		name:   "partial content",
+		header: Header{"Accept-Encoding": {"gzip"}},
+		handler: func(w ResponseWriter, r *Request) {
+			w.Header().Set("Content-Range", "bytes 0-99/1000")
+			w.WriteHeader(StatusPartialContent)
+			io.WriteString(w, text)
+		},
+		wantCode: StatusPartialContent,
+	}, {
+		name:   "error status",
+		header: Header{"Accept-Encoding": {"gzip"}},
+		handler: func(w ResponseWriter, r *Request) {
+			w.WriteHeader(StatusInternalServerError)
+			io.WriteString(w, text)
+		},
+		wantEnc:  "gzip",
+		wantCode: StatusInternalServerError,
+	}, {
+		name:     "no body",
+		header:   Header{"Accept-Encoding": {"gzip"}},
+		handler:  func(w ResponseWriter, r *Request) { w.WriteHeader(StatusNoContent) },
+		wantCode: StatusNoContent,
+	}, {
+		name:   "CONNECT",
+		method: "CONNECT",
+		header: Header{"Accept-Encoding": {"gzip"}},
+		handler: func(w ResponseWriter, r *Request) {
+			io.WriteString(w, text)
+		},
+	}} {
+		t.Run(test.name, func(t *testing.T) {
+			method := test.method
+			if method == "" {
+				method = "GET"
+			}
+			req := httptest.NewRequest(method, "/", nil)
+			req.Header = test.header
+			if req.Header == nil {
+				req.Header = Header{}
+			}
+			rec := httptest.NewRecorder()
+			CompressHandler(HandlerFunc(test.handler)).ServeHTTP(rec, req)
+
+			wantCode := test.wantCode
+			if wantCode == 0 {
+				wantCode = StatusOK
+			}
+			if rec.Code != wantCode {
+				t.Errorf("status = %v, want %v", rec.Code, wantCode)
+			}
+			if got := rec.Header().Get("Content-Encoding"); got != test.wantEnc {
+				t.Errorf("Content-Encoding = %q, want %q", got, test.wantEnc)
+			}
+			if test.wantEnc == "gzip" || test.wantEnc == "zstd" {
+				if rec.Body.Len() >= len(text) {
+					t.Errorf("compressed body is %v bytes, want less than %v", rec.Body.Len(), len(text))
+				}
+				if cl := rec.Header().Get("Content-Length"); cl != "" {
+					t.Errorf("compressed response has Content-Length %q", cl)
+				}
+			}
+			if method != "CONNECT" {
+				if got := rec.Header().Get("Vary"); got != "Accept-Encoding" {
+					t.Errorf("Vary = %q, want %q", got, "Accept-Encoding")
+				}
+			}
+			// Check the body against the handler's output.
+			want := httptest.NewRecorder()
+			test.handler(want, req)
+			if body := decompress(t, rec); body != want.Body.String() {
+				t.Errorf("body differs from the handler's output")
+			}
+		})
+	}
+}
+
+func TestCompressHandlerETag(t *testing.T) {
+	text := strings.Repeat("The quick brown fox jumps over the lazy dog.\n", 100)
+	h := CompressHandler(HandlerFunc(func(w ResponseWriter, r *Request) {
+		ServeContent(w, r, "fox.txt", time.Time{}, strings.NewReader(text))
+	}))
+	etag := `"fox"`
+	serve := func(header Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header = header
+		rec := httptest.NewRecorder()
+		HandlerFunc(func(w ResponseWriter, r *Request) {
+			w.Header().Set("Etag", etag)
+			h.ServeHTTP(w, r)
+		}).ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve(Header{"Accept-Encoding": {"zstd"}})
+	if got, want := rec.Header().Get("Etag"), `"fox-zstd"`; got != want {
+		t.Errorf("compressed response Etag = %q, want %q", got, want)
+	}
+	if got := rec.Header().Get("Accept-Ranges"); got != "" {
+		t.Errorf("compressed response Accept-Ranges = %q, want none", got)
+	}
+
+	rec = serve(Header{"Accept-Encoding": {"zstd"}, "If-None-Match": {`"other", "fox-zstd"`}})
+	if rec.Code != StatusNotModified {
+		t.Fatalf("If-None-Match with compressed ETag: status %v, want 304", rec.Code)
+	}
+	if got, want := rec.Header().Get("Etag"), `"fox-zstd"`; got != want {
+		t.Errorf("304 response Etag = %q, want %q", got, want)
+	}
+	if got := rec.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf("304 response Content-Encoding = %q, want none", got)
+	}
+
+	rec = serve(Header{"Accept-Encoding": {"zstd"}, "If-None-Match": {`"fox-gzip"`}})
+	if rec.Code != StatusOK {
+		t.Errorf("If-None-Match with ETag of another encoding: status %v, want 200", rec.Code)
+	}
+
+	rec = serve(Header{"Accept-Encoding": {"gzip"}, "If-Match": {`"fox-gzip"`}})
+	if rec.Code != StatusOK || rec.Header().Get("Etag") != `"fox-gzip"` {
+		t.Errorf("If-Match with compressed ETag: status %v, Etag %q; want 200, %q", rec.Code, rec.Header().Get("Etag"), `"fox-gzip"`)
+	}
+	if body := decompress(t, rec); body != text {
+		t.Errorf("body differs from the handler's output")
+	}
+
+	rec = serve(Header{"Accept-Encoding": {"gzip"}, "If-Match": {`"fox-zstd"`}})
+	if rec.Code != StatusPreconditionFailed {
+		t.Errorf("If-Match with ETag of another encoding: status %v, want 412", rec.Code)
+	}
+
+	rec = serve(Header{"Range": {"bytes=0-9"}})
+	if rec.Code != StatusPartialContent || rec.Body.String() != text[:10] {
+		t.Errorf("uncompressed range request: status %v, body %q; want 206, %q", rec.Code, rec.Body, text[:10])
+	}
+}
+
+func TestCompressHandlerFlush(t *testing.T) { run(t, testCompressHandlerFlush) }
+func testCompressHandlerFlush(t *testing.T, mode testMode) {
+	lines := make(chan string)
+	cst := newClientServerTest(t, mode, CompressHandler(HandlerFunc(func(w ResponseWriter, r *Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.(Flusher).Flush() // send the header before any data
+		for line := range lines {
+			if line == "" {
+				return
+			}
+			io.WriteString(w, line)
+			if err := NewResponseController(w).Flush(); err != nil {
+				t.Errorf("Flush: %v", err)
+			}
+		}
+	})))
+	for _, enc := range []string{"gzip", "zstd"} {
+		req, _ := NewRequest("GET", cst.ts.URL, nil)
+		req.Header.Set("Accept-Encoding", enc)
+		res, err := cst.c.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := res.Header.Get("Content-Encoding"); got != enc {
+			t.Errorf("Content-Encoding = %q, want %q", got, enc)
+		}
+		var br *bufio.Reader
+		for i := range 3 {
+			line := strings.Repeat("x", i) + "data\n"
+			lines <- line
+			if br == nil {
+				var r io.Reader
+				if enc == "gzip" {
+					r, err = gzip.NewReader(res.Body)
+					if err != nil {
+						t.Fatal(err)
+					}
+				} else {
+					r = zstd.NewReader(res.Body)
+				}
+				br = bufio.NewReader(r)
+			}
+			got, err := br.ReadString('\n')
+			if err != nil || got != line {
+				t.Fatalf("%v: read %q, %v; want flushed line %q", enc, got, err, line)
+			}
+		}
+		lines <- ""
+		if rest, err := io.ReadAll(br); err != nil || len(rest) != 0 {
+			t.Errorf("%v: read %q, %v at end of body; want EOF", enc, rest, err)
+		}
+		res.Body.Close()
+	}
+}
+
+func BenchmarkCompressHandler(b *testing.B) {
+	text := bytes.Repeat([]byte("The quick brown fox jumps over the lazy dog.\n"), 1000)
+	for _, enc := range []string{"gzip", "zstd"} {
+		b.Run(enc, func(b *testing.B) {
+			h := CompressHandler(HandlerFunc(func(w ResponseWriter, r *Request) {
+				w.Write(text)
+			}))
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("Accept-Encoding", enc)
+			b.SetBytes(int64(len(text)))
+			b.ReportAllocs()
+			for range b.N {
+				h.ServeHTTP(httptest.NewRecorder(), req)
+			}
+		})
+	}
+}
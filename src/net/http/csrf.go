@@ -0,0 +1,207 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Cross-origin request protection.
+
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"sync/atomic"
+)
+
+// CrossOriginProtection implements protections against Cross-Site Request
+// Forgery (CSRF) by rejecting non-safe cross-origin browser requests.
+//
+// Cross-origin requests are currently detected with the Sec-Fetch-Site
+// header, available in all browsers since 2023, or by comparing the
+// hostname of the Origin header with the Host header.
+//
+// The GET, HEAD, and OPTIONS methods are safe methods and are always
+// allowed. It's important that applications do not perform any state
+// changing actions due to requests with safe methods.
+//
+// Requests without Sec-Fetch-Site or Origin headers are currently assumed
+// to be either same-origin or non-browser requests, and are allowed.
+//
+// The zero value of CrossOriginProtection is not usable; create one with
+// [NewCrossOriginProtection]. Its methods may be called concurrently.
+type CrossOriginProtection struct {
+	bypass *ServeMux
+
+	mu      sync.Mutex // serializes updates to trusted
+	trusted atomic.Pointer[map[string]bool]
+	deny    atomic.Pointer[Handler]
+}
+
+// NewCrossOriginProtection returns a new [CrossOriginProtection] value.
+func NewCrossOriginProtection() *CrossOriginProtection {
+	return &CrossOriginProtection{bypass: NewServeMux()}
+}
+
+// AddTrustedOrigin allows all requests with an [Origin] header
+// which exactly matches the given value.
+//
+// Origin header values are of the form "scheme://host[:port]".
+//
+// AddTrustedOrigin returns an error if the origin is not of that form.
+//
+// [Origin]: https://developer.mozilla.org/en-US/docs/Web/HTTP/Headers/Origin
+func (c *CrossOriginProtection) AddTrustedOrigin(origin string) error {
+	u, err := url.Parse(origin)
+	if err != nil {
+		return fmt.Errorf("invalid origin %q: %w", origin, err)
+	}
+	if u.Scheme == "" {
+		return fmt.Errorf("invalid origin %q: scheme is required", origin)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("invalid origin %q: host is required", origin)
+	}
+	if u.Path != "" || u.RawQuery != "" || u.Fragment != "" || u.User != nil || u.ForceQuery {
+		return fmt.Errorf("invalid origin %q: only scheme, host, and port are allowed", origin)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	trusted := make(map[string]bool)
+	if old := c.trusted.Load(); old != nil {
+		for k := range *old {
+			trusted[k] = true
+		}
+	}
+	trusted[origin] = true
+	c.trusted.Store(&trusted)
+	return nil
+}
+
+// AddInsecureBypassPattern permits all requests that match the given
+// pattern. The pattern syntax and precedence rules are the same as
+// [ServeMux].
+//
+// AddInsecureBypassPattern panics if the pattern is invalid or conflicts
+// with a pattern already added.
+//
+// AddInsecureBypassPattern can be used to exempt specific endpoints, such
+// as webhooks that receive requests from other origins, from protection.
+// The handlers for such endpoints must be safe against cross-origin
+// requests by other means.
+func (c *CrossOriginProtection) AddInsecureBypassPattern(pattern string) {
+	c.bypass.Handle(pattern, bypassHandler{})
+}
+
+// bypassHandler is the handler of bypass patterns.
+// It distinguishes them from the redirects that a ServeMux
+// returns for requests which do not match a pattern exactly.
+type bypassHandler struct{}
+
+func (bypassHandler) ServeHTTP(w ResponseWriter, r *Request) { NotFound(w, r) }
+
+func isBypassHandler(h Handler) bool {
+	_, ok := h.(bypassHandler)
+	return ok
+}
+
+// SetDenyHandler sets a handler to invoke when a request is rejected.
+// The default error handler responds with a 403 Forbidden status.
+//
+// Check does not call the error handler.
+func (c *CrossOriginProtection) SetDenyHandler(h Handler) {
+	if h == nil {
+		c.deny.Store(nil)
+		return
+	}
+	c.deny.Store(&h)
+}
+
+var (
+	errCrossOriginRequest = errors.New("cross-origin request detected from Sec-Fetch-Site header")
+
+	errCrossOriginRequestFromOldBrowser = errors.New("cross-origin request detected, and/or browser is out of date: " +
+		"Sec-Fetch-Site is missing, and Origin does not match Host")
+)
+
+// Check applies cross-origin checks to a request.
+// It returns an error if the request should be rejected.
+func (c *CrossOriginProtection) Check(req *Request) error {
+	switch req.Method {
+	case "GET", "HEAD", "OPTIONS":
+		// Safe methods are always allowed.
+		return nil
+	}
+
+	switch req.Header.Get("Sec-Fetch-Site") {
+	case "":
+		// No Sec-Fetch-Site header is present.
+		// Fallthrough to check the Origin header.
+	case "same-origin", "none":
+		return nil
+	default:
+		if c.isRequestExempt(req) {
+			return nil
+		}
+		return errCrossOriginRequest
+	}
+
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		// Neither Sec-Fetch-Site nor Origin headers are present.
+		// Either the request is same-origin or not a browser request.
+		return nil
+	}
+
+	if o, err := url.Parse(origin); err == nil && o.Host == req.Host {
+		// The Origin header matches the Host header. Note that the Host
+		// header doesn't include the scheme, so we don't know if this
+		// might be an HTTP→HTTPS cross-origin request. We fail open,
+		// since all modern browsers support Sec-Fetch-Site since 2023,
+		// and running an older browser makes a clear security trade-off.
+		// Older browsers can mitigate this attack with HSTS.
+		return nil
+	}
+
+	if c.isRequestExempt(req) {
+		return nil
+	}
+	return errCrossOriginRequestFromOldBrowser
+}
+
+// isRequestExempt checks the bypasses which require taking a lock, and
+// should be deferred until the last moment.
+func (c *CrossOriginProtection) isRequestExempt(req *Request) bool {
+	if h, _ := c.bypass.Handler(req); isBypassHandler(h) {
+		// The request matches a bypass pattern.
+		return true
+	}
+
+	trusted := c.trusted.Load()
+	if trusted == nil {
+		return false
+	}
+	origin := req.Header.Get("Origin")
+	// The request matches a trusted origin.
+	return origin != "" && (*trusted)[origin]
+}
+
+// Handler returns a handler that applies cross-origin checks
+// before invoking the handler h.
+//
+// If a request fails cross-origin checks, the request is rejected
+// with a 403 Forbidden status or handled with the handler passed
+// to [CrossOriginProtection.SetDenyHandler].
+func (c *CrossOriginProtection) Handler(h Handler) Handler {
+	return HandlerFunc(func(w ResponseWriter, r *Request) {
+		if err := c.Check(r); err != nil {
+			if deny := c.deny.Load(); deny != nil {
+				(*deny).ServeHTTP(w, r)
+				return
+			}
+			Error(w, err.Error(), StatusForbidden)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,152 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http_test
+
+import (
+	"io"
+	. "net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// crossOriginStatus sends a request with the given method, path, and
+// headers to a handler protected by p, and returns the response status.
+func crossOriginStatus(p *CrossOriginProtection, method, target string, header map[string]string) int {
+	h := p.Handler(HandlerFunc(func(w ResponseWriter, r *Request) {
+		io.WriteString(w, "OK")
+	}))
+	req := httptest.NewRequest(method, target, nil)
+	req.Host = "example.com"
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestCrossOriginProtection(t *testing.T) {
+	p := NewCrossOriginProtection()
+	for _, test := range []struct {
+		name   string
+		method string
+		header map[string]string
+		want   int
+	}{
+		{"same-origin", "POST", map[string]string{"Sec-Fetch-Site": "same-origin"}, 200},
+		{"none", "POST", map[string]string{"Sec-Fetch-Site": "none"}, 200},
+		{"cross-site", "POST", map[string]string{"Sec-Fetch-Site": "cross-site"}, 403},
+		{"same-site", "POST", map[string]string{"Sec-Fetch-Site": "same-site"}, 403},
+		{"cross-site GET", "GET", map[string]string{"Sec-Fetch-Site": "cross-site"}, 200},
+		{"cross-site HEAD", "HEAD", map[string]string{"Sec-Fetch-Site": "cross-site"}, 200},
+		{"cross-site OPTIONS", "OPTIONS", map[string]string{"Sec-Fetch-Site": "cross-site"}, 200},
+		{"cross-site PUT", "PUT", map[string]string{"Sec-Fetch-Site": "cross-site"}, 403},
+		{"no headers", "POST", nil, 200},
+		{"Origin matches Host", "POST", map[string]string{"Origin": "https://example.com"}, 200},
+		{"Origin mismatch", "POST", map[string]string{"Origin": "https://attacker.example"}, 403},
+		{"Origin port mismatch", "POST", map[string]string{"Origin": "https://example.com:8443"}, 403},
+		{"null Origin", "POST", map[string]string{"Origin": "null"}, 403},
+		{"Sec-Fetch-Site takes precedence", "POST", map[string]string{
+			"Sec-Fetch-Site": "cross-site",
+			"Origin":         "https://example.com",
+		}, 403},
+	} {
+		if got := crossOriginStatus(p, test.method, "/", test.header); got != test.want {
+			t.Errorf("%s: status %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestCrossOriginProtectionTrustedOrigin(t *testing.T) {
+	p := NewCrossOriginProtection()
+	if err := p.AddTrustedOrigin("https://trusted.example"); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		header map[string]string
+		want   int
+	}{
+		{map[string]string{"Origin": "https://trusted.example", "Sec-Fetch-Site": "cross-site"}, 200},
+		{map[string]string{"Origin": "https://trusted.example"}, 200},
+		{map[string]string{"Origin": "http://trusted.example", "Sec-Fetch-Site": "cross-site"}, 403},
+		{map[string]string{"Origin": "https://trusted.example:443", "Sec-Fetch-Site": "cross-site"}, 403},
+		{map[string]string{"Origin": "https://other.example", "Sec-Fetch-Site": "cross-site"}, 403},
+	} {
+		if got := crossOriginStatus(p, "POST", "/", test.header); got != test.want {
+			t.Errorf("headers %v: status %v, want %v", test.header, got, test.want)
+		}
+	}
+
+	for _, origin := range []string{
+		"",
+		"trusted.example",
+		"https://",
+		"https://trusted.example/",
+		"https://trusted.example/path",
+		"https://trusted.example?q",
+		"https://trusted.example#frag",
+		"https://user@trusted.example",
+		"https://trusted.example\x7f",
+	} {
+		if err := p.AddTrustedOrigin(origin); err == nil {
+			t.Errorf("AddTrustedOrigin(%q) succeeded, want error", origin)
+		}
+	}
+}
+
+func TestCrossOriginProtectionBypassPattern(t *testing.T) {
+	p := NewCrossOriginProtection()
+	p.AddInsecureBypassPattern("/hooks/")
+	p.AddInsecureBypassPattern("POST /api/{id}/notify")
+	crossSite := map[string]string{"Sec-Fetch-Site": "cross-site"}
+	for _, test := range []struct {
+		method, target string
+		want           int
+	}{
+		{"POST", "/hooks/", 200},
+		{"POST", "/hooks/github", 200},
+		{"POST", "/api/1/notify", 200},
+		{"PUT", "/api/1/notify", 403},
+		{"POST", "/api/1/other", 403},
+		{"POST", "/", 403},
+		// A request which the bypass patterns would redirect
+		// does not match them.
+		{"POST", "/hooks", 403},
+		{"POST", "/hooks/../admin", 403},
+	} {
+		if got := crossOriginStatus(p, test.method, test.target, crossSite); got != test.want {
+			t.Errorf("%v %v: status %v, want %v", test.method, test.target, got, test.want)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("AddInsecureBypassPattern with a conflicting pattern did not panic")
+		}
+	}()
+	p.AddInsecureBypassPattern("/hooks/")
+}
+
+func TestCrossOriginProtectionDenyHandler(t *testing.T) {
+	p := NewCrossOriginProtection()
+	p.SetDenyHandler(HandlerFunc(func(w ResponseWriter, r *Request) {
+		w.WriteHeader(StatusTeapot)
+	}))
+	crossSite := map[string]string{"Sec-Fetch-Site": "cross-site"}
+	if got := crossOriginStatus(p, "POST", "/", crossSite); got != StatusTeapot {
+		t.Errorf("with deny handler: status %v, want %v", got, StatusTeapot)
+	}
+	p.SetDenyHandler(nil)
+	if got := crossOriginStatus(p, "POST", "/", crossSite); got != StatusForbidden {
+		t.Errorf("after removing deny handler: status %v, want %v", got, StatusForbidden)
+	}
+
+	req := httptest.NewRequest("POST", "/", nil)
+	req.Header.Set("Sec-Fetch-Site", "cross-site")
+	if err := p.Check(req); err == nil || !strings.Contains(err.Error(), "cross-origin") {
+		t.Errorf("Check of cross-site request = %v, want cross-origin error", err)
+	}
+}
@@ -91,10 +91,16 @@ can do so by setting [Transport.TLSNextProto] (for clients) or
 map. Alternatively, the following GODEBUG settings are
 currently supported:
 
-	GODEBUG=http2client=0  # disable HTTP/2 client support
-	GODEBUG=http2server=0  # disable HTTP/2 server support
-	GODEBUG=http2debug=1   # enable verbose HTTP/2 debug logs
-	GODEBUG=http2debug=2   # ... even more verbose, with frame dumps
+	GODEBUG=http2client=0    # disable HTTP/2 client support
+	GODEBUG=http2server=0    # disable HTTP/2 server support
+	GODEBUG=http2debug=1     # enable verbose HTTP/2 debug logs
+	GODEBUG=http2debug=2     # ... even more verbose, with frame dumps
+	GODEBUG=http2xconnect=1  # enable extended CONNECT in the HTTP/2 server
+
+The HTTP/2 server does not accept extended CONNECT requests (RFC 8441),
+which carry WebSocket connections over HTTP/2, unless the http2xconnect
+setting is enabled. As with other GODEBUG settings, a program can enable
+it with a //go:debug http2xconnect=1 directive in its main package.
 
 Please report any issues before disabling HTTP/2 support: https://golang.org/s/http2bug
 
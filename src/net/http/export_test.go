@@ -30,6 +30,7 @@ var (
 	ExportErrServerClosedIdle         = errServerClosedIdle
 	ExportServeFile                   = serveFile
 	ExportScanETag                    = scanETag
+	ExportNegotiateEncoding           = negotiateEncoding
 	ExportHttp2ConfigureServer        = http2ConfigureServer
 	Export_shouldCopyHeaderOnRedirect = shouldCopyHeaderOnRedirect
 	Export_writeStatusLine            = writeStatusLine
@@ -341,3 +342,49 @@ func SetRSTAvoidanceDelay(t *testing.T, d time.Duration) {
 	})
 	rstAvoidanceDelay = d
 }
+
+// ExportHTTP3Connect records altAddr as the HTTP/3 alternative service of
+// the origin of rawURL, as if advertised with an Alt-Svc header, and waits
+// for t to connect to it.
+func ExportHTTP3Connect(t *Transport, rawURL, altAddr string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	key := canonicalAddr(u)
+	t.EnableHTTP3()
+	h3 := t.h3.(*http3Transport)
+	h3.mu.Lock()
+	o := &http3Origin{altAddr: altAddr, expires: time.Now().Add(time.Hour)}
+	if h3.origins == nil {
+		h3.origins = make(map[string]*http3Origin)
+	}
+	h3.origins[key] = o
+	h3.dialLocked(t, key, o)
+	h3.mu.Unlock()
+	for {
+		h3.mu.Lock()
+		cc, dialing := o.cc, o.dialing
+		h3.mu.Unlock()
+		if cc != nil {
+			return nil
+		}
+		if !dialing {
+			return fmt.Errorf("HTTP/3 connection to %v failed", altAddr)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// ExportTransportUsesHTTP3 reports whether HTTP/3 is enabled for t.
+func ExportTransportUsesHTTP3(t *Transport) bool {
+	return t.h3 != nil
+}
+
+// ExportHTTP3AltSvc returns the Alt-Svc header value advertising HTTP/3 by s.
+func ExportHTTP3AltSvc(s *Server) string {
+	if v := s.http3AltSvc.Load(); v != nil {
+		return *v
+	}
+	return ""
+}
@@ -0,0 +1,584 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// HTTP/3 framing, shared by the HTTP/3 server and client.
+// See RFC 9114.
+
+package http
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http/internal/ascii"
+	"net/http/internal/qpack"
+	"net/http/internal/quic"
+	"net/textproto"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/http/httpguts"
+)
+
+// http3NextProto is the ALPN protocol ID of HTTP/3.
+const http3NextProto = "h3"
+
+// HTTP/3 frame types (RFC 9114, Section 7.2).
+const (
+	http3FrameData        = 0x00
+	http3FrameHeaders     = 0x01
+	http3FrameCancelPush  = 0x03
+	http3FrameSettings    = 0x04
+	http3FramePushPromise = 0x05
+	http3FrameGoAway      = 0x07
+	http3FrameMaxPushID   = 0x0d
+)
+
+// HTTP/3 unidirectional stream types (RFC 9114, Section 6.2, and
+// RFC 9204, Section 4.2).
+const (
+	http3StreamControl      = 0x00
+	http3StreamPush         = 0x01
+	http3StreamQPACKEncoder = 0x02
+	http3StreamQPACKDecoder = 0x03
+)
+
+// HTTP/3 settings (RFC 9114, Section 7.2.4.1, and RFC 9204, Section 5).
+const (
+	http3SettingQPACKMaxTableCapacity = 0x01
+	http3SettingMaxFieldSectionSize   = 0x06
+	http3SettingQPACKBlockedStreams   = 0x07
+)
+
+// An http3ErrCode is an HTTP/3 error code (RFC 9114, Section 8.1).
+type http3ErrCode uint64
+
+const (
+	http3ErrNoError              http3ErrCode = 0x100
+	http3ErrGeneralProtocolError http3ErrCode = 0x101
+	http3ErrInternalError        http3ErrCode = 0x102
+	http3ErrStreamCreationError  http3ErrCode = 0x103
+	http3ErrClosedCriticalStream http3ErrCode = 0x104
+	http3ErrFrameUnexpected      http3ErrCode = 0x105
+	http3ErrFrameError           http3ErrCode = 0x106
+	http3ErrExcessiveLoad        http3ErrCode = 0x107
+	http3ErrIDError              http3ErrCode = 0x108
+	http3ErrSettingsError        http3ErrCode = 0x109
+	http3ErrMissingSettings      http3ErrCode = 0x10a
+	http3ErrRequestRejected      http3ErrCode = 0x10b
+	http3ErrRequestCancelled     http3ErrCode = 0x10c
+	http3ErrRequestIncomplete    http3ErrCode = 0x10d
+	http3ErrMessageError         http3ErrCode = 0x10e
+	http3ErrConnectError         http3ErrCode = 0x10f
+	http3ErrVersionFallback      http3ErrCode = 0x110
+
+	http3ErrQPACKDecompressionFailed http3ErrCode = 0x200 // RFC 9204, Section 6
+)
+
+// An http3Error is an error of the HTTP/3 protocol, which aborts a stream
+// or, if conn is set, the connection.
+type http3Error struct {
+	code http3ErrCode
+	conn bool
+	msg  string
+}
+
+func (e *http3Error) Error() string {
+	return fmt.Sprintf("http3: %s (error %#x)", e.msg, uint64(e.code))
+}
+
+func http3ConnError(code http3ErrCode, msg string) error {
+	return &http3Error{code: code, conn: true, msg: msg}
+}
+
+func http3StreamError(code http3ErrCode, msg string) error {
+	return &http3Error{code: code, msg: msg}
+}
+
+// http3AbortConn closes the connection qc if err is a connection error.
+func http3AbortConn(qc *quic.Conn, err error) {
+	var he *http3Error
+	if errors.As(err, &he) && he.conn {
+		qc.CloseWithError(uint64(he.code), he.msg)
+	}
+}
+
+// http3AppendFrame appends a frame with the given type and payload to b.
+func http3AppendFrame(b []byte, typ uint64, payload []byte) []byte {
+	b = quic.AppendVarint(b, typ)
+	b = quic.AppendVarint(b, uint64(len(payload)))
+	return append(b, payload...)
+}
+
+// http3AppendSettings appends the control stream type and a SETTINGS frame
+// with the settings of net/http to b.
+func http3AppendSettings(b []byte, maxFieldSectionSize int64) []byte {
+	b = quic.AppendVarint(b, http3StreamControl)
+	var p []byte
+	p = quic.AppendVarint(p, http3SettingMaxFieldSectionSize)
+	p = quic.AppendVarint(p, uint64(maxFieldSectionSize))
+	return http3AppendFrame(b, http3FrameSettings, p)
+}
+
+// An http3Settings holds the settings of the peer.
+type http3Settings struct {
+	maxFieldSectionSize int64 // -1 if unlimited
+}
+
+func parseHTTP3Settings(p []byte) (http3Settings, error) {
+	s := http3Settings{maxFieldSectionSize: -1}
+	seen := make(map[uint64]bool)
+	for len(p) > 0 {
+		id, n := quic.ConsumeVarint(p)
+		if n < 0 {
+			return s, http3ConnError(http3ErrFrameError, "malformed SETTINGS")
+		}
+		p = p[n:]
+		v, n := quic.ConsumeVarint(p)
+		if n < 0 {
+			return s, http3ConnError(http3ErrFrameError, "malformed SETTINGS")
+		}
+		p = p[n:]
+		if seen[id] {
+			return s, http3ConnError(http3ErrSettingsError, "duplicate setting")
+		}
+		seen[id] = true
+		switch id {
+		case 0x02, 0x03, 0x04, 0x05:
+			// HTTP/2 settings (RFC 9114, Section 7.2.4.1).
+			return s, http3ConnError(http3ErrSettingsError, "HTTP/2 setting in SETTINGS")
+		case http3SettingMaxFieldSectionSize:
+			s.maxFieldSectionSize = int64(min(v, 1<<62))
+		}
+	}
+	return s, nil
+}
+
+// http3FrameReader reads the frames of an HTTP/3 stream.
+type http3FrameReader struct {
+	r *bufio.Reader
+}
+
+func newHTTP3FrameReader(r io.Reader) *http3FrameReader {
+	return &http3FrameReader{r: bufio.NewReaderSize(r, 4<<10)}
+}
+
+// readHeader reads the type and length of the next frame. It returns
+// io.EOF at the end of the stream, before any frame.
+func (fr *http3FrameReader) readHeader() (typ, length uint64, err error) {
+	typ, err = quic.ReadVarint(fr.r)
+	if err != nil {
+		return 0, 0, err
+	}
+	length, err = quic.ReadVarint(fr.r)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return typ, length, err
+}
+
+// readPayload reads a frame payload of the given length, which must not
+// exceed max.
+func (fr *http3FrameReader) readPayload(length uint64, max int64) ([]byte, error) {
+	if length > uint64(max) {
+		return nil, http3ConnError(http3ErrExcessiveLoad, "frame too large")
+	}
+	b := make([]byte, length)
+	if _, err := io.ReadFull(fr.r, b); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return b, nil
+}
+
+// discard skips a frame payload of the given length.
+func (fr *http3FrameReader) discard(length uint64) error {
+	_, err := io.CopyN(io.Discard, fr.r, int64(min(length, 1<<62)))
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// http3CheckRequestStreamFrame returns an error for frames not allowed on
+// request streams.
+func http3CheckRequestStreamFrame(typ uint64) error {
+	switch typ {
+	case http3FrameCancelPush, http3FrameSettings, http3FrameGoAway, http3FrameMaxPushID,
+		0x02, 0x06, 0x08, 0x09: // HTTP/2 frame types
+		return http3ConnError(http3ErrFrameUnexpected, fmt.Sprintf("frame type %#x on request stream", typ))
+	case http3FramePushPromise:
+		// Push is never enabled.
+		return http3ConnError(http3ErrIDError, "PUSH_PROMISE without MAX_PUSH_ID")
+	}
+	return nil
+}
+
+// readHeaders reads the next HEADERS frame of a message, skipping unknown
+// frames, and decodes its field section of at most maxSize bytes.
+// It returns io.EOF at the end of the stream.
+func (fr *http3FrameReader) readHeaders(maxSize int64) ([]qpack.HeaderField, error) {
+	for {
+		typ, length, err := fr.readHeader()
+		if err != nil {
+			return nil, err
+		}
+		switch typ {
+		case http3FrameHeaders:
+			if length > uint64(maxSize) {
+				// A field section is smaller than the size
+				// of its fields.
+				fr.discard(length)
+				return nil, errHTTP3HeadersTooLarge
+			}
+			p, err := fr.readPayload(length, maxSize)
+			if err != nil {
+				return nil, err
+			}
+			var fields []qpack.HeaderField
+			err = qpack.ParseFieldSection(p, maxSize, func(f qpack.HeaderField) error {
+				fields = append(fields, f)
+				return nil
+			})
+			if err == qpack.ErrDynamicTable {
+				return nil, http3ConnError(http3ErrQPACKDecompressionFailed, err.Error())
+			}
+			if err != nil {
+				return nil, errHTTP3HeadersTooLarge
+			}
+			return fields, nil
+		case http3FrameData:
+			return nil, http3ConnError(http3ErrFrameUnexpected, "DATA frame before HEADERS")
+		}
+		if err := http3CheckRequestStreamFrame(typ); err != nil {
+			return nil, err
+		}
+		if err := fr.discard(length); err != nil {
+			return nil, err
+		}
+	}
+}
+
+var errHTTP3HeadersTooLarge = http3StreamError(http3ErrExcessiveLoad, "header list too large")
+
+// http3Fields holds the decoded fields of a HEADERS frame.
+type http3Fields struct {
+	pseudo map[string]string // without the leading colon
+	header Header
+}
+
+// parseHTTP3Fields validates and converts fields, whose pseudo-header
+// fields must be among allowedPseudo.
+func parseHTTP3Fields(fields []qpack.HeaderField, allowedPseudo ...string) (http3Fields, error) {
+	f := http3Fields{header: make(Header)}
+	malformed := func(msg string) (http3Fields, error) {
+		return http3Fields{}, http3StreamError(http3ErrMessageError, msg)
+	}
+	for _, hf := range fields {
+		if strings.HasPrefix(hf.Name, ":") {
+			name := hf.Name[1:]
+			if len(f.header) > 0 {
+				return malformed("pseudo-header field after regular field")
+			}
+			if !slices.Contains(allowedPseudo, name) {
+				return malformed("invalid pseudo-header field " + hf.Name)
+			}
+			if f.pseudo == nil {
+				f.pseudo = make(map[string]string)
+			}
+			if _, dup := f.pseudo[name]; dup {
+				return malformed("duplicate pseudo-header field " + hf.Name)
+			}
+			f.pseudo[name] = hf.Value
+			continue
+		}
+		if lower, ok := ascii.ToLower(hf.Name); !ok || lower != hf.Name || !httpguts.ValidHeaderFieldName(hf.Name) {
+			return malformed(fmt.Sprintf("invalid field name %q", hf.Name))
+		}
+		if !httpguts.ValidHeaderFieldValue(hf.Value) {
+			return malformed(fmt.Sprintf("invalid value for field %q", hf.Name))
+		}
+		switch hf.Name {
+		case "connection", "proxy-connection", "keep-alive", "transfer-encoding", "upgrade":
+			return malformed("connection-specific field " + hf.Name)
+		case "te":
+			if hf.Value != "trailers" {
+				return malformed("invalid TE field")
+			}
+		}
+		key := textproto.CanonicalMIMEHeaderKey(hf.Name)
+		f.header[key] = append(f.header[key], hf.Value)
+	}
+	return f, nil
+}
+
+// http3AppendHeader appends the fields of h to fields, lower-casing their
+// names and dropping connection-specific fields and those in skip. It
+// returns an error if strict is set and a field is invalid, and drops it
+// otherwise.
+func http3AppendHeader(fields []qpack.HeaderField, h Header, strict bool, skip ...string) ([]qpack.HeaderField, error) {
+	for k, vv := range h {
+		name, ok := ascii.ToLower(k)
+		if !ok || !httpguts.ValidHeaderFieldName(k) {
+			if strict {
+				return nil, fmt.Errorf("http3: invalid header field name %q", k)
+			}
+			continue
+		}
+		switch name {
+		case "connection", "proxy-connection", "keep-alive", "transfer-encoding", "upgrade", "host":
+			continue
+		}
+		if slices.Contains(skip, name) {
+			continue
+		}
+		for _, v := range vv {
+			if !httpguts.ValidHeaderFieldValue(v) {
+				if strict {
+					return nil, fmt.Errorf("http3: invalid header field value for %q", k)
+				}
+				continue
+			}
+			if name == "te" && v != "trailers" {
+				continue
+			}
+			fields = append(fields, qpack.HeaderField{
+				Name:      name,
+				Value:     v,
+				Sensitive: name == "authorization" || name == "proxy-authorization",
+			})
+		}
+	}
+	return fields, nil
+}
+
+// http3AppendHeaders appends a HEADERS frame encoding fields to b.
+func http3AppendHeaders(b []byte, fields []qpack.HeaderField) []byte {
+	return http3AppendFrame(b, http3FrameHeaders, qpack.AppendFieldSection(nil, fields))
+}
+
+// http3Body reads the content of a message from the DATA frames of its
+// stream, and then its trailers.
+type http3Body struct {
+	fr            *http3FrameReader
+	remain        uint64 // in the current DATA frame
+	contentLength int64  // -1 if unknown
+	n             int64  // bytes read
+	maxTrailers   int64
+	trailer       Header // set when the trailers are read, may be nil
+	err           error  // sticky error
+
+	// onTrailers, if set, is called with the trailers, before the
+	// body returns io.EOF.
+	onTrailers func(Header)
+}
+
+func (b *http3Body) Read(p []byte) (int, error) {
+	for b.remain == 0 {
+		if b.err != nil {
+			return 0, b.err
+		}
+		b.err = b.nextFrame()
+	}
+	if uint64(len(p)) > b.remain {
+		p = p[:b.remain]
+	}
+	n, err := b.fr.r.Read(p)
+	b.remain -= uint64(n)
+	b.n += int64(n)
+	if b.contentLength >= 0 && b.n > b.contentLength {
+		b.err = http3StreamError(http3ErrMessageError, "body larger than Content-Length")
+		return n, b.err
+	}
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		b.err = err
+	}
+	return n, err
+}
+
+// nextFrame reads the header of the next DATA frame, or the trailers.
+func (b *http3Body) nextFrame() error {
+	for {
+		typ, length, err := b.fr.readHeader()
+		if err == io.EOF {
+			return b.end()
+		}
+		if err != nil {
+			return err
+		}
+		switch typ {
+		case http3FrameData:
+			b.remain = length
+			return nil
+		case http3FrameHeaders:
+			b.remain = 0
+			if length > uint64(b.maxTrailers) {
+				return http3StreamError(http3ErrExcessiveLoad, "trailers too large")
+			}
+			p, err := b.fr.readPayload(length, b.maxTrailers)
+			if err != nil {
+				return err
+			}
+			var fields []qpack.HeaderField
+			if err := qpack.ParseFieldSection(p, b.maxTrailers, func(f qpack.HeaderField) error {
+				fields = append(fields, f)
+				return nil
+			}); err != nil {
+				return http3StreamError(http3ErrMessageError, "invalid trailers")
+			}
+			f, err := parseHTTP3Fields(fields)
+			if err != nil {
+				return err
+			}
+			b.trailer = f.header
+			if _, _, err := b.fr.readHeader(); err != io.EOF {
+				if err == nil {
+					err = http3ConnError(http3ErrFrameUnexpected, "frame after trailers")
+				}
+				return err
+			}
+			return b.end()
+		}
+		if err := http3CheckRequestStreamFrame(typ); err != nil {
+			return err
+		}
+		if err := b.fr.discard(length); err != nil {
+			return err
+		}
+	}
+}
+
+// end checks the length of the content at the end of the stream.
+func (b *http3Body) end() error {
+	if b.contentLength >= 0 && b.n != b.contentLength {
+		return http3StreamError(http3ErrMessageError, "body shorter than Content-Length")
+	}
+	if b.onTrailers != nil && b.trailer != nil {
+		b.onTrailers(b.trailer)
+	}
+	return io.EOF
+}
+
+// http3DataWriter writes its input as DATA frames.
+type http3DataWriter struct {
+	w   io.Writer
+	buf []byte
+}
+
+func (dw *http3DataWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	dw.buf = quic.AppendVarint(dw.buf[:0], http3FrameData)
+	dw.buf = quic.AppendVarint(dw.buf, uint64(len(p)))
+	dw.buf = append(dw.buf, p...)
+	if _, err := dw.w.Write(dw.buf); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// http3ControlStream handles the control stream of the peer, whose type
+// has been read, calling goAway for GOAWAY frames. It returns when the
+// stream fails, with a connection error.
+func http3ControlStream(st *quic.Stream, goAway func(id uint64)) error {
+	fr := newHTTP3FrameReader(st)
+	typ, length, err := fr.readHeader()
+	if err != nil {
+		return http3ConnError(http3ErrClosedCriticalStream, "control stream closed")
+	}
+	if typ != http3FrameSettings {
+		return http3ConnError(http3ErrMissingSettings, "control stream does not start with SETTINGS")
+	}
+	p, err := fr.readPayload(length, 64<<10)
+	if err != nil {
+		return http3ConnError(http3ErrClosedCriticalStream, "control stream closed")
+	}
+	if _, err := parseHTTP3Settings(p); err != nil {
+		return err
+	}
+	for {
+		typ, length, err := fr.readHeader()
+		if err != nil {
+			return http3ConnError(http3ErrClosedCriticalStream, "control stream closed")
+		}
+		switch typ {
+		case http3FrameGoAway:
+			p, err := fr.readPayload(length, 8)
+			if err != nil {
+				return http3ConnError(http3ErrFrameError, "malformed GOAWAY")
+			}
+			id, n := quic.ConsumeVarint(p)
+			if n != len(p) {
+				return http3ConnError(http3ErrFrameError, "malformed GOAWAY")
+			}
+			goAway(id)
+			continue
+		case http3FrameData, http3FrameHeaders, http3FrameSettings, http3FramePushPromise,
+			0x02, 0x06, 0x08, 0x09:
+			return http3ConnError(http3ErrFrameUnexpected, fmt.Sprintf("frame type %#x on control stream", typ))
+		}
+		// CANCEL_PUSH and MAX_PUSH_ID are ignored, since push is never
+		// used, as are unknown frames.
+		if err := fr.discard(length); err != nil {
+			return http3ConnError(http3ErrClosedCriticalStream, "control stream closed")
+		}
+	}
+}
+
+// http3AcceptUniStreams accepts the unidirectional streams of the peer on
+// qc, and serves its control stream, until qc is closed.
+func http3AcceptUniStreams(qc *quic.Conn, goAway func(id uint64)) {
+	sawControl := false
+	for {
+		st, err := qc.AcceptUniStream(context.Background())
+		if err != nil {
+			return
+		}
+		typ, err := quic.ReadVarint(http3ByteReader{st})
+		if err != nil {
+			st.CloseRead(uint64(http3ErrStreamCreationError))
+			continue
+		}
+		switch typ {
+		case http3StreamControl:
+			if sawControl {
+				qc.CloseWithError(uint64(http3ErrStreamCreationError), "second control stream")
+				return
+			}
+			sawControl = true
+			go func() {
+				http3AbortConn(qc, http3ControlStream(st, goAway))
+			}()
+		case http3StreamPush:
+			// Push is never enabled, and clients never push.
+			qc.CloseWithError(uint64(http3ErrIDError), "push stream without MAX_PUSH_ID")
+			return
+		case http3StreamQPACKEncoder, http3StreamQPACKDecoder:
+			// With no dynamic table, the instructions on these
+			// streams have no effect.
+			go io.Copy(io.Discard, st)
+		default:
+			st.CloseRead(uint64(http3ErrStreamCreationError))
+		}
+	}
+}
+
+// http3ByteReader reads single bytes from a reader.
+type http3ByteReader struct {
+	r io.Reader
+}
+
+func (br http3ByteReader) ReadByte() (byte, error) {
+	var b [1]byte
+	_, err := io.ReadFull(br.r, b[:])
+	return b[0], err
+}
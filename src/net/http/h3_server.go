@@ -0,0 +1,1027 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// HTTP/3 server. See RFC 9114.
+
+package http
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http/internal/ascii"
+	"net/http/internal/qpack"
+	"net/http/internal/quic"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/http/httpguts"
+)
+
+// http3MaxConcurrentStreams is the number of concurrent requests a client
+// may send on an HTTP/3 connection.
+const http3MaxConcurrentStreams = 250
+
+// ServeQUIC accepts incoming HTTP/3 connections over QUIC on the packet
+// connection pc, creating a new service goroutine for each. The service
+// goroutines read requests and then call srv.Handler to reply to them.
+//
+// Files containing a certificate and matching private key for the
+// server must be provided if neither the [Server]'s
+// TLSConfig.Certificates nor TLSConfig.GetCertificate are populated.
+//
+// While ServeQUIC is running, responses to HTTPS requests served over
+// HTTP/1 and HTTP/2 by srv advertise HTTP/3 on the port of pc with an
+// Alt-Svc header, unless the handler sets that header.
+//
+// The BaseContext, ConnContext and ConnState hooks of the Server are not
+// used for HTTP/3 connections, and hijacking is not supported.
+//
+// ServeQUIC always returns a non-nil error and closes pc once its
+// connections are closed. After [Server.Shutdown] or [Server.Close], the
+// returned error is [ErrServerClosed].
+func (srv *Server) ServeQUIC(pc net.PacketConn, certFile, keyFile string) error {
+	config, err := srv.http3TLSConfig(certFile, keyFile)
+	if err != nil {
+		pc.Close()
+		return err
+	}
+	qcfg := &quic.Config{
+		TLSConfig:            config,
+		MaxBidiRemoteStreams: http3MaxConcurrentStreams,
+	}
+	// Unlike the keep-alive timeout of HTTP/1, the idle timeout of QUIC
+	// also applies while a request is in progress, so it does not
+	// default to ReadTimeout.
+	if d := srv.IdleTimeout; d > 0 {
+		qcfg.MaxIdleTimeout = d
+	}
+	ep := quic.NewEndpoint(pc, qcfg)
+	if !srv.trackQUICEndpoint(ep, true) {
+		ep.Close()
+		return ErrServerClosed
+	}
+	defer srv.trackQUICEndpoint(ep, false)
+
+	var conns sync.WaitGroup
+	defer func() {
+		// Close the endpoint once the connections are done,
+		// after a graceful shutdown.
+		go func() {
+			conns.Wait()
+			ep.Close()
+		}()
+	}()
+
+	ctx := context.WithValue(context.Background(), ServerContextKey, srv)
+	for {
+		qc, err := ep.Accept(context.Background())
+		if err != nil {
+			if srv.shuttingDown() {
+				return ErrServerClosed
+			}
+			return err
+		}
+		c := srv.newHTTP3Conn(ctx, qc)
+		if !srv.trackHTTP3Conn(c, true) {
+			qc.CloseWithError(uint64(http3ErrNoError), "")
+			continue
+		}
+		conns.Add(1)
+		go func() {
+			defer conns.Done()
+			defer srv.trackHTTP3Conn(c, false)
+			c.serve()
+		}()
+	}
+}
+
+// ListenAndServeQUIC listens on the UDP network address srv.Addr and
+// then calls [Server.ServeQUIC] to handle HTTP/3 requests on incoming QUIC
+// connections.
+//
+// If srv.Addr is blank, ":https" is used.
+//
+// ListenAndServeQUIC always returns a non-nil error. After
+// [Server.Shutdown] or [Server.Close], the returned error is
+// [ErrServerClosed].
+func (srv *Server) ListenAndServeQUIC(certFile, keyFile string) error {
+	if srv.shuttingDown() {
+		return ErrServerClosed
+	}
+	addr := srv.Addr
+	if addr == "" {
+		addr = ":https"
+	}
+	pc, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return err
+	}
+	return srv.ServeQUIC(pc, certFile, keyFile)
+}
+
+func (srv *Server) http3TLSConfig(certFile, keyFile string) (*tls.Config, error) {
+	// Configure HTTP/2 like ServeTLS does, since it may update
+	// srv.TLSConfig, and both are often run concurrently.
+	if err := srv.setupHTTP2_ServeTLS(); err != nil {
+		return nil, err
+	}
+	config := cloneTLSConfig(srv.TLSConfig)
+	config.NextProtos = []string{http3NextProto}
+	configHasCert := len(config.Certificates) > 0 || config.GetCertificate != nil
+	if !configHasCert || certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+// http3ServerState holds the HTTP/3 endpoints and connections of a Server.
+type http3ServerState struct {
+	endpoints map[*quic.Endpoint]struct{}
+	conns     map[*http3ServerConn]struct{}
+}
+
+// http3StateLocked returns the HTTP/3 state of s, creating it if needed.
+// s.mu must be held.
+func (s *Server) http3StateLocked() *http3ServerState {
+	st, _ := s.http3.(*http3ServerState)
+	if st == nil {
+		st = &http3ServerState{
+			endpoints: make(map[*quic.Endpoint]struct{}),
+			conns:     make(map[*http3ServerConn]struct{}),
+		}
+		s.http3 = st
+	}
+	return st
+}
+
+func (st *http3ServerState) close() {
+	for c := range st.conns {
+		c.qc.CloseWithError(uint64(http3ErrNoError), "server closed")
+		delete(st.conns, c)
+	}
+}
+
+func (st *http3ServerState) closeIdleConns() bool {
+	quiescent := true
+	for c := range st.conns {
+		// HTTP/3 connections are told to stop sending requests,
+		// and are closed once their requests are served.
+		if !c.shutdown() {
+			quiescent = false
+			continue
+		}
+		delete(st.conns, c)
+	}
+	return quiescent
+}
+
+func (st *http3ServerState) closeListeners() {
+	for ep := range st.endpoints {
+		ep.StopAccepting()
+	}
+}
+
+// trackQUICEndpoint adds or removes a QUIC endpoint to the set of tracked
+// endpoints, and updates the Alt-Svc header advertising HTTP/3.
+// It reports whether the server is still up (not Shutdown or Closed).
+func (s *Server) trackQUICEndpoint(ep *quic.Endpoint, add bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := s.http3StateLocked()
+	if add {
+		if s.shuttingDown() {
+			return false
+		}
+		st.endpoints[ep] = struct{}{}
+		s.listenerGroup.Add(1)
+	} else {
+		delete(st.endpoints, ep)
+		s.listenerGroup.Done()
+	}
+	var altSvc *string
+	for ep := range st.endpoints {
+		if addr, ok := ep.LocalAddr().(*net.UDPAddr); ok {
+			v := fmt.Sprintf(`%s=":%d"; ma=86400`, http3NextProto, addr.Port)
+			altSvc = &v
+			break
+		}
+	}
+	s.http3AltSvc.Store(altSvc)
+	return true
+}
+
+// trackHTTP3Conn adds or removes an HTTP/3 connection to the set of
+// tracked connections. It reports whether the server is still up.
+func (s *Server) trackHTTP3Conn(c *http3ServerConn, add bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := s.http3StateLocked()
+	if add {
+		if s.shuttingDown() {
+			return false
+		}
+		st.conns[c] = struct{}{}
+	} else {
+		delete(st.conns, c)
+	}
+	return true
+}
+
+// An http3ServerConn is the server side of an HTTP/3 connection.
+type http3ServerConn struct {
+	srv            *Server
+	qc             *quic.Conn
+	ctx            context.Context
+	cancelCtx      context.CancelFunc
+	remoteAddr     string
+	tlsState       *tls.ConnectionState
+	maxHeaderBytes int64
+
+	mu       sync.Mutex
+	ctrl     *quic.Stream // our control stream, nil until opened
+	active   int          // requests being served
+	lastID   int64        // ID of the last request stream accepted
+	goAway   bool         // GOAWAY sent
+	goAwayID int64        // first request stream ID refused after GOAWAY
+}
+
+func (srv *Server) newHTTP3Conn(ctx context.Context, qc *quic.Conn) *http3ServerConn {
+	ctx = context.WithValue(ctx, LocalAddrContextKey, qc.LocalAddr())
+	ctx, cancel := context.WithCancel(ctx)
+	cs := qc.ConnectionState()
+	return &http3ServerConn{
+		srv:            srv,
+		qc:             qc,
+		ctx:            ctx,
+		cancelCtx:      cancel,
+		remoteAddr:     qc.RemoteAddr().String(),
+		tlsState:       &cs,
+		maxHeaderBytes: int64(srv.maxHeaderBytes()),
+		lastID:         -4,
+	}
+}
+
+func (c *http3ServerConn) serve() {
+	defer c.cancelCtx()
+	go func() {
+		select {
+		case <-c.qc.Done():
+			c.cancelCtx()
+		case <-c.ctx.Done():
+		}
+	}()
+
+	ctrl, err := c.qc.OpenUniStream(c.ctx)
+	if err != nil {
+		return
+	}
+	c.mu.Lock()
+	c.ctrl = ctrl
+	_, err = ctrl.Write(http3AppendSettings(nil, c.maxHeaderBytes))
+	if c.goAway {
+		err = c.writeGoAwayLocked()
+	}
+	c.mu.Unlock()
+	if err != nil {
+		return
+	}
+	// A client may only send a GOAWAY frame to limit the pushes of the
+	// server, which never pushes.
+	go http3AcceptUniStreams(c.qc, func(uint64) {})
+
+	for {
+		st, err := c.qc.AcceptStream(context.Background())
+		if err != nil {
+			return
+		}
+		c.mu.Lock()
+		if c.goAway && st.ID() >= c.goAwayID {
+			c.mu.Unlock()
+			st.CloseRead(uint64(http3ErrRequestRejected))
+			st.Reset(uint64(http3ErrRequestRejected))
+			continue
+		}
+		c.active++
+		c.lastID = st.ID()
+		c.mu.Unlock()
+		go c.serveStream(st)
+	}
+}
+
+// shutdown starts the graceful shutdown of the connection, by sending a
+// GOAWAY frame, and closes it if no request is being served. It reports
+// whether the connection is closed.
+func (c *http3ServerConn) shutdown() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.startGracefulShutdownLocked()
+	if c.active == 0 {
+		c.qc.CloseAfterWrites(uint64(http3ErrNoError), "")
+		return true
+	}
+	return false
+}
+
+func (c *http3ServerConn) startGracefulShutdownLocked() {
+	if c.goAway {
+		return
+	}
+	c.goAway = true
+	c.goAwayID = c.lastID + 4
+	if c.ctrl != nil {
+		c.writeGoAwayLocked()
+	}
+}
+
+func (c *http3ServerConn) writeGoAwayLocked() error {
+	_, err := c.ctrl.Write(http3AppendFrame(nil, http3FrameGoAway, quic.AppendVarint(nil, uint64(c.goAwayID))))
+	return err
+}
+
+// requestDone is called when a request has been served.
+func (c *http3ServerConn) requestDone() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.active--
+	if c.goAway && c.active == 0 {
+		c.qc.CloseAfterWrites(uint64(http3ErrNoError), "")
+	}
+}
+
+// abortStream aborts the stream st, or the connection, for err.
+func (c *http3ServerConn) abortStream(st *quic.Stream, err error) {
+	code := http3ErrInternalError
+	var he *http3Error
+	if errors.As(err, &he) {
+		if he.conn {
+			c.qc.CloseWithError(uint64(he.code), he.msg)
+			return
+		}
+		code = he.code
+	}
+	st.CloseRead(uint64(code))
+	st.Reset(uint64(code))
+}
+
+func (c *http3ServerConn) serveStream(st *quic.Stream) {
+	defer c.requestDone()
+
+	fr := newHTTP3FrameReader(st)
+	var headerTimer *time.Timer
+	if d := c.srv.readHeaderTimeout(); d > 0 {
+		headerTimer = time.AfterFunc(d, func() {
+			c.abortStream(st, http3StreamError(http3ErrRequestIncomplete, "timeout reading request headers"))
+		})
+	}
+	fields, err := fr.readHeaders(c.maxHeaderBytes)
+	if headerTimer != nil && !headerTimer.Stop() {
+		return // the stream was aborted
+	}
+	if err == errHTTP3HeadersTooLarge {
+		c.writeSimpleResponse(st, StatusRequestHeaderFieldsTooLarge)
+		return
+	}
+	if err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			err = http3StreamError(http3ErrRequestIncomplete, "incomplete request")
+		}
+		c.abortStream(st, err)
+		return
+	}
+	f, err := parseHTTP3Fields(fields, "method", "scheme", "authority", "path")
+	if err != nil {
+		c.abortStream(st, err)
+		return
+	}
+	w, req, err := c.newResponseWriterAndRequest(st, fr, f)
+	if err != nil {
+		c.abortStream(st, err)
+		return
+	}
+	c.runHandler(w, req, serverHandler{c.srv}.ServeHTTP)
+}
+
+// writeSimpleResponse writes a response with no body and the given
+// status, and ends the stream.
+func (c *http3ServerConn) writeSimpleResponse(st *quic.Stream, code int) {
+	fields := []qpack.HeaderField{{Name: ":status", Value: strconv.Itoa(code)}}
+	st.Write(http3AppendHeaders(nil, fields))
+	st.Close()
+	st.CloseRead(uint64(http3ErrNoError))
+}
+
+func (c *http3ServerConn) newResponseWriterAndRequest(st *quic.Stream, fr *http3FrameReader, f http3Fields) (*http3ResponseWriter, *Request, error) {
+	method := f.pseudo["method"]
+	scheme := f.pseudo["scheme"]
+	authority := f.pseudo["authority"]
+	path := f.pseudo["path"]
+	if method == "CONNECT" {
+		if path != "" || scheme != "" || authority == "" {
+			return nil, nil, http3StreamError(http3ErrMessageError, "malformed CONNECT request")
+		}
+	} else if method == "" || path == "" || (scheme != "https" && scheme != "http") {
+		return nil, nil, http3StreamError(http3ErrMessageError, "missing pseudo-header field")
+	}
+	header := f.header
+	if authority == "" {
+		authority = header.Get("Host")
+	}
+
+	var tlsState *tls.ConnectionState
+	if scheme == "https" {
+		tlsState = c.tlsState
+	}
+
+	needsContinue := httpguts.HeaderValuesContainsToken(header["Expect"], "100-continue")
+	if needsContinue {
+		header.Del("Expect")
+	}
+	// Merge Cookie headers into one "; "-delimited value.
+	if cookies := header["Cookie"]; len(cookies) > 1 {
+		header.Set("Cookie", strings.Join(cookies, "; "))
+	}
+
+	var trailer Header
+	for _, v := range header["Trailer"] {
+		for _, key := range strings.Split(v, ",") {
+			key = CanonicalHeaderKey(textproto.TrimString(key))
+			switch key {
+			case "Transfer-Encoding", "Trailer", "Content-Length":
+				// Bogus. (copy of http1 rules)
+				// Ignore.
+			default:
+				if trailer == nil {
+					trailer = make(Header)
+				}
+				trailer[key] = nil
+			}
+		}
+	}
+	delete(header, "Trailer")
+
+	var u *url.URL
+	var requestURI string
+	if method == "CONNECT" {
+		u = &url.URL{Host: authority}
+		requestURI = authority // mimic HTTP/1 server behavior
+	} else {
+		var err error
+		u, err = url.ParseRequestURI(path)
+		if err != nil {
+			return nil, nil, http3StreamError(http3ErrMessageError, "invalid :path")
+		}
+		requestURI = path
+	}
+
+	contentLength := int64(-1)
+	if vv, ok := header["Content-Length"]; ok {
+		cl, err := strconv.ParseUint(vv[0], 10, 63)
+		if err != nil || len(vv) > 1 && slices.ContainsFunc(vv[1:], func(v string) bool { return v != vv[0] }) {
+			return nil, nil, http3StreamError(http3ErrMessageError, "invalid Content-Length")
+		}
+		contentLength = int64(cl)
+	} else if fr.r.Buffered() == 0 && st.AtEOF() {
+		contentLength = 0
+	}
+
+	ctx, cancel := context.WithCancel(c.ctx)
+	req := &Request{
+		Method:        method,
+		URL:           u,
+		RemoteAddr:    c.remoteAddr,
+		Header:        header,
+		RequestURI:    requestURI,
+		Proto:         "HTTP/3.0",
+		ProtoMajor:    3,
+		ProtoMinor:    0,
+		TLS:           tlsState,
+		Host:          authority,
+		ContentLength: contentLength,
+		Trailer:       trailer,
+		ctx:           ctx,
+	}
+	w := &http3ResponseWriter{
+		conn:      c,
+		st:        st,
+		req:       req,
+		cancelCtx: cancel,
+	}
+	w.bw = bufio.NewWriterSize(http3ChunkWriter{w}, 4<<10)
+	body := &http3Body{
+		fr:            fr,
+		contentLength: contentLength,
+		maxTrailers:   c.maxHeaderBytes,
+		onTrailers: func(h Header) {
+			for k, vv := range h {
+				if _, ok := req.Trailer[k]; ok {
+					req.Trailer[k] = vv
+				}
+			}
+		},
+	}
+	w.body = &http3RequestBody{w: w, body: body, needsContinue: needsContinue}
+	req.Body = w.body
+	go func() {
+		select {
+		case <-st.Aborted():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return w, req, nil
+}
+
+func (c *http3ServerConn) runHandler(w *http3ResponseWriter, req *Request, handler func(ResponseWriter, *Request)) {
+	if d := c.srv.ReadTimeout; d > 0 {
+		w.SetReadDeadline(time.Now().Add(d))
+	}
+	if d := c.srv.WriteTimeout; d > 0 {
+		w.SetWriteDeadline(time.Now().Add(d))
+	}
+	didPanic := true
+	defer func() {
+		w.cancelCtx()
+		if req.MultipartForm != nil {
+			req.MultipartForm.RemoveAll()
+		}
+		if didPanic {
+			e := recover()
+			w.stopTimers()
+			c.abortStream(w.st, http3StreamError(http3ErrInternalError, "handler panic"))
+			// Same as net/http:
+			if e != nil && e != ErrAbortHandler {
+				const size = 64 << 10
+				buf := make([]byte, size)
+				buf = buf[:runtime.Stack(buf, false)]
+				c.srv.logf("http3: panic serving %v: %v\n%s", c.remoteAddr, e, buf)
+			}
+			return
+		}
+		w.handlerDone()
+	}()
+	handler(w, req)
+	didPanic = false
+}
+
+// http3RequestBody is the body of a request received by the server.
+type http3RequestBody struct {
+	w             *http3ResponseWriter
+	body          *http3Body
+	needsContinue bool
+	mu            sync.Mutex
+	sawEOF        bool
+	closed        bool
+}
+
+func (b *http3RequestBody) Read(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return 0, ErrBodyReadAfterClose
+	}
+	if b.needsContinue {
+		b.needsContinue = false
+		b.w.write100Continue()
+	}
+	n, err := b.body.Read(p)
+	if err != nil && err != io.EOF && b.w.readTimedOut.Load() {
+		err = os.ErrDeadlineExceeded
+	}
+	if err == io.EOF {
+		b.sawEOF = true
+	} else if err != nil {
+		var he *http3Error
+		if errors.As(err, &he) {
+			b.w.conn.abortStream(b.w.st, err)
+		}
+	}
+	return n, err
+}
+
+func (b *http3RequestBody) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	return nil
+}
+
+// readComplete reports whether the whole body was read.
+func (b *http3RequestBody) readComplete() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.sawEOF
+}
+
+// http3ResponseWriter is the ResponseWriter of HTTP/3 requests.
+type http3ResponseWriter struct {
+	conn      *http3ServerConn
+	st        *quic.Stream
+	req       *Request
+	body      *http3RequestBody
+	bw        *bufio.Writer // writing to http3ChunkWriter
+	cancelCtx context.CancelFunc
+
+	// wmu serializes the writes on st of the handler and of the
+	// request body, which sends 100 Continue responses.
+	wmu sync.Mutex
+
+	handlerHeader  Header   // nil until called
+	snapHeader     Header   // snapshot of handlerHeader at WriteHeader time
+	trailers       []string // set in writeChunk
+	status         int      // status code passed to WriteHeader
+	wroteHeader    bool     // WriteHeader called (explicitly or implicitly)
+	sentHeader     bool     // the HEADERS frame was written
+	wroteContinue  bool     // 100 Continue response was written
+	handlerDone_   bool     // handler has finished
+	sentContentLen int64    // non-zero if handler set a Content-Length header
+	wroteBytes     int64
+	buf            []byte
+
+	timerMu       sync.Mutex
+	readTimer     *time.Timer
+	writeTimer    *time.Timer
+	readTimedOut  atomic.Bool
+	writeTimedOut atomic.Bool
+}
+
+type http3ChunkWriter struct{ w *http3ResponseWriter }
+
+func (cw http3ChunkWriter) Write(p []byte) (int, error) {
+	return cw.w.writeChunk(p)
+}
+
+func (w *http3ResponseWriter) Header() Header {
+	if w.handlerHeader == nil {
+		w.handlerHeader = make(Header)
+	}
+	return w.handlerHeader
+}
+
+func (w *http3ResponseWriter) WriteHeader(code int) {
+	w.wmu.Lock()
+	defer w.wmu.Unlock()
+	if w.wroteHeader {
+		caller := relevantCaller()
+		w.conn.srv.logf("http: superfluous response.WriteHeader call from %s (%s:%d)", caller.Function, path.Base(caller.File), caller.Line)
+		return
+	}
+	w.writeHeaderLocked(code)
+}
+
+func (w *http3ResponseWriter) writeHeaderLocked(code int) {
+	if w.wroteHeader {
+		return
+	}
+	checkWriteHeaderCode(code)
+
+	// Handle informational headers.
+	if code >= 100 && code <= 199 {
+		// Per RFC 8297 we must not clear the current header map.
+		h := w.handlerHeader
+		_, cl := h["Content-Length"]
+		_, te := h["Transfer-Encoding"]
+		if cl || te {
+			h = h.Clone()
+			h.Del("Content-Length")
+			h.Del("Transfer-Encoding")
+		}
+		fields := []qpack.HeaderField{{Name: ":status", Value: strconv.Itoa(code)}}
+		fields, _ = http3AppendHeader(fields, h, false)
+		w.st.Write(http3AppendHeaders(nil, fields))
+		return
+	}
+
+	w.wroteHeader = true
+	w.status = code
+	if len(w.handlerHeader) > 0 {
+		w.snapHeader = w.handlerHeader.Clone()
+	}
+	// Enforce the declared Content-Length from the first write, rather
+	// than once the first chunk is sent.
+	if cl, err := strconv.ParseUint(w.snapHeader.Get("Content-Length"), 10, 63); err == nil {
+		w.sentContentLen = int64(cl)
+	}
+}
+
+// write100Continue sends a 100 Continue response, when the handler reads
+// the body of a request expecting it.
+func (w *http3ResponseWriter) write100Continue() {
+	w.wmu.Lock()
+	defer w.wmu.Unlock()
+	if w.wroteHeader || w.wroteContinue {
+		return
+	}
+	w.wroteContinue = true
+	w.st.Write(http3AppendHeaders(nil, []qpack.HeaderField{{Name: ":status", Value: "100"}}))
+}
+
+func (w *http3ResponseWriter) Write(p []byte) (int, error) {
+	return w.write(len(p), p, "")
+}
+
+func (w *http3ResponseWriter) WriteString(s string) (int, error) {
+	return w.write(len(s), nil, s)
+}
+
+// either dataB or dataS is non-zero.
+func (w *http3ResponseWriter) write(lenData int, dataB []byte, dataS string) (int, error) {
+	w.wmu.Lock()
+	defer w.wmu.Unlock()
+	if !w.wroteHeader {
+		w.writeHeaderLocked(StatusOK)
+	}
+	if !bodyAllowedForStatus(w.status) {
+		return 0, ErrBodyNotAllowed
+	}
+	w.wroteBytes += int64(lenData)
+	if w.sentContentLen != 0 && w.wroteBytes > w.sentContentLen {
+		return 0, ErrContentLength
+	}
+	var n int
+	var err error
+	if dataB != nil {
+		n, err = w.bw.Write(dataB)
+	} else {
+		n, err = w.bw.WriteString(dataS)
+	}
+	return n, w.writeErr(err)
+}
+
+// writeErr returns os.ErrDeadlineExceeded for the errors caused by the
+// write deadline, and err otherwise.
+func (w *http3ResponseWriter) writeErr(err error) error {
+	if err != nil && w.writeTimedOut.Load() {
+		return os.ErrDeadlineExceeded
+	}
+	return err
+}
+
+// writeChunk writes the chunks of the bufio.Writer. On the first chunk,
+// it writes the HEADERS frame of the response. It is called with wmu
+// held.
+func (w *http3ResponseWriter) writeChunk(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.writeHeaderLocked(StatusOK)
+	}
+	if w.handlerDone_ {
+		w.promoteUndeclaredTrailers()
+	}
+
+	isHeadResp := w.req.Method == "HEAD"
+	if !w.sentHeader {
+		w.sentHeader = true
+		var ctype, clen string
+		if clen = w.snapHeader.Get("Content-Length"); clen != "" {
+			w.snapHeader.Del("Content-Length")
+			if cl, err := strconv.ParseUint(clen, 10, 63); err == nil {
+				w.sentContentLen = int64(cl)
+			} else {
+				clen = ""
+			}
+		}
+		_, hasContentLength := w.snapHeader["Content-Length"]
+		if !hasContentLength && clen == "" && w.handlerDone_ && bodyAllowedForStatus(w.status) && (len(p) > 0 || !isHeadResp) {
+			clen = strconv.Itoa(len(p))
+		}
+		_, hasContentType := w.snapHeader["Content-Type"]
+		// If the Content-Encoding is non-blank, we shouldn't
+		// sniff the body. See Issue golang.org/issue/31753.
+		hasCE := w.snapHeader.Get("Content-Encoding") != ""
+		if !hasCE && !hasContentType && bodyAllowedForStatus(w.status) && len(p) > 0 {
+			ctype = DetectContentType(p)
+		}
+		var date string
+		if _, ok := w.snapHeader["Date"]; !ok {
+			date = time.Now().UTC().Format(TimeFormat)
+		}
+		for _, v := range w.snapHeader["Trailer"] {
+			foreachHeaderElement(v, w.declareTrailer)
+		}
+		// "Connection: close" asks to close the connection once idle,
+		// like for HTTP/1.
+		if v, ok := w.snapHeader["Connection"]; ok {
+			if len(v) > 0 && v[0] == "close" {
+				w.conn.mu.Lock()
+				w.conn.startGracefulShutdownLocked()
+				w.conn.mu.Unlock()
+			}
+		}
+
+		fields := []qpack.HeaderField{{Name: ":status", Value: strconv.Itoa(w.status)}}
+		fields, _ = http3AppendHeader(fields, w.snapHeader, false, "trailer")
+		for _, t := range w.trailers {
+			fields = append(fields, qpack.HeaderField{Name: "trailer", Value: t})
+		}
+		if clen != "" {
+			fields = append(fields, qpack.HeaderField{Name: "content-length", Value: clen})
+		}
+		if ctype != "" {
+			fields = append(fields, qpack.HeaderField{Name: "content-type", Value: ctype})
+		}
+		if date != "" {
+			fields = append(fields, qpack.HeaderField{Name: "date", Value: date})
+		}
+		w.buf = http3AppendHeaders(w.buf[:0], fields)
+		if _, err := w.st.Write(w.buf); err != nil {
+			return 0, err
+		}
+	}
+	if isHeadResp {
+		return len(p), nil
+	}
+	if len(p) > 0 {
+		w.buf = quic.AppendVarint(w.buf[:0], http3FrameData)
+		w.buf = quic.AppendVarint(w.buf, uint64(len(p)))
+		if _, err := w.st.Write(w.buf); err != nil {
+			return 0, err
+		}
+		if _, err := w.st.Write(p); err != nil {
+			return 0, err
+		}
+	}
+	if w.handlerDone_ && w.hasNonemptyTrailers() {
+		var fields []qpack.HeaderField
+		for _, k := range w.trailers {
+			name, ok := ascii.ToLower(k)
+			if !ok {
+				continue
+			}
+			for _, v := range w.handlerHeader[k] {
+				if httpguts.ValidHeaderFieldValue(v) {
+					fields = append(fields, qpack.HeaderField{Name: name, Value: v})
+				}
+			}
+		}
+		if _, err := w.st.Write(http3AppendHeaders(nil, fields)); err != nil {
+			return len(p), err
+		}
+	}
+	return len(p), nil
+}
+
+// declareTrailer is called for each Trailer header when the response
+// header is written.
+func (w *http3ResponseWriter) declareTrailer(k string) {
+	k = CanonicalHeaderKey(k)
+	if !httpguts.ValidTrailerHeader(k) {
+		// Forbidden by RFC 7230, section 4.1.2.
+		w.conn.srv.logf("http3: ignoring invalid trailer %q", k)
+		return
+	}
+	if !slices.Contains(w.trailers, k) {
+		w.trailers = append(w.trailers, k)
+	}
+}
+
+// promoteUndeclaredTrailers declares the fields of the header whose names
+// start with TrailerPrefix as trailers, when the handler is done.
+func (w *http3ResponseWriter) promoteUndeclaredTrailers() {
+	for k, vv := range w.handlerHeader {
+		if !strings.HasPrefix(k, TrailerPrefix) {
+			continue
+		}
+		trailerKey := strings.TrimPrefix(k, TrailerPrefix)
+		w.declareTrailer(trailerKey)
+		w.handlerHeader[CanonicalHeaderKey(trailerKey)] = vv
+	}
+	slices.Sort(w.trailers)
+}
+
+func (w *http3ResponseWriter) hasNonemptyTrailers() bool {
+	for _, trailer := range w.trailers {
+		if _, ok := w.handlerHeader[trailer]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *http3ResponseWriter) Flush() {
+	w.FlushError()
+}
+
+func (w *http3ResponseWriter) FlushError() error {
+	w.wmu.Lock()
+	defer w.wmu.Unlock()
+	return w.flushLocked()
+}
+
+func (w *http3ResponseWriter) flushLocked() error {
+	if w.bw.Buffered() > 0 {
+		return w.writeErr(w.bw.Flush())
+	}
+	// The bufio.Writer won't call writeChunk with zero bytes, so do
+	// it to force the HEADERS frame, and trailers, to be sent.
+	_, err := w.writeChunk(nil)
+	return w.writeErr(err)
+}
+
+func (w *http3ResponseWriter) CloseNotify() <-chan bool {
+	ch := make(chan bool, 1)
+	ctx := w.req.Context()
+	go func() {
+		<-ctx.Done()
+		ch <- true
+	}()
+	return ch
+}
+
+// EnableFullDuplex does nothing: HTTP/3 handlers can always read the
+// request body while writing the response.
+func (w *http3ResponseWriter) EnableFullDuplex() error {
+	return nil
+}
+
+// SetReadDeadline sets the deadline for reading the request body, after
+// which the server stops receiving it.
+func (w *http3ResponseWriter) SetReadDeadline(deadline time.Time) error {
+	w.timerMu.Lock()
+	defer w.timerMu.Unlock()
+	w.readTimer = resetDeadlineTimer(w.readTimer, deadline, func() {
+		w.readTimedOut.Store(true)
+		w.st.CloseRead(uint64(http3ErrRequestCancelled))
+	})
+	return nil
+}
+
+// SetWriteDeadline sets the deadline for writing the response, after
+// which the server resets the stream.
+func (w *http3ResponseWriter) SetWriteDeadline(deadline time.Time) error {
+	w.timerMu.Lock()
+	defer w.timerMu.Unlock()
+	w.writeTimer = resetDeadlineTimer(w.writeTimer, deadline, func() {
+		w.writeTimedOut.Store(true)
+		w.st.Reset(uint64(http3ErrRequestCancelled))
+	})
+	return nil
+}
+
+// resetDeadlineTimer returns a timer calling f at deadline, reusing t if
+// not nil, or nil if deadline is zero. A deadline in the past calls f
+// before returning.
+func resetDeadlineTimer(t *time.Timer, deadline time.Time, f func()) *time.Timer {
+	if t != nil {
+		t.Stop()
+	}
+	if deadline.IsZero() {
+		return nil
+	}
+	if !deadline.After(time.Now()) {
+		f()
+		return nil
+	}
+	if t == nil {
+		return time.AfterFunc(time.Until(deadline), f)
+	}
+	t.Reset(time.Until(deadline))
+	return t
+}
+
+func (w *http3ResponseWriter) stopTimers() {
+	w.timerMu.Lock()
+	defer w.timerMu.Unlock()
+	if w.readTimer != nil {
+		w.readTimer.Stop()
+	}
+	if w.writeTimer != nil {
+		w.writeTimer.Stop()
+	}
+}
+
+// handlerDone completes the response when the handler returns.
+func (w *http3ResponseWriter) handlerDone() {
+	w.wmu.Lock()
+	w.handlerDone_ = true
+	err := w.flushLocked()
+	w.wmu.Unlock()
+	w.stopTimers()
+	if err != nil {
+		w.conn.abortStream(w.st, err)
+		return
+	}
+	w.st.Close()
+	if !w.body.readComplete() {
+		// The response is complete, so the rest of the request is
+		// not needed (RFC 9114, Section 4.1).
+		w.st.CloseRead(uint64(http3ErrNoError))
+	}
+}
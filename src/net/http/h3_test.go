@@ -0,0 +1,337 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Tests of HTTP/3, and of the switch to it from HTTP/1 and HTTP/2.
+
+package http_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"internal/testenv"
+	"io"
+	. "net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTTP3RoundTrip(t *testing.T) {
+	run(t, testHTTP3RoundTrip, []testMode{http1Mode, http2Mode, http3Mode})
+}
+func testHTTP3RoundTrip(t *testing.T, mode testMode) {
+	cst := newClientServerTest(t, mode, HandlerFunc(func(w ResponseWriter, r *Request) {
+		w.Header().Set("Trailer", "Res-Trailer")
+		w.Header().Set("Proto", r.Proto)
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("reading request body: %v", err)
+		}
+		w.Write(body)
+		if got, want := r.Trailer.Get("Req-Trailer"), "req"; got != want {
+			t.Errorf("request trailer = %q, want %q", got, want)
+		}
+		w.Header().Set("Res-Trailer", "res")
+	}))
+
+	body := make([]byte, 1<<20)
+	rand.Read(body)
+	req, _ := NewRequest("POST", cst.ts.URL, nil)
+	req.Trailer = Header{"Req-Trailer": nil}
+	req.Body = &trailerSettingBody{req: req, r: bytes.NewReader(body)}
+	res, err := cst.c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	got, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, body) {
+		t.Errorf("read %d bytes of response body, want the %d bytes sent", len(got), len(body))
+	}
+	if got, want := res.Trailer.Get("Res-Trailer"), "res"; got != want {
+		t.Errorf("response trailer = %q, want %q", got, want)
+	}
+	wantProto := map[testMode]string{
+		http1Mode: "HTTP/1.1",
+		http2Mode: "HTTP/2.0",
+		http3Mode: "HTTP/3.0",
+	}[mode]
+	if res.Proto != wantProto || res.Header.Get("Proto") != wantProto {
+		t.Errorf("response proto = %q, request proto = %q; want %q", res.Proto, res.Header.Get("Proto"), wantProto)
+	}
+}
+
+// trailerSettingBody reads from r, and sets the Req-Trailer trailer of req
+// at EOF.
+type trailerSettingBody struct {
+	req *Request
+	r   io.Reader
+}
+
+func (b *trailerSettingBody) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	if err == io.EOF {
+		b.req.Trailer.Set("Req-Trailer", "req")
+	}
+	return n, err
+}
+
+func (b *trailerSettingBody) Close() error { return nil }
+
+// Tests that a client with EnableHTTP3 switches to HTTP/3 once a server
+// advertises it with Alt-Svc.
+func TestHTTP3AltSvcUpgrade(t *testing.T) {
+	ts := httptest.NewUnstartedServer(HandlerFunc(func(w ResponseWriter, r *Request) {
+		io.WriteString(w, r.Proto)
+	}))
+	startHTTP3Server(t, ts)
+	defer ts.Close()
+	c := ts.Client()
+	tr := c.Transport.(*Transport)
+	tr.EnableHTTP3()
+	defer tr.CloseIdleConnections()
+
+	altSvc := ExportHTTP3AltSvc(ts.Config)
+	if altSvc == "" {
+		t.Fatal("server does not advertise HTTP/3")
+	}
+	for i := 0; ; i++ {
+		res, err := c.Get(ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.ProtoMajor == 3 {
+			if string(body) != "HTTP/3.0" {
+				t.Errorf("handler saw proto %q, want HTTP/3.0", body)
+			}
+			if got := res.Header.Get("Alt-Svc"); got != "" {
+				t.Errorf("HTTP/3 response has Alt-Svc header %q", got)
+			}
+			break
+		}
+		if i == 0 {
+			if got := res.Header.Get("Alt-Svc"); got != altSvc {
+				t.Fatalf("Alt-Svc = %q, want %q", got, altSvc)
+			}
+		}
+		if i > 10000 {
+			t.Fatal("client did not switch to HTTP/3")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestHTTP3SessionResumption(t *testing.T) {
+	cst := newClientServerTest(t, http3Mode, HandlerFunc(func(w ResponseWriter, r *Request) {}))
+	get := func() *Response {
+		t.Helper()
+		res, err := cst.c.Get(cst.ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.ProtoMajor != 3 {
+			t.Fatalf("response proto = %v, want HTTP/3", res.Proto)
+		}
+		return res
+	}
+	if res := get(); res.TLS.DidResume {
+		t.Errorf("first connection resumed a session")
+	}
+	cst.tr.CloseIdleConnections()
+	if err := ExportHTTP3Connect(cst.tr, cst.ts.URL, cst.ts.Listener.Addr().String()); err != nil {
+		t.Fatal(err)
+	}
+	if res := get(); !res.TLS.DidResume {
+		t.Errorf("second connection did not resume the session")
+	}
+}
+
+func TestHTTP3ServerShutdown(t *testing.T) {
+	inHandler := make(chan struct{})
+	release := make(chan struct{})
+	cst := newClientServerTest(t, http3Mode, HandlerFunc(func(w ResponseWriter, r *Request) {
+		close(inHandler)
+		<-release
+		io.WriteString(w, "done")
+	}))
+	gotOnShutdown := make(chan struct{})
+	cst.ts.Config.RegisterOnShutdown(func() { close(gotOnShutdown) })
+
+	type result struct {
+		body string
+		err  error
+	}
+	resc := make(chan result, 1)
+	go func() {
+		res, err := cst.c.Get(cst.ts.URL)
+		if err != nil {
+			resc <- result{err: err}
+			return
+		}
+		defer res.Body.Close()
+		body, err := io.ReadAll(res.Body)
+		resc <- result{string(body), err}
+	}()
+	<-inHandler
+	shutdownc := make(chan error, 1)
+	go func() {
+		shutdownc <- cst.ts.Config.Shutdown(context.Background())
+	}()
+	<-gotOnShutdown
+	select {
+	case err := <-shutdownc:
+		t.Fatalf("Shutdown returned %v with a request in progress", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+	close(release)
+	if r := <-resc; r.err != nil || r.body != "done" {
+		t.Errorf("in-flight request = %q, %v; want done", r.body, r.err)
+	}
+	if err := <-shutdownc; err != nil {
+		t.Errorf("Shutdown = %v", err)
+	}
+}
+
+func TestHTTP3RequestHeadersTooLarge(t *testing.T) {
+	cst := newClientServerTest(t, http3Mode, HandlerFunc(func(w ResponseWriter, r *Request) {
+		t.Errorf("handler called for request with too large headers")
+	}), func(ts *httptest.Server) {
+		ts.Config.MaxHeaderBytes = 1 << 10
+	})
+	req, _ := NewRequest("GET", cst.ts.URL, nil)
+	req.Header.Set("Large", strings.Repeat("a", 4<<10))
+	res, err := cst.c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != StatusRequestHeaderFieldsTooLarge {
+		t.Errorf("status = %v, want %v", res.StatusCode, StatusRequestHeaderFieldsTooLarge)
+	}
+}
+
+func TestHTTP3CancelRequest(t *testing.T) {
+	inHandler := make(chan struct{})
+	handlerDone := make(chan error, 1)
+	cst := newClientServerTest(t, http3Mode, HandlerFunc(func(w ResponseWriter, r *Request) {
+		w.WriteHeader(StatusOK)
+		w.(Flusher).Flush()
+		close(inHandler)
+		<-r.Context().Done()
+		handlerDone <- r.Context().Err()
+	}))
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := NewRequestWithContext(ctx, "GET", cst.ts.URL, nil)
+	res, err := cst.c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	<-inHandler
+	cancel()
+	if _, err := io.ReadAll(res.Body); !errors.Is(err, context.Canceled) {
+		t.Errorf("reading body after cancel: %v, want context.Canceled", err)
+	}
+	if err := <-handlerDone; err == nil {
+		t.Errorf("request context not done after client canceled")
+	}
+}
+
+// Tests that the linker removes the HTTP/3 implementation from programs
+// which use neither Transport.EnableHTTP3 nor Server.ServeQUIC.
+func TestHTTP3LinkerGC(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping in short mode")
+	}
+	t.Parallel()
+	goBin := testenv.GoToolPath(t)
+	testenv.MustHaveGoBuild(t)
+
+	tests := []struct {
+		name    string
+		program string
+		want    []string
+		bad     []string
+	}{
+		{
+			name: "http1_and_http2",
+			program: `package main
+import "net/http"
+func main() {
+	http.Get("https://example.com")
+	http.ListenAndServeTLS("", "", "", nil)
+}
+`,
+			bad: []string{
+				"net/http.(*http3Transport)",
+				"net/http.(*http3ServerConn)",
+				"net/http/internal/quic.",
+				"net/http/internal/qpack.",
+			},
+		},
+		{
+			name: "http3",
+			program: `package main
+import "net/http"
+func main() {
+	tr := &http.Transport{}
+	tr.EnableHTTP3()
+	tr.RoundTrip(nil)
+	new(http.Server).ListenAndServeQUIC("", "")
+}
+`,
+			want: []string{
+				"net/http.(*http3Transport).roundTrip",
+				"net/http.(*http3ServerConn).serve",
+			},
+		},
+	}
+	tmpDir := t.TempDir()
+	goFile := filepath.Join(tmpDir, "x.go")
+	exeFile := filepath.Join(tmpDir, "x.exe")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.WriteFile(goFile, []byte(tt.program), 0644); err != nil {
+				t.Fatal(err)
+			}
+			os.Remove(exeFile)
+			cmd := testenv.Command(t, goBin, "build", "-o", "x.exe", "x.go")
+			cmd.Dir = tmpDir
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("compile: %v, %s", err, out)
+			}
+
+			cmd = testenv.Command(t, goBin, "tool", "nm", "x.exe")
+			cmd.Dir = tmpDir
+			nm, err := cmd.CombinedOutput()
+			if err != nil {
+				t.Fatalf("nm: %v, %s", err, nm)
+			}
+			for _, sym := range tt.want {
+				if !bytes.Contains(nm, []byte(sym)) {
+					t.Errorf("expected symbol %q not found", sym)
+				}
+			}
+			for _, sym := range tt.bad {
+				if bytes.Contains(nm, []byte(sym)) {
+					t.Errorf("unexpected symbol %q found", sym)
+				}
+			}
+		})
+	}
+}
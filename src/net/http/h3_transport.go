@@ -0,0 +1,1007 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// HTTP/3 client. See RFC 9114.
+
+package http
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http/httptrace"
+	"net/http/internal/ascii"
+	"net/http/internal/qpack"
+	"net/http/internal/quic"
+	"net/textproto"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http/httpguts"
+)
+
+const (
+	// http3DefaultAltSvcMaxAge is the lifetime of Alt-Svc entries
+	// without a "ma" parameter (RFC 7838, Section 3.1).
+	http3DefaultAltSvcMaxAge = 24 * time.Hour
+
+	// http3BrokenTimeout is the time during which an alternative
+	// service that could not be reached is not tried again.
+	http3BrokenTimeout = 5 * time.Minute
+
+	// http3DialTimeout is the default timeout of QUIC handshakes.
+	http3DialTimeout = 10 * time.Second
+
+	// http3KeepAlivePeriod is the interval of the PINGs which keep a
+	// connection with requests in progress from timing out while
+	// waiting for the server. It is below the default idle timeout of
+	// 30 seconds.
+	http3KeepAlivePeriod = 15 * time.Second
+
+	http3DefaultUserAgent = "Go-http-client/3"
+)
+
+// errHTTP3Skip is returned by http3Transport.roundTrip when a request is
+// not sent over HTTP/3, and must be sent over TCP instead.
+var errHTTP3Skip = errors.New("net/http: HTTP/3 not used")
+
+// EnableHTTP3 enables HTTP/3 over QUIC for HTTPS requests to origins
+// which advertise it with an Alt-Svc response header, when no proxy
+// is used. It must be called before the Transport is used.
+//
+// Requests are sent over HTTP/1 or HTTP/2 until a QUIC connection
+// to the alternative service is established in the background.
+// If the connection cannot be established, the origin is
+// contacted over TCP only for some time. Requests with no body
+// and a safe method may be sent in 0-RTT data when a session is
+// resumed; the TLSClientConfig's ClientSessionCache, or a cache
+// of the Transport if it is nil, stores the sessions.
+// HTTP/3 connections do not use DialContext, DialTLSContext, or
+// the connection pool of MaxConnsPerHost.
+//
+// A clone of the Transport made by [Transport.Clone] also uses HTTP/3.
+func (t *Transport) EnableHTTP3() {
+	if t.h3 == nil {
+		t.h3 = new(http3Transport)
+	}
+}
+
+// http3Transport holds the HTTP/3 state of a Transport: the alternative
+// services learned from Alt-Svc headers, and the QUIC connections to them.
+type http3Transport struct {
+	mu       sync.Mutex
+	ep       *quic.Endpoint // shared by all connections, nil until needed
+	sessions tls.ClientSessionCache
+	origins  map[string]*http3Origin // keyed by canonicalAddr of the origin
+	dialing  int                     // dials in progress
+}
+
+// An http3Origin is an origin for which an HTTP/3 alternative service
+// was advertised.
+type http3Origin struct {
+	altAddr     string    // "host:port" of the alternative
+	expires     time.Time // of the Alt-Svc entry
+	cc          *http3ClientConn
+	dialing     bool
+	brokenUntil time.Time
+}
+
+// roundTrip sends req over an HTTP/3 connection to its origin, if one is
+// ready. It returns errHTTP3Skip if req must be sent over TCP.
+func (h3 *http3Transport) roundTrip(t *Transport, req *Request) (*Response, error) {
+	if t.DisableKeepAlives {
+		return nil, errHTTP3Skip
+	}
+	key := canonicalAddr(req.URL)
+	h3.mu.Lock()
+	o := h3.origins[key]
+	var cc *http3ClientConn
+	if o != nil {
+		if time.Now().After(o.expires) {
+			delete(h3.origins, key)
+		} else if o.cc != nil && o.cc.reserve() {
+			cc = o.cc
+		} else if o.cc == nil {
+			h3.dialLocked(t, key, o)
+		}
+	}
+	h3.mu.Unlock()
+	if cc == nil {
+		return nil, errHTTP3Skip
+	}
+	if t.Proxy != nil {
+		// Alternative services are only used for direct connections.
+		if u, err := t.Proxy(req); err != nil || u != nil {
+			cc.release()
+			return nil, errHTTP3Skip
+		}
+	}
+	return cc.roundTrip(req)
+}
+
+// noteAltSvc records the HTTP/3 alternative service advertised in the
+// Alt-Svc header of resp, a response to req, and starts connecting to it
+// in the background.
+func (h3 *http3Transport) noteAltSvc(t *Transport, req *Request, resp *Response) {
+	v := resp.Header["Alt-Svc"]
+	if len(v) == 0 {
+		return
+	}
+	key := canonicalAddr(req.URL)
+	alt, maxAge, clear, ok := parseAltSvc(v)
+	if !ok {
+		return
+	}
+	h3.mu.Lock()
+	defer h3.mu.Unlock()
+	o := h3.origins[key]
+	if clear {
+		if o != nil && o.cc != nil {
+			o.cc.shutdown()
+		}
+		delete(h3.origins, key)
+		return
+	}
+	if alt == "" {
+		return
+	}
+	if alt[0] == ':' {
+		// An empty host means the host of the origin.
+		host, _, _ := net.SplitHostPort(key)
+		alt = net.JoinHostPort(host, alt[1:])
+	}
+	if o == nil || o.altAddr != alt {
+		if o != nil && o.cc != nil {
+			o.cc.shutdown()
+		}
+		o = &http3Origin{altAddr: alt}
+		if h3.origins == nil {
+			h3.origins = make(map[string]*http3Origin)
+		}
+		h3.origins[key] = o
+	}
+	o.expires = time.Now().Add(maxAge)
+	if o.cc == nil {
+		h3.dialLocked(t, key, o)
+	}
+}
+
+// parseAltSvc returns the first "h3" alternative of the Alt-Svc field
+// values v, and its lifetime. It sets clear for the "clear" value.
+func parseAltSvc(v []string) (alt string, maxAge time.Duration, clear, ok bool) {
+	for _, line := range v {
+		for _, entry := range strings.Split(line, ",") {
+			params := strings.Split(entry, ";")
+			proto, value, found := strings.Cut(textproto.TrimString(params[0]), "=")
+			if !found {
+				if proto == "clear" {
+					return "", 0, true, true
+				}
+				continue
+			}
+			if proto != http3NextProto {
+				continue
+			}
+			value, err := strconv.Unquote(value)
+			if err != nil || !strings.Contains(value, ":") {
+				continue
+			}
+			maxAge = http3DefaultAltSvcMaxAge
+			for _, p := range params[1:] {
+				name, pv, _ := strings.Cut(textproto.TrimString(p), "=")
+				if name == "ma" {
+					if n, err := strconv.ParseUint(pv, 10, 31); err == nil {
+						maxAge = time.Duration(n) * time.Second
+					}
+				}
+			}
+			return value, maxAge, false, true
+		}
+	}
+	return "", 0, false, false
+}
+
+// dialLocked starts connecting to the alternative service of o, unless it
+// is broken or a dial is already in progress.
+func (h3 *http3Transport) dialLocked(t *Transport, key string, o *http3Origin) {
+	if o.dialing || time.Now().Before(o.brokenUntil) {
+		return
+	}
+	if h3.ep == nil {
+		pc, err := net.ListenPacket("udp", ":0")
+		if err != nil {
+			o.brokenUntil = time.Now().Add(http3BrokenTimeout)
+			return
+		}
+		h3.ep = quic.NewEndpoint(pc, nil)
+	}
+	if h3.sessions == nil {
+		h3.sessions = tls.NewLRUClientSessionCache(0)
+	}
+	o.dialing = true
+	h3.dialing++
+	ep := h3.ep
+
+	host, _, _ := net.SplitHostPort(key)
+	config := cloneTLSConfig(t.TLSClientConfig)
+	if config.ServerName == "" {
+		config.ServerName = host
+	}
+	config.NextProtos = []string{http3NextProto}
+	if config.ClientSessionCache == nil {
+		config.ClientSessionCache = h3.sessions
+	}
+	qcfg := &quic.Config{
+		TLSConfig: config,
+		Allow0RTT: true,
+	}
+	if t.IdleConnTimeout > 0 {
+		qcfg.MaxIdleTimeout = t.IdleConnTimeout
+	}
+	timeout := t.TLSHandshakeTimeout
+	if timeout <= 0 {
+		timeout = http3DialTimeout
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		qc, err := ep.Dial(ctx, "udp", o.altAddr, qcfg)
+		var cc *http3ClientConn
+		if err == nil {
+			cc, err = newHTTP3ClientConn(t, qc)
+		}
+
+		h3.mu.Lock()
+		defer h3.mu.Unlock()
+		o.dialing = false
+		h3.dialing--
+		if err != nil {
+			o.brokenUntil = time.Now().Add(http3BrokenTimeout)
+			return
+		}
+		if h3.origins[key] != o {
+			// The alternative service changed while dialing.
+			cc.shutdown()
+			return
+		}
+		o.cc = cc
+		go func() {
+			<-qc.Done()
+			h3.mu.Lock()
+			defer h3.mu.Unlock()
+			if o.cc == cc {
+				o.cc = nil
+			}
+		}()
+	}()
+}
+
+func (h3 *http3Transport) clone() http3RoundTripper {
+	return new(http3Transport)
+}
+
+// closeIdleConnections closes the HTTP/3 connections with no request in
+// progress, and the QUIC endpoint once no connection uses it.
+func (h3 *http3Transport) closeIdleConnections() {
+	h3.mu.Lock()
+	defer h3.mu.Unlock()
+	inUse := h3.dialing > 0
+	for _, o := range h3.origins {
+		if o.cc == nil {
+			continue
+		}
+		if o.cc.closeIfIdle() {
+			o.cc = nil
+		} else {
+			inUse = true
+		}
+	}
+	if !inUse && h3.ep != nil {
+		h3.ep.Close()
+		h3.ep = nil
+	}
+}
+
+// An http3ClientConn is the client side of an HTTP/3 connection.
+type http3ClientConn struct {
+	t  *Transport
+	qc *quic.Conn
+
+	mu     sync.Mutex
+	ctrl   *quic.Stream
+	goAway bool // GOAWAY received or sent, no new requests
+	active int  // requests in progress
+}
+
+func newHTTP3ClientConn(t *Transport, qc *quic.Conn) (*http3ClientConn, error) {
+	cc := &http3ClientConn{
+		t:  t,
+		qc: qc,
+	}
+	ctrl, err := qc.OpenUniStream(context.Background())
+	if err == nil {
+		_, err = ctrl.Write(http3AppendSettings(nil, cc.maxHeaderBytes()))
+	}
+	if err != nil {
+		qc.CloseWithError(uint64(http3ErrInternalError), "")
+		return nil, err
+	}
+	cc.ctrl = ctrl
+	go http3AcceptUniStreams(qc, func(uint64) {
+		// Requests in progress were either accepted by the
+		// server, or will be reset with H3_REQUEST_REJECTED.
+		cc.mu.Lock()
+		cc.goAway = true
+		cc.mu.Unlock()
+	})
+	go func() {
+		// Servers cannot open request streams (RFC 9114, Section 6.1).
+		if _, err := qc.AcceptStream(context.Background()); err == nil {
+			qc.CloseWithError(uint64(http3ErrStreamCreationError), "server-initiated bidirectional stream")
+		}
+	}()
+	return cc, nil
+}
+
+// maxHeaderBytes returns the limit on the size of response headers.
+func (cc *http3ClientConn) maxHeaderBytes() int64 {
+	if v := cc.t.MaxResponseHeaderBytes; v > 0 {
+		return v
+	}
+	return 10 << 20 // conservative default; same as HTTP/1 and HTTP/2
+}
+
+// reserve reserves the connection for a new request, and reports whether
+// it succeeded.
+func (cc *http3ClientConn) reserve() bool {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	if cc.goAway || cc.qc.Err() != nil {
+		return false
+	}
+	cc.active++
+	if cc.active == 1 {
+		cc.qc.SetKeepAlivePeriod(http3KeepAlivePeriod)
+	}
+	return true
+}
+
+// release is called when a request is done.
+func (cc *http3ClientConn) release() {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	cc.active--
+	if cc.active == 0 {
+		cc.qc.SetKeepAlivePeriod(0)
+		if cc.goAway {
+			cc.qc.CloseAfterWrites(uint64(http3ErrNoError), "")
+		}
+	}
+}
+
+// shutdown stops sending requests on the connection, and closes it once
+// idle.
+func (cc *http3ClientConn) shutdown() {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	if !cc.goAway {
+		cc.goAway = true
+		// A client GOAWAY carries the last push ID it accepts;
+		// none were ever allowed.
+		cc.ctrl.Write(http3AppendFrame(nil, http3FrameGoAway, quic.AppendVarint(nil, 0)))
+	}
+	if cc.active == 0 {
+		cc.qc.CloseAfterWrites(uint64(http3ErrNoError), "")
+	}
+}
+
+// closeIfIdle closes the connection if no request is in progress, and
+// reports whether it is closed.
+func (cc *http3ClientConn) closeIfIdle() bool {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	if cc.active > 0 && cc.qc.Err() == nil {
+		return false
+	}
+	cc.goAway = true
+	cc.qc.CloseAfterWrites(uint64(http3ErrNoError), "")
+	return true
+}
+
+// roundTrip sends req on a new request stream. The connection has been
+// reserved, and is released when the response body is closed, or when
+// roundTrip returns an error.
+func (cc *http3ClientConn) roundTrip(req *Request) (_ *Response, err error) {
+	ctx := req.Context()
+	trace := httptrace.ContextClientTrace(ctx)
+	released := false
+	defer func() {
+		if err != nil && !released {
+			cc.release()
+		}
+	}()
+
+	contentLength := http3ActualContentLength(req)
+	hasBody := contentLength != 0
+
+	// Requests which can be replayed by an attacker are only sent in
+	// 0-RTT data if they are safe (RFC 8470, Section 2.1).
+	select {
+	case <-cc.qc.HandshakeComplete():
+	default:
+		if hasBody || (req.Method != "" && req.Method != "GET" && req.Method != "HEAD" && req.Method != "OPTIONS") {
+			select {
+			case <-cc.qc.HandshakeComplete():
+			case <-cc.qc.Done():
+				return nil, errHTTP3Skip
+			case <-ctx.Done():
+				req.closeBody()
+				return nil, ctx.Err()
+			}
+		}
+	}
+
+	fields, requestedGzip, err := cc.encodeHeaders(req, contentLength)
+	if err != nil {
+		req.closeBody()
+		return nil, err
+	}
+
+	st, err := cc.qc.OpenStream(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			req.closeBody()
+			return nil, ctx.Err()
+		}
+		return nil, errHTTP3Skip
+	}
+
+	rs := &http3RequestStream{
+		cc:        cc,
+		st:        st,
+		req:       req,
+		donec:     make(chan struct{}),
+		bodyDonec: make(chan struct{}),
+		continuec: make(chan struct{}),
+	}
+	if _, err := st.Write(http3AppendHeaders(nil, fields)); err != nil {
+		st.Reset(uint64(http3ErrRequestCancelled))
+		return nil, errHTTP3Skip
+	}
+	if trace != nil && trace.WroteHeaders != nil {
+		trace.WroteHeaders()
+	}
+
+	if hasBody {
+		rs.expectContinue = req.expectsContinue()
+		go rs.writeBody(contentLength)
+	} else {
+		close(rs.bodyDonec)
+		rs.writeTrailers()
+		st.Close()
+		if trace != nil && trace.WroteRequest != nil {
+			trace.WroteRequest(httptrace.WroteRequestInfo{})
+		}
+	}
+
+	// Abort the stream when the request is canceled, until the
+	// response body is closed.
+	go func() {
+		select {
+		case <-ctx.Done():
+			rs.abort(ctx.Err())
+		case <-req.Cancel:
+			rs.abort(errRequestCanceled)
+		case <-cc.qc.Done():
+			rs.abort(cc.qc.Err())
+		case <-rs.donec:
+		}
+	}()
+	if d := cc.t.ResponseHeaderTimeout; d > 0 {
+		timer := time.AfterFunc(d, func() {
+			rs.abort(errors.New("net/http: timeout awaiting response headers"))
+		})
+		defer timer.Stop()
+	}
+
+	resp, err := rs.readResponse(trace, requestedGzip)
+	if err != nil {
+		if abortErr := rs.abortErr(); abortErr != nil {
+			err = abortErr
+		}
+		rs.abort(err)
+		rs.close()
+		released = true
+		// Requests rejected by the server, and replayable requests
+		// on a connection that closed before they got a response, can
+		// be retried over TCP.
+		var se *quic.StreamError
+		if errors.As(err, &se) && se.Remote && se.Code == uint64(http3ErrRequestRejected) {
+			return nil, errHTTP3Skip
+		}
+		if cc.qc.Err() != nil && ctx.Err() == nil && req.isReplayable() {
+			return nil, errHTTP3Skip
+		}
+		rs.closeReqBody()
+		return nil, err
+	}
+	released = true
+	return resp, nil
+}
+
+// encodeHeaders returns the fields of the HEADERS frame of req.
+func (cc *http3ClientConn) encodeHeaders(req *Request, contentLength int64) (fields []qpack.HeaderField, requestedGzip bool, err error) {
+	if err := http3CheckConnHeaders(req); err != nil {
+		return nil, false, err
+	}
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	host, err = httpguts.PunycodeHostPort(host)
+	if err != nil {
+		return nil, false, err
+	}
+	if !httpguts.ValidHostHeader(host) {
+		return nil, false, errors.New("http3: invalid Host header")
+	}
+	method := req.Method
+	if method == "" {
+		method = "GET"
+	}
+	fields = append(fields, qpack.HeaderField{Name: ":method", Value: method})
+	if method != "CONNECT" {
+		path := req.URL.RequestURI()
+		if !strings.HasPrefix(path, "/") && path != "*" {
+			return nil, false, fmt.Errorf("http3: invalid request :path %q", path)
+		}
+		fields = append(fields,
+			qpack.HeaderField{Name: ":scheme", Value: "https"},
+			qpack.HeaderField{Name: ":path", Value: path},
+		)
+	}
+	fields = append(fields, qpack.HeaderField{Name: ":authority", Value: host})
+
+	fields, err = http3AppendHeader(fields, req.Header, true, "content-length", "user-agent", "trailer", "expect")
+	if err != nil {
+		return nil, false, err
+	}
+	if httpguts.HeaderValuesContainsToken(req.Header["Expect"], "100-continue") {
+		fields = append(fields, qpack.HeaderField{Name: "expect", Value: "100-continue"})
+	}
+	if len(req.Trailer) > 0 {
+		keys := make([]string, 0, len(req.Trailer))
+		for k := range req.Trailer {
+			k = CanonicalHeaderKey(k)
+			switch k {
+			case "Transfer-Encoding", "Trailer", "Content-Length":
+				return nil, false, fmt.Errorf("http3: invalid Trailer key %q", k)
+			}
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		fields = append(fields, qpack.HeaderField{Name: "trailer", Value: strings.Join(keys, ",")})
+	}
+	if ua, ok := req.Header["User-Agent"]; !ok {
+		fields = append(fields, qpack.HeaderField{Name: "user-agent", Value: http3DefaultUserAgent})
+	} else if len(ua) > 0 && ua[0] != "" {
+		fields = append(fields, qpack.HeaderField{Name: "user-agent", Value: ua[0]})
+	}
+	if contentLength > 0 || contentLength == 0 && (method == "POST" || method == "PUT" || method == "PATCH") {
+		fields = append(fields, qpack.HeaderField{Name: "content-length", Value: strconv.FormatInt(contentLength, 10)})
+	}
+	// Ask for gzip, like the HTTP/1 and HTTP/2 transports, unless the
+	// caller asked for an encoding or a range.
+	if !cc.t.DisableCompression &&
+		req.Header.Get("Accept-Encoding") == "" &&
+		req.Header.Get("Range") == "" &&
+		method != "HEAD" {
+		requestedGzip = true
+		fields = append(fields, qpack.HeaderField{Name: "accept-encoding", Value: "gzip"})
+	}
+	return fields, requestedGzip, nil
+}
+
+// http3ActualContentLength returns the length of the body of req, where 0
+// means no body and -1 unknown.
+func http3ActualContentLength(req *Request) int64 {
+	if req.Body == nil || req.Body == NoBody {
+		return 0
+	}
+	if req.ContentLength != 0 {
+		return req.ContentLength
+	}
+	return -1
+}
+
+// http3CheckConnHeaders checks whether req has invalid connection-specific
+// header fields. Those which are valid are not sent.
+func http3CheckConnHeaders(req *Request) error {
+	if v := req.Header.Get("Upgrade"); v != "" {
+		return fmt.Errorf("http3: invalid Upgrade request header: %q", req.Header["Upgrade"])
+	}
+	if vv := req.Header["Transfer-Encoding"]; len(vv) > 0 && (len(vv) > 1 || vv[0] != "" && vv[0] != "chunked") {
+		return fmt.Errorf("http3: invalid Transfer-Encoding request header: %q", vv)
+	}
+	if vv := req.Header["Connection"]; len(vv) > 0 && (len(vv) > 1 || vv[0] != "" && !ascii.EqualFold(vv[0], "close") && !ascii.EqualFold(vv[0], "keep-alive")) {
+		return fmt.Errorf("http3: invalid Connection request header: %q", vv)
+	}
+	return nil
+}
+
+// An http3RequestStream is the stream of a request sent by the client.
+type http3RequestStream struct {
+	cc        *http3ClientConn
+	st        *quic.Stream
+	req       *Request
+	donec     chan struct{} // closed when the response body is closed
+	bodyDonec chan struct{} // closed when the request body is written
+	continuec chan struct{} // closed on 100 Continue, or when the request is aborted
+
+	reqBodyOnce    sync.Once
+	expectContinue bool // wait for 100 Continue before sending the body
+
+	mu        sync.Mutex
+	err       error // abort error
+	continued bool  // continuec is closed
+	skipBody  bool  // final response received while waiting for 100 Continue
+	done      bool
+}
+
+// abort resets the stream with err, unless it is already done.
+func (rs *http3RequestStream) abort(err error) {
+	rs.mu.Lock()
+	if rs.err != nil || rs.done {
+		rs.mu.Unlock()
+		return
+	}
+	rs.err = err
+	rs.signalContinueLocked()
+	rs.mu.Unlock()
+	rs.st.CloseRead(uint64(http3ErrRequestCancelled))
+	rs.st.Reset(uint64(http3ErrRequestCancelled))
+	rs.closeReqBody()
+}
+
+// closeReqBody closes the body of the request, which also interrupts the
+// writing of the body.
+func (rs *http3RequestStream) closeReqBody() {
+	rs.reqBodyOnce.Do(func() {
+		if rs.req.Body != nil {
+			rs.req.Body.Close()
+		}
+	})
+}
+
+func (rs *http3RequestStream) abortErr() error {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return rs.err
+}
+
+func (rs *http3RequestStream) signalContinueLocked() {
+	if !rs.continued {
+		rs.continued = true
+		close(rs.continuec)
+	}
+}
+
+// writeBody writes the body of the request, and its trailers.
+func (rs *http3RequestStream) writeBody(contentLength int64) {
+	defer close(rs.bodyDonec)
+	defer rs.closeReqBody()
+	trace := httptrace.ContextClientTrace(rs.req.Context())
+
+	if rs.expectContinue {
+		d := rs.cc.t.ExpectContinueTimeout
+		var timer <-chan time.Time
+		if d > 0 {
+			t := time.NewTimer(d)
+			defer t.Stop()
+			timer = t.C
+		}
+		select {
+		case <-rs.continuec:
+		case <-timer:
+		}
+		rs.mu.Lock()
+		err, skipBody := rs.err, rs.skipBody
+		rs.mu.Unlock()
+		if err != nil {
+			return
+		}
+		if skipBody {
+			// The server responded without waiting for the body.
+			rs.st.Reset(uint64(http3ErrRequestCancelled))
+			return
+		}
+	}
+
+	dw := &http3DataWriter{w: rs.st}
+	n, err := io.Copy(dw, rs.req.Body)
+	if err == nil && contentLength > 0 && n != contentLength {
+		err = fmt.Errorf("http3: request body length %d does not match ContentLength %d", n, contentLength)
+	}
+	if err == nil {
+		err = rs.writeTrailers()
+	}
+	var se *quic.StreamError
+	if errors.As(err, &se) && se.Remote {
+		// The server does not need the rest of the request, which
+		// does not prevent it from responding (RFC 9114, Section 4.1).
+		rs.st.Reset(se.Code)
+		return
+	}
+	if err != nil {
+		rs.abort(err)
+		return
+	}
+	rs.st.Close()
+	if trace != nil && trace.WroteRequest != nil {
+		trace.WroteRequest(httptrace.WroteRequestInfo{})
+	}
+}
+
+// writeTrailers writes the trailers of the request, if any.
+func (rs *http3RequestStream) writeTrailers() error {
+	if len(rs.req.Trailer) == 0 {
+		return nil
+	}
+	fields, err := http3AppendHeader(nil, rs.req.Trailer, true)
+	if err != nil || len(fields) == 0 {
+		return err
+	}
+	_, err = rs.st.Write(http3AppendHeaders(nil, fields))
+	return err
+}
+
+// readResponse reads the header of the response.
+func (rs *http3RequestStream) readResponse(trace *httptrace.ClientTrace, requestedGzip bool) (*Response, error) {
+	fr := newHTTP3FrameReader(rs.st)
+	num1xx := 0
+	for {
+		fieldList, err := fr.readHeaders(rs.cc.maxHeaderBytes())
+		if err != nil {
+			switch err {
+			case io.EOF:
+				err = io.ErrUnexpectedEOF
+			case errHTTP3HeadersTooLarge:
+				err = fmt.Errorf("net/http: server response headers exceeded %d bytes; aborted", rs.cc.maxHeaderBytes())
+			}
+			return nil, err
+		}
+		if trace != nil && trace.GotFirstResponseByte != nil && num1xx == 0 {
+			trace.GotFirstResponseByte()
+		}
+		f, err := parseHTTP3Fields(fieldList, "status")
+		if err != nil {
+			return nil, err
+		}
+		status := f.pseudo["status"]
+		code, err := strconv.Atoi(status)
+		if err != nil || len(status) != 3 || code < 100 {
+			return nil, http3StreamError(http3ErrMessageError, "malformed :status")
+		}
+		if code < 200 {
+			if code == StatusSwitchingProtocols {
+				return nil, http3StreamError(http3ErrMessageError, "101 Switching Protocols response")
+			}
+			num1xx++
+			if num1xx > 5 {
+				return nil, errors.New("http3: too many 1xx informational responses")
+			}
+			if trace != nil && trace.Got1xxResponse != nil {
+				if err := trace.Got1xxResponse(code, textproto.MIMEHeader(f.header)); err != nil {
+					return nil, err
+				}
+			}
+			if code == StatusContinue {
+				if trace != nil && trace.Got100Continue != nil {
+					trace.Got100Continue()
+				}
+				rs.mu.Lock()
+				rs.signalContinueLocked()
+				rs.mu.Unlock()
+			}
+			continue
+		}
+		// The body of a request expecting 100 Continue is not sent
+		// after a final response.
+		rs.mu.Lock()
+		if !rs.continued {
+			rs.skipBody = true
+			rs.signalContinueLocked()
+		}
+		rs.mu.Unlock()
+		return rs.newResponse(fr, code, f.header, requestedGzip)
+	}
+}
+
+func (rs *http3RequestStream) newResponse(fr *http3FrameReader, code int, header Header, requestedGzip bool) (*Response, error) {
+	cs := rs.cc.qc.ConnectionState()
+	resp := &Response{
+		Status:        strconv.Itoa(code) + " " + StatusText(code),
+		StatusCode:    code,
+		Proto:         "HTTP/3.0",
+		ProtoMajor:    3,
+		Header:        header,
+		ContentLength: -1,
+		Request:       rs.req,
+		TLS:           &cs,
+	}
+	if vv := header["Content-Length"]; len(vv) > 0 {
+		if cl, err := strconv.ParseUint(vv[0], 10, 63); err == nil {
+			resp.ContentLength = int64(cl)
+		}
+	}
+	for _, v := range header["Trailer"] {
+		foreachHeaderElement(v, func(key string) {
+			key = CanonicalHeaderKey(key)
+			switch key {
+			case "Transfer-Encoding", "Trailer", "Content-Length":
+				// Bogus. (copy of http1 rules)
+				// Ignore.
+			default:
+				if resp.Trailer == nil {
+					resp.Trailer = make(Header)
+				}
+				resp.Trailer[key] = nil
+			}
+		})
+	}
+	delete(header, "Trailer")
+
+	if rs.req.Method == "HEAD" || !bodyAllowedForStatus(code) {
+		resp.Body = NoBody
+		rs.close()
+		return resp, nil
+	}
+	contentLength := resp.ContentLength
+	if contentLength >= 0 && len(header["Content-Length"]) > 1 {
+		contentLength = -1
+	}
+	if contentLength == 0 && resp.Trailer == nil {
+		// The rest of the stream can only hold trailers which were
+		// not announced. Like HTTP/1, do not wait for it, so the
+		// request is done even if the body is never read.
+		rs.st.CloseRead(uint64(http3ErrNoError))
+		resp.Body = NoBody
+		rs.close()
+		return resp, nil
+	}
+	body := &http3ResponseBody{
+		rs: rs,
+		body: http3Body{
+			fr:            fr,
+			contentLength: contentLength,
+			maxTrailers:   rs.cc.maxHeaderBytes(),
+			onTrailers: func(h Header) {
+				for k, vv := range h {
+					if resp.Trailer == nil {
+						resp.Trailer = make(Header)
+					}
+					resp.Trailer[k] = vv
+				}
+			},
+		},
+	}
+	resp.Body = body
+	if requestedGzip && ascii.EqualFold(header.Get("Content-Encoding"), "gzip") {
+		header.Del("Content-Encoding")
+		header.Del("Content-Length")
+		resp.ContentLength = -1
+		resp.Body = &http3GzipReader{body: body}
+		resp.Uncompressed = true
+	}
+	return resp, nil
+}
+
+// close ends the request, once its response has been read or discarded.
+func (rs *http3RequestStream) close() {
+	rs.mu.Lock()
+	if rs.done {
+		rs.mu.Unlock()
+		return
+	}
+	rs.done = true
+	rs.mu.Unlock()
+	close(rs.donec)
+	rs.cc.release()
+}
+
+// http3ResponseBody is the body of a response received by the client.
+type http3ResponseBody struct {
+	rs     *http3RequestStream
+	mu     sync.Mutex
+	body   http3Body
+	closed bool
+}
+
+func (b *http3ResponseBody) Read(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return 0, errReadOnClosedResBody
+	}
+	n, err := b.body.Read(p)
+	if err != nil {
+		if abortErr := b.rs.abortErr(); abortErr != nil && err != io.EOF {
+			err = abortErr
+		}
+		if err == io.EOF {
+			b.rs.close()
+		} else {
+			var he *http3Error
+			if errors.As(err, &he) {
+				http3AbortConn(b.rs.cc.qc, err)
+			}
+			b.rs.abort(err)
+		}
+	}
+	return n, err
+}
+
+func (b *http3ResponseBody) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+	rs := b.rs
+	rs.mu.Lock()
+	done := rs.done
+	rs.mu.Unlock()
+	if !done {
+		// The rest of the response is not needed. The request body
+		// is only abandoned if it is still being sent.
+		rs.st.CloseRead(uint64(http3ErrRequestCancelled))
+		select {
+		case <-rs.bodyDonec:
+		default:
+			rs.st.Reset(uint64(http3ErrRequestCancelled))
+			rs.closeReqBody()
+		}
+		rs.close()
+	}
+	return nil
+}
+
+// http3GzipReader wraps a response body so it can lazily call
+// gzip.NewReader on the first call to Read.
+type http3GzipReader struct {
+	body io.ReadCloser // underlying Response.Body
+	zr   *gzip.Reader  // lazily-initialized gzip reader
+	zerr error         // sticky error
+}
+
+func (gz *http3GzipReader) Read(p []byte) (n int, err error) {
+	if gz.zerr != nil {
+		return 0, gz.zerr
+	}
+	if gz.zr == nil {
+		gz.zr, err = gzip.NewReader(gz.body)
+		if err != nil {
+			gz.zerr = err
+			return 0, err
+		}
+	}
+	return gz.zr.Read(p)
+}
+
+func (gz *http3GzipReader) Close() error {
+	if err := gz.body.Close(); err != nil {
+		return err
+	}
+	gz.zerr = fs.ErrClosed
+	return nil
+}
@@ -14,6 +14,7 @@ import (
 	"os"
 	"reflect"
 	"regexp"
+	"slices"
 	"strings"
 	"testing"
 )
@@ -47,6 +48,65 @@ func TestForeachHeaderElement(t *testing.T) {
 	}
 }
 
+func TestProtocols(t *testing.T) {
+	var p Protocols
+	if p.HTTP1() {
+		t.Errorf("zero-value protocols: p.HTTP1() = true, want false")
+	}
+	p.SetHTTP1(true)
+	p.SetHTTP2(true)
+	if !p.HTTP1() {
+		t.Errorf("initialized protocols: p.HTTP1() = false, want true")
+	}
+	if !p.HTTP2() {
+		t.Errorf("initialized protocols: p.HTTP2() = false, want true")
+	}
+	if p.UnencryptedHTTP2() {
+		t.Errorf("initialized protocols: p.UnencryptedHTTP2() = true, want false")
+	}
+	if got, want := p.String(), "{HTTP1,HTTP2}"; got != want {
+		t.Errorf("p.String() = %q, want %q", got, want)
+	}
+	p.SetHTTP1(false)
+	p.SetUnencryptedHTTP2(true)
+	if got, want := p.String(), "{HTTP2,UnencryptedHTTP2}"; got != want {
+		t.Errorf("p.String() = %q, want %q", got, want)
+	}
+}
+
+func TestAdjustNextProtos(t *testing.T) {
+	protos := func(http1, http2 bool) Protocols {
+		var p Protocols
+		p.SetHTTP1(http1)
+		p.SetHTTP2(http2)
+		p.SetUnencryptedHTTP2(true) // never affects ALPN
+		return p
+	}
+	for _, test := range []struct {
+		in     []string
+		protos Protocols
+		want   []string
+	}{
+		{nil, protos(true, true), []string{"h2", "http/1.1"}},
+		{nil, protos(true, false), []string{"http/1.1"}},
+		{nil, protos(false, true), []string{"h2"}},
+		{nil, protos(false, false), nil},
+		{[]string{"http/1.1", "h2"}, protos(true, true), []string{"http/1.1", "h2"}},
+		{[]string{"foo", "h2", "http/1.1"}, protos(true, false), []string{"foo", "http/1.1"}},
+		{[]string{"foo", "h2", "http/1.1"}, protos(false, true), []string{"foo", "h2"}},
+		{[]string{"foo"}, protos(true, true), []string{"foo", "h2", "http/1.1"}},
+	} {
+		in := slices.Clone(test.in)
+		got := adjustNextProtos(in, test.protos)
+		if !slices.Equal(got, test.want) {
+			t.Errorf("adjustNextProtos(%q, %v) = %q, want %q", test.in, test.protos, got, test.want)
+		}
+		if !slices.Equal(in, test.in) {
+			t.Errorf("adjustNextProtos(%q, %v) modified its input to %q", test.in, test.protos, in)
+		}
+	}
+}
+
 // Test that cmd/go doesn't link in the HTTP server.
 //
 // This catches accidental dependencies between the HTTP transport and
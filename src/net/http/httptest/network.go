@@ -0,0 +1,667 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// In-memory network
+
+package httptest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// A Network is an in-memory network, which connects the listeners
+// returned by its Listen method with the connections returned by its
+// Dial and DialContext methods, without using the operating system's
+// network. Tests can use a Network to avoid the cost and limits of
+// loopback sockets, and to simulate slow or faulty networks.
+//
+// Use [Network.NewServer] to start a [Server] listening on a Network.
+// The server's [Server.Client] dials the server through the Network.
+// Other clients can use the Network with an [http.Transport]:
+//
+//	tr := &http.Transport{DialContext: network.DialContext}
+//
+// Connections on a Network behave like TCP connections: writes are
+// buffered, and each direction of a connection can be closed
+// independently with CloseWrite and CloseRead methods.
+//
+// The fields of a Network must not be modified while it is in use.
+// Its methods may be called concurrently.
+type Network struct {
+	// Latency is the time it takes for data written to a
+	// connection to become available to read at the other end.
+	Latency time.Duration
+
+	// Bandwidth, if positive, limits the rate in bytes per second
+	// at which data written to each direction of a connection is
+	// delivered.
+	Bandwidth int64
+
+	// BufferSize is the number of bytes that may be written to each
+	// direction of a connection and not yet read. Writes block while
+	// the buffer is full. If zero, 256KiB is used.
+	BufferSize int
+
+	// Fault, if non-nil, is called for each write to a connection
+	// with the connection's source and destination addresses and the
+	// data written, and returns the fault to inject into the write.
+	// Fault must not modify or retain data.
+	Fault func(src, dst net.Addr, data []byte) Fault
+
+	mu          sync.Mutex
+	listeners   map[string]*networkListener
+	conns       map[*networkConn]struct{}
+	partitioned map[string]bool
+	changed     chan struct{} // closed and replaced when partitions change
+	nextPort    int
+}
+
+// A Fault is a fault injected into a write to a connection
+// on a [Network].
+type Fault int
+
+const (
+	// FaultNone delivers the data.
+	FaultNone Fault = iota
+
+	// FaultDrop discards the data.
+	// The write appears to succeed.
+	FaultDrop
+
+	// FaultCorrupt delivers the data with one bit changed.
+	FaultCorrupt
+
+	// FaultReset resets the connection. The write, and all later
+	// reads and writes at both ends of the connection, fail.
+	FaultReset
+)
+
+var (
+	errConnRefused = errors.New("connection refused")
+	errConnReset   = errors.New("connection reset by peer")
+	errBrokenPipe  = errors.New("broken pipe")
+	errAddrInUse   = errors.New("address already in use")
+)
+
+// A networkAddr is the address of an endpoint on a Network.
+type networkAddr string
+
+func (a networkAddr) Network() string { return "tcp" }
+func (a networkAddr) String() string  { return string(a) }
+
+func (n *Network) bufferSize() int {
+	if n.BufferSize > 0 {
+		return n.BufferSize
+	}
+	return 256 << 10
+}
+
+// init initializes n. It is called with n.mu held.
+func (n *Network) init() {
+	if n.listeners == nil {
+		n.listeners = make(map[string]*networkListener)
+		n.conns = make(map[*networkConn]struct{})
+		n.partitioned = make(map[string]bool)
+		n.changed = make(chan struct{})
+	}
+}
+
+// port returns an unused port number. It is called with n.mu held.
+func (n *Network) port() string {
+	n.nextPort++
+	return strconv.Itoa(32767 + n.nextPort)
+}
+
+// Listen returns a listener for connections to address on n.
+// The network must be "tcp", "tcp4", or "tcp6". The address has the
+// form "host:port". If the host is empty, 127.0.0.1 is used, and if
+// the port is empty or "0", an unused port number is chosen. If the
+// address is empty, both are chosen.
+func (n *Network) Listen(network, address string) (net.Listener, error) {
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+	default:
+		return nil, &net.OpError{Op: "listen", Net: network, Err: net.UnknownNetworkError(network)}
+	}
+	if address == "" {
+		address = ":0"
+	}
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, &net.OpError{Op: "listen", Net: network, Err: err}
+	}
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.init()
+	if port == "" || port == "0" {
+		port = n.port()
+	}
+	addr := networkAddr(net.JoinHostPort(host, port))
+	if _, ok := n.listeners[string(addr)]; ok {
+		return nil, &net.OpError{Op: "listen", Net: network, Addr: addr, Err: errAddrInUse}
+	}
+	l := &networkListener{
+		n:     n,
+		addr:  addr,
+		conns: make(chan *networkConn, 128),
+		done:  make(chan struct{}),
+	}
+	n.listeners[string(addr)] = l
+	return l, nil
+}
+
+// Dial connects to the listener for address on n.
+func (n *Network) Dial(network, address string) (net.Conn, error) {
+	return n.DialContext(context.Background(), network, address)
+}
+
+// DialContext connects to the listener for address on n.
+// It has the signature of the DialContext field of [http.Transport].
+//
+// If the address is partitioned from the network, DialContext blocks
+// until the partition is healed or ctx is done.
+func (n *Network) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	opError := func(err error) error {
+		return &net.OpError{Op: "dial", Net: network, Addr: networkAddr(address), Err: err}
+	}
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+	default:
+		return nil, opError(net.UnknownNetworkError(network))
+	}
+	for {
+		n.mu.Lock()
+		n.init()
+		l := n.listeners[address]
+		partitioned := n.partitioned[address]
+		changed := n.changed
+		n.mu.Unlock()
+		if l == nil {
+			return nil, opError(errConnRefused)
+		}
+		if partitioned {
+			select {
+			case <-changed:
+				continue
+			case <-ctx.Done():
+				return nil, opError(ctx.Err())
+			}
+		}
+		n.mu.Lock()
+		local := networkAddr(net.JoinHostPort("127.0.0.1", n.port()))
+		n.mu.Unlock()
+		client, server := n.newConnPair(local, l.addr)
+		select {
+		case l.conns <- server:
+			return client, nil
+		case <-l.done:
+			client.Close()
+			server.Close()
+			return nil, opError(errConnRefused)
+		case <-ctx.Done():
+			client.Close()
+			server.Close()
+			return nil, opError(ctx.Err())
+		}
+	}
+}
+
+// NewServer starts and returns a new [Server] listening on n.
+// The caller should call Close when finished, to shut it down.
+func (n *Network) NewServer(handler http.Handler) *Server {
+	ts := n.NewUnstartedServer(handler)
+	ts.Start()
+	return ts
+}
+
+// NewUnstartedServer returns a new [Server] listening on n,
+// but doesn't start it.
+//
+// After changing its configuration, the caller should call Start or
+// StartTLS.
+//
+// The caller should call Close when finished, to shut it down.
+func (n *Network) NewUnstartedServer(handler http.Handler) *Server {
+	l, err := n.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic("httptest: failed to listen on network: " + err.Error())
+	}
+	return &Server{
+		Listener: l,
+		Config:   &http.Server{Handler: handler},
+		dial:     n.DialContext,
+	}
+}
+
+// Partition partitions address from the network. Data sent to or from
+// address on existing connections is not delivered, and dials to
+// address block, until the partition is healed with [Network.Heal].
+func (n *Network) Partition(address string) {
+	n.setPartitioned(address, true)
+}
+
+// Heal heals a partition created by [Network.Partition].
+func (n *Network) Heal(address string) {
+	n.setPartitioned(address, false)
+}
+
+func (n *Network) setPartitioned(address string, partitioned bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.init()
+	if partitioned {
+		n.partitioned[address] = true
+	} else {
+		delete(n.partitioned, address)
+	}
+	close(n.changed)
+	n.changed = make(chan struct{})
+}
+
+// isPartitioned reports whether data between the addresses a and b
+// is not delivered, and returns a channel which is closed when that
+// may change.
+func (n *Network) isPartitioned(a, b networkAddr) (bool, <-chan struct{}) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.init()
+	return n.partitioned[string(a)] || n.partitioned[string(b)], n.changed
+}
+
+// ResetConnections resets all connections to or from address.
+// Later reads and writes at both ends of the connections fail.
+func (n *Network) ResetConnections(address string) {
+	n.mu.Lock()
+	var reset []*networkConn
+	for c := range n.conns {
+		if string(c.local) == address || string(c.remote) == address {
+			reset = append(reset, c)
+		}
+	}
+	n.mu.Unlock()
+	for _, c := range reset {
+		c.reset()
+	}
+}
+
+// A networkListener is a listener on a Network.
+type networkListener struct {
+	n         *Network
+	addr      networkAddr
+	conns     chan *networkConn
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func (l *networkListener) Accept() (net.Conn, error) {
+	select {
+	case c := <-l.conns:
+		return c, nil
+	case <-l.done:
+		return nil, &net.OpError{Op: "accept", Net: "tcp", Addr: l.addr, Err: net.ErrClosed}
+	}
+}
+
+func (l *networkListener) Close() error {
+	l.closeOnce.Do(func() {
+		l.n.mu.Lock()
+		delete(l.n.listeners, string(l.addr))
+		l.n.mu.Unlock()
+		close(l.done)
+		// Reset connections which have not been accepted.
+		for {
+			select {
+			case c := <-l.conns:
+				c.reset()
+				c.Close()
+			default:
+				return
+			}
+		}
+	})
+	return nil
+}
+
+func (l *networkListener) Addr() net.Addr { return l.addr }
+
+// newConnPair returns the two ends of a new connection
+// between the addresses a and b.
+func (n *Network) newConnPair(a, b networkAddr) (*networkConn, *networkConn) {
+	ab := newNetworkPipe(n, a, b)
+	ba := newNetworkPipe(n, b, a)
+	ca := &networkConn{n: n, local: a, remote: b, r: ba, w: ab}
+	cb := &networkConn{n: n, local: b, remote: a, r: ab, w: ba}
+	n.mu.Lock()
+	n.conns[ca] = struct{}{}
+	n.conns[cb] = struct{}{}
+	n.mu.Unlock()
+	return ca, cb
+}
+
+// A networkConn is one end of a connection on a Network.
+type networkConn struct {
+	n             *Network
+	local, remote networkAddr
+	r             *networkPipe // data from the remote end
+	w             *networkPipe // data to the remote end
+}
+
+func (c *networkConn) opError(op string, err error) error {
+	if err == io.EOF {
+		return err
+	}
+	return &net.OpError{Op: op, Net: "tcp", Source: c.local, Addr: c.remote, Err: err}
+}
+
+func (c *networkConn) Read(b []byte) (int, error) {
+	n, err := c.r.read(b)
+	if err != nil {
+		err = c.opError("read", err)
+	}
+	return n, err
+}
+
+func (c *networkConn) Write(b []byte) (int, error) {
+	n, err := c.w.write(b)
+	if err == errConnReset {
+		c.reset()
+	}
+	if err != nil {
+		err = c.opError("write", err)
+	}
+	return n, err
+}
+
+// Close closes the connection.
+func (c *networkConn) Close() error {
+	c.n.mu.Lock()
+	delete(c.n.conns, c)
+	c.n.mu.Unlock()
+	c.r.closeReader(true)
+	c.w.closeWriter(true)
+	return nil
+}
+
+// CloseRead shuts down the reading side of the connection.
+func (c *networkConn) CloseRead() error {
+	c.r.closeReader(false)
+	return nil
+}
+
+// CloseWrite shuts down the writing side of the connection.
+// The remote end reads io.EOF after reading any data written before.
+func (c *networkConn) CloseWrite() error {
+	c.w.closeWriter(false)
+	return nil
+}
+
+func (c *networkConn) reset() {
+	c.r.reset()
+	c.w.reset()
+}
+
+func (c *networkConn) LocalAddr() net.Addr  { return c.local }
+func (c *networkConn) RemoteAddr() net.Addr { return c.remote }
+
+func (c *networkConn) SetDeadline(t time.Time) error {
+	c.r.setDeadline(t, true)
+	c.w.setDeadline(t, false)
+	return nil
+}
+
+func (c *networkConn) SetReadDeadline(t time.Time) error {
+	c.r.setDeadline(t, true)
+	return nil
+}
+
+func (c *networkConn) SetWriteDeadline(t time.Time) error {
+	c.w.setDeadline(t, false)
+	return nil
+}
+
+// A networkPipe carries data in one direction of a connection.
+type networkPipe struct {
+	n        *Network
+	src, dst networkAddr
+
+	mu            sync.Mutex
+	segs          []networkSegment // data written and not yet read
+	size          int              // bytes in segs
+	sendDone      time.Time        // when the data in segs has been sent, for Bandwidth
+	readDeadline  time.Time
+	writeDeadline time.Time
+	readerClosed  bool  // the reading end was closed
+	readerGone    bool  // the reading end was closed by Close
+	writerClosed  bool  // the writing end was closed
+	writerGone    bool  // the writing end was closed by Close
+	err           error // the connection was reset
+	changed       chan struct{}
+}
+
+// A networkSegment is data written to a networkPipe, which
+// can be read after a time.
+type networkSegment struct {
+	data []byte
+	at   time.Time
+}
+
+func newNetworkPipe(n *Network, src, dst networkAddr) *networkPipe {
+	return &networkPipe{n: n, src: src, dst: dst, changed: make(chan struct{})}
+}
+
+// signal wakes goroutines waiting for p to change.
+// It is called with p.mu held.
+func (p *networkPipe) signal() {
+	close(p.changed)
+	p.changed = make(chan struct{})
+}
+
+// wait waits until p changes, partitions change, or the time t, if
+// not zero. It is called with p.mu held, and returns with it held.
+func (p *networkPipe) wait(partitionChanged <-chan struct{}, t time.Time) {
+	changed := p.changed
+	p.mu.Unlock()
+	defer p.mu.Lock()
+	var timeout <-chan time.Time
+	if !t.IsZero() {
+		timer := time.NewTimer(time.Until(t))
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case <-changed:
+	case <-partitionChanged:
+	case <-timeout:
+	}
+}
+
+// earliest returns the earlier of the times a and b,
+// where the zero time is later than all others.
+func earliest(a, b time.Time) time.Time {
+	if a.IsZero() || (!b.IsZero() && b.Before(a)) {
+		return b
+	}
+	return a
+}
+
+func (p *networkPipe) read(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for {
+		switch {
+		case p.readerGone:
+			return 0, net.ErrClosed
+		case p.err != nil:
+			return 0, p.err
+		case p.readerClosed:
+			return 0, io.EOF
+		}
+		now := time.Now()
+		if !p.readDeadline.IsZero() && !now.Before(p.readDeadline) {
+			return 0, os.ErrDeadlineExceeded
+		}
+		partitioned, partitionChanged := p.n.isPartitioned(p.src, p.dst)
+		var next time.Time // when the next segment arrives
+		if !partitioned && len(p.segs) > 0 {
+			if next = p.segs[0].at; !now.Before(next) {
+				break
+			}
+		}
+		if len(b) == 0 {
+			return 0, nil
+		}
+		if len(p.segs) == 0 && p.writerClosed {
+			return 0, io.EOF
+		}
+		p.wait(partitionChanged, earliest(next, p.readDeadline))
+	}
+	n := 0
+	now := time.Now()
+	for len(p.segs) > 0 && n < len(b) && !now.Before(p.segs[0].at) {
+		seg := &p.segs[0]
+		m := copy(b[n:], seg.data)
+		n += m
+		seg.data = seg.data[m:]
+		if len(seg.data) == 0 {
+			p.segs[0] = networkSegment{}
+			p.segs = p.segs[1:]
+		}
+	}
+	p.size -= n
+	p.signal()
+	return n, nil
+}
+
+func (p *networkPipe) write(b []byte) (int, error) {
+	fault := FaultNone
+	if p.n.Fault != nil {
+		fault = p.n.Fault(p.src, p.dst, b)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch fault {
+	case FaultDrop:
+		if err := p.writeErr(); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	case FaultCorrupt:
+		if len(b) > 0 {
+			b = append([]byte(nil), b...)
+			b[len(b)/2] ^= 1
+		}
+	case FaultReset:
+		p.err = errConnReset
+		p.segs = nil
+		p.signal()
+		return 0, errConnReset
+	}
+	n := 0
+	for {
+		if err := p.writeErr(); err != nil {
+			return n, err
+		}
+		now := time.Now()
+		if !p.writeDeadline.IsZero() && !now.Before(p.writeDeadline) {
+			return n, os.ErrDeadlineExceeded
+		}
+		if n == len(b) {
+			return n, nil
+		}
+		if p.readerClosed {
+			// Discard data which will not be read.
+			return len(b), nil
+		}
+		space := p.n.bufferSize() - p.size
+		if space <= 0 {
+			p.wait(nil, p.writeDeadline)
+			continue
+		}
+		chunk := b[n:][:min(space, len(b)-n)]
+		sent := now
+		if p.sendDone.After(sent) {
+			sent = p.sendDone
+		}
+		if bw := p.n.Bandwidth; bw > 0 {
+			sent = sent.Add(time.Duration(int64(len(chunk)) * int64(time.Second) / bw))
+		}
+		p.sendDone = sent
+		p.segs = append(p.segs, networkSegment{
+			data: append([]byte(nil), chunk...),
+			at:   sent.Add(p.n.Latency),
+		})
+		p.size += len(chunk)
+		n += len(chunk)
+		p.signal()
+	}
+}
+
+// writeErr returns the error for a write to p, if any.
+// It is called with p.mu held.
+func (p *networkPipe) writeErr() error {
+	switch {
+	case p.writerGone:
+		return net.ErrClosed
+	case p.err != nil:
+		return p.err
+	case p.writerClosed, p.readerGone:
+		return errBrokenPipe
+	}
+	return nil
+}
+
+// closeReader closes the reading end of p.
+// If gone is true, the reading connection was closed.
+func (p *networkPipe) closeReader(gone bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.readerClosed = true
+	p.readerGone = p.readerGone || gone
+	p.segs = nil
+	p.size = 0
+	p.signal()
+}
+
+// closeWriter closes the writing end of p.
+// If gone is true, the writing connection was closed.
+func (p *networkPipe) closeWriter(gone bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.writerClosed = true
+	p.writerGone = p.writerGone || gone
+	p.signal()
+}
+
+func (p *networkPipe) reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err == nil {
+		p.err = errConnReset
+	}
+	p.segs = nil
+	p.size = 0
+	p.signal()
+}
+
+func (p *networkPipe) setDeadline(t time.Time, read bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if read {
+		p.readDeadline = t
+	} else {
+		p.writeDeadline = t
+	}
+	p.signal()
+}
@@ -0,0 +1,296 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package httptest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"golang.org/x/net/nettest"
+)
+
+// networkConnPair returns the two ends of a connection on n.
+func networkConnPair(t testing.TB, n *Network) (client, server net.Conn) {
+	t.Helper()
+	l, err := n.Listen("tcp", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	client, err = n.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err = l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, server
+}
+
+func TestNetworkConn(t *testing.T) {
+	nettest.TestConn(t, func() (c1, c2 net.Conn, stop func(), err error) {
+		n := new(Network)
+		c1, c2 = networkConnPair(t, n)
+		stop = func() {
+			c1.Close()
+			c2.Close()
+		}
+		return c1, c2, stop, nil
+	})
+}
+
+func TestNetworkServer(t *testing.T) {
+	n := new(Network)
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.Proto)
+	})
+	for _, test := range []struct {
+		name  string
+		start func(*Server)
+		want  string
+	}{
+		{"HTTP/1", (*Server).Start, "HTTP/1.1"},
+		{"HTTPS", (*Server).StartTLS, "HTTP/1.1"},
+		{"HTTP/2", func(ts *Server) {
+			ts.EnableHTTP2 = true
+			ts.StartTLS()
+		}, "HTTP/2.0"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			ts := n.NewUnstartedServer(h)
+			test.start(ts)
+			defer ts.Close()
+			for range 3 {
+				res, err := ts.Client().Get(ts.URL)
+				if err != nil {
+					t.Fatal(err)
+				}
+				body, err := io.ReadAll(res.Body)
+				res.Body.Close()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(body) != test.want {
+					t.Errorf("server saw protocol %q, want %q", body, test.want)
+				}
+			}
+		})
+	}
+
+	// The servers are not on the system's network.
+	ts := n.NewServer(h)
+	defer ts.Close()
+	if _, err := http.Get(ts.URL); err == nil {
+		t.Errorf("Get of server on Network with the default client succeeded, want error")
+	}
+}
+
+func TestNetworkDial(t *testing.T) {
+	n := new(Network)
+	if _, err := n.Dial("tcp", "127.0.0.1:80"); err == nil {
+		t.Errorf("Dial with no listener succeeded, want error")
+	}
+	l, err := n.Listen("tcp", "example.com:80")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := n.Listen("tcp", "example.com:80"); err == nil {
+		t.Errorf("second Listen on the same address succeeded, want error")
+	}
+	c, err := n.Dial("tcp", "example.com:80")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if got := c.RemoteAddr().String(); got != "example.com:80" {
+		t.Errorf("RemoteAddr() = %q, want example.com:80", got)
+	}
+	l.Close()
+	if _, err := l.Accept(); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("Accept after Close: %v, want net.ErrClosed", err)
+	}
+	if _, err := c.Read(make([]byte, 1)); err == nil {
+		t.Errorf("Read on connection which was never accepted succeeded, want reset")
+	}
+	if _, err := n.Dial("tcp", "example.com:80"); err == nil {
+		t.Errorf("Dial after listener closed succeeded, want error")
+	}
+}
+
+func TestNetworkHalfClose(t *testing.T) {
+	c1, c2 := networkConnPair(t, new(Network))
+	defer c1.Close()
+	defer c2.Close()
+	io.WriteString(c1, "request")
+	c1.(interface{ CloseWrite() error }).CloseWrite()
+	got, err := io.ReadAll(c2)
+	if err != nil || string(got) != "request" {
+		t.Fatalf("ReadAll after CloseWrite = %q, %v; want %q", got, err, "request")
+	}
+	if _, err := c1.Write([]byte("x")); err == nil {
+		t.Errorf("Write after CloseWrite succeeded, want error")
+	}
+	// The other direction is still open.
+	io.WriteString(c2, "response")
+	c2.Close()
+	got, err = io.ReadAll(c1)
+	if err != nil || string(got) != "response" {
+		t.Errorf("ReadAll of response = %q, %v; want %q", got, err, "response")
+	}
+}
+
+func TestNetworkLatency(t *testing.T) {
+	const latency = 50 * time.Millisecond
+	c1, c2 := networkConnPair(t, &Network{Latency: latency})
+	defer c1.Close()
+	defer c2.Close()
+	start := time.Now()
+	io.WriteString(c1, "ping")
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(c2, buf); err != nil {
+		t.Fatal(err)
+	}
+	io.WriteString(c2, "pong")
+	if _, err := io.ReadFull(c1, buf); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d < 2*latency {
+		t.Errorf("round trip took %v, want at least %v", d, 2*latency)
+	}
+}
+
+func TestNetworkBandwidth(t *testing.T) {
+	const (
+		bandwidth = 1 << 20 // bytes per second
+		size      = 100 << 10
+		want      = size * time.Second / bandwidth
+	)
+	c1, c2 := networkConnPair(t, &Network{Bandwidth: bandwidth})
+	defer c1.Close()
+	defer c2.Close()
+	start := time.Now()
+	go func() {
+		c1.Write(make([]byte, size))
+		c1.Close()
+	}()
+	n, err := io.Copy(io.Discard, c2)
+	if err != nil || n != size {
+		t.Fatalf("read %v bytes, %v; want %v", n, err, size)
+	}
+	if d := time.Since(start); d < want {
+		t.Errorf("transfer took %v, want at least %v", d, want)
+	}
+}
+
+func TestNetworkBufferSize(t *testing.T) {
+	c1, c2 := networkConnPair(t, &Network{BufferSize: 10})
+	defer c1.Close()
+	defer c2.Close()
+	c1.SetWriteDeadline(time.Now().Add(10 * time.Millisecond))
+	n, err := c1.Write(make([]byte, 20))
+	if n != 10 || !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("Write of more than BufferSize = %v, %v; want 10, deadline exceeded", n, err)
+	}
+}
+
+func TestNetworkReset(t *testing.T) {
+	n := new(Network)
+	c1, c2 := networkConnPair(t, n)
+	defer c1.Close()
+	defer c2.Close()
+	io.WriteString(c1, "unread")
+	n.ResetConnections(c2.LocalAddr().String())
+	if _, err := c2.Read(make([]byte, 10)); err == nil || errors.Is(err, io.EOF) {
+		t.Errorf("Read after reset: %v, want reset error", err)
+	}
+	if _, err := c1.Write([]byte("x")); err == nil {
+		t.Errorf("Write after reset succeeded, want error")
+	}
+}
+
+func TestNetworkPartition(t *testing.T) {
+	n := new(Network)
+	c1, c2 := networkConnPair(t, n)
+	defer c1.Close()
+	defer c2.Close()
+	addr := c2.LocalAddr().String()
+	l, err := n.Listen("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	n.Partition(addr)
+	io.WriteString(c1, "hello")
+	c2.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+	if _, err := c2.Read(make([]byte, 5)); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("Read across partition: %v, want deadline exceeded", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := n.DialContext(ctx, "tcp", addr); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("DialContext across partition: %v, want context.DeadlineExceeded", err)
+	}
+
+	// Data written during the partition is delivered when it heals.
+	done := make(chan string)
+	go func() {
+		c2.SetReadDeadline(time.Time{})
+		buf := make([]byte, 5)
+		io.ReadFull(c2, buf)
+		done <- string(buf)
+	}()
+	time.Sleep(5 * time.Millisecond)
+	n.Heal(addr)
+	if got := <-done; got != "hello" {
+		t.Errorf("read %q after healing partition, want %q", got, "hello")
+	}
+}
+
+func TestNetworkFault(t *testing.T) {
+	data := []byte("0123456789")
+	for _, test := range []struct {
+		fault   Fault
+		want    string
+		wantErr bool
+	}{
+		{FaultNone, "0123456789", false},
+		{FaultDrop, "", false},
+		{FaultCorrupt, "0123446789", false},
+		{FaultReset, "", true},
+	} {
+		n := &Network{
+			Fault: func(src, dst net.Addr, p []byte) Fault {
+				if bytes.Equal(p, data) {
+					return test.fault
+				}
+				return FaultNone
+			},
+		}
+		c1, c2 := networkConnPair(t, n)
+		_, werr := c1.Write(data)
+		if (werr != nil) != test.wantErr {
+			t.Errorf("fault %v: Write error %v, want error %v", test.fault, werr, test.wantErr)
+		}
+		c1.Write([]byte("."))
+		c1.Close()
+		got, err := io.ReadAll(c2)
+		if (err != nil) != test.wantErr {
+			t.Errorf("fault %v: Read error %v, want error %v", test.fault, err, test.wantErr)
+		}
+		if want := test.want + "."; !test.wantErr && string(got) != want {
+			t.Errorf("fault %v: read %q, want %q", test.fault, got, want)
+		}
+		c2.Close()
+	}
+}
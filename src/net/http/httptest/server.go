@@ -7,6 +7,7 @@
 package httptest
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"flag"
@@ -22,7 +23,8 @@ import (
 )
 
 // A Server is an HTTP server listening on a system-chosen port on the
-// local loopback interface, for use in end-to-end HTTP tests.
+// local loopback interface, or on a [Network], for use in end-to-end
+// HTTP tests.
 type Server struct {
 	URL      string // base URL of form http://ipaddr:port with no trailing slash
 	Listener net.Listener
@@ -55,6 +57,10 @@ type Server struct {
 	// client is configured for use with the server.
 	// Its transport is automatically closed when Close is called.
 	client *http.Client
+
+	// dial, if non-nil, is the client's dial function,
+	// for servers listening on a Network.
+	dial func(ctx context.Context, network, addr string) (net.Conn, error)
 }
 
 func newLocalListener() net.Listener {
@@ -127,7 +133,7 @@ func (s *Server) Start() {
 		panic("Server already started")
 	}
 	if s.client == nil {
-		s.client = &http.Client{Transport: &http.Transport{}}
+		s.client = &http.Client{Transport: &http.Transport{DialContext: s.dial}}
 	}
 	s.URL = "http://" + s.Listener.Addr().String()
 	s.wrap()
@@ -178,6 +184,7 @@ func (s *Server) StartTLS() {
 			RootCAs: certpool,
 		},
 		ForceAttemptHTTP2: s.EnableHTTP2,
+		DialContext:       s.dial,
 	}
 	s.Listener = tls.NewListener(s.Listener, s.TLS)
 	s.URL = "https://" + s.Listener.Addr().String()
@@ -161,6 +161,12 @@ type ClientTrace struct {
 	// request and any body. It may be called multiple times
 	// in the case of retried requests.
 	WroteRequest func(WroteRequestInfo)
+
+	// RetryAttempt is called before a Client sends another attempt
+	// of a request, as configured by the Client's RetryPolicy.
+	// For a retry, it is called before the Client waits for the
+	// retry delay.
+	RetryAttempt func(RetryAttemptInfo)
 }
 
 // WroteRequestInfo contains information provided to the WroteRequest
@@ -170,6 +176,28 @@ type WroteRequestInfo struct {
 	Err error
 }
 
+// RetryAttemptInfo contains information provided to the RetryAttempt
+// hook.
+type RetryAttemptInfo struct {
+	// Attempt is the number of the attempt about to be sent.
+	// The first attempt is number 1, so the first retry is number 2.
+	Attempt int
+
+	// Hedged reports whether the attempt is a hedged request,
+	// sent because earlier attempts have not completed in time.
+	Hedged bool
+
+	// Delay is how long the Client waits before sending the attempt.
+	Delay time.Duration
+
+	// StatusCode is the status code of the response to the
+	// previous attempt, or zero if there was no response.
+	StatusCode int
+
+	// Err is the error returned by the previous attempt, if any.
+	Err error
+}
+
 // compose modifies t such that it respects the previously-registered hooks in old,
 // subject to the composition policy requested in t.Compose.
 func (t *ClientTrace) compose(old *ClientTrace) {
@@ -0,0 +1,800 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// HTTP caching (RFC 9111) round tripper
+
+package httputil
+
+import (
+	"bufio"
+	"bytes"
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/internal/ascii"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A Cache stores the responses cached by a [CachingTransport].
+// Keys identify requests, and values are serialized responses.
+//
+// A Cache must be safe for concurrent use by multiple goroutines.
+type Cache interface {
+	// Get returns the value stored for key, if any.
+	// The caller must not modify the returned value.
+	Get(key string) (value []byte, ok bool)
+
+	// Set stores value for key, replacing any previous value.
+	// The Cache may decline to store the value, and may evict it
+	// at any time. The caller must not modify value after calling Set.
+	Set(key string, value []byte)
+
+	// Delete removes the value stored for key, if any.
+	Delete(key string)
+}
+
+// CachingTransport is an [http.RoundTripper] that caches responses
+// according to the HTTP caching specification, RFC 9111.
+//
+// CachingTransport caches responses to GET requests. It serves a stored
+// response without contacting the origin server while the response is
+// fresh, as determined by the Cache-Control, Expires, Date, Age, and
+// Last-Modified response headers, and the Cache-Control and Pragma
+// request headers. It revalidates a stale response which has an ETag or
+// Last-Modified header with a conditional request. It stores a single
+// response for each URL, which is used only for requests that match the
+// request headers named by the response's Vary header.
+//
+// CachingTransport also supports the stale-while-revalidate and
+// stale-if-error Cache-Control extensions defined in RFC 5861.
+//
+// Requests with conditional or Range headers are sent to the origin
+// server without consulting the cache. A successful response to a
+// request with an unsafe method, such as POST, invalidates the stored
+// response for its URL.
+type CachingTransport struct {
+	// Transport is the underlying transport, used to send requests
+	// to the origin server.
+	// If nil, http.DefaultTransport is used.
+	Transport http.RoundTripper
+
+	// Cache stores the cached responses.
+	// If nil, no responses are cached.
+	Cache Cache
+
+	// Shared indicates that the cache is shared between users, as in
+	// a proxy. A shared cache does not store responses marked private,
+	// nor, in general, responses to requests with an Authorization
+	// header, and uses the s-maxage Cache-Control directive.
+	// By default, the cache is private, as appropriate for a client.
+	Shared bool
+
+	// MaxBodySize is the maximum size of a response body to cache.
+	// Larger responses are returned, but not stored.
+	// If zero, DefaultMaxBodySize is used.
+	MaxBodySize int64
+
+	// ErrorLog specifies an optional logger for errors that occur
+	// when revalidating responses in the background.
+	// If nil, logging is done via the log package's standard logger.
+	ErrorLog *log.Logger
+
+	revalidating sync.Map // cache key => struct{}, for background revalidations
+}
+
+// DefaultMaxBodySize is the default value of [CachingTransport.MaxBodySize].
+const DefaultMaxBodySize = 10 << 20
+
+// timeNow is time.Now, overridden in tests.
+var timeNow = time.Now
+
+func (t *CachingTransport) transport() http.RoundTripper {
+	if t.Transport != nil {
+		return t.Transport
+	}
+	return http.DefaultTransport
+}
+
+func (t *CachingTransport) maxBodySize() int64 {
+	if t.MaxBodySize > 0 {
+		return t.MaxBodySize
+	}
+	return DefaultMaxBodySize
+}
+
+func (t *CachingTransport) logf(format string, args ...any) {
+	if t.ErrorLog != nil {
+		t.ErrorLog.Printf(format, args...)
+	} else {
+		log.Printf(format, args...)
+	}
+}
+
+// RoundTrip implements the [http.RoundTripper] interface.
+func (t *CachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.Cache == nil || req.Method != "GET" || hasConditionalHeaders(req.Header) {
+		resp, err := t.transport().RoundTrip(req)
+		if err == nil && t.Cache != nil && !isSafeMethod(req.Method) {
+			t.invalidate(req, resp)
+		}
+		return resp, err
+	}
+
+	key := cacheKey(req)
+	reqCC := parseCacheControl(req.Header)
+	if _, ok := req.Header["Cache-Control"]; !ok && headerHasToken(req.Header, "Pragma", "no-cache") {
+		reqCC["no-cache"] = ""
+	}
+	if reqCC.has("no-store") {
+		return t.transport().RoundTrip(req)
+	}
+
+	e := t.load(key, req)
+	var age, lifetime time.Duration
+	if e != nil {
+		now := timeNow()
+		age = e.age(now)
+		lifetime = e.freshnessLifetime(t.Shared)
+		switch {
+		case e.servable(reqCC, age, lifetime, t.Shared):
+			return e.response(req, age), nil
+		case e.staleWhileRevalidate(reqCC, age, lifetime, t.Shared):
+			t.revalidateInBackground(key, req, e)
+			return e.response(req, age), nil
+		}
+	}
+	if reqCC.has("only-if-cached") {
+		return gatewayTimeout(req), nil
+	}
+
+	outreq := req
+	conditional := e != nil && e.hasValidator()
+	if conditional {
+		outreq = e.conditionalRequest(req, req.Context())
+	}
+	reqTime := timeNow()
+	resp, err := t.transport().RoundTrip(outreq)
+	if e != nil && (err != nil || isServerError(resp.StatusCode)) && e.staleIfError(reqCC, age, lifetime, t.Shared) {
+		if resp != nil {
+			resp.Body.Close()
+		}
+		return e.response(req, e.age(timeNow())), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return t.handleResponse(key, req, reqCC, e, conditional, resp, reqTime, timeNow()), nil
+}
+
+// handleResponse stores or updates the cache with resp, the response
+// to a request for req sent at reqTime and received at respTime,
+// and returns the response to return for req.
+// The entry e is the stored response for req, if any, and conditional
+// reports whether the request validated it.
+func (t *CachingTransport) handleResponse(key string, req *http.Request, reqCC cacheControl, e *cacheEntry, conditional bool, resp *http.Response, reqTime, respTime time.Time) *http.Response {
+	if resp.StatusCode == http.StatusNotModified && conditional {
+		resp.Body.Close()
+		e.update(resp, reqTime, respTime)
+		t.store(key, e)
+		return e.response(req, e.age(respTime))
+	}
+	if !t.storable(req, reqCC, resp) {
+		return resp
+	}
+	if resp.ContentLength > t.maxBodySize() {
+		return resp
+	}
+	e = &cacheEntry{
+		reqTime:    reqTime,
+		respTime:   respTime,
+		varyHeader: varyHeader(req, resp.Header),
+		status:     resp.Status,
+		statusCode: resp.StatusCode,
+		header:     resp.Header.Clone(),
+	}
+	resp.Body = &cachingBody{
+		rc:  resp.Body,
+		max: t.maxBodySize(),
+		done: func(body []byte) {
+			e.body = body
+			t.store(key, e)
+		},
+	}
+	return resp
+}
+
+// revalidateInBackground revalidates the stored response e for req,
+// unless a revalidation of the same key is already in progress.
+func (t *CachingTransport) revalidateInBackground(key string, req *http.Request, e *cacheEntry) {
+	if _, loaded := t.revalidating.LoadOrStore(key, struct{}{}); loaded {
+		return
+	}
+	ctx := context.WithoutCancel(req.Context())
+	conditional := e.hasValidator()
+	var outreq *http.Request
+	if conditional {
+		outreq = e.conditionalRequest(req, ctx)
+	} else {
+		outreq = req.Clone(ctx)
+	}
+	go func() {
+		defer t.revalidating.Delete(key)
+		reqTime := timeNow()
+		resp, err := t.transport().RoundTrip(outreq)
+		if err != nil {
+			t.logf("httputil: revalidating %s: %v", key, err)
+			return
+		}
+		resp = t.handleResponse(key, outreq, cacheControl{}, e, conditional, resp, reqTime, timeNow())
+		// Read the body, storing it in the cache.
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}()
+}
+
+// load returns the stored response for req, or nil if there is none.
+func (t *CachingTransport) load(key string, req *http.Request) *cacheEntry {
+	b, ok := t.Cache.Get(key)
+	if !ok {
+		return nil
+	}
+	e, err := decodeCacheEntry(b)
+	if err != nil {
+		t.Cache.Delete(key)
+		return nil
+	}
+	if !e.matchesVary(req) {
+		return nil
+	}
+	return e
+}
+
+func (t *CachingTransport) store(key string, e *cacheEntry) {
+	t.Cache.Set(key, e.encode())
+}
+
+// storable reports whether resp, the response to req, may be stored,
+// as described in RFC 9111, Section 3.
+func (t *CachingTransport) storable(req *http.Request, reqCC cacheControl, resp *http.Response) bool {
+	cc := parseCacheControl(resp.Header)
+	switch {
+	case reqCC.has("no-store") || cc.has("no-store"):
+		return false
+	case t.Shared && cc.has("private"):
+		return false
+	case t.Shared && req.Header.Get("Authorization") != "" &&
+		!cc.has("must-revalidate") && !cc.has("public") && !cc.has("s-maxage"):
+		return false
+	case headerHasToken(resp.Header, "Vary", "*"):
+		return false
+	case resp.StatusCode == http.StatusPartialContent:
+		return false
+	}
+	if cc.has("public") || cc.has("max-age") || (t.Shared && cc.has("s-maxage")) {
+		return true
+	}
+	if _, ok := resp.Header["Expires"]; ok {
+		return true
+	}
+	return heuristicallyCacheable(resp.StatusCode)
+}
+
+// invalidate removes the stored responses for the URLs affected by the
+// response resp to an unsafe request, as described in RFC 9111, Section 4.4.
+func (t *CachingTransport) invalidate(req *http.Request, resp *http.Response) {
+	if resp.StatusCode < 200 || resp.StatusCode > 399 {
+		return
+	}
+	t.Cache.Delete(cacheKey(req))
+	for _, h := range []string{"Location", "Content-Location"} {
+		v := resp.Header.Get(h)
+		if v == "" {
+			continue
+		}
+		u, err := req.URL.Parse(v)
+		if err != nil || u.Scheme != req.URL.Scheme || u.Host != req.URL.Host {
+			continue
+		}
+		t.Cache.Delete(urlKey(u))
+	}
+}
+
+// cacheKey returns the cache key for req.
+func cacheKey(req *http.Request) string {
+	return urlKey(req.URL)
+}
+
+func urlKey(u *url.URL) string {
+	u2 := *u
+	u2.Fragment = ""
+	u2.RawFragment = ""
+	return u2.String()
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case "GET", "HEAD", "OPTIONS", "TRACE":
+		return true
+	}
+	return false
+}
+
+func hasConditionalHeaders(h http.Header) bool {
+	for _, k := range []string{"If-Match", "If-None-Match", "If-Modified-Since", "If-Unmodified-Since", "If-Range", "Range"} {
+		if _, ok := h[k]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func isServerError(code int) bool {
+	switch code {
+	case http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// heuristicallyCacheable reports whether a response with the status
+// code may be stored without explicit freshness information.
+func heuristicallyCacheable(code int) bool {
+	switch code {
+	case 200, 203, 204, 300, 301, 308, 404, 405, 410, 414, 501:
+		return true
+	}
+	return false
+}
+
+// gatewayTimeout returns the response to a request with the
+// only-if-cached directive for which no response is stored.
+func gatewayTimeout(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:     "504 Gateway Timeout",
+		StatusCode: http.StatusGatewayTimeout,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{},
+		Body:       http.NoBody,
+		Request:    req,
+	}
+}
+
+// headerHasToken reports whether the comma-separated values of
+// header h[key] contain token, compared without regard to case.
+func headerHasToken(h http.Header, key, token string) bool {
+	for _, v := range h.Values(key) {
+		for _, t := range strings.Split(v, ",") {
+			if ascii.EqualFold(textproto.TrimString(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// A cacheControl holds the directives of Cache-Control headers,
+// mapping each lower-case directive name to its argument.
+type cacheControl map[string]string
+
+func parseCacheControl(h http.Header) cacheControl {
+	cc := cacheControl{}
+	for _, v := range h.Values("Cache-Control") {
+		for _, d := range strings.Split(v, ",") {
+			name, arg, _ := strings.Cut(d, "=")
+			name, _ = ascii.ToLower(textproto.TrimString(name))
+			if name == "" {
+				continue
+			}
+			arg = textproto.TrimString(arg)
+			if len(arg) >= 2 && arg[0] == '"' && arg[len(arg)-1] == '"' {
+				arg = arg[1 : len(arg)-1]
+			}
+			if _, dup := cc[name]; !dup {
+				cc[name] = arg
+			}
+		}
+	}
+	return cc
+}
+
+func (cc cacheControl) has(directive string) bool {
+	_, ok := cc[directive]
+	return ok
+}
+
+// seconds returns the delta-seconds argument of directive.
+func (cc cacheControl) seconds(directive string) (time.Duration, bool) {
+	arg, ok := cc[directive]
+	if !ok || arg == "" {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(arg, 10, 63)
+	if err != nil {
+		var nerr *strconv.NumError
+		if !errors.As(err, &nerr) || nerr.Err != strconv.ErrRange {
+			return 0, false
+		}
+		n = 1 << 31 // RFC 9111, Section 1.2.2
+	}
+	return time.Duration(min(n, 1<<31)) * time.Second, true
+}
+
+// A cacheEntry is a stored response.
+type cacheEntry struct {
+	reqTime    time.Time   // when the request was sent
+	respTime   time.Time   // when the response was received
+	varyHeader http.Header // request header fields named by Vary
+	status     string
+	statusCode int
+	header     http.Header
+	body       []byte
+}
+
+// varyHeader returns the fields of the request header named by
+// the Vary field of the response header h.
+func varyHeader(req *http.Request, h http.Header) http.Header {
+	vh := http.Header{}
+	for _, v := range h.Values("Vary") {
+		for _, name := range strings.Split(v, ",") {
+			name = textproto.TrimString(name)
+			if name == "" {
+				continue
+			}
+			if vv := req.Header.Values(name); len(vv) > 0 {
+				vh[textproto.CanonicalMIMEHeaderKey(name)] = []string{strings.Join(vv, ", ")}
+			}
+		}
+	}
+	return vh
+}
+
+// matchesVary reports whether the stored response may be used for req,
+// as described in RFC 9111, Section 4.1.
+func (e *cacheEntry) matchesVary(req *http.Request) bool {
+	for _, v := range e.header.Values("Vary") {
+		for _, name := range strings.Split(v, ",") {
+			name = textproto.TrimString(name)
+			if name == "*" {
+				return false
+			}
+			if name == "" {
+				continue
+			}
+			want := e.varyHeader.Get(name)
+			if got := strings.Join(req.Header.Values(name), ", "); got != want {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (e *cacheEntry) date() time.Time {
+	if d, err := http.ParseTime(e.header.Get("Date")); err == nil {
+		return d
+	}
+	return e.respTime
+}
+
+// age returns the current age of the response at now,
+// as described in RFC 9111, Section 4.2.3.
+func (e *cacheEntry) age(now time.Time) time.Duration {
+	apparentAge := max(0, e.respTime.Sub(e.date()))
+	var ageValue time.Duration
+	if n, err := strconv.ParseUint(e.header.Get("Age"), 10, 31); err == nil {
+		ageValue = time.Duration(n) * time.Second
+	}
+	correctedAge := ageValue + e.respTime.Sub(e.reqTime)
+	return max(apparentAge, correctedAge) + now.Sub(e.respTime)
+}
+
+// freshnessLifetime returns the freshness lifetime of the response,
+// as described in RFC 9111, Section 4.2.1.
+func (e *cacheEntry) freshnessLifetime(shared bool) time.Duration {
+	cc := parseCacheControl(e.header)
+	if shared {
+		if d, ok := cc.seconds("s-maxage"); ok {
+			return d
+		}
+	}
+	if d, ok := cc.seconds("max-age"); ok {
+		return d
+	}
+	if v, ok := e.header["Expires"]; ok {
+		exp, err := http.ParseTime(v[0])
+		if err != nil {
+			return 0 // an invalid Expires is in the past
+		}
+		return max(0, exp.Sub(e.date()))
+	}
+	if heuristicallyCacheable(e.statusCode) {
+		// A tenth of the time since the last modification,
+		// as suggested by RFC 9111, Section 4.2.2.
+		if lm, err := http.ParseTime(e.header.Get("Last-Modified")); err == nil {
+			return max(0, e.date().Sub(lm)/10)
+		}
+	}
+	return 0
+}
+
+// mustRevalidate reports whether the response must not be served stale.
+func (e *cacheEntry) mustRevalidate(shared bool) bool {
+	cc := parseCacheControl(e.header)
+	if cc.has("must-revalidate") || cc.has("no-cache") {
+		return true
+	}
+	return shared && (cc.has("proxy-revalidate") || cc.has("s-maxage"))
+}
+
+// servable reports whether the response may be served without
+// contacting the origin server, for a request with cache directives reqCC.
+func (e *cacheEntry) servable(reqCC cacheControl, age, lifetime time.Duration, shared bool) bool {
+	if reqCC.has("no-cache") || parseCacheControl(e.header).has("no-cache") {
+		return false
+	}
+	if d, ok := reqCC.seconds("max-age"); ok && age > d {
+		return false
+	}
+	if d, ok := reqCC.seconds("min-fresh"); ok && lifetime-age < d {
+		return false
+	}
+	if age < lifetime {
+		return true
+	}
+	if e.mustRevalidate(shared) {
+		return false
+	}
+	if arg, ok := reqCC["max-stale"]; ok {
+		if arg == "" {
+			return true
+		}
+		d, ok := reqCC.seconds("max-stale")
+		return ok && age-lifetime <= d
+	}
+	return false
+}
+
+// staleWhileRevalidate reports whether the stale response may be served
+// while it is revalidated in the background (RFC 5861, Section 3).
+func (e *cacheEntry) staleWhileRevalidate(reqCC cacheControl, age, lifetime time.Duration, shared bool) bool {
+	if reqCC.has("no-cache") || reqCC.has("max-age") || reqCC.has("min-fresh") || e.mustRevalidate(shared) {
+		return false
+	}
+	d, ok := parseCacheControl(e.header).seconds("stale-while-revalidate")
+	return ok && age-lifetime <= d
+}
+
+// staleIfError reports whether the stale response may be served
+// when the origin server cannot be reached or returns an error
+// (RFC 5861, Section 4).
+func (e *cacheEntry) staleIfError(reqCC cacheControl, age, lifetime time.Duration, shared bool) bool {
+	if e.mustRevalidate(shared) {
+		return false
+	}
+	d, ok := reqCC.seconds("stale-if-error")
+	if !ok {
+		d, ok = parseCacheControl(e.header).seconds("stale-if-error")
+	}
+	return ok && age-lifetime <= d
+}
+
+func (e *cacheEntry) hasValidator() bool {
+	return e.header.Get("Etag") != "" || e.header.Get("Last-Modified") != ""
+}
+
+// conditionalRequest returns a copy of req with the context ctx,
+// validating the stored response.
+func (e *cacheEntry) conditionalRequest(req *http.Request, ctx context.Context) *http.Request {
+	outreq := req.Clone(ctx)
+	if etag := e.header.Get("Etag"); etag != "" {
+		outreq.Header.Set("If-None-Match", etag)
+	}
+	if lm := e.header.Get("Last-Modified"); lm != "" {
+		outreq.Header.Set("If-Modified-Since", lm)
+	}
+	return outreq
+}
+
+// update updates the stored response with a 304 (Not Modified)
+// response, as described in RFC 9111, Section 4.3.4.
+func (e *cacheEntry) update(resp *http.Response, reqTime, respTime time.Time) {
+	e.reqTime = reqTime
+	e.respTime = respTime
+	for k, vv := range resp.Header {
+		switch k {
+		case "Content-Length", "Transfer-Encoding", "Connection", "Keep-Alive":
+			continue
+		}
+		e.header[k] = vv
+	}
+}
+
+// response returns the stored response, for req, with the given age.
+func (e *cacheEntry) response(req *http.Request, age time.Duration) *http.Response {
+	h := e.header.Clone()
+	h.Set("Age", strconv.FormatInt(int64(age/time.Second), 10))
+	return &http.Response{
+		Status:        e.status,
+		StatusCode:    e.statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        h,
+		Body:          io.NopCloser(bytes.NewReader(e.body)),
+		ContentLength: int64(len(e.body)),
+		Request:       req,
+	}
+}
+
+// encode serializes the entry: the request and response times,
+// the varying request header fields, and the response,
+// in HTTP/1.1 wire format.
+func (e *cacheEntry) encode() []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%d %d\r\n", e.reqTime.UnixNano(), e.respTime.UnixNano())
+	e.varyHeader.Write(&b)
+	b.WriteString("\r\n")
+	resp := &http.Response{
+		Status:        e.status,
+		StatusCode:    e.statusCode,
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.header,
+		Body:          io.NopCloser(bytes.NewReader(e.body)),
+		ContentLength: int64(len(e.body)),
+	}
+	resp.Write(&b)
+	return b.Bytes()
+}
+
+func decodeCacheEntry(b []byte) (*cacheEntry, error) {
+	br := bufio.NewReader(bytes.NewReader(b))
+	tp := textproto.NewReader(br)
+	line, err := tp.ReadLine()
+	if err != nil {
+		return nil, err
+	}
+	var reqTime, respTime int64
+	if _, err := fmt.Sscanf(line, "%d %d", &reqTime, &respTime); err != nil {
+		return nil, err
+	}
+	vh, err := tp.ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &cacheEntry{
+		reqTime:    time.Unix(0, reqTime),
+		respTime:   time.Unix(0, respTime),
+		varyHeader: http.Header(vh),
+		status:     resp.Status,
+		statusCode: resp.StatusCode,
+		header:     resp.Header,
+		body:       body,
+	}, nil
+}
+
+// A cachingBody is the body of a response being stored.
+// It collects the body as it is read, and calls done
+// with the complete body at EOF.
+type cachingBody struct {
+	rc   io.ReadCloser
+	buf  bytes.Buffer
+	max  int64
+	done func(body []byte) // nil after the body is stored or found too large
+}
+
+func (b *cachingBody) Read(p []byte) (int, error) {
+	n, err := b.rc.Read(p)
+	if b.done != nil {
+		if int64(b.buf.Len()+n) > b.max {
+			b.done = nil
+			b.buf = bytes.Buffer{}
+		} else {
+			b.buf.Write(p[:n])
+		}
+	}
+	if err == io.EOF && b.done != nil {
+		b.done(b.buf.Bytes())
+		b.done = nil
+		b.buf = bytes.Buffer{}
+	}
+	return n, err
+}
+
+func (b *cachingBody) Close() error {
+	b.done = nil
+	return b.rc.Close()
+}
+
+// MemoryCache is a [Cache] which stores values in memory,
+// evicting the least recently used values when it is full.
+type MemoryCache struct {
+	maxBytes int64
+
+	mu    sync.Mutex
+	size  int64
+	lru   *list.List // of *memoryCacheEntry, most recently used first
+	items map[string]*list.Element
+}
+
+type memoryCacheEntry struct {
+	key   string
+	value []byte
+}
+
+// NewMemoryCache returns a [MemoryCache] holding at most maxBytes
+// bytes of keys and values.
+func NewMemoryCache(maxBytes int64) *MemoryCache {
+	return &MemoryCache{
+		maxBytes: maxBytes,
+		lru:      list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+// Get implements [Cache].
+func (c *MemoryCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(el)
+	return el.Value.(*memoryCacheEntry).value, true
+}
+
+// Set implements [Cache].
+// Values larger than the cache are not stored.
+func (c *MemoryCache) Set(key string, value []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.remove(key)
+	size := int64(len(key) + len(value))
+	if size > c.maxBytes {
+		return
+	}
+	c.items[key] = c.lru.PushFront(&memoryCacheEntry{key, value})
+	c.size += size
+	for c.size > c.maxBytes {
+		c.remove(c.lru.Back().Value.(*memoryCacheEntry).key)
+	}
+}
+
+// Delete implements [Cache].
+func (c *MemoryCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.remove(key)
+}
+
+func (c *MemoryCache) remove(key string) {
+	el, ok := c.items[key]
+	if !ok {
+		return
+	}
+	ent := c.lru.Remove(el).(*memoryCacheEntry)
+	delete(c.items, key)
+	c.size -= int64(len(ent.key) + len(ent.value))
+}
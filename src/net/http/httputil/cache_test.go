@@ -0,0 +1,417 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package httputil
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// A cacheTest is an origin server and a CachingTransport for it,
+// with a fake clock.
+type cacheTest struct {
+	t  *testing.T
+	ts *httptest.Server
+	ct *CachingTransport
+
+	mu       sync.Mutex
+	now      time.Time
+	hits     int
+	lastReq  *http.Request
+	respond  func(w http.ResponseWriter, r *http.Request)
+	hitNotif chan struct{}
+}
+
+func newCacheTest(t *testing.T, respond func(w http.ResponseWriter, r *http.Request)) *cacheTest {
+	ct := &cacheTest{
+		t:        t,
+		now:      time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		respond:  respond,
+		hitNotif: make(chan struct{}, 10),
+	}
+	ct.ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ct.mu.Lock()
+		ct.hits++
+		ct.lastReq = r
+		respond := ct.respond
+		w.Header().Set("Date", ct.now.Format(http.TimeFormat))
+		ct.mu.Unlock()
+		respond(w, r)
+		select {
+		case ct.hitNotif <- struct{}{}:
+		default:
+		}
+	}))
+	t.Cleanup(ct.ts.Close)
+	ct.ct = &CachingTransport{
+		Transport: ct.ts.Client().Transport,
+		Cache:     NewMemoryCache(1 << 20),
+	}
+	oldTimeNow := timeNow
+	timeNow = func() time.Time {
+		ct.mu.Lock()
+		defer ct.mu.Unlock()
+		return ct.now
+	}
+	t.Cleanup(func() { timeNow = oldTimeNow })
+	return ct
+}
+
+func (ct *cacheTest) advance(d time.Duration) {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	ct.now = ct.now.Add(d)
+}
+
+func (ct *cacheTest) setResponder(respond func(w http.ResponseWriter, r *http.Request)) {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	ct.respond = respond
+}
+
+func (ct *cacheTest) hitCount() int {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	return ct.hits
+}
+
+// get sends a GET request with header h, and returns the response
+// status and body.
+func (ct *cacheTest) get(h http.Header) (*http.Response, string) {
+	ct.t.Helper()
+	req, _ := http.NewRequest("GET", ct.ts.URL+"/resource", nil)
+	for k, v := range h {
+		req.Header[k] = v
+	}
+	resp, err := ct.ct.RoundTrip(req)
+	if err != nil {
+		ct.t.Fatalf("RoundTrip: %v", err)
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		ct.t.Fatalf("reading body: %v", err)
+	}
+	return resp, string(b)
+}
+
+// wantGet sends a GET request, and checks the response body and
+// the number of requests received by the server so far.
+func (ct *cacheTest) wantGet(h http.Header, wantBody string, wantHits int) *http.Response {
+	ct.t.Helper()
+	resp, body := ct.get(h)
+	if body != wantBody {
+		ct.t.Errorf("body = %q, want %q", body, wantBody)
+	}
+	if got := ct.hitCount(); got != wantHits {
+		ct.t.Errorf("server hits = %v, want %v", got, wantHits)
+	}
+	return resp
+}
+
+// versioned returns a responder which serves a body that changes with
+// each request, with the given headers and an ETag.
+func versioned(header ...string) func(w http.ResponseWriter, r *http.Request) {
+	version := 0
+	return func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < len(header); i += 2 {
+			w.Header().Set(header[i], header[i+1])
+		}
+		etag := fmt.Sprintf(`"v%d"`, version)
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		version++
+		w.Header().Set("Etag", fmt.Sprintf(`"v%d"`, version))
+		fmt.Fprintf(w, "version %d", version)
+	}
+}
+
+func TestCachingTransportFresh(t *testing.T) {
+	ct := newCacheTest(t, versioned("Cache-Control", "max-age=60"))
+	ct.wantGet(nil, "version 1", 1)
+	ct.advance(30 * time.Second)
+	resp := ct.wantGet(nil, "version 1", 1)
+	if got, want := resp.Header.Get("Age"), "30"; got != want {
+		t.Errorf("Age = %q, want %q", got, want)
+	}
+
+	// After the response becomes stale, it is revalidated.
+	ct.advance(31 * time.Second)
+	ct.wantGet(nil, "version 1", 2)
+	if got := ct.lastReq.Header.Get("If-None-Match"); got != `"v1"` {
+		t.Errorf("revalidation If-None-Match = %q, want %q", got, `"v1"`)
+	}
+	// The revalidated response is fresh again.
+	ct.wantGet(nil, "version 1", 2)
+}
+
+func TestCachingTransportExpires(t *testing.T) {
+	ct := newCacheTest(t, nil)
+	ct.setResponder(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Expires", ct.now.Add(10*time.Second).Format(http.TimeFormat))
+		io.WriteString(w, "body")
+	})
+	ct.wantGet(nil, "body", 1)
+	ct.advance(5 * time.Second)
+	ct.wantGet(nil, "body", 1)
+	ct.advance(5 * time.Second)
+	ct.wantGet(nil, "body", 2)
+}
+
+func TestCachingTransportHeuristicFreshness(t *testing.T) {
+	ct := newCacheTest(t, nil)
+	ct.setResponder(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Last-Modified", ct.now.Add(-100*time.Second).Format(http.TimeFormat))
+		io.WriteString(w, "body")
+	})
+	ct.wantGet(nil, "body", 1)
+	ct.advance(9 * time.Second)
+	ct.wantGet(nil, "body", 1)
+	ct.advance(2 * time.Second)
+	ct.wantGet(nil, "body", 2)
+	if ct.lastReq.Header.Get("If-Modified-Since") == "" {
+		t.Errorf("revalidation has no If-Modified-Since header")
+	}
+}
+
+func TestCachingTransportNotStored(t *testing.T) {
+	for _, cc := range []string{"no-store", "private"} {
+		t.Run(cc, func(t *testing.T) {
+			ct := newCacheTest(t, versioned("Cache-Control", "max-age=60, "+cc))
+			ct.ct.Shared = true
+			ct.wantGet(nil, "version 1", 1)
+			ct.wantGet(nil, "version 2", 2)
+		})
+	}
+}
+
+func TestCachingTransportSharedAuthorization(t *testing.T) {
+	ct := newCacheTest(t, versioned("Cache-Control", "max-age=60"))
+	ct.ct.Shared = true
+	auth := http.Header{"Authorization": {"Bearer token"}}
+	ct.wantGet(auth, "version 1", 1)
+	ct.wantGet(auth, "version 2", 2)
+}
+
+func TestCachingTransportNoCacheResponse(t *testing.T) {
+	ct := newCacheTest(t, versioned("Cache-Control", "no-cache"))
+	ct.wantGet(nil, "version 1", 1)
+	// The response is stored, but must be revalidated before each use.
+	ct.wantGet(nil, "version 1", 2)
+	ct.wantGet(nil, "version 1", 3)
+}
+
+func TestCachingTransportRequestDirectives(t *testing.T) {
+	ct := newCacheTest(t, versioned("Cache-Control", "max-age=60"))
+	ct.wantGet(nil, "version 1", 1)
+	ct.advance(30 * time.Second)
+
+	ct.wantGet(http.Header{"Cache-Control": {"no-cache"}}, "version 1", 2)
+	ct.wantGet(http.Header{"Pragma": {"no-cache"}}, "version 1", 3)
+	ct.advance(30 * time.Second)
+	ct.wantGet(http.Header{"Cache-Control": {"max-age=10"}}, "version 1", 4)
+	ct.wantGet(http.Header{"Cache-Control": {"min-fresh=61"}}, "version 1", 5)
+
+	// max-stale accepts a stale response.
+	ct.advance(90 * time.Second)
+	ct.wantGet(http.Header{"Cache-Control": {"max-stale=60"}}, "version 1", 5)
+	ct.wantGet(http.Header{"Cache-Control": {"max-stale=10"}}, "version 1", 6)
+}
+
+func TestCachingTransportOnlyIfCached(t *testing.T) {
+	ct := newCacheTest(t, versioned("Cache-Control", "max-age=60"))
+	resp, _ := ct.get(http.Header{"Cache-Control": {"only-if-cached"}})
+	if resp.StatusCode != http.StatusGatewayTimeout {
+		t.Errorf("only-if-cached without stored response: status %v, want 504", resp.StatusCode)
+	}
+	ct.wantGet(nil, "version 1", 1)
+	ct.wantGet(http.Header{"Cache-Control": {"only-if-cached"}}, "version 1", 1)
+}
+
+func TestCachingTransportVary(t *testing.T) {
+	ct := newCacheTest(t, versioned("Cache-Control", "max-age=60", "Vary", "Accept-Language"))
+	en := http.Header{"Accept-Language": {"en"}}
+	fr := http.Header{"Accept-Language": {"fr"}}
+	ct.wantGet(en, "version 1", 1)
+	ct.wantGet(en, "version 1", 1)
+	ct.wantGet(fr, "version 2", 2)
+	ct.wantGet(fr, "version 2", 2)
+	ct.wantGet(en, "version 3", 3)
+}
+
+func TestCachingTransportStaleWhileRevalidate(t *testing.T) {
+	ct := newCacheTest(t, versioned("Cache-Control", "max-age=10, stale-while-revalidate=60"))
+	ct.wantGet(nil, "version 1", 1)
+	<-ct.hitNotif
+	ct.advance(20 * time.Second)
+	ct.setResponder(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=10, stale-while-revalidate=60")
+		io.WriteString(w, "updated")
+	})
+	// The stale response is returned, and revalidated in the background.
+	if _, body := ct.get(nil); body != "version 1" {
+		t.Errorf("body = %q, want stale response %q", body, "version 1")
+	}
+	<-ct.hitNotif
+	// Wait for the revalidated response to be stored.
+	for i := 0; ; i++ {
+		if _, body := ct.get(http.Header{"Cache-Control": {"only-if-cached"}}); body == "updated" {
+			break
+		}
+		if i > 1000 {
+			t.Fatalf("background revalidation did not update cache")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if got := ct.hitCount(); got != 2 {
+		t.Errorf("server hits = %v, want 2", got)
+	}
+
+	// Beyond the stale-while-revalidate window, a request waits for the server.
+	ct.advance(100 * time.Second)
+	ct.wantGet(nil, "updated", 3)
+}
+
+func TestCachingTransportStaleIfError(t *testing.T) {
+	ct := newCacheTest(t, versioned("Cache-Control", "max-age=10, stale-if-error=60"))
+	ct.wantGet(nil, "version 1", 1)
+	ct.setResponder(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	})
+	ct.advance(20 * time.Second)
+	resp := ct.wantGet(nil, "version 1", 2)
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %v, want 200", resp.StatusCode)
+	}
+	ct.advance(100 * time.Second)
+	resp = ct.wantGet(nil, "unavailable\n", 3)
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("status = %v, want 503", resp.StatusCode)
+	}
+}
+
+func TestCachingTransportMustRevalidate(t *testing.T) {
+	ct := newCacheTest(t, versioned("Cache-Control", "max-age=10, must-revalidate, stale-if-error=60"))
+	ct.wantGet(nil, "version 1", 1)
+	ct.setResponder(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	})
+	ct.advance(20 * time.Second)
+	ct.wantGet(http.Header{"Cache-Control": {"max-stale"}}, "unavailable\n", 2)
+}
+
+func TestCachingTransportInvalidation(t *testing.T) {
+	ct := newCacheTest(t, versioned("Cache-Control", "max-age=60"))
+	ct.wantGet(nil, "version 1", 1)
+	resp, err := ct.ct.RoundTrip(httptest.NewRequest("POST", ct.ts.URL+"/resource", strings.NewReader("x")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	ct.wantGet(nil, "version 3", 3)
+}
+
+func TestCachingTransportMaxBodySize(t *testing.T) {
+	ct := newCacheTest(t, versioned("Cache-Control", "max-age=60"))
+	ct.ct.MaxBodySize = 5
+	ct.wantGet(nil, "version 1", 1)
+	ct.wantGet(nil, "version 2", 2)
+}
+
+func TestCacheEntryAge(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, test := range []struct {
+		date      time.Time
+		ageHeader string
+		reqTime   time.Time
+		respTime  time.Time
+		now       time.Time
+		want      time.Duration
+	}{
+		{t0, "", t0, t0, t0, 0},
+		{t0, "", t0, t0, t0.Add(5 * time.Second), 5 * time.Second},
+		{t0, "10", t0, t0, t0.Add(5 * time.Second), 15 * time.Second},
+		// Response delay.
+		{t0, "10", t0, t0.Add(2 * time.Second), t0.Add(2 * time.Second), 12 * time.Second},
+		// Apparent age from Date.
+		{t0.Add(-30 * time.Second), "10", t0, t0, t0, 30 * time.Second},
+		// Date in the future.
+		{t0.Add(30 * time.Second), "", t0, t0, t0, 0},
+	} {
+		e := &cacheEntry{
+			reqTime:  test.reqTime,
+			respTime: test.respTime,
+			header:   http.Header{"Date": {test.date.Format(http.TimeFormat)}},
+		}
+		if test.ageHeader != "" {
+			e.header.Set("Age", test.ageHeader)
+		}
+		if got := e.age(test.now); got != test.want {
+			t.Errorf("age(Date: %v, Age: %q, request %v, response %v, now %v) = %v, want %v",
+				test.date, test.ageHeader, test.reqTime, test.respTime, test.now, got, test.want)
+		}
+	}
+}
+
+func TestCacheEntryEncoding(t *testing.T) {
+	e := &cacheEntry{
+		reqTime:    time.Unix(100, 5),
+		respTime:   time.Unix(101, 7),
+		varyHeader: http.Header{"Accept-Language": {"en, fr"}},
+		status:     "200 OK",
+		statusCode: 200,
+		header:     http.Header{"Etag": {`"x"`}, "Vary": {"Accept-Language"}},
+		body:       []byte("hello\r\n\r\nworld"),
+	}
+	got, err := decodeCacheEntry(e.encode())
+	if err != nil {
+		t.Fatalf("decodeCacheEntry: %v", err)
+	}
+	if !got.reqTime.Equal(e.reqTime) || !got.respTime.Equal(e.respTime) ||
+		got.varyHeader.Get("Accept-Language") != "en, fr" ||
+		got.statusCode != 200 || got.header.Get("Etag") != `"x"` ||
+		string(got.body) != string(e.body) {
+		t.Errorf("decodeCacheEntry(encode(%+v)) = %+v", e, got)
+	}
+	if _, err := decodeCacheEntry([]byte("garbage")); err == nil {
+		t.Errorf("decodeCacheEntry(garbage) succeeded")
+	}
+}
+
+func TestMemoryCache(t *testing.T) {
+	c := NewMemoryCache(10)
+	c.Set("a", []byte("111"))         // 4 bytes
+	c.Set("b", []byte("222"))         // 4 bytes
+	c.Get("a")                        // a is now more recently used than b
+	c.Set("c", []byte("333"))         // evicts b
+	c.Set("d", []byte("big!!!!!!!!")) // too large
+	for _, test := range []struct {
+		key  string
+		want string
+		ok   bool
+	}{
+		{"a", "111", true},
+		{"b", "", false},
+		{"c", "333", true},
+		{"d", "", false},
+	} {
+		v, ok := c.Get(test.key)
+		if string(v) != test.want || ok != test.ok {
+			t.Errorf("Get(%q) = %q, %v; want %q, %v", test.key, v, ok, test.want, test.ok)
+		}
+	}
+	c.Delete("a")
+	if _, ok := c.Get("a"); ok {
+		t.Errorf("Get after Delete found value")
+	}
+}
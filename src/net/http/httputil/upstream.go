@@ -0,0 +1,552 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Load balancing across a pool of upstream servers
+
+package httputil
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrNoHealthyBackend is returned by [UpstreamPool.RoundTrip] when the
+// pool has no healthy backend to send a request to.
+var ErrNoHealthyBackend = errors.New("httputil: no healthy backend")
+
+// A BalancePolicy determines how an [UpstreamPool] chooses
+// the backend for each request.
+type BalancePolicy int
+
+const (
+	// RoundRobin sends requests to each healthy backend in turn.
+	RoundRobin BalancePolicy = iota
+
+	// LeastConnections sends each request to the healthy backend
+	// with the fewest requests in progress.
+	LeastConnections
+
+	// ConsistentHash sends requests with the same hash key to the
+	// same backend, as long as it is healthy. Adding or removing a
+	// backend changes the backend for only a small fraction of keys.
+	ConsistentHash
+)
+
+// An UpstreamPool is an [http.RoundTripper] which balances requests
+// across a set of backend servers. It is intended to be used as the
+// Transport of a [ReverseProxy]:
+//
+//	pool := &httputil.UpstreamPool{Policy: httputil.LeastConnections}
+//	pool.Add(backend1)
+//	pool.Add(backend2)
+//	proxy := &httputil.ReverseProxy{
+//		Rewrite: func(r *httputil.ProxyRequest) {
+//			r.SetXForwarded()
+//		},
+//		Transport: pool,
+//	}
+//
+// For each request, the pool chooses a healthy backend according to its
+// Policy and routes the request to the backend's scheme, host, and base
+// path, as [ProxyRequest.SetURL] does. It does not change the request's
+// Host field, so the backend receives the Host header set by the
+// ReverseProxy's Rewrite or Director function.
+//
+// A backend becomes unhealthy when it fails MaxFails consecutive requests,
+// and remains unhealthy for FailTimeout. A request fails if it returns an
+// error, or a response with status 502 (Bad Gateway), 503 (Service
+// Unavailable), or 504 (Gateway Timeout). If HealthCheck is set, the pool
+// also checks each backend periodically, and a backend which fails the
+// check is unhealthy until it passes a later check.
+//
+// When sending a request to a backend fails with an error, the pool
+// retries it on another healthy backend, if the request is idempotent and
+// can be sent again. A request is idempotent if its method is GET, HEAD,
+// OPTIONS, TRACE, PUT, or DELETE, or if it has an Idempotency-Key or
+// X-Idempotency-Key header. A request can be sent again if its body is
+// nil, [http.NoBody], or can be obtained again with [http.Request.GetBody].
+//
+// The configuration fields of an UpstreamPool must not be modified
+// after its first backend is added. Its methods may be called
+// concurrently.
+type UpstreamPool struct {
+	// Policy is the policy used to choose the backend for a request.
+	Policy BalancePolicy
+
+	// HashKey returns the key used to choose the backend for a request
+	// with the ConsistentHash policy. If nil, the client's IP address,
+	// from the request's RemoteAddr field, is used.
+	HashKey func(*http.Request) string
+
+	// HealthCheck, if non-nil, configures active health checks.
+	HealthCheck *HealthCheck
+
+	// MaxFails is the number of consecutive failed requests after which
+	// a backend is considered unhealthy. If zero, 1 is used.
+	// If negative, failed requests do not affect a backend's health.
+	MaxFails int
+
+	// FailTimeout is how long a backend which failed MaxFails requests
+	// remains unhealthy. If zero, 10 seconds is used.
+	FailTimeout time.Duration
+
+	// MaxAttempts is the maximum number of backends a request is sent to.
+	// If zero, 3 is used.
+	MaxAttempts int
+
+	// Transport is used to send requests and health checks to the
+	// backends. If nil, http.DefaultTransport is used.
+	Transport http.RoundTripper
+
+	mu       sync.Mutex
+	backends []*Backend
+	ring     []ringEntry   // sorted virtual nodes, for ConsistentHash
+	stop     chan struct{} // closed by Close; nil until health checks start
+	closed   bool
+	next     atomic.Uint64 // round-robin counter
+}
+
+// A HealthCheck configures the active health checks of an [UpstreamPool].
+type HealthCheck struct {
+	// Path is the path of the URL requested to check a backend,
+	// relative to the backend's URL. If empty, the backend's URL
+	// is requested.
+	Path string
+
+	// Interval is the time between checks of each backend.
+	// If zero, 10 seconds is used.
+	Interval time.Duration
+
+	// Timeout is the time after which a check fails if the backend
+	// has not responded. If zero, 5 seconds is used.
+	Timeout time.Duration
+
+	// Healthy, if non-nil, reports whether the response to a check
+	// shows that the backend is healthy. The response body is closed
+	// after Healthy returns. If nil, a backend is healthy if it responds
+	// with a 2xx or 3xx status.
+	Healthy func(*http.Response) bool
+}
+
+func (hc *HealthCheck) interval() time.Duration {
+	if hc.Interval > 0 {
+		return hc.Interval
+	}
+	return 10 * time.Second
+}
+
+func (hc *HealthCheck) timeout() time.Duration {
+	if hc.Timeout > 0 {
+		return hc.Timeout
+	}
+	return 5 * time.Second
+}
+
+// A Backend is a server in an [UpstreamPool].
+type Backend struct {
+	// URL is the backend's URL. Requests are sent to its
+	// scheme, host, and path, joined with the request's path.
+	// It must not be modified.
+	URL *url.URL
+
+	active   atomic.Int64
+	requests atomic.Uint64
+	failures atomic.Uint64
+
+	mu        sync.Mutex
+	checkDown bool      // failed the last active health check
+	fails     int       // consecutive failed requests
+	downUntil time.Time // unhealthy until, after MaxFails failed requests
+}
+
+// BackendStats are statistics about a [Backend].
+type BackendStats struct {
+	// Healthy reports whether the pool sends requests to the backend.
+	Healthy bool
+
+	// ActiveRequests is the number of requests to the backend in
+	// progress, including those whose response body is being read.
+	ActiveRequests int64
+
+	// Requests is the number of requests sent to the backend,
+	// and Failures is the number of those which failed.
+	// Health checks are not included.
+	Requests uint64
+	Failures uint64
+}
+
+// Stats returns statistics about b.
+func (b *Backend) Stats() BackendStats {
+	return BackendStats{
+		Healthy:        b.healthy(timeNow()),
+		ActiveRequests: b.active.Load(),
+		Requests:       b.requests.Load(),
+		Failures:       b.failures.Load(),
+	}
+}
+
+func (b *Backend) healthy(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !b.checkDown && !now.Before(b.downUntil)
+}
+
+// Add adds a backend with the given URL to the pool, and returns it.
+func (p *UpstreamPool) Add(target *url.URL) *Backend {
+	b := &Backend{URL: target}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.backends = append(p.backends, b)
+	p.ring = appendRing(p.ring, b)
+	if p.HealthCheck != nil && p.stop == nil && !p.closed {
+		p.stop = make(chan struct{})
+		go p.checkHealth(p.stop)
+	}
+	return b
+}
+
+// Remove removes b from the pool. Requests in progress to b are
+// not affected.
+func (p *UpstreamPool) Remove(b *Backend) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.backends = slices.DeleteFunc(p.backends, func(b2 *Backend) bool { return b2 == b })
+	p.ring = slices.DeleteFunc(p.ring, func(e ringEntry) bool { return e.b == b })
+}
+
+// Backends returns the backends in the pool, in the order they were added.
+func (p *UpstreamPool) Backends() []*Backend {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return slices.Clone(p.backends)
+}
+
+// Close stops the pool's active health checks.
+// The pool continues to send requests to its backends.
+func (p *UpstreamPool) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.closed {
+		p.closed = true
+		if p.stop != nil {
+			close(p.stop)
+		}
+	}
+	return nil
+}
+
+func (p *UpstreamPool) transport() http.RoundTripper {
+	if p.Transport != nil {
+		return p.Transport
+	}
+	return http.DefaultTransport
+}
+
+func (p *UpstreamPool) maxAttempts() int {
+	if p.MaxAttempts > 0 {
+		return p.MaxAttempts
+	}
+	return 3
+}
+
+// RoundTrip implements the [http.RoundTripper] interface.
+func (p *UpstreamPool) RoundTrip(req *http.Request) (*http.Response, error) {
+	retryable := isIdempotent(req) && (req.Body == nil || req.Body == http.NoBody || req.GetBody != nil)
+	var (
+		tried   []*Backend
+		lastErr error
+	)
+	for attempt := 1; ; attempt++ {
+		b := p.pick(req, tried)
+		if b == nil {
+			if lastErr != nil {
+				return nil, lastErr
+			}
+			return nil, ErrNoHealthyBackend
+		}
+		tried = append(tried, b)
+		out := new(http.Request)
+		*out = *req
+		out.URL = new(url.URL)
+		*out.URL = *req.URL
+		rewriteRequestURL(out, b.URL)
+		if attempt > 1 && req.Body != nil && req.Body != http.NoBody {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			out.Body = body
+		}
+		resp, err := p.send(b, out)
+		if err == nil {
+			return resp, nil
+		}
+		lastErr = err
+		if !retryable || attempt >= p.maxAttempts() || req.Context().Err() != nil {
+			return nil, err
+		}
+	}
+}
+
+// isIdempotent reports whether req may be sent more than once.
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case "GET", "HEAD", "OPTIONS", "TRACE", "PUT", "DELETE":
+		return true
+	}
+	_, ok := req.Header["Idempotency-Key"]
+	if !ok {
+		_, ok = req.Header["X-Idempotency-Key"]
+	}
+	return ok
+}
+
+// send sends req to b, recording the result in b's statistics.
+func (p *UpstreamPool) send(b *Backend, req *http.Request) (*http.Response, error) {
+	b.active.Add(1)
+	b.requests.Add(1)
+	resp, err := p.transport().RoundTrip(req)
+	if err != nil {
+		b.active.Add(-1)
+		if req.Context().Err() == nil {
+			p.failed(b)
+		}
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		p.failed(b)
+	default:
+		b.mu.Lock()
+		b.fails = 0
+		b.mu.Unlock()
+	}
+	done := func() { b.active.Add(-1) }
+	if rwc, ok := resp.Body.(io.ReadWriteCloser); ok {
+		// Keep the body of a 101 Switching Protocols response writable.
+		resp.Body = &doneOnCloseReadWriter{rwc, sync.OnceFunc(done)}
+	} else {
+		resp.Body = &doneOnCloseBody{resp.Body, sync.OnceFunc(done)}
+	}
+	return resp, nil
+}
+
+// failed records a failed request to b.
+func (p *UpstreamPool) failed(b *Backend) {
+	b.failures.Add(1)
+	if p.MaxFails < 0 {
+		return
+	}
+	maxFails := max(p.MaxFails, 1)
+	failTimeout := p.FailTimeout
+	if failTimeout <= 0 {
+		failTimeout = 10 * time.Second
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.fails++
+	if b.fails >= maxFails {
+		b.fails = 0
+		b.downUntil = timeNow().Add(failTimeout)
+	}
+}
+
+// pick returns the backend for req, ignoring unhealthy backends and
+// the backends in tried. It returns nil if there are none.
+func (p *UpstreamPool) pick(req *http.Request, tried []*Backend) *Backend {
+	now := timeNow()
+	usable := func(b *Backend) bool {
+		return b.healthy(now) && !slices.Contains(tried, b)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n := len(p.backends)
+	if n == 0 {
+		return nil
+	}
+	switch p.Policy {
+	case ConsistentHash:
+		h := hashString(p.hashKey(req))
+		i, _ := slices.BinarySearchFunc(p.ring, h, func(e ringEntry, h uint64) int {
+			switch {
+			case e.hash < h:
+				return -1
+			case e.hash > h:
+				return 1
+			}
+			return 0
+		})
+		for j := range len(p.ring) {
+			if b := p.ring[(i+j)%len(p.ring)].b; usable(b) {
+				return b
+			}
+		}
+		return nil
+	case LeastConnections:
+		// Start at a rotating position, so that ties are
+		// broken by round robin.
+		start := int(p.next.Add(1) % uint64(n))
+		var best *Backend
+		var bestActive int64
+		for j := range n {
+			b := p.backends[(start+j)%n]
+			if !usable(b) {
+				continue
+			}
+			if active := b.active.Load(); best == nil || active < bestActive {
+				best, bestActive = b, active
+			}
+		}
+		return best
+	default:
+		start := int(p.next.Add(1) % uint64(n))
+		for j := range n {
+			if b := p.backends[(start+j)%n]; usable(b) {
+				return b
+			}
+		}
+		return nil
+	}
+}
+
+func (p *UpstreamPool) hashKey(req *http.Request) string {
+	if p.HashKey != nil {
+		return p.HashKey(req)
+	}
+	if host, _, err := net.SplitHostPort(req.RemoteAddr); err == nil {
+		return host
+	}
+	return req.RemoteAddr
+}
+
+// virtualNodes is the number of points on the hash ring per backend.
+const virtualNodes = 100
+
+// A ringEntry is a point on the hash ring used by ConsistentHash.
+type ringEntry struct {
+	hash uint64
+	b    *Backend
+}
+
+// appendRing adds the virtual nodes of b to the sorted ring.
+func appendRing(ring []ringEntry, b *Backend) []ringEntry {
+	key := b.URL.String()
+	for i := range virtualNodes {
+		ring = append(ring, ringEntry{hashString(key + "#" + strconv.Itoa(i)), b})
+	}
+	slices.SortStableFunc(ring, func(x, y ringEntry) int {
+		switch {
+		case x.hash < y.hash:
+			return -1
+		case x.hash > y.hash:
+			return 1
+		}
+		return 0
+	})
+	return ring
+}
+
+func hashString(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	// FNV does not spread short, similar keys around the ring,
+	// so finish with the MurmurHash3 finalizer.
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+// checkHealth runs the active health checks until stop is closed.
+func (p *UpstreamPool) checkHealth(stop <-chan struct{}) {
+	t := time.NewTicker(p.HealthCheck.interval())
+	defer t.Stop()
+	for {
+		var wg sync.WaitGroup
+		for _, b := range p.Backends() {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ok := p.check(b)
+				b.mu.Lock()
+				b.checkDown = !ok
+				if ok {
+					// A passing check ends an earlier failure timeout.
+					b.fails = 0
+					b.downUntil = time.Time{}
+				}
+				b.mu.Unlock()
+			}()
+		}
+		wg.Wait()
+		select {
+		case <-t.C:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// check reports whether b passes a health check.
+func (p *UpstreamPool) check(b *Backend) bool {
+	hc := p.HealthCheck
+	ctx, cancel := context.WithTimeout(context.Background(), hc.timeout())
+	defer cancel()
+	u := *b.URL
+	if hc.Path != "" {
+		u.Path, u.RawPath = joinURLPath(b.URL, &url.URL{Path: hc.Path})
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return false
+	}
+	resp, err := p.transport().RoundTrip(req)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+	if hc.Healthy != nil {
+		return hc.Healthy(resp)
+	}
+	return resp.StatusCode >= 200 && resp.StatusCode < 400
+}
+
+// doneOnCloseBody is the body of a response from a backend.
+// Closing it calls done, ending the request.
+type doneOnCloseBody struct {
+	io.ReadCloser
+	done func()
+}
+
+func (b *doneOnCloseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.done()
+	return err
+}
+
+// doneOnCloseReadWriter is like doneOnCloseBody,
+// for the body of a 101 Switching Protocols response.
+type doneOnCloseReadWriter struct {
+	io.ReadWriteCloser
+	done func()
+}
+
+func (b *doneOnCloseReadWriter) Close() error {
+	err := b.ReadWriteCloser.Close()
+	b.done()
+	return err
+}
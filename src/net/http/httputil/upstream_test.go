@@ -0,0 +1,284 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package httputil
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newUpstreamTest starts backends serving handlers, adds them to pool,
+// and returns a proxy server using pool.
+func newUpstreamTest(t *testing.T, pool *UpstreamPool, handlers ...http.HandlerFunc) (*httptest.Server, []*Backend) {
+	t.Helper()
+	var backends []*Backend
+	for _, h := range handlers {
+		ts := httptest.NewServer(h)
+		t.Cleanup(ts.Close)
+		u, err := url.Parse(ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		backends = append(backends, pool.Add(u))
+	}
+	t.Cleanup(func() { pool.Close() })
+	proxy := httptest.NewServer(&ReverseProxy{
+		Rewrite:   func(r *ProxyRequest) {},
+		Transport: pool,
+		ErrorLog:  log.New(io.Discard, "", 0),
+	})
+	t.Cleanup(proxy.Close)
+	return proxy, backends
+}
+
+// nameHandler returns a handler which responds with name.
+func nameHandler(name string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, name)
+	}
+}
+
+func getBody(t *testing.T, req *http.Request) (int, string) {
+	t.Helper()
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res.StatusCode, string(body)
+}
+
+func get(t *testing.T, url string) string {
+	t.Helper()
+	req, _ := http.NewRequest("GET", url, nil)
+	_, body := getBody(t, req)
+	return body
+}
+
+func TestUpstreamPoolRoundRobin(t *testing.T) {
+	pool := &UpstreamPool{}
+	proxy, backends := newUpstreamTest(t, pool, nameHandler("a"), nameHandler("b"), nameHandler("c"))
+	counts := map[string]int{}
+	for range 9 {
+		counts[get(t, proxy.URL)]++
+	}
+	if counts["a"] != 3 || counts["b"] != 3 || counts["c"] != 3 {
+		t.Errorf("responses from backends: %v, want 3 from each", counts)
+	}
+	for i, b := range backends {
+		if got := b.Stats(); got != (BackendStats{Healthy: true, Requests: 3}) {
+			t.Errorf("backend %v: Stats() = %+v, want 3 requests", i, got)
+		}
+	}
+}
+
+func TestUpstreamPoolPath(t *testing.T) {
+	pool := &UpstreamPool{}
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.URL.RequestURI())
+	}))
+	defer backend.Close()
+	u, _ := url.Parse(backend.URL + "/base/?x=1")
+	pool.Add(u)
+	proxy := httptest.NewServer(&ReverseProxy{
+		Rewrite:   func(r *ProxyRequest) { r.Out.URL.RawQuery = r.In.URL.RawQuery },
+		Transport: pool,
+	})
+	defer proxy.Close()
+	if got, want := get(t, proxy.URL+"/dir?y=2"), "/base/dir?x=1&y=2"; got != want {
+		t.Errorf("backend got request for %q, want %q", got, want)
+	}
+}
+
+func TestUpstreamPoolLeastConnections(t *testing.T) {
+	pool := &UpstreamPool{Policy: LeastConnections}
+	block := make(chan struct{})
+	defer close(block)
+	proxy, backends := newUpstreamTest(t, pool,
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/slow" {
+				w.WriteHeader(200)
+				w.(http.Flusher).Flush()
+				<-block
+			}
+			io.WriteString(w, "a")
+		},
+		nameHandler("b"),
+	)
+	// Send a slow request to a, and keep its response open.
+	var res *http.Response
+	for range 2 {
+		var err error
+		res, err = http.Get(proxy.URL + "/slow")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if backends[0].Stats().ActiveRequests == 1 {
+			break
+		}
+		// The request went to b.
+		res.Body.Close()
+	}
+	defer res.Body.Close()
+	if got := backends[0].Stats().ActiveRequests; got != 1 {
+		t.Fatalf("backend a has %v active requests, want 1", got)
+	}
+	for range 4 {
+		if got := get(t, proxy.URL); got != "b" {
+			t.Errorf("got response from %q, want b, which has fewer connections", got)
+		}
+	}
+	if got := backends[1].Stats().ActiveRequests; got != 0 {
+		t.Errorf("backend b has %v active requests after responses are read, want 0", got)
+	}
+}
+
+func TestUpstreamPoolConsistentHash(t *testing.T) {
+	pool := &UpstreamPool{
+		Policy: ConsistentHash,
+		HashKey: func(r *http.Request) string {
+			return r.Header.Get("User")
+		},
+	}
+	proxy, backends := newUpstreamTest(t, pool, nameHandler("a"), nameHandler("b"), nameHandler("c"), nameHandler("d"))
+	getUser := func(user int) string {
+		req, _ := http.NewRequest("GET", proxy.URL, nil)
+		req.Header.Set("User", fmt.Sprint(user))
+		_, body := getBody(t, req)
+		return body
+	}
+	const users = 100
+	before := map[int]string{}
+	counts := map[string]int{}
+	for u := range users {
+		before[u] = getUser(u)
+		counts[before[u]]++
+		if again := getUser(u); again != before[u] {
+			t.Errorf("user %v: sent to %q, then %q", u, before[u], again)
+		}
+	}
+	if len(counts) != 4 {
+		t.Errorf("users were sent to backends %v, want all four", counts)
+	}
+	// Removing a backend only moves its own users.
+	pool.Remove(backends[3])
+	for u := range users {
+		if got := getUser(u); got != before[u] && before[u] != "d" {
+			t.Errorf("user %v: sent to %q after removing d, was %q", u, got, before[u])
+		}
+	}
+}
+
+func TestUpstreamPoolPassiveHealth(t *testing.T) {
+	pool := &UpstreamPool{MaxFails: 2, FailTimeout: time.Hour}
+	proxy, backends := newUpstreamTest(t, pool,
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		},
+		nameHandler("b"),
+	)
+	for range 10 {
+		get(t, proxy.URL)
+	}
+	if got, want := backends[0].Stats(), (BackendStats{Healthy: false, Requests: 2, Failures: 2}); got != want {
+		t.Errorf("failing backend: Stats() = %+v, want %+v", got, want)
+	}
+	if got, want := backends[1].Stats(), (BackendStats{Healthy: true, Requests: 8}); got != want {
+		t.Errorf("healthy backend: Stats() = %+v, want %+v", got, want)
+	}
+
+	// With no healthy backends, the proxy responds with an error.
+	pool.Remove(backends[1])
+	req, _ := http.NewRequest("GET", proxy.URL, nil)
+	if code, _ := getBody(t, req); code != http.StatusBadGateway {
+		t.Errorf("with no healthy backend: status %v, want 502", code)
+	}
+	if _, err := pool.RoundTrip(req); !errors.Is(err, ErrNoHealthyBackend) {
+		t.Errorf("RoundTrip with no healthy backend: %v, want %v", err, ErrNoHealthyBackend)
+	}
+}
+
+func TestUpstreamPoolRetry(t *testing.T) {
+	pool := &UpstreamPool{MaxFails: -1}
+	// The first backend refuses connections.
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	u, _ := url.Parse(down.URL)
+	downBackend := pool.Add(u)
+	var requests atomic.Int32
+	proxy, _ := newUpstreamTest(t, pool, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		io.WriteString(w, "b")
+	})
+
+	for range 4 {
+		if got := get(t, proxy.URL); got != "b" {
+			t.Errorf("GET: got response %q, want retry on b", got)
+		}
+	}
+	if got := requests.Load(); got != 4 {
+		t.Errorf("b got %v requests, want 4", got)
+	}
+	if got := downBackend.Stats(); !got.Healthy || got.Failures == 0 {
+		t.Errorf("down backend: Stats() = %+v, want failures, and healthy with MaxFails < 0", got)
+	}
+
+	// A POST is not retried.
+	codes := map[int]int{}
+	for range 4 {
+		req, _ := http.NewRequest("POST", proxy.URL, strings.NewReader("body"))
+		code, _ := getBody(t, req)
+		codes[code]++
+	}
+	if codes[http.StatusOK] != 2 || codes[http.StatusBadGateway] != 2 {
+		t.Errorf("POST status codes: %v, want two 200 and two 502", codes)
+	}
+}
+
+func TestUpstreamPoolHealthCheck(t *testing.T) {
+	var healthy atomic.Bool
+	pool := &UpstreamPool{
+		HealthCheck: &HealthCheck{
+			Path:     "/healthz",
+			Interval: time.Millisecond,
+		},
+	}
+	_, backends := newUpstreamTest(t, pool, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthz" {
+			t.Errorf("health check of path %q, want /healthz", r.URL.Path)
+		}
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	})
+	waitHealthy := func(want bool) {
+		t.Helper()
+		for {
+			if backends[0].Stats().Healthy == want {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	waitHealthy(false)
+	healthy.Store(true)
+	waitHealthy(true)
+	if got := backends[0].Stats().Requests; got != 0 {
+		t.Errorf("Stats().Requests = %v, want health checks not counted", got)
+	}
+}
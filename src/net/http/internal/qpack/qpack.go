@@ -0,0 +1,243 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package qpack implements the field compression of HTTP/3, as defined by
+// RFC 9204, for use by net/http.
+//
+// Only the static table is used. Endpoints using this package advertise
+// a dynamic table capacity of zero, which forbids their peers from
+// referencing the dynamic table, and never open encoder or decoder
+// streams.
+package qpack
+
+import (
+	"errors"
+
+	"golang.org/x/net/http2/hpack"
+)
+
+// A HeaderField is a name-value pair. Names must be in lower case.
+type HeaderField struct {
+	Name, Value string
+
+	// Sensitive means that the field must never be stored in the
+	// dynamic table of an intermediary.
+	Sensitive bool
+}
+
+// Size returns the size of f, as used by the SETTINGS_MAX_FIELD_SECTION_SIZE
+// setting (RFC 9114, Section 4.2.2).
+func (f HeaderField) Size() int64 {
+	return int64(len(f.Name)) + int64(len(f.Value)) + 32
+}
+
+var (
+	// ErrDynamicTable is returned for field sections referencing the
+	// dynamic table.
+	ErrDynamicTable = errors.New("qpack: reference to the dynamic table")
+
+	errInvalid   = errors.New("qpack: invalid field section")
+	errTooLarge  = errors.New("qpack: field section too large")
+	errBadStatic = errors.New("qpack: invalid static table index")
+)
+
+// AppendFieldSection appends the encoding of the field section made of
+// fields to b.
+func AppendFieldSection(b []byte, fields []HeaderField) []byte {
+	// Required Insert Count and Delta Base are zero
+	// (RFC 9204, Section 4.5.1).
+	b = append(b, 0, 0)
+	for _, f := range fields {
+		b = appendField(b, f)
+	}
+	return b
+}
+
+func appendField(b []byte, f HeaderField) []byte {
+	i, exact := lookupStatic(f.Name, f.Value)
+	var nbit byte
+	if f.Sensitive {
+		nbit = 0x20
+	}
+	switch {
+	case exact && !f.Sensitive:
+		// Indexed Field Line, with the static table bit set.
+		return appendInt(b, 0xc0, 6, uint64(i))
+	case i >= 0:
+		// Literal Field Line with Name Reference, to the static table.
+		b = appendInt(b, 0x50|nbit, 4, uint64(i))
+	default:
+		// Literal Field Line with Literal Name.
+		b = appendString(b, 0x20|nbit>>1, 3, f.Name)
+	}
+	return appendString(b, 0, 7, f.Value)
+}
+
+// appendInt appends the prefix integer v using the n low bits of the first
+// byte, whose other bits are set from first (RFC 7541, Section 5.1).
+func appendInt(b []byte, first byte, n uint, v uint64) []byte {
+	max := uint64(1)<<n - 1
+	if v < max {
+		return append(b, first|byte(v))
+	}
+	b = append(b, first|byte(max))
+	v -= max
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+// appendString appends the string s, whose length prefix uses the n low
+// bits of the first byte and whose Huffman flag is the bit above them.
+func appendString(b []byte, first byte, n uint, s string) []byte {
+	if l := hpack.HuffmanEncodeLength(s); l < uint64(len(s)) {
+		b = appendInt(b, first|1<<n, n, l)
+		return hpack.AppendHuffmanString(b, s)
+	}
+	b = appendInt(b, first, n, uint64(len(s)))
+	return append(b, s...)
+}
+
+// readInt reads a prefix integer using the n low bits of the first byte
+// of b, and returns it and the rest of b.
+func readInt(b []byte, n uint) (uint64, []byte, error) {
+	if len(b) == 0 {
+		return 0, nil, errInvalid
+	}
+	max := uint64(1)<<n - 1
+	v := uint64(b[0]) & max
+	b = b[1:]
+	if v < max {
+		return v, b, nil
+	}
+	for shift := uint(0); len(b) > 0; shift += 7 {
+		if shift > 56 {
+			return 0, nil, errInvalid
+		}
+		c := b[0]
+		b = b[1:]
+		v += uint64(c&0x7f) << shift
+		if c&0x80 == 0 {
+			return v, b, nil
+		}
+	}
+	return 0, nil, errInvalid
+}
+
+// readString reads a string whose length prefix uses the n low bits of the
+// first byte of b and whose Huffman flag is the bit above them.
+func readString(b []byte, n uint, maxLen int64) (string, []byte, error) {
+	if len(b) == 0 {
+		return "", nil, errInvalid
+	}
+	huff := b[0]&(1<<n) != 0
+	l, b, err := readInt(b, n)
+	if err != nil {
+		return "", nil, err
+	}
+	if l > uint64(len(b)) {
+		return "", nil, errInvalid
+	}
+	var s string
+	if huff {
+		if s, err = hpack.HuffmanDecodeToString(b[:l]); err != nil {
+			return "", nil, errInvalid
+		}
+	} else {
+		s = string(b[:l])
+	}
+	if int64(len(s)) > maxLen {
+		return "", nil, errTooLarge
+	}
+	return s, b[l:], nil
+}
+
+// ParseFieldSection decodes the field section b and calls f for each of
+// its fields. It returns an error if the size of the fields exceeds
+// maxSize.
+func ParseFieldSection(b []byte, maxSize int64, f func(HeaderField) error) error {
+	ric, b, err := readInt(b, 8)
+	if err != nil {
+		return err
+	}
+	if ric != 0 {
+		return ErrDynamicTable
+	}
+	if _, b, err = readInt(b, 7); err != nil {
+		return err
+	}
+	var size int64
+	for len(b) > 0 {
+		var hf HeaderField
+		c := b[0]
+		switch {
+		case c&0x80 != 0:
+			// Indexed Field Line.
+			if c&0x40 == 0 {
+				return ErrDynamicTable
+			}
+			var i uint64
+			if i, b, err = readInt(b, 6); err != nil {
+				return err
+			}
+			if i >= uint64(len(staticTable)) {
+				return errBadStatic
+			}
+			hf = staticTable[i]
+		case c&0x40 != 0:
+			// Literal Field Line with Name Reference.
+			if c&0x10 == 0 {
+				return ErrDynamicTable
+			}
+			hf.Sensitive = c&0x20 != 0
+			var i uint64
+			if i, b, err = readInt(b, 4); err != nil {
+				return err
+			}
+			if i >= uint64(len(staticTable)) {
+				return errBadStatic
+			}
+			hf.Name = staticTable[i].Name
+			if hf.Value, b, err = readString(b, 7, maxSize-size-hf.Size()); err != nil {
+				return err
+			}
+		case c&0x20 != 0:
+			// Literal Field Line with Literal Name.
+			hf.Sensitive = c&0x10 != 0
+			if hf.Name, b, err = readString(b, 3, maxSize-size-32); err != nil {
+				return err
+			}
+			if hf.Value, b, err = readString(b, 7, maxSize-size-hf.Size()); err != nil {
+				return err
+			}
+		default:
+			// Post-base references are to the dynamic table.
+			return ErrDynamicTable
+		}
+		size += hf.Size()
+		if size > maxSize {
+			return errTooLarge
+		}
+		if err := f(hf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lookupStatic returns the index of the entry of the static table
+// matching name and value, with exact set, or else the index of an entry
+// with the name, or -1.
+func lookupStatic(name, value string) (i int, exact bool) {
+	staticOnce.Do(initStaticIndex)
+	if i, ok := staticByField[[2]string{name, value}]; ok {
+		return i, true
+	}
+	if i, ok := staticByName[name]; ok {
+		return i, false
+	}
+	return -1, false
+}
@@ -0,0 +1,95 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package qpack
+
+import (
+	"encoding/hex"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func parseAll(b []byte, maxSize int64) ([]HeaderField, error) {
+	var fields []HeaderField
+	err := ParseFieldSection(b, maxSize, func(f HeaderField) error {
+		fields = append(fields, f)
+		return nil
+	})
+	return fields, err
+}
+
+func TestRoundTrip(t *testing.T) {
+	fields := []HeaderField{
+		{Name: ":method", Value: "GET"},
+		{Name: ":scheme", Value: "https"},
+		{Name: ":authority", Value: "example.com"},
+		{Name: ":path", Value: "/index.html"},
+		{Name: "user-agent", Value: "Go-http-client/3"},
+		{Name: "authorization", Value: "secret", Sensitive: true},
+		{Name: "content-type", Value: "text/plain"},
+		{Name: "x-custom", Value: ""},
+		{Name: "x-long", Value: strings.Repeat("abc\x00\xff", 100)},
+		{Name: "cache-control", Value: "no-cache", Sensitive: true},
+	}
+	b := AppendFieldSection(nil, fields)
+	got, err := parseAll(b, 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, fields) {
+		t.Errorf("round trip:\n got %+v\nwant %+v", got, fields)
+	}
+	if _, err := parseAll(b, 100); err == nil {
+		t.Errorf("ParseFieldSection accepted a field section larger than its limit")
+	}
+}
+
+// TestDecodeExample decodes the field section of RFC 9204, Appendix B.1.
+func TestDecodeExample(t *testing.T) {
+	b, _ := hex.DecodeString("0000510b2f696e6465782e68746d6c")
+	got, err := parseAll(b, 1<<10)
+	want := []HeaderField{{Name: ":path", Value: "/index.html"}}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseFieldSection = %+v, %v, want %+v", got, err, want)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name, hex string
+	}{
+		{"dynamic insert count", "0100"},
+		{"dynamic indexed", "000080"},
+		{"post-base indexed", "000010"},
+		{"static out of range", "0000ff24"},
+		{"truncated string", "0000510b2f69"},
+		{"truncated prefix", "00"},
+	} {
+		b, _ := hex.DecodeString(tt.hex)
+		if _, err := parseAll(b, 1<<10); err == nil {
+			t.Errorf("%s: ParseFieldSection(%s) succeeded", tt.name, tt.hex)
+		}
+	}
+}
+
+func TestStaticTable(t *testing.T) {
+	if len(staticTable) != 99 {
+		t.Errorf("static table has %d entries, want 99", len(staticTable))
+	}
+	for _, tt := range []struct {
+		i           int
+		name, value string
+	}{
+		{0, ":authority", ""},
+		{17, ":method", "GET"},
+		{25, ":status", "200"},
+		{63, ":status", "100"},
+		{98, "x-frame-options", "sameorigin"},
+	} {
+		if f := staticTable[tt.i]; f.Name != tt.name || f.Value != tt.value {
+			t.Errorf("staticTable[%d] = %q: %q, want %q: %q", tt.i, f.Name, f.Value, tt.name, tt.value)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quic
+
+// A sendBuffer holds the data written to a stream or to the CRYPTO
+// stream of an encryption level until the peer acknowledges it.
+type sendBuffer struct {
+	buf   []byte   // data from offset base onwards
+	base  int64    // offset of buf[0]; all data before it is acknowledged
+	next  int64    // offset of the first byte never sent
+	acked rangeset // acknowledged data at or after base
+	lost  rangeset // data to send again
+
+	fin       bool // whether the stream has ended at end()
+	finSent   bool
+	finAcked  bool
+	finToSend bool // whether the FIN bit must be sent again
+}
+
+// end returns the offset of the end of the data written.
+func (b *sendBuffer) end() int64 {
+	return b.base + int64(len(b.buf))
+}
+
+// buffered returns the amount of data written and not yet acknowledged.
+func (b *sendBuffer) buffered() int {
+	return len(b.buf)
+}
+
+func (b *sendBuffer) write(p []byte) {
+	b.buf = append(b.buf, p...)
+}
+
+// hasData reports whether there is data, or a FIN, to send, limited to
+// offsets before limit for new data.
+func (b *sendBuffer) hasData(limit int64) bool {
+	if len(b.lost) > 0 || b.finToSend {
+		return true
+	}
+	if b.next < b.end() && b.next < limit {
+		return true
+	}
+	return b.fin && !b.finSent && b.next == b.end()
+}
+
+// nextRange returns the next range of at most max bytes to send, data
+// lost first, and reports whether it ends the stream. New data is only
+// sent before offset limit. The returned data is marked as sent.
+func (b *sendBuffer) nextRange(max int, limit int64) (off int64, data []byte, fin bool) {
+	for len(b.lost) > 0 {
+		sp := b.lost[0]
+		if sp.end <= b.base {
+			b.lost = b.lost[1:]
+			continue
+		}
+		start := sp.start
+		if start < b.base {
+			start = b.base
+		}
+		// Skip over data acknowledged since it was lost.
+		if b.acked.contains(start) {
+			b.lost.remove(start, b.acked.prefix(start))
+			continue
+		}
+		end := min(sp.end, start+int64(max))
+		for _, a := range b.acked {
+			if a.start > start && a.start < end {
+				end = a.start
+				break
+			}
+		}
+		b.lost.remove(start, end)
+		fin = b.fin && end == b.end() && (b.finToSend || !b.finSent)
+		if fin {
+			b.finSent, b.finToSend = true, false
+		}
+		return start, b.buf[start-b.base : end-b.base], fin
+	}
+	end := min(b.end(), b.next+int64(max), max64(limit, b.next))
+	off = b.next
+	data = b.buf[off-b.base : end-b.base]
+	b.next = end
+	fin = b.fin && end == b.end() && (b.finToSend || !b.finSent)
+	if fin {
+		b.finSent, b.finToSend = true, false
+	}
+	return off, data, fin
+}
+
+func max64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// ack records that [off, end) was acknowledged by the peer, and
+// releases the data no longer needed.
+func (b *sendBuffer) ack(off, end int64, fin bool) {
+	if fin {
+		b.finAcked = true
+		b.finToSend = false
+	}
+	if end <= b.base {
+		return
+	}
+	b.acked.add(max64(off, b.base), end)
+	if len(b.acked) > 0 && b.acked[0].start <= b.base {
+		n := b.acked[0].end - b.base
+		b.buf = b.buf[n:]
+		b.base += n
+		b.acked = b.acked[1:]
+		if len(b.buf) == 0 {
+			b.buf = nil
+		}
+	}
+}
+
+// lose records that [off, end) was lost and must be sent again.
+func (b *sendBuffer) lose(off, end int64, fin bool) {
+	if fin && !b.finAcked {
+		b.finToSend = true
+	}
+	if end > b.base {
+		b.lost.add(max64(off, b.base), end)
+	}
+}
+
+// done reports whether all the data and the FIN have been acknowledged.
+func (b *sendBuffer) done() bool {
+	return b.fin && b.finAcked && len(b.buf) == 0
+}
+
+// A recvBuffer reassembles the data received on a stream or on the
+// CRYPTO stream of an encryption level.
+type recvBuffer struct {
+	buf   []byte   // buf[i] is the byte at offset base+i
+	base  int64    // offset of the next byte to read
+	recvd rangeset // ranges received at or after base
+	max   int64    // largest offset received
+	final int64    // final size, or -1 if not known
+}
+
+func newRecvBuffer() recvBuffer {
+	return recvBuffer{final: -1}
+}
+
+// write stores data received at offset off.
+func (b *recvBuffer) write(off int64, data []byte) {
+	end := off + int64(len(data))
+	b.max = max64(b.max, end)
+	if end <= b.base {
+		return
+	}
+	if off < b.base {
+		data = data[b.base-off:]
+		off = b.base
+	}
+	if n := int(end - b.base); n > len(b.buf) {
+		if n <= cap(b.buf) {
+			b.buf = b.buf[:n]
+		} else {
+			b.buf = append(b.buf, make([]byte, n-len(b.buf))...)
+		}
+	}
+	copy(b.buf[off-b.base:], data)
+	b.recvd.add(off, end)
+}
+
+// readable returns the number of bytes that can be read.
+func (b *recvBuffer) readable() int {
+	if len(b.recvd) == 0 || b.recvd[0].start > b.base {
+		return 0
+	}
+	return int(b.recvd[0].end - b.base)
+}
+
+// read reads into p and returns the number of bytes read.
+func (b *recvBuffer) read(p []byte) int {
+	n := copy(p, b.buf[:b.readable()])
+	b.discard(n)
+	return n
+}
+
+// peek returns the data that can be read, without consuming it.
+func (b *recvBuffer) peek() []byte {
+	return b.buf[:b.readable()]
+}
+
+// discard consumes n readable bytes.
+func (b *recvBuffer) discard(n int) {
+	b.buf = append(b.buf[:0], b.buf[n:]...)
+	b.base += int64(n)
+	b.recvd.remove(0, b.base)
+}
+
+// eof reports whether all the data of the stream has been read.
+func (b *recvBuffer) eof() bool {
+	return b.final >= 0 && b.base == b.final
+}
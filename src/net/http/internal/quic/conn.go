@@ -0,0 +1,1393 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quic
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// A spaceID identifies a packet number space.
+type spaceID int
+
+const (
+	initialSpace spaceID = iota
+	handshakeSpace
+	appDataSpace
+)
+
+var spaceLevels = [...]tls.QUICEncryptionLevel{
+	initialSpace:   tls.QUICEncryptionLevelInitial,
+	handshakeSpace: tls.QUICEncryptionLevelHandshake,
+	appDataSpace:   tls.QUICEncryptionLevelApplication,
+}
+
+// maxCryptoBuffer is the maximum amount of out-of-order CRYPTO data
+// buffered at an encryption level.
+const maxCryptoBuffer = 64 << 10
+
+// maxAckRanges is the maximum number of ranges of packet numbers
+// remembered, and acknowledged, in a packet number space.
+const maxAckRanges = 32
+
+// maxUndecryptable is the maximum number of packets buffered while
+// waiting for the keys to decrypt them.
+const maxUndecryptable = 16
+
+// A pnSpace is the state of a packet number space.
+type pnSpace struct {
+	read, write *keys // for appDataSpace, the 1-RTT keys
+	discarded   bool
+
+	// Packets sent.
+	nextPN           int64
+	largestAcked     int64
+	sent             []*sentPacket // by increasing packet number
+	lossTime         time.Time
+	lastAckEliciting time.Time
+	probes           int // ack-eliciting packets to send on PTO
+
+	// Packets received.
+	recvd            rangeset
+	largestRecv      int64
+	largestRecvTime  time.Time
+	ackNeeded        bool      // packets were received since the last ACK
+	ackTime          time.Time // when an ACK must be sent, if not zero
+	unackedEliciting int
+
+	cryptoSend sendBuffer
+	cryptoRecv recvBuffer
+}
+
+// A localError is a transport error detected by this endpoint.
+type localError struct {
+	code   transportError
+	reason string
+}
+
+func (e *localError) Error() string { return e.reason }
+
+// A notifier wakes the goroutines waiting for a change of state.
+// It is guarded by the mutex of the connection.
+type notifier struct {
+	c chan struct{}
+}
+
+func (n *notifier) wait() <-chan struct{} {
+	if n.c == nil {
+		n.c = make(chan struct{})
+	}
+	return n.c
+}
+
+func (n *notifier) notify() {
+	if n.c != nil {
+		close(n.c)
+		n.c = nil
+	}
+}
+
+// A Conn is a QUIC connection.
+type Conn struct {
+	ep         *Endpoint
+	cfg        *Config
+	isClient   bool
+	remote     net.Addr
+	serverName string
+	tls        *tls.QUICConn
+
+	inc            chan []byte   // datagrams received
+	wakec          chan struct{} // wakes the connection loop
+	donec          chan struct{} // closed when the connection is done
+	handshakeDonec chan struct{} // closed when the handshake completes
+	earlyc         chan struct{} // closed when 0-RTT data can be sent
+
+	mu          sync.Mutex
+	localCID    []byte
+	remoteCID   []byte
+	origDCID    []byte // destination connection ID of the first Initial
+	peerSCID    []byte // source connection ID of the peer's first packet
+	gotPeerCID  bool
+	localParams transportParams
+	peerParams  transportParams
+	tlsState    tls.ConnectionState
+
+	spaces               [3]pnSpace
+	earlyRead            *keys
+	earlyWrite           *keys
+	nextRead             *keys // 1-RTT read keys of the next key phase
+	keyPhase             bool
+	keysChanged          bool
+	undecryptable        [][]byte
+	handshakeComplete    bool
+	handshakeConfirmed   bool
+	handshakeDonePending bool
+
+	rtt      rttState
+	cc       newReno
+	ptoCount int
+
+	// Streams. Indexes of the arrays are 0 for bidirectional streams
+	// and 1 for unidirectional streams.
+	streams          map[int64]*Stream
+	sendQueue        []*Stream
+	nextLocal        [2]int64 // number of streams opened
+	maxLocal         [2]int64 // limit set by the peer
+	nextRemote       [2]int64 // number of streams opened by the peer
+	maxRemote        [2]int64 // limit set for the peer
+	maxRemotePending [2]bool
+	acceptq          [2][]*Stream
+	acceptn          [2]notifier
+	openn            notifier
+
+	// Connection flow control.
+	maxData        int64 // limit set by the peer
+	dataSent       int64
+	recvMaxData    int64 // limit set for the peer
+	recvData       int64
+	recvConsumed   int64
+	maxDataPending bool
+
+	pathResponses [][]byte
+	pingPending   bool
+
+	// Closing.
+	closeErr         error // set once the connection is closing
+	closing          bool  // a CONNECTION_CLOSE was, or must be, sent
+	closeSendPending bool
+	closeApp         bool
+	closeCode        uint64
+	closeReason      string
+	closeDeadline    time.Time
+	closeAfterWrites *ApplicationError // set by CloseAfterWrites
+	draining         bool
+	finished         bool
+
+	idleTimeout   time.Duration
+	keepAlive     time.Duration
+	lastActivity  time.Time
+	addrValidated bool
+	bytesRecvd    int
+	bytesSent     int
+}
+
+// newConn returns a new connection using tlsConfig, which must have been
+// prepared by prepareTLSConfig.
+func newConn(ep *Endpoint, remote net.Addr, isClient bool, cfg *Config, tlsConfig *tls.Config, origDCID, peerSCID []byte) (*Conn, error) {
+	c := &Conn{
+		ep:             ep,
+		cfg:            cfg,
+		isClient:       isClient,
+		remote:         remote,
+		inc:            make(chan []byte, 64),
+		wakec:          make(chan struct{}, 1),
+		donec:          make(chan struct{}),
+		handshakeDonec: make(chan struct{}),
+		earlyc:         make(chan struct{}),
+		localCID:       newConnID(),
+		origDCID:       origDCID,
+		streams:        make(map[int64]*Stream),
+		rtt:            newRTTState(),
+		cc:             newNewReno(),
+		peerParams:     defaultTransportParams(),
+		idleTimeout:    cfg.maxIdleTimeout(),
+		keepAlive:      cfg.KeepAlivePeriod,
+		lastActivity:   time.Now(),
+		addrValidated:  isClient,
+	}
+	if isClient {
+		c.remoteCID = origDCID
+	} else {
+		c.remoteCID = bytes.Clone(peerSCID)
+		c.peerSCID = c.remoteCID
+		c.gotPeerCID = true
+	}
+	for i := range c.spaces {
+		c.spaces[i].largestAcked = -1
+		c.spaces[i].largestRecv = -1
+		c.spaces[i].cryptoRecv = newRecvBuffer()
+	}
+	clientKeys, serverKeys := initialKeys(origDCID)
+	if isClient {
+		c.spaces[initialSpace].read, c.spaces[initialSpace].write = serverKeys, clientKeys
+	} else {
+		c.spaces[initialSpace].read, c.spaces[initialSpace].write = clientKeys, serverKeys
+	}
+
+	streamWindow := cfg.maxStreamReadBufferSize()
+	c.localParams = transportParams{
+		maxIdleTimeout:          c.idleTimeout,
+		maxUDPPayloadSize:       65527,
+		initialMaxData:          cfg.maxConnReadBufferSize(),
+		maxStreamDataBidiLocal:  streamWindow,
+		maxStreamDataBidiRemote: streamWindow,
+		maxStreamDataUni:        streamWindow,
+		initialMaxStreamsBidi:   cfg.maxBidiRemoteStreams(),
+		initialMaxStreamsUni:    cfg.maxUniRemoteStreams(),
+		ackDelayExponent:        3,
+		maxAckDelay:             25 * time.Millisecond,
+		disableActiveMigration:  true,
+		activeConnectionIDLimit: 2,
+		initialSCID:             c.localCID,
+	}
+	if !isClient {
+		c.localParams.origDCID = origDCID
+	}
+	c.recvMaxData = c.localParams.initialMaxData
+	c.maxRemote = [2]int64{c.localParams.initialMaxStreamsBidi, c.localParams.initialMaxStreamsUni}
+
+	c.serverName = tlsConfig.ServerName
+	qc := &tls.QUICConfig{TLSConfig: tlsConfig}
+	if isClient {
+		c.tls = tls.QUICClient(qc)
+	} else {
+		c.tls = tls.QUICServer(qc)
+	}
+	c.tls.SetTransportParameters(c.localParams.marshal())
+	if err := c.tls.Start(context.Background()); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	err := c.handleTLSEvents(time.Now())
+	c.mu.Unlock()
+	if err != nil {
+		c.tls.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// LocalAddr returns the local network address.
+func (c *Conn) LocalAddr() net.Addr { return c.ep.LocalAddr() }
+
+// RemoteAddr returns the remote network address.
+func (c *Conn) RemoteAddr() net.Addr { return c.remote }
+
+// ConnectionState returns basic TLS details about the connection, once
+// the handshake has completed.
+func (c *Conn) ConnectionState() tls.ConnectionState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.tlsState
+}
+
+// HandshakeComplete returns a channel that is closed when the handshake
+// completes. Until then, a client that resumed a session sends data in
+// 0-RTT packets, which the server may reject and which may be replayed.
+func (c *Conn) HandshakeComplete() <-chan struct{} {
+	return c.handshakeDonec
+}
+
+// Done returns a channel that is closed when the connection is closed.
+func (c *Conn) Done() <-chan struct{} {
+	return c.donec
+}
+
+// Err returns the error that closed the connection, or nil if it is
+// still open.
+func (c *Conn) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closeErr
+}
+
+// SetKeepAlivePeriod changes the keep-alive period of the connection,
+// initially Config.KeepAlivePeriod. A period of zero disables keep-alives.
+func (c *Conn) SetKeepAlivePeriod(d time.Duration) {
+	c.mu.Lock()
+	c.keepAlive = d
+	c.mu.Unlock()
+	c.wake()
+}
+
+// Close closes the connection with the application error code 0.
+func (c *Conn) Close() error {
+	return c.CloseWithError(0, "")
+}
+
+// CloseWithError closes the connection with an application error code
+// and reason sent to the peer. It does not wait for the peer to
+// acknowledge the closure.
+func (c *Conn) CloseWithError(code uint64, reason string) error {
+	c.mu.Lock()
+	c.abortLocked(time.Now(), &ApplicationError{Code: code, Reason: reason})
+	c.mu.Unlock()
+	c.wake()
+	return nil
+}
+
+// CloseAfterWrites closes the connection like CloseWithError, once the
+// peer has acknowledged the data written to the streams of the
+// connection. Unlike with CloseWithError, data written before is not lost.
+func (c *Conn) CloseAfterWrites(code uint64, reason string) {
+	c.mu.Lock()
+	if c.closeAfterWrites == nil {
+		c.closeAfterWrites = &ApplicationError{Code: code, Reason: reason}
+	}
+	c.mu.Unlock()
+	c.wake()
+}
+
+// writesAckedLocked reports whether all the data written to the streams of
+// the connection, and not abandoned, has been acknowledged.
+func (c *Conn) writesAckedLocked() bool {
+	for _, s := range c.streams {
+		if !s.canSend || s.resetPending || s.resetSent {
+			continue
+		}
+		if s.send.buffered() > 0 || s.send.fin && !s.send.finAcked {
+			return false
+		}
+	}
+	return true
+}
+
+// abortLocked starts closing the connection with the error err.
+func (c *Conn) abortLocked(now time.Time, err error) {
+	if c.closeErr != nil {
+		return
+	}
+	c.closeErr = err
+	c.closing = true
+	c.closeSendPending = true
+	c.closeDeadline = now.Add(3 * c.rtt.pto())
+	switch err := err.(type) {
+	case *ApplicationError:
+		c.closeApp = true
+		c.closeCode = err.Code
+		c.closeReason = err.Reason
+	case *TransportError:
+		c.closeCode = err.Code
+		c.closeReason = err.Reason
+	}
+	c.notifyAll()
+}
+
+// abortWithError closes the connection after an error detected while
+// processing packets.
+func (c *Conn) abortWithError(now time.Time, err error) {
+	var code transportError
+	var le *localError
+	var alert tls.AlertError
+	switch {
+	case errors.As(err, &le):
+		code = le.code
+	case errors.As(err, &alert):
+		code = errTLSAlertBase + transportError(alert)
+	default:
+		code = errInternal
+	}
+	c.abortLocked(now, &TransportError{Code: uint64(code), Reason: err.Error()})
+}
+
+// notifyAll wakes all the goroutines waiting on the connection.
+func (c *Conn) notifyAll() {
+	for _, s := range c.streams {
+		s.readn.notify()
+		s.writen.notify()
+	}
+	for _, s := range c.sendQueue {
+		s.readn.notify()
+		s.writen.notify()
+	}
+	c.openn.notify()
+	c.acceptn[0].notify()
+	c.acceptn[1].notify()
+}
+
+func (c *Conn) wake() {
+	select {
+	case c.wakec <- struct{}{}:
+	default:
+	}
+}
+
+// loop runs the connection until it is done.
+func (c *Conn) loop() {
+	defer c.ep.connDone(c)
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+	for {
+		c.mu.Lock()
+		now := time.Now()
+		c.handleTimers(now)
+		if c.closeAfterWrites != nil && c.writesAckedLocked() {
+			c.abortLocked(now, c.closeAfterWrites)
+		}
+		var out [][]byte
+		for len(out) < 16 {
+			d := c.buildDatagram(now)
+			if d == nil {
+				break
+			}
+			out = append(out, d)
+		}
+		done := c.finished || c.closing && !c.closeSendPending && !now.Before(c.closeDeadline)
+		next := c.nextDeadline()
+		c.mu.Unlock()
+
+		for _, d := range out {
+			c.ep.writeTo(d, c.remote)
+		}
+		if done {
+			c.finish()
+			return
+		}
+		d := time.Hour
+		if !next.IsZero() {
+			d = max(next.Sub(now), time.Millisecond)
+		}
+		timer.Reset(d)
+		select {
+		case b := <-c.inc:
+			c.mu.Lock()
+			now := time.Now()
+			c.handleDatagram(now, b)
+			for more := true; more; {
+				select {
+				case b := <-c.inc:
+					c.handleDatagram(now, b)
+				default:
+					more = false
+				}
+			}
+			c.mu.Unlock()
+		case <-c.wakec:
+		case <-timer.C:
+		}
+	}
+}
+
+// finish releases the resources of a connection that is done.
+func (c *Conn) finish() {
+	c.mu.Lock()
+	c.finished = true
+	if c.closeErr == nil {
+		c.closeErr = ErrEndpointClosed
+	}
+	c.notifyAll()
+	c.mu.Unlock()
+	close(c.donec)
+	c.tls.Close()
+}
+
+// handleTimers handles the expiry of the timers of the connection.
+func (c *Conn) handleTimers(now time.Time) {
+	if c.finished || c.draining {
+		return
+	}
+	if c.closing {
+		return
+	}
+	if !now.Before(c.idleDeadline()) {
+		c.closeErr = ErrIdleTimeout
+		c.notifyAll()
+		c.finished = true
+		return
+	}
+	if p := c.keepAlive; p > 0 && c.handshakeComplete && now.Sub(c.lastActivity) >= p {
+		c.pingPending = true
+	}
+	for id := range c.spaces {
+		sp := &c.spaces[id]
+		if !sp.lossTime.IsZero() && !now.Before(sp.lossTime) {
+			c.detectLoss(now, spaceID(id))
+			return
+		}
+	}
+	if t, id, ok := c.ptoDeadline(); ok && !now.Before(t) {
+		c.onPTO(id)
+	}
+}
+
+// idleDeadline returns the time at which the connection times out.
+func (c *Conn) idleDeadline() time.Time {
+	return c.lastActivity.Add(max(c.idleTimeout, 3*c.rtt.pto()))
+}
+
+// nextDeadline returns the time at which handleTimers or buildDatagram
+// must run next, or the zero time if there is none.
+func (c *Conn) nextDeadline() time.Time {
+	if c.finished || c.draining {
+		return time.Time{}
+	}
+	if c.closing {
+		return c.closeDeadline
+	}
+	next := c.idleDeadline()
+	earliest := func(t time.Time) {
+		if !t.IsZero() && t.Before(next) {
+			next = t
+		}
+	}
+	if p := c.keepAlive; p > 0 && c.handshakeComplete {
+		earliest(c.lastActivity.Add(p))
+	}
+	lossTimer := false
+	for id := range c.spaces {
+		sp := &c.spaces[id]
+		if !sp.discarded && sp.ackNeeded {
+			earliest(sp.ackTime)
+		}
+		if !sp.lossTime.IsZero() {
+			earliest(sp.lossTime)
+			lossTimer = true
+		}
+	}
+	if !lossTimer {
+		if t, _, ok := c.ptoDeadline(); ok {
+			earliest(t)
+		}
+	}
+	return next
+}
+
+// handleDatagram handles a datagram received from the peer.
+func (c *Conn) handleDatagram(now time.Time, b []byte) {
+	if c.finished || c.draining {
+		return
+	}
+	c.bytesRecvd += len(b)
+	for len(b) > 0 {
+		n := c.handlePacket(now, b, true)
+		if n <= 0 {
+			break
+		}
+		b = b[n:]
+	}
+	// Packets that could not be decrypted may be decrypted with new
+	// keys installed while handling this datagram.
+	for c.keysChanged && len(c.undecryptable) > 0 && !c.closing {
+		c.keysChanged = false
+		pkts := c.undecryptable
+		c.undecryptable = nil
+		for _, p := range pkts {
+			c.handlePacket(now, p, true)
+		}
+	}
+}
+
+// handlePacket handles the first packet of b, and returns its size, or a
+// non-positive value if the rest of the datagram must be dropped.
+func (c *Conn) handlePacket(now time.Time, b []byte, bufferable bool) int {
+	h, ok := parseHeader(b)
+	if !ok || h.typ != packetType1RTT && h.version != quicVersion1 {
+		return -1
+	}
+	if h.typ == packetTypeRetry {
+		return -1 // Retry is not supported.
+	}
+	if !bytes.Equal(h.dcid, c.localCID) && (c.isClient || h.typ == packetType1RTT || !bytes.Equal(h.dcid, c.origDCID)) {
+		return h.size
+	}
+	if c.closing {
+		c.closeSendPending = true
+		return h.size
+	}
+	var space spaceID
+	var k *keys
+	switch h.typ {
+	case packetTypeInitial:
+		space, k = initialSpace, c.spaces[initialSpace].read
+	case packetTypeHandshake:
+		space, k = handshakeSpace, c.spaces[handshakeSpace].read
+	case packetType0RTT:
+		if c.isClient || c.handshakeComplete {
+			return h.size
+		}
+		space, k = appDataSpace, c.earlyRead
+	case packetType1RTT:
+		space, k = appDataSpace, c.spaces[appDataSpace].read
+	}
+	sp := &c.spaces[space]
+	if sp.discarded {
+		return h.size
+	}
+	if k == nil {
+		if bufferable && h.typ != packetTypeInitial && len(c.undecryptable) < maxUndecryptable {
+			c.undecryptable = append(c.undecryptable, bytes.Clone(b[:h.size]))
+		}
+		return h.size
+	}
+	pkt := b[:h.size]
+	pnLen, truncated, ok := k.unprotectHeader(pkt, h.pnOff)
+	if !ok {
+		return h.size
+	}
+	pn := decodePacketNumber(sp.largestRecv, truncated, pnLen)
+	hdrLen := h.pnOff + pnLen
+	reserved := pkt[0] & 0x0c
+	keyUpdate := false
+	if h.typ == packetType1RTT {
+		reserved = pkt[0] & 0x18
+		if phase := pkt[0]&0x04 != 0; phase != c.keyPhase {
+			if c.nextRead == nil {
+				nk, err := k.next()
+				if err != nil {
+					return h.size
+				}
+				c.nextRead = nk
+			}
+			k = c.nextRead
+			keyUpdate = true
+		}
+	}
+	payload, err := k.open(pkt, hdrLen, pn)
+	if err != nil {
+		return h.size
+	}
+	if reserved != 0 {
+		c.abortWithError(now, &localError{errProtocolViolation, "reserved header bits set"})
+		return -1
+	}
+	if keyUpdate {
+		if !c.handshakeConfirmed {
+			c.abortWithError(now, &localError{errKeyUpdate, "key update before handshake confirmation"})
+			return -1
+		}
+		// The peer initiated a key update: update the sending keys too.
+		nw, err := sp.write.next()
+		if err != nil {
+			return h.size
+		}
+		sp.read, sp.write, c.nextRead = c.nextRead, nw, nil
+		c.keyPhase = !c.keyPhase
+	}
+	if sp.recvd.contains(pn) {
+		return h.size // duplicate
+	}
+
+	if c.isClient && !c.gotPeerCID && h.typ != packetType1RTT {
+		c.gotPeerCID = true
+		c.remoteCID = bytes.Clone(h.scid)
+		c.peerSCID = c.remoteCID
+	}
+	if !c.isClient && h.typ == packetTypeHandshake && !c.addrValidated {
+		// Receiving a Handshake packet validates the address of the
+		// client, and means it no longer needs Initial packets.
+		c.addrValidated = true
+		c.discardSpace(initialSpace)
+	}
+
+	ackEliciting, err := c.handleFrames(now, h.typ, space, payload)
+	if err != nil {
+		c.abortWithError(now, err)
+		return -1
+	}
+	if sp.discarded {
+		return h.size
+	}
+	sp.recvd.add(pn, pn+1)
+	if len(sp.recvd) > maxAckRanges {
+		sp.recvd = sp.recvd[len(sp.recvd)-maxAckRanges:]
+	}
+	if pn > sp.largestRecv {
+		sp.largestRecv = pn
+		sp.largestRecvTime = now
+	}
+	sp.ackNeeded = true
+	if ackEliciting {
+		sp.unackedEliciting++
+		switch {
+		case space != appDataSpace, sp.unackedEliciting >= 2, pn < sp.largestRecv:
+			sp.ackTime = now
+		case sp.ackTime.IsZero():
+			sp.ackTime = now.Add(c.localParams.maxAckDelay)
+		}
+	}
+	c.lastActivity = now
+	return h.size
+}
+
+// frameReader parses the fields of frames.
+type frameReader struct {
+	b   []byte
+	err bool
+}
+
+func (r *frameReader) varint() int64 {
+	v, n := ConsumeVarint(r.b)
+	if n < 0 {
+		r.err = true
+		return 0
+	}
+	r.b = r.b[n:]
+	return int64(v)
+}
+
+func (r *frameReader) bytes(n int64) []byte {
+	if n < 0 || n > int64(len(r.b)) {
+		r.err = true
+		return nil
+	}
+	b := r.b[:n]
+	r.b = r.b[n:]
+	return b
+}
+
+// Frame types (RFC 9000, Section 19).
+const (
+	frameTypePadding            = 0x00
+	frameTypePing               = 0x01
+	frameTypeAck                = 0x02
+	frameTypeAckECN             = 0x03
+	frameTypeResetStream        = 0x04
+	frameTypeStopSending        = 0x05
+	frameTypeCrypto             = 0x06
+	frameTypeNewToken           = 0x07
+	frameTypeStreamBase         = 0x08
+	frameTypeStreamMax          = 0x0f
+	frameTypeMaxData            = 0x10
+	frameTypeMaxStreamData      = 0x11
+	frameTypeMaxStreamsBidi     = 0x12
+	frameTypeMaxStreamsUni      = 0x13
+	frameTypeDataBlocked        = 0x14
+	frameTypeStreamDataBlocked  = 0x15
+	frameTypeStreamsBlockedBidi = 0x16
+	frameTypeStreamsBlockedUni  = 0x17
+	frameTypeNewConnectionID    = 0x18
+	frameTypeRetireConnectionID = 0x19
+	frameTypePathChallenge      = 0x1a
+	frameTypePathResponse       = 0x1b
+	frameTypeConnectionClose    = 0x1c
+	frameTypeConnectionCloseApp = 0x1d
+	frameTypeHandshakeDone      = 0x1e
+)
+
+// handleFrames handles the frames of a packet, and reports whether the
+// packet is ack-eliciting.
+func (c *Conn) handleFrames(now time.Time, typ packetType, space spaceID, payload []byte) (ackEliciting bool, err error) {
+	if len(payload) == 0 {
+		return false, &localError{errProtocolViolation, "packet with no frames"}
+	}
+	r := &frameReader{b: payload}
+	for len(r.b) > 0 && !c.closing && !c.draining {
+		ft := r.varint()
+		if r.err {
+			break
+		}
+		switch ft {
+		case frameTypePadding, frameTypeAck, frameTypeAckECN, frameTypeConnectionClose, frameTypeConnectionCloseApp:
+		default:
+			ackEliciting = true
+		}
+		if !frameAllowed(typ, ft) {
+			return false, &localError{errProtocolViolation, "frame not allowed in packet type " + typ.String()}
+		}
+		switch {
+		case ft == frameTypePadding:
+			for len(r.b) > 0 && r.b[0] == 0 {
+				r.b = r.b[1:]
+			}
+		case ft == frameTypePing:
+		case ft == frameTypeAck || ft == frameTypeAckECN:
+			err = c.handleAckFrame(now, space, r, ft == frameTypeAckECN)
+		case ft == frameTypeCrypto:
+			off := r.varint()
+			data := r.bytes(r.varint())
+			if !r.err {
+				err = c.handleCrypto(now, space, off, data)
+			}
+		case ft >= frameTypeStreamBase && ft <= frameTypeStreamMax:
+			id := r.varint()
+			var off int64
+			if ft&0x04 != 0 {
+				off = r.varint()
+			}
+			var data []byte
+			if ft&0x02 != 0 {
+				data = r.bytes(r.varint())
+			} else {
+				data, r.b = r.b, nil
+			}
+			if !r.err {
+				err = c.handleStreamFrame(id, off, data, ft&0x01 != 0)
+			}
+		case ft == frameTypeResetStream:
+			id, code, final := r.varint(), r.varint(), r.varint()
+			if !r.err {
+				err = c.handleResetStream(id, uint64(code), final)
+			}
+		case ft == frameTypeStopSending:
+			id, code := r.varint(), r.varint()
+			if !r.err {
+				err = c.handleStopSending(id, uint64(code))
+			}
+		case ft == frameTypeMaxData:
+			if v := r.varint(); v > c.maxData {
+				c.maxData = v
+				c.queueBlockedStreams()
+			}
+		case ft == frameTypeMaxStreamData:
+			id, v := r.varint(), r.varint()
+			if !r.err {
+				err = c.handleMaxStreamData(id, v)
+			}
+		case ft == frameTypeMaxStreamsBidi || ft == frameTypeMaxStreamsUni:
+			v := r.varint()
+			if v > 1<<60 {
+				return false, &localError{errFrameEncoding, "invalid MAX_STREAMS"}
+			}
+			i := ft - frameTypeMaxStreamsBidi
+			if v > c.maxLocal[i] {
+				c.maxLocal[i] = v
+				c.openn.notify()
+			}
+		case ft == frameTypeDataBlocked, ft == frameTypeStreamsBlockedBidi, ft == frameTypeStreamsBlockedUni,
+			ft == frameTypeRetireConnectionID:
+			r.varint()
+		case ft == frameTypeStreamDataBlocked:
+			r.varint()
+			r.varint()
+		case ft == frameTypeNewToken:
+			if !c.isClient {
+				return false, &localError{errProtocolViolation, "NEW_TOKEN sent by client"}
+			}
+			r.bytes(r.varint())
+		case ft == frameTypeNewConnectionID:
+			// Only the connection ID of the handshake is used.
+			r.varint()
+			r.varint()
+			if n := r.bytes(1); len(n) == 1 {
+				r.bytes(int64(n[0]))
+			}
+			r.bytes(16)
+		case ft == frameTypePathChallenge:
+			if data := r.bytes(8); !r.err && len(c.pathResponses) < 4 {
+				c.pathResponses = append(c.pathResponses, bytes.Clone(data))
+			}
+		case ft == frameTypePathResponse:
+			r.bytes(8)
+		case ft == frameTypeConnectionClose || ft == frameTypeConnectionCloseApp:
+			code := r.varint()
+			if ft == frameTypeConnectionClose {
+				r.varint() // frame type
+			}
+			reason := r.bytes(r.varint())
+			if r.err {
+				break
+			}
+			c.draining = true
+			c.finished = true
+			if ft == frameTypeConnectionClose {
+				c.closeErr = &TransportError{Code: uint64(code), Reason: string(reason), Remote: true}
+			} else {
+				c.closeErr = &ApplicationError{Code: uint64(code), Reason: string(reason), Remote: true}
+			}
+			c.notifyAll()
+		case ft == frameTypeHandshakeDone:
+			if !c.isClient {
+				return false, &localError{errProtocolViolation, "HANDSHAKE_DONE sent by client"}
+			}
+			if !c.handshakeConfirmed {
+				c.handshakeConfirmed = true
+				c.discardSpace(handshakeSpace)
+			}
+		default:
+			return false, &localError{errFrameEncoding, "unknown frame type"}
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+	if r.err {
+		return false, &localError{errFrameEncoding, "malformed frame"}
+	}
+	return ackEliciting, nil
+}
+
+// frameAllowed reports whether frames of type ft may be sent in packets
+// of type typ (RFC 9000, Section 12.4).
+func frameAllowed(typ packetType, ft int64) bool {
+	switch typ {
+	case packetTypeInitial, packetTypeHandshake:
+		switch ft {
+		case frameTypePadding, frameTypePing, frameTypeAck, frameTypeAckECN, frameTypeCrypto, frameTypeConnectionClose:
+			return true
+		}
+		return false
+	case packetType0RTT:
+		switch ft {
+		case frameTypeAck, frameTypeAckECN, frameTypeCrypto, frameTypeHandshakeDone, frameTypeNewToken,
+			frameTypePathResponse, frameTypeRetireConnectionID:
+			return false
+		}
+	}
+	return true
+}
+
+// handleAckFrame handles an ACK frame.
+func (c *Conn) handleAckFrame(now time.Time, space spaceID, r *frameReader, ecn bool) error {
+	largest, delay, count, first := r.varint(), r.varint(), r.varint(), r.varint()
+	if r.err || first > largest {
+		return &localError{errFrameEncoding, "malformed ACK frame"}
+	}
+	ranges := []span{{largest - first, largest + 1}}
+	lo := largest - first
+	for i := int64(0); i < count && !r.err; i++ {
+		gap, n := r.varint(), r.varint()
+		hi := lo - gap - 2
+		lo = hi - n
+		if lo < 0 {
+			return &localError{errFrameEncoding, "malformed ACK frame"}
+		}
+		ranges = append(ranges, span{lo, hi + 1})
+	}
+	if ecn {
+		r.varint()
+		r.varint()
+		r.varint()
+	}
+	if r.err {
+		return &localError{errFrameEncoding, "malformed ACK frame"}
+	}
+	ackDelay := time.Duration(delay<<c.peerParams.ackDelayExponent) * time.Microsecond
+	return c.handleAck(now, space, ranges, largest, ackDelay)
+}
+
+// handleAck handles the acknowledgment of the packets in ranges
+// (RFC 9002, Section 6).
+func (c *Conn) handleAck(now time.Time, space spaceID, ranges []span, largest int64, ackDelay time.Duration) error {
+	sp := &c.spaces[space]
+	if largest >= sp.nextPN {
+		return &localError{errProtocolViolation, "acknowledgment of a packet never sent"}
+	}
+	acked := func(pn int64) bool {
+		for _, r := range ranges {
+			if r.start <= pn && pn < r.end {
+				return true
+			}
+		}
+		return false
+	}
+	var newly []*sentPacket
+	keep := sp.sent[:0]
+	for _, p := range sp.sent {
+		if acked(p.num) {
+			newly = append(newly, p)
+		} else {
+			keep = append(keep, p)
+		}
+	}
+	for i := len(keep); i < len(sp.sent); i++ {
+		sp.sent[i] = nil
+	}
+	sp.sent = keep
+	if len(newly) == 0 {
+		return nil
+	}
+	sp.largestAcked = max(sp.largestAcked, largest)
+	if last := newly[len(newly)-1]; last.num == largest {
+		for _, p := range newly {
+			if p.ackEliciting {
+				if space != appDataSpace {
+					ackDelay = 0
+				}
+				c.rtt.update(now.Sub(last.time), ackDelay, c.peerParams.maxAckDelay, c.handshakeConfirmed)
+				break
+			}
+		}
+	}
+	for _, p := range newly {
+		if p.inFlight {
+			c.cc.onAcked(p)
+		}
+		c.onPacketAcked(space, p)
+	}
+	c.detectLoss(now, space)
+	c.ptoCount = 0
+	return nil
+}
+
+// onPacketAcked acts on the acknowledgment of the frames of a packet.
+func (c *Conn) onPacketAcked(space spaceID, p *sentPacket) {
+	for _, f := range p.frames {
+		switch f.kind {
+		case sentCrypto:
+			c.spaces[space].cryptoSend.ack(f.off, f.end, false)
+		case sentStream:
+			s := f.stream
+			s.send.ack(f.off, f.end, f.fin)
+			s.writen.notify()
+			c.checkStreamDone(s)
+		case sentResetStream:
+			f.stream.resetAcked = true
+			c.checkStreamDone(f.stream)
+		}
+	}
+}
+
+// requeue arranges for the frames of a packet that was, or may have
+// been, lost to be sent again.
+func (c *Conn) requeue(space spaceID, p *sentPacket) {
+	for _, f := range p.frames {
+		s := f.stream
+		switch f.kind {
+		case sentCrypto:
+			c.spaces[space].cryptoSend.lose(f.off, f.end, false)
+		case sentStream:
+			if !s.resetPending && !s.resetSent {
+				s.send.lose(f.off, f.end, f.fin)
+				c.queueStream(s)
+			}
+		case sentResetStream:
+			if !s.resetAcked {
+				s.resetPending = true
+				c.queueStream(s)
+			}
+		case sentStopSending:
+			if !s.recv.eof() && !s.resetRecvd {
+				s.stopPending = true
+				c.queueStream(s)
+			}
+		case sentMaxData:
+			c.maxDataPending = true
+		case sentMaxStreamData:
+			if s.recv.final < 0 && !s.readClosed {
+				s.maxStreamDataPending = true
+				c.queueStream(s)
+			}
+		case sentMaxStreamsBidi:
+			c.maxRemotePending[0] = true
+		case sentMaxStreamsUni:
+			c.maxRemotePending[1] = true
+		case sentHandshakeDone:
+			c.handshakeDonePending = true
+		}
+	}
+}
+
+// detectLoss declares lost the packets of a packet number space sent long
+// enough before an acknowledged packet (RFC 9002, Section 6.1).
+func (c *Conn) detectLoss(now time.Time, space spaceID) {
+	sp := &c.spaces[space]
+	sp.lossTime = time.Time{}
+	if sp.largestAcked < 0 {
+		return
+	}
+	delay := c.rtt.lossDelay()
+	lostSendTime := now.Add(-delay)
+	var lost []*sentPacket
+	keep := sp.sent[:0]
+	for _, p := range sp.sent {
+		switch {
+		case p.num > sp.largestAcked:
+			keep = append(keep, p)
+		case !p.time.After(lostSendTime) || sp.largestAcked >= p.num+packetThreshold:
+			lost = append(lost, p)
+		default:
+			keep = append(keep, p)
+			if t := p.time.Add(delay); sp.lossTime.IsZero() || t.Before(sp.lossTime) {
+				sp.lossTime = t
+			}
+		}
+	}
+	for i := len(keep); i < len(sp.sent); i++ {
+		sp.sent[i] = nil
+	}
+	sp.sent = keep
+	if len(lost) == 0 {
+		return
+	}
+	var lostBytes int
+	var first, last time.Time
+	for _, p := range lost {
+		if p.inFlight {
+			lostBytes += p.size
+			if first.IsZero() {
+				first = p.time
+			}
+			last = p.time
+		}
+		c.requeue(space, p)
+	}
+	// Declare persistent congestion when all the packets sent over a
+	// long enough period have been lost (RFC 9002, Section 7.6).
+	period := (c.rtt.pto() + c.peerParams.maxAckDelay) * persistentCongest
+	persistent := c.rtt.sampled && len(lost) > 1 && last.Sub(first) > period
+	c.cc.onLost(lostBytes, last, now, persistent)
+}
+
+// ptoDeadline returns the time at which the probe timeout expires and its
+// packet number space (RFC 9002, Section 6.2).
+func (c *Conn) ptoDeadline() (time.Time, spaceID, bool) {
+	backoff := time.Duration(1) << min(c.ptoCount, 16)
+	dur := c.rtt.pto() * backoff
+	var t time.Time
+	var space spaceID
+	found := false
+	for id := range c.spaces {
+		sp := &c.spaces[id]
+		if sp.discarded || !hasAckEliciting(sp) {
+			continue
+		}
+		d := dur
+		if spaceID(id) == appDataSpace {
+			if !c.handshakeComplete {
+				continue
+			}
+			d += c.peerParams.maxAckDelay * backoff
+		}
+		if pt := sp.lastAckEliciting.Add(d); !found || pt.Before(t) {
+			t, space, found = pt, spaceID(id), true
+		}
+	}
+	if !found && c.isClient && !c.handshakeComplete {
+		// The client must keep sending until the server has validated
+		// its address, so that the server can send more data.
+		space = initialSpace
+		if c.spaces[handshakeSpace].write != nil {
+			space = handshakeSpace
+		}
+		last := c.spaces[space].lastAckEliciting
+		if last.IsZero() {
+			last = c.lastActivity
+		}
+		return last.Add(dur), space, true
+	}
+	return t, space, found
+}
+
+func hasAckEliciting(sp *pnSpace) bool {
+	for _, p := range sp.sent {
+		if p.ackEliciting && p.inFlight {
+			return true
+		}
+	}
+	return false
+}
+
+// onPTO sends probes when the probe timeout expires.
+func (c *Conn) onPTO(space spaceID) {
+	c.ptoCount++
+	sp := &c.spaces[space]
+	sp.probes = 2
+	// Send the data of the packets in flight again; whatever arrives
+	// first is used.
+	for _, p := range sp.sent {
+		if p.ackEliciting {
+			c.requeue(space, p)
+		}
+	}
+	// The PTO timer is rearmed from the time the probes are sent.
+	sp.lastAckEliciting = time.Now()
+}
+
+// discardSpace discards the keys and state of a packet number space.
+func (c *Conn) discardSpace(space spaceID) {
+	sp := &c.spaces[space]
+	if sp.discarded {
+		return
+	}
+	for _, p := range sp.sent {
+		if p.inFlight {
+			c.cc.onRemoved(p)
+		}
+	}
+	*sp = pnSpace{discarded: true, largestAcked: -1, largestRecv: -1}
+	c.ptoCount = 0
+}
+
+// handleCrypto handles CRYPTO data, and passes it to TLS.
+func (c *Conn) handleCrypto(now time.Time, space spaceID, off int64, data []byte) error {
+	sp := &c.spaces[space]
+	if off+int64(len(data)) > sp.cryptoRecv.base+maxCryptoBuffer {
+		return &localError{errCryptoBufferExceeds, "too much buffered CRYPTO data"}
+	}
+	sp.cryptoRecv.write(off, data)
+	return c.feedTLS(now)
+}
+
+// feedTLS passes the CRYPTO data received in order to TLS, and handles
+// the resulting events. Data of the application level is held until the
+// handshake completes.
+func (c *Conn) feedTLS(now time.Time) error {
+	for id := range c.spaces {
+		sp := &c.spaces[id]
+		if sp.discarded || spaceID(id) == appDataSpace && !c.handshakeComplete {
+			continue
+		}
+		for {
+			d := sp.cryptoRecv.peek()
+			if len(d) == 0 {
+				break
+			}
+			err := c.tls.HandleData(spaceLevels[id], d)
+			sp.cryptoRecv.discard(len(d))
+			if err != nil {
+				return err
+			}
+			if err := c.handleTLSEvents(now); err != nil {
+				return err
+			}
+			if sp.discarded {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// handleTLSEvents handles the events of the TLS handshake.
+func (c *Conn) handleTLSEvents(now time.Time) error {
+	for {
+		e := c.tls.NextEvent()
+		switch e.Kind {
+		case tls.QUICNoEvent:
+			return nil
+		case tls.QUICSetReadSecret, tls.QUICSetWriteSecret:
+			k, err := newKeys(e.Suite, e.Data)
+			if err != nil {
+				return &localError{errInternal, err.Error()}
+			}
+			read := e.Kind == tls.QUICSetReadSecret
+			switch e.Level {
+			case tls.QUICEncryptionLevelEarly:
+				if read {
+					c.earlyRead = k
+				} else {
+					c.start0RTT(k)
+				}
+			case tls.QUICEncryptionLevelHandshake:
+				if read {
+					c.spaces[handshakeSpace].read = k
+				} else {
+					c.spaces[handshakeSpace].write = k
+				}
+			case tls.QUICEncryptionLevelApplication:
+				if read {
+					c.spaces[appDataSpace].read = k
+				} else {
+					c.spaces[appDataSpace].write = k
+				}
+			}
+			if read {
+				c.keysChanged = true
+			}
+		case tls.QUICWriteData:
+			for id, l := range spaceLevels {
+				if l == e.Level {
+					c.spaces[id].cryptoSend.write(e.Data)
+				}
+			}
+		case tls.QUICTransportParameters:
+			if err := c.handlePeerParams(e.Data); err != nil {
+				return err
+			}
+		case tls.QUICTransportParametersRequired:
+			c.tls.SetTransportParameters(c.localParams.marshal())
+		case tls.QUICRejectedEarlyData:
+			c.reject0RTT()
+		case tls.QUICHandshakeDone:
+			if err := c.onHandshakeComplete(now); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// handlePeerParams handles the transport parameters of the peer.
+func (c *Conn) handlePeerParams(b []byte) error {
+	p, err := unmarshalTransportParams(b, c.isClient)
+	if err != nil {
+		return &localError{errTransportParameter, err.Error()}
+	}
+	if !bytes.Equal(p.initialSCID, c.peerSCID) {
+		return &localError{errTransportParameter, "initial_source_connection_id mismatch"}
+	}
+	if c.isClient && (!bytes.Equal(p.origDCID, c.origDCID) || p.hasRetrySourceConnection) {
+		return &localError{errTransportParameter, "original_destination_connection_id mismatch"}
+	}
+	c.setPeerParams(p)
+	if c.isClient {
+		c.ep.storeParams(c.serverName, b)
+	}
+	if p.maxIdleTimeout > 0 && p.maxIdleTimeout < c.idleTimeout {
+		c.idleTimeout = p.maxIdleTimeout
+	}
+	return nil
+}
+
+// setPeerParams applies the limits set by transport parameters of the
+// peer.
+func (c *Conn) setPeerParams(p transportParams) {
+	c.peerParams = p
+	c.maxData = max(c.maxData, p.initialMaxData)
+	c.maxLocal[0] = max(c.maxLocal[0], p.initialMaxStreamsBidi)
+	c.maxLocal[1] = max(c.maxLocal[1], p.initialMaxStreamsUni)
+	for _, s := range c.streams {
+		s.sendMax = max(s.sendMax, c.initialSendMax(s.id))
+	}
+	c.queueBlockedStreams()
+	c.openn.notify()
+}
+
+// start0RTT installs the keys of 0-RTT packets on a client, if the
+// transport parameters of the server are remembered from a previous
+// connection.
+func (c *Conn) start0RTT(k *keys) {
+	b := c.ep.loadParams(c.serverName)
+	if b == nil || !c.cfg.Allow0RTT {
+		return
+	}
+	p, err := unmarshalTransportParams(b, true)
+	if err != nil {
+		return
+	}
+	// The connection IDs of the previous connection do not apply.
+	p.origDCID, p.initialSCID = nil, nil
+	c.setPeerParams(p)
+	c.earlyWrite = k
+	close(c.earlyc)
+}
+
+// reject0RTT handles the rejection of 0-RTT data by the server: the
+// data is sent again in 1-RTT packets.
+func (c *Conn) reject0RTT() {
+	c.earlyWrite = nil
+	sp := &c.spaces[appDataSpace]
+	keep := sp.sent[:0]
+	for _, p := range sp.sent {
+		if !p.is0RTT {
+			keep = append(keep, p)
+			continue
+		}
+		if p.inFlight {
+			c.cc.onRemoved(p)
+		}
+		c.requeue(appDataSpace, p)
+	}
+	sp.sent = keep
+}
+
+// onHandshakeComplete handles the completion of the handshake.
+func (c *Conn) onHandshakeComplete(now time.Time) error {
+	c.handshakeComplete = true
+	c.tlsState = c.tls.ConnectionState()
+	c.earlyWrite = nil
+	if !c.isClient {
+		// The server confirms the handshake when it completes
+		// (RFC 9001, Section 4.1.2).
+		c.handshakeConfirmed = true
+		c.handshakeDonePending = true
+		c.discardSpace(handshakeSpace)
+		c.earlyRead = nil
+		if !c.ep.serverTLS.SessionTicketsDisabled {
+			// Failing to send a session ticket only disables
+			// resumption.
+			c.tls.SendSessionTicket(tls.QUICSessionTicketOptions{EarlyData: c.cfg.Allow0RTT})
+		}
+	}
+	close(c.handshakeDonec)
+	if !c.isClient {
+		c.ep.queueAccept(c)
+	}
+	return c.feedTLS(now)
+}
@@ -0,0 +1,417 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quic
+
+import "time"
+
+// A pendingPacket is a packet being assembled into a datagram.
+type pendingPacket struct {
+	typ     packetType
+	space   spaceID
+	k       *keys
+	payload []byte
+	sent    *sentPacket
+}
+
+// buildDatagram returns the next datagram to send, or nil if there is
+// nothing to send.
+func (c *Conn) buildDatagram(now time.Time) []byte {
+	if c.finished || c.draining {
+		return nil
+	}
+	if c.closing {
+		if !c.closeSendPending {
+			return nil
+		}
+		c.closeSendPending = false
+		return c.buildCloseDatagram()
+	}
+	budget := maxDatagramSize
+	if !c.addrValidated {
+		// Anti-amplification limit (RFC 9000, Section 8.1).
+		budget = min(budget, 3*c.bytesRecvd-c.bytesSent)
+		if budget < 64 {
+			return nil
+		}
+	}
+
+	var pkts []pendingPacket
+	used := 0
+	pad := false
+	for _, space := range []spaceID{initialSpace, handshakeSpace, appDataSpace} {
+		sp := &c.spaces[space]
+		if sp.discarded {
+			continue
+		}
+		typ, k := packetType(space*2), sp.write // Initial or Handshake
+		if space == appDataSpace {
+			typ = packetType1RTT
+			if k == nil {
+				typ, k = packetType0RTT, c.earlyWrite
+			}
+		}
+		if k == nil {
+			continue
+		}
+		hdrSize := 1 + len(c.remoteCID) + pnLen
+		if typ != packetType1RTT {
+			hdrSize = longHeaderSize(typ, c.remoteCID, c.localCID)
+		}
+		avail := budget - used - hdrSize - aeadTagSize
+		if avail < 32 {
+			break
+		}
+		payload, sent := c.appendFrames(now, typ, space, avail)
+		if len(payload) == 0 {
+			continue
+		}
+		pkts = append(pkts, pendingPacket{typ: typ, space: space, k: k, payload: payload, sent: sent})
+		used += hdrSize + len(payload) + aeadTagSize
+		if typ == packetTypeInitial && (c.isClient || sent.ackEliciting) {
+			pad = true
+		}
+	}
+	if len(pkts) == 0 {
+		return nil
+	}
+	// Datagrams carrying Initial packets are padded, to prevent
+	// amplification attacks and to validate the path MTU
+	// (RFC 9000, Section 14.1).
+	if size := min(maxDatagramSize, budget); pad && used < size {
+		last := &pkts[len(pkts)-1]
+		last.payload = append(last.payload, make([]byte, size-used)...)
+		last.sent.inFlight = true
+		used = size
+	}
+
+	out := make([]byte, 0, used)
+	sentHandshake := false
+	for _, p := range pkts {
+		sp := &c.spaces[p.space]
+		pn := sp.nextPN
+		sp.nextPN++
+		pkt := make([]byte, 0, hdrSizeOf(p, c)+len(p.payload)+aeadTagSize)
+		var pnOff int
+		if p.typ == packetType1RTT {
+			pkt, pnOff = appendShortHeader(pkt, c.remoteCID, c.keyPhase, pn)
+		} else {
+			pkt, pnOff = appendLongHeader(pkt, p.typ, c.remoteCID, c.localCID, pn, len(p.payload))
+		}
+		pkt = append(pkt, p.payload...)
+		pkt = p.k.protect(pkt, pnOff, pnLen, pn)
+		out = append(out, pkt...)
+
+		s := p.sent
+		s.num = pn
+		s.time = now
+		s.size = len(pkt)
+		s.is0RTT = p.typ == packetType0RTT
+		if s.ackEliciting {
+			sp.lastAckEliciting = now
+			c.lastActivity = now
+		}
+		if s.inFlight {
+			c.cc.onSent(s.size)
+		}
+		if s.inFlight || len(s.frames) > 0 {
+			sp.sent = append(sp.sent, s)
+		}
+		if c.isClient && p.typ == packetTypeHandshake {
+			sentHandshake = true
+		}
+	}
+	c.bytesSent += len(out)
+	if sentHandshake {
+		// A client stops sending Initial packets once it sends
+		// Handshake packets (RFC 9001, Section 4.9.1).
+		c.discardSpace(initialSpace)
+	}
+	return out
+}
+
+func hdrSizeOf(p pendingPacket, c *Conn) int {
+	if p.typ == packetType1RTT {
+		return 1 + len(c.remoteCID) + pnLen
+	}
+	return longHeaderSize(p.typ, c.remoteCID, c.localCID)
+}
+
+// appendFrames returns the payload of a packet of type typ of at most
+// avail bytes, and the record of the frames it holds.
+func (c *Conn) appendFrames(now time.Time, typ packetType, space spaceID, avail int) ([]byte, *sentPacket) {
+	sp := &c.spaces[space]
+	p := &sentPacket{}
+
+	var ack []byte
+	if typ != packetType0RTT && sp.ackNeeded && len(sp.recvd) > 0 {
+		var delay int64
+		if space == appDataSpace {
+			delay = now.Sub(sp.largestRecvTime).Microseconds() >> c.localParams.ackDelayExponent
+		}
+		ack = appendAckFrame(nil, sp.recvd, delay)
+		if len(ack) > avail {
+			ack = nil
+		}
+	}
+	b := ack
+	room := func() int { return avail - len(b) }
+
+	if c.cc.canSend() || sp.probes > 0 {
+		if typ == packetType1RTT {
+			b = c.appendControlFrames(b, p, avail)
+		}
+		if typ != packetType0RTT {
+			for room() > 16 && sp.cryptoSend.hasData(1<<62) {
+				off, data, _ := sp.cryptoSend.nextRange(room()-1-8-4, 1<<62)
+				if len(data) == 0 {
+					continue
+				}
+				b = append(b, frameTypeCrypto)
+				b = AppendVarint(b, uint64(off))
+				b = AppendVarint(b, uint64(len(data)))
+				b = append(b, data...)
+				p.frames = append(p.frames, sentFrame{kind: sentCrypto, off: off, end: off + int64(len(data))})
+				p.ackEliciting = true
+			}
+		}
+		if space == appDataSpace {
+			b = c.appendStreamFrames(b, p, avail)
+		}
+		if (sp.probes > 0 || c.pingPending && typ == packetType1RTT) && !p.ackEliciting && room() > 0 {
+			b = append(b, frameTypePing)
+			p.ackEliciting = true
+		}
+		if p.ackEliciting {
+			if sp.probes > 0 {
+				sp.probes--
+			}
+			if typ == packetType1RTT {
+				c.pingPending = false
+			}
+		}
+	}
+
+	if len(b) == len(ack) && (len(ack) == 0 || sp.ackTime.IsZero() || sp.ackTime.After(now)) {
+		// Only send an ACK on its own when it is due.
+		return nil, nil
+	}
+	if len(ack) > 0 {
+		sp.ackNeeded = false
+		sp.ackTime = time.Time{}
+		sp.unackedEliciting = 0
+	}
+	p.inFlight = p.ackEliciting
+	return b, p
+}
+
+// appendAckFrame appends an ACK frame acknowledging the packet numbers in
+// recvd.
+func appendAckFrame(b []byte, recvd rangeset, delay int64) []byte {
+	last := recvd[len(recvd)-1]
+	b = append(b, frameTypeAck)
+	b = AppendVarint(b, uint64(last.end-1))
+	b = AppendVarint(b, uint64(delay))
+	b = AppendVarint(b, uint64(len(recvd)-1))
+	b = AppendVarint(b, uint64(last.end-1-last.start))
+	prev := last.start
+	for i := len(recvd) - 2; i >= 0; i-- {
+		r := recvd[i]
+		b = AppendVarint(b, uint64(prev-r.end-1))
+		b = AppendVarint(b, uint64(r.end-1-r.start))
+		prev = r.start
+	}
+	return b
+}
+
+// maxControlFrameSize is the maximum size of the control frames sent.
+const maxControlFrameSize = 1 + 3*8
+
+// appendControlFrames appends the connection-level control frames that
+// must be sent.
+func (c *Conn) appendControlFrames(b []byte, p *sentPacket, avail int) []byte {
+	if c.handshakeDonePending && len(b)+1 <= avail {
+		c.handshakeDonePending = false
+		b = append(b, frameTypeHandshakeDone)
+		p.frames = append(p.frames, sentFrame{kind: sentHandshakeDone})
+		p.ackEliciting = true
+	}
+	for len(c.pathResponses) > 0 && len(b)+9 <= avail {
+		b = append(b, frameTypePathResponse)
+		b = append(b, c.pathResponses[0]...)
+		c.pathResponses = c.pathResponses[1:]
+		p.ackEliciting = true
+	}
+	if c.maxDataPending && len(b)+maxControlFrameSize <= avail {
+		c.maxDataPending = false
+		b = append(b, frameTypeMaxData)
+		b = AppendVarint(b, uint64(c.recvMaxData))
+		p.frames = append(p.frames, sentFrame{kind: sentMaxData})
+		p.ackEliciting = true
+	}
+	for i, kind := range []sentFrameKind{sentMaxStreamsBidi, sentMaxStreamsUni} {
+		if c.maxRemotePending[i] && len(b)+maxControlFrameSize <= avail {
+			c.maxRemotePending[i] = false
+			b = append(b, byte(frameTypeMaxStreamsBidi+i))
+			b = AppendVarint(b, uint64(c.maxRemote[i]))
+			p.frames = append(p.frames, sentFrame{kind: kind})
+			p.ackEliciting = true
+		}
+	}
+	return b
+}
+
+// appendStreamFrames appends the frames of the streams that have
+// something to send, in turn.
+func (c *Conn) appendStreamFrames(b []byte, p *sentPacket, avail int) []byte {
+	n := 0
+	for len(c.sendQueue) > n && avail-len(b) > maxControlFrameSize {
+		s := c.sendQueue[n]
+		b = c.appendStreamFramesOf(b, p, s, avail)
+		if s.needsSend(c) {
+			n++
+		} else {
+			s.queued = false
+			copy(c.sendQueue[n:], c.sendQueue[n+1:])
+			c.sendQueue[len(c.sendQueue)-1] = nil
+			c.sendQueue = c.sendQueue[:len(c.sendQueue)-1]
+		}
+	}
+	// Move the streams that were served to the back of the queue.
+	if n > 0 && n < len(c.sendQueue) {
+		served := append([]*Stream(nil), c.sendQueue[:n]...)
+		copy(c.sendQueue, c.sendQueue[n:])
+		copy(c.sendQueue[len(c.sendQueue)-n:], served)
+	}
+	return b
+}
+
+// appendStreamFramesOf appends the frames of the stream s.
+func (c *Conn) appendStreamFramesOf(b []byte, p *sentPacket, s *Stream, avail int) []byte {
+	if s.resetPending && len(b)+maxControlFrameSize <= avail {
+		s.resetPending = false
+		s.resetSent = true
+		b = append(b, frameTypeResetStream)
+		b = AppendVarint(b, uint64(s.id))
+		b = AppendVarint(b, s.resetCode)
+		b = AppendVarint(b, uint64(s.send.next))
+		p.frames = append(p.frames, sentFrame{kind: sentResetStream, stream: s})
+		p.ackEliciting = true
+	}
+	if s.stopPending && len(b)+maxControlFrameSize <= avail {
+		s.stopPending = false
+		b = append(b, frameTypeStopSending)
+		b = AppendVarint(b, uint64(s.id))
+		b = AppendVarint(b, s.stopCode)
+		p.frames = append(p.frames, sentFrame{kind: sentStopSending, stream: s})
+		p.ackEliciting = true
+	}
+	if s.maxStreamDataPending && len(b)+maxControlFrameSize <= avail {
+		s.maxStreamDataPending = false
+		b = append(b, frameTypeMaxStreamData)
+		b = AppendVarint(b, uint64(s.id))
+		b = AppendVarint(b, uint64(s.recvMax))
+		p.frames = append(p.frames, sentFrame{kind: sentMaxStreamData, stream: s})
+		p.ackEliciting = true
+	}
+	for !s.resetSent && !s.resetPending {
+		limit := s.sendLimit(c)
+		if !s.send.hasData(limit) {
+			break
+		}
+		// Frame type, stream ID, offset and a two-byte length.
+		hdr := 1 + SizeVarint(uint64(s.id)) + 8 + 2
+		room := avail - len(b) - hdr
+		if room < 1 {
+			break
+		}
+		before := s.send.next
+		off, data, fin := s.send.nextRange(room, limit)
+		c.dataSent += s.send.next - before
+		if len(data) == 0 && !fin {
+			continue
+		}
+		ft := byte(frameTypeStreamBase | 0x02)
+		if off > 0 {
+			ft |= 0x04
+		}
+		if fin {
+			ft |= 0x01
+		}
+		b = append(b, ft)
+		b = AppendVarint(b, uint64(s.id))
+		if off > 0 {
+			b = AppendVarint(b, uint64(off))
+		}
+		b = AppendVarint(b, uint64(len(data)))
+		b = append(b, data...)
+		p.frames = append(p.frames, sentFrame{kind: sentStream, stream: s, off: off, end: off + int64(len(data)), fin: fin})
+		p.ackEliciting = true
+	}
+	return b
+}
+
+// buildCloseDatagram returns a datagram holding CONNECTION_CLOSE frames at
+// all the encryption levels that may be in use by the peer.
+func (c *Conn) buildCloseDatagram() []byte {
+	var pkts []pendingPacket
+	used := 0
+	pad := false
+	for _, space := range []spaceID{initialSpace, handshakeSpace, appDataSpace} {
+		sp := &c.spaces[space]
+		if sp.discarded || sp.write == nil {
+			continue
+		}
+		typ := packetType(space * 2)
+		if space == appDataSpace {
+			typ = packetType1RTT
+		}
+		var b []byte
+		if c.closeApp && typ == packetType1RTT {
+			b = append(b, frameTypeConnectionCloseApp)
+			b = AppendVarint(b, c.closeCode)
+			b = AppendVarint(b, uint64(len(c.closeReason)))
+			b = append(b, c.closeReason...)
+		} else {
+			// Application errors are not revealed before the
+			// handshake completes (RFC 9000, Section 10.2.3).
+			code, reason := c.closeCode, c.closeReason
+			if c.closeApp {
+				code, reason = uint64(errApplication), ""
+			}
+			b = append(b, frameTypeConnectionClose)
+			b = AppendVarint(b, code)
+			b = append(b, 0)
+			b = AppendVarint(b, uint64(len(reason)))
+			b = append(b, reason...)
+		}
+		p := pendingPacket{typ: typ, space: space, k: sp.write, payload: b}
+		pkts = append(pkts, p)
+		used += hdrSizeOf(p, c) + len(b) + aeadTagSize
+		pad = pad || typ == packetTypeInitial && c.isClient
+	}
+	if len(pkts) == 0 {
+		return nil
+	}
+	if pad && used < maxDatagramSize {
+		last := &pkts[len(pkts)-1]
+		last.payload = append(last.payload, make([]byte, maxDatagramSize-used)...)
+	}
+	var out []byte
+	for _, p := range pkts {
+		sp := &c.spaces[p.space]
+		pn := sp.nextPN
+		sp.nextPN++
+		var pkt []byte
+		var pnOff int
+		if p.typ == packetType1RTT {
+			pkt, pnOff = appendShortHeader(nil, c.remoteCID, c.keyPhase, pn)
+		} else {
+			pkt, pnOff = appendLongHeader(nil, p.typ, c.remoteCID, c.localCID, pn, len(p.payload))
+		}
+		pkt = append(pkt, p.payload...)
+		out = append(out, p.k.protect(pkt, pnOff, pnLen, pn)...)
+	}
+	return out
+}
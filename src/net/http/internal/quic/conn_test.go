@@ -0,0 +1,550 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quic
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"net/http/internal/testcert"
+	"sync"
+	"testing"
+	"time"
+)
+
+func testServerTLSConfig(t *testing.T) *tls.Config {
+	cert, err := tls.X509KeyPair(testcert.LocalhostCert, testcert.LocalhostKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		NextProtos:   []string{"test"},
+	}
+}
+
+func testClientTLSConfig() *tls.Config {
+	return &tls.Config{
+		InsecureSkipVerify: true,
+		NextProtos:         []string{"test"},
+	}
+}
+
+// A lossyConn is a PacketConn that drops some of the datagrams it writes.
+type lossyConn struct {
+	net.PacketConn
+
+	mu    sync.Mutex
+	n     int
+	drop  func(n int, b []byte) bool
+	sent  [][]byte
+	saved bool
+}
+
+func (c *lossyConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	c.mu.Lock()
+	c.n++
+	drop := c.drop != nil && c.drop(c.n, b)
+	if c.saved {
+		c.sent = append(c.sent, bytes.Clone(b))
+	}
+	c.mu.Unlock()
+	if drop {
+		return len(b), nil
+	}
+	return c.PacketConn.WriteTo(b, addr)
+}
+
+func (c *lossyConn) setDrop(drop func(n int, b []byte) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.drop = drop
+}
+
+func listenPacket(t *testing.T) net.PacketConn {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen on loopback: %v", err)
+	}
+	return pc
+}
+
+type testPair struct {
+	server, client *Endpoint
+	serverPC       *lossyConn
+	clientPC       *lossyConn
+}
+
+func newTestPair(t *testing.T, serverCfg *Config) *testPair {
+	if serverCfg == nil {
+		serverCfg = &Config{}
+	}
+	if serverCfg.TLSConfig == nil {
+		serverCfg.TLSConfig = testServerTLSConfig(t)
+	}
+	p := &testPair{
+		serverPC: &lossyConn{PacketConn: listenPacket(t)},
+		clientPC: &lossyConn{PacketConn: listenPacket(t)},
+	}
+	p.server = NewEndpoint(p.serverPC, serverCfg)
+	p.client = NewEndpoint(p.clientPC, nil)
+	t.Cleanup(func() {
+		p.client.Close()
+		p.server.Close()
+	})
+	return p
+}
+
+// dial connects the client to the server, and returns both ends.
+func (p *testPair) dial(t *testing.T, cfg *Config) (client, server *Conn) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if cfg == nil {
+		cfg = &Config{TLSConfig: testClientTLSConfig()}
+	}
+	client, err := p.client.Dial(ctx, "udp", p.server.LocalAddr().String(), cfg)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	server, err = p.server.Accept(ctx)
+	if err != nil {
+		t.Fatalf("Accept: %v", err)
+	}
+	return client, server
+}
+
+// echo serves the bidirectional streams of c, by writing back what they
+// read.
+func echo(c *Conn) {
+	for {
+		s, err := c.AcceptStream(context.Background())
+		if err != nil {
+			return
+		}
+		go func() {
+			io.Copy(s, s)
+			s.Close()
+		}()
+	}
+}
+
+// roundTrip writes data on a new stream of c, and returns what it reads
+// back.
+func roundTrip(t *testing.T, c *Conn, data []byte) []byte {
+	t.Helper()
+	s, err := c.OpenStream(context.Background())
+	if err != nil {
+		t.Fatalf("OpenStream: %v", err)
+	}
+	errc := make(chan error, 1)
+	go func() {
+		_, err := s.Write(data)
+		if err == nil {
+			err = s.Close()
+		}
+		errc <- err
+	}()
+	got, err := io.ReadAll(s)
+	if err != nil {
+		t.Fatalf("reading stream: %v", err)
+	}
+	if err := <-errc; err != nil {
+		t.Fatalf("writing stream: %v", err)
+	}
+	return got
+}
+
+func testData(n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(i * 7 / 3)
+	}
+	return b
+}
+
+func TestHandshake(t *testing.T) {
+	p := newTestPair(t, nil)
+	client, server := p.dial(t, nil)
+	select {
+	case <-client.HandshakeComplete():
+	case <-time.After(10 * time.Second):
+		t.Fatal("client handshake did not complete")
+	}
+	cs := client.ConnectionState()
+	if cs.Version != tls.VersionTLS13 || cs.NegotiatedProtocol != "test" {
+		t.Errorf("client ConnectionState: version %x, protocol %q", cs.Version, cs.NegotiatedProtocol)
+	}
+	if !server.ConnectionState().HandshakeComplete {
+		t.Errorf("accepted connection has not completed its handshake")
+	}
+	if server.RemoteAddr().String() != client.LocalAddr().String() {
+		t.Errorf("server RemoteAddr = %v, want %v", server.RemoteAddr(), client.LocalAddr())
+	}
+}
+
+func TestStreams(t *testing.T) {
+	p := newTestPair(t, nil)
+	client, server := p.dial(t, nil)
+	go echo(server)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			data := testData(1000 * (i + 1))
+			if got := roundTrip(t, client, data); !bytes.Equal(got, data) {
+				t.Errorf("stream %d: read %d bytes, want %d matching ones", i, len(got), len(data))
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestUniStreams(t *testing.T) {
+	p := newTestPair(t, nil)
+	client, server := p.dial(t, nil)
+	data := testData(5000)
+	go func() {
+		s, err := client.OpenUniStream(context.Background())
+		if err != nil {
+			t.Errorf("OpenUniStream: %v", err)
+			return
+		}
+		s.Write(data)
+		s.Close()
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s, err := server.AcceptUniStream(ctx)
+	if err != nil {
+		t.Fatalf("AcceptUniStream: %v", err)
+	}
+	if !s.IsUnidirectional() || s.ID() != 2 {
+		t.Errorf("stream ID = %d, unidirectional = %v, want 2, true", s.ID(), s.IsUnidirectional())
+	}
+	got, err := io.ReadAll(s)
+	if err != nil || !bytes.Equal(got, data) {
+		t.Errorf("read %d bytes, %v, want %d", len(got), err, len(data))
+	}
+}
+
+func TestFlowControl(t *testing.T) {
+	p := newTestPair(t, &Config{
+		MaxStreamReadBufferSize: 4 << 10,
+		MaxConnReadBufferSize:   16 << 10,
+	})
+	client, server := p.dial(t, &Config{
+		TLSConfig:               testClientTLSConfig(),
+		MaxStreamReadBufferSize: 8 << 10,
+		MaxConnReadBufferSize:   8 << 10,
+	})
+	go echo(server)
+	data := testData(1 << 20)
+	if got := roundTrip(t, client, data); !bytes.Equal(got, data) {
+		t.Errorf("read %d bytes, want %d matching ones", len(got), len(data))
+	}
+}
+
+func TestStreamLimit(t *testing.T) {
+	p := newTestPair(t, &Config{MaxBidiRemoteStreams: 2})
+	client, server := p.dial(t, nil)
+	go echo(server)
+	for i := 0; i < 10; i++ {
+		if got := roundTrip(t, client, []byte("ping")); string(got) != "ping" {
+			t.Fatalf("stream %d: read %q, want ping", i, got)
+		}
+	}
+}
+
+func TestLossRecovery(t *testing.T) {
+	p := newTestPair(t, nil)
+	p.serverPC.setDrop(func(n int, b []byte) bool { return n%5 == 0 })
+	p.clientPC.setDrop(func(n int, b []byte) bool { return n%7 == 0 })
+	client, server := p.dial(t, nil)
+	go echo(server)
+	data := testData(256 << 10)
+	if got := roundTrip(t, client, data); !bytes.Equal(got, data) {
+		t.Errorf("read %d bytes, want %d matching ones", len(got), len(data))
+	}
+}
+
+func TestLostHandshake(t *testing.T) {
+	p := newTestPair(t, nil)
+	// Drop the first flights of both ends.
+	p.serverPC.setDrop(func(n int, b []byte) bool { return n == 1 })
+	p.clientPC.setDrop(func(n int, b []byte) bool { return n == 1 })
+	client, server := p.dial(t, nil)
+	go echo(server)
+	if got := roundTrip(t, client, []byte("hello")); string(got) != "hello" {
+		t.Errorf("read %q, want hello", got)
+	}
+}
+
+func TestStreamReset(t *testing.T) {
+	p := newTestPair(t, nil)
+	client, server := p.dial(t, nil)
+	s, err := client.OpenStream(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Write([]byte("hello"))
+	ss, err := server.AcceptStream(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 5)
+	if _, err := io.ReadFull(ss, buf); err != nil {
+		t.Fatal(err)
+	}
+	ss.Reset(42)
+
+	select {
+	case <-s.Aborted():
+	case <-time.After(10 * time.Second):
+		t.Fatal("reset stream is not aborted")
+	}
+	_, err = s.Read(buf)
+	var se *StreamError
+	if !errors.As(err, &se) || se.Code != 42 || !se.Remote {
+		t.Errorf("Read after reset = %v, want StreamError with code 42", err)
+	}
+
+	// The peer stops sending on the stream after a STOP_SENDING.
+	s, err = client.OpenStream(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Write([]byte("hello"))
+	ss, err = server.AcceptStream(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ss.CloseRead(7)
+	for {
+		_, err = s.Write([]byte("data"))
+		if err != nil {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if !errors.As(err, &se) || se.Code != 7 || !se.Remote {
+		t.Errorf("Write after STOP_SENDING = %v, want StreamError with code 7", err)
+	}
+}
+
+func TestCloseWithError(t *testing.T) {
+	p := newTestPair(t, nil)
+	client, server := p.dial(t, nil)
+	client.CloseWithError(7, "bye")
+	_, err := server.AcceptStream(context.Background())
+	var ae *ApplicationError
+	if !errors.As(err, &ae) || ae.Code != 7 || ae.Reason != "bye" || !ae.Remote {
+		t.Errorf("AcceptStream after close = %v, want ApplicationError 7", err)
+	}
+	select {
+	case <-client.Done():
+	case <-time.After(10 * time.Second):
+		t.Fatal("closed connection is not done")
+	}
+	if _, err := client.OpenStream(context.Background()); !errors.As(err, &ae) || ae.Remote {
+		t.Errorf("OpenStream after close = %v, want local ApplicationError", err)
+	}
+}
+
+func TestCloseAfterWrites(t *testing.T) {
+	p := newTestPair(t, nil)
+	// Lose some of the data, but not the CONNECTION_CLOSE sent after it.
+	p.clientPC.setDrop(func(n int, b []byte) bool { return n%5 == 0 && n < 100 })
+	client, server := p.dial(t, nil)
+	s, err := client.OpenStream(context.Background())
+	if err != nil {
+		t.Fatalf("OpenStream: %v", err)
+	}
+	data := testData(256 << 10)
+	if _, err := s.Write(data); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	s.Close()
+	client.CloseAfterWrites(7, "bye")
+
+	ss, err := server.AcceptStream(context.Background())
+	if err != nil {
+		t.Fatalf("AcceptStream: %v", err)
+	}
+	if got, err := io.ReadAll(ss); err != nil || !bytes.Equal(got, data) {
+		t.Errorf("read %d bytes, %v; want %d matching ones", len(got), err, len(data))
+	}
+	_, err = server.AcceptStream(context.Background())
+	var ae *ApplicationError
+	if !errors.As(err, &ae) || ae.Code != 7 || !ae.Remote {
+		t.Errorf("AcceptStream after close = %v, want ApplicationError 7", err)
+	}
+}
+
+func TestIdleTimeout(t *testing.T) {
+	p := newTestPair(t, &Config{MaxIdleTimeout: 100 * time.Millisecond})
+	client, server := p.dial(t, nil)
+	// Stop the server from acknowledging anything.
+	p.serverPC.setDrop(func(int, []byte) bool { return true })
+	select {
+	case <-client.Done():
+	case <-time.After(10 * time.Second):
+		t.Fatal("idle connection did not time out")
+	}
+	if err := client.Err(); !errors.Is(err, ErrIdleTimeout) {
+		t.Errorf("Err() = %v, want ErrIdleTimeout", err)
+	}
+	<-server.Done()
+}
+
+func TestKeepAlive(t *testing.T) {
+	p := newTestPair(t, &Config{MaxIdleTimeout: 200 * time.Millisecond})
+	client, server := p.dial(t, &Config{
+		TLSConfig:       testClientTLSConfig(),
+		KeepAlivePeriod: 50 * time.Millisecond,
+	})
+	go echo(server)
+	time.Sleep(500 * time.Millisecond)
+	if got := roundTrip(t, client, []byte("hello")); string(got) != "hello" {
+		t.Errorf("read %q, want hello", got)
+	}
+}
+
+func TestSetKeepAlivePeriod(t *testing.T) {
+	p := newTestPair(t, &Config{MaxIdleTimeout: 200 * time.Millisecond})
+	client, server := p.dial(t, nil)
+	go echo(server)
+	client.SetKeepAlivePeriod(50 * time.Millisecond)
+	time.Sleep(500 * time.Millisecond)
+	if err := client.Err(); err != nil {
+		t.Fatalf("connection with keep-alives closed: %v", err)
+	}
+	client.SetKeepAlivePeriod(0)
+	select {
+	case <-client.Done():
+	case <-time.After(10 * time.Second):
+		t.Fatal("connection without keep-alives did not time out")
+	}
+}
+
+func TestEndpointClose(t *testing.T) {
+	p := newTestPair(t, nil)
+	client, server := p.dial(t, nil)
+	p.server.Close()
+	if _, err := p.server.Accept(context.Background()); err != ErrEndpointClosed {
+		t.Errorf("Accept after Close = %v, want ErrEndpointClosed", err)
+	}
+	<-server.Done()
+	select {
+	case <-client.Done():
+	case <-time.After(10 * time.Second):
+		t.Fatal("client was not closed with the server endpoint")
+	}
+}
+
+func TestStopAccepting(t *testing.T) {
+	p := newTestPair(t, nil)
+	client, server := p.dial(t, nil)
+	go echo(server)
+	p.server.StopAccepting()
+	if _, err := p.server.Accept(context.Background()); err != ErrEndpointClosed {
+		t.Errorf("Accept after StopAccepting = %v, want ErrEndpointClosed", err)
+	}
+	if got := roundTrip(t, client, []byte("hello")); string(got) != "hello" {
+		t.Errorf("existing connection: read %q, want hello", got)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if _, err := p.client.Dial(ctx, "udp", p.server.LocalAddr().String(), &Config{TLSConfig: testClientTLSConfig()}); err == nil {
+		t.Errorf("Dial succeeded after StopAccepting")
+	}
+}
+
+// count0RTT returns the number of 0-RTT packets in datagrams.
+func count0RTT(datagrams [][]byte) int {
+	n := 0
+	for _, b := range datagrams {
+		for len(b) > 0 && isLongHeader(b) {
+			h, ok := parseHeader(b)
+			if !ok {
+				break
+			}
+			if h.typ == packetType0RTT {
+				n++
+			}
+			b = b[h.size:]
+		}
+	}
+	return n
+}
+
+func test0RTT(t *testing.T, accept bool) {
+	serverTLS := testServerTLSConfig(t)
+	p := newTestPair(t, &Config{TLSConfig: serverTLS, Allow0RTT: true})
+	clientCfg := &Config{TLSConfig: testClientTLSConfig(), Allow0RTT: true}
+	clientCfg.TLSConfig.ClientSessionCache = tls.NewLRUClientSessionCache(1)
+
+	client, server := p.dial(t, clientCfg)
+	go echo(server)
+	if got := roundTrip(t, client, []byte("hello")); string(got) != "hello" {
+		t.Fatalf("read %q, want hello", got)
+	}
+	client.Close()
+
+	if !accept {
+		// A server using the same session ticket keys, but not
+		// allowing 0-RTT.
+		serverTLS = serverTLS.Clone()
+		serverTLS.SessionTicketKey = p.server.serverTLS.SessionTicketKey
+		p2 := newTestPair(t, &Config{TLSConfig: serverTLS})
+		p2.client, p2.clientPC = p.client, p.clientPC
+		p = p2
+	}
+	p.clientPC.mu.Lock()
+	p.clientPC.saved = true
+	p.clientPC.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, err := p.client.Dial(ctx, "udp", p.server.LocalAddr().String(), clientCfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		server, err := p.server.Accept(ctx)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		echo(server)
+	}()
+	data := []byte("early data")
+	if got := roundTrip(t, client, data); !bytes.Equal(got, data) {
+		t.Fatalf("read %q, want %q", got, data)
+	}
+	if !client.ConnectionState().DidResume {
+		t.Errorf("client did not resume the session")
+	}
+
+	p.clientPC.mu.Lock()
+	n := count0RTT(p.clientPC.sent)
+	p.clientPC.mu.Unlock()
+	if n == 0 {
+		t.Errorf("client sent no 0-RTT packets")
+	}
+}
+
+func Test0RTT(t *testing.T) {
+	test0RTT(t, true)
+}
+
+func Test0RTTRejected(t *testing.T) {
+	test0RTT(t, false)
+}
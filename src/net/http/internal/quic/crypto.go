@@ -0,0 +1,197 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quic
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"hash"
+
+	"golang.org/x/crypto/chacha20"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+)
+
+// initialSalt is the salt used to derive the Initial secrets of QUIC
+// version 1 (RFC 9001, Section 5.2).
+var initialSalt = []byte{
+	0x38, 0x76, 0x2c, 0xf7, 0xf5, 0x59, 0x34, 0xb3, 0x4d, 0x17,
+	0x9a, 0xe6, 0xa4, 0xc8, 0x0c, 0xad, 0xcc, 0xbb, 0x7f, 0x0a,
+}
+
+// aeadTagSize is the size of the authentication tag of all the AEADs
+// used by QUIC.
+const aeadTagSize = 16
+
+// A keys holds the packet protection keys of one direction of an
+// encryption level.
+type keys struct {
+	suite  uint16
+	secret []byte
+	aead   cipher.AEAD
+	iv     []byte
+	hp     func(sample []byte) [5]byte // header protection mask
+}
+
+// hkdfExpandLabel implements HKDF-Expand-Label from RFC 8446, Section 7.1,
+// with an empty context.
+func hkdfExpandLabel(h func() hash.Hash, secret []byte, label string, length int) []byte {
+	label = "tls13 " + label
+	info := make([]byte, 0, 4+len(label))
+	info = append(info, byte(length>>8), byte(length), byte(len(label)))
+	info = append(info, label...)
+	info = append(info, 0)
+	out := make([]byte, length)
+	if _, err := hkdf.Expand(h, secret, info).Read(out); err != nil {
+		panic("quic: HKDF-Expand-Label failed: " + err.Error())
+	}
+	return out
+}
+
+func suiteHash(suite uint16) func() hash.Hash {
+	if suite == tls.TLS_AES_256_GCM_SHA384 {
+		return sha512.New384
+	}
+	return sha256.New
+}
+
+// newKeys derives the packet protection keys of a TLS cipher suite from
+// a traffic secret (RFC 9001, Section 5.1).
+func newKeys(suite uint16, secret []byte) (*keys, error) {
+	k := &keys{suite: suite, secret: append([]byte(nil), secret...)}
+	h := suiteHash(suite)
+	keyLen := 32
+	if suite == tls.TLS_AES_128_GCM_SHA256 {
+		keyLen = 16
+	}
+	key := hkdfExpandLabel(h, secret, "quic key", keyLen)
+	k.iv = hkdfExpandLabel(h, secret, "quic iv", 12)
+	hpKey := hkdfExpandLabel(h, secret, "quic hp", keyLen)
+	switch suite {
+	case tls.TLS_AES_128_GCM_SHA256, tls.TLS_AES_256_GCM_SHA384:
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		if k.aead, err = cipher.NewGCM(block); err != nil {
+			return nil, err
+		}
+		hpBlock, err := aes.NewCipher(hpKey)
+		if err != nil {
+			return nil, err
+		}
+		k.hp = func(sample []byte) (mask [5]byte) {
+			var out [aes.BlockSize]byte
+			hpBlock.Encrypt(out[:], sample)
+			copy(mask[:], out[:])
+			return mask
+		}
+	case tls.TLS_CHACHA20_POLY1305_SHA256:
+		var err error
+		if k.aead, err = chacha20poly1305.New(key); err != nil {
+			return nil, err
+		}
+		k.hp = func(sample []byte) (mask [5]byte) {
+			c, err := chacha20.NewUnauthenticatedCipher(hpKey, sample[4:16])
+			if err != nil {
+				panic("quic: " + err.Error())
+			}
+			c.SetCounter(binary.LittleEndian.Uint32(sample[:4]))
+			c.XORKeyStream(mask[:], mask[:])
+			return mask
+		}
+	default:
+		return nil, errors.New("quic: unsupported cipher suite")
+	}
+	return k, nil
+}
+
+// initialKeys returns the Initial packet protection keys of the client
+// and of the server for the destination connection ID of the first
+// Initial packet of the client.
+func initialKeys(cid []byte) (client, server *keys) {
+	secret := hkdf.Extract(sha256.New, cid, initialSalt)
+	client, err := newKeys(tls.TLS_AES_128_GCM_SHA256, hkdfExpandLabel(sha256.New, secret, "client in", sha256.Size))
+	if err != nil {
+		panic(err)
+	}
+	server, err = newKeys(tls.TLS_AES_128_GCM_SHA256, hkdfExpandLabel(sha256.New, secret, "server in", sha256.Size))
+	if err != nil {
+		panic(err)
+	}
+	return client, server
+}
+
+// next returns the keys of the next key phase (RFC 9001, Section 6).
+// The header protection key does not change.
+func (k *keys) next() (*keys, error) {
+	h := suiteHash(k.suite)
+	nk, err := newKeys(k.suite, hkdfExpandLabel(h, k.secret, "quic ku", h().Size()))
+	if err != nil {
+		return nil, err
+	}
+	nk.hp = k.hp
+	return nk, nil
+}
+
+func (k *keys) nonce(pn int64) []byte {
+	nonce := make([]byte, len(k.iv))
+	copy(nonce, k.iv)
+	for i := 0; i < 8; i++ {
+		nonce[len(nonce)-1-i] ^= byte(pn >> (8 * i))
+	}
+	return nonce
+}
+
+// protect encrypts the payload of the packet pkt, whose packet number of
+// pnLen bytes starts at pnOff, and applies header protection. pkt must
+// have room for the authentication tag.
+func (k *keys) protect(pkt []byte, pnOff, pnLen int, pn int64) []byte {
+	hdr := pkt[:pnOff+pnLen]
+	pkt = k.aead.Seal(hdr, k.nonce(pn), pkt[pnOff+pnLen:], hdr)
+	mask := k.hp(pkt[pnOff+4 : pnOff+4+16])
+	if pkt[0]&0x80 != 0 {
+		pkt[0] ^= mask[0] & 0x0f
+	} else {
+		pkt[0] ^= mask[0] & 0x1f
+	}
+	for i := 0; i < pnLen; i++ {
+		pkt[pnOff+i] ^= mask[1+i]
+	}
+	return pkt
+}
+
+// unprotectHeader removes the header protection of the packet pkt whose
+// packet number starts at pnOff, and returns the length and the
+// truncated value of the packet number.
+func (k *keys) unprotectHeader(pkt []byte, pnOff int) (pnLen int, pn int64, ok bool) {
+	if len(pkt) < pnOff+4+16 {
+		return 0, 0, false
+	}
+	mask := k.hp(pkt[pnOff+4 : pnOff+4+16])
+	if pkt[0]&0x80 != 0 {
+		pkt[0] ^= mask[0] & 0x0f
+	} else {
+		pkt[0] ^= mask[0] & 0x1f
+	}
+	pnLen = int(pkt[0]&0x03) + 1
+	for i := 0; i < pnLen; i++ {
+		pkt[pnOff+i] ^= mask[1+i]
+		pn = pn<<8 | int64(pkt[pnOff+i])
+	}
+	return pnLen, pn, true
+}
+
+// open decrypts the payload of the packet pkt, whose header of hdrLen
+// bytes has already been unprotected, in place.
+func (k *keys) open(pkt []byte, hdrLen int, pn int64) ([]byte, error) {
+	payload := pkt[hdrLen:]
+	return k.aead.Open(payload[:0], k.nonce(pn), payload, pkt[:hdrLen])
+}
@@ -0,0 +1,398 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quic
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"errors"
+	"internal/race"
+	"net"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// maxAcceptQueue is the maximum number of connections waiting to be
+// accepted.
+const maxAcceptQueue = 128
+
+// An Endpoint sends and receives QUIC packets on a network connection,
+// for the connections it dials and, when it listens, the connections it
+// accepts.
+type Endpoint struct {
+	pc        net.PacketConn
+	cfg       *Config     // for accepted connections, nil if not listening
+	serverTLS *tls.Config // for accepted connections
+
+	readDone chan struct{}
+	connWG   sync.WaitGroup
+
+	mu      sync.Mutex
+	conns   map[string]*Conn // by connection ID
+	all     map[*Conn]struct{}
+	acceptq []*Conn
+	acceptn notifier
+	closed  bool
+	stopped bool              // not accepting connections
+	params  map[string][]byte // transport parameters of servers, by name
+}
+
+// Listen listens for QUIC connections on the local network address.
+// The network must be "udp", "udp4" or "udp6". If cfg is nil, the
+// Endpoint does not accept connections, and may only dial them.
+func Listen(network, address string, cfg *Config) (*Endpoint, error) {
+	pc, err := net.ListenPacket(network, address)
+	if err != nil {
+		return nil, err
+	}
+	return NewEndpoint(pc, cfg), nil
+}
+
+// NewEndpoint returns an Endpoint using the network connection pc, which
+// it takes ownership of. If cfg is nil, the Endpoint does not accept
+// connections.
+func NewEndpoint(pc net.PacketConn, cfg *Config) *Endpoint {
+	e := &Endpoint{
+		pc:       pc,
+		cfg:      cfg,
+		readDone: make(chan struct{}),
+		conns:    make(map[string]*Conn),
+		all:      make(map[*Conn]struct{}),
+		params:   make(map[string][]byte),
+	}
+	if cfg != nil {
+		e.serverTLS = prepareTLSConfig(cfg.TLSConfig)
+		if e.serverTLS.SessionTicketKey == [32]byte{} {
+			// Connections share the configuration, and so the
+			// keys of their session tickets.
+			rand.Read(e.serverTLS.SessionTicketKey[:])
+		}
+		if !cfg.Allow0RTT {
+			disableEarlyData(e.serverTLS)
+		}
+	}
+	go e.readLoop()
+	return e
+}
+
+// LocalAddr returns the local network address.
+func (e *Endpoint) LocalAddr() net.Addr {
+	return e.pc.LocalAddr()
+}
+
+// Close closes the connections of the Endpoint, without waiting for their
+// peers to acknowledge it, and then closes its network connection.
+func (e *Endpoint) Close() error {
+	e.mu.Lock()
+	if e.closed {
+		e.mu.Unlock()
+		return nil
+	}
+	e.closed = true
+	e.acceptn.notify()
+	conns := make([]*Conn, 0, len(e.all))
+	for c := range e.all {
+		conns = append(conns, c)
+	}
+	e.mu.Unlock()
+
+	for _, c := range conns {
+		c.mu.Lock()
+		now := time.Now()
+		c.abortLocked(now, ErrEndpointClosed)
+		c.closeDeadline = now
+		c.mu.Unlock()
+		c.wake()
+	}
+	e.connWG.Wait()
+	err := e.pc.Close()
+	<-e.readDone
+	return err
+}
+
+// StopAccepting makes the Endpoint refuse new connections, and Accept
+// return ErrEndpointClosed, without closing the existing connections.
+func (e *Endpoint) StopAccepting() {
+	e.mu.Lock()
+	e.stopped = true
+	e.acceptn.notify()
+	refused := e.acceptq
+	e.acceptq = nil
+	e.mu.Unlock()
+	for _, c := range refused {
+		c.mu.Lock()
+		c.abortLocked(time.Now(), &TransportError{Code: uint64(errConnectionRefused), Reason: "server closed"})
+		c.mu.Unlock()
+		c.wake()
+	}
+}
+
+// Accept waits for and returns the next connection whose handshake has
+// completed.
+func (e *Endpoint) Accept(ctx context.Context) (*Conn, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for {
+		if len(e.acceptq) > 0 {
+			c := e.acceptq[0]
+			e.acceptq[0] = nil
+			e.acceptq = e.acceptq[1:]
+			return c, nil
+		}
+		if e.closed || e.stopped {
+			return nil, ErrEndpointClosed
+		}
+		if e.cfg == nil {
+			return nil, errors.New("quic: Accept on an endpoint that does not listen")
+		}
+		ch := e.acceptn.wait()
+		e.mu.Unlock()
+		select {
+		case <-ch:
+		case <-ctx.Done():
+			e.mu.Lock()
+			return nil, ctx.Err()
+		}
+		e.mu.Lock()
+	}
+}
+
+// Dial opens a connection to the network address, and waits for its
+// handshake to complete, or, with cfg.Allow0RTT, for the connection to
+// be able to send 0-RTT data. If the ServerName of cfg.TLSConfig is
+// empty, the host of address is used.
+func (e *Endpoint) Dial(ctx context.Context, network, address string, cfg *Config) (*Conn, error) {
+	raddr, err := net.ResolveUDPAddr(network, address)
+	if err != nil {
+		return nil, err
+	}
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	tlsConfig := prepareTLSConfig(cfg.TLSConfig)
+	if tlsConfig.ServerName == "" {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.ServerName = host
+	}
+
+	e.mu.Lock()
+	if e.closed {
+		e.mu.Unlock()
+		return nil, ErrEndpointClosed
+	}
+	e.mu.Unlock()
+	c, err := newConn(e, raddr, true, cfg, tlsConfig, newConnID(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if !e.addConn(c) {
+		c.tls.Close()
+		return nil, ErrEndpointClosed
+	}
+	select {
+	case <-c.handshakeDonec:
+		return c, nil
+	case <-c.earlyc:
+		return c, nil
+	case <-c.donec:
+		return nil, c.Err()
+	case <-ctx.Done():
+		c.mu.Lock()
+		c.abortLocked(time.Now(), &TransportError{Code: uint64(errNo), Reason: "dial canceled"})
+		c.mu.Unlock()
+		c.wake()
+		return nil, ctx.Err()
+	}
+}
+
+// addConn registers a new connection and starts it, unless e is closed.
+func (e *Endpoint) addConn(c *Conn) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.closed {
+		return false
+	}
+	e.conns[string(c.localCID)] = c
+	if !c.isClient {
+		e.conns[string(c.origDCID)] = c
+	}
+	e.all[c] = struct{}{}
+	e.connWG.Add(1)
+	go c.loop()
+	return true
+}
+
+// connDone forgets a connection that is done.
+func (e *Endpoint) connDone(c *Conn) {
+	e.mu.Lock()
+	delete(e.conns, string(c.localCID))
+	if !c.isClient && e.conns[string(c.origDCID)] == c {
+		delete(e.conns, string(c.origDCID))
+	}
+	delete(e.all, c)
+	e.mu.Unlock()
+	e.connWG.Done()
+}
+
+// queueAccept makes a server connection available to Accept.
+func (e *Endpoint) queueAccept(c *Conn) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.stopped || len(e.acceptq) >= maxAcceptQueue {
+		c.abortLocked(time.Now(), &TransportError{Code: uint64(errConnectionRefused), Reason: "server busy"})
+		return
+	}
+	e.acceptq = append(e.acceptq, c)
+	e.acceptn.notify()
+}
+
+// storeParams remembers the transport parameters of a server, for
+// sending 0-RTT data on later connections to it.
+func (e *Endpoint) storeParams(serverName string, params []byte) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.params[serverName] = bytes.Clone(params)
+}
+
+func (e *Endpoint) loadParams(serverName string) []byte {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.params[serverName]
+}
+
+// ioSync lets the race detector see that what happened before a datagram
+// is sent happens before it is received, as the syscall package does for
+// the reads and writes of stream sockets.
+var ioSync byte
+
+func (e *Endpoint) writeTo(b []byte, addr net.Addr) {
+	if race.Enabled {
+		race.ReleaseMerge(unsafe.Pointer(&ioSync))
+	}
+	e.pc.WriteTo(b, addr)
+}
+
+// readLoop reads datagrams and dispatches them to their connections.
+func (e *Endpoint) readLoop() {
+	defer close(e.readDone)
+	buf := make([]byte, 65536)
+	for {
+		n, addr, err := e.pc.ReadFrom(buf)
+		if err != nil {
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				continue
+			}
+			return
+		}
+		if race.Enabled {
+			race.Acquire(unsafe.Pointer(&ioSync))
+		}
+		e.dispatch(bytes.Clone(buf[:n]), addr)
+	}
+}
+
+func (e *Endpoint) dispatch(b []byte, addr net.Addr) {
+	dcid, ok := destConnID(b)
+	if !ok {
+		return
+	}
+	e.mu.Lock()
+	c := e.conns[string(dcid)]
+	e.mu.Unlock()
+	if c == nil {
+		c = e.newServerConn(b, addr)
+		if c == nil {
+			return
+		}
+	}
+	if c.remote.String() != addr.String() {
+		return // Connection migration is not supported.
+	}
+	select {
+	case c.inc <- b:
+	default:
+	}
+}
+
+// newServerConn returns a new connection for the first datagram of a
+// client, or nil if it does not start a connection.
+func (e *Endpoint) newServerConn(b []byte, addr net.Addr) *Conn {
+	e.mu.Lock()
+	listening := e.cfg != nil && !e.closed && !e.stopped
+	e.mu.Unlock()
+	if !listening || !isLongHeader(b) {
+		return nil
+	}
+	h, ok := parseHeader(b)
+	if !ok {
+		return nil
+	}
+	if h.version != quicVersion1 {
+		if len(b) >= maxDatagramSize && h.version != 0 {
+			e.writeTo(appendVersionNegotiation(nil, h.scid, h.dcid), addr)
+		}
+		return nil
+	}
+	// Clients pad datagrams of Initial packets, and choose a destination
+	// connection ID of at least 8 bytes (RFC 9000, Section 7.2).
+	if h.typ != packetTypeInitial || len(b) < maxDatagramSize || len(h.dcid) < 8 {
+		return nil
+	}
+	c, err := newConn(e, addr, false, e.cfg, e.serverTLS, bytes.Clone(h.dcid), h.scid)
+	if err != nil {
+		return nil
+	}
+	if !e.addConn(c) {
+		c.tls.Close()
+		return nil
+	}
+	return c
+}
+
+// prepareTLSConfig returns a copy of config for QUIC connections.
+func prepareTLSConfig(config *tls.Config) *tls.Config {
+	config = config.Clone()
+	if config == nil {
+		config = new(tls.Config)
+	}
+	if config.MinVersion < tls.VersionTLS13 {
+		config.MinVersion = tls.VersionTLS13
+	}
+	return config
+}
+
+// disableEarlyData makes a server configuration reject 0-RTT data, even
+// from the sessions of tickets that allowed it.
+func disableEarlyData(config *tls.Config) {
+	unwrap := config.UnwrapSession
+	config.UnwrapSession = func(identity []byte, cs tls.ConnectionState) (*tls.SessionState, error) {
+		var s *tls.SessionState
+		var err error
+		if unwrap != nil {
+			s, err = unwrap(identity, cs)
+		} else {
+			s, err = config.DecryptTicket(identity, cs)
+		}
+		if s != nil {
+			s.EarlyData = false
+		}
+		return s, err
+	}
+}
+
+func newConnID() []byte {
+	b := make([]byte, connIDLen)
+	if _, err := rand.Read(b); err != nil {
+		panic("quic: " + err.Error())
+	}
+	return b
+}
@@ -0,0 +1,224 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quic
+
+import "encoding/binary"
+
+const (
+	quicVersion1 = 0x00000001
+
+	// connIDLen is the length of the connection IDs chosen by an
+	// Endpoint.
+	connIDLen = 8
+
+	// maxDatagramSize is the size of the UDP datagrams sent, which is
+	// the smallest maximum datagram size allowed (RFC 9000, Section 14).
+	maxDatagramSize = 1200
+
+	// maxCIDLen is the maximum length of a connection ID in QUIC
+	// version 1.
+	maxCIDLen = 20
+
+	// pnLen is the length of the packet numbers sent.
+	pnLen = 4
+)
+
+// A packetType is the type of a QUIC packet.
+type packetType uint8
+
+const (
+	packetTypeInitial packetType = iota
+	packetType0RTT
+	packetTypeHandshake
+	packetTypeRetry
+	packetType1RTT
+)
+
+func (t packetType) String() string {
+	switch t {
+	case packetTypeInitial:
+		return "Initial"
+	case packetType0RTT:
+		return "0-RTT"
+	case packetTypeHandshake:
+		return "Handshake"
+	case packetTypeRetry:
+		return "Retry"
+	}
+	return "1-RTT"
+}
+
+// A packetHeader is the parsed, still protected, header of a packet.
+type packetHeader struct {
+	typ     packetType
+	version uint32
+	dcid    []byte
+	scid    []byte
+	pnOff   int // offset of the packet number
+	size    int // size of the packet in the datagram
+}
+
+// isLongHeader reports whether the datagram b starts with a long header
+// packet.
+func isLongHeader(b []byte) bool {
+	return len(b) > 0 && b[0]&0x80 != 0
+}
+
+// destConnID returns the destination connection ID of the first packet
+// of the datagram b, assuming that connection IDs in short headers are
+// connIDLen bytes long.
+func destConnID(b []byte) ([]byte, bool) {
+	if isLongHeader(b) {
+		if len(b) < 6 || int(b[5]) > maxCIDLen || len(b) < 6+int(b[5]) {
+			return nil, false
+		}
+		return b[6 : 6+b[5]], true
+	}
+	if len(b) < 1+connIDLen {
+		return nil, false
+	}
+	return b[1 : 1+connIDLen], true
+}
+
+// parseHeader parses the header of the first packet of the datagram b.
+// For long header packets of versions other than QUIC version 1, only the
+// version and the connection IDs are set.
+func parseHeader(b []byte) (h packetHeader, ok bool) {
+	if len(b) == 0 {
+		return h, false
+	}
+	if !isLongHeader(b) {
+		if len(b) < 1+connIDLen || b[0]&0x40 == 0 {
+			return h, false
+		}
+		h.typ = packetType1RTT
+		h.dcid = b[1 : 1+connIDLen]
+		h.pnOff = 1 + connIDLen
+		h.size = len(b)
+		return h, true
+	}
+	if len(b) < 7 {
+		return h, false
+	}
+	h.version = binary.BigEndian.Uint32(b[1:])
+	off := 5
+	n := int(b[off])
+	off++
+	if n > maxCIDLen || len(b) < off+n+1 {
+		return h, false
+	}
+	h.dcid = b[off : off+n]
+	off += n
+	n = int(b[off])
+	off++
+	if n > maxCIDLen || len(b) < off+n {
+		return h, false
+	}
+	h.scid = b[off : off+n]
+	off += n
+	if h.version != quicVersion1 {
+		return h, true
+	}
+	if b[0]&0x40 == 0 {
+		return h, false
+	}
+	h.typ = packetType((b[0] >> 4) & 0x03)
+	switch h.typ {
+	case packetTypeRetry:
+		h.size = len(b)
+		return h, true
+	case packetTypeInitial:
+		tokLen, n := ConsumeVarint(b[off:])
+		if n < 0 || uint64(len(b)-off-n) < tokLen {
+			return h, false
+		}
+		off += n + int(tokLen)
+	}
+	length, n := ConsumeVarint(b[off:])
+	if n < 0 || uint64(len(b)-off-n) < length {
+		return h, false
+	}
+	off += n
+	h.pnOff = off
+	h.size = off + int(length)
+	return h, true
+}
+
+// decodePacketNumber returns the full packet number of a truncated
+// packet number of pnLen bytes, given the largest packet number received
+// (RFC 9000, Appendix A.3).
+func decodePacketNumber(largest, truncated int64, pnLen int) int64 {
+	expected := largest + 1
+	win := int64(1) << (8 * pnLen)
+	hwin := win / 2
+	mask := win - 1
+	candidate := (expected &^ mask) | truncated
+	switch {
+	case candidate <= expected-hwin && candidate < 1<<62-win:
+		return candidate + win
+	case candidate > expected+hwin && candidate >= win:
+		return candidate - win
+	}
+	return candidate
+}
+
+// appendLongHeader appends the header of a long header packet of the
+// given type with a payload of the given length, not including the
+// authentication tag, and returns the offset of its packet number.
+func appendLongHeader(b []byte, typ packetType, dcid, scid []byte, pn int64, payloadLen int) ([]byte, int) {
+	b = append(b, 0xc0|byte(typ)<<4|(pnLen-1))
+	b = binary.BigEndian.AppendUint32(b, quicVersion1)
+	b = append(b, byte(len(dcid)))
+	b = append(b, dcid...)
+	b = append(b, byte(len(scid)))
+	b = append(b, scid...)
+	if typ == packetTypeInitial {
+		b = append(b, 0) // no token
+	}
+	// The length is always encoded in two bytes, so that the size of
+	// the header does not depend on it.
+	length := pnLen + payloadLen + aeadTagSize
+	b = append(b, 0x40|byte(length>>8), byte(length))
+	pnOff := len(b)
+	b = binary.BigEndian.AppendUint32(b, uint32(pn))
+	return b, pnOff
+}
+
+// longHeaderSize returns the size of the header of a long header packet,
+// including the packet number.
+func longHeaderSize(typ packetType, dcid, scid []byte) int {
+	n := 1 + 4 + 1 + len(dcid) + 1 + len(scid) + 2 + pnLen
+	if typ == packetTypeInitial {
+		n++
+	}
+	return n
+}
+
+// appendShortHeader appends the header of a 1-RTT packet and returns the
+// offset of its packet number.
+func appendShortHeader(b []byte, dcid []byte, keyPhase bool, pn int64) ([]byte, int) {
+	first := byte(0x40 | (pnLen - 1))
+	if keyPhase {
+		first |= 0x04
+	}
+	b = append(b, first)
+	b = append(b, dcid...)
+	pnOff := len(b)
+	b = binary.BigEndian.AppendUint32(b, uint32(pn))
+	return b, pnOff
+}
+
+// appendVersionNegotiation appends a Version Negotiation packet in
+// response to a packet with the given connection IDs
+// (RFC 9000, Section 17.2.1).
+func appendVersionNegotiation(b []byte, dcid, scid []byte) []byte {
+	b = append(b, 0xc0)
+	b = binary.BigEndian.AppendUint32(b, 0)
+	b = append(b, byte(len(dcid)))
+	b = append(b, dcid...)
+	b = append(b, byte(len(scid)))
+	b = append(b, scid...)
+	return binary.BigEndian.AppendUint32(b, quicVersion1)
+}
@@ -0,0 +1,220 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quic
+
+import (
+	"bytes"
+	"errors"
+	"time"
+)
+
+// Transport parameter IDs (RFC 9000, Section 18.2).
+const (
+	paramOriginalDestinationConnectionID = 0x00
+	paramMaxIdleTimeout                  = 0x01
+	paramStatelessResetToken             = 0x02
+	paramMaxUDPPayloadSize               = 0x03
+	paramInitialMaxData                  = 0x04
+	paramInitialMaxStreamDataBidiLocal   = 0x05
+	paramInitialMaxStreamDataBidiRemote  = 0x06
+	paramInitialMaxStreamDataUni         = 0x07
+	paramInitialMaxStreamsBidi           = 0x08
+	paramInitialMaxStreamsUni            = 0x09
+	paramAckDelayExponent                = 0x0a
+	paramMaxAckDelay                     = 0x0b
+	paramDisableActiveMigration          = 0x0c
+	paramActiveConnectionIDLimit         = 0x0e
+	paramInitialSourceConnectionID       = 0x0f
+	paramRetrySourceConnectionID         = 0x10
+)
+
+// transportParams are the QUIC transport parameters of an endpoint.
+type transportParams struct {
+	origDCID                 []byte // nil if absent
+	initialSCID              []byte // nil if absent
+	maxIdleTimeout           time.Duration
+	maxUDPPayloadSize        int64
+	initialMaxData           int64
+	maxStreamDataBidiLocal   int64
+	maxStreamDataBidiRemote  int64
+	maxStreamDataUni         int64
+	initialMaxStreamsBidi    int64
+	initialMaxStreamsUni     int64
+	ackDelayExponent         int64
+	maxAckDelay              time.Duration
+	disableActiveMigration   bool
+	activeConnectionIDLimit  int64
+	hasRetrySourceConnection bool
+}
+
+func defaultTransportParams() transportParams {
+	return transportParams{
+		maxUDPPayloadSize:       65527,
+		ackDelayExponent:        3,
+		maxAckDelay:             25 * time.Millisecond,
+		activeConnectionIDLimit: 2,
+	}
+}
+
+func appendParam(b []byte, id uint64, v []byte) []byte {
+	b = AppendVarint(b, id)
+	b = AppendVarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+func appendIntParam(b []byte, id uint64, v int64) []byte {
+	b = AppendVarint(b, id)
+	b = AppendVarint(b, uint64(SizeVarint(uint64(v))))
+	return AppendVarint(b, uint64(v))
+}
+
+// marshal encodes the parameters that differ from their defaults.
+func (p *transportParams) marshal() []byte {
+	var b []byte
+	if p.origDCID != nil {
+		b = appendParam(b, paramOriginalDestinationConnectionID, p.origDCID)
+	}
+	if p.maxIdleTimeout > 0 {
+		b = appendIntParam(b, paramMaxIdleTimeout, p.maxIdleTimeout.Milliseconds())
+	}
+	if p.maxUDPPayloadSize != 65527 {
+		b = appendIntParam(b, paramMaxUDPPayloadSize, p.maxUDPPayloadSize)
+	}
+	for _, ip := range []struct {
+		id uint64
+		v  int64
+	}{
+		{paramInitialMaxData, p.initialMaxData},
+		{paramInitialMaxStreamDataBidiLocal, p.maxStreamDataBidiLocal},
+		{paramInitialMaxStreamDataBidiRemote, p.maxStreamDataBidiRemote},
+		{paramInitialMaxStreamDataUni, p.maxStreamDataUni},
+		{paramInitialMaxStreamsBidi, p.initialMaxStreamsBidi},
+		{paramInitialMaxStreamsUni, p.initialMaxStreamsUni},
+	} {
+		if ip.v > 0 {
+			b = appendIntParam(b, ip.id, ip.v)
+		}
+	}
+	if p.maxAckDelay != 25*time.Millisecond {
+		b = appendIntParam(b, paramMaxAckDelay, p.maxAckDelay.Milliseconds())
+	}
+	if p.disableActiveMigration {
+		b = appendParam(b, paramDisableActiveMigration, nil)
+	}
+	if p.activeConnectionIDLimit != 2 {
+		b = appendIntParam(b, paramActiveConnectionIDLimit, p.activeConnectionIDLimit)
+	}
+	if p.initialSCID != nil {
+		b = appendParam(b, paramInitialSourceConnectionID, p.initialSCID)
+	}
+	return b
+}
+
+var errMalformedParams = errors.New("malformed transport parameters")
+
+// unmarshalTransportParams decodes the transport parameters sent by the
+// peer. Server-only parameters are rejected if fromServer is false.
+func unmarshalTransportParams(b []byte, fromServer bool) (transportParams, error) {
+	p := defaultTransportParams()
+	var seen []uint64
+	for len(b) > 0 {
+		id, n := ConsumeVarint(b)
+		if n < 0 {
+			return p, errMalformedParams
+		}
+		b = b[n:]
+		length, n := ConsumeVarint(b)
+		if n < 0 || uint64(len(b)-n) < length {
+			return p, errMalformedParams
+		}
+		v := b[n : n+int(length)]
+		b = b[n+int(length):]
+		for _, s := range seen {
+			if s == id {
+				return p, errors.New("duplicate transport parameter")
+			}
+		}
+		seen = append(seen, id)
+
+		var iv int64
+		intParam := func() error {
+			x, n := ConsumeVarint(v)
+			if n != len(v) {
+				return errMalformedParams
+			}
+			iv = int64(x)
+			return nil
+		}
+		switch id {
+		case paramOriginalDestinationConnectionID, paramStatelessResetToken, paramRetrySourceConnectionID:
+			if !fromServer {
+				return p, errors.New("server-only transport parameter sent by client")
+			}
+			switch id {
+			case paramOriginalDestinationConnectionID:
+				p.origDCID = bytes.Clone(v)
+			case paramRetrySourceConnectionID:
+				p.hasRetrySourceConnection = true
+			}
+		case paramInitialSourceConnectionID:
+			p.initialSCID = bytes.Clone(v)
+		case paramDisableActiveMigration:
+			if len(v) != 0 {
+				return p, errMalformedParams
+			}
+			p.disableActiveMigration = true
+		case paramMaxIdleTimeout, paramMaxUDPPayloadSize, paramInitialMaxData,
+			paramInitialMaxStreamDataBidiLocal, paramInitialMaxStreamDataBidiRemote,
+			paramInitialMaxStreamDataUni, paramInitialMaxStreamsBidi, paramInitialMaxStreamsUni,
+			paramAckDelayExponent, paramMaxAckDelay, paramActiveConnectionIDLimit:
+			if err := intParam(); err != nil {
+				return p, err
+			}
+			switch id {
+			case paramMaxIdleTimeout:
+				p.maxIdleTimeout = time.Duration(iv) * time.Millisecond
+			case paramMaxUDPPayloadSize:
+				if iv < 1200 {
+					return p, errors.New("invalid max_udp_payload_size")
+				}
+				p.maxUDPPayloadSize = iv
+			case paramInitialMaxData:
+				p.initialMaxData = iv
+			case paramInitialMaxStreamDataBidiLocal:
+				p.maxStreamDataBidiLocal = iv
+			case paramInitialMaxStreamDataBidiRemote:
+				p.maxStreamDataBidiRemote = iv
+			case paramInitialMaxStreamDataUni:
+				p.maxStreamDataUni = iv
+			case paramInitialMaxStreamsBidi, paramInitialMaxStreamsUni:
+				if iv > 1<<60 {
+					return p, errors.New("invalid initial_max_streams")
+				}
+				if id == paramInitialMaxStreamsBidi {
+					p.initialMaxStreamsBidi = iv
+				} else {
+					p.initialMaxStreamsUni = iv
+				}
+			case paramAckDelayExponent:
+				if iv > 20 {
+					return p, errors.New("invalid ack_delay_exponent")
+				}
+				p.ackDelayExponent = iv
+			case paramMaxAckDelay:
+				if iv >= 1<<14 {
+					return p, errors.New("invalid max_ack_delay")
+				}
+				p.maxAckDelay = time.Duration(iv) * time.Millisecond
+			case paramActiveConnectionIDLimit:
+				if iv < 2 {
+					return p, errors.New("invalid active_connection_id_limit")
+				}
+				p.activeConnectionIDLimit = iv
+			}
+		}
+		// Unknown parameters are ignored.
+	}
+	return p, nil
+}
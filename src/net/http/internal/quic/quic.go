@@ -0,0 +1,237 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package quic implements the QUIC transport protocol, as defined by
+// RFC 9000, RFC 9001 and RFC 9002, for use by the HTTP/3 implementation
+// in net/http.
+//
+// The implementation supports QUIC version 1 with packet protection from
+// crypto/tls, bidirectional and unidirectional streams with flow control,
+// loss detection, NewReno congestion control and 0-RTT. It does not
+// support connection migration, Retry packets or stateless resets.
+package quic
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// A Config configures an [Endpoint] or a connection.
+type Config struct {
+	// TLSConfig is the TLS configuration of the connections.
+	// Its MinVersion is raised to TLS 1.3.
+	TLSConfig *tls.Config
+
+	// MaxIdleTimeout is the time after which an idle connection is
+	// closed. If zero, 30 seconds is used.
+	MaxIdleTimeout time.Duration
+
+	// KeepAlivePeriod, if positive, is the time after which a PING is
+	// sent on an idle connection to keep it open.
+	KeepAlivePeriod time.Duration
+
+	// MaxBidiRemoteStreams and MaxUniRemoteStreams are the numbers of
+	// concurrent bidirectional and unidirectional streams the peer may
+	// open. If zero, 100 is used.
+	MaxBidiRemoteStreams int64
+	MaxUniRemoteStreams  int64
+
+	// MaxStreamReadBufferSize and MaxConnReadBufferSize are the flow
+	// control windows of streams and of connections. If zero, 1 MiB and
+	// 4 MiB are used.
+	MaxStreamReadBufferSize int64
+	MaxConnReadBufferSize   int64
+
+	// Allow0RTT enables 0-RTT. A client sends data on the streams it
+	// opens before the handshake completes when it resumes a session
+	// that allows it, and [Endpoint.Dial] returns as soon as it can do
+	// so. A server issues session tickets that allow 0-RTT.
+	//
+	// A server accepts 0-RTT data, but only makes the connection
+	// available to [Endpoint.Accept] once the handshake has completed,
+	// which protects it from replayed data.
+	Allow0RTT bool
+}
+
+func (c *Config) maxIdleTimeout() time.Duration {
+	if c.MaxIdleTimeout > 0 {
+		return c.MaxIdleTimeout
+	}
+	return 30 * time.Second
+}
+
+func (c *Config) maxBidiRemoteStreams() int64 { return orDefault(c.MaxBidiRemoteStreams, 100) }
+func (c *Config) maxUniRemoteStreams() int64  { return orDefault(c.MaxUniRemoteStreams, 100) }
+func (c *Config) maxStreamReadBufferSize() int64 {
+	return orDefault(c.MaxStreamReadBufferSize, 1<<20)
+}
+func (c *Config) maxConnReadBufferSize() int64 { return orDefault(c.MaxConnReadBufferSize, 4<<20) }
+
+func orDefault(v, def int64) int64 {
+	if v > 0 {
+		return v
+	}
+	return def
+}
+
+// A transportError is a QUIC transport error code (RFC 9000, Section 20.1).
+type transportError uint64
+
+const (
+	errNo                  transportError = 0x00
+	errInternal            transportError = 0x01
+	errConnectionRefused   transportError = 0x02
+	errFlowControl         transportError = 0x03
+	errStreamLimit         transportError = 0x04
+	errStreamState         transportError = 0x05
+	errFinalSize           transportError = 0x06
+	errFrameEncoding       transportError = 0x07
+	errTransportParameter  transportError = 0x08
+	errProtocolViolation   transportError = 0x0a
+	errApplication         transportError = 0x0c
+	errCryptoBufferExceeds transportError = 0x0d
+	errKeyUpdate           transportError = 0x0e
+	errTLSAlertBase        transportError = 0x0100
+)
+
+// A TransportError is a QUIC transport error that closed a connection.
+type TransportError struct {
+	Code   uint64
+	Reason string
+	Remote bool // whether the peer closed the connection
+}
+
+func (e *TransportError) Error() string {
+	side := "local"
+	if e.Remote {
+		side = "remote"
+	}
+	s := fmt.Sprintf("quic: %s transport error %#x", side, e.Code)
+	if e.Reason != "" {
+		s += ": " + e.Reason
+	}
+	return s
+}
+
+// An ApplicationError is an application protocol error that closed a
+// connection, set by [Conn.CloseWithError].
+type ApplicationError struct {
+	Code   uint64
+	Reason string
+	Remote bool // whether the peer closed the connection
+}
+
+func (e *ApplicationError) Error() string {
+	side := "local"
+	if e.Remote {
+		side = "remote"
+	}
+	s := fmt.Sprintf("quic: %s application error %#x", side, e.Code)
+	if e.Reason != "" {
+		s += ": " + e.Reason
+	}
+	return s
+}
+
+// A StreamError is returned by the methods of a [Stream] when the stream
+// has been reset, or when the peer asked to stop sending on it.
+type StreamError struct {
+	Code   uint64
+	Remote bool // whether the peer reset the stream
+}
+
+func (e *StreamError) Error() string {
+	if e.Remote {
+		return fmt.Sprintf("quic: stream reset by peer with code %#x", e.Code)
+	}
+	return fmt.Sprintf("quic: stream reset with code %#x", e.Code)
+}
+
+var (
+	// ErrIdleTimeout is returned when a connection is closed after
+	// being idle for too long.
+	ErrIdleTimeout = errors.New("quic: idle timeout")
+
+	// ErrEndpointClosed is returned by the methods of a closed
+	// [Endpoint], and by those of its connections.
+	ErrEndpointClosed = errors.New("quic: endpoint closed")
+
+	errClosedStream = errors.New("quic: use of closed stream")
+)
+
+// maxVarint is the largest value of a variable-length integer.
+const maxVarint = 1<<62 - 1
+
+// AppendVarint appends v to b as a variable-length integer
+// (RFC 9000, Section 16).
+func AppendVarint(b []byte, v uint64) []byte {
+	switch {
+	case v < 1<<6:
+		return append(b, byte(v))
+	case v < 1<<14:
+		return append(b, 0x40|byte(v>>8), byte(v))
+	case v < 1<<30:
+		return append(b, 0x80|byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+	case v <= maxVarint:
+		return append(b, 0xc0|byte(v>>56), byte(v>>48), byte(v>>40), byte(v>>32),
+			byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+	}
+	panic("quic: variable-length integer out of range")
+}
+
+// SizeVarint returns the encoded size of v as a variable-length integer.
+func SizeVarint(v uint64) int {
+	switch {
+	case v < 1<<6:
+		return 1
+	case v < 1<<14:
+		return 2
+	case v < 1<<30:
+		return 4
+	}
+	return 8
+}
+
+// ConsumeVarint parses a variable-length integer at the start of b,
+// and returns it with its encoded size. It returns a negative size if b
+// is too short.
+func ConsumeVarint(b []byte) (v uint64, n int) {
+	if len(b) == 0 {
+		return 0, -1
+	}
+	n = 1 << (b[0] >> 6)
+	if len(b) < n {
+		return 0, -1
+	}
+	v = uint64(b[0] & 0x3f)
+	for _, c := range b[1:n] {
+		v = v<<8 | uint64(c)
+	}
+	return v, n
+}
+
+// ReadVarint reads a variable-length integer from r.
+// It returns io.ErrUnexpectedEOF if r ends in the middle of it.
+func ReadVarint(r io.ByteReader) (uint64, error) {
+	c, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	n := 1 << (c >> 6)
+	v := uint64(c & 0x3f)
+	for i := 1; i < n; i++ {
+		c, err := r.ReadByte()
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+		v = v<<8 | uint64(c)
+	}
+	return v, nil
+}
@@ -0,0 +1,207 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quic
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func fromHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func TestVarint(t *testing.T) {
+	for _, tt := range []struct {
+		v   uint64
+		enc string
+	}{
+		{0, "00"},
+		{37, "25"},
+		{15293, "7bbd"},
+		{494878333, "9d7f3e7d"},
+		{151288809941952652, "c2197c5eff14e88c"},
+		{maxVarint, "ffffffffffffffff"},
+	} {
+		b := AppendVarint(nil, tt.v)
+		if got := hex.EncodeToString(b); got != tt.enc {
+			t.Errorf("AppendVarint(%d) = %s, want %s", tt.v, got, tt.enc)
+		}
+		if SizeVarint(tt.v) != len(b) {
+			t.Errorf("SizeVarint(%d) = %d, want %d", tt.v, SizeVarint(tt.v), len(b))
+		}
+		v, n := ConsumeVarint(b)
+		if v != tt.v || n != len(b) {
+			t.Errorf("ConsumeVarint(%s) = %d, %d, want %d, %d", tt.enc, v, n, tt.v, len(b))
+		}
+		if _, n := ConsumeVarint(b[:len(b)-1]); n >= 0 {
+			t.Errorf("ConsumeVarint(%x) succeeded on a truncated varint", b[:len(b)-1])
+		}
+		v, err := ReadVarint(bytes.NewReader(b))
+		if v != tt.v || err != nil {
+			t.Errorf("ReadVarint(%s) = %d, %v, want %d, nil", tt.enc, v, err, tt.v)
+		}
+	}
+}
+
+// TestInitialKeys checks the keys derived for the Initial packets of
+// RFC 9001, Appendix A.1.
+func TestInitialKeys(t *testing.T) {
+	client, server := initialKeys(fromHex("8394c8f03e515708"))
+	for _, tt := range []struct {
+		name        string
+		k           *keys
+		key, iv, hp string
+		sample      string
+		mask        string
+	}{{
+		name:   "client",
+		k:      client,
+		key:    "1f369613dd76d5467730efcbe3b1a22d",
+		iv:     "fa044b2f42a3fd3b46fb255c",
+		hp:     "9f50449e04a0e810283a1e9933adedd2",
+		sample: "d1b1c98dd7689fb8ec11d242b123dc9b",
+		mask:   "437b9aec36",
+	}, {
+		name:   "server",
+		k:      server,
+		key:    "cf3a5331653c364c88f0f379b6067e37",
+		iv:     "0ac1493ca1905853b0bba03e",
+		hp:     "c206b8d9b9f0f37644430b490eeaa314",
+		sample: "2cd0991cd25b0aac406a5816b6394100",
+		mask:   "2ec0d8356a",
+	}} {
+		if got := hex.EncodeToString(hkdfExpandLabel(sha256.New, tt.k.secret, "quic key", 16)); got != tt.key {
+			t.Errorf("%s key = %s, want %s", tt.name, got, tt.key)
+		}
+		if got := hex.EncodeToString(tt.k.iv); got != tt.iv {
+			t.Errorf("%s iv = %s, want %s", tt.name, got, tt.iv)
+		}
+		if got := hex.EncodeToString(hkdfExpandLabel(sha256.New, tt.k.secret, "quic hp", 16)); got != tt.hp {
+			t.Errorf("%s hp = %s, want %s", tt.name, got, tt.hp)
+		}
+		mask := tt.k.hp(fromHex(tt.sample))
+		if got := hex.EncodeToString(mask[:]); got != tt.mask {
+			t.Errorf("%s mask = %s, want %s", tt.name, got, tt.mask)
+		}
+	}
+}
+
+// TestChaCha20Keys checks the ChaCha20-Poly1305 short header packet of
+// RFC 9001, Appendix A.5.
+func TestChaCha20Keys(t *testing.T) {
+	secret := fromHex("9ac312a7f877468ebe69422748ad00a15443f18203a07d6060f688f30f21632b")
+	k, err := newKeys(tls.TLS_CHACHA20_POLY1305_SHA256, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkt := []byte{0x42, 0x00, 0xbf, 0xf4, 0x01}
+	pkt = append(pkt, make([]byte, aeadTagSize)...)
+	pkt = k.protect(pkt[:5], 1, 3, 654360564)
+	want := "4cfe4189655e5cd55c41f69080575d7999c25a5bfb"
+	if got := hex.EncodeToString(pkt); got != want {
+		t.Errorf("protected packet = %s, want %s", got, want)
+	}
+
+	pnLen, pn, ok := k.unprotectHeader(pkt, 1)
+	if !ok || pnLen != 3 || decodePacketNumber(654360563, pn, pnLen) != 654360564 {
+		t.Fatalf("unprotectHeader = %d, %d, %v", pnLen, pn, ok)
+	}
+	payload, err := k.open(pkt, 1+pnLen, 654360564)
+	if err != nil || !bytes.Equal(payload, []byte{0x01}) {
+		t.Errorf("open = %x, %v, want 01, nil", payload, err)
+	}
+
+	next, err := k.next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := hex.EncodeToString(next.secret), "1223504755036d556342ee9361d253421a826c9ecdf3c7148684b36b714881f9"; got != want {
+		t.Errorf("next secret = %s, want %s", got, want)
+	}
+}
+
+func TestDecodePacketNumber(t *testing.T) {
+	for _, tt := range []struct {
+		largest, truncated int64
+		pnLen              int
+		want               int64
+	}{
+		{0xa82f30ea, 0x9b32, 2, 0xa82f9b32},
+		{-1, 0, 4, 0},
+		{0xff, 0x01, 1, 0x101},
+		{0x100, 0xff, 1, 0xff},
+	} {
+		if got := decodePacketNumber(tt.largest, tt.truncated, tt.pnLen); got != tt.want {
+			t.Errorf("decodePacketNumber(%#x, %#x, %d) = %#x, want %#x", tt.largest, tt.truncated, tt.pnLen, got, tt.want)
+		}
+	}
+}
+
+func TestRangeset(t *testing.T) {
+	var s rangeset
+	s.add(10, 20)
+	s.add(30, 40)
+	s.add(20, 25)
+	s.add(5, 6)
+	if want := (rangeset{{5, 6}, {10, 25}, {30, 40}}); !reflect.DeepEqual(s, want) {
+		t.Fatalf("after add: %v, want %v", s, want)
+	}
+	s.add(6, 35)
+	if want := (rangeset{{5, 40}}); !reflect.DeepEqual(s, want) {
+		t.Fatalf("after add: %v, want %v", s, want)
+	}
+	s.remove(10, 20)
+	if want := (rangeset{{5, 10}, {20, 40}}); !reflect.DeepEqual(s, want) {
+		t.Fatalf("after remove: %v, want %v", s, want)
+	}
+	for _, tt := range []struct {
+		v    int64
+		want bool
+	}{{4, false}, {5, true}, {9, true}, {10, false}, {20, true}, {39, true}, {40, false}} {
+		if got := s.contains(tt.v); got != tt.want {
+			t.Errorf("contains(%d) = %v, want %v", tt.v, got, tt.want)
+		}
+	}
+	if got := s.max(); got != 39 {
+		t.Errorf("max() = %d, want 39", got)
+	}
+	if got := s.prefix(5); got != 10 {
+		t.Errorf("prefix(5) = %d, want 10", got)
+	}
+}
+
+func TestTransportParams(t *testing.T) {
+	p := defaultTransportParams()
+	p.origDCID = []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	p.initialSCID = []byte{9, 10}
+	p.maxIdleTimeout = 30 * time.Second
+	p.initialMaxData = 1 << 20
+	p.maxStreamDataBidiLocal = 1 << 16
+	p.maxStreamDataBidiRemote = 1 << 17
+	p.maxStreamDataUni = 1 << 18
+	p.initialMaxStreamsBidi = 100
+	p.initialMaxStreamsUni = 3
+	p.disableActiveMigration = true
+	got, err := unmarshalTransportParams(p.marshal(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, p) {
+		t.Errorf("unmarshalTransportParams(marshal(%+v)) = %+v", p, got)
+	}
+	if _, err := unmarshalTransportParams(p.marshal(), false); err == nil {
+		t.Errorf("client parameters with original_destination_connection_id accepted")
+	}
+}
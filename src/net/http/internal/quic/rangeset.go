@@ -0,0 +1,96 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quic
+
+// A span is the range of integers [start, end).
+type span struct {
+	start, end int64
+}
+
+// A rangeset is a set of integers, stored as sorted, non-overlapping and
+// non-adjacent spans.
+type rangeset []span
+
+// add adds [start, end) to s.
+func (s *rangeset) add(start, end int64) {
+	if start >= end {
+		return
+	}
+	r := *s
+	// Find the first span that ends at or after start.
+	i := 0
+	for i < len(r) && r[i].end < start {
+		i++
+	}
+	// Merge all the spans that start at or before end.
+	j := i
+	for j < len(r) && r[j].start <= end {
+		start = min(start, r[j].start)
+		end = max(end, r[j].end)
+		j++
+	}
+	if i == j {
+		r = append(r, span{})
+		copy(r[i+1:], r[i:])
+		r[i] = span{start, end}
+	} else {
+		r[i] = span{start, end}
+		r = append(r[:i+1], r[j:]...)
+	}
+	*s = r
+}
+
+// remove removes [start, end) from s.
+func (s *rangeset) remove(start, end int64) {
+	if start >= end {
+		return
+	}
+	var out rangeset
+	for _, sp := range *s {
+		if sp.end <= start || sp.start >= end {
+			out = append(out, sp)
+			continue
+		}
+		if sp.start < start {
+			out = append(out, span{sp.start, start})
+		}
+		if sp.end > end {
+			out = append(out, span{end, sp.end})
+		}
+	}
+	*s = out
+}
+
+// contains reports whether v is in s.
+func (s rangeset) contains(v int64) bool {
+	for _, sp := range s {
+		if v < sp.start {
+			return false
+		}
+		if v < sp.end {
+			return true
+		}
+	}
+	return false
+}
+
+// max returns the largest integer in s, or -1 if s is empty.
+func (s rangeset) max() int64 {
+	if len(s) == 0 {
+		return -1
+	}
+	return s[len(s)-1].end - 1
+}
+
+// prefix returns the end of the span that contains v, or v if there is
+// none: [v, prefix) is the longest range starting at v in s.
+func (s rangeset) prefix(v int64) int64 {
+	for _, sp := range s {
+		if sp.start <= v && v < sp.end {
+			return sp.end
+		}
+	}
+	return v
+}
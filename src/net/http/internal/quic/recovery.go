@@ -0,0 +1,167 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quic
+
+import "time"
+
+// Loss detection and congestion control constants (RFC 9002, Appendix
+// A.2 and B.2).
+const (
+	packetThreshold   = 3
+	timerGranularity  = time.Millisecond
+	initialRTT        = 333 * time.Millisecond
+	initialWindow     = 10 * maxDatagramSize
+	minimumWindow     = 2 * maxDatagramSize
+	lossReductionNum  = 1 // kLossReductionFactor is 1/2
+	lossReductionDen  = 2
+	persistentCongest = 3
+)
+
+// A sentFrameKind identifies the kind of a frame whose loss or
+// acknowledgment must be acted upon.
+type sentFrameKind uint8
+
+const (
+	sentCrypto sentFrameKind = iota
+	sentStream
+	sentResetStream
+	sentStopSending
+	sentMaxData
+	sentMaxStreamData
+	sentMaxStreamsBidi
+	sentMaxStreamsUni
+	sentHandshakeDone
+)
+
+// A sentFrame records a frame sent in a packet.
+type sentFrame struct {
+	kind     sentFrameKind
+	stream   *Stream // for stream frames
+	off, end int64   // range of data, for CRYPTO and STREAM frames
+	fin      bool
+}
+
+// A sentPacket records a packet sent and not yet acknowledged or lost.
+type sentPacket struct {
+	num          int64
+	time         time.Time
+	size         int
+	ackEliciting bool
+	inFlight     bool
+	is0RTT       bool
+	frames       []sentFrame
+}
+
+// rttState estimates the round-trip time (RFC 9002, Section 5).
+type rttState struct {
+	latest   time.Duration
+	smoothed time.Duration
+	variance time.Duration
+	min      time.Duration
+	sampled  bool
+}
+
+func newRTTState() rttState {
+	return rttState{smoothed: initialRTT, variance: initialRTT / 2}
+}
+
+func (r *rttState) update(latest, ackDelay, maxAckDelay time.Duration, handshakeConfirmed bool) {
+	r.latest = latest
+	if !r.sampled {
+		r.sampled = true
+		r.min = latest
+		r.smoothed = latest
+		r.variance = latest / 2
+		return
+	}
+	r.min = min(r.min, latest)
+	if handshakeConfirmed {
+		ackDelay = min(ackDelay, maxAckDelay)
+	}
+	adjusted := latest
+	if latest >= r.min+ackDelay {
+		adjusted = latest - ackDelay
+	}
+	diff := r.smoothed - adjusted
+	if diff < 0 {
+		diff = -diff
+	}
+	r.variance = (3*r.variance + diff) / 4
+	r.smoothed = (7*r.smoothed + adjusted) / 8
+}
+
+// pto returns the probe timeout, without backoff and max_ack_delay.
+func (r *rttState) pto() time.Duration {
+	return r.smoothed + max(4*r.variance, timerGranularity)
+}
+
+// lossDelay returns the time after which a packet sent before an
+// acknowledged packet is considered lost.
+func (r *rttState) lossDelay() time.Duration {
+	return max(9*max(r.latest, r.smoothed)/8, timerGranularity)
+}
+
+// newReno implements the NewReno congestion controller of RFC 9002,
+// Section 7.
+type newReno struct {
+	window        int
+	ssthresh      int
+	inFlight      int
+	ackedBytes    int // bytes acknowledged in congestion avoidance
+	recoveryStart time.Time
+}
+
+func newNewReno() newReno {
+	return newReno{window: initialWindow, ssthresh: 1<<31 - 1}
+}
+
+// canSend reports whether an ack-eliciting packet may be sent.
+func (cc *newReno) canSend() bool {
+	return cc.inFlight+maxDatagramSize <= cc.window
+}
+
+func (cc *newReno) onSent(size int) {
+	cc.inFlight += size
+}
+
+func (cc *newReno) onAcked(p *sentPacket) {
+	cc.inFlight -= p.size
+	if !p.time.After(cc.recoveryStart) {
+		return // in recovery
+	}
+	if cc.window < cc.ssthresh {
+		cc.window += p.size
+		return
+	}
+	cc.ackedBytes += p.size
+	if cc.ackedBytes >= cc.window {
+		cc.ackedBytes -= cc.window
+		cc.window += maxDatagramSize
+	}
+}
+
+// onRemoved removes a packet from the bytes in flight without acting on
+// its loss, when its keys are discarded.
+func (cc *newReno) onRemoved(p *sentPacket) {
+	cc.inFlight -= p.size
+}
+
+// onLost handles the loss of packets, the last of which was sent at
+// sentTime, and collapses the window if persistent is set.
+func (cc *newReno) onLost(lost int, sentTime, now time.Time, persistent bool) {
+	cc.inFlight -= lost
+	if persistent {
+		cc.window = minimumWindow
+		cc.recoveryStart = time.Time{}
+		return
+	}
+	if !sentTime.After(cc.recoveryStart) {
+		return // already in recovery
+	}
+	cc.recoveryStart = now
+	cc.ssthresh = cc.window * lossReductionNum / lossReductionDen
+	cc.window = max(cc.ssthresh, minimumWindow)
+	cc.ackedBytes = 0
+}
@@ -0,0 +1,566 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quic
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// maxStreamWriteBuffer is the maximum amount of data written to a stream
+// and not yet acknowledged by the peer before Write blocks.
+const maxStreamWriteBuffer = 1 << 20
+
+// A Stream is an ordered byte stream of a QUIC connection.
+// A Stream may be used by one reading and one writing goroutine at a time.
+type Stream struct {
+	conn *Conn
+	id   int64
+
+	// The fields below are guarded by conn.mu.
+	canSend, canRecv bool
+	queued           bool // in conn.sendQueue
+	done             bool // removed from conn.streams
+
+	// Sending.
+	send         sendBuffer
+	sendMax      int64 // limit set by the peer
+	writeClosed  bool
+	resetPending bool
+	resetSent    bool
+	resetAcked   bool
+	resetCode    uint64
+	stopRecvd    bool // STOP_SENDING received
+	stopRecvCode uint64
+	writen       notifier
+
+	// Receiving.
+	recv                 recvBuffer
+	recvMax              int64 // limit set for the peer
+	recvWindow           int64
+	maxStreamDataPending bool
+	readClosed           bool
+	stopPending          bool
+	stopCode             uint64
+	resetRecvd           bool // RESET_STREAM received
+	resetRecvCode        uint64
+	readn                notifier
+
+	abortc chan struct{} // closed when the peer aborts the stream
+}
+
+// ID returns the stream ID.
+func (s *Stream) ID() int64 { return s.id }
+
+// IsUnidirectional reports whether s is a unidirectional stream.
+func (s *Stream) IsUnidirectional() bool { return s.id&0x02 != 0 }
+
+// Aborted returns a channel that is closed when the peer resets the
+// stream or asks to stop sending on it.
+func (s *Stream) Aborted() <-chan struct{} { return s.abortc }
+
+// abortedLocked closes the channel returned by Aborted, if it is not
+// already closed.
+func (s *Stream) abortedLocked() {
+	select {
+	case <-s.abortc:
+	default:
+		close(s.abortc)
+	}
+}
+
+// isLocal reports whether the stream ID id was opened by c.
+func (c *Conn) isLocal(id int64) bool {
+	return (id&0x01 == 0) == c.isClient
+}
+
+// initialSendMax returns the flow control limit of a new stream set by
+// the transport parameters of the peer.
+func (c *Conn) initialSendMax(id int64) int64 {
+	switch {
+	case id&0x02 != 0:
+		return c.peerParams.maxStreamDataUni
+	case c.isLocal(id):
+		return c.peerParams.maxStreamDataBidiRemote
+	}
+	return c.peerParams.maxStreamDataBidiLocal
+}
+
+func (c *Conn) newStream(id int64) *Stream {
+	uni := id&0x02 != 0
+	local := c.isLocal(id)
+	s := &Stream{
+		conn:    c,
+		id:      id,
+		canSend: local || !uni,
+		canRecv: !local || !uni,
+		recv:    newRecvBuffer(),
+		sendMax: c.initialSendMax(id),
+		abortc:  make(chan struct{}),
+	}
+	switch {
+	case uni:
+		s.recvWindow = c.localParams.maxStreamDataUni
+	case local:
+		s.recvWindow = c.localParams.maxStreamDataBidiLocal
+	default:
+		s.recvWindow = c.localParams.maxStreamDataBidiRemote
+	}
+	s.recvMax = s.recvWindow
+	c.streams[id] = s
+	return s
+}
+
+// OpenStream opens a bidirectional stream, waiting until the peer allows
+// it. The peer learns of the stream when data is first sent on it.
+func (c *Conn) OpenStream(ctx context.Context) (*Stream, error) {
+	return c.openStream(ctx, 0)
+}
+
+// OpenUniStream opens a unidirectional stream, waiting until the peer
+// allows it.
+func (c *Conn) OpenUniStream(ctx context.Context) (*Stream, error) {
+	return c.openStream(ctx, 1)
+}
+
+func (c *Conn) openStream(ctx context.Context, typ int) (*Stream, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for {
+		if c.closeErr != nil {
+			return nil, c.closeErr
+		}
+		if c.nextLocal[typ] < c.maxLocal[typ] {
+			id := c.nextLocal[typ]<<2 | int64(typ)<<1
+			if !c.isClient {
+				id |= 0x01
+			}
+			c.nextLocal[typ]++
+			return c.newStream(id), nil
+		}
+		ch := c.openn.wait()
+		c.mu.Unlock()
+		select {
+		case <-ch:
+		case <-ctx.Done():
+			c.mu.Lock()
+			return nil, ctx.Err()
+		}
+		c.mu.Lock()
+	}
+}
+
+// AcceptStream waits for and returns the next bidirectional stream
+// opened by the peer.
+func (c *Conn) AcceptStream(ctx context.Context) (*Stream, error) {
+	return c.acceptStream(ctx, 0)
+}
+
+// AcceptUniStream waits for and returns the next unidirectional stream
+// opened by the peer.
+func (c *Conn) AcceptUniStream(ctx context.Context) (*Stream, error) {
+	return c.acceptStream(ctx, 1)
+}
+
+func (c *Conn) acceptStream(ctx context.Context, typ int) (*Stream, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for {
+		if q := c.acceptq[typ]; len(q) > 0 {
+			s := q[0]
+			q[0] = nil
+			c.acceptq[typ] = q[1:]
+			return s, nil
+		}
+		if c.closeErr != nil {
+			return nil, c.closeErr
+		}
+		ch := c.acceptn[typ].wait()
+		c.mu.Unlock()
+		select {
+		case <-ch:
+		case <-ctx.Done():
+			c.mu.Lock()
+			return nil, ctx.Err()
+		}
+		c.mu.Lock()
+	}
+}
+
+// streamForFrame returns the stream of a frame received from the peer,
+// opening the streams of the peer it implies. It returns nil if the
+// stream is already done.
+func (c *Conn) streamForFrame(id int64) (*Stream, error) {
+	if s := c.streams[id]; s != nil {
+		return s, nil
+	}
+	typ := int(id>>1) & 0x01
+	num := id >> 2
+	if c.isLocal(id) {
+		if num >= c.nextLocal[typ] {
+			return nil, &localError{errStreamState, "frame for a stream not yet opened"}
+		}
+		return nil, nil
+	}
+	if num >= c.maxRemote[typ] {
+		return nil, &localError{errStreamLimit, "stream limit exceeded"}
+	}
+	if num < c.nextRemote[typ] {
+		return nil, nil
+	}
+	// Opening a stream opens all the streams of the same type with lower
+	// numbers (RFC 9000, Section 3.2).
+	var s *Stream
+	for ; c.nextRemote[typ] <= num; c.nextRemote[typ]++ {
+		sid := c.nextRemote[typ]<<2 | id&0x03
+		s = c.newStream(sid)
+		c.acceptq[typ] = append(c.acceptq[typ], s)
+	}
+	c.acceptn[typ].notify()
+	return s, nil
+}
+
+// handleStreamFrame handles a STREAM frame.
+func (c *Conn) handleStreamFrame(id, off int64, data []byte, fin bool) error {
+	end := off + int64(len(data))
+	if end > maxVarint {
+		return &localError{errFrameEncoding, "stream offset too large"}
+	}
+	s, err := c.streamForFrame(id)
+	if err != nil || s == nil {
+		return err
+	}
+	if !s.canRecv {
+		return &localError{errStreamState, "STREAM frame for a send-only stream"}
+	}
+	if err := c.checkFinalSize(s, end, fin); err != nil {
+		return err
+	}
+	if end > s.recvMax {
+		return &localError{errFlowControl, "stream flow control limit exceeded"}
+	}
+	if err := c.recvGrowth(s, end); err != nil {
+		return err
+	}
+	if fin {
+		s.recv.final = end
+	}
+	if s.resetRecvd || s.readClosed {
+		// The data is discarded.
+		c.consumed(end - s.recv.max)
+		s.recv.max = max(s.recv.max, end)
+		return nil
+	}
+	s.recv.write(off, data)
+	s.readn.notify()
+	return nil
+}
+
+// checkFinalSize checks that data ending at end is consistent with the
+// final size of the stream s (RFC 9000, Section 4.5).
+func (c *Conn) checkFinalSize(s *Stream, end int64, fin bool) error {
+	if s.recv.final >= 0 && (end > s.recv.final || fin && end != s.recv.final) ||
+		fin && end < s.recv.max {
+		return &localError{errFinalSize, "inconsistent final size"}
+	}
+	return nil
+}
+
+// recvGrowth accounts for data received on s up to offset end against the
+// connection flow control limit.
+func (c *Conn) recvGrowth(s *Stream, end int64) error {
+	if end > s.recv.max {
+		c.recvData += end - s.recv.max
+		if c.recvData > c.recvMaxData {
+			return &localError{errFlowControl, "connection flow control limit exceeded"}
+		}
+	}
+	return nil
+}
+
+// consumed records that n bytes received were read or discarded, and
+// extends the connection flow control limit when needed.
+func (c *Conn) consumed(n int64) {
+	if n <= 0 {
+		return
+	}
+	c.recvConsumed += n
+	window := c.localParams.initialMaxData
+	if c.recvMaxData-c.recvConsumed < window/2 {
+		c.recvMaxData = c.recvConsumed + window
+		c.maxDataPending = true
+	}
+}
+
+// handleResetStream handles a RESET_STREAM frame.
+func (c *Conn) handleResetStream(id int64, code uint64, final int64) error {
+	s, err := c.streamForFrame(id)
+	if err != nil || s == nil {
+		return err
+	}
+	if !s.canRecv {
+		return &localError{errStreamState, "RESET_STREAM for a send-only stream"}
+	}
+	if err := c.checkFinalSize(s, final, true); err != nil {
+		return err
+	}
+	if err := c.recvGrowth(s, final); err != nil {
+		return err
+	}
+	if s.resetRecvd || s.recv.eof() {
+		return nil
+	}
+	s.resetRecvd = true
+	s.resetRecvCode = code
+	if !s.readClosed {
+		c.consumed(final - s.recv.base)
+	} else {
+		c.consumed(final - s.recv.max)
+	}
+	s.recv.final = final
+	s.recv.max = final
+	s.recv.buf = nil
+	s.readn.notify()
+	s.abortedLocked()
+	c.checkStreamDone(s)
+	return nil
+}
+
+// handleStopSending handles a STOP_SENDING frame.
+func (c *Conn) handleStopSending(id int64, code uint64) error {
+	s, err := c.streamForFrame(id)
+	if err != nil || s == nil {
+		return err
+	}
+	if !s.canSend {
+		return &localError{errStreamState, "STOP_SENDING for a receive-only stream"}
+	}
+	if s.stopRecvd {
+		return nil
+	}
+	s.stopRecvd = true
+	s.stopRecvCode = code
+	if !s.send.done() && !s.resetSent && !s.resetPending {
+		s.resetCode = code
+		s.resetPending = true
+		c.queueStream(s)
+	}
+	s.writen.notify()
+	s.abortedLocked()
+	return nil
+}
+
+// handleMaxStreamData handles a MAX_STREAM_DATA frame.
+func (c *Conn) handleMaxStreamData(id, v int64) error {
+	s, err := c.streamForFrame(id)
+	if err != nil || s == nil {
+		return err
+	}
+	if !s.canSend {
+		return &localError{errStreamState, "MAX_STREAM_DATA for a receive-only stream"}
+	}
+	if v > s.sendMax {
+		s.sendMax = v
+		c.queueStream(s)
+	}
+	return nil
+}
+
+// queueStream schedules s for sending if it has something to send.
+func (c *Conn) queueStream(s *Stream) {
+	if !s.queued && s.needsSend(c) {
+		s.queued = true
+		c.sendQueue = append(c.sendQueue, s)
+	}
+}
+
+// queueBlockedStreams schedules the streams whose data was blocked by the
+// connection flow control limit.
+func (c *Conn) queueBlockedStreams() {
+	for _, s := range c.streams {
+		c.queueStream(s)
+	}
+}
+
+// sendLimit returns the offset before which new data may be sent on s.
+func (s *Stream) sendLimit(c *Conn) int64 {
+	return min(s.sendMax, s.send.next+c.maxData-c.dataSent)
+}
+
+// needsSend reports whether s has frames to send.
+func (s *Stream) needsSend(c *Conn) bool {
+	return s.resetPending || s.stopPending || s.maxStreamDataPending ||
+		!s.resetSent && s.send.hasData(s.sendLimit(c))
+}
+
+// checkStreamDone forgets the stream s once both of its directions are
+// done, and allows the peer to open another stream when s was opened by
+// the peer.
+func (c *Conn) checkStreamDone(s *Stream) {
+	if s.done {
+		return
+	}
+	sendDone := !s.canSend || s.send.done() || s.resetAcked
+	recvDone := !s.canRecv || s.recv.eof() || s.resetRecvd || s.readClosed
+	if !sendDone || !recvDone {
+		return
+	}
+	s.done = true
+	delete(c.streams, s.id)
+	if !c.isLocal(s.id) {
+		typ := int(s.id>>1) & 0x01
+		c.maxRemote[typ]++
+		c.maxRemotePending[typ] = true
+	}
+}
+
+// Read reads data from the stream. It returns io.EOF at the end of the
+// stream, and a *StreamError if the peer reset it.
+func (s *Stream) Read(b []byte) (int, error) {
+	c := s.conn
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for {
+		switch {
+		case !s.canRecv:
+			return 0, errors.New("quic: read from a send-only stream")
+		case s.readClosed:
+			return 0, errClosedStream
+		case s.resetRecvd:
+			return 0, &StreamError{Code: s.resetRecvCode, Remote: true}
+		}
+		if n := s.recv.readable(); n > 0 {
+			n = s.recv.read(b)
+			c.consumed(int64(n))
+			if s.recv.final < 0 && s.recvMax-s.recv.base < s.recvWindow/2 {
+				s.recvMax = s.recv.base + s.recvWindow
+				s.maxStreamDataPending = true
+				c.queueStream(s)
+			}
+			if s.recv.eof() {
+				c.checkStreamDone(s)
+			}
+			if c.maxDataPending || s.maxStreamDataPending {
+				c.wake()
+			}
+			return n, nil
+		}
+		if s.recv.eof() {
+			c.checkStreamDone(s)
+			return 0, io.EOF
+		}
+		if c.closeErr != nil {
+			return 0, c.closeErr
+		}
+		ch := s.readn.wait()
+		c.mu.Unlock()
+		<-ch
+		c.mu.Lock()
+	}
+}
+
+// AtEOF reports whether all the data of the stream has been read, so
+// that Read returns io.EOF without blocking.
+func (s *Stream) AtEOF() bool {
+	c := s.conn
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return s.canRecv && !s.readClosed && !s.resetRecvd && s.recv.eof()
+}
+
+// Write writes data to the stream. It blocks while too much data is
+// waiting to be acknowledged by the peer.
+func (s *Stream) Write(b []byte) (int, error) {
+	c := s.conn
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for {
+		switch {
+		case !s.canSend:
+			return n, errors.New("quic: write to a receive-only stream")
+		case s.stopRecvd:
+			return n, &StreamError{Code: s.stopRecvCode, Remote: true}
+		case s.writeClosed || s.resetPending || s.resetSent:
+			return n, errClosedStream
+		case c.closeErr != nil:
+			return n, c.closeErr
+		case len(b) == 0:
+			return n, nil
+		}
+		if room := maxStreamWriteBuffer - s.send.buffered(); room > 0 {
+			m := min(room, len(b))
+			s.send.write(b[:m])
+			b = b[m:]
+			n += m
+			c.queueStream(s)
+			c.wake()
+			continue
+		}
+		ch := s.writen.wait()
+		c.mu.Unlock()
+		<-ch
+		c.mu.Lock()
+	}
+}
+
+// Close ends the sending direction of the stream. Data written before is
+// still delivered. Close does nothing on a receive-only stream.
+func (s *Stream) Close() error {
+	c := s.conn
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !s.canSend || s.writeClosed || s.resetPending || s.resetSent {
+		return nil
+	}
+	s.writeClosed = true
+	s.send.fin = true
+	c.queueStream(s)
+	c.wake()
+	return nil
+}
+
+// CloseRead asks the peer to stop sending on the stream with the given
+// application error code, and discards the data received.
+func (s *Stream) CloseRead(code uint64) {
+	c := s.conn
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !s.canRecv || s.readClosed {
+		return
+	}
+	s.readClosed = true
+	if !s.recv.eof() && !s.resetRecvd {
+		c.consumed(s.recv.max - s.recv.base)
+		s.recv.buf = nil
+		if s.recv.final < 0 {
+			s.stopPending = true
+			s.stopCode = code
+			s.maxStreamDataPending = false
+			c.queueStream(s)
+			c.wake()
+		}
+	}
+	s.readn.notify()
+	c.checkStreamDone(s)
+}
+
+// Reset abandons the sending direction of the stream with the given
+// application error code. Data written and not yet delivered may be lost.
+func (s *Stream) Reset(code uint64) {
+	c := s.conn
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !s.canSend || s.resetPending || s.resetSent || s.send.done() {
+		return
+	}
+	s.resetPending = true
+	s.resetCode = code
+	s.send.lost = nil
+	c.queueStream(s)
+	c.wake()
+	s.writen.notify()
+}
@@ -22,6 +22,8 @@ var http2errRequestCanceled = errors.New("net/http: request canceled")
 
 var http2goAwayTimeout = 1 * time.Second
 
+var http2disableExtendedConnectProtocol = true
+
 const http2NextProtoTLS = "h2"
 
 type http2Transport struct {
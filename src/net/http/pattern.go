@@ -205,6 +205,92 @@ func pathUnescape(path string) string {
 	return u
 }
 
+// PatternPath returns the path matched by the [ServeMux] pattern
+// with each wildcard replaced by its value in values.
+// The method and host of the pattern, if any, are ignored.
+//
+// Values are escaped so that matching the path against the pattern yields
+// the same values from [Request.PathValue]. The value of a "{name}"
+// wildcard is a single path segment: any slash in it is escaped. The value
+// of a "{name...}" wildcard may contain slashes separating several segments.
+// PatternPath returns an error if a wildcard has no value, if a value
+// does not correspond to a wildcard in the pattern, or if a value
+// contains an empty, "." or ".." segment, since such a path would
+// be redirected rather than matched.
+func PatternPath(pattern string, values map[string]string) (string, error) {
+	p, err := parsePattern(pattern)
+	if err != nil {
+		return "", fmt.Errorf("parsing %q: %w", pattern, err)
+	}
+	var b strings.Builder
+	nwild := 0
+	for _, seg := range p.segments {
+		if !seg.wild {
+			b.WriteByte('/')
+			if seg.s != "/" { // "/" is "{$}"
+				b.WriteString(url.PathEscape(seg.s))
+			}
+			continue
+		}
+		if seg.s == "" {
+			// Trailing slash.
+			b.WriteByte('/')
+			continue
+		}
+		nwild++
+		v, ok := values[seg.s]
+		if !ok {
+			return "", fmt.Errorf("missing value for wildcard %q", seg.s)
+		}
+		if !seg.multi {
+			if err := checkPathSegment(seg.s, v, false); err != nil {
+				return "", err
+			}
+			b.WriteByte('/')
+			b.WriteString(url.PathEscape(v))
+			continue
+		}
+		elems := strings.Split(v, "/")
+		for i, elem := range elems {
+			// The final segment may be empty, giving a trailing slash.
+			if err := checkPathSegment(seg.s, elem, i == len(elems)-1); err != nil {
+				return "", err
+			}
+			b.WriteByte('/')
+			b.WriteString(url.PathEscape(elem))
+		}
+	}
+	if nwild < len(values) {
+		var unknown string
+		for name := range values {
+			if !p.hasWildcard(name) && (unknown == "" || name < unknown) {
+				unknown = name
+			}
+		}
+		return "", fmt.Errorf("no wildcard named %q in pattern %q", unknown, pattern)
+	}
+	return b.String(), nil
+}
+
+// checkPathSegment reports an error if the segment elem of the value
+// of the named wildcard would not survive path cleaning.
+func checkPathSegment(name, elem string, emptyOK bool) error {
+	if (elem == "" && !emptyOK) || elem == "." || elem == ".." {
+		return fmt.Errorf("invalid value for wildcard %q: path segment %q", name, elem)
+	}
+	return nil
+}
+
+// hasWildcard reports whether p has a wildcard with the given name.
+func (p *pattern) hasWildcard(name string) bool {
+	for _, seg := range p.segments {
+		if seg.wild && seg.s != "" && seg.s == name {
+			return true
+		}
+	}
+	return false
+}
+
 // relationship is a relationship between two patterns, p1 and p2.
 type relationship string
 
@@ -5,6 +5,7 @@
 package http
 
 import (
+	"net/url"
 	"slices"
 	"strings"
 	"testing"
@@ -171,6 +172,85 @@ func mustParsePattern(tb testing.TB, s string) *pattern {
 	return p
 }
 
+func TestPatternPath(t *testing.T) {
+	for _, test := range []struct {
+		pattern string
+		values  map[string]string
+		want    string
+	}{
+		{"/", nil, "/"},
+		{"/{$}", nil, "/"},
+		{"GET example.com/a/b/", nil, "/a/b/"},
+		{"/a/{$}", nil, "/a/"},
+		{"/%61%2Fb", nil, "/a%2Fb"},
+		{"/a/{x}", map[string]string{"x": "b"}, "/a/b"},
+		{"/a/{x}/c", map[string]string{"x": "b/c d"}, "/a/b%2Fc%20d/c"},
+		{"/a/{x}/{y}/", map[string]string{"x": "1", "y": "?#%"}, "/a/1/%3F%23%25/"},
+		{"/a/{rest...}", map[string]string{"rest": ""}, "/a/"},
+		{"/a/{rest...}", map[string]string{"rest": "b/c d/"}, "/a/b/c%20d/"},
+	} {
+		got, err := PatternPath(test.pattern, test.values)
+		if err != nil {
+			t.Errorf("PatternPath(%q, %v): %v", test.pattern, test.values, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("PatternPath(%q, %v) = %q, want %q", test.pattern, test.values, got, test.want)
+			continue
+		}
+
+		// The path matches the pattern, with the same values.
+		pat := mustParsePattern(t, test.pattern)
+		var root routingNode
+		root.addPattern(pat, NotFoundHandler())
+		u, err := url.Parse(got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		method := pat.method
+		if method == "" {
+			method = "GET"
+		}
+		n, matches := root.match(pat.host, method, u.EscapedPath())
+		if n == nil {
+			t.Errorf("PatternPath(%q, %v) = %q, which does not match the pattern", test.pattern, test.values, got)
+			continue
+		}
+		var names []string
+		for _, seg := range pat.segments {
+			if seg.wild && seg.s != "" {
+				names = append(names, seg.s)
+			}
+		}
+		for i, name := range names {
+			if matches[i] != test.values[name] {
+				t.Errorf("PatternPath(%q, %v) = %q, which matches %s=%q", test.pattern, test.values, got, name, matches[i])
+			}
+		}
+	}
+}
+
+func TestPatternPathError(t *testing.T) {
+	for _, test := range []struct {
+		pattern string
+		values  map[string]string
+		want    string
+	}{
+		{"/{x", nil, "bad wildcard segment"},
+		{"/a/{x}", nil, `missing value for wildcard "x"`},
+		{"/a/{x}", map[string]string{"x": ""}, `invalid value for wildcard "x"`},
+		{"/a/{x}", map[string]string{"x": ".."}, `invalid value for wildcard "x"`},
+		{"/a/{x...}", map[string]string{"x": "b/./c"}, `invalid value for wildcard "x"`},
+		{"/a/{x...}", map[string]string{"x": "b//c"}, `invalid value for wildcard "x"`},
+		{"/a/{x}", map[string]string{"x": "b", "z": "c", "y": "d"}, `no wildcard named "y"`},
+	} {
+		_, err := PatternPath(test.pattern, test.values)
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("PatternPath(%q, %v): got error %v, want error containing %q", test.pattern, test.values, err, test.want)
+		}
+	}
+}
+
 func TestCompareMethods(t *testing.T) {
 	for _, test := range []struct {
 		p1, p2 string
@@ -167,6 +167,14 @@ type Request struct {
 	// and Connection are automatically written when needed and
 	// values in Header may be ignored. See the documentation
 	// for the Request.Write method.
+	//
+	// A CONNECT request with a ":protocol" key in Header is an
+	// extended CONNECT request (RFC 8441), which opens a tunnel
+	// for the named protocol, such as "websocket", over a stream
+	// of an HTTP/2 connection. The Transport sends such requests
+	// only over HTTP/2, and only to servers which support them.
+	// The HTTP/2 server sets the ":protocol" key of the Header of
+	// extended CONNECT requests it receives.
 	Header Header
 
 	// Body is the request's body.
@@ -526,6 +534,11 @@ func (r *Request) multipartReader(allowMixed bool) (*multipart.Reader, error) {
 	return multipart.NewReader(r.Body, boundary), nil
 }
 
+// isExtendedConnect reports whether r is an extended CONNECT request.
+func (r *Request) isExtendedConnect() bool {
+	return r.Method == "CONNECT" && r.Header.Get(":protocol") != ""
+}
+
 // isH2Upgrade reports whether r represents the http2 "client preface"
 // magic string.
 func (r *Request) isH2Upgrade() bool {
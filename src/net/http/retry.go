@@ -0,0 +1,347 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// HTTP client retries and hedging.
+
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http/httptrace"
+	"strconv"
+	"time"
+)
+
+// A RetryPolicy configures how a [Client] retries requests that fail
+// with a transient error, and how it hedges slow requests.
+//
+// The Client retries or hedges only requests that are safe to repeat:
+// requests with method GET, HEAD, OPTIONS, or TRACE, or with an
+// Idempotency-Key or X-Idempotency-Key header, and whose body is nil,
+// [NoBody], or can be obtained again with [Request.GetBody].
+//
+// By default, an attempt is retried if it fails with a network error,
+// such as a connection that is refused, reset, or closed before the
+// response is complete, or with a response with status 429 (Too Many
+// Requests), 502 (Bad Gateway), 503 (Service Unavailable), or 504
+// (Gateway Timeout). Other errors, such as an invalid request or a
+// failure to verify the server's certificate, are not retried.
+// The Client does not retry once the request's context is done or
+// the Client's Timeout has expired, or if the retry delay would
+// extend past the context's deadline or the Client's Timeout.
+//
+// Each attempt is reported to the [httptrace.ClientTrace.RetryAttempt]
+// hook of the request's context.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of times a request is sent,
+	// including the first attempt. If MaxAttempts is less than 2,
+	// requests are neither retried nor hedged.
+	MaxAttempts int
+
+	// MinBackoff and MaxBackoff bound the delay before a retry.
+	// The delay before the nth retry is chosen at random between
+	// zero and the smaller of MaxBackoff and MinBackoff * 2^(n-1).
+	// If MinBackoff is zero, 100 milliseconds is used.
+	// If MaxBackoff is zero, 10 seconds is used.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// MaxRetryAfter is the longest delay requested by the Retry-After
+	// header of a 429 or 503 response that the Client waits for before
+	// retrying. The Client waits for the requested delay instead of
+	// its backoff delay, and returns a response requesting a longer
+	// delay without retrying. If zero, one minute is used.
+	MaxRetryAfter time.Duration
+
+	// HedgeDelay, if positive, enables hedging: when an attempt
+	// has received no response after HedgeDelay, the Client sends
+	// another attempt without canceling the earlier ones, up to a
+	// total of MaxAttempts. The first response which is not retried
+	// is returned, and the other attempts are canceled. An attempt
+	// which fails is retried immediately, without a backoff delay.
+	HedgeDelay time.Duration
+
+	// ShouldRetry, if non-nil, reports whether to retry an attempt
+	// which returned the response resp or the error err, replacing
+	// the default conditions. Exactly one of resp and err is non-nil.
+	// ShouldRetry must not read or close the response body.
+	ShouldRetry func(resp *Response, err error) bool
+}
+
+func (p *RetryPolicy) shouldRetry(resp *Response, err error) bool {
+	if p.ShouldRetry != nil {
+		return p.ShouldRetry(resp, err)
+	}
+	if err != nil {
+		return isTransientError(err)
+	}
+	switch resp.StatusCode {
+	case StatusTooManyRequests, StatusBadGateway, StatusServiceUnavailable, StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// isTransientError reports whether err, returned by an attempt,
+// is a network error that may not recur if the request is sent again.
+func isTransientError(err error) bool {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTemporary || dnsErr.IsTimeout
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// backoff returns the delay before the given attempt,
+// which is at least 2.
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	minBackoff := p.MinBackoff
+	if minBackoff <= 0 {
+		minBackoff = 100 * time.Millisecond
+	}
+	maxBackoff := p.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = 10 * time.Second
+	}
+	limit := maxBackoff
+	if shift := attempt - 2; shift < 62 && minBackoff<<shift>>shift == minBackoff {
+		limit = min(limit, minBackoff<<shift)
+	}
+	if limit <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(limit) + 1))
+}
+
+// retryDelay returns the delay before retrying after resp,
+// and whether to retry.
+func (p *RetryPolicy) retryDelay(attempt int, resp *Response) (time.Duration, bool) {
+	if resp != nil && (resp.StatusCode == StatusTooManyRequests || resp.StatusCode == StatusServiceUnavailable) {
+		if d, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+			maxRetryAfter := p.MaxRetryAfter
+			if maxRetryAfter <= 0 {
+				maxRetryAfter = time.Minute
+			}
+			return d, d <= maxRetryAfter
+		}
+	}
+	return p.backoff(attempt), true
+}
+
+// parseRetryAfter parses the value of a Retry-After header,
+// which is either a number of seconds or an HTTP date.
+func parseRetryAfter(v string, now time.Time) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if n, err := strconv.ParseUint(v, 10, 31); err == nil {
+		return time.Duration(n) * time.Second, true
+	}
+	if t, err := ParseTime(v); err == nil {
+		return max(0, t.Sub(now)), true
+	}
+	return 0, false
+}
+
+// attemptRequest returns a copy of req to send as the given attempt.
+// The copy has its own header, since sending a request may modify it.
+// Attempts after the first get a new body from req.GetBody.
+func attemptRequest(req *Request, attempt int) (*Request, error) {
+	r := new(Request)
+	*r = *req
+	r.Header = req.Header.Clone()
+	if attempt > 1 && req.Body != nil && req.Body != NoBody {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		r.Body = body
+	}
+	return r, nil
+}
+
+// discardResponse closes the body of a response that is not returned
+// to the caller, reading a little of it first to allow the connection
+// to be reused.
+func discardResponse(resp *Response) {
+	if resp == nil {
+		return
+	}
+	io.CopyN(io.Discard, resp.Body, 4<<10)
+	resp.Body.Close()
+}
+
+// sendWithRetries sends req as c.send does, retrying or hedging it
+// according to c.RetryPolicy.
+func (c *Client) sendWithRetries(req *Request, deadline time.Time) (resp *Response, didTimeout func() bool, err error) {
+	p := c.RetryPolicy
+	if p == nil || p.MaxAttempts < 2 || !req.isReplayable() {
+		return c.send(req, deadline)
+	}
+	if p.HedgeDelay > 0 {
+		return c.sendHedged(req, deadline, p)
+	}
+
+	ctx := req.Context()
+	trace := httptrace.ContextClientTrace(ctx)
+	for attempt := 1; ; attempt++ {
+		r, err := attemptRequest(req, attempt)
+		if err != nil {
+			return nil, alwaysFalse, err
+		}
+		resp, didTimeout, err = c.send(r, deadline)
+		if attempt >= p.MaxAttempts || ctx.Err() != nil || (err != nil && didTimeout()) || !p.shouldRetry(resp, err) {
+			return resp, didTimeout, err
+		}
+		delay, ok := p.retryDelay(attempt+1, resp)
+		if !ok {
+			return resp, didTimeout, err
+		}
+		retryAt := time.Now().Add(delay)
+		if ctxDeadline, ok := ctx.Deadline(); ok && retryAt.After(ctxDeadline) {
+			return resp, didTimeout, err
+		}
+		if !deadline.IsZero() && retryAt.After(deadline) {
+			return resp, didTimeout, err
+		}
+		if trace != nil && trace.RetryAttempt != nil {
+			info := httptrace.RetryAttemptInfo{Attempt: attempt + 1, Delay: delay, Err: err}
+			if resp != nil {
+				info.StatusCode = resp.StatusCode
+			}
+			trace.RetryAttempt(info)
+		}
+		if delay > 0 {
+			t := time.NewTimer(delay)
+			select {
+			case <-t.C:
+			case <-ctx.Done():
+				t.Stop()
+				return resp, didTimeout, err
+			}
+		}
+		discardResponse(resp)
+	}
+}
+
+// A hedgedResult is the result of an attempt of a hedged request.
+type hedgedResult struct {
+	resp       *Response
+	didTimeout func() bool
+	err        error
+	attempt    int // index into the attempts' cancel funcs
+}
+
+// sendHedged sends req, starting a new attempt each time p.HedgeDelay
+// passes without a response, or an attempt fails.
+func (c *Client) sendHedged(req *Request, deadline time.Time, p *RetryPolicy) (*Response, func() bool, error) {
+	ctx := req.Context()
+	trace := httptrace.ContextClientTrace(ctx)
+	results := make(chan hedgedResult, p.MaxAttempts)
+	cancels := make([]context.CancelFunc, 0, p.MaxAttempts)
+	sent, inFlight := 0, 0
+	start := func(prev *hedgedResult) error {
+		r, err := attemptRequest(req, sent+1)
+		if err != nil {
+			return err
+		}
+		sent++
+		inFlight++
+		if sent > 1 && trace != nil && trace.RetryAttempt != nil {
+			info := httptrace.RetryAttemptInfo{Attempt: sent, Hedged: prev == nil}
+			if prev != nil {
+				info.Err = prev.err
+				if prev.resp != nil {
+					info.StatusCode = prev.resp.StatusCode
+				}
+			}
+			trace.RetryAttempt(info)
+		}
+		actx, cancel := context.WithCancel(ctx)
+		cancels = append(cancels, cancel)
+		r = r.WithContext(actx)
+		attempt := len(cancels) - 1
+		go func() {
+			resp, didTimeout, err := c.send(r, deadline)
+			results <- hedgedResult{resp, didTimeout, err, attempt}
+		}()
+		return nil
+	}
+	// finish returns res, canceling the other attempts.
+	finish := func(res hedgedResult) (*Response, func() bool, error) {
+		for i, cancel := range cancels {
+			if i != res.attempt {
+				cancel()
+			}
+		}
+		go func(n int) {
+			for range n {
+				discardResponse((<-results).resp)
+			}
+		}(inFlight)
+		if res.resp != nil {
+			res.resp.Body = &cancelOnCloseBody{ReadCloser: res.resp.Body, cancel: cancels[res.attempt]}
+		} else {
+			cancels[res.attempt]()
+		}
+		return res.resp, res.didTimeout, res.err
+	}
+
+	if err := start(nil); err != nil {
+		req.closeBody()
+		return nil, alwaysFalse, err
+	}
+	timer := time.NewTimer(p.HedgeDelay)
+	defer timer.Stop()
+	var last *hedgedResult // latest retryable result, not yet discarded
+	for {
+		select {
+		case res := <-results:
+			inFlight--
+			retry := ctx.Err() == nil && !(res.err != nil && res.didTimeout()) && p.shouldRetry(res.resp, res.err)
+			if last != nil {
+				discardResponse(last.resp)
+			}
+			if !retry || (sent >= p.MaxAttempts && inFlight == 0) {
+				return finish(res)
+			}
+			last = &res
+			if sent < p.MaxAttempts {
+				if err := start(last); err != nil {
+					return finish(*last)
+				}
+				timer.Reset(p.HedgeDelay)
+			}
+		case <-timer.C:
+			if sent < p.MaxAttempts {
+				if err := start(nil); err != nil {
+					// Keep waiting for the attempts in flight.
+					continue
+				}
+				timer.Reset(p.HedgeDelay)
+			}
+		}
+	}
+}
+
+// cancelOnCloseBody is the body of the response to a hedged request.
+// Closing it cancels the context of the attempt that received it.
+type cancelOnCloseBody struct {
+	io.ReadCloser
+	cancel func()
+}
+
+func (b *cancelOnCloseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,304 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http_test
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	. "net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClientRetry(t *testing.T) { run(t, testClientRetry) }
+func testClientRetry(t *testing.T, mode testMode) {
+	var attempts atomic.Int32
+	cst := newClientServerTest(t, mode, HandlerFunc(func(w ResponseWriter, r *Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != "request body" {
+			t.Errorf("attempt %v: request body = %q, want %q", attempts.Load()+1, body, "request body")
+		}
+		if attempts.Add(1) < 3 {
+			w.WriteHeader(StatusServiceUnavailable)
+			return
+		}
+		io.WriteString(w, "ok")
+	}))
+	c := cst.c
+	c.RetryPolicy = &RetryPolicy{
+		MaxAttempts: 3,
+		MinBackoff:  time.Millisecond,
+	}
+	var traced []httptrace.RetryAttemptInfo
+	ctx := httptrace.WithClientTrace(context.Background(), &httptrace.ClientTrace{
+		RetryAttempt: func(info httptrace.RetryAttemptInfo) {
+			traced = append(traced, info)
+		},
+	})
+	req, _ := NewRequestWithContext(ctx, "PUT", cst.ts.URL, strings.NewReader("request body"))
+	req.Header.Set("Idempotency-Key", "1")
+	res, err := c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 200 {
+		t.Fatalf("StatusCode = %v, want 200", res.StatusCode)
+	}
+	if got := attempts.Load(); got != 3 {
+		t.Errorf("server saw %v attempts, want 3", got)
+	}
+	if len(traced) != 2 {
+		t.Fatalf("RetryAttempt called %v times, want 2", len(traced))
+	}
+	for i, info := range traced {
+		if info.Attempt != i+2 || info.StatusCode != StatusServiceUnavailable || info.Hedged {
+			t.Errorf("RetryAttempt #%v: %+v, want Attempt %v, StatusCode 503", i, info, i+2)
+		}
+	}
+}
+
+func TestClientRetryNotReplayable(t *testing.T) { run(t, testClientRetryNotReplayable) }
+func testClientRetryNotReplayable(t *testing.T, mode testMode) {
+	var attempts atomic.Int32
+	cst := newClientServerTest(t, mode, HandlerFunc(func(w ResponseWriter, r *Request) {
+		attempts.Add(1)
+		w.WriteHeader(StatusServiceUnavailable)
+	}))
+	c := cst.c
+	c.RetryPolicy = &RetryPolicy{
+		MaxAttempts: 3,
+		MinBackoff:  time.Millisecond,
+	}
+	res, err := c.Post(cst.ts.URL, "text/plain", strings.NewReader("body"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != StatusServiceUnavailable {
+		t.Errorf("StatusCode = %v, want 503", res.StatusCode)
+	}
+	if got := attempts.Load(); got != 1 {
+		t.Errorf("server saw %v attempts of POST, want 1", got)
+	}
+}
+
+func TestClientRetryAfter(t *testing.T) { run(t, testClientRetryAfter) }
+func testClientRetryAfter(t *testing.T, mode testMode) {
+	var attempts atomic.Int32
+	cst := newClientServerTest(t, mode, HandlerFunc(func(w ResponseWriter, r *Request) {
+		switch attempts.Add(1) {
+		case 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(StatusTooManyRequests)
+		case 2:
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(StatusTooManyRequests)
+		default:
+			t.Errorf("unexpected attempt after Retry-After: 3600")
+		}
+	}))
+	c := cst.c
+	c.RetryPolicy = &RetryPolicy{
+		MaxAttempts: 5,
+		MinBackoff:  time.Hour, // Retry-After takes precedence.
+	}
+	res, err := c.Get(cst.ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != StatusTooManyRequests || res.Header.Get("Retry-After") != "3600" {
+		t.Errorf("got response %v with Retry-After %q, want 429 with Retry-After 3600", res.StatusCode, res.Header.Get("Retry-After"))
+	}
+	if got := attempts.Load(); got != 2 {
+		t.Errorf("server saw %v attempts, want 2", got)
+	}
+}
+
+func TestClientRetryRespectsDeadline(t *testing.T) { run(t, testClientRetryRespectsDeadline) }
+func testClientRetryRespectsDeadline(t *testing.T, mode testMode) {
+	var attempts atomic.Int32
+	cst := newClientServerTest(t, mode, HandlerFunc(func(w ResponseWriter, r *Request) {
+		attempts.Add(1)
+		w.WriteHeader(StatusBadGateway)
+	}))
+	c := cst.c
+	c.Timeout = time.Minute
+	c.RetryPolicy = &RetryPolicy{
+		MaxAttempts: 3,
+		MinBackoff:  time.Hour,
+		MaxBackoff:  time.Hour,
+	}
+	// The backoff delay is random, so try until a delay
+	// past the Client's Timeout is chosen.
+	for range 10 {
+		attempts.Store(0)
+		start := time.Now()
+		res, err := c.Get(cst.ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if d := time.Since(start); d > c.Timeout {
+			t.Fatalf("Get took %v, longer than the Client's Timeout", d)
+		}
+		if res.StatusCode != StatusBadGateway {
+			t.Errorf("StatusCode = %v, want 502", res.StatusCode)
+		}
+		if attempts.Load() == 1 {
+			return
+		}
+	}
+	t.Errorf("request retried despite backoff past the Client's Timeout")
+}
+
+func TestClientRetryShouldRetry(t *testing.T) { run(t, testClientRetryShouldRetry) }
+func testClientRetryShouldRetry(t *testing.T, mode testMode) {
+	var attempts atomic.Int32
+	cst := newClientServerTest(t, mode, HandlerFunc(func(w ResponseWriter, r *Request) {
+		attempts.Add(1)
+		w.WriteHeader(StatusConflict)
+	}))
+	c := cst.c
+	c.RetryPolicy = &RetryPolicy{
+		MaxAttempts: 4,
+		MinBackoff:  time.Millisecond,
+		ShouldRetry: func(res *Response, err error) bool {
+			return res != nil && res.StatusCode == StatusConflict
+		},
+	}
+	res, err := c.Get(cst.ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if got := attempts.Load(); got != 4 {
+		t.Errorf("server saw %v attempts, want 4", got)
+	}
+}
+
+func TestClientRetryNetworkError(t *testing.T) {
+	run(t, testClientRetryNetworkError, []testMode{http1Mode})
+}
+func testClientRetryNetworkError(t *testing.T, mode testMode) {
+	var attempts atomic.Int32
+	cst := newClientServerTest(t, mode, HandlerFunc(func(w ResponseWriter, r *Request) {
+		if attempts.Add(1) < 3 {
+			conn, _, err := w.(Hijacker).Hijack()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			conn.Close()
+			return
+		}
+		io.WriteString(w, "ok")
+	}))
+	c := cst.c
+	c.RetryPolicy = &RetryPolicy{
+		MaxAttempts: 3,
+		MinBackoff:  time.Millisecond,
+	}
+	res, err := c.Get(cst.ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if got := attempts.Load(); got != 3 {
+		t.Errorf("server saw %v attempts, want 3", got)
+	}
+}
+
+func TestClientRetryCertificateError(t *testing.T) {
+	run(t, testClientRetryCertificateError, []testMode{https1Mode, http2Mode})
+}
+func testClientRetryCertificateError(t *testing.T, mode testMode) {
+	var conns atomic.Int32
+	cst := newClientServerTest(t, mode, HandlerFunc(func(w ResponseWriter, r *Request) {
+		t.Error("handler called")
+	}), optQuietLog, func(ts *httptest.Server) {
+		ts.Config.ConnState = func(c net.Conn, state ConnState) {
+			if state == StateNew {
+				conns.Add(1)
+			}
+		}
+	})
+	// The client does not trust the test server's certificate.
+	c := &Client{
+		Transport: &Transport{},
+		RetryPolicy: &RetryPolicy{
+			MaxAttempts: 3,
+			MinBackoff:  time.Millisecond,
+		},
+	}
+	defer c.CloseIdleConnections()
+	_, err := c.Get(cst.ts.URL)
+	var verifyErr *tls.CertificateVerificationError
+	if !errors.As(err, &verifyErr) {
+		t.Fatalf("Get: %v, want a certificate verification error", err)
+	}
+	if got := conns.Load(); got != 1 {
+		t.Errorf("server saw %v connections, want 1", got)
+	}
+}
+
+func TestClientHedge(t *testing.T) { run(t, testClientHedge) }
+func testClientHedge(t *testing.T, mode testMode) {
+	var (
+		attempts atomic.Int32
+		canceled = make(chan struct{})
+	)
+	cst := newClientServerTest(t, mode, HandlerFunc(func(w ResponseWriter, r *Request) {
+		if attempts.Add(1) == 1 {
+			// The first attempt is slow, and is canceled
+			// when the hedged attempt succeeds.
+			<-r.Context().Done()
+			close(canceled)
+			return
+		}
+		io.WriteString(w, "hedged")
+	}))
+	c := cst.c
+	c.RetryPolicy = &RetryPolicy{
+		MaxAttempts: 2,
+		HedgeDelay:  10 * time.Millisecond,
+	}
+	var (
+		mu     sync.Mutex
+		traced []httptrace.RetryAttemptInfo
+	)
+	ctx := httptrace.WithClientTrace(context.Background(), &httptrace.ClientTrace{
+		RetryAttempt: func(info httptrace.RetryAttemptInfo) {
+			mu.Lock()
+			defer mu.Unlock()
+			traced = append(traced, info)
+		},
+	})
+	req, _ := NewRequestWithContext(ctx, "GET", cst.ts.URL, nil)
+	res, err := c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil || string(body) != "hedged" {
+		t.Errorf("response body = %q, %v; want %q", body, err, "hedged")
+	}
+	<-canceled
+	mu.Lock()
+	defer mu.Unlock()
+	if len(traced) != 1 || traced[0].Attempt != 2 || !traced[0].Hedged {
+		t.Errorf("RetryAttempt calls: %+v, want one hedged attempt 2", traced)
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"fmt"
 	"internal/godebug"
 	"io"
+	"iter"
 	"log"
 	"math/rand"
 	"net"
@@ -1810,7 +1811,7 @@ func (c *conn) closeWriteAndWait() {
 // so that those can't be overridden with alternate implementations.
 func validNextProto(proto string) bool {
 	switch proto {
-	case "", "http/1.1", "http/1.0":
+	case "", "http/1.1", "http/1.0", nextProtoUnencryptedHTTP2:
 		return false
 	}
 	return true
@@ -1963,6 +1964,16 @@ func (c *conn) serve(ctx context.Context) {
 	c.bufr = newBufioReader(c.r)
 	c.bufw = newBufioWriterSize(checkConnErrorWriter{c}, 4<<10)
 
+	protos := c.server.protocols()
+	if c.tlsState == nil && protos.UnencryptedHTTP2() {
+		if c.maybeServeUnencryptedHTTP2(ctx) {
+			return
+		}
+	}
+	if !protos.HTTP1() {
+		return
+	}
+
 	for {
 		w, err := c.readRequest(ctx)
 		if c.r.remain != c.server.initialReadLimitSize() {
@@ -2082,6 +2093,41 @@ func (c *conn) serve(ctx context.Context) {
 	}
 }
 
+// maybeServeUnencryptedHTTP2 hands c off to the HTTP/2 server if it
+// begins with the HTTP/2 connection preface, and reports whether it did.
+// Otherwise, any bytes read remain buffered in c.bufr for HTTP/1.
+func (c *conn) maybeServeUnencryptedHTTP2(ctx context.Context) bool {
+	fn, ok := c.server.TLSNextProto[nextProtoUnencryptedHTTP2]
+	if !ok {
+		return false
+	}
+	hasPreface := func(preface string) bool {
+		c.r.setReadLimit(int64(len(preface) - c.bufr.Buffered()))
+		got, err := c.bufr.Peek(len(preface))
+		c.r.setInfiniteReadLimit()
+		return err == nil && string(got) == preface
+	}
+	const preface = "PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n"
+	if d := c.server.readHeaderTimeout(); d > 0 {
+		c.rwc.SetReadDeadline(time.Now().Add(d))
+	}
+	// Peek at a prefix of the preface first, which is no longer than
+	// any HTTP/1 request, so as not to block waiting for bytes which
+	// an HTTP/1 client will never send.
+	ok = hasPreface(preface[:len("PRI * HTTP/2.0")]) && hasPreface(preface)
+	c.rwc.SetReadDeadline(time.Time{})
+	if !ok {
+		return false
+	}
+	// The HTTP/2 server is told that the preface has been read,
+	// and the read limits above leave nothing else buffered.
+	c.bufr.Discard(len(preface))
+	h := initALPNRequest{ctx, nil, serverHandler{c.server}}
+	c.setState(c.rwc, StateActive, skipHooks)
+	fn(c.server, unencryptedTLSConn(c.rwc), h)
+	return true
+}
+
 func (w *response) sendExpectationFailed() {
 	// TODO(bradfitz): let ServeHTTP handlers handle
 	// requests with non-standard expectation[s]? Seems
@@ -2437,7 +2483,7 @@ type ServeMux struct {
 	mu       sync.RWMutex
 	tree     routingNode
 	index    routingIndex
-	patterns []*pattern  // TODO(jba): remove if possible
+	patterns []muxEntry  // registered patterns, in registration order
 	mux121   serveMux121 // used only when GODEBUG=httpmuxgo121=1
 }
 
@@ -2748,10 +2794,9 @@ func (mux *ServeMux) registerErr(patstr string, handler Handler) error {
 	if f, ok := handler.(HandlerFunc); ok && f == nil {
 		return errors.New("http: nil handler")
 	}
-
-	pat, err := parsePattern(patstr)
+	pat, err := parseMuxPattern(patstr)
 	if err != nil {
-		return fmt.Errorf("parsing %q: %w", patstr, err)
+		return err
 	}
 
 	// Get the caller's location, for better conflict error messages.
@@ -2765,21 +2810,96 @@ func (mux *ServeMux) registerErr(patstr string, handler Handler) error {
 
 	mux.mu.Lock()
 	defer mux.mu.Unlock()
-	// Check for conflict.
-	if err := mux.index.possiblyConflictingPatterns(pat, func(pat2 *pattern) error {
+	if err := mux.checkConflicts(pat); err != nil {
+		return err
+	}
+	mux.tree.addPattern(pat, handler)
+	mux.index.addPattern(pat)
+	mux.patterns = append(mux.patterns, muxEntry{h: handler, pattern: patstr})
+	return nil
+}
+
+func parseMuxPattern(patstr string) (*pattern, error) {
+	if patstr == "" {
+		return nil, errors.New("http: invalid pattern")
+	}
+	pat, err := parsePattern(patstr)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %q: %w", patstr, err)
+	}
+	return pat, nil
+}
+
+// checkConflicts returns an error if pat conflicts with a registered pattern.
+// mux.mu must be held.
+func (mux *ServeMux) checkConflicts(pat *pattern) error {
+	return mux.index.possiblyConflictingPatterns(pat, func(pat2 *pattern) error {
 		if pat.conflictsWith(pat2) {
 			d := describeConflict(pat, pat2)
+			if pat.loc == "" {
+				return fmt.Errorf("pattern %q conflicts with pattern %q (registered at %s):\n%s",
+					pat, pat2, pat2.loc, d)
+			}
 			return fmt.Errorf("pattern %q (registered at %s) conflicts with pattern %q (registered at %s):\n%s",
 				pat, pat.loc, pat2, pat2.loc, d)
 		}
 		return nil
-	}); err != nil {
+	})
+}
+
+// CheckPattern reports whether pattern could be registered with mux.
+// It returns nil if pattern is valid and does not conflict with a
+// pattern already registered with mux. Otherwise, it returns the
+// error describing why [ServeMux.Handle] would panic.
+// CheckPattern does not register pattern.
+func (mux *ServeMux) CheckPattern(pattern string) error {
+	if use121 {
+		mux.mux121.mu.RLock()
+		defer mux.mux121.mu.RUnlock()
+		if pattern == "" {
+			return errors.New("http: invalid pattern")
+		}
+		if _, exist := mux.mux121.m[pattern]; exist {
+			return errors.New("http: multiple registrations for " + pattern)
+		}
+		return nil
+	}
+	pat, err := parseMuxPattern(pattern)
+	if err != nil {
 		return err
 	}
-	mux.tree.addPattern(pat, handler)
-	mux.index.addPattern(pat)
-	mux.patterns = append(mux.patterns, pat)
-	return nil
+	mux.mu.RLock()
+	defer mux.mu.RUnlock()
+	return mux.checkConflicts(pat)
+}
+
+// Patterns returns an iterator over the patterns registered with mux
+// and their handlers, in the order in which they were registered.
+// With GODEBUG=httpmuxgo121=1, the patterns are sorted instead.
+func (mux *ServeMux) Patterns() iter.Seq2[string, Handler] {
+	return func(yield func(string, Handler) bool) {
+		var es []muxEntry
+		if use121 {
+			// Go 1.21 patterns are not kept in registration order.
+			mux.mux121.mu.RLock()
+			for _, e := range mux.mux121.m {
+				es = append(es, e)
+			}
+			mux.mux121.mu.RUnlock()
+			slices.SortFunc(es, func(a, b muxEntry) int { return strings.Compare(a.pattern, b.pattern) })
+		} else {
+			// Registered entries are never modified, so a
+			// slice of them is safe to use without the lock.
+			mux.mu.RLock()
+			es = mux.patterns
+			mux.mu.RUnlock()
+		}
+		for _, e := range es {
+			if !yield(e.pattern, e.h) {
+				return
+			}
+		}
+	}
 }
 
 // Serve accepts incoming HTTP connections on the listener l,
@@ -2913,6 +3033,20 @@ type Server struct {
 	// value.
 	ConnContext func(ctx context.Context, c net.Conn) context.Context
 
+	// Protocols is the set of protocols accepted by the server.
+	//
+	// If Protocols includes UnencryptedHTTP2, the server will accept
+	// unencrypted HTTP/2 connections. The server can serve both
+	// HTTP/1 and unencrypted HTTP/2 on the same address and port,
+	// telling them apart by the HTTP/2 connection preface.
+	// Unencrypted HTTP/2 is served to clients with prior knowledge
+	// only; the HTTP/1 "Upgrade: h2c" mechanism is not supported.
+	//
+	// If Protocols is nil, the default is usually HTTP/1 and HTTP/2.
+	// If TLSNextProto is non-nil and does not contain an "h2" entry,
+	// the default is HTTP/1 only.
+	Protocols *Protocols
+
 	inShutdown atomic.Bool // true when server is in shutdown
 
 	disableKeepAlives atomic.Bool
@@ -2924,9 +3058,27 @@ type Server struct {
 	activeConn map[*conn]struct{}
 	onShutdown []func()
 
+	http3       http3Server            // set by ServeQUIC
+	http3AltSvc atomic.Pointer[string] // Alt-Svc advertising HTTP/3, or nil
+
 	listenerGroup sync.WaitGroup
 }
 
+// http3Server is the interface through which a Server manages its HTTP/3
+// endpoints and connections. It is set by [Server.ServeQUIC], so that
+// programs which do not serve HTTP/3 do not link in its implementation.
+// Its methods are called with the Server's mu held.
+type http3Server interface {
+	// close closes the HTTP/3 connections.
+	close()
+	// closeIdleConns closes the HTTP/3 connections with no request in
+	// progress, and tells the others to stop sending requests.
+	// It reports whether all the connections are closed.
+	closeIdleConns() bool
+	// closeListeners stops accepting HTTP/3 connections.
+	closeListeners()
+}
+
 // Close immediately closes all active net.Listeners and any
 // connections in state [StateNew], [StateActive], or [StateIdle]. For a
 // graceful shutdown, use [Server.Shutdown].
@@ -2954,6 +3106,9 @@ func (srv *Server) Close() error {
 		c.rwc.Close()
 		delete(srv.activeConn, c)
 	}
+	if srv.http3 != nil {
+		srv.http3.close()
+	}
 	return err
 }
 
@@ -3058,6 +3213,9 @@ func (s *Server) closeIdleConns() bool {
 		c.rwc.Close()
 		delete(s.activeConn, c)
 	}
+	if s.http3 != nil && !s.http3.closeIdleConns() {
+		quiescent = false
+	}
 	return quiescent
 }
 
@@ -3068,6 +3226,9 @@ func (s *Server) closeListenersLocked() error {
 			err = cerr
 		}
 	}
+	if s.http3 != nil {
+		s.http3.closeListeners()
+	}
 	return err
 }
 
@@ -3137,6 +3298,13 @@ func (sh serverHandler) ServeHTTP(rw ResponseWriter, req *Request) {
 	if !sh.srv.DisableGeneralOptionsHandler && req.RequestURI == "*" && req.Method == "OPTIONS" {
 		handler = globalOptionsHandler{}
 	}
+	if req.TLS != nil && req.ProtoMajor < 3 {
+		if altSvc := sh.srv.http3AltSvc.Load(); altSvc != nil {
+			if h := rw.Header(); h["Alt-Svc"] == nil {
+				h["Alt-Svc"] = []string{*altSvc}
+			}
+		}
+	}
 
 	handler.ServeHTTP(rw, req)
 }
@@ -3193,6 +3361,11 @@ var testHookServerServe func(*Server, net.Listener) // used if non-nil
 // shouldConfigureHTTP2ForServe reports whether Server.Serve should configure
 // automatic HTTP/2. (which sets up the srv.TLSNextProto map)
 func (srv *Server) shouldConfigureHTTP2ForServe() bool {
+	if srv.protocols().UnencryptedHTTP2() {
+		// Unencrypted HTTP/2 needs no tls.Config, but is served
+		// by the HTTP/2 implementation all the same.
+		return true
+	}
 	if srv.TLSConfig == nil {
 		// Compatibility with Go 1.6:
 		// If there's no TLSConfig, it's possible that the user just
@@ -3311,9 +3484,12 @@ func (srv *Server) ServeTLS(l net.Listener, certFile, keyFile string) error {
 	}
 
 	config := cloneTLSConfig(srv.TLSConfig)
-	if !slices.Contains(config.NextProtos, "http/1.1") {
-		config.NextProtos = append(config.NextProtos, "http/1.1")
+	protos := srv.protocols()
+	if srv.TLSNextProto["h2"] == nil {
+		// HTTP/2 is disabled, or couldn't be configured.
+		protos.SetHTTP2(false)
 	}
+	config.NextProtos = adjustNextProtos(config.NextProtos, protos)
 
 	configHasCert := len(config.Certificates) > 0 || config.GetCertificate != nil
 	if !configHasCert || certFile != "" || keyFile != "" {
@@ -3515,6 +3691,18 @@ func (srv *Server) onceSetNextProtoDefaults_Serve() {
 
 var http2server = godebug.New("http2server")
 
+// http2xconnect enables support for extended CONNECT requests (RFC 8441)
+// in the HTTP/2 server, which WebSocket connections over HTTP/2 use.
+var http2xconnect = godebug.New("http2xconnect")
+
+// Read http2xconnect once at startup, since the HTTP/2 server reads
+// the setting without synchronization.
+func init() {
+	if http2xconnect.Value() == "1" {
+		http2disableExtendedConnectProtocol = false
+	}
+}
+
 // onceSetNextProtoDefaults configures HTTP/2, if the user hasn't
 // configured otherwise. (by setting srv.TLSNextProto non-nil)
 // It must only be called via srv.nextProtoOnce (use srv.setupHTTP2_*).
@@ -3526,14 +3714,34 @@ func (srv *Server) onceSetNextProtoDefaults() {
 		http2server.IncNonDefault()
 		return
 	}
-	// Enable HTTP/2 by default if the user hasn't otherwise
-	// configured their TLSNextProto map.
-	if srv.TLSNextProto == nil {
-		conf := &http2Server{
-			NewWriteScheduler: func() http2WriteScheduler { return http2NewPriorityWriteScheduler(nil) },
-		}
-		srv.nextProtoErr = http2ConfigureServer(srv, conf)
+	p := srv.protocols()
+	if !p.HTTP2() && !p.UnencryptedHTTP2() {
+		return
+	}
+	if _, ok := srv.TLSNextProto["h2"]; ok {
+		// The user has already configured HTTP/2, probably
+		// with golang.org/x/net/http2.ConfigureServer.
+		return
+	}
+	conf := &http2Server{
+		NewWriteScheduler: func() http2WriteScheduler { return http2NewPriorityWriteScheduler(nil) },
+	}
+	srv.nextProtoErr = http2ConfigureServer(srv, conf)
+}
+
+// protocols returns the set of protocols served by srv.
+func (srv *Server) protocols() Protocols {
+	if srv.Protocols != nil {
+		return *srv.Protocols
+	}
+	var p Protocols
+	p.SetHTTP1(true)
+	// The historic way of disabling HTTP/2 is to set TLSNextProto
+	// to a non-nil map with no "h2" entry.
+	if _, ok := srv.TLSNextProto["h2"]; srv.TLSNextProto == nil || ok {
+		p.SetHTTP2(true)
 	}
+	return p
 }
 
 // TimeoutHandler returns a [Handler] that runs h with the given time limit.
@@ -3725,7 +3933,7 @@ func (globalOptionsHandler) ServeHTTP(w ResponseWriter, r *Request) {
 // Requests come from ALPN protocol handlers.
 type initALPNRequest struct {
 	ctx context.Context
-	c   *tls.Conn
+	c   *tls.Conn // nil for unencrypted HTTP/2
 	h   serverHandler
 }
 
@@ -3736,14 +3944,14 @@ type initALPNRequest struct {
 func (h initALPNRequest) BaseContext() context.Context { return h.ctx }
 
 func (h initALPNRequest) ServeHTTP(rw ResponseWriter, req *Request) {
-	if req.TLS == nil {
+	if req.TLS == nil && h.c != nil {
 		req.TLS = &tls.ConnectionState{}
 		*req.TLS = h.c.ConnectionState()
 	}
 	if req.Body == nil {
 		req.Body = NoBody
 	}
-	if req.RemoteAddr == "" {
+	if req.RemoteAddr == "" && h.c != nil {
 		req.RemoteAddr = h.c.RemoteAddr().String()
 	}
 	h.h.ServeHTTP(rw, req)
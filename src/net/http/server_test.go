@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"net/url"
 	"regexp"
+	"slices"
 	"testing"
 	"time"
 )
@@ -161,6 +162,60 @@ func TestRegisterErr(t *testing.T) {
 	}
 }
 
+func TestCheckPattern(t *testing.T) {
+	mux := NewServeMux()
+	mux.Handle("/a", &handler{})
+	for _, test := range []struct {
+		pattern    string
+		wantRegexp string // empty for no error
+	}{
+		{"/b", ""},
+		{"GET /a/{x}", ""},
+		{"", "invalid pattern"},
+		{"/{x", `parsing "/\{x": at offset 1: bad wildcard segment`},
+		{"/a", `^pattern "/a" conflicts with pattern "/a" \(registered at .*/server_test.go:\d+\)`},
+	} {
+		err := mux.CheckPattern(test.pattern)
+		if test.wantRegexp == "" {
+			if err != nil {
+				t.Errorf("CheckPattern(%q) = %v, want nil", test.pattern, err)
+			}
+			continue
+		}
+		re := regexp.MustCompile(test.wantRegexp)
+		if err == nil || !re.MatchString(err.Error()) {
+			t.Errorf("CheckPattern(%q) = %v, want error matching %q", test.pattern, err, test.wantRegexp)
+		}
+	}
+	// CheckPattern does not register the pattern.
+	if err := mux.CheckPattern("/b"); err != nil {
+		t.Errorf("CheckPattern(%q) after checking it: %v", "/b", err)
+	}
+}
+
+func TestServeMuxPatterns(t *testing.T) {
+	mux := NewServeMux()
+	want := []string{"/b", "GET /a/{x}", "example.com/", "/a"}
+	for i, p := range want {
+		mux.Handle(p, &handler{i})
+	}
+	var got []string
+	for p, h := range mux.Patterns() {
+		if h.(*handler).i != len(got) {
+			t.Errorf("pattern %q: got handler %d, want %d", p, h.(*handler).i, len(got))
+		}
+		got = append(got, p)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got patterns %q, want %q", got, want)
+	}
+	// Registering a pattern while iterating does not deadlock.
+	mux.Patterns()(func(string, Handler) bool {
+		mux.Handle("/c", &handler{})
+		return false
+	})
+}
+
 func TestExactMatch(t *testing.T) {
 	for _, test := range []struct {
 		pattern string
@@ -292,6 +292,21 @@ type Transport struct {
 	// To use a custom dialer or TLS config and still attempt HTTP/2
 	// upgrades, set this to true.
 	ForceAttemptHTTP2 bool
+
+	// Protocols is the set of protocols supported by the transport.
+	//
+	// If Protocols includes UnencryptedHTTP2 and does not include HTTP1,
+	// the transport will use unencrypted HTTP/2 for requests for http:// URLs,
+	// assuming prior knowledge that the server supports it.
+	// Requests which must be made over HTTP/1, such as protocol
+	// upgrades, and requests through an HTTP proxy still use HTTP/1.
+	//
+	// If Protocols is nil, the default is usually HTTP/1 only.
+	// If ForceAttemptHTTP2 is true, or if TLSNextProto contains an "h2" entry,
+	// the default is HTTP/1 and HTTP/2.
+	Protocols *Protocols
+
+	h3 http3RoundTripper // set by EnableHTTP3
 }
 
 // A cancelKey is the key of the reqCanceler map.
@@ -344,6 +359,13 @@ func (t *Transport) Clone() *Transport {
 	if t.TLSClientConfig != nil {
 		t2.TLSClientConfig = t.TLSClientConfig.Clone()
 	}
+	if t.Protocols != nil {
+		t2.Protocols = &Protocols{}
+		*t2.Protocols = *t.Protocols
+	}
+	if t.h3 != nil {
+		t2.h3 = t.h3.clone()
+	}
 	if !t.tlsNextProtoWasNil {
 		npm := map[string]func(authority string, c *tls.Conn) RoundTripper{}
 		for k, v := range t.TLSNextProto {
@@ -354,6 +376,20 @@ func (t *Transport) Clone() *Transport {
 	return t2
 }
 
+// http3RoundTripper is the interface through which a Transport sends
+// requests over HTTP/3. It is set by [Transport.EnableHTTP3], so that
+// programs which do not use HTTP/3 do not link in its implementation.
+type http3RoundTripper interface {
+	// roundTrip sends req over HTTP/3, or returns errHTTP3Skip
+	// if req must be sent over TCP.
+	roundTrip(t *Transport, req *Request) (*Response, error)
+	// noteAltSvc records the alternative services advertised by resp.
+	noteAltSvc(t *Transport, req *Request, resp *Response)
+	closeIdleConnections()
+	// clone returns a new, empty round tripper for a clone of the Transport.
+	clone() http3RoundTripper
+}
+
 // h2Transport is the interface we expect to be able to call from
 // net/http against an *http2.Transport that's either bundled into
 // h2_bundle.go or supplied by the user via x/net/http2.
@@ -394,18 +430,12 @@ func (t *Transport) onceSetNextProtoDefaults() {
 		}
 	}
 
-	if t.TLSNextProto != nil {
-		// This is the documented way to disable http2 on a
-		// Transport.
+	p := t.protocols()
+	if !p.HTTP2() && !p.UnencryptedHTTP2() {
 		return
 	}
-	if !t.ForceAttemptHTTP2 && (t.TLSClientConfig != nil || t.Dial != nil || t.DialContext != nil || t.hasCustomTLSDialer()) {
-		// Be conservative and don't automatically enable
-		// http2 if they've specified a custom TLS config or
-		// custom dialers. Let them opt-in themselves via
-		// http2.ConfigureTransport so we don't surprise them
-		// by modifying their tls.Config. Issue 14275.
-		// However, if ForceAttemptHTTP2 is true, it overrides the above checks.
+	if _, ok := t.TLSNextProto["h2"]; ok {
+		// HTTP/2 is already configured, by the user.
 		return
 	}
 	if omitBundledHTTP2 {
@@ -434,6 +464,33 @@ func (t *Transport) onceSetNextProtoDefaults() {
 	}
 }
 
+// protocols returns the set of protocols supported by t.
+func (t *Transport) protocols() Protocols {
+	if t.Protocols != nil {
+		return *t.Protocols
+	}
+	var p Protocols
+	p.SetHTTP1(true)
+	switch {
+	case t.TLSNextProto != nil:
+		// Setting TLSNextProto to a non-nil map with no "h2" entry
+		// is the documented way to disable http2 on a Transport.
+		if t.TLSNextProto["h2"] != nil {
+			p.SetHTTP2(true)
+		}
+	case !t.ForceAttemptHTTP2 && (t.TLSClientConfig != nil || t.Dial != nil || t.DialContext != nil || t.hasCustomTLSDialer()):
+		// Be conservative and don't automatically enable
+		// http2 if they've specified a custom TLS config or
+		// custom dialers. Let them opt-in themselves via
+		// http2.ConfigureTransport so we don't surprise them
+		// by modifying their tls.Config. Issue 14275.
+		// However, if ForceAttemptHTTP2 is true, it overrides the above checks.
+	default:
+		p.SetHTTP2(true)
+	}
+	return p
+}
+
 // ProxyFromEnvironment returns the URL of the proxy to use for a
 // given request, as indicated by the environment variables
 // HTTP_PROXY, HTTPS_PROXY and NO_PROXY (or the lowercase versions
@@ -548,7 +605,13 @@ func (t *Transport) roundTrip(req *Request) (*Response, error) {
 	isHTTP := scheme == "http" || scheme == "https"
 	if isHTTP {
 		// Validate the outgoing headers.
-		if err := validateHeaders(req.Header); err != "" {
+		hdrs := req.Header
+		if req.isExtendedConnect() {
+			// The :protocol pseudo-header is sent by the HTTP/2 implementation.
+			hdrs = hdrs.Clone()
+			delete(hdrs, ":protocol")
+		}
+		if err := validateHeaders(hdrs); err != "" {
 			req.closeBody()
 			return nil, fmt.Errorf("net/http: invalid header %s", err)
 		}
@@ -587,6 +650,21 @@ func (t *Transport) roundTrip(req *Request) (*Response, error) {
 		return nil, errors.New("http: no Host in request URL")
 	}
 
+	if t.h3 != nil && scheme == "https" && !req.isExtendedConnect() {
+		resp, err := t.h3.roundTrip(t, req)
+		if err == nil {
+			resp.Request = origReq
+			return resp, nil
+		}
+		if err != errHTTP3Skip {
+			return nil, err
+		}
+		req, err = rewindBody(req)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -614,6 +692,13 @@ func (t *Transport) roundTrip(req *Request) (*Response, error) {
 			return nil, err
 		}
 
+		if pconn.alt == nil && req.isExtendedConnect() {
+			t.setReqCanceler(cancelKey, nil)
+			t.putOrCloseIdleConn(pconn)
+			req.closeBody()
+			return nil, errExtendedConnectHTTP1
+		}
+
 		var resp *Response
 		if pconn.alt != nil {
 			// HTTP/2 path.
@@ -624,6 +709,9 @@ func (t *Transport) roundTrip(req *Request) (*Response, error) {
 		}
 		if err == nil {
 			resp.Request = origReq
+			if t.h3 != nil && scheme == "https" && cm.proxyURL == nil {
+				t.h3.noteAltSvc(t, req, resp)
+			}
 			return resp, nil
 		}
 
@@ -659,6 +747,8 @@ func (t *Transport) roundTrip(req *Request) (*Response, error) {
 	}
 }
 
+var errExtendedConnectHTTP1 = errors.New("net/http: extended CONNECT request requires HTTP/2")
+
 var errCannotRewind = errors.New("net/http: cannot rewind body after connection loss")
 
 type readTrackingBody struct {
@@ -818,6 +908,9 @@ func (t *Transport) CloseIdleConnections() {
 	if t2 := t.h2transport; t2 != nil {
 		t2.CloseIdleConnections()
 	}
+	if t.h3 != nil {
+		t.h3.closeIdleConnections()
+	}
 }
 
 // CancelRequest cancels an in-flight request by closing its connection.
@@ -1634,6 +1727,13 @@ func (pconn *persistConn) addTLS(ctx context.Context, name string, trace *httptr
 	}
 	if pconn.cacheKey.onlyH1 {
 		cfg.NextProtos = nil
+	} else if p := pconn.t.Protocols; p != nil {
+		protos := *p
+		if pconn.t.TLSNextProto["h2"] == nil {
+			// HTTP/2 is disabled, or couldn't be configured.
+			protos.SetHTTP2(false)
+		}
+		cfg.NextProtos = adjustNextProtos(cfg.NextProtos, protos)
 	}
 	plainConn := pconn.conn
 	tlsConn := tls.Client(plainConn, cfg)
@@ -1868,6 +1968,25 @@ func (t *Transport) dialConn(ctx context.Context, cm connectMethod) (pconn *pers
 		}
 	}
 
+	// Requests sent to an HTTP proxy, rather than tunneled through
+	// a SOCKS proxy, are left to HTTP/1.
+	viaHTTPProxy := cm.proxyURL != nil && cm.proxyURL.Scheme != "socks5" && cm.proxyURL.Scheme != "socks5h"
+	if pconn.tlsState == nil && !cm.onlyH1 && !viaHTTPProxy {
+		if p := t.Protocols; p != nil && p.UnencryptedHTTP2() && !p.HTTP1() {
+			next, ok := t.TLSNextProto[nextProtoUnencryptedHTTP2]
+			if !ok {
+				pconn.conn.Close()
+				return nil, errors.New("net/http: Transport does not support unencrypted HTTP/2")
+			}
+			alt := next(cm.targetAddr, unencryptedTLSConn(pconn.conn))
+			if e, ok := alt.(erringRoundTripper); ok {
+				// pconn.conn was closed by next (http2configureTransports.upgradeFn).
+				return nil, e.RoundTripErr()
+			}
+			return &persistConn{t: t, cacheKey: pconn.cacheKey, alt: alt}, nil
+		}
+	}
+
 	pconn.br = bufio.NewReaderSize(pconn, t.readBufferSize())
 	pconn.bw = bufio.NewWriterSize(persistConnWriter{pconn}, t.writeBufferSize())
 
@@ -6283,12 +6283,14 @@ func TestTransportClone(t *testing.T) {
 		GetProxyConnectHeader:  func(context.Context, *url.URL, string) (Header, error) { return nil, nil },
 		MaxResponseHeaderBytes: 1,
 		ForceAttemptHTTP2:      true,
+		Protocols:              &Protocols{},
 		TLSNextProto: map[string]func(authority string, c *tls.Conn) RoundTripper{
 			"foo": func(authority string, c *tls.Conn) RoundTripper { panic("") },
 		},
 		ReadBufferSize:  1,
 		WriteBufferSize: 1,
 	}
+	tr.EnableHTTP3()
 	tr2 := tr.Clone()
 	rv := reflect.ValueOf(tr2).Elem()
 	rt := rv.Type()
@@ -6305,6 +6307,9 @@ func TestTransportClone(t *testing.T) {
 	if _, ok := tr2.TLSNextProto["foo"]; !ok {
 		t.Errorf("cloned Transport lacked TLSNextProto 'foo' key")
 	}
+	if !ExportTransportUsesHTTP3(tr2) {
+		t.Errorf("cloned Transport does not use HTTP/3")
+	}
 
 	// But test that a nil TLSNextProto is kept nil:
 	tr = new(Transport)
@@ -0,0 +1,211 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package websocket
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/internal/ascii"
+	"net/url"
+	"time"
+)
+
+// AcceptOptions configures [Accept].
+type AcceptOptions struct {
+	// Subprotocols lists the subprotocols supported by the server,
+	// in order of preference. Accept selects the first of them that
+	// the client also supports. If there is none, the connection
+	// has no subprotocol.
+	Subprotocols []string
+
+	// CheckOrigin, if non-nil, reports whether to accept a request.
+	// If CheckOrigin is nil, Accept rejects a request with an Origin
+	// header whose host differs from the Host of the request, to
+	// prevent cross-site WebSocket hijacking.
+	CheckOrigin func(r *http.Request) bool
+
+	// EnableCompression enables the permessage-deflate extension,
+	// if the client supports it.
+	EnableCompression bool
+}
+
+// Accept accepts a WebSocket connection from the client sending r,
+// completing the opening handshake.
+//
+// If the request is not a valid WebSocket opening handshake,
+// Accept replies to it with an HTTP error and returns an error.
+//
+// Over HTTP/1, Accept takes over the connection from the server,
+// and the connection remains open after the handler returns.
+// Over HTTP/2, the WebSocket connection is the stream of the request,
+// and the handler must not return until it has finished using the Conn.
+func Accept(w http.ResponseWriter, r *http.Request, opts *AcceptOptions) (*Conn, error) {
+	if opts == nil {
+		opts = &AcceptOptions{}
+	}
+	if r.ProtoMajor == 1 {
+		return accept1(w, r, opts)
+	}
+	return accept2(w, r, opts)
+}
+
+// accept1 accepts an HTTP/1 upgrade request.
+func accept1(w http.ResponseWriter, r *http.Request, opts *AcceptOptions) (*Conn, error) {
+	if !headerContainsToken(r.Header, "Connection", "upgrade") ||
+		!headerContainsToken(r.Header, "Upgrade", "websocket") {
+		w.Header().Set("Connection", "Upgrade")
+		w.Header().Set("Upgrade", "websocket")
+		return nil, rejectRequest(w, http.StatusUpgradeRequired, "not a WebSocket handshake")
+	}
+	if r.Method != "GET" {
+		return nil, rejectRequest(w, http.StatusMethodNotAllowed, "WebSocket handshake method is not GET")
+	}
+	if err := checkHandshake(w, r, opts); err != nil {
+		return nil, err
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if b, err := base64.StdEncoding.DecodeString(key); err != nil || len(b) != 16 {
+		return nil, rejectRequest(w, http.StatusBadRequest, "invalid Sec-WebSocket-Key")
+	}
+	subprotocol, dp := negotiate(w, r, opts)
+
+	rc := http.NewResponseController(w)
+	netConn, brw, err := rc.Hijack()
+	if err != nil {
+		rejectRequest(w, http.StatusInternalServerError, "cannot take over connection")
+		return nil, err
+	}
+	// Clear any deadlines set by the server.
+	netConn.SetDeadline(time.Time{})
+
+	h := w.Header()
+	h.Set("Upgrade", "websocket")
+	h.Set("Connection", "Upgrade")
+	h.Set("Sec-WebSocket-Accept", acceptKey(key))
+	brw.WriteString("HTTP/1.1 101 Switching Protocols\r\n")
+	h.Write(brw)
+	brw.WriteString("\r\n")
+	if err := brw.Flush(); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	return newConn(connConfig{
+		br:          brw.Reader,
+		bw:          brw.Writer,
+		flush:       brw.Writer.Flush,
+		close:       netConn.Close,
+		subprotocol: subprotocol,
+		deflate:     dp,
+	}), nil
+}
+
+// accept2 accepts an HTTP/2 extended CONNECT request.
+func accept2(w http.ResponseWriter, r *http.Request, opts *AcceptOptions) (*Conn, error) {
+	if r.Method != "CONNECT" || r.Header.Get(":protocol") != "websocket" {
+		return nil, rejectRequest(w, http.StatusBadRequest, "not a WebSocket handshake")
+	}
+	if err := checkHandshake(w, r, opts); err != nil {
+		return nil, err
+	}
+	subprotocol, dp := negotiate(w, r, opts)
+
+	rc := http.NewResponseController(w)
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return nil, err
+	}
+	bw := bufio.NewWriter(w)
+	return newConn(connConfig{
+		br: bufio.NewReader(r.Body),
+		bw: bw,
+		flush: func() error {
+			if err := bw.Flush(); err != nil {
+				return err
+			}
+			return rc.Flush()
+		},
+		close: func() error {
+			// Reset the stream, unblocking any reads and writes.
+			rc.SetReadDeadline(time.Now())
+			rc.SetWriteDeadline(time.Now())
+			return r.Body.Close()
+		},
+		subprotocol: subprotocol,
+		deflate:     dp,
+	}), nil
+}
+
+// checkHandshake checks the version and origin of a handshake request.
+func checkHandshake(w http.ResponseWriter, r *http.Request, opts *AcceptOptions) error {
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		return rejectRequest(w, http.StatusUpgradeRequired, "unsupported WebSocket version")
+	}
+	checkOrigin := opts.CheckOrigin
+	if checkOrigin == nil {
+		checkOrigin = sameOrigin
+	}
+	if !checkOrigin(r) {
+		return rejectRequest(w, http.StatusForbidden, "cross-origin WebSocket request not allowed")
+	}
+	return nil
+}
+
+// negotiate chooses the subprotocol and extensions for a connection,
+// and sets the corresponding response headers.
+func negotiate(w http.ResponseWriter, r *http.Request, opts *AcceptOptions) (string, *deflateParams) {
+	var subprotocol string
+	offered := headerTokens(r.Header, "Sec-WebSocket-Protocol")
+choose:
+	for _, s := range opts.Subprotocols {
+		for _, o := range offered {
+			if s == o {
+				subprotocol = s
+				break choose
+			}
+		}
+	}
+	if subprotocol != "" {
+		w.Header().Set("Sec-WebSocket-Protocol", subprotocol)
+	}
+	var dp *deflateParams
+	if opts.EnableCompression {
+		var ext string
+		if dp, ext = acceptDeflate(r.Header); dp != nil {
+			w.Header().Set("Sec-WebSocket-Extensions", ext)
+		}
+	}
+	return subprotocol, dp
+}
+
+// sameOrigin reports whether r has no Origin header,
+// or an Origin whose host is the host of r.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return ascii.EqualFold(u.Host, r.Host)
+}
+
+// rejectRequest replies to a request that is not an acceptable
+// WebSocket handshake, and returns an error describing it.
+func rejectRequest(w http.ResponseWriter, code int, msg string) error {
+	http.Error(w, msg, code)
+	return errors.New("websocket: " + msg)
+}
+
+// acceptKey returns the Sec-WebSocket-Accept value for key.
+func acceptKey(key string) string {
+	h := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(h[:])
+}
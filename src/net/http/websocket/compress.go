@@ -0,0 +1,220 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package websocket
+
+import (
+	"compress/flate"
+	"errors"
+	"io"
+	"net/http"
+	"net/textproto"
+	"strings"
+)
+
+// This file implements the permessage-deflate extension, RFC 7692.
+
+const deflateExtension = "permessage-deflate"
+
+// deflateParams are the negotiated parameters of permessage-deflate.
+type deflateParams struct {
+	// readNoTakeover is set if the peer resets its compressor
+	// after each message.
+	readNoTakeover bool
+
+	// writeNoTakeover is set if we must reset our compressor
+	// after each message.
+	writeNoTakeover bool
+}
+
+// deflateTail is appended to the payload of a compressed message
+// before decompressing it: the four bytes of the empty stored block
+// that the sender removed, followed by a final empty stored block,
+// which ends the stream for the decompressor.
+const deflateTail = "\x00\x00\xff\xff\x01\x00\x00\xff\xff"
+
+// windowSize is the size of the LZ77 window used by compress/flate.
+const windowSize = 32 << 10
+
+// appendWindow appends p to the window w, keeping only the last
+// windowSize bytes.
+func appendWindow(w, p []byte) []byte {
+	if len(p) >= windowSize {
+		return append(w[:0], p[len(p)-windowSize:]...)
+	}
+	if over := len(w) + len(p) - windowSize; over > 0 {
+		w = w[:copy(w, w[over:])]
+	}
+	return append(w, p...)
+}
+
+// An extension is an element of a Sec-WebSocket-Extensions header.
+type extension struct {
+	name   string
+	params []extensionParam
+}
+
+type extensionParam struct {
+	name, value string
+}
+
+// parseExtensions parses the Sec-WebSocket-Extensions header of h.
+func parseExtensions(h http.Header) []extension {
+	var exts []extension
+	for _, v := range headerTokens(h, "Sec-WebSocket-Extensions") {
+		name, rest, _ := strings.Cut(v, ";")
+		ext := extension{name: textproto.TrimString(name)}
+		for rest != "" {
+			var p string
+			p, rest, _ = strings.Cut(rest, ";")
+			k, v, ok := strings.Cut(p, "=")
+			param := extensionParam{name: textproto.TrimString(k)}
+			if ok {
+				v = textproto.TrimString(v)
+				if len(v) >= 2 && v[0] == '"' && v[len(v)-1] == '"' {
+					v = v[1 : len(v)-1]
+				}
+				param.value = v
+			}
+			ext.params = append(ext.params, param)
+		}
+		exts = append(exts, ext)
+	}
+	return exts
+}
+
+// acceptDeflate chooses the first permessage-deflate offer in h that
+// the server can accept. It returns the parameters for the server and
+// the value of the server's Sec-WebSocket-Extensions header, or nil
+// if there is no acceptable offer.
+func acceptDeflate(h http.Header) (*deflateParams, string) {
+offers:
+	for _, ext := range parseExtensions(h) {
+		if ext.name != deflateExtension {
+			continue
+		}
+		dp := &deflateParams{}
+		resp := deflateExtension
+		for _, p := range ext.params {
+			switch p.name {
+			case "server_no_context_takeover":
+				dp.writeNoTakeover = true
+				resp += "; server_no_context_takeover"
+			case "client_no_context_takeover":
+				dp.readNoTakeover = true
+				resp += "; client_no_context_takeover"
+			case "server_max_window_bits":
+				// compress/flate always uses a 32 KiB window.
+				if p.value != "15" {
+					continue offers
+				}
+				resp += "; server_max_window_bits=15"
+			case "client_max_window_bits":
+				// Any window size can be decompressed.
+			default:
+				continue offers
+			}
+		}
+		return dp, resp
+	}
+	return nil, ""
+}
+
+// checkDeflateResponse checks the server's Sec-WebSocket-Extensions
+// header in h, and returns the negotiated parameters, or nil if the
+// server did not accept compression.
+func checkDeflateResponse(h http.Header, offered bool) (*deflateParams, error) {
+	exts := parseExtensions(h)
+	if len(exts) == 0 {
+		return nil, nil
+	}
+	if !offered || len(exts) > 1 || exts[0].name != deflateExtension {
+		return nil, errors.New("websocket: server accepted an extension that was not offered")
+	}
+	dp := &deflateParams{}
+	for _, p := range exts[0].params {
+		switch p.name {
+		case "server_no_context_takeover":
+			dp.readNoTakeover = true
+		case "client_no_context_takeover":
+			dp.writeNoTakeover = true
+		case "server_max_window_bits":
+			// Any window size can be decompressed.
+		default:
+			return nil, errors.New("websocket: invalid permessage-deflate parameter " + p.name)
+		}
+	}
+	return dp, nil
+}
+
+// A payloadBuffer accumulates the payload of outgoing frames.
+// It is the destination of the compressor.
+type payloadBuffer struct {
+	b []byte
+}
+
+func (b *payloadBuffer) Write(p []byte) (int, error) {
+	b.b = append(b.b, p...)
+	return len(p), nil
+}
+
+// reset empties b, dropping unusually large buffers.
+func (b *payloadBuffer) reset() {
+	if cap(b.b) > 4*fragmentSize {
+		b.b = nil
+	}
+	b.b = b.b[:0]
+}
+
+// compressor returns the compressor of c, which writes to c.wbuf.
+func (c *Conn) compressor() *flate.Writer {
+	if c.fw == nil {
+		c.fw, _ = flate.NewWriter(&c.wbuf, flate.BestSpeed)
+	}
+	return c.fw
+}
+
+// A deflateSource is the input of the decompressor: the payload of
+// the current message followed by deflateTail.
+type deflateSource struct {
+	c    *Conn
+	tail int // bytes of deflateTail read, or -1 before the end of the payload
+}
+
+func (s *deflateSource) Read(p []byte) (int, error) {
+	if s.tail < 0 {
+		n, err := s.c.readData(p)
+		if err == nil || n > 0 {
+			return n, err
+		}
+		if err != io.EOF {
+			return 0, err
+		}
+		s.tail = 0
+	}
+	if s.tail == len(deflateTail) {
+		return 0, io.EOF
+	}
+	n := copy(p, deflateTail[s.tail:])
+	s.tail += n
+	return n, nil
+}
+
+func (s *deflateSource) ReadByte() (byte, error) {
+	if c := s.c; s.tail < 0 && c.frameRemain > 0 {
+		b, err := c.br.ReadByte()
+		if err != nil {
+			return 0, c.readFailed(noEOF(err))
+		}
+		c.frameRemain--
+		if c.frame.masked {
+			b ^= c.frame.maskKey[c.maskPos]
+			c.maskPos = (c.maskPos + 1) & 3
+		}
+		return b, nil
+	}
+	var b [1]byte
+	_, err := s.Read(b[:])
+	return b[0], err
+}
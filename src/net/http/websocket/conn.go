@@ -0,0 +1,841 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package websocket
+
+import (
+	"bufio"
+	"compress/flate"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// defaultReadLimit is the default maximum size of a received message.
+	defaultReadLimit = 32 << 20
+
+	// fragmentSize is the payload size of the frames sent by a
+	// message writer returned by Conn.Writer.
+	fragmentSize = 16 << 10
+
+	// closeTimeout is how long Conn.Close waits for the peer
+	// to reply to a close frame.
+	closeTimeout = 5 * time.Second
+)
+
+var (
+	errMessageTooBig = errors.New("websocket: message exceeds read limit")
+	errInvalidUTF8   = errors.New("websocket: invalid UTF-8 in text message")
+	errStaleReader   = errors.New("websocket: read from a message reader after the next message was started")
+	errWriterClosed  = errors.New("websocket: write to a closed message writer")
+	errTrailingData  = errors.New("data after end of compressed stream")
+)
+
+// A Conn is a WebSocket connection.
+//
+// A Conn supports one concurrent reader, calling [Conn.Read] or
+// [Conn.Reader], and one concurrent writer, calling [Conn.Write] or
+// [Conn.Writer]. [Conn.Ping], [Conn.Close], and [Conn.CloseNow] may be
+// called concurrently with all other methods.
+type Conn struct {
+	br             *bufio.Reader
+	bw             *bufio.Writer
+	flush          func() error // flushes bw and the underlying transport
+	closeTransport func() error
+	client         bool
+	subprotocol    string
+	deflate        *deflateParams // nil if compression was not negotiated
+
+	readLimit atomic.Int64
+
+	// readMu is a semaphore held while reading from br.
+	// The fields below are guarded by readMu.
+	readMu      chan struct{}
+	readErr     error // sticky
+	hdrBuf      [maxFrameHeaderLen]byte
+	ctrlBuf     [maxControlPayload]byte
+	frame       frameHeader // current data frame
+	frameRemain int64       // unread payload bytes of frame
+	maskPos     int         // masking offset in frame
+	msgReader   *messageReader
+	fr          io.ReadCloser // decompressor
+	readDict    []byte        // recent decompressed data, for context takeover
+
+	// writeMsgMu is a semaphore held while writing a data message.
+	// The compressor and wbuf are guarded by writeMsgMu.
+	writeMsgMu chan struct{}
+	fw         *flate.Writer
+	wbuf       payloadBuffer
+
+	// writeMu guards writes to bw, which are one frame at a time,
+	// and the fields below.
+	writeMu   sync.Mutex
+	writeErr  error // sticky
+	closeSent bool
+	whdrBuf   [maxFrameHeaderLen]byte
+	maskBuf   []byte
+
+	closeOnce     sync.Once
+	closed        chan struct{} // closed by closeNow
+	closeErr      error         // result of closeTransport
+	closeReceived chan struct{} // closed when peerClose is set
+	peerClose     *CloseError
+
+	pingMu    sync.Mutex
+	pingCount uint64
+	pings     map[string]chan struct{}
+}
+
+// connConfig is the configuration of a new Conn.
+type connConfig struct {
+	br          *bufio.Reader
+	bw          *bufio.Writer
+	flush       func() error
+	close       func() error
+	client      bool
+	subprotocol string
+	deflate     *deflateParams
+}
+
+func newConn(cfg connConfig) *Conn {
+	c := &Conn{
+		br:             cfg.br,
+		bw:             cfg.bw,
+		flush:          cfg.flush,
+		closeTransport: cfg.close,
+		client:         cfg.client,
+		subprotocol:    cfg.subprotocol,
+		deflate:        cfg.deflate,
+		readMu:         make(chan struct{}, 1),
+		writeMsgMu:     make(chan struct{}, 1),
+		closed:         make(chan struct{}),
+		closeReceived:  make(chan struct{}),
+		pings:          make(map[string]chan struct{}),
+	}
+	c.readLimit.Store(defaultReadLimit)
+	return c
+}
+
+// Subprotocol returns the subprotocol negotiated during the opening
+// handshake, or "" if none was.
+func (c *Conn) Subprotocol() string {
+	return c.subprotocol
+}
+
+// SetReadLimit sets the maximum size of a message read from c.
+// The size of a compressed message is its size after decompression.
+// If a message exceeds the limit, the connection is closed with
+// [StatusMessageTooBig]. The default limit is 32 MiB.
+func (c *Conn) SetReadLimit(n int64) {
+	c.readLimit.Store(n)
+}
+
+// isClosed reports whether closeNow has been called.
+func (c *Conn) isClosed() bool {
+	select {
+	case <-c.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// closedErr returns the error reported by operations on a closed Conn.
+func (c *Conn) closedErr() error {
+	select {
+	case <-c.closeReceived:
+		return c.peerClose
+	default:
+		return net.ErrClosed
+	}
+}
+
+// closeNow closes the underlying transport.
+func (c *Conn) closeNow() {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+		c.closeErr = c.closeTransport()
+	})
+}
+
+// watch calls f, closing the connection if ctx is done before f returns.
+// If ctx is done, watch returns ctx.Err().
+func (c *Conn) watch(ctx context.Context, f func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	stop := context.AfterFunc(ctx, c.closeNow)
+	err := f()
+	if !stop() {
+		return ctx.Err()
+	}
+	return err
+}
+
+// Reading.
+
+func (c *Conn) lockRead(ctx context.Context) error {
+	select {
+	case c.readMu <- struct{}{}:
+		return nil
+	case <-c.closed:
+		return c.closedErr()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *Conn) unlockRead() {
+	<-c.readMu
+}
+
+// Read reads the next data message from c.
+//
+// If ctx is done before the message has been read, Read closes
+// the connection and returns ctx.Err().
+func (c *Conn) Read(ctx context.Context) (MessageType, []byte, error) {
+	typ, r, err := c.Reader(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	return typ, b, nil
+}
+
+// Reader returns the type of the next data message from c and a
+// reader for its payload. Any unread part of the previous message
+// is discarded.
+//
+// The reader returns [io.EOF] at the end of the message.
+// If ctx is done before the message has been read, the reader closes
+// the connection and returns ctx.Err().
+func (c *Conn) Reader(ctx context.Context) (MessageType, io.Reader, error) {
+	if err := c.lockRead(ctx); err != nil {
+		return 0, nil, err
+	}
+	defer c.unlockRead()
+	var mr *messageReader
+	err := c.watch(ctx, func() (err error) {
+		mr, err = c.nextMessage()
+		return err
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	mr.ctx = ctx
+	return mr.typ, mr, nil
+}
+
+// nextMessage discards the rest of the current message,
+// and reads the header of the next data message.
+func (c *Conn) nextMessage() (*messageReader, error) {
+	if mr := c.msgReader; mr != nil {
+		c.msgReader = nil
+		if mr.err == nil {
+			if _, err := io.Copy(io.Discard, readerFunc(mr.read)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if c.readErr != nil {
+		return nil, c.readErr
+	}
+	if c.isClosed() {
+		return nil, c.closedErr()
+	}
+	h, err := c.nextFrame()
+	if err != nil {
+		return nil, err
+	}
+	if h.op == opContinuation {
+		return nil, c.fail(StatusProtocolError, protocolError("unexpected continuation frame"))
+	}
+	mr := &messageReader{c: c, typ: MessageType(h.op)}
+	if h.rsv1 {
+		var dict []byte
+		if !c.deflate.readNoTakeover {
+			dict = c.readDict
+			mr.saveDict = true
+		}
+		src := &deflateSource{c: c, tail: -1}
+		mr.src = src
+		if c.fr == nil {
+			c.fr = flate.NewReaderDict(src, dict)
+		} else if err := c.fr.(flate.Resetter).Reset(src, dict); err != nil {
+			return nil, err
+		}
+		mr.fr = c.fr
+	}
+	c.msgReader = mr
+	return mr, nil
+}
+
+// nextFrame reads frame headers until the next data frame,
+// handling any control frames before it.
+func (c *Conn) nextFrame() (frameHeader, error) {
+	for {
+		h, err := readFrameHeader(c.br, &c.hdrBuf)
+		if err != nil {
+			return h, c.readFailed(noEOF(err))
+		}
+		switch {
+		case h.masked == c.client:
+			// Clients mask their frames; servers do not.
+			return h, c.fail(StatusProtocolError, protocolError("incorrect masking"))
+		case h.rsv1 && (c.deflate == nil || h.op == opContinuation || h.op.isControl()):
+			return h, c.fail(StatusProtocolError, protocolError("unexpected RSV1 bit"))
+		}
+		if !h.op.isControl() {
+			c.frame = h
+			c.frameRemain = h.length
+			c.maskPos = 0
+			return h, nil
+		}
+		if err := c.handleControl(h); err != nil {
+			return h, err
+		}
+	}
+}
+
+// handleControl reads the payload of the control frame h, and acts on it.
+func (c *Conn) handleControl(h frameHeader) error {
+	p := c.ctrlBuf[:h.length]
+	if _, err := io.ReadFull(c.br, p); err != nil {
+		return c.readFailed(noEOF(err))
+	}
+	if h.masked {
+		maskBytes(h.maskKey, 0, p)
+	}
+	switch h.op {
+	case opPing:
+		// Errors writing the pong are reported to the writer.
+		c.writeControl(opPong, p)
+	case opPong:
+		c.pingMu.Lock()
+		if ch, ok := c.pings[string(p)]; ok {
+			close(ch)
+			delete(c.pings, string(p))
+		}
+		c.pingMu.Unlock()
+	case opClose:
+		ce := &CloseError{Code: StatusNoStatusRcvd}
+		if len(p) == 1 {
+			return c.fail(StatusProtocolError, protocolError("invalid close frame"))
+		}
+		if len(p) >= 2 {
+			ce.Code = StatusCode(binary.BigEndian.Uint16(p))
+			ce.Reason = string(p[2:])
+			if !validWireStatus(ce.Code) {
+				return c.fail(StatusProtocolError, protocolError("invalid close status code"))
+			}
+			if !utf8.ValidString(ce.Reason) {
+				return c.fail(StatusInvalidFramePayloadData, errInvalidUTF8)
+			}
+		}
+		c.readErr = ce
+		c.peerClose = ce
+		close(c.closeReceived)
+		// Echo the status code, unless we have already sent a close frame.
+		c.writeClose(ce.Code, "")
+		c.closeNow()
+		return ce
+	}
+	return nil
+}
+
+// readData reads the payload of the current data message into p.
+// It returns io.EOF at the end of the message.
+func (c *Conn) readData(p []byte) (int, error) {
+	for c.frameRemain == 0 {
+		if c.frame.fin {
+			return 0, io.EOF
+		}
+		h, err := c.nextFrame()
+		if err != nil {
+			return 0, err
+		}
+		if h.op != opContinuation {
+			return 0, c.fail(StatusProtocolError, protocolError("expected continuation frame"))
+		}
+	}
+	if int64(len(p)) > c.frameRemain {
+		p = p[:c.frameRemain]
+	}
+	n, err := c.br.Read(p)
+	c.frameRemain -= int64(n)
+	if c.frame.masked {
+		c.maskPos = maskBytes(c.frame.maskKey, c.maskPos, p[:n])
+	}
+	if err != nil {
+		return n, c.readFailed(noEOF(err))
+	}
+	return n, nil
+}
+
+// readFailed records the failure of a read from the connection with err.
+func (c *Conn) readFailed(err error) error {
+	var perr protocolError
+	if errors.As(err, &perr) {
+		return c.fail(StatusProtocolError, err)
+	}
+	if c.isClosed() {
+		err = c.closedErr()
+	}
+	c.readErr = err
+	c.closeNow()
+	return err
+}
+
+// fail fails the connection with a close frame with the given status,
+// recording err as the error for subsequent reads.
+func (c *Conn) fail(code StatusCode, err error) error {
+	c.readErr = err
+	c.writeClose(code, "")
+	c.closeNow()
+	return err
+}
+
+// A messageReader reads the payload of a data message.
+type messageReader struct {
+	c        *Conn
+	ctx      context.Context
+	typ      MessageType
+	fr       io.Reader // decompressor, for a compressed message
+	src      *deflateSource
+	saveDict bool  // record decompressed data in c.readDict
+	n        int64 // bytes read
+	err      error // sticky
+
+	// partial holds an incomplete UTF-8 sequence at the end
+	// of the last read of a text message.
+	partial    [utf8.UTFMax]byte
+	partialLen int
+}
+
+func (r *messageReader) Read(p []byte) (int, error) {
+	c := r.c
+	if err := c.lockRead(r.ctx); err != nil {
+		return 0, err
+	}
+	defer c.unlockRead()
+	if c.msgReader != r {
+		return 0, errStaleReader
+	}
+	var n int
+	err := c.watch(r.ctx, func() (err error) {
+		n, err = r.read(p)
+		return err
+	})
+	return n, err
+}
+
+// read reads from the message, with c.readMu held.
+func (r *messageReader) read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	c := r.c
+	var n int
+	var err error
+	if r.fr != nil {
+		n, err = r.fr.Read(p)
+		if err == io.EOF && r.src.tail < 0 && (c.frameRemain > 0 || !c.frame.fin) {
+			// The compressed data ended before the payload.
+			err = errTrailingData
+		}
+		if err != nil && err != io.EOF && c.readErr == nil {
+			err = c.fail(StatusInvalidFramePayloadData, fmt.Errorf("websocket: invalid compressed message: %w", err))
+		}
+	} else {
+		n, err = c.readData(p)
+	}
+	if n > 0 {
+		r.n += int64(n)
+		if r.n > c.readLimit.Load() {
+			r.err = c.fail(StatusMessageTooBig, errMessageTooBig)
+			return 0, r.err
+		}
+		if r.typ == TextMessage && !r.validUTF8(p[:n]) {
+			r.err = c.fail(StatusInvalidFramePayloadData, errInvalidUTF8)
+			return 0, r.err
+		}
+		if r.saveDict {
+			c.readDict = appendWindow(c.readDict, p[:n])
+		}
+	}
+	if err == io.EOF && r.partialLen > 0 {
+		err = c.fail(StatusInvalidFramePayloadData, errInvalidUTF8)
+		n = 0
+	}
+	r.err = err
+	return n, err
+}
+
+// validUTF8 reports whether p continues a valid UTF-8 text,
+// holding back an incomplete sequence at the end of p.
+func (r *messageReader) validUTF8(p []byte) bool {
+	if r.partialLen > 0 {
+		for len(p) > 0 && !utf8.FullRune(r.partial[:r.partialLen]) {
+			r.partial[r.partialLen] = p[0]
+			r.partialLen++
+			p = p[1:]
+		}
+		if !utf8.FullRune(r.partial[:r.partialLen]) {
+			return true
+		}
+		if ch, size := utf8.DecodeRune(r.partial[:r.partialLen]); ch == utf8.RuneError && size == 1 {
+			return false
+		}
+		r.partialLen = 0
+	}
+	end := len(p)
+	for i := len(p) - 1; i >= 0 && i > len(p)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(p[i]) {
+			if !utf8.FullRune(p[i:]) {
+				end = i
+			}
+			break
+		}
+	}
+	if !utf8.Valid(p[:end]) {
+		return false
+	}
+	r.partialLen = copy(r.partial[:], p[end:])
+	return true
+}
+
+type readerFunc func(p []byte) (int, error)
+
+func (f readerFunc) Read(p []byte) (int, error) { return f(p) }
+
+// Writing.
+
+func (c *Conn) lockWrite(ctx context.Context) error {
+	select {
+	case c.writeMsgMu <- struct{}{}:
+		return nil
+	case <-c.closed:
+		return c.closedErr()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *Conn) unlockWrite() {
+	<-c.writeMsgMu
+}
+
+// Write writes a data message of type typ with payload p to c.
+//
+// If ctx is done before the message has been written, Write closes
+// the connection and returns ctx.Err().
+func (c *Conn) Write(ctx context.Context, typ MessageType, p []byte) error {
+	w, err := c.writer(ctx, typ)
+	if err != nil {
+		return err
+	}
+	return w.finish(p)
+}
+
+// Writer returns a writer for a data message of type typ.
+// The message is sent in fragments as it is written,
+// and is complete when the writer is closed.
+// The writer must be closed before the next message is written.
+//
+// If ctx is done before the writer has been closed, the writer
+// closes the connection and returns ctx.Err().
+func (c *Conn) Writer(ctx context.Context, typ MessageType) (io.WriteCloser, error) {
+	return c.writer(ctx, typ)
+}
+
+func (c *Conn) writer(ctx context.Context, typ MessageType) (*messageWriter, error) {
+	if typ != TextMessage && typ != BinaryMessage {
+		return nil, errBadType
+	}
+	if err := c.lockWrite(ctx); err != nil {
+		return nil, err
+	}
+	c.wbuf.reset()
+	return &messageWriter{
+		c:          c,
+		ctx:        ctx,
+		op:         opcode(typ),
+		compressed: c.deflate != nil,
+	}, nil
+}
+
+// A messageWriter writes a data message.
+type messageWriter struct {
+	c          *Conn
+	ctx        context.Context
+	op         opcode // opcode of the next frame
+	compressed bool
+	done       bool
+}
+
+func (w *messageWriter) Write(p []byte) (int, error) {
+	if w.done {
+		return 0, errWriterClosed
+	}
+	c := w.c
+	err := c.watch(w.ctx, func() error {
+		if w.compressed {
+			if _, err := c.compressor().Write(p); err != nil {
+				return err
+			}
+		} else {
+			c.wbuf.Write(p)
+		}
+		if len(c.wbuf.b) >= fragmentSize {
+			return w.writeFrame(false)
+		}
+		return nil
+	})
+	if err != nil {
+		w.abort()
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w *messageWriter) Close() error {
+	if w.done {
+		return errWriterClosed
+	}
+	return w.finish(nil)
+}
+
+// finish writes p and completes the message.
+func (w *messageWriter) finish(p []byte) error {
+	c := w.c
+	defer w.abort()
+	return c.watch(w.ctx, func() error {
+		if !w.compressed {
+			if len(c.wbuf.b) == 0 {
+				return w.writePayload(p, true)
+			}
+			c.wbuf.Write(p)
+			return w.writeFrame(true)
+		}
+		fw := c.compressor()
+		if _, err := fw.Write(p); err != nil {
+			return err
+		}
+		if err := fw.Flush(); err != nil {
+			return err
+		}
+		// Remove the empty stored block ending the flushed data.
+		c.wbuf.b = c.wbuf.b[:len(c.wbuf.b)-4]
+		if c.deflate.writeNoTakeover {
+			fw.Reset(&c.wbuf)
+		}
+		return w.writeFrame(true)
+	})
+}
+
+// abort releases the writer's lock on the connection.
+func (w *messageWriter) abort() {
+	if !w.done {
+		w.done = true
+		w.c.unlockWrite()
+	}
+}
+
+// writeFrame writes the contents of c.wbuf as a frame.
+// A compressed message may end with an empty stored block,
+// which is removed when the message is complete, so the last
+// four bytes of c.wbuf are held back from a non-final frame.
+func (w *messageWriter) writeFrame(final bool) error {
+	c := w.c
+	b := c.wbuf.b
+	keep := 0
+	if w.compressed && !final {
+		keep = 4
+	}
+	if err := w.writePayload(b[:len(b)-keep], final); err != nil {
+		return err
+	}
+	c.wbuf.b = b[:copy(b, b[len(b)-keep:])]
+	return nil
+}
+
+// writePayload writes p as the payload of the next frame of the message.
+func (w *messageWriter) writePayload(p []byte, final bool) error {
+	c := w.c
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.closeSent {
+		return errCloseSent
+	}
+	h := frameHeader{
+		fin:  final,
+		op:   w.op,
+		rsv1: w.compressed && w.op != opContinuation,
+	}
+	w.op = opContinuation
+	return c.writeFrameLocked(h, p, final)
+}
+
+// writeControl writes a control frame.
+func (c *Conn) writeControl(op opcode, p []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.closeSent {
+		return errCloseSent
+	}
+	return c.writeFrameLocked(frameHeader{fin: true, op: op}, p, true)
+}
+
+// writeClose writes a close frame, if one has not already been sent.
+func (c *Conn) writeClose(code StatusCode, reason string) error {
+	var p []byte
+	if code != StatusNoStatusRcvd {
+		p = binary.BigEndian.AppendUint16(make([]byte, 0, 2+len(reason)), uint16(code))
+		p = append(p, reason...)
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.closeSent {
+		return errCloseSent
+	}
+	c.closeSent = true
+	return c.writeFrameLocked(frameHeader{fin: true, op: opClose}, p, true)
+}
+
+// writeFrameLocked writes a frame with header h and payload p,
+// with c.writeMu held. It does not modify p.
+func (c *Conn) writeFrameLocked(h frameHeader, p []byte, flush bool) error {
+	if c.writeErr != nil {
+		return c.writeErr
+	}
+	h.length = int64(len(p))
+	if c.client {
+		h.masked = true
+		binary.LittleEndian.PutUint32(h.maskKey[:], rand.Uint32())
+	}
+	_, err := c.bw.Write(appendFrameHeader(c.whdrBuf[:0], h))
+	if err == nil {
+		if h.masked {
+			err = c.writeMasked(h.maskKey, p)
+		} else {
+			_, err = c.bw.Write(p)
+		}
+	}
+	if err == nil && flush {
+		err = c.flush()
+	}
+	if err != nil {
+		if c.isClosed() {
+			err = c.closedErr()
+		}
+		c.writeErr = err
+		c.closeNow()
+	}
+	return err
+}
+
+// writeMasked writes p masked with key to c.bw.
+func (c *Conn) writeMasked(key [4]byte, p []byte) error {
+	if c.maskBuf == nil {
+		c.maskBuf = make([]byte, 4096)
+	}
+	pos := 0
+	for len(p) > 0 {
+		n := copy(c.maskBuf, p)
+		p = p[n:]
+		pos = maskBytes(key, pos, c.maskBuf[:n])
+		if _, err := c.bw.Write(c.maskBuf[:n]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Ping sends a ping frame to the peer, and waits for the reply.
+//
+// Replies are received by the reader, so Ping returns only while
+// another goroutine is reading from c.
+func (c *Conn) Ping(ctx context.Context) error {
+	c.pingMu.Lock()
+	c.pingCount++
+	p := binary.BigEndian.AppendUint64(nil, c.pingCount)
+	ch := make(chan struct{})
+	c.pings[string(p)] = ch
+	c.pingMu.Unlock()
+	defer func() {
+		c.pingMu.Lock()
+		delete(c.pings, string(p))
+		c.pingMu.Unlock()
+	}()
+
+	if err := c.writeControl(opPing, p); err != nil {
+		return err
+	}
+	select {
+	case <-ch:
+		return nil
+	case <-c.closed:
+		return c.closedErr()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close performs the closing handshake: it sends a close frame with
+// the given status code and reason, waits for the peer's close frame,
+// and closes the connection.
+//
+// The reason must be at most 123 bytes long. If no other goroutine is
+// reading from c, Close reads and discards messages until it receives
+// the peer's close frame. Close closes the connection without waiting
+// further if the peer has not replied within five seconds.
+func (c *Conn) Close(code StatusCode, reason string) error {
+	if !validWireStatus(code) {
+		return errInvalidStatus
+	}
+	if len(reason) > maxControlPayload-2 {
+		return errReasonTooLong
+	}
+	if err := c.writeClose(code, reason); err != nil {
+		c.closeNow()
+		return err
+	}
+
+	t := time.AfterFunc(closeTimeout, c.closeNow)
+	defer t.Stop()
+	select {
+	case c.readMu <- struct{}{}:
+		for {
+			if _, err := c.nextMessage(); err != nil {
+				break
+			}
+		}
+		c.unlockRead()
+	case <-c.closeReceived:
+	case <-c.closed:
+	}
+	c.closeNow()
+	return nil
+}
+
+// CloseNow closes the connection without a closing handshake.
+func (c *Conn) CloseNow() error {
+	if c.isClosed() {
+		return net.ErrClosed
+	}
+	c.closeNow()
+	return c.closeErr
+}
@@ -0,0 +1,251 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package websocket
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// DialOptions configures [Dial].
+type DialOptions struct {
+	// HTTPClient is the client used to send the opening handshake.
+	// If nil, [http.DefaultClient] is used.
+	// The client's Timeout, if any, applies to the opening handshake.
+	HTTPClient *http.Client
+
+	// Header holds additional header fields for the opening handshake,
+	// such as Origin or Authorization.
+	Header http.Header
+
+	// Subprotocols lists the subprotocols the client supports,
+	// in order of preference.
+	Subprotocols []string
+
+	// EnableCompression enables the permessage-deflate extension,
+	// if the server supports it.
+	EnableCompression bool
+}
+
+// Dial opens a WebSocket connection to the URL urlStr,
+// whose scheme is "ws" or "wss".
+//
+// For a "wss" URL, Dial first tries to open the connection over
+// HTTP/2 with an extended CONNECT request, and falls back to an
+// HTTP/1 upgrade request if that fails.
+//
+// Dial returns the server's response to the opening handshake.
+// If the handshake fails, the response, if any, holds the first
+// kilobyte of its body. Otherwise, the response has no body.
+//
+// The ctx applies only to the opening handshake.
+// Once Dial returns, the connection is no longer affected by ctx.
+func Dial(ctx context.Context, urlStr string, opts *DialOptions) (*Conn, *http.Response, error) {
+	if opts == nil {
+		opts = &DialOptions{}
+	}
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch u.Scheme {
+	case "ws":
+		u.Scheme = "http"
+	case "wss":
+		u.Scheme = "https"
+	default:
+		return nil, nil, errors.New("websocket: unsupported URL scheme " + u.Scheme)
+	}
+	client := http.DefaultClient
+	if opts.HTTPClient != nil {
+		client = opts.HTTPClient
+	}
+	if client.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, client.Timeout)
+		defer cancel()
+		c := *client
+		c.Timeout = 0
+		client = &c
+	}
+
+	// The request's context must outlive ctx, since canceling it
+	// closes the connection.
+	connCtx, connCancel := context.WithCancel(context.WithoutCancel(ctx))
+	stop := context.AfterFunc(ctx, connCancel)
+
+	var c *Conn
+	var resp *http.Response
+	err = errors.ErrUnsupported
+	if u.Scheme == "https" {
+		c, resp, err = dial2(connCtx, connCancel, client, u, opts)
+	}
+	if err != nil && resp == nil && ctx.Err() == nil {
+		c, resp, err = dial1(connCtx, connCancel, client, u, opts)
+	}
+	if !stop() {
+		if c != nil {
+			c.CloseNow()
+		}
+		c, err = nil, ctx.Err()
+	}
+	if err != nil {
+		connCancel()
+		return nil, resp, err
+	}
+	return c, resp, nil
+}
+
+// dial1 opens a connection with an HTTP/1 upgrade request.
+func dial1(ctx context.Context, cancel context.CancelFunc, client *http.Client, u *url.URL, opts *DialOptions) (*Conn, *http.Response, error) {
+	req, err := newHandshakeRequest(ctx, "GET", u, nil, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	var nonce [16]byte
+	rand.Read(nonce[:])
+	key := base64.StdEncoding.EncodeToString(nonce[:])
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Key", key)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		return nil, truncateBody(resp), errors.New("websocket: handshake failed: " + resp.Status)
+	}
+	fail := func(msg string) (*Conn, *http.Response, error) {
+		resp.Body.Close()
+		resp.Body = http.NoBody
+		return nil, resp, errors.New("websocket: handshake failed: " + msg)
+	}
+	rwc, ok := resp.Body.(io.ReadWriteCloser)
+	if !ok {
+		return fail("response body is not writable")
+	}
+	if !headerContainsToken(resp.Header, "Connection", "upgrade") ||
+		!headerContainsToken(resp.Header, "Upgrade", "websocket") {
+		return fail("invalid Upgrade response")
+	}
+	if resp.Header.Get("Sec-WebSocket-Accept") != acceptKey(key) {
+		return fail("invalid Sec-WebSocket-Accept")
+	}
+	subprotocol, dp, err := checkResponse(resp, opts)
+	if err != nil {
+		return fail(err.Error())
+	}
+	resp.Body = http.NoBody
+	bw := bufio.NewWriter(rwc)
+	return newConn(connConfig{
+		br:    bufio.NewReader(rwc),
+		bw:    bw,
+		flush: bw.Flush,
+		close: func() error {
+			err := rwc.Close()
+			cancel()
+			return err
+		},
+		client:      true,
+		subprotocol: subprotocol,
+		deflate:     dp,
+	}), resp, nil
+}
+
+// dial2 opens a connection with an HTTP/2 extended CONNECT request.
+func dial2(ctx context.Context, cancel context.CancelFunc, client *http.Client, u *url.URL, opts *DialOptions) (*Conn, *http.Response, error) {
+	pr, pw := io.Pipe()
+	req, err := newHandshakeRequest(ctx, "CONNECT", u, pr, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set(":protocol", "websocket")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		pw.Close()
+		return nil, nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		pw.Close()
+		return nil, truncateBody(resp), errors.New("websocket: handshake failed: " + resp.Status)
+	}
+	subprotocol, dp, err := checkResponse(resp, opts)
+	if err != nil {
+		pw.Close()
+		resp.Body.Close()
+		resp.Body = http.NoBody
+		return nil, resp, errors.New("websocket: handshake failed: " + err.Error())
+	}
+	body := resp.Body
+	resp.Body = http.NoBody
+	bw := bufio.NewWriter(pw)
+	return newConn(connConfig{
+		br:    bufio.NewReader(body),
+		bw:    bw,
+		flush: bw.Flush,
+		close: func() error {
+			pw.Close()
+			err := body.Close()
+			cancel()
+			return err
+		},
+		client:      true,
+		subprotocol: subprotocol,
+		deflate:     dp,
+	}), resp, nil
+}
+
+// newHandshakeRequest returns an opening handshake request
+// with the headers common to HTTP/1 and HTTP/2.
+func newHandshakeRequest(ctx context.Context, method string, u *url.URL, body io.Reader, opts *DialOptions) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Header != nil {
+		req.Header = opts.Header.Clone()
+	}
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	if len(opts.Subprotocols) > 0 {
+		req.Header.Set("Sec-WebSocket-Protocol", strings.Join(opts.Subprotocols, ", "))
+	}
+	if opts.EnableCompression {
+		req.Header.Set("Sec-WebSocket-Extensions", deflateExtension)
+	}
+	return req, nil
+}
+
+// checkResponse checks the subprotocol and extensions selected by the
+// server in resp.
+func checkResponse(resp *http.Response, opts *DialOptions) (string, *deflateParams, error) {
+	subprotocol := resp.Header.Get("Sec-WebSocket-Protocol")
+	if subprotocol != "" && !slices.Contains(opts.Subprotocols, subprotocol) {
+		return "", nil, errors.New("server selected a subprotocol that was not offered")
+	}
+	dp, err := checkDeflateResponse(resp.Header, opts.EnableCompression)
+	if err != nil {
+		return "", nil, err
+	}
+	return subprotocol, dp, nil
+}
+
+// truncateBody replaces the body of resp with its first kilobyte.
+func truncateBody(resp *http.Response) *http.Response {
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(b))
+	return resp
+}
@@ -0,0 +1,154 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package websocket
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// An opcode is the type of a frame, from RFC 6455, Section 5.2.
+type opcode byte
+
+const (
+	opContinuation opcode = 0x0
+	opText         opcode = 0x1
+	opBinary       opcode = 0x2
+	opClose        opcode = 0x8
+	opPing         opcode = 0x9
+	opPong         opcode = 0xa
+)
+
+func (op opcode) isControl() bool { return op&0x8 != 0 }
+
+// A frameHeader is the header of a frame.
+type frameHeader struct {
+	fin     bool
+	rsv1    bool // set on the first frame of a compressed message
+	op      opcode
+	masked  bool
+	maskKey [4]byte
+	length  int64
+}
+
+// maxFrameHeaderLen is the length of the longest frame header.
+const maxFrameHeaderLen = 2 + 8 + 4
+
+// A protocolError is a violation of the protocol by the peer.
+// It fails the connection with StatusProtocolError.
+type protocolError string
+
+func (e protocolError) Error() string { return "websocket: protocol error: " + string(e) }
+
+// readFrameHeader reads a frame header from r.
+// It checks only that the header is well formed,
+// not that it is valid on the connection.
+func readFrameHeader(r io.Reader, buf *[maxFrameHeaderLen]byte) (frameHeader, error) {
+	var h frameHeader
+	b := buf[:2]
+	if _, err := io.ReadFull(r, b); err != nil {
+		return h, err
+	}
+	h.fin = b[0]&0x80 != 0
+	h.rsv1 = b[0]&0x40 != 0
+	if b[0]&0x30 != 0 {
+		return h, protocolError("reserved bits set")
+	}
+	h.op = opcode(b[0] & 0xf)
+	switch h.op {
+	case opContinuation, opText, opBinary, opClose, opPing, opPong:
+	default:
+		return h, protocolError("unknown opcode")
+	}
+	h.masked = b[1]&0x80 != 0
+	lenBytes := 0
+	switch l := b[1] & 0x7f; l {
+	case 126:
+		lenBytes = 2
+	case 127:
+		lenBytes = 8
+	default:
+		h.length = int64(l)
+	}
+	n := lenBytes
+	if h.masked {
+		n += 4
+	}
+	b = buf[:n]
+	if _, err := io.ReadFull(r, b); err != nil {
+		return h, noEOF(err)
+	}
+	switch lenBytes {
+	case 2:
+		h.length = int64(binary.BigEndian.Uint16(b))
+	case 8:
+		u := binary.BigEndian.Uint64(b)
+		if u>>63 != 0 {
+			return h, protocolError("frame length overflow")
+		}
+		h.length = int64(u)
+	}
+	if h.masked {
+		copy(h.maskKey[:], b[lenBytes:])
+	}
+	if h.op.isControl() {
+		if !h.fin {
+			return h, protocolError("fragmented control frame")
+		}
+		if h.length > maxControlPayload {
+			return h, protocolError("control frame too long")
+		}
+	}
+	return h, nil
+}
+
+// appendFrameHeader appends the encoding of h to b.
+func appendFrameHeader(b []byte, h frameHeader) []byte {
+	b0 := byte(h.op)
+	if h.fin {
+		b0 |= 0x80
+	}
+	if h.rsv1 {
+		b0 |= 0x40
+	}
+	var maskBit byte
+	if h.masked {
+		maskBit = 0x80
+	}
+	switch {
+	case h.length <= 125:
+		b = append(b, b0, maskBit|byte(h.length))
+	case h.length <= 0xffff:
+		b = append(b, b0, maskBit|126)
+		b = binary.BigEndian.AppendUint16(b, uint16(h.length))
+	default:
+		b = append(b, b0, maskBit|127)
+		b = binary.BigEndian.AppendUint64(b, uint64(h.length))
+	}
+	if h.masked {
+		b = append(b, h.maskKey[:]...)
+	}
+	return b
+}
+
+// maskBytes masks or unmasks b with key, where pos is the offset
+// of b in the frame's payload. It returns the offset after b.
+func maskBytes(key [4]byte, pos int, b []byte) int {
+	for i := range b {
+		b[i] ^= key[pos&3]
+		pos++
+	}
+	return pos & 3
+}
+
+// noEOF converts io.EOF to io.ErrUnexpectedEOF, for reads which
+// must not end at the end of the stream.
+func noEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
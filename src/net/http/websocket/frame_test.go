@@ -0,0 +1,207 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package websocket
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestFrameHeaderRoundTrip(t *testing.T) {
+	for _, h := range []frameHeader{
+		{fin: true, op: opText, length: 0},
+		{fin: true, op: opBinary, length: 125},
+		{fin: false, op: opText, rsv1: true, length: 126},
+		{fin: false, op: opContinuation, length: 0xffff},
+		{fin: true, op: opBinary, length: 0x10000},
+		{fin: true, op: opPing, masked: true, maskKey: [4]byte{1, 2, 3, 4}, length: 5},
+		{fin: true, op: opBinary, masked: true, maskKey: [4]byte{5, 6, 7, 8}, length: 1 << 40},
+	} {
+		b := appendFrameHeader(nil, h)
+		var buf [maxFrameHeaderLen]byte
+		got, err := readFrameHeader(bytes.NewReader(b), &buf)
+		if err != nil {
+			t.Errorf("readFrameHeader(appendFrameHeader(%+v)): %v", h, err)
+			continue
+		}
+		if got != h {
+			t.Errorf("readFrameHeader(appendFrameHeader(%+v)) = %+v", h, got)
+		}
+	}
+}
+
+func TestReadFrameHeaderErrors(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		b    string
+	}{
+		{"reserved bits", "\xa1\x00"},
+		{"unknown opcode", "\x83\x00"},
+		{"fragmented control frame", "\x09\x00"},
+		{"long control frame", "\x89\x7e\x00\x7e"},
+		{"length overflow", "\x82\x7f\x80\x00\x00\x00\x00\x00\x00\x00"},
+	} {
+		var buf [maxFrameHeaderLen]byte
+		_, err := readFrameHeader(bytes.NewReader([]byte(test.b)), &buf)
+		var perr protocolError
+		if !errors.As(err, &perr) {
+			t.Errorf("%v: readFrameHeader error = %v, want protocol error", test.name, err)
+		}
+	}
+	var buf [maxFrameHeaderLen]byte
+	if _, err := readFrameHeader(bytes.NewReader([]byte("\x82\x7e\x01")), &buf); err != io.ErrUnexpectedEOF {
+		t.Errorf("readFrameHeader of truncated header: %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+}
+
+func TestValidUTF8(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"hello",
+		"héllo, wörld",
+		"日本語",
+		"\U0001F600\U0001F600",
+		"�",
+		"\xff",
+		"h\xc3",
+		"\xe6\x97",
+		"\xed\xa0\x80", // surrogate
+	} {
+		want := utf8.ValidString(s)
+		// Split s at every pair of positions.
+		for i := 0; i <= len(s); i++ {
+			for j := i; j <= len(s); j++ {
+				var r messageReader
+				ok := r.validUTF8([]byte(s[:i])) &&
+					r.validUTF8([]byte(s[i:j])) &&
+					r.validUTF8([]byte(s[j:])) &&
+					r.partialLen == 0
+				if ok != want {
+					t.Errorf("validUTF8(%q split at %v, %v) = %v, want %v", s, i, j, ok, want)
+				}
+			}
+		}
+	}
+}
+
+func TestAcceptDeflate(t *testing.T) {
+	for _, test := range []struct {
+		offer string
+		want  string
+	}{
+		{"", ""},
+		{"x-webkit-deflate-frame", ""},
+		{"permessage-deflate", "permessage-deflate"},
+		{"permessage-deflate; client_max_window_bits", "permessage-deflate"},
+		{"permessage-deflate; server_no_context_takeover; client_no_context_takeover",
+			"permessage-deflate; server_no_context_takeover; client_no_context_takeover"},
+		{"permessage-deflate; server_max_window_bits=10", ""},
+		{"permessage-deflate; server_max_window_bits=10, permessage-deflate",
+			"permessage-deflate"},
+		{`permessage-deflate; server_max_window_bits="15"`,
+			"permessage-deflate; server_max_window_bits=15"},
+		{"permessage-deflate; unknown", ""},
+	} {
+		h := http.Header{}
+		if test.offer != "" {
+			h.Set("Sec-WebSocket-Extensions", test.offer)
+		}
+		_, got := acceptDeflate(h)
+		if got != test.want {
+			t.Errorf("acceptDeflate(%q) = %q, want %q", test.offer, got, test.want)
+		}
+	}
+}
+
+// newPipeConn returns a Conn for one end of a net.Pipe,
+// and the other end of the pipe.
+func newPipeConn(client bool, dp *deflateParams) (*Conn, net.Conn) {
+	c1, c2 := net.Pipe()
+	bw := bufio.NewWriter(c1)
+	return newConn(connConfig{
+		br:      bufio.NewReader(c1),
+		bw:      bw,
+		flush:   bw.Flush,
+		close:   c1.Close,
+		client:  client,
+		deflate: dp,
+	}), c2
+}
+
+func TestReadCompressedExample(t *testing.T) {
+	// The examples of RFC 7692, Section 7.2.3.
+	c, peer := newPipeConn(true, &deflateParams{})
+	defer c.CloseNow()
+	go func() {
+		// "Hello", compressed.
+		peer.Write([]byte("\xc1\x07\xf2\x48\xcd\xc9\xc9\x07\x00"))
+		// "Hello" again, using the previous message as a dictionary.
+		peer.Write([]byte("\xc1\x05\xf2\x00\x11\x00\x00"))
+		// "Hello", compressed and fragmented.
+		peer.Write([]byte("\x41\x03\xf2\x48\xcd"))
+		peer.Write([]byte("\x80\x04\xc9\xc9\x07\x00"))
+	}()
+	for i := range 3 {
+		typ, msg, err := c.Read(context.Background())
+		if err != nil {
+			t.Fatalf("message %v: Read: %v", i, err)
+		}
+		if typ != TextMessage || string(msg) != "Hello" {
+			t.Fatalf("message %v: Read = %v %q, want TextMessage %q", i, typ, msg, "Hello")
+		}
+	}
+}
+
+func TestReadProtocolErrors(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		client bool
+		frames string
+		code   StatusCode
+	}{
+		{"unmasked frame from client", false, "\x81\x00", StatusProtocolError},
+		{"masked frame from server", true, "\x81\x80\x00\x00\x00\x00", StatusProtocolError},
+		{"unexpected continuation", true, "\x80\x00", StatusProtocolError},
+		{"missing continuation", true, "\x01\x01a\x81\x01b", StatusProtocolError},
+		{"compressed without extension", true, "\xc1\x00", StatusProtocolError},
+		{"invalid UTF-8", true, "\x81\x01\xff", StatusInvalidFramePayloadData},
+		{"truncated UTF-8", true, "\x01\x01\xc3\x80\x00", StatusInvalidFramePayloadData},
+		{"invalid close code", true, "\x88\x02\x03\xed", StatusProtocolError},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			c, peer := newPipeConn(test.client, nil)
+			defer c.CloseNow()
+			go peer.Write([]byte(test.frames))
+			closeFrame := make(chan []byte, 1)
+			go func() {
+				b, _ := io.ReadAll(peer)
+				closeFrame <- b
+			}()
+			if _, _, err := c.Read(context.Background()); err == nil {
+				t.Fatalf("Read succeeded, want error")
+			}
+			b := <-closeFrame
+			var buf [maxFrameHeaderLen]byte
+			r := bytes.NewReader(b)
+			h, err := readFrameHeader(r, &buf)
+			if err != nil || h.op != opClose || h.length < 2 {
+				t.Fatalf("peer received %q, want close frame", b)
+			}
+			p := make([]byte, h.length)
+			io.ReadFull(r, p)
+			maskBytes(h.maskKey, 0, p)
+			if code := StatusCode(int(p[0])<<8 | int(p[1])); code != test.code {
+				t.Errorf("close code = %v, want %v", code, test.code)
+			}
+		})
+	}
+}
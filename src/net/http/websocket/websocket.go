@@ -0,0 +1,185 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package websocket implements the WebSocket protocol defined in RFC 6455.
+//
+// A server accepts WebSocket connections in an HTTP handler by calling
+// [Accept], and a client opens them with [Dial]:
+//
+//	http.HandleFunc("/echo", func(w http.ResponseWriter, r *http.Request) {
+//		c, err := websocket.Accept(w, r, nil)
+//		if err != nil {
+//			return // Accept has replied to the request
+//		}
+//		defer c.CloseNow()
+//		for {
+//			typ, msg, err := c.Read(r.Context())
+//			if err != nil {
+//				return
+//			}
+//			if err := c.Write(r.Context(), typ, msg); err != nil {
+//				return
+//			}
+//		}
+//	})
+//
+// Over HTTP/1, a WebSocket connection begins with a request to upgrade
+// the HTTP connection, which the server then takes over using
+// [http.ResponseController]. Over HTTP/2, a WebSocket connection is
+// a single stream of the HTTP/2 connection, opened with an extended
+// CONNECT request (RFC 8441). An [http.Server] accepts such requests
+// only when the GODEBUG setting http2xconnect=1 is set, for example
+// with a //go:debug http2xconnect=1 directive in the main package.
+// Since the method of the request is then CONNECT rather than GET,
+// the [http.ServeMux] pattern for a WebSocket handler should not
+// specify a method.
+//
+// Messages may be compressed with the permessage-deflate extension
+// defined in RFC 7692, if both peers enable it.
+//
+// A [Conn] supports one concurrent reader and one concurrent writer.
+// Control frames, such as the ping and close frames, are handled by
+// the reader, so an application must keep reading from a connection
+// for [Conn.Ping] and [Conn.Close] to complete promptly.
+package websocket
+
+import (
+	"errors"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/http/httpguts"
+)
+
+// A MessageType is the type of a WebSocket data message.
+type MessageType int
+
+const (
+	// TextMessage denotes a text message, whose payload is UTF-8 text.
+	TextMessage MessageType = 1
+
+	// BinaryMessage denotes a binary message.
+	BinaryMessage MessageType = 2
+)
+
+func (t MessageType) String() string {
+	switch t {
+	case TextMessage:
+		return "TextMessage"
+	case BinaryMessage:
+		return "BinaryMessage"
+	}
+	return "MessageType(" + strconv.Itoa(int(t)) + ")"
+}
+
+// A StatusCode is the status code of a close frame,
+// as defined in RFC 6455, Section 7.4.
+type StatusCode int
+
+const (
+	StatusNormalClosure           StatusCode = 1000
+	StatusGoingAway               StatusCode = 1001
+	StatusProtocolError           StatusCode = 1002
+	StatusUnsupportedData         StatusCode = 1003
+	StatusNoStatusRcvd            StatusCode = 1005 // never sent in a close frame
+	StatusAbnormalClosure         StatusCode = 1006 // never sent in a close frame
+	StatusInvalidFramePayloadData StatusCode = 1007
+	StatusPolicyViolation         StatusCode = 1008
+	StatusMessageTooBig           StatusCode = 1009
+	StatusMandatoryExtension      StatusCode = 1010
+	StatusInternalError           StatusCode = 1011
+	StatusServiceRestart          StatusCode = 1012
+	StatusTryAgainLater           StatusCode = 1013
+	StatusBadGateway              StatusCode = 1014
+	StatusTLSHandshake            StatusCode = 1015 // never sent in a close frame
+)
+
+var statusText = map[StatusCode]string{
+	StatusNormalClosure:           "normal closure",
+	StatusGoingAway:               "going away",
+	StatusProtocolError:           "protocol error",
+	StatusUnsupportedData:         "unsupported data",
+	StatusNoStatusRcvd:            "no status received",
+	StatusAbnormalClosure:         "abnormal closure",
+	StatusInvalidFramePayloadData: "invalid frame payload data",
+	StatusPolicyViolation:         "policy violation",
+	StatusMessageTooBig:           "message too big",
+	StatusMandatoryExtension:      "mandatory extension",
+	StatusInternalError:           "internal error",
+	StatusServiceRestart:          "service restart",
+	StatusTryAgainLater:           "try again later",
+	StatusBadGateway:              "bad gateway",
+	StatusTLSHandshake:            "TLS handshake",
+}
+
+func (c StatusCode) String() string {
+	if s, ok := statusText[c]; ok {
+		return s
+	}
+	return "status " + strconv.Itoa(int(c))
+}
+
+// validWireStatus reports whether c may appear in a close frame.
+func validWireStatus(c StatusCode) bool {
+	switch {
+	case c >= 1000 && c <= 1014:
+		return c != 1004 && c != StatusNoStatusRcvd && c != StatusAbnormalClosure
+	case c >= 3000 && c <= 4999:
+		// Registered with IANA, or for private use.
+		return true
+	}
+	return false
+}
+
+// A CloseError is returned by the methods of a [Conn] after the peer
+// has closed the connection with a close frame.
+// A close frame without a status code has the code [StatusNoStatusRcvd].
+type CloseError struct {
+	Code   StatusCode
+	Reason string
+}
+
+func (e *CloseError) Error() string {
+	s := "websocket: closed by peer: " + strconv.Itoa(int(e.Code)) + " (" + e.Code.String() + ")"
+	if e.Reason != "" {
+		s += ": " + e.Reason
+	}
+	return s
+}
+
+// maxControlPayload is the maximum length of the payload of a control frame.
+const maxControlPayload = 125
+
+var (
+	errCloseSent     = errors.New("websocket: close frame already sent")
+	errReasonTooLong = errors.New("websocket: close reason longer than 123 bytes")
+	errInvalidStatus = errors.New("websocket: invalid close status code")
+	errBadType       = errors.New("websocket: invalid message type")
+)
+
+// The GUID appended to the key of an HTTP/1 opening handshake
+// to compute the accept value, from RFC 6455, Section 1.3.
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// headerTokens returns the comma-separated tokens of the values of
+// header h[key], trimmed of white space.
+func headerTokens(h http.Header, key string) []string {
+	var tokens []string
+	for _, v := range h[http.CanonicalHeaderKey(key)] {
+		for _, t := range strings.Split(v, ",") {
+			if t = textproto.TrimString(t); t != "" {
+				tokens = append(tokens, t)
+			}
+		}
+	}
+	return tokens
+}
+
+// headerContainsToken reports whether header h[key] contains token,
+// compared without regard to case.
+func headerContainsToken(h http.Header, key, token string) bool {
+	return httpguts.HeaderValuesContainsToken(h[http.CanonicalHeaderKey(key)], token)
+}
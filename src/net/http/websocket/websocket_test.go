@@ -0,0 +1,397 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package websocket_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"internal/testenv"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/websocket"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testMode string
+
+const (
+	http1Mode    = testMode("h1")     // HTTP/1 upgrade, without TLS
+	https1Mode   = testMode("https1") // HTTP/1 upgrade over TLS
+	http2Mode    = testMode("h2")     // HTTP/2 extended CONNECT
+	fallbackMode = testMode("h2-fallback")
+)
+
+var allModes = []testMode{http1Mode, https1Mode, http2Mode, fallbackMode}
+
+// xconnect is whether the process runs with GODEBUG=http2xconnect=1,
+// without which HTTP/2 servers do not accept extended CONNECT requests.
+var xconnect = strings.Contains(os.Getenv("GODEBUG"), "http2xconnect=1")
+
+func run(t *testing.T, f func(t *testing.T, mode testMode)) {
+	for _, mode := range allModes {
+		t.Run(string(mode), func(t *testing.T) {
+			switch {
+			case mode == http2Mode && !xconnect:
+				runWithXConnect(t)
+			case mode == fallbackMode && xconnect:
+				t.Skip("HTTP/2 servers accept extended CONNECT with http2xconnect=1")
+			default:
+				f(t, mode)
+			}
+		})
+	}
+}
+
+// runWithXConnect runs the test t in a child process with
+// GODEBUG=http2xconnect=1. The setting is read when net/http
+// is initialized, so it cannot be changed within this process.
+func runWithXConnect(t *testing.T) {
+	testenv.MustHaveExec(t)
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pattern []string
+	for _, name := range strings.Split(t.Name(), "/") {
+		pattern = append(pattern, "^"+regexp.QuoteMeta(name)+"$")
+	}
+	cmd := testenv.Command(t, exe, "-test.run="+strings.Join(pattern, "/"), "-test.v")
+	cmd.Env = append(cmd.Environ(), "GODEBUG=http2xconnect=1")
+	out, err := cmd.CombinedOutput()
+	if err != nil || !bytes.Contains(out, []byte("--- PASS: "+t.Name())) {
+		t.Fatalf("%v: %v\n%s", cmd, err, out)
+	}
+}
+
+// newServer starts a server for the handler h, and returns the URL
+// of the server with a WebSocket scheme and a client for it.
+func newServer(t *testing.T, mode testMode, h http.HandlerFunc) (string, *http.Client) {
+	ts := httptest.NewUnstartedServer(h)
+	switch mode {
+	case http1Mode:
+		ts.Start()
+	case https1Mode:
+		ts.StartTLS()
+	case http2Mode:
+		ts.EnableHTTP2 = true
+		ts.StartTLS()
+	case fallbackMode:
+		// The server supports HTTP/2, but not extended CONNECT.
+		ts.EnableHTTP2 = true
+		ts.StartTLS()
+	}
+	t.Cleanup(ts.Close)
+	return "ws" + strings.TrimPrefix(ts.URL, "http"), ts.Client()
+}
+
+func echoHandler(t *testing.T, opts *websocket.AcceptOptions) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c, err := websocket.Accept(w, r, opts)
+		if err != nil {
+			t.Errorf("Accept: %v", err)
+			return
+		}
+		defer c.CloseNow()
+		for {
+			typ, r, err := c.Reader(context.Background())
+			if err != nil {
+				return
+			}
+			w, err := c.Writer(context.Background(), typ)
+			if err != nil {
+				return
+			}
+			if _, err := io.Copy(w, r); err != nil {
+				return
+			}
+			if err := w.Close(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func TestEcho(t *testing.T) {
+	run(t, func(t *testing.T, mode testMode) {
+		for _, compress := range []bool{false, true} {
+			t.Run("compress="+map[bool]string{false: "false", true: "true"}[compress], func(t *testing.T) {
+				testEcho(t, mode, compress)
+			})
+		}
+	})
+}
+
+func testEcho(t *testing.T, mode testMode, compress bool) {
+	u, client := newServer(t, mode, echoHandler(t, &websocket.AcceptOptions{
+		EnableCompression: true,
+	}))
+	ctx := context.Background()
+	c, resp, err := websocket.Dial(ctx, u, &websocket.DialOptions{
+		HTTPClient:        client,
+		EnableCompression: compress,
+	})
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer c.CloseNow()
+	wantProto := 1
+	if mode == http2Mode {
+		wantProto = 2
+	}
+	if resp.ProtoMajor != wantProto {
+		t.Errorf("handshake response protocol = %v, want HTTP/%v", resp.Proto, wantProto)
+	}
+	if got := resp.Header.Get("Sec-WebSocket-Extensions") != ""; got != compress {
+		t.Errorf("compression negotiated = %v, want %v", got, compress)
+	}
+
+	messages := []struct {
+		typ websocket.MessageType
+		msg []byte
+	}{
+		{websocket.TextMessage, []byte("hello")},
+		{websocket.BinaryMessage, nil},
+		{websocket.TextMessage, []byte(strings.Repeat("héllo, wörld ", 10000))},
+		{websocket.BinaryMessage, bytes.Repeat([]byte{0, 1, 2, 3, 4, 5, 6, 7}, 20000)},
+		{websocket.TextMessage, []byte("hello")},
+	}
+	for _, m := range messages {
+		if err := c.Write(ctx, m.typ, m.msg); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+		typ, msg, err := c.Read(ctx)
+		if err != nil {
+			t.Fatalf("Read: %v", err)
+		}
+		if typ != m.typ || !bytes.Equal(msg, m.msg) {
+			t.Fatalf("echo of %v message of length %v: got %v message of length %v", m.typ, len(m.msg), typ, len(msg))
+		}
+	}
+
+	// Write a fragmented message.
+	w, err := c.Writer(ctx, websocket.BinaryMessage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want []byte
+	for i := range 100 {
+		p := bytes.Repeat([]byte{byte(i)}, 1000+i)
+		want = append(want, p...)
+		if _, err := w.Write(p); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, got, err := c.Read(ctx); err != nil || !bytes.Equal(got, want) {
+		t.Fatalf("echo of fragmented message: got %v bytes, %v; want %v bytes", len(got), err, len(want))
+	}
+
+	if err := c.Close(websocket.StatusNormalClosure, ""); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+}
+
+func TestCloseHandshake(t *testing.T) {
+	run(t, testCloseHandshake)
+}
+func testCloseHandshake(t *testing.T, mode testMode) {
+	done := make(chan error, 1)
+	u, client := newServer(t, mode, func(w http.ResponseWriter, r *http.Request) {
+		c, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			t.Errorf("Accept: %v", err)
+			return
+		}
+		done <- c.Close(4000, "goodbye")
+	})
+	c, _, err := websocket.Dial(context.Background(), u, &websocket.DialOptions{HTTPClient: client})
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer c.CloseNow()
+	_, _, err = c.Read(context.Background())
+	var ce *websocket.CloseError
+	if !errors.As(err, &ce) || ce.Code != 4000 || ce.Reason != "goodbye" {
+		t.Fatalf("Read: %v, want CloseError with code 4000", err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("server Close: %v", err)
+	}
+	if err := c.Write(context.Background(), websocket.TextMessage, []byte("x")); err == nil {
+		t.Errorf("Write after close succeeded")
+	}
+}
+
+func TestPing(t *testing.T) {
+	run(t, testPing)
+}
+func testPing(t *testing.T, mode testMode) {
+	u, client := newServer(t, mode, echoHandler(t, nil))
+	ctx := context.Background()
+	c, _, err := websocket.Dial(ctx, u, &websocket.DialOptions{HTTPClient: client})
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer c.CloseNow()
+	// Pongs are received by the reader.
+	go c.Read(ctx)
+	for range 3 {
+		if err := c.Ping(ctx); err != nil {
+			t.Fatalf("Ping: %v", err)
+		}
+	}
+}
+
+func TestSubprotocol(t *testing.T) {
+	run(t, testSubprotocol)
+}
+func testSubprotocol(t *testing.T, mode testMode) {
+	u, client := newServer(t, mode, echoHandler(t, &websocket.AcceptOptions{
+		Subprotocols: []string{"chat.v2", "chat.v1"},
+	}))
+	for _, test := range []struct {
+		offer []string
+		want  string
+	}{
+		{[]string{"chat.v1", "chat.v2"}, "chat.v2"},
+		{[]string{"chat.v1"}, "chat.v1"},
+		{[]string{"other"}, ""},
+		{nil, ""},
+	} {
+		c, _, err := websocket.Dial(context.Background(), u, &websocket.DialOptions{
+			HTTPClient:   client,
+			Subprotocols: test.offer,
+		})
+		if err != nil {
+			t.Fatalf("Dial: %v", err)
+		}
+		if got := c.Subprotocol(); got != test.want {
+			t.Errorf("offering %q: subprotocol %q, want %q", test.offer, got, test.want)
+		}
+		c.CloseNow()
+	}
+}
+
+func TestCrossOriginRejected(t *testing.T) {
+	run(t, testCrossOriginRejected)
+}
+func testCrossOriginRejected(t *testing.T, mode testMode) {
+	u, client := newServer(t, mode, func(w http.ResponseWriter, r *http.Request) {
+		if _, err := websocket.Accept(w, r, nil); err == nil {
+			t.Errorf("Accept succeeded, want error")
+		}
+	})
+	_, resp, err := websocket.Dial(context.Background(), u, &websocket.DialOptions{
+		HTTPClient: client,
+		Header:     http.Header{"Origin": {"https://evil.example"}},
+	})
+	if err == nil {
+		t.Fatalf("Dial succeeded, want error")
+	}
+	if resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("Dial: response %v, want status 403", resp)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), "cross-origin") {
+		t.Errorf("response body %q does not explain rejection", body)
+	}
+}
+
+func TestReadLimit(t *testing.T) {
+	run(t, testReadLimit)
+}
+func testReadLimit(t *testing.T, mode testMode) {
+	u, client := newServer(t, mode, func(w http.ResponseWriter, r *http.Request) {
+		c, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			t.Errorf("Accept: %v", err)
+			return
+		}
+		defer c.CloseNow()
+		c.SetReadLimit(1000)
+		if _, _, err := c.Read(r.Context()); err == nil {
+			t.Errorf("server Read of large message succeeded")
+		}
+	})
+	ctx := context.Background()
+	c, _, err := websocket.Dial(ctx, u, &websocket.DialOptions{HTTPClient: client})
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer c.CloseNow()
+	c.Write(ctx, websocket.BinaryMessage, make([]byte, 1001))
+	_, _, err = c.Read(ctx)
+	var ce *websocket.CloseError
+	if !errors.As(err, &ce) || ce.Code != websocket.StatusMessageTooBig {
+		t.Fatalf("Read: %v, want CloseError with StatusMessageTooBig", err)
+	}
+}
+
+func TestReadContext(t *testing.T) {
+	run(t, testReadContext)
+}
+func testReadContext(t *testing.T, mode testMode) {
+	u, client := newServer(t, mode, echoHandler(t, nil))
+	c, _, err := websocket.Dial(context.Background(), u, &websocket.DialOptions{HTTPClient: client})
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer c.CloseNow()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, _, err := c.Read(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Read: %v, want %v", err, context.DeadlineExceeded)
+	}
+	// The connection is closed after a read is interrupted.
+	if _, _, err := c.Read(context.Background()); err == nil {
+		t.Fatalf("Read after interrupted read succeeded")
+	}
+}
+
+func TestDialContextAfterHandshake(t *testing.T) {
+	run(t, testDialContextAfterHandshake)
+}
+func testDialContextAfterHandshake(t *testing.T, mode testMode) {
+	u, client := newServer(t, mode, echoHandler(t, nil))
+	ctx, cancel := context.WithCancel(context.Background())
+	c, _, err := websocket.Dial(ctx, u, &websocket.DialOptions{HTTPClient: client})
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer c.CloseNow()
+	cancel()
+	bg := context.Background()
+	if err := c.Write(bg, websocket.TextMessage, []byte("hello")); err != nil {
+		t.Fatalf("Write after canceling Dial context: %v", err)
+	}
+	if _, msg, err := c.Read(bg); err != nil || string(msg) != "hello" {
+		t.Fatalf("Read after canceling Dial context: %q, %v", msg, err)
+	}
+}
+
+func TestAcceptNotWebSocket(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := websocket.Accept(w, r, nil); err == nil {
+			t.Errorf("Accept succeeded, want error")
+		}
+	}))
+	defer ts.Close()
+	resp, err := ts.Client().Get(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUpgradeRequired {
+		t.Errorf("status = %v, want %v", resp.StatusCode, http.StatusUpgradeRequired)
+	}
+}
@@ -27,6 +27,7 @@ var (
 	x86HasPOPCNT bool
 	x86HasSSE41  bool
 	x86HasFMA    bool
+	x86HasAVX512 bool // AVX-512 F, VL and DQ
 
 	armHasVFPv4 bool
 
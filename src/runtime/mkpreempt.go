@@ -250,6 +250,14 @@ func genAMD64() {
 			lSSE.add("MOVUPS", reg, 16)
 		}
 	}
+	// With AVX2 guaranteed, package simd keeps 256-bit vectors in the
+	// Y registers, so save them whole.
+	lAVX := layout{stack: l.stack, sp: "SP"}
+	for _, reg := range regNamesAMD64 {
+		if strings.HasPrefix(reg, "X") {
+			lAVX.add("VMOVDQU", "Y"+reg[1:], 32)
+		}
+	}
 
 	// TODO: MXCSR register?
 
@@ -258,17 +266,26 @@ func genAMD64() {
 	p("// Save flags before clobbering them")
 	p("PUSHFQ")
 	p("// obj doesn't understand ADD/SUB on SP, but does understand ADJSP")
+	p("#ifdef hasAVX2")
+	p("ADJSP $%d", lAVX.stack)
+	p("#else")
 	p("ADJSP $%d", lSSE.stack)
+	p("#endif")
 	p("// But vet doesn't know ADJSP, so suppress vet stack checking")
 	p("NOP SP")
 
 	l.save()
+	p("#ifdef hasAVX2")
+	lAVX.save()
+	p("#else")
+	lSSE.save()
+	p("#endif")
 
 	// Apparently, the signal handling code path in darwin kernel leaves
 	// the upper bits of Y registers in a dirty state, which causes
 	// many SSE operations (128-bit and narrower) become much slower.
 	// Clear the upper bits to get to a clean state. See issue #37174.
-	// It is safe here as Go code don't use the upper bits of Y registers.
+	// It is safe here as the vector registers have been saved above.
 	p("#ifdef GOOS_darwin")
 	p("#ifndef hasAVX")
 	p("CMPB internal∕cpu·X86+const_offsetX86HasAVX(SB), $0")
@@ -277,11 +294,18 @@ func genAMD64() {
 	p("VZEROUPPER")
 	p("#endif")
 
-	lSSE.save()
 	p("CALL ·asyncPreempt2(SB)")
+	p("#ifdef hasAVX2")
+	lAVX.restore()
+	p("#else")
 	lSSE.restore()
+	p("#endif")
 	l.restore()
+	p("#ifdef hasAVX2")
+	p("ADJSP $%d", -lAVX.stack)
+	p("#else")
 	p("ADJSP $%d", -lSSE.stack)
+	p("#endif")
 	p("POPFQ")
 	p("POPQ BP")
 	p("RET")
@@ -354,10 +378,19 @@ func genARM64() {
 		"MOVD %d(RSP), R0\nMOVD R0, FPSR",
 		8)
 	// TODO: FPCR? I don't think we'll change it, so no need to save.
-	// Add floating point registers F0-F31.
+	// Add floating point registers F0-F31. The compiler keeps the
+	// 128-bit vectors of package simd in V0-V15, so save the full Q
+	// registers for those, 16-byte aligned.
+	if l.stack%16 != 0 {
+		l.stack += 8
+	}
 	for i := 0; i < 31; i += 2 {
 		reg := fmt.Sprintf("(F%d, F%d)", i, i+1)
-		l.add2("FSTPD", "FLDPD", reg, 16)
+		if i < 16 {
+			l.add2("FSTPQ", "FLDPQ", reg, 32)
+		} else {
+			l.add2("FSTPD", "FLDPD", reg, 16)
+		}
 	}
 	if l.stack%16 != 0 {
 		l.stack += 8 // SP needs 16-byte alignment
@@ -88,7 +88,10 @@ func TestConvertMemProfile(t *testing.T) {
 }
 
 func genericAllocFunc[T interface{ uint32 | uint64 }](n int) []T {
-	return make([]T, n)
+	// Keep the allocation on the heap, so that it is profiled.
+	s := make([]T, n)
+	memSink = s
+	return s
 }
 
 func profileToStrings(p *profile.Profile) []string {
@@ -129,6 +132,7 @@ func TestGenericsHashKeyInPprofBuilder(t *testing.T) {
 	for _, sz := range []int{32, 64} {
 		genericAllocFunc[uint64](sz / 8)
 	}
+	memSink = nil
 
 	runtime.GC()
 	buf := bytes.NewBuffer(nil)
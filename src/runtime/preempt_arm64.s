@@ -4,8 +4,8 @@
 #include "textflag.h"
 
 TEXT ·asyncPreempt(SB),NOSPLIT|NOFRAME,$0-0
-	MOVD R30, -496(RSP)
-	SUB $496, RSP
+	MOVD R30, -624(RSP)
+	SUB $624, RSP
 	MOVD R29, -8(RSP)
 	SUB $8, RSP, R29
 	#ifdef GOOS_ios
@@ -28,39 +28,39 @@ TEXT ·asyncPreempt(SB),NOSPLIT|NOFRAME,$0-0
 	MOVD R0, 216(RSP)
 	MOVD FPSR, R0
 	MOVD R0, 224(RSP)
-	FSTPD (F0, F1), 232(RSP)
-	FSTPD (F2, F3), 248(RSP)
-	FSTPD (F4, F5), 264(RSP)
-	FSTPD (F6, F7), 280(RSP)
-	FSTPD (F8, F9), 296(RSP)
-	FSTPD (F10, F11), 312(RSP)
-	FSTPD (F12, F13), 328(RSP)
-	FSTPD (F14, F15), 344(RSP)
-	FSTPD (F16, F17), 360(RSP)
-	FSTPD (F18, F19), 376(RSP)
-	FSTPD (F20, F21), 392(RSP)
-	FSTPD (F22, F23), 408(RSP)
-	FSTPD (F24, F25), 424(RSP)
-	FSTPD (F26, F27), 440(RSP)
-	FSTPD (F28, F29), 456(RSP)
-	FSTPD (F30, F31), 472(RSP)
+	FSTPQ (F0, F1), 240(RSP)
+	FSTPQ (F2, F3), 272(RSP)
+	FSTPQ (F4, F5), 304(RSP)
+	FSTPQ (F6, F7), 336(RSP)
+	FSTPQ (F8, F9), 368(RSP)
+	FSTPQ (F10, F11), 400(RSP)
+	FSTPQ (F12, F13), 432(RSP)
+	FSTPQ (F14, F15), 464(RSP)
+	FSTPD (F16, F17), 496(RSP)
+	FSTPD (F18, F19), 512(RSP)
+	FSTPD (F20, F21), 528(RSP)
+	FSTPD (F22, F23), 544(RSP)
+	FSTPD (F24, F25), 560(RSP)
+	FSTPD (F26, F27), 576(RSP)
+	FSTPD (F28, F29), 592(RSP)
+	FSTPD (F30, F31), 608(RSP)
 	CALL ·asyncPreempt2(SB)
-	FLDPD 472(RSP), (F30, F31)
-	FLDPD 456(RSP), (F28, F29)
-	FLDPD 440(RSP), (F26, F27)
-	FLDPD 424(RSP), (F24, F25)
-	FLDPD 408(RSP), (F22, F23)
-	FLDPD 392(RSP), (F20, F21)
-	FLDPD 376(RSP), (F18, F19)
-	FLDPD 360(RSP), (F16, F17)
-	FLDPD 344(RSP), (F14, F15)
-	FLDPD 328(RSP), (F12, F13)
-	FLDPD 312(RSP), (F10, F11)
-	FLDPD 296(RSP), (F8, F9)
-	FLDPD 280(RSP), (F6, F7)
-	FLDPD 264(RSP), (F4, F5)
-	FLDPD 248(RSP), (F2, F3)
-	FLDPD 232(RSP), (F0, F1)
+	FLDPD 608(RSP), (F30, F31)
+	FLDPD 592(RSP), (F28, F29)
+	FLDPD 576(RSP), (F26, F27)
+	FLDPD 560(RSP), (F24, F25)
+	FLDPD 544(RSP), (F22, F23)
+	FLDPD 528(RSP), (F20, F21)
+	FLDPD 512(RSP), (F18, F19)
+	FLDPD 496(RSP), (F16, F17)
+	FLDPQ 464(RSP), (F14, F15)
+	FLDPQ 432(RSP), (F12, F13)
+	FLDPQ 400(RSP), (F10, F11)
+	FLDPQ 368(RSP), (F8, F9)
+	FLDPQ 336(RSP), (F6, F7)
+	FLDPQ 304(RSP), (F4, F5)
+	FLDPQ 272(RSP), (F2, F3)
+	FLDPQ 240(RSP), (F0, F1)
 	MOVD 224(RSP), R0
 	MOVD R0, FPSR
 	MOVD 216(RSP), R0
@@ -78,8 +78,8 @@ TEXT ·asyncPreempt(SB),NOSPLIT|NOFRAME,$0-0
 	LDP 40(RSP), (R4, R5)
 	LDP 24(RSP), (R2, R3)
 	LDP 8(RSP), (R0, R1)
-	MOVD 496(RSP), R30
+	MOVD 624(RSP), R30
 	MOVD -8(RSP), R29
 	MOVD (RSP), R27
-	ADD $512, RSP
+	ADD $640, RSP
 	JMP (R27)
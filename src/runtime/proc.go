@@ -700,6 +700,7 @@ func cpuinit(env string) {
 		x86HasPOPCNT = cpu.X86.HasPOPCNT
 		x86HasSSE41 = cpu.X86.HasSSE41
 		x86HasFMA = cpu.X86.HasFMA
+		x86HasAVX512 = cpu.X86.HasAVX512F && cpu.X86.HasAVX512VL && cpu.X86.HasAVX512DQ
 
 	case "arm":
 		armHasVFPv4 = cpu.ARM.HasVFPv4
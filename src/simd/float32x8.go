@@ -0,0 +1,50 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package simd
+
+// Float32x8 is a vector of 8 float32 lanes.
+type Float32x8 [8]float32
+
+// LoadFloat32x8 returns a vector of the first 8 elements of s.
+// It panics if len(s) < 8.
+func LoadFloat32x8(s []float32) Float32x8 {
+	return Float32x8(s)
+}
+
+// BroadcastFloat32x8 returns a vector with all lanes set to v.
+func BroadcastFloat32x8(v float32) Float32x8 {
+	return Float32x8([8]float32{v, v, v, v, v, v, v, v})
+}
+
+// Store stores the lanes of x in the first 8 elements of s.
+// It panics if len(s) < 8.
+func (x Float32x8) Store(s []float32) {
+	*(*Float32x8)(s) = x
+}
+
+// Add returns the lane-wise sum x + y.
+func (x Float32x8) Add(y Float32x8) Float32x8 {
+	return addFloat32x8(x, y)
+}
+
+// Sub returns the lane-wise difference x - y.
+func (x Float32x8) Sub(y Float32x8) Float32x8 {
+	return subFloat32x8(x, y)
+}
+
+// Mul returns the lane-wise product x * y.
+func (x Float32x8) Mul(y Float32x8) Float32x8 {
+	return mulFloat32x8(x, y)
+}
+
+// Div returns the lane-wise quotient x / y.
+func (x Float32x8) Div(y Float32x8) Float32x8 {
+	return divFloat32x8(x, y)
+}
+
+// Sqrt returns the lane-wise square root of x.
+func (x Float32x8) Sqrt() Float32x8 {
+	return sqrtFloat32x8(x)
+}
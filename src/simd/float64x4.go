@@ -0,0 +1,50 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package simd
+
+// Float64x4 is a vector of 4 float64 lanes.
+type Float64x4 [4]float64
+
+// LoadFloat64x4 returns a vector of the first 4 elements of s.
+// It panics if len(s) < 4.
+func LoadFloat64x4(s []float64) Float64x4 {
+	return Float64x4(s)
+}
+
+// BroadcastFloat64x4 returns a vector with all lanes set to v.
+func BroadcastFloat64x4(v float64) Float64x4 {
+	return Float64x4([4]float64{v, v, v, v})
+}
+
+// Store stores the lanes of x in the first 4 elements of s.
+// It panics if len(s) < 4.
+func (x Float64x4) Store(s []float64) {
+	*(*Float64x4)(s) = x
+}
+
+// Add returns the lane-wise sum x + y.
+func (x Float64x4) Add(y Float64x4) Float64x4 {
+	return addFloat64x4(x, y)
+}
+
+// Sub returns the lane-wise difference x - y.
+func (x Float64x4) Sub(y Float64x4) Float64x4 {
+	return subFloat64x4(x, y)
+}
+
+// Mul returns the lane-wise product x * y.
+func (x Float64x4) Mul(y Float64x4) Float64x4 {
+	return mulFloat64x4(x, y)
+}
+
+// Div returns the lane-wise quotient x / y.
+func (x Float64x4) Div(y Float64x4) Float64x4 {
+	return divFloat64x4(x, y)
+}
+
+// Sqrt returns the lane-wise square root of x.
+func (x Float64x4) Sqrt() Float64x4 {
+	return sqrtFloat64x4(x)
+}
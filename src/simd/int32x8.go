@@ -0,0 +1,82 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package simd
+
+// Int32x8 is a vector of 8 int32 lanes.
+type Int32x8 [8]int32
+
+// LoadInt32x8 returns a vector of the first 8 elements of s.
+// It panics if len(s) < 8.
+func LoadInt32x8(s []int32) Int32x8 {
+	return Int32x8(s)
+}
+
+// BroadcastInt32x8 returns a vector with all lanes set to v.
+func BroadcastInt32x8(v int32) Int32x8 {
+	return Int32x8([8]int32{v, v, v, v, v, v, v, v})
+}
+
+// Store stores the lanes of x in the first 8 elements of s.
+// It panics if len(s) < 8.
+func (x Int32x8) Store(s []int32) {
+	*(*Int32x8)(s) = x
+}
+
+// Add returns the lane-wise sum x + y.
+func (x Int32x8) Add(y Int32x8) Int32x8 {
+	return addInt32x8(x, y)
+}
+
+// Sub returns the lane-wise difference x - y.
+func (x Int32x8) Sub(y Int32x8) Int32x8 {
+	return subInt32x8(x, y)
+}
+
+// Mul returns the lane-wise product x * y.
+func (x Int32x8) Mul(y Int32x8) Int32x8 {
+	return mulInt32x8(x, y)
+}
+
+// Min returns the lane-wise minimum of x and y.
+func (x Int32x8) Min(y Int32x8) Int32x8 {
+	return minInt32x8(x, y)
+}
+
+// Max returns the lane-wise maximum of x and y.
+func (x Int32x8) Max(y Int32x8) Int32x8 {
+	return maxInt32x8(x, y)
+}
+
+// Equal returns a vector whose lanes are all ones where x and y are
+// equal and zero elsewhere.
+func (x Int32x8) Equal(y Int32x8) Int32x8 {
+	return equalInt32x8(x, y)
+}
+
+// Greater returns a vector whose lanes are all ones where x is greater
+// than y and zero elsewhere.
+func (x Int32x8) Greater(y Int32x8) Int32x8 {
+	return greaterInt32x8(x, y)
+}
+
+// And returns the bitwise x & y.
+func (x Int32x8) And(y Int32x8) Int32x8 {
+	return andInt32x8(x, y)
+}
+
+// Or returns the bitwise x | y.
+func (x Int32x8) Or(y Int32x8) Int32x8 {
+	return orInt32x8(x, y)
+}
+
+// Xor returns the bitwise x ^ y.
+func (x Int32x8) Xor(y Int32x8) Int32x8 {
+	return xorInt32x8(x, y)
+}
+
+// AndNot returns the bitwise x &^ y.
+func (x Int32x8) AndNot(y Int32x8) Int32x8 {
+	return andNotInt32x8(x, y)
+}
@@ -0,0 +1,82 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package simd
+
+// Int64x4 is a vector of 4 int64 lanes.
+type Int64x4 [4]int64
+
+// LoadInt64x4 returns a vector of the first 4 elements of s.
+// It panics if len(s) < 4.
+func LoadInt64x4(s []int64) Int64x4 {
+	return Int64x4(s)
+}
+
+// BroadcastInt64x4 returns a vector with all lanes set to v.
+func BroadcastInt64x4(v int64) Int64x4 {
+	return Int64x4([4]int64{v, v, v, v})
+}
+
+// Store stores the lanes of x in the first 4 elements of s.
+// It panics if len(s) < 4.
+func (x Int64x4) Store(s []int64) {
+	*(*Int64x4)(s) = x
+}
+
+// Add returns the lane-wise sum x + y.
+func (x Int64x4) Add(y Int64x4) Int64x4 {
+	return addInt64x4(x, y)
+}
+
+// Sub returns the lane-wise difference x - y.
+func (x Int64x4) Sub(y Int64x4) Int64x4 {
+	return subInt64x4(x, y)
+}
+
+// Mul returns the lane-wise product x * y.
+func (x Int64x4) Mul(y Int64x4) Int64x4 {
+	return mulInt64x4(x, y)
+}
+
+// Min returns the lane-wise minimum of x and y.
+func (x Int64x4) Min(y Int64x4) Int64x4 {
+	return minInt64x4(x, y)
+}
+
+// Max returns the lane-wise maximum of x and y.
+func (x Int64x4) Max(y Int64x4) Int64x4 {
+	return maxInt64x4(x, y)
+}
+
+// Equal returns a vector whose lanes are all ones where x and y are
+// equal and zero elsewhere.
+func (x Int64x4) Equal(y Int64x4) Int64x4 {
+	return equalInt64x4(x, y)
+}
+
+// Greater returns a vector whose lanes are all ones where x is greater
+// than y and zero elsewhere.
+func (x Int64x4) Greater(y Int64x4) Int64x4 {
+	return greaterInt64x4(x, y)
+}
+
+// And returns the bitwise x & y.
+func (x Int64x4) And(y Int64x4) Int64x4 {
+	return andInt64x4(x, y)
+}
+
+// Or returns the bitwise x | y.
+func (x Int64x4) Or(y Int64x4) Int64x4 {
+	return orInt64x4(x, y)
+}
+
+// Xor returns the bitwise x ^ y.
+func (x Int64x4) Xor(y Int64x4) Int64x4 {
+	return xorInt64x4(x, y)
+}
+
+// AndNot returns the bitwise x &^ y.
+func (x Int64x4) AndNot(y Int64x4) Int64x4 {
+	return andNotInt64x4(x, y)
+}
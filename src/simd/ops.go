@@ -0,0 +1,404 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package simd
+
+import "math"
+
+// The functions in this file implement the vector operations in Go.
+// The compiler replaces calls to them with SIMD instructions where the
+// architecture and CPU support them (see cmd/compile/internal/ssagen),
+// so they must keep their signatures. They work on copies of the
+// vectors as plain arrays, because the compiler keeps vectors in
+// registers, where indexing them is slow.
+
+func addUint8x32(x, y Uint8x32) Uint8x32 {
+	a, b := [32]uint8(x), [32]uint8(y)
+	var z [32]uint8
+	for i := range z {
+		z[i] = a[i] + b[i]
+	}
+	return Uint8x32(z)
+}
+
+func subUint8x32(x, y Uint8x32) Uint8x32 {
+	a, b := [32]uint8(x), [32]uint8(y)
+	var z [32]uint8
+	for i := range z {
+		z[i] = a[i] - b[i]
+	}
+	return Uint8x32(z)
+}
+
+func minUint8x32(x, y Uint8x32) Uint8x32 {
+	a, b := [32]uint8(x), [32]uint8(y)
+	var z [32]uint8
+	for i := range z {
+		z[i] = min(a[i], b[i])
+	}
+	return Uint8x32(z)
+}
+
+func maxUint8x32(x, y Uint8x32) Uint8x32 {
+	a, b := [32]uint8(x), [32]uint8(y)
+	var z [32]uint8
+	for i := range z {
+		z[i] = max(a[i], b[i])
+	}
+	return Uint8x32(z)
+}
+
+func equalUint8x32(x, y Uint8x32) Uint8x32 {
+	a, b := [32]uint8(x), [32]uint8(y)
+	var z [32]uint8
+	for i := range z {
+		if a[i] == b[i] {
+			z[i] = 0xff
+		}
+	}
+	return Uint8x32(z)
+}
+
+func andUint8x32(x, y Uint8x32) Uint8x32 {
+	a, b := [32]uint8(x), [32]uint8(y)
+	var z [32]uint8
+	for i := range z {
+		z[i] = a[i] & b[i]
+	}
+	return Uint8x32(z)
+}
+
+func orUint8x32(x, y Uint8x32) Uint8x32 {
+	a, b := [32]uint8(x), [32]uint8(y)
+	var z [32]uint8
+	for i := range z {
+		z[i] = a[i] | b[i]
+	}
+	return Uint8x32(z)
+}
+
+func xorUint8x32(x, y Uint8x32) Uint8x32 {
+	a, b := [32]uint8(x), [32]uint8(y)
+	var z [32]uint8
+	for i := range z {
+		z[i] = a[i] ^ b[i]
+	}
+	return Uint8x32(z)
+}
+
+func andNotUint8x32(x, y Uint8x32) Uint8x32 {
+	a, b := [32]uint8(x), [32]uint8(y)
+	var z [32]uint8
+	for i := range z {
+		z[i] = a[i] &^ b[i]
+	}
+	return Uint8x32(z)
+}
+
+func signBitsUint8x32(x Uint8x32) uint32 {
+	a := [32]uint8(x)
+	var m uint32
+	for i, b := range a {
+		m |= uint32(b>>7) << i
+	}
+	return m
+}
+
+func addInt32x8(x, y Int32x8) Int32x8 {
+	a, b := [8]int32(x), [8]int32(y)
+	var z [8]int32
+	for i := range z {
+		z[i] = a[i] + b[i]
+	}
+	return Int32x8(z)
+}
+
+func subInt32x8(x, y Int32x8) Int32x8 {
+	a, b := [8]int32(x), [8]int32(y)
+	var z [8]int32
+	for i := range z {
+		z[i] = a[i] - b[i]
+	}
+	return Int32x8(z)
+}
+
+func mulInt32x8(x, y Int32x8) Int32x8 {
+	a, b := [8]int32(x), [8]int32(y)
+	var z [8]int32
+	for i := range z {
+		z[i] = a[i] * b[i]
+	}
+	return Int32x8(z)
+}
+
+func minInt32x8(x, y Int32x8) Int32x8 {
+	a, b := [8]int32(x), [8]int32(y)
+	var z [8]int32
+	for i := range z {
+		z[i] = min(a[i], b[i])
+	}
+	return Int32x8(z)
+}
+
+func maxInt32x8(x, y Int32x8) Int32x8 {
+	a, b := [8]int32(x), [8]int32(y)
+	var z [8]int32
+	for i := range z {
+		z[i] = max(a[i], b[i])
+	}
+	return Int32x8(z)
+}
+
+func equalInt32x8(x, y Int32x8) Int32x8 {
+	a, b := [8]int32(x), [8]int32(y)
+	var z [8]int32
+	for i := range z {
+		if a[i] == b[i] {
+			z[i] = -1
+		}
+	}
+	return Int32x8(z)
+}
+
+func greaterInt32x8(x, y Int32x8) Int32x8 {
+	a, b := [8]int32(x), [8]int32(y)
+	var z [8]int32
+	for i := range z {
+		if a[i] > b[i] {
+			z[i] = -1
+		}
+	}
+	return Int32x8(z)
+}
+
+func andInt32x8(x, y Int32x8) Int32x8 {
+	a, b := [8]int32(x), [8]int32(y)
+	var z [8]int32
+	for i := range z {
+		z[i] = a[i] & b[i]
+	}
+	return Int32x8(z)
+}
+
+func orInt32x8(x, y Int32x8) Int32x8 {
+	a, b := [8]int32(x), [8]int32(y)
+	var z [8]int32
+	for i := range z {
+		z[i] = a[i] | b[i]
+	}
+	return Int32x8(z)
+}
+
+func xorInt32x8(x, y Int32x8) Int32x8 {
+	a, b := [8]int32(x), [8]int32(y)
+	var z [8]int32
+	for i := range z {
+		z[i] = a[i] ^ b[i]
+	}
+	return Int32x8(z)
+}
+
+func andNotInt32x8(x, y Int32x8) Int32x8 {
+	a, b := [8]int32(x), [8]int32(y)
+	var z [8]int32
+	for i := range z {
+		z[i] = a[i] &^ b[i]
+	}
+	return Int32x8(z)
+}
+
+func addInt64x4(x, y Int64x4) Int64x4 {
+	a, b := [4]int64(x), [4]int64(y)
+	var z [4]int64
+	for i := range z {
+		z[i] = a[i] + b[i]
+	}
+	return Int64x4(z)
+}
+
+func subInt64x4(x, y Int64x4) Int64x4 {
+	a, b := [4]int64(x), [4]int64(y)
+	var z [4]int64
+	for i := range z {
+		z[i] = a[i] - b[i]
+	}
+	return Int64x4(z)
+}
+
+func mulInt64x4(x, y Int64x4) Int64x4 {
+	a, b := [4]int64(x), [4]int64(y)
+	var z [4]int64
+	for i := range z {
+		z[i] = a[i] * b[i]
+	}
+	return Int64x4(z)
+}
+
+func minInt64x4(x, y Int64x4) Int64x4 {
+	a, b := [4]int64(x), [4]int64(y)
+	var z [4]int64
+	for i := range z {
+		z[i] = min(a[i], b[i])
+	}
+	return Int64x4(z)
+}
+
+func maxInt64x4(x, y Int64x4) Int64x4 {
+	a, b := [4]int64(x), [4]int64(y)
+	var z [4]int64
+	for i := range z {
+		z[i] = max(a[i], b[i])
+	}
+	return Int64x4(z)
+}
+
+func equalInt64x4(x, y Int64x4) Int64x4 {
+	a, b := [4]int64(x), [4]int64(y)
+	var z [4]int64
+	for i := range z {
+		if a[i] == b[i] {
+			z[i] = -1
+		}
+	}
+	return Int64x4(z)
+}
+
+func greaterInt64x4(x, y Int64x4) Int64x4 {
+	a, b := [4]int64(x), [4]int64(y)
+	var z [4]int64
+	for i := range z {
+		if a[i] > b[i] {
+			z[i] = -1
+		}
+	}
+	return Int64x4(z)
+}
+
+func andInt64x4(x, y Int64x4) Int64x4 {
+	a, b := [4]int64(x), [4]int64(y)
+	var z [4]int64
+	for i := range z {
+		z[i] = a[i] & b[i]
+	}
+	return Int64x4(z)
+}
+
+func orInt64x4(x, y Int64x4) Int64x4 {
+	a, b := [4]int64(x), [4]int64(y)
+	var z [4]int64
+	for i := range z {
+		z[i] = a[i] | b[i]
+	}
+	return Int64x4(z)
+}
+
+func xorInt64x4(x, y Int64x4) Int64x4 {
+	a, b := [4]int64(x), [4]int64(y)
+	var z [4]int64
+	for i := range z {
+		z[i] = a[i] ^ b[i]
+	}
+	return Int64x4(z)
+}
+
+func andNotInt64x4(x, y Int64x4) Int64x4 {
+	a, b := [4]int64(x), [4]int64(y)
+	var z [4]int64
+	for i := range z {
+		z[i] = a[i] &^ b[i]
+	}
+	return Int64x4(z)
+}
+
+func addFloat32x8(x, y Float32x8) Float32x8 {
+	a, b := [8]float32(x), [8]float32(y)
+	var z [8]float32
+	for i := range z {
+		z[i] = a[i] + b[i]
+	}
+	return Float32x8(z)
+}
+
+func subFloat32x8(x, y Float32x8) Float32x8 {
+	a, b := [8]float32(x), [8]float32(y)
+	var z [8]float32
+	for i := range z {
+		z[i] = a[i] - b[i]
+	}
+	return Float32x8(z)
+}
+
+func mulFloat32x8(x, y Float32x8) Float32x8 {
+	a, b := [8]float32(x), [8]float32(y)
+	var z [8]float32
+	for i := range z {
+		z[i] = a[i] * b[i]
+	}
+	return Float32x8(z)
+}
+
+func divFloat32x8(x, y Float32x8) Float32x8 {
+	a, b := [8]float32(x), [8]float32(y)
+	var z [8]float32
+	for i := range z {
+		z[i] = a[i] / b[i]
+	}
+	return Float32x8(z)
+}
+
+func sqrtFloat32x8(x Float32x8) Float32x8 {
+	a := [8]float32(x)
+	var z [8]float32
+	for i := range z {
+		// Rounding the float64 square root to float32 gives the
+		// correctly rounded float32 square root.
+		z[i] = float32(math.Sqrt(float64(a[i])))
+	}
+	return Float32x8(z)
+}
+
+func addFloat64x4(x, y Float64x4) Float64x4 {
+	a, b := [4]float64(x), [4]float64(y)
+	var z [4]float64
+	for i := range z {
+		z[i] = a[i] + b[i]
+	}
+	return Float64x4(z)
+}
+
+func subFloat64x4(x, y Float64x4) Float64x4 {
+	a, b := [4]float64(x), [4]float64(y)
+	var z [4]float64
+	for i := range z {
+		z[i] = a[i] - b[i]
+	}
+	return Float64x4(z)
+}
+
+func mulFloat64x4(x, y Float64x4) Float64x4 {
+	a, b := [4]float64(x), [4]float64(y)
+	var z [4]float64
+	for i := range z {
+		z[i] = a[i] * b[i]
+	}
+	return Float64x4(z)
+}
+
+func divFloat64x4(x, y Float64x4) Float64x4 {
+	a, b := [4]float64(x), [4]float64(y)
+	var z [4]float64
+	for i := range z {
+		z[i] = a[i] / b[i]
+	}
+	return Float64x4(z)
+}
+
+func sqrtFloat64x4(x Float64x4) Float64x4 {
+	a := [4]float64(x)
+	var z [4]float64
+	for i := range z {
+		z[i] = math.Sqrt(a[i])
+	}
+	return Float64x4(z)
+}
@@ -0,0 +1,62 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package simd provides 256-bit vector types and lane-wise operations on
+// them.
+//
+// The compiler implements the operations with SIMD instructions where
+// the CPU provides them, and keeps vectors in registers. On amd64, it
+// uses AVX2 when GOAMD64=v3 or higher, and pairs of SSE instructions on
+// 128-bit halves otherwise, checking for SSE4.1 at run time for the
+// operations that need it when GOAMD64=v1. The Mul, Min and Max methods
+// of [Int64x4] use AVX-512 instructions, checked for at run time unless
+// GOAMD64=v4. On arm64, the compiler uses NEON (Advanced SIMD), except
+// for the Mul method of [Int64x4] and the SignBits method of [Uint8x32].
+// Elsewhere, and for the operations without a suitable instruction, the
+// operations are implemented in portable Go, with the same results. The
+// functions [HasAVX2], [HasAVX512] and [HasNEON] report which
+// instructions the CPU supports.
+//
+// A vector type such as [Int32x8] is an array of its lanes, so a lane can
+// be read or set by indexing, and vectors can be compared with ==.
+// Operations on vectors are ordinary method calls that the compiler can
+// inline. For example, this counts the bytes of s equal to c, 32 at a
+// time:
+//
+//	cv := simd.BroadcastUint8x32(c)
+//	n := 0
+//	for ; len(s) >= 32; s = s[32:] {
+//		eq := simd.LoadUint8x32(s).Equal(cv)
+//		n += bits.OnesCount32(eq.SignBits())
+//	}
+//
+// Comparisons such as Equal return a vector whose lanes are all ones
+// where the comparison is true and zero where it is false, which can be
+// combined with the bitwise operations.
+package simd
+
+import (
+	"internal/cpu"
+	"internal/goarch"
+)
+
+// HasAVX2 reports whether the CPU supports AVX2, which is used for the
+// vector operations on amd64 when GOAMD64=v3 or higher.
+func HasAVX2() bool {
+	return cpu.X86.HasAVX2
+}
+
+// HasAVX512 reports whether the CPU supports the AVX-512 F, VL and DQ
+// extensions, which are used on amd64 for the Mul, Min and Max
+// methods of [Int64x4].
+func HasAVX512() bool {
+	return cpu.X86.HasAVX512F && cpu.X86.HasAVX512VL && cpu.X86.HasAVX512DQ
+}
+
+// HasNEON reports whether the CPU supports NEON, the Advanced SIMD
+// extension used for the vector operations on arm64. It is part of
+// the arm64 baseline, so HasNEON reports true on all arm64 systems.
+func HasNEON() bool {
+	return goarch.IsArm64 == 1
+}
@@ -0,0 +1,383 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package simd_test
+
+import (
+	"internal/cpu"
+	"internal/testenv"
+	"math"
+	"math/bits"
+	"math/rand/v2"
+	"os"
+	"runtime"
+	"simd"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+const iterations = 100
+
+func randUint8x32() (x simd.Uint8x32) {
+	for i := range x {
+		x[i] = uint8(rand.Uint32())
+		if i%4 == 0 {
+			x[i] = uint8(i) // make some lanes equal
+		}
+	}
+	return x
+}
+
+func randInt32x8() (x simd.Int32x8) {
+	for i := range x {
+		x[i] = int32(rand.Uint32())
+		if i%4 == 0 {
+			x[i] = int32(i)
+		}
+	}
+	return x
+}
+
+func randInt64x4() (x simd.Int64x4) {
+	for i := range x {
+		x[i] = int64(rand.Uint64())
+		if i%2 == 0 {
+			x[i] = int64(i)
+		}
+	}
+	return x
+}
+
+func randFloat32x8() (x simd.Float32x8) {
+	for i := range x {
+		x[i] = rand.Float32() * 100
+	}
+	return x
+}
+
+func randFloat64x4() (x simd.Float64x4) {
+	for i := range x {
+		x[i] = rand.Float64() * 100
+	}
+	return x
+}
+
+func mask[T int32 | int64 | uint8](b bool) T {
+	if b {
+		return ^T(0)
+	}
+	return 0
+}
+
+func TestUint8x32(t *testing.T) {
+	for range iterations {
+		x, y := randUint8x32(), randUint8x32()
+		for i := range x {
+			check(t, "Add", i, x.Add(y)[i], x[i]+y[i])
+			check(t, "Sub", i, x.Sub(y)[i], x[i]-y[i])
+			check(t, "Min", i, x.Min(y)[i], min(x[i], y[i]))
+			check(t, "Max", i, x.Max(y)[i], max(x[i], y[i]))
+			check(t, "Equal", i, x.Equal(y)[i], mask[uint8](x[i] == y[i]))
+			check(t, "And", i, x.And(y)[i], x[i]&y[i])
+			check(t, "Or", i, x.Or(y)[i], x[i]|y[i])
+			check(t, "Xor", i, x.Xor(y)[i], x[i]^y[i])
+			check(t, "AndNot", i, x.AndNot(y)[i], x[i]&^y[i])
+			check(t, "SignBits", i, x.SignBits()>>i&1, uint32(x[i]>>7))
+		}
+	}
+}
+
+func TestInt32x8(t *testing.T) {
+	for range iterations {
+		x, y := randInt32x8(), randInt32x8()
+		for i := range x {
+			check(t, "Add", i, x.Add(y)[i], x[i]+y[i])
+			check(t, "Sub", i, x.Sub(y)[i], x[i]-y[i])
+			check(t, "Mul", i, x.Mul(y)[i], x[i]*y[i])
+			check(t, "Min", i, x.Min(y)[i], min(x[i], y[i]))
+			check(t, "Max", i, x.Max(y)[i], max(x[i], y[i]))
+			check(t, "Equal", i, x.Equal(y)[i], mask[int32](x[i] == y[i]))
+			check(t, "Greater", i, x.Greater(y)[i], mask[int32](x[i] > y[i]))
+			check(t, "And", i, x.And(y)[i], x[i]&y[i])
+			check(t, "Or", i, x.Or(y)[i], x[i]|y[i])
+			check(t, "Xor", i, x.Xor(y)[i], x[i]^y[i])
+			check(t, "AndNot", i, x.AndNot(y)[i], x[i]&^y[i])
+		}
+	}
+}
+
+func TestInt64x4(t *testing.T) {
+	for range iterations {
+		x, y := randInt64x4(), randInt64x4()
+		for i := range x {
+			check(t, "Add", i, x.Add(y)[i], x[i]+y[i])
+			check(t, "Sub", i, x.Sub(y)[i], x[i]-y[i])
+			check(t, "Mul", i, x.Mul(y)[i], x[i]*y[i])
+			check(t, "Min", i, x.Min(y)[i], min(x[i], y[i]))
+			check(t, "Max", i, x.Max(y)[i], max(x[i], y[i]))
+			check(t, "Equal", i, x.Equal(y)[i], mask[int64](x[i] == y[i]))
+			check(t, "Greater", i, x.Greater(y)[i], mask[int64](x[i] > y[i]))
+			check(t, "And", i, x.And(y)[i], x[i]&y[i])
+			check(t, "Or", i, x.Or(y)[i], x[i]|y[i])
+			check(t, "Xor", i, x.Xor(y)[i], x[i]^y[i])
+			check(t, "AndNot", i, x.AndNot(y)[i], x[i]&^y[i])
+		}
+	}
+}
+
+func TestFloat32x8(t *testing.T) {
+	for range iterations {
+		x, y := randFloat32x8(), randFloat32x8()
+		for i := range x {
+			check(t, "Add", i, x.Add(y)[i], x[i]+y[i])
+			check(t, "Sub", i, x.Sub(y)[i], x[i]-y[i])
+			check(t, "Mul", i, x.Mul(y)[i], x[i]*y[i])
+			check(t, "Div", i, x.Div(y)[i], x[i]/y[i])
+			check(t, "Sqrt", i, x.Sqrt()[i], float32(math.Sqrt(float64(x[i]))))
+		}
+	}
+}
+
+func TestFloat64x4(t *testing.T) {
+	for range iterations {
+		x, y := randFloat64x4(), randFloat64x4()
+		for i := range x {
+			check(t, "Add", i, x.Add(y)[i], x[i]+y[i])
+			check(t, "Sub", i, x.Sub(y)[i], x[i]-y[i])
+			check(t, "Mul", i, x.Mul(y)[i], x[i]*y[i])
+			check(t, "Div", i, x.Div(y)[i], x[i]/y[i])
+			check(t, "Sqrt", i, x.Sqrt()[i], math.Sqrt(x[i]))
+		}
+	}
+}
+
+func check[T comparable](t *testing.T, op string, lane int, got, want T) {
+	t.Helper()
+	if got != want {
+		t.Fatalf("%s: lane %d = %v, want %v", op, lane, got, want)
+	}
+}
+
+func TestLoadStore(t *testing.T) {
+	s := []int32{1, 2, 3, 4, 5, 6, 7, 8, 9}
+	x := simd.LoadInt32x8(s[1:])
+	if want := (simd.Int32x8{2, 3, 4, 5, 6, 7, 8, 9}); x != want {
+		t.Errorf("LoadInt32x8 = %v, want %v", x, want)
+	}
+	d := make([]int32, 9)
+	x.Store(d)
+	if want := (simd.Int32x8{2, 3, 4, 5, 6, 7, 8, 9}); simd.LoadInt32x8(d) != want || d[8] != 0 {
+		t.Errorf("Store stored %v", d)
+	}
+	if x := simd.BroadcastUint8x32(7); x.Equal(simd.Uint8x32{}).SignBits() != 0 || x[31] != 7 {
+		t.Errorf("BroadcastUint8x32(7) = %v", x)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("LoadInt32x8 of short slice did not panic")
+		}
+	}()
+	simd.LoadInt32x8(s[2:])
+}
+
+func count(s []byte, c byte) int {
+	cv := simd.BroadcastUint8x32(c)
+	n := 0
+	for ; len(s) >= 32; s = s[32:] {
+		eq := simd.LoadUint8x32(s).Equal(cv)
+		n += bits.OnesCount32(eq.SignBits())
+	}
+	for _, b := range s {
+		if b == c {
+			n++
+		}
+	}
+	return n
+}
+
+func TestCount(t *testing.T) {
+	s := make([]byte, 1000)
+	want := 0
+	for i := range s {
+		s[i] = byte(rand.IntN(8))
+		if s[i] == 3 {
+			want++
+		}
+	}
+	if got := count(s, 3); got != want {
+		t.Errorf("count = %d, want %d", got, want)
+	}
+}
+
+// TestPreempt checks that vectors held in registers survive the
+// asynchronous preemption of a loop without calls. The loop keeps
+// vectors of several types live, so that they occupy several registers.
+func TestPreempt(t *testing.T) {
+	n := 1 << 26
+	if testing.Short() {
+		n = 1 << 22
+	}
+	type result struct {
+		a simd.Int32x8
+		b simd.Int64x4
+		c simd.Uint8x32
+		d simd.Float64x4
+	}
+	a0 := simd.Int32x8{0, 1, 2, 3, 4, 5, 6, 7}
+	b0 := simd.Int64x4{-1, -2, -3, -4}
+	c0 := simd.Uint8x32{31: 1}
+	d0 := simd.Float64x4{0.5, 1.5, 2.5, 3.5}
+	done := make(chan result)
+	go func() {
+		a, b, c, d := a0, b0, c0, d0
+		oneA := simd.BroadcastInt32x8(1)
+		oneB := simd.BroadcastInt64x4(1)
+		oneC := simd.BroadcastUint8x32(1)
+		oneD := simd.BroadcastFloat64x4(1)
+		for range n {
+			a = a.Add(oneA)
+			b = b.Add(oneB)
+			c = c.Add(oneC)
+			d = d.Add(oneD)
+		}
+		done <- result{a, b, c, d}
+	}()
+	want := result{
+		a0.Add(simd.BroadcastInt32x8(int32(n))),
+		b0.Add(simd.BroadcastInt64x4(int64(n))),
+		c0.Add(simd.BroadcastUint8x32(uint8(n))),
+		d0.Add(simd.BroadcastFloat64x4(float64(n))),
+	}
+	for {
+		select {
+		case got := <-done:
+			if got != want {
+				t.Errorf("got %v, want %v", got, want)
+			}
+			return
+		default:
+			runtime.GC()
+		}
+	}
+}
+
+// TestPreemptLive checks that all the vectors a loop without calls keeps
+// in registers survive asynchronous preemption. The loop runs until the
+// test has completed several GCs, each of which has to preempt it.
+func TestPreemptLive(t *testing.T) {
+	if runtime.GOARCH == "wasm" {
+		t.Skip("no asynchronous preemption on wasm")
+	}
+	if strings.Contains(os.Getenv("GODEBUG"), "asyncpreemptoff=1") {
+		t.Skip("asynchronous preemption disabled")
+	}
+	type result struct {
+		v [6]simd.Int32x8
+		n int32
+	}
+	var init [6]simd.Int32x8
+	for i := range init {
+		for j := range init[i] {
+			init[i][j] = int32(i<<8 | j)
+		}
+	}
+	var stop atomic.Bool
+	started := make(chan bool)
+	done := make(chan result)
+	go func() {
+		// Six vectors and the increment use 14 of the 16 vector
+		// registers when vectors are split into 128-bit halves.
+		v0, v1, v2, v3, v4, v5 := init[0], init[1], init[2], init[3], init[4], init[5]
+		one := simd.BroadcastInt32x8(1)
+		var n int32
+		close(started)
+		for !stop.Load() {
+			v0 = v0.Add(one)
+			v1 = v1.Sub(one)
+			v2 = v2.Add(one)
+			v3 = v3.Sub(one)
+			v4 = v4.Add(one)
+			v5 = v5.Sub(one)
+			n++
+		}
+		done <- result{[6]simd.Int32x8{v0, v1, v2, v3, v4, v5}, n}
+	}()
+	<-started
+	for range 10 {
+		runtime.GC()
+	}
+	stop.Store(true)
+	got := <-done
+	for i, v := range got.v {
+		d := simd.BroadcastInt32x8(got.n)
+		want := init[i].Add(d)
+		if i%2 == 1 {
+			want = init[i].Sub(d)
+		}
+		if v != want {
+			t.Errorf("after %d iterations, vector %d = %v, want %v", got.n, i, v, want)
+		}
+	}
+}
+
+// TestPreemptAVX2 runs the preemption tests with GOAMD64=v3, with which
+// the compiler keeps vectors whole in the Y registers, and asyncPreempt
+// saves those instead of the X registers.
+func TestPreemptAVX2(t *testing.T) {
+	if runtime.GOARCH != "amd64" {
+		t.Skip("Y registers are only used on amd64")
+	}
+	if !simd.HasAVX2() {
+		t.Skip("CPU does not support AVX2")
+	}
+	if v := os.Getenv("GOAMD64"); v == "v3" || v == "v4" {
+		t.Skip("Y registers already tested")
+	}
+	if os.Getenv("GODEBUG") != "" {
+		t.Skip("GODEBUG already set")
+	}
+	testenv.MustHaveGoBuild(t)
+	args := []string{"test", "-count=1", "-run=^TestPreempt(Live)?$"}
+	if testing.Short() {
+		args = append(args, "-short")
+	}
+	cmd := testenv.Command(t, testenv.GoToolPath(t), append(args, "simd")...)
+	cmd.Env = append(cmd.Environ(), "GOAMD64=v3")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+}
+
+// TestPortable runs the tests with the instructions that the compiler
+// checks for at run time disabled, to test the Go implementation of the
+// operations that use them.
+func TestPortable(t *testing.T) {
+	if runtime.GOARCH != "amd64" {
+		t.Skip("only amd64 has CPU-dependent vector instructions")
+	}
+	if !cpu.X86.HasSSE41 && !simd.HasAVX512() {
+		t.Skip("the Go implementation is already in use")
+	}
+	if os.Getenv("GODEBUG") != "" {
+		t.Skip("GODEBUG already set")
+	}
+	testenv.MustHaveExec(t)
+	cmd := testenv.Command(t, os.Args[0], "-test.run=^Test[A-Z]", "-test.skip=^TestPortable$")
+	cmd.Env = append(cmd.Environ(), "GODEBUG=cpu.sse41=off,cpu.avx512f=off")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+}
+
+func BenchmarkCount(b *testing.B) {
+	s := make([]byte, 4096)
+	b.SetBytes(int64(len(s)))
+	for range b.N {
+		count(s, 1)
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package simd
+
+// Uint8x32 is a vector of 32 uint8 lanes.
+type Uint8x32 [32]uint8
+
+// LoadUint8x32 returns a vector of the first 32 elements of s.
+// It panics if len(s) < 32.
+func LoadUint8x32(s []uint8) Uint8x32 {
+	return Uint8x32(s)
+}
+
+// BroadcastUint8x32 returns a vector with all lanes set to v.
+func BroadcastUint8x32(v uint8) Uint8x32 {
+	var a [32]uint8
+	for i := range a {
+		a[i] = v
+	}
+	return Uint8x32(a)
+}
+
+// Store stores the lanes of x in the first 32 elements of s.
+// It panics if len(s) < 32.
+func (x Uint8x32) Store(s []uint8) {
+	*(*Uint8x32)(s) = x
+}
+
+// Add returns the lane-wise sum x + y.
+func (x Uint8x32) Add(y Uint8x32) Uint8x32 {
+	return addUint8x32(x, y)
+}
+
+// Sub returns the lane-wise difference x - y.
+func (x Uint8x32) Sub(y Uint8x32) Uint8x32 {
+	return subUint8x32(x, y)
+}
+
+// Min returns the lane-wise minimum of x and y.
+func (x Uint8x32) Min(y Uint8x32) Uint8x32 {
+	return minUint8x32(x, y)
+}
+
+// Max returns the lane-wise maximum of x and y.
+func (x Uint8x32) Max(y Uint8x32) Uint8x32 {
+	return maxUint8x32(x, y)
+}
+
+// Equal returns a vector whose lanes are all ones where x and y are
+// equal and zero elsewhere.
+func (x Uint8x32) Equal(y Uint8x32) Uint8x32 {
+	return equalUint8x32(x, y)
+}
+
+// And returns the bitwise x & y.
+func (x Uint8x32) And(y Uint8x32) Uint8x32 {
+	return andUint8x32(x, y)
+}
+
+// Or returns the bitwise x | y.
+func (x Uint8x32) Or(y Uint8x32) Uint8x32 {
+	return orUint8x32(x, y)
+}
+
+// Xor returns the bitwise x ^ y.
+func (x Uint8x32) Xor(y Uint8x32) Uint8x32 {
+	return xorUint8x32(x, y)
+}
+
+// AndNot returns the bitwise x &^ y.
+func (x Uint8x32) AndNot(y Uint8x32) Uint8x32 {
+	return andNotUint8x32(x, y)
+}
+
+// SignBits returns a mask with bit i set if the high bit of lane i of x
+// is set. Applied to the result of a comparison, it has a bit set for
+// each lane where the comparison is true.
+func (x Uint8x32) SignBits() uint32 {
+	return signBitsUint8x32(x)
+}
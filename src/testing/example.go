@@ -78,8 +78,12 @@ func (eg *InternalExample) processRunResult(stdout string, timeSpent time.Durati
 	if fail != "" || !finished || recovered != nil {
 		fmt.Printf("%s--- FAIL: %s (%s)\n%s", chatty.prefix(), eg.Name, dstr, fail)
 		passed = false
-	} else if chatty.on {
-		fmt.Printf("%s--- PASS: %s (%s)\n", chatty.prefix(), eg.Name, dstr)
+		writeResultLog("fail", eg.Name, timeSpent)
+	} else {
+		if chatty.on {
+			fmt.Printf("%s--- PASS: %s (%s)\n", chatty.prefix(), eg.Name, dstr)
+		}
+		writeResultLog("pass", eg.Name, timeSpent)
 	}
 
 	if chatty.on && chatty.json {
@@ -409,6 +409,7 @@ func (f *F) report() {
 	if *isFuzzWorker || f.parent == nil {
 		return
 	}
+	logResult(&f.common)
 	dstr := fmtDuration(f.duration)
 	format := "--- %s: %s (%s)\n"
 	if f.Failed() {
@@ -0,0 +1,95 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package testing
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A passedTest is a top-level test, example, or fuzz test that
+// passed in an earlier run and is not being run again.
+type passedTest struct {
+	name     string
+	duration time.Duration
+}
+
+// readPassedFile reads the names and durations of the tests that passed
+// according to the -test.passedfile, which is in the format written
+// to -test.resultlogfile.
+func readPassedFile(file string) (map[string]time.Duration, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	passed := make(map[string]time.Duration)
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := s.Text()
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("%s: malformed line %q", file, line)
+		}
+		secs, err := strconv.ParseFloat(fields[2], 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: malformed line %q", file, line)
+		}
+		if fields[0] == "pass" {
+			passed[fields[1]] = time.Duration(secs * float64(time.Second))
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return passed, nil
+}
+
+// skipPassed removes from m the tests, examples, and fuzz tests
+// that passed according to the -test.passedfile, and returns them
+// in the order they would have run.
+func (m *M) skipPassed() ([]passedTest, error) {
+	durations, err := readPassedFile(*passedFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading -test.passedfile: %w", err)
+	}
+
+	var passed []passedTest
+	keep := func(name string) bool {
+		d, ok := durations[name]
+		if ok {
+			passed = append(passed, passedTest{name, d})
+		}
+		return !ok
+	}
+	m.tests = filterTests(m.tests, func(t InternalTest) bool { return keep(t.Name) })
+	m.fuzzTargets = filterTests(m.fuzzTargets, func(f InternalFuzzTarget) bool { return keep(f.Name) })
+	m.examples = filterTests(m.examples, func(e InternalExample) bool { return keep(e.Name) })
+	return passed, nil
+}
+
+// reportPassed reports the tests that are not being run again
+// because they passed earlier, and carries their results over
+// to the -test.resultlogfile.
+func reportPassed(passed []passedTest) {
+	var printer *chattyPrinter
+	if chatty.on {
+		printer = newChattyPrinter(os.Stdout)
+	}
+	for _, p := range passed {
+		if printer != nil {
+			printer.Updatef(p.name, "--- PASS: %s (cached)\n", p.name)
+		}
+		writeResultLog("pass", p.name, p.duration)
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package testing
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A shardSpec is a parsed -test.shard value.
+// It selects shard index (counting from 1) out of count shards.
+type shardSpec struct {
+	index, count int
+}
+
+func (s shardSpec) String() string {
+	return fmt.Sprintf("%d/%d", s.index, s.count)
+}
+
+// parseShard parses a -test.shard value of the form "i/n",
+// where 1 <= i <= n.
+func parseShard(s string) (shardSpec, error) {
+	is, ns, ok := strings.Cut(s, "/")
+	if !ok {
+		return shardSpec{}, fmt.Errorf("invalid -test.shard %q: want i/n", s)
+	}
+	i, err1 := strconv.Atoi(is)
+	n, err2 := strconv.Atoi(ns)
+	if err1 != nil || err2 != nil || n < 1 || i < 1 || i > n {
+		return shardSpec{}, fmt.Errorf("invalid -test.shard %q: want i/n with 1 <= i <= n", s)
+	}
+	return shardSpec{index: i, count: n}, nil
+}
+
+// readShardTimes reads the -test.shardtimes file.
+// Each line holds a top-level test name and the number of seconds
+// it took to run, separated by a space.
+func readShardTimes(file string) (map[string]float64, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	times := make(map[string]float64)
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := s.Text()
+		if line == "" {
+			continue
+		}
+		name, secs, ok := strings.Cut(line, " ")
+		if !ok {
+			return nil, fmt.Errorf("%s: malformed line %q", file, line)
+		}
+		d, err := strconv.ParseFloat(secs, 64)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("%s: malformed line %q", file, line)
+		}
+		times[name] = d
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return times, nil
+}
+
+// minShardWeight is the smallest weight given to a test when
+// balancing shards, so that a run of very fast tests is still spread
+// across the shards instead of all landing on the first one.
+const minShardWeight = 0.001
+
+// assignShards partitions names across count shards and reports the
+// shard (counting from 0) that each name is assigned to.
+//
+// The assignment depends only on names, times, and count, so that
+// every shard of a test binary computes the same partition.
+// Names are placed, longest-running first, on whichever shard has the
+// least total running time so far. Names without a recorded time are
+// assumed to take the mean of the recorded times; if there are no
+// recorded times at all, every name weighs the same and the names are
+// dealt out round-robin in sorted order.
+func assignShards(names []string, times map[string]float64, count int) map[string]int {
+	var total float64
+	var known int
+	for _, name := range names {
+		if d, ok := times[name]; ok {
+			total += d
+			known++
+		}
+	}
+	mean := 1.0
+	if known > 0 {
+		mean = total / float64(known)
+	}
+
+	type item struct {
+		name   string
+		weight float64
+	}
+	items := make([]item, 0, len(names))
+	for _, name := range names {
+		w, ok := times[name]
+		if !ok {
+			w = mean
+		}
+		items = append(items, item{name, max(w, minShardWeight)})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].weight != items[j].weight {
+			return items[i].weight > items[j].weight
+		}
+		return items[i].name < items[j].name
+	})
+
+	load := make([]float64, count)
+	shard := make(map[string]int, len(items))
+	for _, it := range items {
+		k := 0
+		for j := 1; j < count; j++ {
+			if load[j] < load[k] {
+				k = j
+			}
+		}
+		load[k] += it.weight
+		shard[it.name] = k
+	}
+	return shard
+}
+
+// applyShard restricts m's tests, benchmarks, fuzz targets, and examples
+// to those assigned to the shard selected by -test.shard.
+func (m *M) applyShard() (shardSpec, error) {
+	spec, err := parseShard(*shard)
+	if err != nil {
+		return shardSpec{}, err
+	}
+	var times map[string]float64
+	if *shardTimes != "" {
+		times, err = readShardTimes(*shardTimes)
+		if err != nil {
+			return shardSpec{}, fmt.Errorf("reading -test.shardtimes: %w", err)
+		}
+	}
+
+	var names []string
+	seen := make(map[string]bool)
+	add := func(name string) {
+		// Names are Go identifiers and so are unique in a package,
+		// but a hand-written TestMain could pass anything to MainStart.
+		if seen[name] {
+			err = fmt.Errorf("-test.shard requires unique test names; %s appears twice", name)
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	for _, t := range m.tests {
+		add(t.Name)
+	}
+	for _, b := range m.benchmarks {
+		add(b.Name)
+	}
+	for _, f := range m.fuzzTargets {
+		add(f.Name)
+	}
+	for _, e := range m.examples {
+		add(e.Name)
+	}
+	if err != nil {
+		return shardSpec{}, err
+	}
+
+	assigned := assignShards(names, times, spec.count)
+	mine := func(name string) bool { return assigned[name] == spec.index-1 }
+	m.tests = filterTests(m.tests, func(t InternalTest) bool { return mine(t.Name) })
+	m.benchmarks = filterTests(m.benchmarks, func(b InternalBenchmark) bool { return mine(b.Name) })
+	m.fuzzTargets = filterTests(m.fuzzTargets, func(f InternalFuzzTarget) bool { return mine(f.Name) })
+	m.examples = filterTests(m.examples, func(e InternalExample) bool { return mine(e.Name) })
+	return spec, nil
+}
+
+// filterTests returns the elements of list for which keep returns true.
+// It does not modify list, which may be shared with the caller of MainStart.
+func filterTests[T any](list []T, keep func(T) bool) []T {
+	var out []T
+	for _, x := range list {
+		if keep(x) {
+			out = append(out, x)
+		}
+	}
+	return out
+}
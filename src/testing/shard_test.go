@@ -0,0 +1,100 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package testing
+
+import (
+	"fmt"
+	"reflect"
+)
+
+func TestParseShard(t *T) {
+	for _, tc := range []struct {
+		in   string
+		want shardSpec
+		ok   bool
+	}{
+		{"1/1", shardSpec{1, 1}, true},
+		{"2/4", shardSpec{2, 4}, true},
+		{"4/4", shardSpec{4, 4}, true},
+		{"0/4", shardSpec{}, false},
+		{"5/4", shardSpec{}, false},
+		{"1/0", shardSpec{}, false},
+		{"-1/4", shardSpec{}, false},
+		{"1", shardSpec{}, false},
+		{"a/b", shardSpec{}, false},
+		{"", shardSpec{}, false},
+	} {
+		got, err := parseShard(tc.in)
+		if (err == nil) != tc.ok || got != tc.want {
+			t.Errorf("parseShard(%q) = %v, %v; want %v, ok=%v", tc.in, got, err, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestAssignShardsRoundRobin(t *T) {
+	names := []string{"TestE", "TestA", "TestD", "TestB", "TestC"}
+	got := assignShards(names, nil, 2)
+	want := map[string]int{"TestA": 0, "TestB": 1, "TestC": 0, "TestD": 1, "TestE": 0}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("assignShards without times = %v; want %v", got, want)
+	}
+}
+
+func TestAssignShardsBalanced(t *T) {
+	names := []string{"TestSlow", "TestMedium1", "TestMedium2", "TestFast1", "TestFast2", "TestNew"}
+	times := map[string]float64{
+		"TestSlow":    10,
+		"TestMedium1": 5,
+		"TestMedium2": 5,
+		"TestFast1":   0,
+		"TestFast2":   0,
+	}
+	got := assignShards(names, times, 2)
+
+	// TestSlow alone should weigh as much as both medium tests together.
+	// TestNew is assumed to take the mean of the recorded times, 4s.
+	load := make([]float64, 2)
+	for name, k := range got {
+		w, ok := times[name]
+		if !ok {
+			w = 4
+		}
+		load[k] += w
+	}
+	if got["TestMedium1"] != got["TestMedium2"] || got["TestSlow"] == got["TestMedium1"] {
+		t.Errorf("assignShards = %v; want TestSlow apart from TestMedium1 and TestMedium2", got)
+	}
+	if d := load[0] - load[1]; d > 4 || d < -4 {
+		t.Errorf("assignShards = %v; shard loads %v are unbalanced", got, load)
+	}
+}
+
+func TestAssignShardsComplete(t *T) {
+	var names []string
+	for i := 0; i < 100; i++ {
+		names = append(names, fmt.Sprintf("Test%03d", i))
+	}
+	times := map[string]float64{"Test007": 3, "Test042": 1.5, "Test099": 0.25}
+	for _, n := range []int{1, 3, 7, 100, 150} {
+		got := assignShards(names, times, n)
+		if len(got) != len(names) {
+			t.Fatalf("assignShards(%d) assigned %d names; want %d", n, len(got), len(names))
+		}
+		for name, k := range got {
+			if k < 0 || k >= n {
+				t.Errorf("assignShards(%d) put %s in shard %d", n, name, k)
+			}
+		}
+
+		// The assignment must not depend on the order of names.
+		reversed := make([]string, len(names))
+		for i, name := range names {
+			reversed[len(names)-1-i] = name
+		}
+		if again := assignShards(reversed, times, n); !reflect.DeepEqual(got, again) {
+			t.Errorf("assignShards(%d) depends on the order of names", n)
+		}
+	}
+}
@@ -442,6 +442,10 @@ func Init() {
 	parallel = flag.Int("test.parallel", runtime.GOMAXPROCS(0), "run at most `n` tests in parallel")
 	testlog = flag.String("test.testlogfile", "", "write test action log to `file` (for use only by cmd/go)")
 	shuffle = flag.String("test.shuffle", "off", "randomize the execution order of tests and benchmarks")
+	shard = flag.String("test.shard", "", "run only the tests, examples, benchmarks, and fuzz tests in shard `i/n`")
+	shardTimes = flag.String("test.shardtimes", "", "balance -test.shard using the test durations in `file` (for use only by cmd/go)")
+	resultlog = flag.String("test.resultlogfile", "", "write top-level test results to `file` (for use only by cmd/go)")
+	passedFile = flag.String("test.passedfile", "", "do not rerun tests recorded as passed in `file` (for use only by cmd/go)")
 	fullPath = flag.Bool("test.fullpath", false, "show full file names in error messages")
 
 	initBenchmarkFlags()
@@ -473,13 +477,18 @@ var (
 	cpuListStr           *string
 	parallel             *int
 	shuffle              *string
+	shard                *string
+	shardTimes           *string
 	testlog              *string
+	resultlog            *string
+	passedFile           *string
 	fullPath             *bool
 
 	haveExamples bool // are there examples?
 
-	cpuList     []int
-	testlogFile *os.File
+	cpuList       []int
+	testlogFile   *os.File
+	resultlogFile *os.File
 
 	numFailed atomic.Uint32 // number of test failures
 
@@ -1989,12 +1998,40 @@ func (m *M) Run() (code int) {
 		return
 	}
 
+	var shardDesc string
+	if *shard != "" {
+		spec, err := m.applyShard()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "testing:", err)
+			m.exitCode = 2
+			return
+		}
+		shardDesc = spec.String()
+	}
+
+	var passed []passedTest
+	if *passedFile != "" {
+		var err error
+		passed, err = m.skipPassed()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "testing:", err)
+			m.exitCode = 2
+			return
+		}
+	}
+
 	if *matchList != "" {
 		listTests(m.deps.MatchString, m.tests, m.benchmarks, m.fuzzTargets, m.examples)
 		m.exitCode = 0
 		return
 	}
 
+	if shardDesc != "" {
+		// Report the shard, marked as framing so that test2json can
+		// attribute the events that follow to it.
+		fmt.Print(chatty.prefix(), "-test.shard ", shardDesc, "\n")
+	}
+
 	if *shuffle != "off" {
 		var n int64
 		var err error
@@ -2023,13 +2060,14 @@ func (m *M) Run() (code int) {
 	// Workers start after this is done by their parent process, and they should
 	// not repeat this work.
 	if !*isFuzzWorker {
+		reportPassed(passed)
 		deadline := m.startAlarm()
 		haveExamples = len(m.examples) > 0
 		testRan, testOk := runTests(m.deps.MatchString, m.tests, deadline)
 		fuzzTargetsRan, fuzzTargetsOk := runFuzzTests(m.deps, m.fuzzTargets, deadline)
 		exampleRan, exampleOk := runExamples(m.deps.MatchString, m.examples)
 		m.stopAlarm()
-		if !testRan && !exampleRan && !fuzzTargetsRan && *matchBenchmarks == "" && *matchFuzz == "" {
+		if !testRan && !exampleRan && !fuzzTargetsRan && len(passed) == 0 && *matchBenchmarks == "" && *matchFuzz == "" {
 			fmt.Fprintln(os.Stderr, "testing: warning: no tests to run")
 			if testingTesting && *match != "^$" {
 				// If this happens during testing of package testing it could be that
@@ -2075,6 +2113,9 @@ func (t *T) report() {
 	if t.parent == nil {
 		return
 	}
+	if t.level == 1 {
+		logResult(&t.common)
+	}
 	dstr := fmtDuration(t.duration)
 	format := "--- %s: %s (%s)\n"
 	if t.Failed() {
@@ -2088,6 +2129,31 @@ func (t *T) report() {
 	}
 }
 
+// resultlogMu serializes writes to resultlogFile by parallel tests.
+var resultlogMu sync.Mutex
+
+// logResult records the outcome and duration of the top-level test c
+// in the -test.resultlogfile, if any.
+func logResult(c *common) {
+	action := "pass"
+	if c.Failed() {
+		action = "fail"
+	} else if c.Skipped() {
+		action = "skip"
+	}
+	writeResultLog(action, c.name, c.duration)
+}
+
+// writeResultLog appends a line to the -test.resultlogfile, if any.
+func writeResultLog(action, name string, d time.Duration) {
+	if resultlogFile == nil {
+		return
+	}
+	resultlogMu.Lock()
+	defer resultlogMu.Unlock()
+	fmt.Fprintf(resultlogFile, "%s %s %.6f\n", action, name, d.Seconds())
+}
+
 func listTests(matchString func(pat, str string) (bool, error), tests []InternalTest, benchmarks []InternalBenchmark, fuzzTargets []InternalFuzzTarget, examples []InternalExample) {
 	if _, err := matchString(*matchList, "non-empty"); err != nil {
 		fmt.Fprintf(os.Stderr, "testing: invalid regexp in -test.list (%q): %s\n", *matchList, err)
@@ -2239,6 +2305,21 @@ func (m *M) before() {
 		m.deps.StartTestLog(f)
 		testlogFile = f
 	}
+	if *resultlog != "" {
+		// Like the test log, this file is for use by cmd/go.
+		var f *os.File
+		var err error
+		if m.numRun == 1 {
+			f, err = os.Create(*resultlog)
+		} else {
+			f, err = os.OpenFile(*resultlog, os.O_WRONLY|os.O_APPEND, 0)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "testing: %s\n", err)
+			os.Exit(2)
+		}
+		resultlogFile = f
+	}
 	if *panicOnExit0 {
 		m.deps.SetPanicOnExit0(true)
 	}
@@ -2269,6 +2350,12 @@ func (m *M) writeProfiles() {
 			os.Exit(2)
 		}
 	}
+	if *resultlog != "" {
+		if err := resultlogFile.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "testing: can't write %s: %s\n", *resultlog, err)
+			os.Exit(2)
+		}
+	}
 	if *cpuProfile != "" {
 		m.deps.StopCPUProfile() // flushes profile to disk
 	}
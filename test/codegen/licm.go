@@ -0,0 +1,47 @@
+// asmcheck
+
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package codegen
+
+// This file contains codegen tests related to hoisting
+// loop-invariant values out of loops.
+
+type licmT struct {
+	a, b int
+}
+
+func licmLoad(p *licmT) int {
+	s := p.a
+	// The load of p.b is done once, before the loop,
+	// instead of being folded into the comparison.
+	// amd64:"CMPQ\t[A-Z]+, [A-Z]+",-"CMPQ\t8\\("
+	for i := 0; i < p.b; i++ {
+		s += i
+	}
+	return s
+}
+
+func licmArith(a []int, x, y int) int {
+	s := 0
+	// The loop counts down so that it isn't unrolled,
+	// which would compute x*y + 3 before each copy of the loop.
+	for i := len(a) - 1; i >= 0; i-- {
+		// amd64:"LEAQ\t3\\(",-"ADDQ\t\\$3"
+		s += a[i] * (x*y + 3)
+	}
+	return s
+}
+
+func licmConvert(a []float64, k int) float64 {
+	s := 0.0
+	for _, v := range a {
+		// The conversion is done once, so the loaded
+		// element can't be folded into the multiplication.
+		// amd64:"MULSD\tX[0-9]+, X[0-9]+",-"MULSD\t\\("
+		s += v * float64(k)
+	}
+	return s
+}
@@ -0,0 +1,73 @@
+// asmcheck
+
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package codegen
+
+import "simd"
+
+// This file contains codegen tests related to the vector operations
+// in package simd.
+
+func simdAddInt32x8(x, y simd.Int32x8) simd.Int32x8 {
+	// amd64/v1:"PADDL\tX[0-9], X[0-9]",-"VZEROUPPER"
+	// amd64/v3:"VPADDD\tY[0-9], Y[0-9], Y[0-9]","VZEROUPPER",-"VPADDD\t[^Y]"
+	// arm64:"VADD\tV[0-9].S4, V[0-9].S4, V[0-9].S4"
+	return x.Add(y)
+}
+
+func simdEqualUint8x32(x, y simd.Uint8x32) uint32 {
+	// amd64/v1:"PCMPEQB","PMOVMSKB"
+	// amd64/v3:"VPCMPEQB\tY[0-9], Y[0-9], Y[0-9]","VPMOVMSKB\tY[0-9]"
+	// arm64:"VCMEQ\tV[0-9].B16, V[0-9].B16, V[0-9].B16"
+	return x.Equal(y).SignBits()
+}
+
+func simdAndNotInt64x4(x, y simd.Int64x4) simd.Int64x4 {
+	// amd64/v1:"PANDN\tX[0-9], X[0-9]"
+	// amd64/v3:"VPANDN\tY[0-9], Y[0-9], Y[0-9]"
+	// arm64:"VBIC\tV[0-9].B16, V[0-9].B16, V[0-9].B16"
+	return x.AndNot(y)
+}
+
+func simdMinInt64x4(x, y simd.Int64x4) simd.Int64x4 {
+	// amd64/v1:"VPMINSQ\tX[0-9], X[0-9], X[0-9]"
+	// amd64/v4:"VPMINSQ\tY[0-9], Y[0-9], Y[0-9]"
+	// arm64:"VCMGT","VBSL"
+	return x.Min(y)
+}
+
+func simdSqrtFloat64x4(x simd.Float64x4) simd.Float64x4 {
+	// amd64/v1:"SQRTPD\tX[0-9], X[0-9]"
+	// amd64/v3:"VSQRTPD\tY[0-9], Y[0-9]"
+	// arm64:"VFSQRT\tV[0-9].D2, V[0-9].D2"
+	return x.Sqrt()
+}
+
+// A chain of operations keeps the vectors in registers, with a single
+// VZEROUPPER before returning.
+func simdChainInt32x8(x, y simd.Int32x8) simd.Int32x8 {
+	// amd64/v3:"VPADDD\tY","VPMULLD\tY","VPMAXSD\tY",-"autotmp"
+	return x.Add(y).Mul(y).Max(x)
+}
+
+var simdGlobal simd.Int32x8
+
+// Vectors in global variables are loaded and stored through a register
+// holding their address, as FMOVQ has no sym(SB) form on arm64.
+func simdLoadGlobal(x simd.Int32x8) simd.Int32x8 {
+	// arm64:"MOVD\t[$].*simdGlobal[(]SB[)]","FMOVQ\t[(]R[0-9]+[)]"
+	return simdGlobal.Add(x)
+}
+
+func simdStoreGlobal(x simd.Int32x8) {
+	// arm64:"FMOVQ\tF[0-9]+, [(]R[0-9]+[)]"
+	simdGlobal = x.Add(x)
+}
+
+func simdAddLiteral(x simd.Int32x8) simd.Int32x8 {
+	// arm64:"MOVD\t[$].*stmp_[0-9]+[(]SB[)]","FMOVQ\t[(]R[0-9]+[)]"
+	return x.Add(simd.Int32x8{1, 2, 3, 4, 5, 6, 7, 8})
+}
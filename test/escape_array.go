@@ -123,7 +123,8 @@ func doesMakeSlice(x *string, y *string) { // ERROR "leaking param: x" "leaking
 
 func nonconstArray() {
 	n := 32
-	s1 := make([]int, n)    // ERROR "make\(\[\]int, n\) escapes to heap"
-	s2 := make([]int, 0, n) // ERROR "make\(\[\]int, 0, n\) escapes to heap"
-	_, _ = s1, s2
+	s1 := make([]int, n)      // ERROR "make\(\[\]int, n\) does not escape, stack allocated if capacity <= 4$"
+	s2 := make([]int, 0, n)   // ERROR "make\(\[\]int, 0, n\) does not escape, stack allocated if capacity <= 4$"
+	s3 := make([][64]byte, n) // ERROR "make\(\[\]\[64\]byte, n\) escapes to heap"
+	_, _, _ = s1, s2, s3
 }
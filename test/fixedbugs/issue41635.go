@@ -12,6 +12,10 @@ func f() { // ERROR ""
 	_ = make([]byte, 100, 1<<17) // ERROR "too large for stack" ""
 	_ = make([]byte, n, 1<<17)   // ERROR "too large for stack" ""
 
-	_ = make([]byte, n)      // ERROR "non-constant size" ""
+	_ = make([]byte, n)      // ERROR "stack allocated if capacity <= 32"
+	_ = make([]byte, 10, m)  // ERROR "stack allocated if capacity <= 32"
 	_ = make([]byte, 100, m) // ERROR "non-constant size" ""
+
+	_ = make([][64]byte, n)      // ERROR "non-constant size" ""
+	_ = make([][64]byte, 100, m) // ERROR "non-constant size" ""
 }
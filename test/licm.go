@@ -0,0 +1,92 @@
+// errorcheck -0 -d=ssa/licm/debug=1
+
+//go:build amd64 || arm64
+
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Test that loop-invariant values are hoisted out of loops,
+// and that values that are not safe to hoist stay put.
+
+package main
+
+type T struct {
+	a, b int
+}
+
+func invariantArith(a []int, x, y int) int {
+	s := 0
+	for _, v := range a {
+		s += v * (x*y + 3) // ERROR "hoisted Mul64 out of loop$" "hoisted Add64 out of loop$"
+	}
+	return s
+}
+
+func nestedLoops(a [][]int, x, y int) int {
+	s := 0
+	for _, r := range a {
+		for _, v := range r {
+			s += v ^ (x * y) // ERROR "hoisted Mul64 out of loop$"
+		}
+	}
+	return s
+}
+
+func invariantLoad(p *T, n int) int {
+	s := p.a // p is nil checked here, before the loop.
+	for i := 0; i < n; i++ {
+		s += p.b // ERROR "hoisted Load out of loop$" "hoisted OffPtr out of loop$"
+	}
+	return s
+}
+
+func loadNotNilChecked(p *T, n int) int {
+	s := 0
+	for i := 0; i < n; i++ {
+		// The nil check must happen only if the loop runs.
+		s += p.b
+	}
+	return s
+}
+
+func loadWithStore(p *T, a []int) {
+	_ = p.a
+	for i := range a {
+		// The store to a[i] might change p.b,
+		// so only its address is hoisted.
+		a[i] = p.b // ERROR "hoisted OffPtr out of loop$"
+	}
+}
+
+func loadWithCall(p *T, n int) {
+	_ = p.a
+	for i := 0; i < n; i++ {
+		sink = p.b // ERROR "hoisted OffPtr out of loop$"
+		g()
+	}
+}
+
+func indexNotBoundsChecked(a []int, i, n int) int {
+	s := 0
+	for j := 0; j < n; j++ {
+		// The address of a[i] may be out of bounds until the bounds
+		// check in the loop, so only the offset of a[i] is hoisted.
+		s += a[i] // ERROR "hoisted Lsh64x64 out of loop$"
+	}
+	return s
+}
+
+func division(a []int, x, y int) int {
+	s := 0
+	for _, v := range a {
+		// The division must not be done if the loop doesn't run.
+		s += v + x/y
+	}
+	return s
+}
+
+var sink int
+
+//go:noinline
+func g() {}
@@ -0,0 +1,71 @@
+// errorcheck -0 -d=ssa/unroll/debug=1
+
+//go:build amd64 || arm64
+
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Test that small counted loops are unrolled.
+
+package p
+
+func sum(s []int) int {
+	x := 0
+	for i := 0; i < len(s); i++ { // ERROR "Induction variable: limits \[0,\?\), increment 1$" "unrolled loop by 4$"
+		x += s[i]
+	}
+	return x
+}
+
+func fill(s []byte, b byte) {
+	for i := range s { // ERROR "Induction variable: limits \[0,\?\), increment 1$" "unrolled loop by 4$"
+		s[i] = b
+	}
+}
+
+func constLimit(a *[100]int) (x int) {
+	for i := 0; i < 100; i += 2 { // ERROR "Induction variable: limits \[0,98\], increment 2$" "unrolled loop by 4$"
+		x += a[i]
+	}
+	return
+}
+
+func shortLoop(a *[3]int) (x int) {
+	for i := 0; i < 3; i++ { // ERROR "Induction variable: limits \[0,3\), increment 1$"
+		x += a[i] // too few iterations to unroll
+	}
+	return
+}
+
+func bigBody(s []int) (x int) {
+	for i := 0; i < len(s); i++ { // ERROR "Induction variable: limits \[0,\?\), increment 1$" "unrolled loop by 2$"
+		v := s[i]
+		x += v*v + v>>3 + v<<5 + x>>2
+	}
+	return
+}
+
+func call(s []int) {
+	for i := 0; i < len(s); i++ { // ERROR "Induction variable: limits \[0,\?\), increment 1$"
+		g(s[i]) // calls aren't unrolled
+	}
+}
+
+func g(int)
+
+func countDown(s []int) (x int) {
+	for i := len(s) - 1; i >= 0; i-- { // ERROR "Induction variable: limits \[0,\?\], increment 1$"
+		x += s[i]
+	}
+	return
+}
+
+func conditional(s []int) (x int) {
+	for i := 0; i < len(s); i++ { // ERROR "Induction variable: limits \[0,\?\), increment 1$"
+		if s[i] > 0 { // more than one block
+			x += s[i]
+		}
+	}
+	return
+}
@@ -0,0 +1,44 @@
+// run
+
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Test that loops whose limit changes inside the loop are not
+// unrolled with a limit computed before the loop.
+
+package main
+
+//go:noinline
+func f(n int) int {
+	s := 0
+	for i := 0; i < n; i++ {
+		s += i
+		n -= 1
+	}
+	return s
+}
+
+//go:noinline
+func g(n int) int {
+	s := 0
+	for i := 0; i < n; i++ {
+		s += id(i)
+		n -= 1
+	}
+	return s
+}
+
+//go:noinline
+func id(i int) int {
+	return i
+}
+
+func main() {
+	for n := -3; n < 100; n++ {
+		if got, want := f(n), g(n); got != want {
+			println("f(", n, ") =", got, ", want", want)
+			panic("bad")
+		}
+	}
+}